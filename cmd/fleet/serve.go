@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -20,6 +21,7 @@ import (
 	"github.com/go-kit/kit/log/level"
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
 	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/datastore/metrics"
 	"github.com/kolide/fleet/server/datastore/mysql"
 	"github.com/kolide/fleet/server/health"
 	"github.com/kolide/fleet/server/kolide"
@@ -44,6 +46,12 @@ type initializer interface {
 	Initialize() error
 }
 
+type connectionCounter interface {
+	// OpenConnections returns the number of open database
+	// connections
+	OpenConnections() int
+}
+
 func createServeCmd(configManager config.Manager) *cobra.Command {
 	// Whether to enable the debug endpoints
 	debug := false
@@ -111,6 +119,28 @@ the way that the Fleet server works.
 				}
 			}
 
+			if config.Osquery.EnrollClientCert && (!config.Server.TLS || config.Server.TLSClientCA == "") {
+				initFatal(
+					errors.New("server.tls must be enabled and server.tls_client_ca set"),
+					"enabling osquery.enroll_client_cert",
+				)
+			}
+			if config.Osquery.EnrollClientCert && config.Osquery.EnrollClientCertNames == "" {
+				initFatal(
+					errors.New("osquery.enroll_client_cert_names must be set"),
+					"enabling osquery.enroll_client_cert",
+				)
+			}
+
+			var tlsConfig *tls.Config
+			if config.Server.TLS {
+				var err error
+				tlsConfig, err = serverTLSConfig(config.Server)
+				if err != nil {
+					initFatal(err, "configuring TLS")
+				}
+			}
+
 			var ds kolide.Datastore
 			var err error
 			mailService := mail.NewService()
@@ -170,6 +200,32 @@ the way that the Fleet server works.
 				}
 			}
 
+			if config.Mysql.EnableMetrics {
+				dsFieldKeys := []string{"method", "error"}
+				dsCallCount := kitprometheus.NewCounterFrom(prometheus.CounterOpts{
+					Namespace: "datastore",
+					Subsystem: "mysql",
+					Name:      "call_count",
+					Help:      "Number of datastore calls.",
+				}, dsFieldKeys)
+				dsCallLatency := kitprometheus.NewHistogramFrom(prometheus.HistogramOpts{
+					Namespace: "datastore",
+					Subsystem: "mysql",
+					Name:      "call_latency_seconds",
+					Help:      "Duration of datastore calls in seconds.",
+					Buckets:   prometheus.DefBuckets,
+				}, dsFieldKeys)
+				if counter, ok := ds.(connectionCounter); ok {
+					prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+						Namespace: "datastore",
+						Subsystem: "mysql",
+						Name:      "open_connections",
+						Help:      "Number of open connections to the primary database.",
+					}, func() float64 { return float64(counter.OpenConnections()) }))
+				}
+				ds = metrics.NewDatastore(ds, dsCallCount, dsCallLatency)
+			}
+
 			var resultStore kolide.QueryResultStore
 			redisPool := pubsub.NewRedisPool(config.Redis.Address, config.Redis.Password)
 			resultStore = pubsub.NewRedisQueryResults(redisPool)
@@ -185,10 +241,24 @@ the way that the Fleet server works.
 				for {
 					ds.CleanupDistributedQueryCampaigns(time.Now())
 					ds.CleanupIncomingHosts(time.Now())
+					ds.ClearElapsedHostMaintenance(time.Now())
+					purged, err := ds.PurgeDeletedQueries(time.Now().Add(-config.App.DeletedQueryRetention))
+					if err != nil {
+						level.Info(logger).Log("err", err, "msg", "failed to purge deleted queries")
+					} else if purged > 0 {
+						level.Info(logger).Log("msg", "purged deleted queries", "count", purged)
+					}
+					ds.CleanupIngestionFailures(time.Now().Add(-config.App.IngestionFailureRetention))
 					<-ticker.C
 				}
 			}()
 
+			reapCtx, cancelReap := context.WithCancel(context.Background())
+			go svc.ReapCampaigns(reapCtx)
+			go svc.ReapSessions(reapCtx)
+			go svc.SyncExternalLabels(reapCtx)
+			go svc.RecalculateLabels(reapCtx)
+
 			fieldKeys := []string{"method", "error"}
 			requestCount := kitprometheus.NewCounterFrom(prometheus.CounterOpts{
 				Namespace: "api",
@@ -251,7 +321,7 @@ the way that the Fleet server works.
 			launcher := launcher.New(svc, logger, grpc.NewServer(), healthCheckers)
 
 			rootMux := http.NewServeMux()
-			rootMux.Handle("/healthz", prometheus.InstrumentHandler("healthz", health.Handler(httpLogger, healthCheckers)))
+			rootMux.Handle("/healthz", prometheus.InstrumentHandler("healthz", service.MakeHealthzHandler(svc, httpLogger)))
 			rootMux.Handle("/version", prometheus.InstrumentHandler("version", version.Handler()))
 			rootMux.Handle("/assets/", prometheus.InstrumentHandler("static_assets", service.ServeStaticAssets("/assets/")))
 			rootMux.Handle("/metrics", prometheus.InstrumentHandler("metrics", promhttp.Handler()))
@@ -309,7 +379,17 @@ the way that the Fleet server works.
 					errs <- srv.ListenAndServe()
 				} else {
 					logger.Log("transport", "https", "address", config.Server.Address, "msg", "listening")
-					srv.TLSConfig = getTLSConfig(config.Server.TLSProfile)
+					srv.TLSConfig = tlsConfig
+					if config.Server.TLSClientCA != "" {
+						pool, err := loadClientCAs(config.Server.TLSClientCA)
+						if err != nil {
+							initFatal(err, "loading TLS client CA")
+						}
+						// Client certificates are optional, so that hosts
+						// without one can still enroll with a secret.
+						srv.TLSConfig.ClientCAs = pool
+						srv.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+					}
 					errs <- srv.ListenAndServeTLS(
 						config.Server.Cert,
 						config.Server.Key,
@@ -323,7 +403,13 @@ the way that the Fleet server works.
 				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 				defer cancel()
 				errs <- func() error {
+					cancelReap()
 					launcher.GracefulStop()
+					// Live query streams are hijacked connections that
+					// srv.Shutdown does not wait for, so drain them first.
+					if err := svc.DrainCampaigns(ctx); err != nil {
+						logger.Log("msg", "draining campaigns", "err", err)
+					}
 					return srv.Shutdown(ctx)
 				}()
 			}()
@@ -337,6 +423,112 @@ the way that the Fleet server works.
 	return serveCmd
 }
 
+// loadClientCAs reads the PEM encoded CA certificates used to verify TLS
+// client certificates.
+func loadClientCAs(path string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading client CA file")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// tlsVersions maps the values accepted by server.tls_min_version to TLS
+// versions.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsCurves maps the values accepted by server.tls_curve_preferences to
+// curves. The names may also be given with a Curve prefix, as in
+// CurveP256.
+var tlsCurves = map[string]tls.CurveID{
+	"X25519": tls.X25519,
+	"P256":   tls.CurveP256,
+	"P384":   tls.CurveP384,
+	"P521":   tls.CurveP521,
+}
+
+// serverTLSConfig returns the TLS configuration for the server profile, with
+// the minimum version, cipher suites and curves replaced by those set in the
+// server config. Unknown names are returned as errors so that a mistyped
+// setting fails at startup rather than silently weakening the config.
+func serverTLSConfig(conf config.ServerConfig) (*tls.Config, error) {
+	cfg := getTLSConfig(conf.TLSProfile)
+
+	if conf.TLSMinVersion != "" {
+		version, ok := tlsVersions[strings.TrimSpace(conf.TLSMinVersion)]
+		if !ok {
+			return nil, errors.Errorf("unknown TLS version %q in server.tls_min_version", conf.TLSMinVersion)
+		}
+		cfg.MinVersion = version
+	}
+
+	if names := splitTLSList(conf.TLSCipherSuites); len(names) > 0 {
+		suites := make(map[string]*tls.CipherSuite)
+		for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+			suites[suite.Name] = suite
+			// Also accept the names of the crypto/tls constants for the
+			// ChaCha20-Poly1305 suites, which omit the hash
+			if strings.HasSuffix(suite.Name, "_POLY1305_SHA256") {
+				suites[strings.TrimSuffix(suite.Name, "_SHA256")] = suite
+			}
+		}
+		cfg.CipherSuites = nil
+		for _, name := range names {
+			suite, ok := suites[name]
+			if !ok {
+				return nil, errors.Errorf("unknown cipher suite %q in server.tls_cipher_suites", name)
+			}
+			if isTLS13Only(suite) {
+				// crypto/tls always enables every TLS 1.3 cipher suite
+				return nil, errors.Errorf("cipher suite %q in server.tls_cipher_suites is a TLS 1.3 suite, which cannot be configured", name)
+			}
+			cfg.CipherSuites = append(cfg.CipherSuites, suite.ID)
+		}
+	}
+
+	if names := splitTLSList(conf.TLSCurvePreferences); len(names) > 0 {
+		cfg.CurvePreferences = nil
+		for _, name := range names {
+			curve, ok := tlsCurves[strings.TrimPrefix(name, "Curve")]
+			if !ok {
+				return nil, errors.Errorf("unknown curve %q in server.tls_curve_preferences", name)
+			}
+			cfg.CurvePreferences = append(cfg.CurvePreferences, curve)
+		}
+	}
+
+	return cfg, nil
+}
+
+func isTLS13Only(suite *tls.CipherSuite) bool {
+	for _, version := range suite.SupportedVersions {
+		if version != tls.VersionTLS13 {
+			return false
+		}
+	}
+	return true
+}
+
+// splitTLSList splits a comma-separated TLS setting, ignoring empty items.
+func splitTLSList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // Support for TLS security profiles, we set up the TLS configuation based on
 // value supplied to server_tls_compatibility command line flag. The default
 // profile is 'modern'.
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/kolide/fleet/server/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerTLSConfig(t *testing.T) {
+	// The profile settings are used when nothing is overridden
+	cfg, err := serverTLSConfig(config.ServerConfig{TLSProfile: config.TLSProfileModern})
+	require.Nil(t, err)
+	assert.Equal(t, getTLSConfig(config.TLSProfileModern), cfg)
+
+	cfg, err = serverTLSConfig(config.ServerConfig{
+		TLSProfile:          config.TLSProfileModern,
+		TLSMinVersion:       "1.3",
+		TLSCipherSuites:     "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384, TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,",
+		TLSCurvePreferences: "X25519,CurveP256",
+	})
+	require.Nil(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), cfg.MinVersion)
+	assert.Equal(t, []uint16{
+		tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+	}, cfg.CipherSuites)
+	assert.Equal(t, []tls.CurveID{tls.X25519, tls.CurveP256}, cfg.CurvePreferences)
+
+	var invalid = []struct {
+		conf config.ServerConfig
+		err  string
+	}{
+		{config.ServerConfig{TLSMinVersion: "1.4"}, "unknown TLS version"},
+		{config.ServerConfig{TLSCipherSuites: "TLS_ECDHE_RSA_WITH_AES_256_GCM"}, "unknown cipher suite"},
+		{config.ServerConfig{TLSCipherSuites: "TLS_AES_128_GCM_SHA256"}, "TLS 1.3 suite"},
+		{config.ServerConfig{TLSCurvePreferences: "P224"}, "unknown curve"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.err, func(t *testing.T) {
+			tt.conf.TLSProfile = config.TLSProfileModern
+			_, err := serverTLSConfig(tt.conf)
+			require.NotNil(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
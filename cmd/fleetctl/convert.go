@@ -6,7 +6,6 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"regexp"
-	"strconv"
 	"strings"
 
 	"github.com/ghodss/yaml"
@@ -33,18 +32,9 @@ func specGroupFromPack(name string, inputPack kolide.PermissivePackContent) (*sp
 			Query:       query.Query,
 		}
 
-		interval := uint(0)
-		switch i := query.Interval.(type) {
-		case string:
-			u64, err := strconv.ParseUint(i, 10, 32)
-			if err != nil {
-				return nil, errors.Wrap(err, "converting interval from string to uint")
-			}
-			interval = uint(u64)
-		case uint:
-			interval = i
-		case float64:
-			interval = uint(i)
+		interval, err := query.IntervalSeconds()
+		if err != nil {
+			return nil, err
 		}
 
 		specs.Queries = append(specs.Queries, spec)
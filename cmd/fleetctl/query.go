@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/briandowns/spinner"
+	"github.com/kolide/fleet/server/service"
 	"github.com/urfave/cli"
 )
 
@@ -20,6 +21,7 @@ type resultOutput struct {
 func queryCommand() cli.Command {
 	var (
 		flHosts, flLabels, flQuery, flQueryName string
+		flTransport                             string
 		flDebug, flQuiet, flExit                bool
 		flTimeout                               time.Duration
 	)
@@ -82,6 +84,13 @@ func queryCommand() cli.Command {
 				Destination: &flTimeout,
 				Usage:       "How long to run query before exiting (10s, 1h, etc.)",
 			},
+			cli.StringFlag{
+				Name:        "transport",
+				EnvVar:      "TRANSPORT",
+				Value:       service.LiveQueryTransportWebsocket,
+				Destination: &flTransport,
+				Usage:       "Transport for streaming results (websocket or sse)",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			fleet, err := clientFromCLI(c)
@@ -109,6 +118,10 @@ func queryCommand() cli.Command {
 				return fmt.Errorf("Query must be specified with --query or --query-name")
 			}
 
+			if err := fleet.SetLiveQueryTransport(flTransport); err != nil {
+				return err
+			}
+
 			hosts := strings.Split(flHosts, ",")
 			labels := strings.Split(flLabels, ",")
 
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/WatchBeam/clock"
+	"github.com/kolide/fleet/server/kolide"
+)
+
+// InmemIdempotencyCache is a kolide.IdempotencyCache which stores IDs in
+// memory. It is suitable for a single Fleet server.
+type InmemIdempotencyCache struct {
+	mtx     sync.Mutex
+	clock   clock.Clock
+	entries map[string]idempotencyEntry
+}
+
+type idempotencyEntry struct {
+	kolide.IdempotencyEntry
+	expires time.Time
+}
+
+var _ kolide.IdempotencyCache = (*InmemIdempotencyCache)(nil)
+
+// NewInmemIdempotencyCache creates an empty InmemIdempotencyCache.
+func NewInmemIdempotencyCache(c clock.Clock) *InmemIdempotencyCache {
+	return &InmemIdempotencyCache{
+		clock:   c,
+		entries: make(map[string]idempotencyEntry),
+	}
+}
+
+// Reserve implements kolide.IdempotencyCache.
+func (c *InmemIdempotencyCache) Reserve(key, hash string, ttl time.Duration) (kolide.IdempotencyEntry, bool, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := c.clock.Now()
+	// Drop expired entries so that keys that are never retried do not
+	// accumulate.
+	for k, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+
+	if entry, ok := c.entries[key]; ok {
+		return entry.IdempotencyEntry, false, nil
+	}
+	c.entries[key] = idempotencyEntry{
+		IdempotencyEntry: kolide.IdempotencyEntry{Hash: hash, Pending: true},
+		expires:          now.Add(ttl),
+	}
+	return kolide.IdempotencyEntry{}, true, nil
+}
+
+// Set implements kolide.IdempotencyCache.
+func (c *InmemIdempotencyCache) Set(key string, id uint, ttl time.Duration) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	entry := c.entries[key]
+	entry.ID = id
+	entry.Pending = false
+	entry.expires = c.clock.Now().Add(ttl)
+	c.entries[key] = entry
+	return nil
+}
+
+// Release implements kolide.IdempotencyCache.
+func (c *InmemIdempotencyCache) Release(key string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	delete(c.entries, key)
+	return nil
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/WatchBeam/clock"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInmemIdempotencyCache(t *testing.T) {
+	c := clock.NewMockClock()
+	cache := NewInmemIdempotencyCache(c)
+
+	_, reserved, err := cache.Reserve("foo", "hash", time.Minute)
+	require.Nil(t, err)
+	assert.True(t, reserved)
+
+	// The key is claimed until the request completes
+	entry, reserved, err := cache.Reserve("foo", "other", time.Minute)
+	require.Nil(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, kolide.IdempotencyEntry{Hash: "hash", Pending: true}, entry)
+
+	require.Nil(t, cache.Set("foo", 3, time.Minute))
+	entry, reserved, err = cache.Reserve("foo", "hash", time.Minute)
+	require.Nil(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, kolide.IdempotencyEntry{Hash: "hash", ID: 3}, entry)
+
+	c.AddTime(time.Minute)
+	_, reserved, err = cache.Reserve("foo", "hash", time.Minute)
+	require.Nil(t, err)
+	assert.True(t, reserved)
+
+	// Released keys can be claimed again
+	require.Nil(t, cache.Release("foo"))
+	_, reserved, err = cache.Reserve("foo", "hash", time.Minute)
+	require.Nil(t, err)
+	assert.True(t, reserved)
+
+	require.Nil(t, cache.Set("foo", 4, time.Second))
+	c.AddTime(time.Second)
+	_, _, err = cache.Reserve("bar", "hash", time.Minute)
+	require.Nil(t, err)
+	assert.Len(t, cache.entries, 1)
+}
@@ -0,0 +1,67 @@
+// Package cache provides implementations of kolide.ResultsCache.
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/WatchBeam/clock"
+	"github.com/kolide/fleet/server/kolide"
+)
+
+// InmemResultsCache is a kolide.ResultsCache which stores results in memory.
+// It is suitable for a single Fleet server.
+type InmemResultsCache struct {
+	mtx     sync.Mutex
+	clock   clock.Clock
+	entries map[string]resultsEntry
+}
+
+type resultsEntry struct {
+	results []kolide.DistributedQueryResult
+	expires time.Time
+}
+
+var _ kolide.ResultsCache = (*InmemResultsCache)(nil)
+
+// NewInmemResultsCache creates an empty InmemResultsCache.
+func NewInmemResultsCache(c clock.Clock) *InmemResultsCache {
+	return &InmemResultsCache{
+		clock:   c,
+		entries: make(map[string]resultsEntry),
+	}
+}
+
+// Get implements kolide.ResultsCache.
+func (c *InmemResultsCache) Get(key string) ([]kolide.DistributedQueryResult, bool, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !c.clock.Now().Before(entry.expires) {
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	return entry.results, true, nil
+}
+
+// Set implements kolide.ResultsCache.
+func (c *InmemResultsCache) Set(key string, results []kolide.DistributedQueryResult, ttl time.Duration) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := c.clock.Now()
+	// Drop expired entries so that results for queries that are no longer
+	// run do not accumulate.
+	for k, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[key] = resultsEntry{results: results, expires: now.Add(ttl)}
+	return nil
+}
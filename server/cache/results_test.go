@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/WatchBeam/clock"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInmemResultsCache(t *testing.T) {
+	c := clock.NewMockClock()
+	cache := NewInmemResultsCache(c)
+
+	_, ok, err := cache.Get("foo")
+	require.Nil(t, err)
+	assert.False(t, ok)
+
+	results := []kolide.DistributedQueryResult{
+		{DistributedQueryCampaignID: 1, Rows: []map[string]string{{"count": "3"}}},
+	}
+	require.Nil(t, cache.Set("foo", results, time.Minute))
+
+	got, ok, err := cache.Get("foo")
+	require.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, results, got)
+
+	_, ok, err = cache.Get("bar")
+	require.Nil(t, err)
+	assert.False(t, ok)
+
+	c.AddTime(time.Minute)
+	_, ok, err = cache.Get("foo")
+	require.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestInmemResultsCacheSetDropsExpired(t *testing.T) {
+	c := clock.NewMockClock()
+	cache := NewInmemResultsCache(c)
+
+	require.Nil(t, cache.Set("foo", nil, time.Second))
+	c.AddTime(time.Second)
+	require.Nil(t, cache.Set("bar", nil, time.Minute))
+	assert.Len(t, cache.entries, 1)
+}
@@ -29,6 +29,15 @@ type MysqlConfig struct {
 	TLSConfig     string `yaml:"tls_config"` //tls=customValue in DSN
 	MaxOpenConns  int    `yaml:"max_open_conns"`
 	MaxIdleConns  int    `yaml:"max_idle_conns"`
+	EnableMetrics bool   `yaml:"enable_metrics"`
+	// ReplicaAddresses is a comma-separated list of read replica addresses
+	// (host:port). Replicas use the same protocol, credentials, database
+	// and TLS settings as the primary.
+	ReplicaAddresses string `yaml:"replica_addresses"`
+
+	// ConnMaxLifetime is the maximum amount of time a connection may be
+	// reused before it is closed and replaced.
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
 }
 
 // RedisConfig defines configs related to Redis
@@ -44,27 +53,85 @@ const (
 	TLSProfileOld          = "old"
 )
 
+const (
+	HostIdentifierKey      = "osquery.host_identifier"
+	HostIdentifierProvided = "provided"
+	HostIdentifierUUID     = "uuid"
+	HostIdentifierHostname = "hostname"
+	HostIdentifierInstance = "instance"
+)
+
+const (
+	LogRateLimitActionKey    = "osquery.log_rate_limit_action"
+	LogRateLimitActionDrop   = "drop"
+	LogRateLimitActionReject = "reject"
+)
+
 // ServerConfig defines configs related to the Fleet server
 type ServerConfig struct {
-	Address    string
-	Cert       string
-	Key        string
-	TLS        bool
-	TLSProfile string
-	URLPrefix  string `yaml:"url_prefix"`
+	Address     string
+	Cert        string
+	Key         string
+	TLS         bool
+	TLSProfile  string
+	TLSClientCA string `yaml:"tls_client_ca"`
+	// TLSMinVersion, TLSCipherSuites and TLSCurvePreferences override the
+	// settings of the TLS profile when set. The cipher suites and curves
+	// are comma-separated lists of the names used by crypto/tls.
+	TLSMinVersion       string `yaml:"tls_min_version"`
+	TLSCipherSuites     string `yaml:"tls_cipher_suites"`
+	TLSCurvePreferences string `yaml:"tls_curve_preferences"`
+	URLPrefix           string `yaml:"url_prefix"`
+	// CORSAllowedOrigins is a comma-separated list of the origins allowed
+	// to make cross-origin API requests, or * for any origin. When empty,
+	// only same-origin requests are allowed.
+	CORSAllowedOrigins string `yaml:"cors_allowed_origins"`
+	// CORSAllowedMethods and CORSAllowedHeaders are comma-separated lists
+	// of the methods and headers allowed in cross-origin API requests.
+	CORSAllowedMethods string `yaml:"cors_allowed_methods"`
+	CORSAllowedHeaders string `yaml:"cors_allowed_headers"`
+
+	// TrustedProxies is a comma-separated list of the IP addresses and CIDR
+	// ranges of the proxies whose X-Forwarded-For headers are used to find
+	// the address of clients.
+	TrustedProxies string `yaml:"trusted_proxies"`
 }
 
 // AuthConfig defines configs related to user authorization
 type AuthConfig struct {
-	JwtKey      string `yaml:"jwt_key"`
-	BcryptCost  int    `yaml:"bcrypt_cost"`
-	SaltKeySize int    `yaml:"salt_key_size"`
+	JwtKey               string        `yaml:"jwt_key"`
+	BcryptCost           int           `yaml:"bcrypt_cost"`
+	SaltKeySize          int           `yaml:"salt_key_size"`
+	LockoutThreshold     int           `yaml:"lockout_threshold"`
+	LockoutDuration      time.Duration `yaml:"lockout_duration"`
+	LockoutNotifyAddress string        `yaml:"lockout_notify_address"`
+
+	// LoginRateLimit is the number of login attempts allowed from each IP
+	// per LoginRateLimitWindow, across every account.
+	LoginRateLimit       int           `yaml:"login_rate_limit"`
+	LoginRateLimitWindow time.Duration `yaml:"login_rate_limit_window"`
 }
 
 // AppConfig defines configs related to HTTP
 type AppConfig struct {
 	TokenKeySize              int           `yaml:"token_key_size"`
 	InviteTokenValidityPeriod time.Duration `yaml:"invite_token_validity_period"`
+	DeletedQueryRetention     time.Duration `yaml:"deleted_query_retention"`
+	CampaignRetention         time.Duration `yaml:"campaign_retention"`
+	// IngestionFailureRetention is how long the detail query results that
+	// could not be ingested are kept.
+	IngestionFailureRetention time.Duration `yaml:"ingestion_failure_retention"`
+	// IdempotencyWindow is how long the resource created by a request
+	// with an Idempotency-Key header is returned for retries of the
+	// request.
+	IdempotencyWindow time.Duration `yaml:"idempotency_window"`
+	// LabelSyncURL is polled for the members of manual labels maintained
+	// by an external system. No labels are synced when it is empty.
+	LabelSyncURL        string        `yaml:"label_sync_url"`
+	LabelSyncAuthHeader string        `yaml:"label_sync_auth_header"`
+	LabelSyncInterval   time.Duration `yaml:"label_sync_interval"`
+	// ValidateQuerySQL rejects saved queries whose SQL has syntax errors.
+	ValidateQuerySQL bool `yaml:"validate_query_sql"`
 }
 
 // SessionConfig defines configs related to user sessions
@@ -75,14 +142,34 @@ type SessionConfig struct {
 
 // OsqueryConfig defines configs related to osquery
 type OsqueryConfig struct {
-	NodeKeySize          int           `yaml:"node_key_size"`
-	StatusLogPlugin      string        `yaml:"status_log_plugin"`
-	ResultLogPlugin      string        `yaml:"result_log_plugin"`
-	LabelUpdateInterval  time.Duration `yaml:"label_update_interval"`
-	DetailUpdateInterval time.Duration `yaml:"detail_update_interval"`
-	StatusLogFile        string        `yaml:"status_log_file"`
-	ResultLogFile        string        `yaml:"result_log_file"`
-	EnableLogRotation    bool          `yaml:"enable_log_rotation"`
+	NodeKeySize           int           `yaml:"node_key_size"`
+	StatusLogPlugin       string        `yaml:"status_log_plugin"`
+	ResultLogPlugin       string        `yaml:"result_log_plugin"`
+	PackResultLogPlugins  string        `yaml:"pack_result_log_plugins"`
+	LabelUpdateInterval   time.Duration `yaml:"label_update_interval"`
+	DetailUpdateInterval  time.Duration `yaml:"detail_update_interval"`
+	StatusLogFile         string        `yaml:"status_log_file"`
+	ResultLogFile         string        `yaml:"result_log_file"`
+	EnableLogRotation     bool          `yaml:"enable_log_rotation"`
+	EnrollRateLimit       int           `yaml:"enroll_rate_limit"`
+	EnrollCooldown        time.Duration `yaml:"enroll_cooldown"`
+	EnrollClientCert      bool          `yaml:"enroll_client_cert"`
+	EnrollmentApproval    bool          `yaml:"enrollment_approval"`
+	HostIdentifier        string        `yaml:"host_identifier"`
+	LogRateLimit          int           `yaml:"log_rate_limit"`
+	LogRateLimitAction    string        `yaml:"log_rate_limit_action"`
+	MaxResultRows         int           `yaml:"max_result_rows"`
+	HealthCheckLogPlugins bool          `yaml:"health_check_log_plugins"`
+	MaxRequestBodySize    int           `yaml:"max_request_body_size"`
+
+	// EnrollClientCertNames is a comma-separated list of the
+	// certificate common names, or patterns matching them, that may enroll
+	// with a client certificate.
+	EnrollClientCertNames string `yaml:"enroll_client_cert_names"`
+
+	// LabelRecalculationInterval is how often the queries of modified
+	// labels are sent back to the hosts that evaluated them.
+	LabelRecalculationInterval time.Duration `yaml:"label_recalculation_interval"`
 }
 
 // LoggingConfig defines configs related to logging
@@ -159,6 +246,12 @@ func (man Manager) addConfigs() {
 		"MySQL TLS config value. Use skip-verify, true, false or custom key.")
 	man.addConfigInt("mysql.max_open_conns", 50, "MySQL maximum open connection handles.")
 	man.addConfigInt("mysql.max_idle_conns", 50, "MySQL maximum idle connection handles.")
+	man.addConfigDuration("mysql.conn_max_lifetime", 15*time.Minute,
+		"MySQL maximum amount of time a connection may be reused.")
+	man.addConfigBool("mysql.enable_metrics", false,
+		"Export Prometheus metrics for the count and latency of datastore calls.")
+	man.addConfigString("mysql.replica_addresses", "",
+		"Comma-separated MySQL read replica addresses (host:port) for read-heavy queries")
 
 	// Redis
 	man.addConfigString("redis.address", "localhost:6379",
@@ -178,8 +271,24 @@ func (man Manager) addConfigs() {
 	man.addConfigString(TLSProfileKey, TLSProfileModern,
 		fmt.Sprintf("TLS security profile choose one of %s, %s or %s",
 			TLSProfileModern, TLSProfileIntermediate, TLSProfileOld))
+	man.addConfigString("server.tls_client_ca", "",
+		"Path to a PEM encoded CA bundle used to verify TLS client certificates")
+	man.addConfigString("server.tls_min_version", "",
+		"Minimum TLS version (1.0, 1.1, 1.2 or 1.3), overriding the TLS profile")
+	man.addConfigString("server.tls_cipher_suites", "",
+		"Comma-separated TLS cipher suite names, overriding the TLS profile")
+	man.addConfigString("server.tls_curve_preferences", "",
+		"Comma-separated TLS curve names (X25519, P256, P384, P521), overriding the TLS profile")
 	man.addConfigString("server.url_prefix", "",
 		"URL prefix used on server and frontend endpoints")
+	man.addConfigString("server.cors_allowed_origins", "",
+		"Comma-separated origins allowed to make cross-origin API requests, or * for any origin")
+	man.addConfigString("server.cors_allowed_methods", "GET,POST,PATCH,DELETE",
+		"Comma-separated methods allowed in cross-origin API requests")
+	man.addConfigString("server.cors_allowed_headers", "Authorization,Content-Type,Idempotency-Key",
+		"Comma-separated headers allowed in cross-origin API requests")
+	man.addConfigString("server.trusted_proxies", "",
+		"Comma-separated IP addresses and CIDR ranges of the proxies trusted to set X-Forwarded-For")
 
 	// Auth
 	man.addConfigString("auth.jwt_key", "",
@@ -188,6 +297,16 @@ func (man Manager) addConfigs() {
 		"Bcrypt iterations")
 	man.addConfigInt("auth.salt_key_size", 24,
 		"Size of salt for passwords")
+	man.addConfigInt("auth.lockout_threshold", 0,
+		"Consecutive failed logins that lock an account (0 to disable)")
+	man.addConfigDuration("auth.lockout_duration", 15*time.Minute,
+		"Duration of the lockout that follows repeated failed logins")
+	man.addConfigString("auth.lockout_notify_address", "",
+		"Email address notified of account lockouts, in addition to the user")
+	man.addConfigInt("auth.login_rate_limit", 0,
+		"Login attempts allowed from each IP per window (0 to disable)")
+	man.addConfigDuration("auth.login_rate_limit_window", time.Minute,
+		"Window of the login rate limit")
 
 	// App
 	man.addConfigString("app.token_key", "CHANGEME",
@@ -196,6 +315,22 @@ func (man Manager) addConfigs() {
 		"Duration invite tokens remain valid (i.e. 1h)")
 	man.addConfigInt("app.token_key_size", 24,
 		"Size of generated tokens")
+	man.addConfigDuration("app.deleted_query_retention", 30*24*time.Hour,
+		"Duration deleted queries can be restored before they are purged")
+	man.addConfigDuration("app.campaign_retention", 7*24*time.Hour,
+		"Duration completed live query campaigns are kept before they are purged")
+	man.addConfigDuration("app.ingestion_failure_retention", 7*24*time.Hour,
+		"Duration failed detail query ingestions are kept before they are purged")
+	man.addConfigDuration("app.idempotency_window", 24*time.Hour,
+		"Duration retries of a create request with the same Idempotency-Key return the original resource")
+	man.addConfigString("app.label_sync_url", "",
+		"URL polled for the members of externally managed manual labels")
+	man.addConfigString("app.label_sync_auth_header", "",
+		"Authorization header sent to the label sync URL")
+	man.addConfigDuration("app.label_sync_interval", 1*time.Hour,
+		"Interval between polls of the label sync URL")
+	man.addConfigBool("app.validate_query_sql", false,
+		"Reject saved queries whose SQL has syntax errors")
 
 	// Session
 	man.addConfigInt("session.key_size", 64,
@@ -210,8 +345,12 @@ func (man Manager) addConfigs() {
 		"Log plugin to use for status logs")
 	man.addConfigString("osquery.result_log_plugin", "filesystem",
 		"Log plugin to use for result logs")
+	man.addConfigString("osquery.pack_result_log_plugins", "",
+		"Comma-separated additional log plugins that packs can send result logs to")
 	man.addConfigDuration("osquery.label_update_interval", 1*time.Hour,
 		"Interval to update host label membership (i.e. 1h)")
+	man.addConfigDuration("osquery.label_recalculation_interval", 10*time.Minute,
+		"Interval to send the queries of modified labels back to hosts (i.e. 10m)")
 	man.addConfigDuration("osquery.detail_update_interval", 1*time.Hour,
 		"Interval to update host details (i.e. 1h)")
 	man.addConfigString("osquery.status_log_file", "",
@@ -220,6 +359,28 @@ func (man Manager) addConfigs() {
 		"(DEPRECATED: Use filesystem.result_log_file) Path for osqueryd result logs")
 	man.addConfigBool("osquery.enable_log_rotation", false,
 		"(DEPRECATED: Use filesystem.enable_log_rotation) Enable automatic rotation for osquery log files")
+	man.addConfigInt("osquery.enroll_rate_limit", 0,
+		"Maximum enroll and config requests per second (0 for unlimited)")
+	man.addConfigDuration("osquery.enroll_cooldown", 0,
+		"Window in which re-enrolling hosts reuse their existing node key (0 to disable)")
+	man.addConfigBool("osquery.enroll_client_cert", false,
+		"Allow hosts with a verified TLS client certificate to enroll without an enroll secret")
+	man.addConfigString("osquery.enroll_client_cert_names", "",
+		"Comma-separated client certificate common names, or patterns, allowed to enroll without an enroll secret")
+	man.addConfigBool("osquery.enrollment_approval", false,
+		"Require newly enrolled hosts to be approved by an admin before they are served a config")
+	man.addConfigString(HostIdentifierKey, HostIdentifierProvided,
+		"Identifier used to match enrolling hosts to existing hosts (provided, uuid, hostname or instance)")
+	man.addConfigInt("osquery.log_rate_limit", 0,
+		"Maximum status and result log rows per minute accepted from each host (0 for unlimited)")
+	man.addConfigString(LogRateLimitActionKey, LogRateLimitActionDrop,
+		"Action for log rows exceeding the rate limit (drop or reject)")
+	man.addConfigInt("osquery.max_result_rows", 0,
+		"Maximum rows kept from each scheduled query result of a host (0 for unlimited)")
+	man.addConfigBool("osquery.health_check_log_plugins", false,
+		"Include the osquery log destinations in the health check")
+	man.addConfigInt("osquery.max_request_body_size", 100*1024*1024,
+		"Maximum size in bytes of the log and distributed query result requests of hosts (0 for unlimited)")
 
 	// Logging
 	man.addConfigBool("logging.debug", false,
@@ -259,53 +420,90 @@ func (man Manager) LoadConfig() KolideConfig {
 
 	return KolideConfig{
 		Mysql: MysqlConfig{
-			Protocol:      man.getConfigString("mysql.protocol"),
-			Address:       man.getConfigString("mysql.address"),
-			Username:      man.getConfigString("mysql.username"),
-			Password:      man.getConfigString("mysql.password"),
-			Database:      man.getConfigString("mysql.database"),
-			TLSCert:       man.getConfigString("mysql.tls_cert"),
-			TLSKey:        man.getConfigString("mysql.tls_key"),
-			TLSCA:         man.getConfigString("mysql.tls_ca"),
-			TLSServerName: man.getConfigString("mysql.tls_server_name"),
-			TLSConfig:     man.getConfigString("mysql.tls_config"),
-			MaxOpenConns:  man.getConfigInt("mysql.max_open_conns"),
-			MaxIdleConns:  man.getConfigInt("mysql.max_idle_conns"),
+			Protocol:         man.getConfigString("mysql.protocol"),
+			Address:          man.getConfigString("mysql.address"),
+			Username:         man.getConfigString("mysql.username"),
+			Password:         man.getConfigString("mysql.password"),
+			Database:         man.getConfigString("mysql.database"),
+			TLSCert:          man.getConfigString("mysql.tls_cert"),
+			TLSKey:           man.getConfigString("mysql.tls_key"),
+			TLSCA:            man.getConfigString("mysql.tls_ca"),
+			TLSServerName:    man.getConfigString("mysql.tls_server_name"),
+			TLSConfig:        man.getConfigString("mysql.tls_config"),
+			MaxOpenConns:     man.getConfigInt("mysql.max_open_conns"),
+			MaxIdleConns:     man.getConfigInt("mysql.max_idle_conns"),
+			EnableMetrics:    man.getConfigBool("mysql.enable_metrics"),
+			ReplicaAddresses: man.getConfigString("mysql.replica_addresses"),
+			ConnMaxLifetime:  man.getConfigDuration("mysql.conn_max_lifetime"),
 		},
 		Redis: RedisConfig{
 			Address:  man.getConfigString("redis.address"),
 			Password: man.getConfigString("redis.password"),
 		},
 		Server: ServerConfig{
-			Address:    man.getConfigString("server.address"),
-			Cert:       man.getConfigString("server.cert"),
-			Key:        man.getConfigString("server.key"),
-			TLS:        man.getConfigBool("server.tls"),
-			TLSProfile: man.getConfigTLSProfile(),
-			URLPrefix:  man.getConfigString("server.url_prefix"),
+			Address:             man.getConfigString("server.address"),
+			Cert:                man.getConfigString("server.cert"),
+			Key:                 man.getConfigString("server.key"),
+			TLS:                 man.getConfigBool("server.tls"),
+			TLSProfile:          man.getConfigTLSProfile(),
+			TLSClientCA:         man.getConfigString("server.tls_client_ca"),
+			TLSMinVersion:       man.getConfigString("server.tls_min_version"),
+			TLSCipherSuites:     man.getConfigString("server.tls_cipher_suites"),
+			TLSCurvePreferences: man.getConfigString("server.tls_curve_preferences"),
+			URLPrefix:           man.getConfigString("server.url_prefix"),
+			CORSAllowedOrigins:  man.getConfigString("server.cors_allowed_origins"),
+			CORSAllowedMethods:  man.getConfigString("server.cors_allowed_methods"),
+			CORSAllowedHeaders:  man.getConfigString("server.cors_allowed_headers"),
+			TrustedProxies:      man.getConfigString("server.trusted_proxies"),
 		},
 		Auth: AuthConfig{
-			JwtKey:      man.getConfigString("auth.jwt_key"),
-			BcryptCost:  man.getConfigInt("auth.bcrypt_cost"),
-			SaltKeySize: man.getConfigInt("auth.salt_key_size"),
+			JwtKey:               man.getConfigString("auth.jwt_key"),
+			BcryptCost:           man.getConfigInt("auth.bcrypt_cost"),
+			SaltKeySize:          man.getConfigInt("auth.salt_key_size"),
+			LockoutThreshold:     man.getConfigInt("auth.lockout_threshold"),
+			LockoutDuration:      man.getConfigDuration("auth.lockout_duration"),
+			LockoutNotifyAddress: man.getConfigString("auth.lockout_notify_address"),
+			LoginRateLimit:       man.getConfigInt("auth.login_rate_limit"),
+			LoginRateLimitWindow: man.getConfigDuration("auth.login_rate_limit_window"),
 		},
 		App: AppConfig{
 			TokenKeySize:              man.getConfigInt("app.token_key_size"),
 			InviteTokenValidityPeriod: man.getConfigDuration("app.invite_token_validity_period"),
+			DeletedQueryRetention:     man.getConfigDuration("app.deleted_query_retention"),
+			CampaignRetention:         man.getConfigDuration("app.campaign_retention"),
+			IngestionFailureRetention: man.getConfigDuration("app.ingestion_failure_retention"),
+			IdempotencyWindow:         man.getConfigDuration("app.idempotency_window"),
+			LabelSyncURL:              man.getConfigString("app.label_sync_url"),
+			LabelSyncAuthHeader:       man.getConfigString("app.label_sync_auth_header"),
+			LabelSyncInterval:         man.getConfigDuration("app.label_sync_interval"),
+			ValidateQuerySQL:          man.getConfigBool("app.validate_query_sql"),
 		},
 		Session: SessionConfig{
 			KeySize:  man.getConfigInt("session.key_size"),
 			Duration: man.getConfigDuration("session.duration"),
 		},
 		Osquery: OsqueryConfig{
-			NodeKeySize:          man.getConfigInt("osquery.node_key_size"),
-			StatusLogPlugin:      man.getConfigString("osquery.status_log_plugin"),
-			ResultLogPlugin:      man.getConfigString("osquery.result_log_plugin"),
-			StatusLogFile:        man.getConfigString("osquery.status_log_file"),
-			ResultLogFile:        man.getConfigString("osquery.result_log_file"),
-			LabelUpdateInterval:  man.getConfigDuration("osquery.label_update_interval"),
-			DetailUpdateInterval: man.getConfigDuration("osquery.detail_update_interval"),
-			EnableLogRotation:    man.getConfigBool("osquery.enable_log_rotation"),
+			NodeKeySize:                man.getConfigInt("osquery.node_key_size"),
+			StatusLogPlugin:            man.getConfigString("osquery.status_log_plugin"),
+			ResultLogPlugin:            man.getConfigString("osquery.result_log_plugin"),
+			PackResultLogPlugins:       man.getConfigString("osquery.pack_result_log_plugins"),
+			StatusLogFile:              man.getConfigString("osquery.status_log_file"),
+			ResultLogFile:              man.getConfigString("osquery.result_log_file"),
+			LabelUpdateInterval:        man.getConfigDuration("osquery.label_update_interval"),
+			LabelRecalculationInterval: man.getConfigDuration("osquery.label_recalculation_interval"),
+			DetailUpdateInterval:       man.getConfigDuration("osquery.detail_update_interval"),
+			EnableLogRotation:          man.getConfigBool("osquery.enable_log_rotation"),
+			EnrollRateLimit:            man.getConfigInt("osquery.enroll_rate_limit"),
+			EnrollCooldown:             man.getConfigDuration("osquery.enroll_cooldown"),
+			EnrollClientCert:           man.getConfigBool("osquery.enroll_client_cert"),
+			EnrollClientCertNames:      man.getConfigString("osquery.enroll_client_cert_names"),
+			EnrollmentApproval:         man.getConfigBool("osquery.enrollment_approval"),
+			HostIdentifier:             man.getConfigHostIdentifier(),
+			LogRateLimit:               man.getConfigInt("osquery.log_rate_limit"),
+			LogRateLimitAction:         man.getConfigLogRateLimitAction(),
+			MaxResultRows:              man.getConfigInt("osquery.max_result_rows"),
+			HealthCheckLogPlugins:      man.getConfigBool("osquery.health_check_log_plugins"),
+			MaxRequestBodySize:         man.getConfigInt("osquery.max_request_body_size"),
 		},
 		Logging: LoggingConfig{
 			Debug:         man.getConfigBool("logging.debug"),
@@ -442,6 +640,42 @@ func (man Manager) getConfigTLSProfile() string {
 	return sval
 }
 
+// Custom handling for the host identifier which can only accept specific
+// values for the argument
+func (man Manager) getConfigHostIdentifier() string {
+	ival := man.getInterfaceVal(HostIdentifierKey)
+	sval, err := cast.ToStringE(ival)
+	if err != nil {
+		panic(fmt.Sprintf("%s requires a string value: %s", HostIdentifierKey, err.Error()))
+	}
+	switch sval {
+	case HostIdentifierProvided, HostIdentifierUUID, HostIdentifierHostname, HostIdentifierInstance:
+		// no error
+	default:
+		panic(fmt.Sprintf("%s must be one of %s, %s, %s or %s", HostIdentifierKey,
+			HostIdentifierProvided, HostIdentifierUUID, HostIdentifierHostname, HostIdentifierInstance))
+	}
+	return sval
+}
+
+// Custom handling for the log rate limit action which can only accept specific
+// values for the argument
+func (man Manager) getConfigLogRateLimitAction() string {
+	ival := man.getInterfaceVal(LogRateLimitActionKey)
+	sval, err := cast.ToStringE(ival)
+	if err != nil {
+		panic(fmt.Sprintf("%s requires a string value: %s", LogRateLimitActionKey, err.Error()))
+	}
+	switch sval {
+	case LogRateLimitActionDrop, LogRateLimitActionReject:
+		// no error
+	default:
+		panic(fmt.Sprintf("%s must be one of %s or %s", LogRateLimitActionKey,
+			LogRateLimitActionDrop, LogRateLimitActionReject))
+	}
+	return sval
+}
+
 // addConfigInt adds a int config to the config options
 func (man Manager) addConfigInt(key string, defVal int, usage string) {
 	man.command.PersistentFlags().Int(flagNameFromConfigKey(key), defVal, getFlagUsage(key, usage))
@@ -535,22 +769,31 @@ func TestConfig() KolideConfig {
 		App: AppConfig{
 			TokenKeySize:              24,
 			InviteTokenValidityPeriod: 5 * 24 * time.Hour,
+			DeletedQueryRetention:     30 * 24 * time.Hour,
+			CampaignRetention:         7 * 24 * time.Hour,
+			IngestionFailureRetention: 7 * 24 * time.Hour,
+			IdempotencyWindow:         24 * time.Hour,
+			LabelSyncInterval:         1 * time.Hour,
 		},
 		Auth: AuthConfig{
-			JwtKey:      "CHANGEME",
-			BcryptCost:  6, // Low cost keeps tests fast
-			SaltKeySize: 24,
+			JwtKey:          "CHANGEME",
+			BcryptCost:      6, // Low cost keeps tests fast
+			SaltKeySize:     24,
+			LockoutDuration: 15 * time.Minute,
 		},
 		Session: SessionConfig{
 			KeySize:  64,
 			Duration: 24 * 90 * time.Hour,
 		},
 		Osquery: OsqueryConfig{
-			NodeKeySize:          24,
-			StatusLogPlugin:      "filesystem",
-			ResultLogPlugin:      "filesystem",
-			LabelUpdateInterval:  1 * time.Hour,
-			DetailUpdateInterval: 1 * time.Hour,
+			NodeKeySize:                24,
+			StatusLogPlugin:            "filesystem",
+			ResultLogPlugin:            "filesystem",
+			LabelUpdateInterval:        1 * time.Hour,
+			LabelRecalculationInterval: 10 * time.Minute,
+			DetailUpdateInterval:       1 * time.Hour,
+			HostIdentifier:             HostIdentifierProvided,
+			LogRateLimitAction:         LogRateLimitActionDrop,
 		},
 		Logging: LoggingConfig{
 			Debug:         true,
@@ -41,6 +41,11 @@ func TestConfigRoundtrip(t *testing.T) {
 					// we have to explicitly set value for this key as it will only
 					// accept old, intermediate, or modern
 					key_v.SetString(TLSProfileModern)
+				case "HostIdentifier":
+					// similarly, only specific values are accepted
+					key_v.SetString(HostIdentifierInstance)
+				case "LogRateLimitAction":
+					key_v.SetString(LogRateLimitActionReject)
 				default:
 					key_v.SetString(v.Elem().Type().Field(conf_index).Name + "_" + conf_v.Type().Field(key_index).Name)
 				}
@@ -0,0 +1,34 @@
+// Package clientcert enables setting and reading the verified TLS client
+// certificate of a request from context
+package clientcert
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+)
+
+type key int
+
+const certKey key = 0
+
+// FromHTTPRequest returns the client certificate of the request if one was
+// presented and verified during the TLS handshake.
+func FromHTTPRequest(r *http.Request) (*x509.Certificate, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	return r.TLS.VerifiedChains[0][0], true
+}
+
+// NewContext returns a new context carrying the verified client certificate.
+func NewContext(ctx context.Context, cert *x509.Certificate) context.Context {
+	return context.WithValue(ctx, certKey, cert)
+}
+
+// FromContext extracts the verified client certificate from context if
+// present.
+func FromContext(ctx context.Context) (*x509.Certificate, bool) {
+	cert, ok := ctx.Value(certKey).(*x509.Certificate)
+	return cert, ok
+}
@@ -0,0 +1,35 @@
+// Package idempotency enables setting and reading the idempotency key
+// supplied by clients that retry requests.
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type key int
+
+const idempotencyKey key = 0
+
+// Key identifies the retries of a single request.
+type Key string
+
+// FromHTTPRequest extracts the Idempotency-Key header if present.
+func FromHTTPRequest(r *http.Request) Key {
+	return Key(strings.TrimSpace(r.Header.Get("Idempotency-Key")))
+}
+
+// NewContext returns a new context carrying the idempotency key.
+func NewContext(ctx context.Context, k Key) context.Context {
+	if k == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, idempotencyKey, k)
+}
+
+// FromContext extracts the idempotency key if present.
+func FromContext(ctx context.Context) (Key, bool) {
+	k, ok := ctx.Value(idempotencyKey).(Key)
+	return k, ok
+}
@@ -0,0 +1,20 @@
+// Package requestid enables setting and reading
+// the ID of the current request from context
+package requestid
+
+import "context"
+
+type key int
+
+const requestIDKey key = 0
+
+// NewContext returns a new context carrying the request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// FromContext extracts the request ID from context if present.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok
+}
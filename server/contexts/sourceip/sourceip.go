@@ -0,0 +1,103 @@
+// Package sourceip enables setting and reading the address of the client
+// that made the current request from context
+package sourceip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type key int
+
+const sourceIPKey key = 0
+
+// TrustedProxies are the networks of the proxies whose X-Forwarded-For
+// headers are used to find the address of the client.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses a comma-separated list of IP addresses and CIDR
+// ranges.
+func ParseTrustedProxies(list string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, errors.Errorf("invalid trusted proxy '%s'", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, errors.Errorf("invalid trusted proxy '%s'", entry)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// Contains returns whether addr is the address of a trusted proxy.
+func (p TrustedProxies) Contains(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// FromHTTPRequest returns the address of the client that made the request.
+// The X-Forwarded-For header can be set by clients, so it is only used when
+// the request comes from a trusted proxy. The right-most address of the
+// header that is not a trusted proxy is then returned, as the addresses to
+// its left were reported by the client itself.
+func FromHTTPRequest(r *http.Request, proxies TrustedProxies) string {
+	addr := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	if !proxies.Contains(addr) {
+		return addr
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header["X-Forwarded-For"], ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		entry := strings.TrimSpace(forwarded[i])
+		if entry == "" {
+			continue
+		}
+		addr = entry
+		if !proxies.Contains(entry) {
+			break
+		}
+	}
+	return addr
+}
+
+// NewContext returns a new context carrying the address of the client.
+func NewContext(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, sourceIPKey, addr)
+}
+
+// FromContext extracts the address of the client from context if present.
+func FromContext(ctx context.Context) (string, bool) {
+	addr, ok := ctx.Value(sourceIPKey).(string)
+	return addr, ok
+}
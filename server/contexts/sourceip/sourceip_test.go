@@ -0,0 +1,61 @@
+package sourceip
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies(" 10.0.0.0/8, 192.168.1.10,,fd00::1 ")
+	require.Nil(t, err)
+	assert.True(t, proxies.Contains("10.1.2.3"))
+	assert.True(t, proxies.Contains("192.168.1.10"))
+	assert.False(t, proxies.Contains("192.168.1.11"))
+	assert.True(t, proxies.Contains("fd00::1"))
+	assert.False(t, proxies.Contains("fd00::2"))
+	assert.False(t, proxies.Contains("not an ip"))
+
+	proxies, err = ParseTrustedProxies("")
+	require.Nil(t, err)
+	assert.Empty(t, proxies)
+
+	_, err = ParseTrustedProxies("10.0.0.0/8,proxy.example.com")
+	assert.EqualError(t, err, "invalid trusted proxy 'proxy.example.com'")
+	_, err = ParseTrustedProxies("10.0.0.0/33")
+	assert.EqualError(t, err, "invalid trusted proxy '10.0.0.0/33'")
+}
+
+func TestFromHTTPRequest(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8")
+	require.Nil(t, err)
+
+	for _, tt := range []struct {
+		remoteAddr string
+		forwarded  []string
+		proxies    TrustedProxies
+		expected   string
+	}{
+		// The header is ignored unless the peer is trusted
+		{"192.168.1.5:52000", nil, nil, "192.168.1.5"},
+		{"192.168.1.5:52000", []string{"1.2.3.4"}, nil, "192.168.1.5"},
+		{"192.168.1.5:52000", []string{"1.2.3.4"}, proxies, "192.168.1.5"},
+		{"10.0.0.1:52000", []string{"1.2.3.4"}, nil, "10.0.0.1"},
+		// Addresses added by clients, to the left, are skipped
+		{"10.0.0.1:52000", []string{"1.2.3.4, 5.6.7.8"}, proxies, "5.6.7.8"},
+		{"10.0.0.1:52000", []string{"1.2.3.4, 5.6.7.8, 10.0.0.2"}, proxies, "5.6.7.8"},
+		{"10.0.0.1:52000", []string{"1.2.3.4", "5.6.7.8,10.0.0.2"}, proxies, "5.6.7.8"},
+		// Only trusted proxies forwarded the request
+		{"10.0.0.1:52000", []string{"10.0.0.3, 10.0.0.2"}, proxies, "10.0.0.3"},
+		{"10.0.0.1:52000", nil, proxies, "10.0.0.1"},
+	} {
+		r := httptest.NewRequest("POST", "/api/v1/kolide/login", nil)
+		r.RemoteAddr = tt.remoteAddr
+		for _, value := range tt.forwarded {
+			r.Header.Add("X-Forwarded-For", value)
+		}
+		assert.Equal(t, tt.expected, FromHTTPRequest(r, tt.proxies), "%s %v", tt.remoteAddr, tt.forwarded)
+	}
+}
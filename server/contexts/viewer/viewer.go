@@ -28,6 +28,9 @@ func FromContext(ctx context.Context) (Viewer, bool) {
 type Viewer struct {
 	User    *kolide.User
 	Session *kolide.Session
+	// APIToken is set instead of Session when the request was
+	// authenticated with an API token.
+	APIToken *kolide.APIToken
 }
 
 // UserID is a helper that enables quick access to the user ID of the current
@@ -79,6 +82,9 @@ func (v Viewer) IsLoggedIn() bool {
 			return false
 		}
 	}
+	if v.APIToken != nil && v.APIToken.ID != 0 {
+		return true
+	}
 	if v.Session != nil {
 		// Without having access to a service to call GetInfoAboutSession(id),
 		// we can't synchronously check the database here.
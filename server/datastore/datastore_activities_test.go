@@ -0,0 +1,56 @@
+package datastore
+
+import (
+	"testing"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testActivities(t *testing.T, ds kolide.Datastore) {
+	activities, err := ds.ListActivities(kolide.ListOptions{})
+	require.Nil(t, err)
+	assert.Len(t, activities, 0)
+
+	user, err := ds.NewUser(&kolide.User{Username: "admin", Email: "admin@example.com", Admin: true})
+	require.Nil(t, err)
+
+	targetID := uint(42)
+	_, err = ds.NewActivity(&kolide.Activity{
+		ActorID:       &user.ID,
+		ActorUsername: user.Username,
+		Action:        "modify_pack",
+		TargetID:      &targetID,
+	})
+	require.Nil(t, err)
+	_, err = ds.NewActivity(&kolide.Activity{
+		Action:     "delete_pack",
+		TargetName: "osquery_monitoring",
+	})
+	require.Nil(t, err)
+
+	activities, err = ds.ListActivities(kolide.ListOptions{
+		OrderKey:       "id",
+		OrderDirection: kolide.OrderDescending,
+	})
+	require.Nil(t, err)
+	require.Len(t, activities, 2)
+
+	assert.Equal(t, "delete_pack", activities[0].Action)
+	assert.Nil(t, activities[0].ActorID)
+	assert.Nil(t, activities[0].TargetID)
+	assert.Equal(t, "osquery_monitoring", activities[0].TargetName)
+	assert.False(t, activities[0].CreatedAt.IsZero())
+
+	assert.Equal(t, "modify_pack", activities[1].Action)
+	require.NotNil(t, activities[1].ActorID)
+	assert.Equal(t, user.ID, *activities[1].ActorID)
+	assert.Equal(t, "admin", activities[1].ActorUsername)
+	require.NotNil(t, activities[1].TargetID)
+	assert.Equal(t, targetID, *activities[1].TargetID)
+
+	activities, err = ds.ListActivities(kolide.ListOptions{PerPage: 1})
+	require.Nil(t, err)
+	assert.Len(t, activities, 1)
+}
@@ -0,0 +1,63 @@
+package datastore
+
+import (
+	"testing"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAPITokens(t *testing.T, ds kolide.Datastore) {
+	zwass := test.NewUser(t, ds, "Zach", "zwass", "zwass@kolide.co", true)
+	admin := test.NewUser(t, ds, "Admin", "admin", "admin@kolide.co", true)
+
+	tokens, err := ds.ListAPITokensForUser(zwass.ID)
+	require.Nil(t, err)
+	assert.Len(t, tokens, 0)
+
+	token1, err := ds.NewAPIToken(&kolide.APIToken{
+		UserID: zwass.ID,
+		Name:   "automation",
+		Key:    "key1",
+		Scopes: kolide.Scopes{kolide.ScopeHostsRead, kolide.ScopeQueriesRun},
+	})
+	require.Nil(t, err)
+	assert.NotZero(t, token1.ID)
+	_, err = ds.NewAPIToken(&kolide.APIToken{
+		UserID: admin.ID,
+		Name:   "other",
+		Key:    "key2",
+		Scopes: kolide.Scopes{kolide.ScopeLabelsWrite},
+	})
+	require.Nil(t, err)
+
+	// Keys are unique
+	_, err = ds.NewAPIToken(&kolide.APIToken{UserID: admin.ID, Key: "key1", Scopes: kolide.Scopes{}})
+	assert.NotNil(t, err)
+
+	token, err := ds.APITokenByKey("key1")
+	require.Nil(t, err)
+	assert.Equal(t, token1.ID, token.ID)
+	assert.Equal(t, zwass.ID, token.UserID)
+	assert.Equal(t, "automation", token.Name)
+	assert.Equal(t, kolide.Scopes{kolide.ScopeHostsRead, kolide.ScopeQueriesRun}, token.Scopes)
+
+	token, err = ds.APIToken(token1.ID)
+	require.Nil(t, err)
+	assert.Equal(t, "key1", token.Key)
+
+	_, err = ds.APITokenByKey("missing")
+	assert.True(t, kolide.IsNotFound(err))
+
+	tokens, err = ds.ListAPITokensForUser(zwass.ID)
+	require.Nil(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, token1.ID, tokens[0].ID)
+
+	require.Nil(t, ds.DeleteAPIToken(token1.ID))
+	_, err = ds.APIToken(token1.ID)
+	assert.True(t, kolide.IsNotFound(err))
+	assert.True(t, kolide.IsNotFound(ds.DeleteAPIToken(token1.ID)))
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/stretchr/testify/assert"
@@ -78,9 +79,9 @@ func testAdditionalQueries(t *testing.T, ds kolide.Datastore) {
 }
 
 func testEnrollSecrets(t *testing.T, ds kolide.Datastore) {
-	name, err := ds.VerifyEnrollSecret("missing")
+	secret, err := ds.VerifyEnrollSecret("missing")
 	assert.Error(t, err)
-	assert.Empty(t, name)
+	assert.Nil(t, secret)
 
 	err = ds.ApplyEnrollSecretSpec(
 		&kolide.EnrollSecretSpec{
@@ -92,15 +93,16 @@ func testEnrollSecrets(t *testing.T, ds kolide.Datastore) {
 	)
 	assert.NoError(t, err)
 
-	name, err = ds.VerifyEnrollSecret("one")
+	secret, err = ds.VerifyEnrollSecret("one")
 	assert.Error(t, err, "secret should not match")
-	assert.Empty(t, name, "secret name should be empty")
-	name, err = ds.VerifyEnrollSecret("one_secret")
+	assert.Nil(t, secret)
+	secret, err = ds.VerifyEnrollSecret("one_secret")
 	assert.NoError(t, err)
-	assert.Equal(t, "one", name)
-	name, err = ds.VerifyEnrollSecret("two_secret")
+	require.NotNil(t, secret)
+	assert.Equal(t, "one", secret.Name)
+	secret, err = ds.VerifyEnrollSecret("two_secret")
 	assert.Error(t, err)
-	assert.Equal(t, "", name)
+	assert.Nil(t, secret)
 
 	err = ds.ApplyEnrollSecretSpec(
 		&kolide.EnrollSecretSpec{
@@ -112,12 +114,13 @@ func testEnrollSecrets(t *testing.T, ds kolide.Datastore) {
 	)
 	assert.NoError(t, err)
 
-	name, err = ds.VerifyEnrollSecret("one_secret")
+	secret, err = ds.VerifyEnrollSecret("one_secret")
 	assert.Error(t, err)
-	assert.Equal(t, "", name)
-	name, err = ds.VerifyEnrollSecret("two_secret")
+	assert.Nil(t, secret)
+	secret, err = ds.VerifyEnrollSecret("two_secret")
 	assert.NoError(t, err)
-	assert.Equal(t, "two", name)
+	require.NotNil(t, secret)
+	assert.Equal(t, "two", secret.Name)
 
 }
 
@@ -151,3 +154,94 @@ func testEnrollSecretRoundtrip(t *testing.T, ds kolide.Datastore) {
 	assert.Equal(t, "two_secret", spec.Secrets[2].Secret)
 	assert.Equal(t, true, spec.Secrets[2].Active)
 }
+
+func testEnrollSecretScoped(t *testing.T, ds kolide.Datastore) {
+	label, err := ds.NewLabel(&kolide.Label{Name: "contractors", LabelType: kolide.LabelTypeManual})
+	require.NoError(t, err)
+
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	labelName := "contractors"
+	err = ds.ApplyEnrollSecretSpec(&kolide.EnrollSecretSpec{
+		Secrets: []kolide.EnrollSecret{
+			{Name: "scoped", Secret: "scoped_secret", Active: true, ExpiresAt: &expiresAt, Label: &labelName},
+		},
+	})
+	require.NoError(t, err)
+
+	secret, err := ds.VerifyEnrollSecret("scoped_secret")
+	require.NoError(t, err)
+	require.NotNil(t, secret.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*secret.ExpiresAt))
+	require.NotNil(t, secret.LabelID)
+	assert.Equal(t, label.ID, *secret.LabelID)
+
+	spec, err := ds.GetEnrollSecretSpec()
+	require.NoError(t, err)
+	var found bool
+	for _, s := range spec.Secrets {
+		if s.Name == "scoped" {
+			found = true
+			require.NotNil(t, s.Label)
+			assert.Equal(t, "contractors", *s.Label)
+		}
+	}
+	assert.True(t, found)
+
+	unknown := "unknown"
+	err = ds.ApplyEnrollSecretSpec(&kolide.EnrollSecretSpec{
+		Secrets: []kolide.EnrollSecret{
+			{Name: "bad", Secret: "bad_secret", Active: true, Label: &unknown},
+		},
+	})
+	assert.Error(t, err)
+
+	// Enrolled hosts can only be added to manual labels
+	_, err = ds.NewLabel(&kolide.Label{Name: "linux", Query: "select 1"})
+	require.NoError(t, err)
+	queryLabel := "linux"
+	err = ds.ApplyEnrollSecretSpec(&kolide.EnrollSecretSpec{
+		Secrets: []kolide.EnrollSecret{
+			{Name: "bad", Secret: "bad_secret", Active: true, Label: &queryLabel},
+		},
+	})
+	assert.Error(t, err)
+}
+
+func testEnrollSecretUsage(t *testing.T, ds kolide.Datastore) {
+	err := ds.ApplyEnrollSecretSpec(&kolide.EnrollSecretSpec{
+		Secrets: []kolide.EnrollSecret{
+			{Name: "one", Secret: "one_secret", Active: true},
+			{Name: "two", Secret: "two_secret", Active: false},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = ds.EnrollHost("host1", "key1", "one", 0, false)
+	require.NoError(t, err)
+	_, err = ds.EnrollHost("host2", "key2", "one", 0, false)
+	require.NoError(t, err)
+
+	usage, err := ds.EnrollSecretUsage()
+	require.NoError(t, err)
+	require.Len(t, usage, 3)
+	assert.Equal(t, "default", usage[0].Name)
+	assert.Equal(t, uint(0), usage[0].Hosts)
+	assert.Nil(t, usage[0].LastUsed)
+	assert.Equal(t, "one", usage[1].Name)
+	assert.True(t, usage[1].Active)
+	assert.Equal(t, uint(2), usage[1].Hosts)
+	assert.NotNil(t, usage[1].LastUsed)
+	assert.Equal(t, "two", usage[2].Name)
+	assert.False(t, usage[2].Active)
+	assert.Equal(t, uint(0), usage[2].Hosts)
+
+	// Hosts that re-enroll are counted for the secret they last used
+	_, err = ds.EnrollHost("host2", "key3", "two", 0, false)
+	require.NoError(t, err)
+	usage, err = ds.EnrollSecretUsage()
+	require.NoError(t, err)
+	require.Len(t, usage, 3)
+	assert.Equal(t, uint(1), usage[1].Hosts)
+	assert.Equal(t, uint(1), usage[2].Hosts)
+	assert.NotNil(t, usage[2].LastUsed)
+}
@@ -0,0 +1,66 @@
+package datastore
+
+import (
+	"testing"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testATCTables(t *testing.T, ds kolide.Datastore) {
+	tables, err := ds.ListATCTables()
+	require.Nil(t, err)
+	assert.Len(t, tables, 0)
+
+	events, err := ds.NewATCTable(&kolide.ATCTable{
+		Name:    "app_events",
+		Path:    "/var/lib/app/events.db",
+		Query:   "SELECT id, name FROM events",
+		Columns: kolide.ATCColumns{"id", "name"},
+	})
+	require.Nil(t, err)
+	assert.NotZero(t, events.ID)
+
+	_, err = ds.NewATCTable(&kolide.ATCTable{
+		Name:    "app_events",
+		Path:    "/other.db",
+		Query:   "SELECT 1",
+		Columns: kolide.ATCColumns{"a"},
+	})
+	assert.NotNil(t, err)
+
+	bookmarks, err := ds.NewATCTable(&kolide.ATCTable{
+		Name:     "bookmarks",
+		Path:     "/Users/%/Library/bookmarks.db",
+		Query:    "SELECT url FROM bookmarks",
+		Columns:  kolide.ATCColumns{"url"},
+		Platform: "darwin",
+	})
+	require.Nil(t, err)
+
+	bookmarks.Columns = kolide.ATCColumns{"url", "title"}
+	bookmarks.Query = "SELECT url, title FROM bookmarks"
+	require.Nil(t, ds.SaveATCTable(bookmarks))
+	got, err := ds.ATCTable(bookmarks.ID)
+	require.Nil(t, err)
+	assert.Equal(t, kolide.ATCColumns{"url", "title"}, got.Columns)
+	assert.Equal(t, "darwin", got.Platform)
+
+	// Names must stay unique
+	bookmarks.Name = "app_events"
+	assert.NotNil(t, ds.SaveATCTable(bookmarks))
+
+	tables, err = ds.ListATCTables()
+	require.Nil(t, err)
+	require.Len(t, tables, 2)
+	assert.Equal(t, "app_events", tables[0].Name)
+	assert.Equal(t, "bookmarks", tables[1].Name)
+
+	require.Nil(t, ds.DeleteATCTable(events.ID))
+	err = ds.DeleteATCTable(events.ID)
+	assert.True(t, kolide.IsNotFound(err))
+	_, err = ds.ATCTable(events.ID)
+	assert.True(t, kolide.IsNotFound(err))
+	assert.True(t, kolide.IsNotFound(ds.SaveATCTable(&kolide.ATCTable{ID: events.ID, Name: "gone"})))
+}
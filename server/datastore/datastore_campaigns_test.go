@@ -41,6 +41,23 @@ func testDistributedQueryCampaign(t *testing.T, ds kolide.Datastore) {
 		assert.Equal(t, campaign.Status, retrieved.Status)
 	}
 
+	{
+		campaign.ExecutionTimeout = 120
+		require.Nil(t, ds.SaveDistributedQueryCampaign(campaign))
+		retrieved, err := ds.DistributedQueryCampaign(campaign.ID)
+		require.Nil(t, err)
+		assert.Equal(t, uint(120), retrieved.ExecutionTimeout)
+		assert.False(t, retrieved.AllowResubmission)
+	}
+
+	{
+		campaign.AllowResubmission = true
+		require.Nil(t, ds.SaveDistributedQueryCampaign(campaign))
+		retrieved, err := ds.DistributedQueryCampaign(campaign.ID)
+		require.Nil(t, err)
+		assert.True(t, retrieved.AllowResubmission)
+	}
+
 	h1 := test.NewHost(t, ds, "foo.local", "192.168.1.10", "1", "1", mockClock.Now())
 	h2 := test.NewHost(t, ds, "bar.local", "192.168.1.11", "2", "2", mockClock.Now().Add(-1*time.Hour))
 	h3 := test.NewHost(t, ds, "baz.local", "192.168.1.12", "3", "3", mockClock.Now().Add(-13*time.Minute))
@@ -162,3 +179,150 @@ func testCleanupDistributedQueryCampaigns(t *testing.T, ds kolide.Datastore) {
 	}
 
 }
+
+func testPurgeCompletedCampaigns(t *testing.T, ds kolide.Datastore) {
+	user := test.NewUser(t, ds, "Zach", "zwass", "zwass@kolide.co", true)
+
+	mockClock := clock.NewMockClock()
+
+	query := test.NewQuery(t, ds, "test", "select * from time", user.ID, false)
+	h1 := test.NewHost(t, ds, "1", "", "1", "1", mockClock.Now())
+
+	c1 := test.NewCampaign(t, ds, query.ID, kolide.QueryComplete, mockClock.Now())
+	c2 := test.NewCampaign(t, ds, query.ID, kolide.QueryRunning, mockClock.Now())
+	test.AddHostToCampaign(t, ds, c1.ID, h1.ID)
+	test.AddHostToCampaign(t, ds, c2.ID, h1.ID)
+	test.NewExecution(t, ds, c2.ID, h1.ID)
+
+	mockClock.AddTime(time.Hour)
+	c3 := test.NewCampaign(t, ds, query.ID, kolide.QueryComplete, mockClock.Now())
+
+	// Nothing was created before the cutoff
+	purged, err := ds.PurgeCompletedCampaigns(mockClock.Now().Add(-2 * time.Hour))
+	require.Nil(t, err)
+	assert.Equal(t, uint(0), purged)
+
+	// Only the completed campaign created before the cutoff is purged
+	purged, err = ds.PurgeCompletedCampaigns(mockClock.Now().Add(-time.Minute))
+	require.Nil(t, err)
+	assert.Equal(t, uint(1), purged)
+
+	_, err = ds.DistributedQueryCampaign(c1.ID)
+	assert.True(t, kolide.IsNotFound(err))
+	checkTargets(t, ds, c1.ID, []uint{}, []uint{})
+
+	_, err = ds.DistributedQueryCampaign(c2.ID)
+	assert.Nil(t, err)
+	checkTargets(t, ds, c2.ID, []uint{h1.ID}, []uint{})
+	_, err = ds.DistributedQueryCampaign(c3.ID)
+	assert.Nil(t, err)
+}
+
+func testCompleteDistributedQueryCampaign(t *testing.T, ds kolide.Datastore) {
+	user := test.NewUser(t, ds, "Zach", "zwass", "zwass@kolide.co", true)
+
+	mockClock := clock.NewMockClock()
+
+	query := test.NewQuery(t, ds, "test", "select * from time", user.ID, false)
+	campaign := test.NewCampaign(t, ds, query.ID, kolide.QueryRunning, mockClock.Now())
+	h1 := test.NewHost(t, ds, "1", "", "1", "1", mockClock.Now())
+	h2 := test.NewHost(t, ds, "2", "", "2", "2", mockClock.Now())
+
+	summary, err := ds.DistributedQueryCampaignSummary(campaign.ID)
+	require.Nil(t, err)
+	assert.Equal(t, kolide.DistributedQueryCampaignSummary{}, *summary)
+
+	_, err = ds.NewDistributedQueryExecution(&kolide.DistributedQueryExecution{
+		HostID:                     h1.ID,
+		DistributedQueryCampaignID: campaign.ID,
+		Status:                     kolide.ExecutionSucceeded,
+		RowCount:                   3,
+	})
+	require.Nil(t, err)
+	_, err = ds.NewDistributedQueryExecution(&kolide.DistributedQueryExecution{
+		HostID:                     h2.ID,
+		DistributedQueryCampaignID: campaign.ID,
+		Status:                     kolide.ExecutionFailed,
+	})
+	require.Nil(t, err)
+
+	executed, err := ds.DistributedQueryExecutionExists(campaign.ID, h1.ID)
+	require.Nil(t, err)
+	assert.True(t, executed)
+	executed, err = ds.DistributedQueryExecutionExists(campaign.ID+1, h1.ID)
+	require.Nil(t, err)
+	assert.False(t, executed)
+
+	summary, err = ds.DistributedQueryCampaignSummary(campaign.ID)
+	require.Nil(t, err)
+	assert.Equal(t, kolide.DistributedQueryCampaignSummary{HostsResponded: 2, HostsFailed: 1, Rows: 3}, *summary)
+
+	// Only the first call completes the campaign
+	completed, err := ds.CompleteDistributedQueryCampaign(campaign.ID)
+	require.Nil(t, err)
+	assert.True(t, completed)
+	completed, err = ds.CompleteDistributedQueryCampaign(campaign.ID)
+	require.Nil(t, err)
+	assert.False(t, completed)
+
+	retrieved, err := ds.DistributedQueryCampaign(campaign.ID)
+	require.Nil(t, err)
+	assert.Equal(t, kolide.QueryComplete, retrieved.Status)
+
+	_, err = ds.CompleteDistributedQueryCampaign(campaign.ID + 100)
+	assert.True(t, kolide.IsNotFound(err))
+}
+
+func testMarkDistributedQueryCampaignDisconnected(t *testing.T, ds kolide.Datastore) {
+	user := test.NewUser(t, ds, "Zach", "zwass", "zwass@kolide.co", true)
+
+	mockClock := clock.NewMockClock()
+
+	query := test.NewQuery(t, ds, "test", "select * from time", user.ID, false)
+	campaign := test.NewCampaign(t, ds, query.ID, kolide.QueryRunning, mockClock.Now())
+	assert.Nil(t, campaign.DisconnectedAt)
+
+	disconnectedAt := mockClock.Now().UTC().Truncate(time.Second)
+	err := ds.MarkDistributedQueryCampaignDisconnected(campaign.ID, &disconnectedAt)
+	require.Nil(t, err)
+	retrieved, err := ds.DistributedQueryCampaign(campaign.ID)
+	require.Nil(t, err)
+	require.NotNil(t, retrieved.DisconnectedAt)
+	assert.True(t, disconnectedAt.Equal(*retrieved.DisconnectedAt))
+
+	// Clearing the disconnect time leaves the campaign running
+	err = ds.MarkDistributedQueryCampaignDisconnected(campaign.ID, nil)
+	require.Nil(t, err)
+	retrieved, err = ds.DistributedQueryCampaign(campaign.ID)
+	require.Nil(t, err)
+	assert.Nil(t, retrieved.DisconnectedAt)
+	assert.Equal(t, kolide.QueryRunning, retrieved.Status)
+
+	err = ds.MarkDistributedQueryCampaignDisconnected(campaign.ID+100, nil)
+	assert.True(t, kolide.IsNotFound(err))
+}
+
+func testListActiveDistributedQueryCampaigns(t *testing.T, ds kolide.Datastore) {
+	user := test.NewUser(t, ds, "Zach", "zwass", "zwass@kolide.co", true)
+
+	mockClock := clock.NewMockClock()
+
+	query := test.NewQuery(t, ds, "test", "select * from time", user.ID, false)
+	waiting := test.NewCampaign(t, ds, query.ID, kolide.QueryWaiting, mockClock.Now())
+	running := test.NewCampaign(t, ds, query.ID, kolide.QueryRunning, mockClock.Now().Add(time.Second))
+	test.NewCampaign(t, ds, query.ID, kolide.QueryComplete, mockClock.Now())
+
+	campaigns, err := ds.ListActiveDistributedQueryCampaigns()
+	require.Nil(t, err)
+	require.Len(t, campaigns, 2)
+	assert.Equal(t, waiting.ID, campaigns[0].ID)
+	assert.Equal(t, running.ID, campaigns[1].ID)
+
+	_, err = ds.CompleteDistributedQueryCampaign(waiting.ID)
+	require.Nil(t, err)
+
+	campaigns, err = ds.ListActiveDistributedQueryCampaigns()
+	require.Nil(t, err)
+	require.Len(t, campaigns, 1)
+	assert.Equal(t, running.ID, campaigns[0].ID)
+}
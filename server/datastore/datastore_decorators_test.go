@@ -0,0 +1,55 @@
+package datastore
+
+import (
+	"testing"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDecorators(t *testing.T, ds kolide.Datastore) {
+	decs, err := ds.ListDecorators()
+	require.Nil(t, err)
+	assert.Len(t, decs, 0)
+
+	load, err := ds.NewDecorator(&kolide.Decorator{
+		Name:  "asset tag",
+		Type:  kolide.DecoratorLoad,
+		Query: "SELECT tag FROM asset_tag;",
+	})
+	require.Nil(t, err)
+	assert.NotZero(t, load.ID)
+
+	interval, err := ds.NewDecorator(&kolide.Decorator{
+		Name:     "uptime",
+		Type:     kolide.DecoratorInterval,
+		Interval: 3600,
+		Query:    "SELECT total_seconds AS uptime FROM uptime;",
+	})
+	require.Nil(t, err)
+
+	dec, err := ds.Decorator(interval.ID)
+	require.Nil(t, err)
+	assert.Equal(t, kolide.DecoratorInterval, dec.Type)
+	assert.Equal(t, uint(3600), dec.Interval)
+	assert.Equal(t, "uptime", dec.Name)
+
+	dec.Interval = 60
+	require.Nil(t, ds.SaveDecorator(dec))
+	dec, err = ds.Decorator(interval.ID)
+	require.Nil(t, err)
+	assert.Equal(t, uint(60), dec.Interval)
+
+	decs, err = ds.ListDecorators()
+	require.Nil(t, err)
+	require.Len(t, decs, 2)
+	assert.Equal(t, "asset tag", decs[0].Name)
+	assert.Equal(t, "uptime", decs[1].Name)
+
+	require.Nil(t, ds.DeleteDecorator(load.ID))
+	_, err = ds.Decorator(load.ID)
+	assert.True(t, kolide.IsNotFound(err))
+	assert.True(t, kolide.IsNotFound(ds.DeleteDecorator(load.ID)))
+	assert.True(t, kolide.IsNotFound(ds.SaveDecorator(&kolide.Decorator{ID: load.ID})))
+}
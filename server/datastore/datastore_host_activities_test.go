@@ -0,0 +1,71 @@
+package datastore
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testHostActivities(t *testing.T, ds kolide.Datastore) {
+	h, err := ds.NewHost(&kolide.Host{
+		DetailUpdateTime: time.Now(),
+		SeenTime:         time.Now(),
+		OsqueryHostID:    "foobar",
+		NodeKey:          "nodekey",
+		UUID:             "uuid",
+		HostName:         "foobar.local",
+	})
+	require.Nil(t, err)
+
+	activities, err := ds.ListHostActivities(h.ID, kolide.HostActivityListOptions{})
+	require.Nil(t, err)
+	assert.Empty(t, activities)
+
+	enrolled := time.Date(2020, 7, 1, 8, 0, 0, 0, time.UTC)
+	details := json.RawMessage(`{"label_id":1,"label_name":"macOS"}`)
+	for i, a := range []*kolide.HostActivity{
+		{Type: kolide.HostActivityEnrolled},
+		{Type: kolide.HostActivityLabelAdded, Details: &details},
+		{Type: kolide.HostActivityRebooted},
+	} {
+		a.HostID = h.ID
+		a.CreatedAt = enrolled.Add(time.Duration(i) * time.Hour)
+		_, err = ds.NewHostActivity(a)
+		require.Nil(t, err)
+		assert.NotZero(t, a.ID)
+	}
+
+	activities, err = ds.ListHostActivities(h.ID, kolide.HostActivityListOptions{
+		ListOptions: kolide.ListOptions{OrderKey: "id", OrderDirection: kolide.OrderDescending},
+	})
+	require.Nil(t, err)
+	require.Len(t, activities, 3)
+	assert.Equal(t, kolide.HostActivityRebooted, activities[0].Type)
+	assert.Equal(t, kolide.HostActivityLabelAdded, activities[1].Type)
+	require.NotNil(t, activities[1].Details)
+	assert.JSONEq(t, string(details), string(*activities[1].Details))
+	assert.Equal(t, kolide.HostActivityEnrolled, activities[2].Type)
+	assert.Equal(t, enrolled, activities[2].CreatedAt.UTC())
+
+	activities, err = ds.ListHostActivities(h.ID, kolide.HostActivityListOptions{
+		ListOptions: kolide.ListOptions{PerPage: 2, Page: 1, OrderKey: "id"},
+	})
+	require.Nil(t, err)
+	require.Len(t, activities, 1)
+	assert.Equal(t, kolide.HostActivityRebooted, activities[0].Type)
+
+	activities, err = ds.ListHostActivities(h.ID, kolide.HostActivityListOptions{
+		Types: []string{kolide.HostActivityEnrolled, kolide.HostActivityRebooted},
+	})
+	require.Nil(t, err)
+	assert.Len(t, activities, 2)
+
+	// Activities are not shared between hosts
+	activities, err = ds.ListHostActivities(h.ID+1, kolide.HostActivityListOptions{})
+	require.Nil(t, err)
+	assert.Empty(t, activities)
+}
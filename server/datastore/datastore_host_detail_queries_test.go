@@ -0,0 +1,83 @@
+package datastore
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testHostDetailQueries(t *testing.T, ds kolide.Datastore) {
+	queries, err := ds.ListHostDetailQueries()
+	require.Nil(t, err)
+	assert.Len(t, queries, 0)
+
+	chassis, err := ds.NewHostDetailQuery(&kolide.HostDetailQuery{
+		Name:  "chassis",
+		Query: "SELECT chassis_type FROM system_info;",
+	})
+	require.Nil(t, err)
+	assert.NotZero(t, chassis.ID)
+
+	_, err = ds.NewHostDetailQuery(&kolide.HostDetailQuery{
+		Name:  "chassis",
+		Query: "SELECT 1;",
+	})
+	assert.NotNil(t, err)
+
+	tag, err := ds.NewHostDetailQuery(&kolide.HostDetailQuery{
+		Name:  "asset_tag",
+		Query: "SELECT tag FROM asset_tag;",
+	})
+	require.Nil(t, err)
+
+	queries, err = ds.ListHostDetailQueries()
+	require.Nil(t, err)
+	require.Len(t, queries, 2)
+	assert.Equal(t, "asset_tag", queries[0].Name)
+	assert.Equal(t, "chassis", queries[1].Name)
+
+	require.Nil(t, ds.DeleteHostDetailQuery(tag.ID))
+	err = ds.DeleteHostDetailQuery(tag.ID)
+	assert.True(t, kolide.IsNotFound(err))
+
+	queries, err = ds.ListHostDetailQueries()
+	require.Nil(t, err)
+	require.Len(t, queries, 1)
+	assert.Equal(t, chassis.ID, queries[0].ID)
+}
+
+func testHostExtraDetails(t *testing.T, ds kolide.Datastore) {
+	h, err := ds.NewHost(&kolide.Host{
+		DetailUpdateTime: time.Now(),
+		SeenTime:         time.Now(),
+		OsqueryHostID:    "foobar",
+		NodeKey:          "nodekey",
+		UUID:             "uuid",
+		HostName:         "foobar.local",
+	})
+	require.Nil(t, err)
+
+	h, err = ds.Host(h.ID)
+	require.Nil(t, err)
+	assert.Nil(t, h.ExtraDetails)
+
+	details := json.RawMessage(`{"chassis": {"chassis_type": "Laptop"}}`)
+	h.ExtraDetails = &details
+	require.Nil(t, ds.SaveHost(h))
+
+	// Saving a host without extra details leaves them unchanged
+	h, err = ds.AuthenticateHost("nodekey")
+	require.Nil(t, err)
+	h.HostName = "baz.local"
+	require.Nil(t, ds.SaveHost(h))
+
+	h, err = ds.Host(h.ID)
+	require.Nil(t, err)
+	assert.Equal(t, "baz.local", h.HostName)
+	require.NotNil(t, h.ExtraDetails)
+	assert.JSONEq(t, string(details), string(*h.ExtraDetails))
+}
@@ -0,0 +1,68 @@
+package datastore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testHostUptimeEvents(t *testing.T, ds kolide.Datastore) {
+	h, err := ds.NewHost(&kolide.Host{
+		DetailUpdateTime: time.Now(),
+		SeenTime:         time.Now(),
+		OsqueryHostID:    "foobar",
+		NodeKey:          "nodekey",
+		UUID:             "uuid",
+		HostName:         "foobar.local",
+	})
+	require.Nil(t, err)
+
+	events, err := ds.ListHostUptimeEvents(h.ID)
+	require.Nil(t, err)
+	assert.Empty(t, events)
+
+	first := time.Date(2020, 7, 1, 8, 0, 0, 0, time.UTC)
+	second := first.Add(24 * time.Hour)
+	third := second.Add(24 * time.Hour)
+	_, err = ds.NewHostUptimeEvent(&kolide.HostUptimeEvent{
+		HostID:           h.ID,
+		BootTime:         second,
+		PreviousBootTime: first,
+		DetectedAt:       second.Add(time.Minute),
+	})
+	require.Nil(t, err)
+	_, err = ds.NewHostUptimeEvent(&kolide.HostUptimeEvent{
+		HostID:           h.ID,
+		BootTime:         third,
+		PreviousBootTime: second,
+		DetectedAt:       third.Add(time.Minute),
+	})
+	require.Nil(t, err)
+
+	events, err = ds.ListHostUptimeEvents(h.ID)
+	require.Nil(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, third, events[0].BootTime.UTC())
+	assert.Equal(t, second, events[0].PreviousBootTime.UTC())
+	assert.Equal(t, second, events[1].BootTime.UTC())
+
+	events, err = ds.ListHostUptimeEvents(h.ID + 1)
+	require.Nil(t, err)
+	assert.Empty(t, events)
+
+	// The boot time of the host is kept when it is saved without one
+	h, err = ds.AuthenticateHost("nodekey")
+	require.Nil(t, err)
+	assert.Nil(t, h.BootTime)
+	h.BootTime = &third
+	require.Nil(t, ds.SaveHost(h))
+	h.BootTime = nil
+	require.Nil(t, ds.SaveHost(h))
+	h, err = ds.AuthenticateHost("nodekey")
+	require.Nil(t, err)
+	require.NotNil(t, h.BootTime)
+	assert.Equal(t, third, h.BootTime.UTC())
+}
@@ -2,6 +2,7 @@ package datastore
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 	"strconv"
@@ -142,6 +143,156 @@ func testDeleteHost(t *testing.T, ds kolide.Datastore) {
 	assert.NotNil(t, err)
 }
 
+func testDeleteHostsByLabel(t *testing.T, ds kolide.Datastore) {
+	var hosts []*kolide.Host
+	for i := 0; i < 3; i++ {
+		h, err := ds.NewHost(&kolide.Host{
+			DetailUpdateTime: time.Now(),
+			SeenTime:         time.Now(),
+			OsqueryHostID:    strconv.Itoa(i),
+			NodeKey:          strconv.Itoa(i),
+			UUID:             strconv.Itoa(i),
+			HostName:         fmt.Sprintf("foo%d.local", i),
+		})
+		require.Nil(t, err)
+		hosts = append(hosts, h)
+	}
+
+	l1 := &kolide.LabelSpec{
+		ID:    1,
+		Name:  "label foo",
+		Query: "query1",
+	}
+	require.Nil(t, ds.ApplyLabelSpecs([]*kolide.LabelSpec{l1}))
+
+	require.Nil(t, ds.RecordLabelQueryExecutions(hosts[0], map[uint]bool{l1.ID: true}, time.Now()))
+	require.Nil(t, ds.RecordLabelQueryExecutions(hosts[1], map[uint]bool{l1.ID: true}, time.Now()))
+	require.Nil(t, ds.RecordLabelQueryExecutions(hosts[2], map[uint]bool{l1.ID: false}, time.Now()))
+
+	deleted, err := ds.DeleteHostsByLabel(l1.ID)
+	require.Nil(t, err)
+	assert.Equal(t, 2, deleted)
+
+	_, err = ds.Host(hosts[0].ID)
+	assert.NotNil(t, err)
+	_, err = ds.Host(hosts[1].ID)
+	assert.NotNil(t, err)
+	_, err = ds.Host(hosts[2].ID)
+	assert.Nil(t, err)
+
+	deleted, err = ds.DeleteHostsByLabel(l1.ID)
+	require.Nil(t, err)
+	assert.Equal(t, 0, deleted)
+
+	// The members of manual labels are deleted too
+	manual, err := ds.NewLabel(&kolide.Label{
+		Name:      "decommissioned",
+		LabelType: kolide.LabelTypeManual,
+	})
+	require.Nil(t, err)
+	h, err := ds.NewHost(&kolide.Host{
+		DetailUpdateTime: time.Now(),
+		SeenTime:         time.Now(),
+		OsqueryHostID:    "manual",
+		NodeKey:          "manual",
+		UUID:             "manual",
+		HostName:         "manual.local",
+	})
+	require.Nil(t, err)
+	require.Nil(t, ds.AddHostsToLabel(manual.ID, []uint{h.ID}))
+
+	deleted, err = ds.DeleteHostsByLabel(manual.ID)
+	require.Nil(t, err)
+	assert.Equal(t, 1, deleted)
+	_, err = ds.Host(h.ID)
+	assert.NotNil(t, err)
+	_, err = ds.Host(hosts[2].ID)
+	assert.Nil(t, err)
+
+	// And the hosts matching the criteria of computed labels
+	h, err = ds.NewHost(&kolide.Host{
+		DetailUpdateTime: time.Now(),
+		SeenTime:         time.Now(),
+		OsqueryHostID:    "computed",
+		NodeKey:          "computed",
+		UUID:             "computed",
+		HostName:         "computed.local",
+		Platform:         "windows",
+	})
+	require.Nil(t, err)
+	computed, err := ds.NewLabel(&kolide.Label{
+		Name:      "windows",
+		LabelType: kolide.LabelTypeComputed,
+		Criteria:  &kolide.LabelCriteria{Platform: "windows"},
+	})
+	require.Nil(t, err)
+
+	deleted, err = ds.DeleteHostsByLabel(computed.ID)
+	require.Nil(t, err)
+	assert.Equal(t, 1, deleted)
+	_, err = ds.Host(h.ID)
+	assert.NotNil(t, err)
+	_, err = ds.Host(hosts[2].ID)
+	assert.Nil(t, err)
+}
+
+func testAggregateHosts(t *testing.T, ds kolide.Datastore) {
+	var hosts []*kolide.Host
+	for i, platform := range []string{"darwin", "ubuntu", "darwin", "windows"} {
+		h, err := ds.NewHost(&kolide.Host{
+			DetailUpdateTime: time.Now(),
+			SeenTime:         time.Now(),
+			OsqueryHostID:    strconv.Itoa(i),
+			NodeKey:          strconv.Itoa(i),
+			UUID:             strconv.Itoa(i),
+			HostName:         fmt.Sprintf("foo%d.local", i),
+			Platform:         platform,
+			OsqueryVersion:   "4.4.0",
+		})
+		require.Nil(t, err)
+		hosts = append(hosts, h)
+	}
+
+	aggregates, err := ds.AggregateHosts("platform")
+	require.Nil(t, err)
+	assert.Equal(t, []kolide.HostAggregate{
+		{Value: "darwin", Count: 2},
+		{Value: "ubuntu", Count: 1},
+		{Value: "windows", Count: 1},
+	}, aggregates)
+
+	aggregates, err = ds.AggregateHosts("osquery_version")
+	require.Nil(t, err)
+	assert.Equal(t, []kolide.HostAggregate{{Value: "4.4.0", Count: 4}}, aggregates)
+
+	l1 := &kolide.LabelSpec{ID: 1, Name: "label foo", Query: "query1"}
+	l2 := &kolide.LabelSpec{ID: 2, Name: "label bar", Query: "query2"}
+	require.Nil(t, ds.ApplyLabelSpecs([]*kolide.LabelSpec{l1, l2}))
+	require.Nil(t, ds.RecordLabelQueryExecutions(hosts[0], map[uint]bool{l1.ID: true, l2.ID: true}, time.Now()))
+	require.Nil(t, ds.RecordLabelQueryExecutions(hosts[1], map[uint]bool{l1.ID: true, l2.ID: false}, time.Now()))
+	require.Nil(t, ds.RecordLabelQueryExecutions(hosts[2], map[uint]bool{l1.ID: true}, time.Now()))
+
+	aggregates, err = ds.AggregateHosts(kolide.HostAggregateLabel)
+	require.Nil(t, err)
+	assert.Equal(t, []kolide.HostAggregate{
+		{Value: "label foo", Count: 3},
+		{Value: "label bar", Count: 1},
+	}, aggregates)
+
+	// Deleted hosts are not counted
+	require.Nil(t, ds.DeleteHost(hosts[0].ID))
+	aggregates, err = ds.AggregateHosts("platform")
+	require.Nil(t, err)
+	assert.Equal(t, []kolide.HostAggregate{
+		{Value: "darwin", Count: 1},
+		{Value: "ubuntu", Count: 1},
+		{Value: "windows", Count: 1},
+	}, aggregates)
+
+	_, err = ds.AggregateHosts("node_key; DROP TABLE hosts")
+	assert.NotNil(t, err)
+}
+
 func testIdempotentDeleteHost(t *testing.T, ds kolide.Datastore) {
 	host, err := ds.NewHost(&kolide.Host{
 		DetailUpdateTime: time.Now(),
@@ -204,7 +355,7 @@ func testListHost(t *testing.T, ds kolide.Datastore) {
 	err = ds.SaveHost(hosts[3])
 	require.Nil(t, err)
 
-	hosts2, err := ds.ListHosts(kolide.ListOptions{})
+	hosts2, err := ds.ListHosts(kolide.HostListOptions{})
 	require.Nil(t, err)
 	assert.Equal(t, len(hosts), len(hosts2))
 
@@ -215,7 +366,7 @@ func testListHost(t *testing.T, ds kolide.Datastore) {
 	assert.Equal(t, "en2", hosts2[3].NetworkInterfaces[0].Interface)
 
 	// Test with logic for only a few hosts
-	hosts2, err = ds.ListHosts(kolide.ListOptions{PerPage: 4, Page: 0})
+	hosts2, err = ds.ListHosts(kolide.HostListOptions{ListOptions: kolide.ListOptions{PerPage: 4, Page: 0}})
 	require.Nil(t, err)
 	assert.Equal(t, 4, len(hosts2))
 
@@ -227,11 +378,11 @@ func testListHost(t *testing.T, ds kolide.Datastore) {
 
 	err = ds.DeleteHost(hosts[0].ID)
 	require.Nil(t, err)
-	hosts2, err = ds.ListHosts(kolide.ListOptions{})
+	hosts2, err = ds.ListHosts(kolide.HostListOptions{})
 	require.Nil(t, err)
 	assert.Equal(t, len(hosts)-1, len(hosts2))
 
-	hosts, err = ds.ListHosts(kolide.ListOptions{})
+	hosts, err = ds.ListHosts(kolide.HostListOptions{})
 	require.Nil(t, err)
 	require.Equal(t, len(hosts2), len(hosts))
 	hosts[0].NetworkInterfaces = []*kolide.NetworkInterface{
@@ -247,7 +398,7 @@ func testListHost(t *testing.T, ds kolide.Datastore) {
 
 	err = ds.SaveHost(hosts[0])
 	require.Nil(t, err)
-	hosts2, err = ds.ListHosts(kolide.ListOptions{})
+	hosts2, err = ds.ListHosts(kolide.HostListOptions{})
 	require.Nil(t, err)
 	require.Equal(t, hosts[0].ID, hosts2[0].ID)
 	assert.Equal(t, len(hosts[0].NetworkInterfaces), len(hosts2[0].NetworkInterfaces))
@@ -255,10 +406,174 @@ func testListHost(t *testing.T, ds kolide.Datastore) {
 	assert.Equal(t, hosts[0].ID, hosts2[0].NetworkInterfaces[0].HostID)
 }
 
+func testListHostsSeenStatus(t *testing.T, ds kolide.Datastore) {
+	_, err := ds.NewHost(&kolide.Host{
+		DetailUpdateTime: time.Now(),
+		SeenTime:         time.Now(),
+		OsqueryHostID:    "1",
+		NodeKey:          "1",
+		UUID:             "1",
+		HostName:         "online.local",
+	})
+	require.Nil(t, err)
+	_, err = ds.NewHost(&kolide.Host{
+		DetailUpdateTime: time.Now(),
+		SeenTime:         time.Now().Add(-2 * time.Hour),
+		OsqueryHostID:    "2",
+		NodeKey:          "2",
+		UUID:             "2",
+		HostName:         "offline.local",
+	})
+	require.Nil(t, err)
+
+	hosts, err := ds.ListHosts(kolide.HostListOptions{
+		SeenStatus: kolide.StatusOnline,
+		SeenWithin: time.Hour,
+	})
+	require.Nil(t, err)
+	require.Len(t, hosts, 1)
+	assert.Equal(t, "online.local", hosts[0].HostName)
+
+	hosts, err = ds.ListHosts(kolide.HostListOptions{
+		SeenStatus: kolide.StatusOffline,
+		SeenWithin: time.Hour,
+	})
+	require.Nil(t, err)
+	require.Len(t, hosts, 1)
+	assert.Equal(t, "offline.local", hosts[0].HostName)
+
+	hosts, err = ds.ListHosts(kolide.HostListOptions{})
+	require.Nil(t, err)
+	assert.Len(t, hosts, 2)
+
+	count, err := ds.CountHosts(kolide.HostListOptions{
+		SeenStatus: kolide.StatusOnline,
+		SeenWithin: time.Hour,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	// Pagination does not affect the count
+	count, err = ds.CountHosts(kolide.HostListOptions{
+		ListOptions: kolide.ListOptions{PerPage: 1, Page: 1},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func testListHostsMatchQuery(t *testing.T, ds kolide.Datastore) {
+	newHost := func(id, hostName, uuid, serial string) *kolide.Host {
+		host, err := ds.NewHost(&kolide.Host{
+			DetailUpdateTime: time.Now(),
+			SeenTime:         time.Now(),
+			OsqueryHostID:    id,
+			NodeKey:          id,
+			UUID:             uuid,
+			HostName:         hostName,
+		})
+		require.Nil(t, err)
+		host.HardwareSerial = serial
+		require.Nil(t, ds.SaveHost(host))
+		return host
+	}
+	newHost("1", "web-10.local", "uuid-web10", "C02ABC")
+	newHost("2", "web-1", "uuid-web1", "C03DEF")
+	db := newHost("3", "db.local", "uuid-db", "C04GHI")
+	db.NetworkInterfaces = []*kolide.NetworkInterface{
+		{HostID: db.ID, Interface: "en0", IPAddress: "10.0.0.5"},
+	}
+	require.Nil(t, ds.SaveHost(db))
+
+	hostNames := func(opt kolide.HostListOptions) []string {
+		hosts, err := ds.ListHosts(opt)
+		require.Nil(t, err)
+		names := []string{}
+		for _, host := range hosts {
+			names = append(names, host.HostName)
+		}
+		return names
+	}
+
+	// The exact match is ranked before the requested order
+	opt := kolide.HostListOptions{
+		ListOptions: kolide.ListOptions{OrderKey: "host_name", OrderDirection: kolide.OrderDescending},
+		MatchQuery:  "web-1",
+	}
+	assert.Equal(t, []string{"web-1", "web-10.local"}, hostNames(opt))
+	opt.MatchQuery = "uuid-web1"
+	assert.Equal(t, []string{"web-1", "web-10.local"}, hostNames(opt))
+
+	assert.Equal(t, []string{"web-10.local"}, hostNames(kolide.HostListOptions{MatchQuery: "C02"}))
+	assert.Equal(t, []string{"db.local"}, hostNames(kolide.HostListOptions{MatchQuery: "10.0.0"}))
+	// Only prefixes are matched
+	assert.Empty(t, hostNames(kolide.HostListOptions{MatchQuery: "local"}))
+
+	count, err := ds.CountHosts(kolide.HostListOptions{MatchQuery: "web"})
+	require.Nil(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func testStreamHosts(t *testing.T, ds kolide.Datastore) {
+	if ds.Name() == "inmem" {
+		t.Skip("inmem is being deprecated, test skipped")
+	}
+
+	var hosts []*kolide.Host
+	for i := 0; i < 4; i++ {
+		h, err := ds.NewHost(&kolide.Host{
+			DetailUpdateTime: time.Now(),
+			SeenTime:         time.Now(),
+			OsqueryHostID:    fmt.Sprint(i),
+			NodeKey:          fmt.Sprint(i),
+			UUID:             fmt.Sprint(i),
+			HostName:         fmt.Sprintf("host%d.local", i),
+		})
+		require.Nil(t, err)
+		hosts = append(hosts, h)
+	}
+	require.Nil(t, ds.DeleteHost(hosts[3].ID))
+
+	manual, err := ds.NewLabel(&kolide.Label{Name: "manual", LabelType: kolide.LabelTypeManual})
+	require.Nil(t, err)
+	require.Nil(t, ds.AddHostsToLabel(manual.ID, []uint{hosts[0].ID}))
+	query, err := ds.NewLabel(&kolide.Label{Name: "query", Query: "select 1"})
+	require.Nil(t, err)
+	require.Nil(t, ds.RecordLabelQueryExecutions(hosts[1], map[uint]bool{query.ID: true}, time.Now()))
+	require.Nil(t, ds.RecordLabelQueryExecutions(hosts[2], map[uint]bool{query.ID: false}, time.Now()))
+
+	streamedNames := func(opt kolide.HostListOptions) []string {
+		names := []string{}
+		err := ds.StreamHosts(opt, func(host *kolide.Host) error {
+			names = append(names, host.HostName)
+			return nil
+		})
+		require.Nil(t, err)
+		return names
+	}
+
+	assert.Equal(t, []string{"host0.local", "host1.local", "host2.local"}, streamedNames(kolide.HostListOptions{}))
+	assert.Equal(t, []string{"host0.local"}, streamedNames(kolide.HostListOptions{LabelIDs: []uint{manual.ID}}))
+	assert.Equal(t, []string{"host0.local", "host1.local"}, streamedNames(kolide.HostListOptions{LabelIDs: []uint{manual.ID, query.ID}}))
+	assert.Equal(t, []string{"host2.local"}, streamedNames(kolide.HostListOptions{MatchQuery: "host2"}))
+
+	count, err := ds.CountHosts(kolide.HostListOptions{LabelIDs: []uint{query.ID}})
+	require.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	// Errors from the callback stop the iteration
+	calls := 0
+	err = ds.StreamHosts(kolide.HostListOptions{}, func(host *kolide.Host) error {
+		calls++
+		return errors.New("write failed")
+	})
+	assert.EqualError(t, err, "write failed")
+	assert.Equal(t, 1, calls)
+}
+
 func testEnrollHost(t *testing.T, ds kolide.Datastore) {
 	var hosts []*kolide.Host
 	for _, tt := range enrollTests {
-		h, err := ds.EnrollHost(tt.uuid, tt.nodeKey, "default")
+		h, err := ds.EnrollHost(tt.uuid, tt.nodeKey, "default", 0, false)
 		require.Nil(t, err)
 
 		hosts = append(hosts, h)
@@ -268,9 +583,192 @@ func testEnrollHost(t *testing.T, ds kolide.Datastore) {
 
 }
 
+func testEnrollHostCooldown(t *testing.T, ds kolide.Datastore) {
+	h1, err := ds.EnrollHost("cooldown_host", "key1", "default", time.Hour, false)
+	require.Nil(t, err)
+	assert.Equal(t, "key1", h1.NodeKey)
+
+	// Re-enrolling within the cooldown keeps the existing node key
+	h2, err := ds.EnrollHost("cooldown_host", "key2", "default", time.Hour, false)
+	require.Nil(t, err)
+	assert.Equal(t, h1.ID, h2.ID)
+	assert.Equal(t, "key1", h2.NodeKey)
+
+	// Hosts with the same hardware UUID but another identifier do not get
+	// the node key of the existing host
+	h1.UUID = "hardware_uuid"
+	require.Nil(t, ds.SaveHost(h1))
+	h3, err := ds.EnrollHost("reinstalled_host", "key3", "default", time.Hour, false)
+	require.Nil(t, err)
+	assert.NotEqual(t, h1.ID, h3.ID)
+	assert.Equal(t, "key3", h3.NodeKey)
+
+	// Without a cooldown a new node key is always issued
+	h4, err := ds.EnrollHost("cooldown_host", "key4", "default", 0, false)
+	require.Nil(t, err)
+	assert.Equal(t, h1.ID, h4.ID)
+	assert.Equal(t, "key4", h4.NodeKey)
+
+	_, err = ds.AuthenticateHost("key1")
+	assert.NotNil(t, err)
+}
+
+func testHostDiskEncryption(t *testing.T, ds kolide.Datastore) {
+	encrypted, err := ds.EnrollHost("encrypted_host", "key1", "default", 0, false)
+	require.Nil(t, err)
+	unencrypted, err := ds.EnrollHost("unencrypted_host", "key2", "default", 0, false)
+	require.Nil(t, err)
+	unknown, err := ds.EnrollHost("unknown_host", "key3", "default", 0, false)
+	require.Nil(t, err)
+	assert.Equal(t, kolide.DiskEncryptionUnknown, unknown.DiskEncryption)
+
+	encrypted.DiskEncryption = kolide.DiskEncryptionEncrypted
+	require.Nil(t, ds.SaveHost(encrypted))
+	unencrypted.DiskEncryption = kolide.DiskEncryptionUnencrypted
+	require.Nil(t, ds.SaveHost(unencrypted))
+
+	authenticated, err := ds.AuthenticateHost("key1")
+	require.Nil(t, err)
+	assert.Equal(t, kolide.DiskEncryptionEncrypted, authenticated.DiskEncryption)
+
+	for status, host := range map[string]*kolide.Host{
+		kolide.DiskEncryptionEncrypted:   encrypted,
+		kolide.DiskEncryptionUnencrypted: unencrypted,
+		kolide.DiskEncryptionUnknown:     unknown,
+	} {
+		hosts, err := ds.ListHosts(kolide.HostListOptions{DiskEncryption: status})
+		require.Nil(t, err)
+		require.Len(t, hosts, 1, status)
+		assert.Equal(t, host.ID, hosts[0].ID, status)
+	}
+
+	aggregates, err := ds.AggregateHosts("disk_encryption")
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []kolide.HostAggregate{
+		{Value: kolide.DiskEncryptionEncrypted, Count: 1},
+		{Value: kolide.DiskEncryptionUnencrypted, Count: 1},
+		{Value: kolide.DiskEncryptionUnknown, Count: 1},
+	}, aggregates)
+}
+
+func testMergeHosts(t *testing.T, ds kolide.Datastore) {
+	keep, err := ds.EnrollHost("keep", "key1", "default", 0, false)
+	require.Nil(t, err)
+	dup1, err := ds.EnrollHost("dup1", "key2", "default", 0, false)
+	require.Nil(t, err)
+	dup2, err := ds.EnrollHost("dup2", "key3", "default", 0, false)
+	require.Nil(t, err)
+
+	l1, err := ds.NewLabel(&kolide.Label{Name: "l1", LabelType: kolide.LabelTypeManual})
+	require.Nil(t, err)
+	l2, err := ds.NewLabel(&kolide.Label{Name: "l2", LabelType: kolide.LabelTypeManual})
+	require.Nil(t, err)
+	require.Nil(t, ds.AddHostsToLabel(l1.ID, []uint{keep.ID, dup1.ID}))
+	require.Nil(t, ds.AddHostsToLabel(l2.ID, []uint{dup2.ID}))
+
+	_, err = ds.NewHostUptimeEvent(&kolide.HostUptimeEvent{HostID: dup1.ID, DetectedAt: time.Now()})
+	require.Nil(t, err)
+	_, err = ds.NewHostActivity(&kolide.HostActivity{HostID: dup2.ID, CreatedAt: time.Now(), Type: kolide.HostActivityEnrolled})
+	require.Nil(t, err)
+	// keep and dup1 both ran campaign 1
+	for _, exec := range []kolide.DistributedQueryExecution{
+		{HostID: keep.ID, DistributedQueryCampaignID: 1},
+		{HostID: dup1.ID, DistributedQueryCampaignID: 1},
+		{HostID: dup2.ID, DistributedQueryCampaignID: 2},
+	} {
+		exec := exec
+		_, err = ds.NewDistributedQueryExecution(&exec)
+		require.Nil(t, err)
+	}
+
+	// Nothing is merged when one of the hosts does not exist
+	_, err = ds.MergeHosts(keep.ID, []uint{dup1.ID, dup2.ID + 1})
+	require.NotNil(t, err)
+	_, err = ds.Host(dup1.ID)
+	require.Nil(t, err)
+	events, err := ds.ListHostUptimeEvents(keep.ID)
+	require.Nil(t, err)
+	assert.Empty(t, events)
+
+	merge, err := ds.MergeHosts(keep.ID, []uint{dup1.ID, dup2.ID})
+	require.Nil(t, err)
+	assert.Equal(t, kolide.HostMerge{
+		HostID:           keep.ID,
+		MergedHostIDs:    []uint{dup1.ID, dup2.ID},
+		LabelMemberships: 1,
+		UptimeEvents:     1,
+		Activities:       1,
+		QueryExecutions:  1,
+	}, merge)
+
+	for _, id := range []uint{dup1.ID, dup2.ID} {
+		_, err = ds.Host(id)
+		assert.NotNil(t, err)
+	}
+	for _, lid := range []uint{l1.ID, l2.ID} {
+		hosts, err := ds.ListHostsInLabel(lid)
+		require.Nil(t, err)
+		require.Len(t, hosts, 1)
+		assert.Equal(t, keep.ID, hosts[0].ID)
+	}
+	events, err = ds.ListHostUptimeEvents(keep.ID)
+	require.Nil(t, err)
+	assert.Len(t, events, 1)
+	activities, err := ds.ListHostActivities(keep.ID, kolide.HostActivityListOptions{})
+	require.Nil(t, err)
+	assert.Len(t, activities, 1)
+	executed, err := ds.DistributedQueryExecutionExists(2, keep.ID)
+	require.Nil(t, err)
+	assert.True(t, executed)
+	executed, err = ds.DistributedQueryExecutionExists(1, dup1.ID)
+	require.Nil(t, err)
+	assert.False(t, executed)
+}
+
+func testEnrollHostPending(t *testing.T, ds kolide.Datastore) {
+	pending, err := ds.EnrollHost("pending_host", "key1", "default", 0, true)
+	require.Nil(t, err)
+	assert.True(t, pending.Pending)
+	approved, err := ds.EnrollHost("approved_host", "key2", "default", 0, false)
+	require.Nil(t, err)
+	assert.False(t, approved.Pending)
+
+	authenticated, err := ds.AuthenticateHost("key1")
+	require.Nil(t, err)
+	assert.True(t, authenticated.Pending)
+
+	isPending := true
+	hosts, err := ds.ListHosts(kolide.HostListOptions{Pending: &isPending})
+	require.Nil(t, err)
+	require.Len(t, hosts, 1)
+	assert.Equal(t, pending.ID, hosts[0].ID)
+	count, err := ds.CountHosts(kolide.HostListOptions{Pending: &isPending})
+	require.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	// Existing hosts keep their approval when they re-enroll
+	h, err := ds.EnrollHost("approved_host", "key3", "default", 0, true)
+	require.Nil(t, err)
+	assert.False(t, h.Pending)
+
+	require.Nil(t, ds.ApproveHost(pending.ID))
+	h, err = ds.Host(pending.ID)
+	require.Nil(t, err)
+	assert.False(t, h.Pending)
+	hosts, err = ds.ListHosts(kolide.HostListOptions{Pending: &isPending})
+	require.Nil(t, err)
+	assert.Empty(t, hosts)
+
+	// A deleted host must be approved again
+	require.Nil(t, ds.DeleteHost(pending.ID))
+	h, err = ds.EnrollHost("pending_host", "key4", "default", 0, true)
+	require.Nil(t, err)
+	assert.True(t, h.Pending)
+}
+
 func testAuthenticateHost(t *testing.T, ds kolide.Datastore) {
 	for _, tt := range enrollTests {
-		h, err := ds.EnrollHost(tt.uuid, tt.nodeKey, "default")
+		h, err := ds.EnrollHost(tt.uuid, tt.nodeKey, "default", 0, false)
 		require.Nil(t, err)
 
 		returned, err := ds.AuthenticateHost(h.NodeKey)
@@ -474,6 +972,14 @@ func testDistributedQueriesForHost(t *testing.T, ds kolide.Datastore) {
 	target, err = ds.NewDistributedQueryCampaignTarget(target)
 	require.Nil(t, err)
 
+	// Also target h2 explicitly. It should receive the query only once.
+	_, err = ds.NewDistributedQueryCampaignTarget(&kolide.DistributedQueryCampaignTarget{
+		Type:                       kolide.TargetHost,
+		DistributedQueryCampaignID: c1.ID,
+		TargetID:                   h2.ID,
+	})
+	require.Nil(t, err)
+
 	// All should have the query now
 	queries, err = ds.DistributedQueriesForHost(h1)
 	require.Nil(t, err)
@@ -551,11 +1057,12 @@ func testGenerateHostStatusStatistics(t *testing.T, ds kolide.Datastore) {
 
 	mockClock := clock.NewMockClock()
 
-	online, offline, mia, new, err := ds.GenerateHostStatusStatistics(mockClock.Now())
+	online, offline, mia, maintenance, new, err := ds.GenerateHostStatusStatistics(mockClock.Now())
 	assert.Nil(t, err)
 	assert.Equal(t, uint(0), online)
 	assert.Equal(t, uint(0), offline)
 	assert.Equal(t, uint(0), mia)
+	assert.Equal(t, uint(0), maintenance)
 	assert.Equal(t, uint(0), new)
 
 	// Online
@@ -607,19 +1114,49 @@ func testGenerateHostStatusStatistics(t *testing.T, ds kolide.Datastore) {
 	})
 	require.Nil(t, err)
 
-	online, offline, mia, new, err = ds.GenerateHostStatusStatistics(mockClock.Now())
+	online, offline, mia, maintenance, new, err = ds.GenerateHostStatusStatistics(mockClock.Now())
 	assert.Nil(t, err)
 	assert.Equal(t, uint(2), online)
 	assert.Equal(t, uint(1), offline)
 	assert.Equal(t, uint(1), mia)
+	assert.Equal(t, uint(0), maintenance)
 	assert.Equal(t, uint(4), new)
 
-	online, offline, mia, new, err = ds.GenerateHostStatusStatistics(mockClock.Now().Add(1 * time.Hour))
+	online, offline, mia, maintenance, new, err = ds.GenerateHostStatusStatistics(mockClock.Now().Add(1 * time.Hour))
 	assert.Nil(t, err)
 	assert.Equal(t, uint(0), online)
 	assert.Equal(t, uint(3), offline)
 	assert.Equal(t, uint(1), mia)
+	assert.Equal(t, uint(0), maintenance)
+	assert.Equal(t, uint(4), new)
+
+	// Offline and MIA hosts in maintenance are counted separately, while
+	// online hosts in maintenance are still online
+	until := mockClock.Now().Add(2 * time.Hour)
+	require.Nil(t, ds.SetHostMaintenance(1, &until))
+	require.Nil(t, ds.SetHostMaintenance(3, &until))
+	require.Nil(t, ds.SetHostMaintenance(4, &until))
+
+	online, offline, mia, maintenance, new, err = ds.GenerateHostStatusStatistics(mockClock.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, uint(2), online)
+	assert.Equal(t, uint(0), offline)
+	assert.Equal(t, uint(0), mia)
+	assert.Equal(t, uint(2), maintenance)
 	assert.Equal(t, uint(4), new)
+
+	// Maintenance windows have no effect once they end, and are then
+	// cleared
+	online, offline, mia, maintenance, new, err = ds.GenerateHostStatusStatistics(mockClock.Now().Add(3 * time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, uint(3), offline)
+	assert.Equal(t, uint(1), mia)
+	assert.Equal(t, uint(0), maintenance)
+
+	require.Nil(t, ds.ClearElapsedHostMaintenance(mockClock.Now().Add(3*time.Hour)))
+	h, err = ds.Host(3)
+	require.Nil(t, err)
+	assert.Nil(t, h.MaintenanceUntil)
 }
 
 func testMarkHostSeen(t *testing.T, ds kolide.Datastore) {
@@ -773,6 +1310,33 @@ func testHostIDsByName(t *testing.T, ds kolide.Datastore) {
 	assert.Equal(t, hosts, []uint{2, 3, 6})
 }
 
+func testHostIDsByIdentifier(t *testing.T, ds kolide.Datastore) {
+	var hosts []*kolide.Host
+	for i := 0; i < 5; i++ {
+		h, err := ds.NewHost(&kolide.Host{
+			DetailUpdateTime: time.Now(),
+			SeenTime:         time.Now(),
+			OsqueryHostID:    fmt.Sprintf("host%d", i),
+			NodeKey:          fmt.Sprintf("%d", i),
+			UUID:             fmt.Sprintf("%d", i),
+			HostName:         "foo.local",
+		})
+		require.Nil(t, err)
+		hosts = append(hosts, h)
+	}
+	require.Nil(t, ds.DeleteHost(hosts[3].ID))
+
+	// Deleted hosts and unknown identifiers are not matched
+	ids, err := ds.HostIDsByIdentifier([]string{"host3", "host1", "host4", "foo.local"})
+	require.Nil(t, err)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	assert.Equal(t, []uint{hosts[1].ID, hosts[4].ID}, ids)
+
+	ids, err = ds.HostIDsByIdentifier(nil)
+	require.Nil(t, err)
+	assert.Empty(t, ids)
+}
+
 func testHostAdditional(t *testing.T, ds kolide.Datastore) {
 	_, err := ds.NewHost(&kolide.Host{
 		DetailUpdateTime: time.Now(),
@@ -842,3 +1406,140 @@ func testHostAdditional(t *testing.T, ds kolide.Datastore) {
 	require.Nil(t, err)
 	assert.Equal(t, additional, *h.Additional)
 }
+
+func testListHostsCursor(t *testing.T, ds kolide.Datastore) {
+	platforms := []string{"darwin", "ubuntu", "darwin", "windows", "ubuntu"}
+	for i, platform := range platforms {
+		_, err := ds.NewHost(&kolide.Host{
+			DetailUpdateTime: time.Now(),
+			SeenTime:         time.Now(),
+			OsqueryHostID:    strconv.Itoa(i),
+			NodeKey:          strconv.Itoa(i),
+			UUID:             strconv.Itoa(i),
+			HostName:         fmt.Sprintf("host%d.local", i),
+			Platform:         platform,
+		})
+		require.Nil(t, err)
+	}
+
+	for _, orderKey := range []string{"", "platform"} {
+		after := ""
+		seen := map[uint]bool{}
+		var lastPlatform string
+		for page := 0; page < len(platforms); page++ {
+			opt := kolide.HostListOptions{
+				ListOptions: kolide.ListOptions{
+					PerPage:        2,
+					OrderKey:       orderKey,
+					OrderDirection: kolide.OrderDescending,
+				},
+				After: &after,
+			}
+			hosts, err := ds.ListHosts(opt)
+			require.Nil(t, err)
+			for _, h := range hosts {
+				assert.False(t, seen[h.ID], "host %d returned twice", h.ID)
+				seen[h.ID] = true
+				if orderKey != "" && lastPlatform != "" {
+					assert.True(t, h.Platform <= lastPlatform)
+				}
+				lastPlatform = h.Platform
+			}
+			if len(hosts) < 2 {
+				break
+			}
+			after, err = kolide.NewHostCursor(hosts[len(hosts)-1], orderKey)
+			require.Nil(t, err)
+		}
+		assert.Len(t, seen, len(platforms), orderKey)
+	}
+}
+
+func testExpireHostDetails(t *testing.T, ds kolide.Datastore) {
+	host, err := ds.EnrollHost("1", "nodekey1", "default", 0, false)
+	require.Nil(t, err)
+	enrolledDetailUpdateTime := host.DetailUpdateTime
+
+	host.DetailUpdateTime = time.Now()
+	require.Nil(t, ds.SaveHost(host))
+
+	require.Nil(t, ds.ExpireHostDetails(host.ID))
+	host, err = ds.Host(host.ID)
+	require.Nil(t, err)
+	assert.True(t, host.DetailUpdateTime.Equal(enrolledDetailUpdateTime))
+}
+
+func testNoisyHosts(t *testing.T, ds kolide.Datastore) {
+	h1, err := ds.EnrollHost("1", "nodekey1", "default", 0, false)
+	require.Nil(t, err)
+	h2, err := ds.EnrollHost("2", "nodekey2", "default", 0, false)
+	require.Nil(t, err)
+	_, err = ds.EnrollHost("3", "nodekey3", "default", 0, false)
+	require.Nil(t, err)
+
+	hosts, err := ds.ListNoisyHosts()
+	require.Nil(t, err)
+	assert.Empty(t, hosts)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.Nil(t, ds.RecordNoisyHost(h1.ID, 10, now.Add(-time.Hour)))
+	require.Nil(t, ds.RecordNoisyHost(h2.ID, 5, now.Add(-time.Minute)))
+	require.Nil(t, ds.RecordNoisyHost(h1.ID, 3, now))
+
+	hosts, err = ds.ListNoisyHosts()
+	require.Nil(t, err)
+	require.Len(t, hosts, 2)
+	assert.Equal(t, h1.ID, hosts[0].ID)
+	assert.Equal(t, uint(13), hosts[0].DroppedLogRows)
+	require.NotNil(t, hosts[0].NoisyTime)
+	assert.True(t, hosts[0].NoisyTime.Equal(now))
+	assert.Equal(t, h2.ID, hosts[1].ID)
+	assert.Equal(t, uint(5), hosts[1].DroppedLogRows)
+
+	// Saving the host does not reset the noisy flag
+	require.Nil(t, ds.SaveHost(hosts[1]))
+	hosts, err = ds.ListNoisyHosts()
+	require.Nil(t, err)
+	assert.Len(t, hosts, 2)
+}
+
+func testRecordTruncatedResults(t *testing.T, ds kolide.Datastore) {
+	h1, err := ds.EnrollHost("1", "nodekey1", "default", 0, false)
+	require.Nil(t, err)
+	assert.Nil(t, h1.TruncatedResultsTime)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.Nil(t, ds.RecordTruncatedResults(h1.ID, 10, now.Add(-time.Hour)))
+	require.Nil(t, ds.RecordTruncatedResults(h1.ID, 3, now))
+
+	h1, err = ds.Host(h1.ID)
+	require.Nil(t, err)
+	assert.Equal(t, uint(13), h1.TruncatedResultRows)
+	require.NotNil(t, h1.TruncatedResultsTime)
+	assert.True(t, h1.TruncatedResultsTime.Equal(now))
+}
+
+func testSetHostsConfigRefresh(t *testing.T, ds kolide.Datastore) {
+	host, err := ds.EnrollHost("1", "nodekey1", "default", 0, false)
+	require.Nil(t, err)
+	assert.False(t, host.ConfigRefreshRequested)
+
+	// Unknown IDs are ignored
+	require.Nil(t, ds.SetHostsConfigRefresh([]uint{host.ID, 9999}, true))
+
+	host, err = ds.AuthenticateHost("nodekey1")
+	require.Nil(t, err)
+	assert.True(t, host.ConfigRefreshRequested)
+
+	// Saving the host does not clear a pending refresh
+	host.ConfigRefreshRequested = false
+	require.Nil(t, ds.SaveHost(host))
+	host, err = ds.Host(host.ID)
+	require.Nil(t, err)
+	assert.True(t, host.ConfigRefreshRequested)
+
+	require.Nil(t, ds.SetHostsConfigRefresh([]uint{host.ID}, false))
+	host, err = ds.Host(host.ID)
+	require.Nil(t, err)
+	assert.False(t, host.ConfigRefreshRequested)
+}
@@ -0,0 +1,69 @@
+package datastore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testIngestionFailures(t *testing.T, ds kolide.Datastore) {
+	h, err := ds.NewHost(&kolide.Host{
+		DetailUpdateTime: time.Now(),
+		SeenTime:         time.Now(),
+		OsqueryHostID:    "foobar",
+		NodeKey:          "nodekey",
+		UUID:             "uuid",
+		HostName:         "foobar.local",
+	})
+	require.Nil(t, err)
+
+	failures, err := ds.ListIngestionFailures(kolide.ListOptions{})
+	require.Nil(t, err)
+	assert.Empty(t, failures)
+
+	received := time.Date(2020, 8, 1, 8, 0, 0, 0, time.UTC)
+	for i, f := range []*kolide.IngestionFailure{
+		{QueryName: "os_version", Error: "expected single result got 0"},
+		{QueryName: "system_info", Error: `strconv.Atoi: parsing "a lot": invalid syntax`},
+		{QueryName: "uptime", Error: "expected single result got 2"},
+	} {
+		f.HostID = h.ID
+		f.CreatedAt = received.Add(time.Duration(i) * time.Hour)
+		_, err = ds.NewIngestionFailure(f)
+		require.Nil(t, err)
+		assert.NotZero(t, f.ID)
+	}
+
+	failures, err = ds.ListIngestionFailures(kolide.ListOptions{
+		OrderKey:       "id",
+		OrderDirection: kolide.OrderDescending,
+	})
+	require.Nil(t, err)
+	require.Len(t, failures, 3)
+	assert.Equal(t, "uptime", failures[0].QueryName)
+	assert.Equal(t, "system_info", failures[1].QueryName)
+	assert.Equal(t, `strconv.Atoi: parsing "a lot": invalid syntax`, failures[1].Error)
+	assert.Equal(t, h.ID, failures[1].HostID)
+	assert.Equal(t, "os_version", failures[2].QueryName)
+
+	failures, err = ds.ListIngestionFailures(kolide.ListOptions{PerPage: 1})
+	require.Nil(t, err)
+	assert.Len(t, failures, 1)
+
+	// Only the failures received before the cutoff are deleted
+	err = ds.CleanupIngestionFailures(received.Add(90 * time.Minute))
+	require.Nil(t, err)
+	failures, err = ds.ListIngestionFailures(kolide.ListOptions{OrderKey: "id"})
+	require.Nil(t, err)
+	require.Len(t, failures, 1)
+	assert.Equal(t, "uptime", failures[0].QueryName)
+
+	// Failures are deleted with their host
+	require.Nil(t, ds.DeleteHost(h.ID))
+	failures, err = ds.ListIngestionFailures(kolide.ListOptions{})
+	require.Nil(t, err)
+	assert.Empty(t, failures)
+}
@@ -17,7 +17,7 @@ func testLabels(t *testing.T, db kolide.Datastore) {
 	var host *kolide.Host
 	var err error
 	for i := 0; i < 10; i++ {
-		host, err = db.EnrollHost(string(i), string(i), "default")
+		host, err = db.EnrollHost(string(i), string(i), "default", 0, false)
 		require.Nil(t, err, "enrollment should succeed")
 		hosts = append(hosts, *host)
 	}
@@ -328,6 +328,200 @@ func testListHostsInLabel(t *testing.T, db kolide.Datastore) {
 	}
 }
 
+func testListHostsInComputedLabel(t *testing.T, db kolide.Datastore) {
+	h1, err := db.NewHost(&kolide.Host{
+		DetailUpdateTime: time.Now(),
+		SeenTime:         time.Now().Add(-48 * time.Hour),
+		OsqueryHostID:    "1",
+		NodeKey:          "1",
+		UUID:             "1",
+		HostName:         "foo.local",
+		Platform:         "darwin",
+	})
+	require.Nil(t, err)
+
+	h2, err := db.NewHost(&kolide.Host{
+		DetailUpdateTime: time.Now(),
+		SeenTime:         time.Now(),
+		OsqueryHostID:    "2",
+		NodeKey:          "2",
+		UUID:             "2",
+		HostName:         "bar.local",
+		Platform:         "darwin",
+	})
+	require.Nil(t, err)
+
+	err = db.ApplyLabelSpecs([]*kolide.LabelSpec{
+		{
+			Name:      "offline",
+			LabelType: kolide.LabelTypeComputed,
+			Criteria:  &kolide.LabelCriteria{OfflineFor: 24 * 60 * 60},
+		},
+		{
+			Name:  "sql",
+			Query: "select 1",
+		},
+	})
+	require.Nil(t, err)
+
+	spec, err := db.GetLabelSpec("offline")
+	require.Nil(t, err)
+	require.NotNil(t, spec.Criteria)
+	assert.Equal(t, uint(24*60*60), spec.Criteria.OfflineFor)
+
+	ids, err := db.LabelIDsByName([]string{"offline", "sql"})
+	require.Nil(t, err)
+	require.Len(t, ids, 2)
+	offlineID, sqlID := ids[0], ids[1]
+
+	hosts, err := db.ListHostsInLabel(offlineID)
+	require.Nil(t, err)
+	require.Len(t, hosts, 1)
+	assert.Equal(t, h1.ID, hosts[0].ID)
+
+	// Computed labels are not sent to hosts as queries
+	queries, err := db.LabelQueriesForHost(h1, time.Now())
+	require.Nil(t, err)
+	assert.Equal(t, map[string]string{fmt.Sprint(sqlID): "select 1"}, queries)
+
+	require.Nil(t, db.RecordLabelQueryExecutions(h2, map[uint]bool{sqlID: true}, time.Now()))
+	hosts, err = db.ListUniqueHostsInLabels([]uint{offlineID, sqlID})
+	require.Nil(t, err)
+	assert.Len(t, hosts, 2)
+
+	labels, err := db.ListLabelsForHost(h1.ID)
+	require.Nil(t, err)
+	require.Len(t, labels, 1)
+	assert.Equal(t, "offline", labels[0].Name)
+}
+
+func testManualLabelMembership(t *testing.T, db kolide.Datastore) {
+	var hosts []*kolide.Host
+	for i := 0; i < 3; i++ {
+		h, err := db.NewHost(&kolide.Host{
+			DetailUpdateTime: time.Now(),
+			SeenTime:         time.Now(),
+			OsqueryHostID:    fmt.Sprint(i),
+			NodeKey:          fmt.Sprint(i),
+			UUID:             fmt.Sprint(i),
+			HostName:         fmt.Sprintf("host%d.local", i),
+		})
+		require.Nil(t, err)
+		hosts = append(hosts, h)
+	}
+
+	label, err := db.NewLabel(&kolide.Label{
+		Name:      "golden image",
+		LabelType: kolide.LabelTypeManual,
+	})
+	require.Nil(t, err)
+
+	require.Nil(t, db.AddHostsToLabel(label.ID, []uint{hosts[0].ID, hosts[1].ID}))
+	// Adding an existing member is a no-op
+	require.Nil(t, db.AddHostsToLabel(label.ID, []uint{hosts[1].ID}))
+
+	members, err := db.ListHostsInLabel(label.ID)
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []uint{hosts[0].ID, hosts[1].ID}, hostIDs(members))
+
+	members, err = db.ListUniqueHostsInLabels([]uint{label.ID})
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []uint{hosts[0].ID, hosts[1].ID}, hostIDs(members))
+
+	ids, err := db.HostIDsInTargets(nil, []uint{label.ID})
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []uint{hosts[0].ID, hosts[1].ID}, ids)
+
+	labels, err := db.ListLabelsForHost(hosts[0].ID)
+	require.Nil(t, err)
+	require.Len(t, labels, 1)
+	assert.Equal(t, label.ID, labels[0].ID)
+
+	// Manual labels are not sent to hosts as queries
+	queries, err := db.LabelQueriesForHost(hosts[0], time.Now())
+	require.Nil(t, err)
+	assert.NotContains(t, queries, fmt.Sprint(label.ID))
+
+	require.Nil(t, db.RemoveHostsFromLabel(label.ID, []uint{hosts[0].ID, hosts[2].ID}))
+	members, err = db.ListHostsInLabel(label.ID)
+	require.Nil(t, err)
+	assert.Equal(t, []uint{hosts[1].ID}, hostIDs(members))
+
+	// Deleting a host removes it from the label
+	require.Nil(t, db.DeleteHost(hosts[1].ID))
+	members, err = db.ListHostsInLabel(label.ID)
+	require.Nil(t, err)
+	assert.Empty(t, members)
+
+	err = db.AddHostsToLabel(label.ID, []uint{hosts[1].ID})
+	assert.True(t, kolide.IsNotFound(err))
+}
+
+func testTransferHostsToLabel(t *testing.T, db kolide.Datastore) {
+	if db.Name() == "inmem" {
+		t.Skip("inmem is being deprecated, test skipped")
+	}
+
+	var hosts []*kolide.Host
+	for i := 0; i < 3; i++ {
+		h, err := db.NewHost(&kolide.Host{
+			DetailUpdateTime: time.Now(),
+			SeenTime:         time.Now(),
+			OsqueryHostID:    fmt.Sprint(i),
+			NodeKey:          fmt.Sprint(i),
+			UUID:             fmt.Sprint(i),
+			HostName:         fmt.Sprintf("host%d.local", i),
+		})
+		require.Nil(t, err)
+		hosts = append(hosts, h)
+	}
+
+	from, err := db.NewLabel(&kolide.Label{Name: "staging", LabelType: kolide.LabelTypeManual})
+	require.Nil(t, err)
+	to, err := db.NewLabel(&kolide.Label{Name: "production", LabelType: kolide.LabelTypeManual})
+	require.Nil(t, err)
+	require.Nil(t, db.AddHostsToLabel(from.ID, []uint{hosts[0].ID, hosts[1].ID, hosts[2].ID}))
+
+	label := "production"
+	require.Nil(t, db.ApplyEnrollSecretSpec(&kolide.EnrollSecretSpec{
+		Secrets: []kolide.EnrollSecret{{Name: "prod", Secret: "prod_secret", Active: true, Label: &label}},
+	}))
+
+	// Unknown hosts fail the transfer without changes
+	missing, err := db.TransferHostsToLabel(to.ID, []uint{hosts[0].ID, 9999})
+	require.Nil(t, err)
+	assert.Equal(t, []uint{9999}, missing)
+	members, err := db.ListHostsInLabel(from.ID)
+	require.Nil(t, err)
+	assert.Len(t, members, 3)
+
+	missing, err = db.TransferHostsToLabel(to.ID, []uint{hosts[0].ID, hosts[1].ID})
+	require.Nil(t, err)
+	assert.Empty(t, missing)
+
+	members, err = db.ListHostsInLabel(from.ID)
+	require.Nil(t, err)
+	assert.Equal(t, []uint{hosts[2].ID}, hostIDs(members))
+	members, err = db.ListHostsInLabel(to.ID)
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []uint{hosts[0].ID, hosts[1].ID}, hostIDs(members))
+
+	host, err := db.Host(hosts[0].ID)
+	require.Nil(t, err)
+	assert.Equal(t, "prod", host.EnrollSecretName)
+	host, err = db.Host(hosts[2].ID)
+	require.Nil(t, err)
+	assert.Equal(t, "", host.EnrollSecretName)
+}
+
+func hostIDs(hosts []kolide.Host) []uint {
+	ids := []uint{}
+	for _, h := range hosts {
+		ids = append(ids, h.ID)
+	}
+	return ids
+}
+
 func testBuiltInLabels(t *testing.T, db kolide.Datastore) {
 	require.Nil(t, db.MigrateData())
 
@@ -421,8 +615,9 @@ func setupLabelSpecsTest(t *testing.T, ds kolide.Datastore) []*kolide.LabelSpec
 			Platform:    "darwin",
 		},
 		&kolide.LabelSpec{
-			Name:  "bar",
-			Query: "select * from bar",
+			Name:                 "bar",
+			Query:                "select * from bar",
+			DetailUpdateInterval: 600,
 		},
 		&kolide.LabelSpec{
 			Name:  "bing",
@@ -485,10 +680,147 @@ func testSaveLabel(t *testing.T, db kolide.Datastore) {
 	require.Nil(t, err)
 	label.Name = "changed name"
 	label.Description = "changed description"
+	label.DetailUpdateInterval = 300
 	_, err = db.SaveLabel(label)
 	require.Nil(t, err)
 	saved, err := db.Label(label.ID)
 	require.Nil(t, err)
 	assert.Equal(t, label.Name, saved.Name)
 	assert.Equal(t, label.Description, saved.Description)
+	assert.Equal(t, uint(300), saved.DetailUpdateInterval)
+}
+
+func testSyncLabelSpecs(t *testing.T, ds kolide.Datastore) {
+	setupLabelSpecsTest(t, ds)
+
+	result, err := ds.SyncLabelSpecs([]*kolide.LabelSpec{
+		{
+			Name:        "foo",
+			Query:       "select * from foo",
+			Description: "foo description",
+			Platform:    "darwin",
+		},
+		{
+			Name:  "bar",
+			Query: "select * from bar where changed",
+		},
+		{
+			Name:  "baz",
+			Query: "select * from baz",
+		},
+	}, false)
+	require.Nil(t, err)
+	assert.Equal(t, &kolide.ApplyLabelSpecsResult{
+		Created: []string{"baz"},
+		Updated: []string{"bar"},
+		Deleted: []string{},
+	}, result)
+
+	spec, err := ds.GetLabelSpec("bar")
+	require.Nil(t, err)
+	assert.Equal(t, "select * from bar where changed", spec.Query)
+	assert.Zero(t, spec.DetailUpdateInterval)
+
+	// Pruning deletes the labels not in the specs, other than the built-in
+	// labels
+	result, err = ds.SyncLabelSpecs([]*kolide.LabelSpec{
+		{
+			Name:  "baz",
+			Query: "select * from baz",
+		},
+	}, true)
+	require.Nil(t, err)
+	assert.Equal(t, &kolide.ApplyLabelSpecsResult{
+		Created: []string{},
+		Updated: []string{},
+		Deleted: []string{"bar", "bing", "foo"},
+	}, result)
+
+	specs, err := ds.GetLabelSpecs()
+	require.Nil(t, err)
+	var names []string
+	for _, s := range specs {
+		names = append(names, s.Name)
+	}
+	assert.ElementsMatch(t, []string{"All Hosts", "baz"}, names)
+
+	// A failed spec leaves every label unchanged
+	_, err = ds.SyncLabelSpecs([]*kolide.LabelSpec{
+		{Name: "qux", Query: "select 1"},
+		{Name: ""},
+	}, true)
+	require.NotNil(t, err)
+	_, err = ds.GetLabelSpec("qux")
+	assert.NotNil(t, err)
+	_, err = ds.GetLabelSpec("baz")
+	assert.Nil(t, err)
+}
+
+func testRefreshModifiedLabels(t *testing.T, ds kolide.Datastore) {
+	host, err := ds.EnrollHost("1", "1", "default", 0, false)
+	require.Nil(t, err)
+	other, err := ds.EnrollHost("2", "2", "default", 0, false)
+	require.Nil(t, err)
+
+	err = ds.ApplyLabelSpecs([]*kolide.LabelSpec{
+		{Name: "label1", Query: "query1"},
+		{Name: "label2", Query: "query2"},
+		{Name: "label3", Query: "query3"},
+	})
+	require.Nil(t, err)
+	ids, err := ds.LabelIDsByName([]string{"label1", "label2", "label3"})
+	require.Nil(t, err)
+	require.Len(t, ids, 3)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	// Executions after the labels were last modified are not refreshed
+	evaluated := time.Now().Add(time.Hour)
+	for _, h := range []*kolide.Host{host, other} {
+		err = ds.RecordLabelQueryExecutions(h, map[uint]bool{ids[0]: true, ids[1]: true, ids[2]: false}, evaluated)
+		require.Nil(t, err)
+	}
+	refreshed, err := ds.RefreshModifiedLabels(10)
+	require.Nil(t, err)
+	assert.Empty(t, refreshed)
+
+	// The host evaluated label2 and label3 before they were modified
+	err = ds.RecordLabelQueryExecutions(host, map[uint]bool{ids[1]: true, ids[2]: false}, time.Now().Add(-time.Hour))
+	require.Nil(t, err)
+	cutoff := time.Now().Add(-2 * time.Hour)
+	queries, err := ds.LabelQueriesForHost(host, cutoff)
+	require.Nil(t, err)
+	assert.Empty(t, queries)
+
+	// Only limit labels are refreshed by each call
+	refreshed, err = ds.RefreshModifiedLabels(1)
+	require.Nil(t, err)
+	require.Len(t, refreshed, 1)
+	more, err := ds.RefreshModifiedLabels(10)
+	require.Nil(t, err)
+	require.Len(t, more, 1)
+	refreshed = append(refreshed, more...)
+	sort.Slice(refreshed, func(i, j int) bool { return refreshed[i] < refreshed[j] })
+	assert.Equal(t, []uint{ids[1], ids[2]}, refreshed)
+
+	refreshed, err = ds.RefreshModifiedLabels(10)
+	require.Nil(t, err)
+	assert.Empty(t, refreshed)
+
+	// Refreshed queries are sent although the executions are fresh, and
+	// membership is kept until the host reports again
+	queries, err = ds.LabelQueriesForHost(host, cutoff)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]string{strconv.Itoa(int(ids[1])): "query2", strconv.Itoa(int(ids[2])): "query3"}, queries)
+	queries, err = ds.LabelQueriesForHost(other, cutoff)
+	require.Nil(t, err)
+	assert.Empty(t, queries)
+	labels, err := ds.ListLabelsForHost(host.ID)
+	require.Nil(t, err)
+	assert.Len(t, labels, 2)
+
+	err = ds.RecordLabelQueryExecutions(host, map[uint]bool{ids[1]: false, ids[2]: false}, evaluated)
+	require.Nil(t, err)
+	queries, err = ds.LabelQueriesForHost(host, cutoff)
+	require.Nil(t, err)
+	assert.Empty(t, queries)
 }
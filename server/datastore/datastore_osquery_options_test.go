@@ -3,6 +3,7 @@ package datastore
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/stretchr/testify/assert"
@@ -16,6 +17,9 @@ func testApplyOsqueryOptions(t *testing.T, ds kolide.Datastore) {
 			Platforms: map[string]json.RawMessage{
 				"darwin": json.RawMessage(`{"froob": "ling"}`),
 			},
+			Labels: map[string]json.RawMessage{
+				"macs": json.RawMessage(`{"disable_events": false}`),
+			},
 		},
 	}
 
@@ -33,6 +37,7 @@ func testApplyOsqueryOptions(t *testing.T, ds kolide.Datastore) {
 			Platforms: map[string]json.RawMessage{
 				"linux": json.RawMessage(`{"transitive": "nightfall"}`),
 			},
+			Labels: map[string]json.RawMessage{},
 		},
 	}
 
@@ -56,6 +61,7 @@ func testApplyOsqueryOptionsNoOverrides(t *testing.T, ds kolide.Datastore) {
 	require.Nil(t, err)
 	assert.Equal(t, expectedOpts.Config, retrievedOpts.Config)
 	assert.Empty(t, retrievedOpts.Overrides.Platforms)
+	assert.Empty(t, retrievedOpts.Overrides.Labels)
 }
 
 func testOsqueryOptionsForHost(t *testing.T, ds kolide.Datastore) {
@@ -93,3 +99,95 @@ func testOsqueryOptionsForHost(t *testing.T, ds kolide.Datastore) {
 		})
 	}
 }
+
+func testOsqueryLabelOptionsForHost(t *testing.T, ds kolide.Datastore) {
+	h1, err := ds.NewHost(&kolide.Host{
+		DetailUpdateTime: time.Now(),
+		SeenTime:         time.Now(),
+		OsqueryHostID:    "1",
+		NodeKey:          "1",
+		UUID:             "1",
+		HostName:         "foo.local",
+	})
+	require.Nil(t, err)
+
+	h2, err := ds.NewHost(&kolide.Host{
+		DetailUpdateTime: time.Now(),
+		SeenTime:         time.Now(),
+		OsqueryHostID:    "2",
+		NodeKey:          "2",
+		UUID:             "2",
+		HostName:         "bar.local",
+	})
+	require.Nil(t, err)
+
+	err = ds.ApplyLabelSpecs([]*kolide.LabelSpec{
+		{Name: "macs", Query: "select 1"},
+		{Name: "servers", Query: "select 2"},
+		{Name: "pinned", LabelType: kolide.LabelTypeManual},
+	})
+	require.Nil(t, err)
+	labelID := func(name string) uint {
+		ids, err := ds.LabelIDsByName([]string{name})
+		require.Nil(t, err)
+		require.Len(t, ids, 1)
+		return ids[0]
+	}
+
+	err = ds.RecordLabelQueryExecutions(h1, map[uint]bool{labelID("macs"): true, labelID("servers"): false}, time.Now())
+	require.Nil(t, err)
+	err = ds.AddHostsToLabel(labelID("pinned"), []uint{h2.ID})
+	require.Nil(t, err)
+
+	macsOpts := json.RawMessage(`{"disable_events": false}`)
+	serversOpts := json.RawMessage(`{"distributed_interval": 60}`)
+	pinnedOpts := json.RawMessage(`{"logger_tls_period": 30}`)
+	err = ds.ApplyOptions(&kolide.OptionsSpec{
+		Config: json.RawMessage(`{}`),
+		Overrides: kolide.OptionsOverrides{
+			Labels: map[string]json.RawMessage{
+				"macs":    macsOpts,
+				"servers": serversOpts,
+				"pinned":  pinnedOpts,
+				"missing": json.RawMessage(`{"disable_events": true}`),
+			},
+		},
+	})
+	require.Nil(t, err)
+
+	opts, err := ds.LabelOptionsForHost(h1.ID)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]json.RawMessage{"macs": macsOpts}, opts)
+
+	opts, err = ds.LabelOptionsForHost(h2.ID)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]json.RawMessage{"pinned": pinnedOpts}, opts)
+}
+
+func testOsqueryIntervalsForPlatform(t *testing.T, ds kolide.Datastore) {
+	configRefresh, distributedInterval := 600, 120
+	expectedOpts := &kolide.OptionsSpec{
+		Config: json.RawMessage(`{"options": {"distributed_interval": 10}}`),
+		Overrides: kolide.OptionsOverrides{
+			Platforms: map[string]json.RawMessage{},
+			Labels:    map[string]json.RawMessage{},
+			Intervals: map[string]kolide.OsqueryIntervals{
+				"darwin": {ConfigRefresh: &configRefresh, DistributedInterval: &distributedInterval},
+				"linux":  {LoggerTLSPeriod: &distributedInterval},
+			},
+		},
+	}
+	require.Nil(t, ds.ApplyOptions(expectedOpts))
+
+	retrievedOpts, err := ds.GetOptions()
+	require.Nil(t, err)
+	assert.Equal(t, expectedOpts, retrievedOpts)
+
+	intervals, err := ds.IntervalsForPlatform("darwin")
+	require.Nil(t, err)
+	assert.Equal(t, &kolide.OsqueryIntervals{ConfigRefresh: &configRefresh, DistributedInterval: &distributedInterval}, intervals)
+
+	intervals, err = ds.IntervalsForPlatform("windows")
+	require.Nil(t, err)
+	assert.Nil(t, intervals)
+}
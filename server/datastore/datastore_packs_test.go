@@ -2,6 +2,7 @@ package datastore
 
 import (
 	"testing"
+	"time"
 
 	"github.com/WatchBeam/clock"
 	"github.com/kolide/fleet/server/kolide"
@@ -182,8 +183,12 @@ func setupPackSpecsTest(t *testing.T, ds kolide.Datastore) []*kolide.PackSpec {
 	stringPtr := func(s string) *string { return &s }
 	expectedSpecs := []*kolide.PackSpec{
 		&kolide.PackSpec{
-			ID:   1,
-			Name: "test_pack",
+			ID:              1,
+			Name:            "test_pack",
+			LoggerPlugin:    "firehose",
+			WindowStartHour: uintPtr(22),
+			WindowEndHour:   uintPtr(6),
+			WindowDays:      "sat,sun",
 			Targets: kolide.PackSpecTargets{
 				Labels: []string{
 					"foo",
@@ -193,25 +198,28 @@ func setupPackSpecsTest(t *testing.T, ds kolide.Datastore) []*kolide.PackSpec {
 			},
 			Queries: []kolide.PackSpecQuery{
 				kolide.PackSpecQuery{
-					QueryName:   queries[0].Name,
-					Name:        "q0",
-					Description: "test_foo",
-					Interval:    42,
+					QueryName:     queries[0].Name,
+					Name:          "q0",
+					Description:   "test_foo",
+					Interval:      42,
+					ColumnMapping: &kolide.ColumnMapping{{Column: "a", As: "b"}},
 				},
 				kolide.PackSpecQuery{
 					QueryName: queries[0].Name,
 					Name:      "foo_snapshot",
 					Interval:  600,
 					Snapshot:  boolPtr(true),
+					Disabled:  true,
 				},
 				kolide.PackSpecQuery{
-					Name:      "q2",
-					QueryName: queries[1].Name,
-					Interval:  600,
-					Removed:   boolPtr(false),
-					Shard:     uintPtr(73),
-					Platform:  stringPtr("foobar"),
-					Version:   stringPtr("0.0.0.0.0.1"),
+					Name:          "q2",
+					QueryName:     queries[1].Name,
+					Interval:      600,
+					Removed:       boolPtr(false),
+					Shard:         uintPtr(73),
+					MaxResultRows: uintPtr(500),
+					Platform:      stringPtr("foobar"),
+					Version:       stringPtr("0.0.0.0.0.1"),
 				},
 			},
 		},
@@ -495,3 +503,150 @@ func testListPacksForHost(t *testing.T, ds kolide.Datastore) {
 	require.Nil(t, err)
 	assert.Len(t, packs, 2)
 }
+
+func testListPacksForHostManualLabel(t *testing.T, ds kolide.Datastore) {
+	if ds.Name() == "inmem" {
+		t.Skip("inmem is deprecated")
+	}
+
+	mockClock := clock.NewMockClock()
+
+	label, err := ds.NewLabel(&kolide.Label{
+		Name:      "golden image",
+		LabelType: kolide.LabelTypeManual,
+	})
+	require.Nil(t, err)
+
+	err = ds.ApplyPackSpecs([]*kolide.PackSpec{
+		{
+			ID:      1,
+			Name:    "manual_pack",
+			Targets: kolide.PackSpecTargets{Labels: []string{label.Name}},
+		},
+	})
+	require.Nil(t, err)
+	disabled, err := ds.NewPack(&kolide.Pack{Name: "disabled_pack", Disabled: true})
+	require.Nil(t, err)
+	require.Nil(t, ds.AddLabelToPack(label.ID, disabled.ID))
+
+	h1 := test.NewHost(t, ds, "h1.local", "10.10.10.1", "1", "1", mockClock.Now())
+	h2 := test.NewHost(t, ds, "h2.local", "10.10.10.2", "2", "2", mockClock.Now())
+
+	packs, err := ds.ListPacksForHost(h1.ID)
+	require.Nil(t, err)
+	assert.Len(t, packs, 0)
+
+	require.Nil(t, ds.AddHostsToLabel(label.ID, []uint{h1.ID}))
+
+	// Only the enabled pack is delivered to the members of the label
+	packs, err = ds.ListPacksForHost(h1.ID)
+	require.Nil(t, err)
+	if assert.Len(t, packs, 1) {
+		assert.Equal(t, "manual_pack", packs[0].Name)
+	}
+
+	packs, err = ds.ListPacksForHost(h2.ID)
+	require.Nil(t, err)
+	assert.Len(t, packs, 0)
+
+	require.Nil(t, ds.RemoveHostsFromLabel(label.ID, []uint{h1.ID}))
+	packs, err = ds.ListPacksForHost(h1.ID)
+	require.Nil(t, err)
+	assert.Len(t, packs, 0)
+}
+
+func testListPacksForHostComputedLabel(t *testing.T, ds kolide.Datastore) {
+	if ds.Name() == "inmem" {
+		t.Skip("inmem is deprecated")
+	}
+
+	mockClock := clock.NewMockClock()
+
+	h1 := test.NewHost(t, ds, "h1.local", "10.10.10.1", "1", "1", mockClock.Now())
+	h2 := test.NewHost(t, ds, "h2.local", "10.10.10.2", "2", "2", mockClock.Now())
+	h1.Platform = "windows"
+	require.Nil(t, ds.SaveHost(h1))
+
+	err := ds.ApplyLabelSpecs([]*kolide.LabelSpec{
+		{
+			Name:      "windows",
+			LabelType: kolide.LabelTypeComputed,
+			Criteria:  &kolide.LabelCriteria{Platform: "windows"},
+		},
+	})
+	require.Nil(t, err)
+	err = ds.ApplyPackSpecs([]*kolide.PackSpec{
+		{
+			ID:      1,
+			Name:    "computed_pack",
+			Targets: kolide.PackSpecTargets{Labels: []string{"windows"}},
+		},
+	})
+	require.Nil(t, err)
+
+	packs, err := ds.ListPacksForHost(h1.ID)
+	require.Nil(t, err)
+	if assert.Len(t, packs, 1) {
+		assert.Equal(t, "computed_pack", packs[0].Name)
+	}
+
+	packs, err = ds.ListPacksForHost(h2.ID)
+	require.Nil(t, err)
+	assert.Len(t, packs, 0)
+}
+
+func testListHostsMissingPack(t *testing.T, ds kolide.Datastore) {
+	if ds.Name() == "inmem" {
+		t.Skip("inmem is deprecated")
+	}
+
+	u1 := test.NewUser(t, ds, "Admin", "admin", "admin@kolide.co", true)
+	q1 := test.NewQuery(t, ds, "time", "select * from time;", u1.ID, true)
+	require.Nil(t, ds.ApplyLabelSpecs([]*kolide.LabelSpec{{Name: "production", Query: "select 1"}}))
+	labelIDs, err := ds.LabelIDsByName([]string{"production"})
+	require.Nil(t, err)
+	require.Len(t, labelIDs, 1)
+	require.Nil(t, ds.ApplyPackSpecs([]*kolide.PackSpec{{
+		Name:    "baseline",
+		Targets: kolide.PackSpecTargets{Labels: []string{"production"}},
+	}}))
+	pack, ok, err := ds.PackByName("baseline")
+	require.Nil(t, err)
+	require.True(t, ok)
+	sq := test.NewScheduledQuery(t, ds, pack.ID, q1.ID, 60, false, false)
+
+	now := time.Now()
+	h1 := test.NewHost(t, ds, "h1.local", "10.10.10.1", "1", "1", now)
+	h2 := test.NewHost(t, ds, "h2.local", "10.10.10.2", "2", "2", now)
+	h3 := test.NewHost(t, ds, "h3.local", "10.10.10.3", "3", "3", now)
+	// h4 is not targeted by the pack
+	test.NewHost(t, ds, "h4.local", "10.10.10.4", "4", "4", now)
+	for _, h := range []*kolide.Host{h1, h2} {
+		require.Nil(t, ds.RecordLabelQueryExecutions(h, map[uint]bool{labelIDs[0]: true}, now))
+	}
+	require.Nil(t, ds.AddHostToPack(h3.ID, pack.ID))
+
+	hostNames := func(opt kolide.HostListOptions) []string {
+		hosts, err := ds.ListHostsMissingPack(pack.ID, opt)
+		require.Nil(t, err)
+		names := []string{}
+		for _, host := range hosts {
+			names = append(names, host.HostName)
+		}
+		return names
+	}
+
+	opt := kolide.HostListOptions{ListOptions: kolide.ListOptions{OrderKey: "host_name"}}
+	assert.Equal(t, []string{"h1.local", "h2.local", "h3.local"}, hostNames(opt))
+
+	require.Nil(t, ds.SaveScheduledQueryStats(h1.ID, []kolide.ScheduledQueryStats{
+		{ScheduledQueryID: sq.ID, LastExecuted: now, Executions: 1},
+	}))
+	assert.Equal(t, []string{"h2.local", "h3.local"}, hostNames(opt))
+	assert.Equal(t, []string{"h3.local"}, hostNames(kolide.HostListOptions{MatchQuery: "h3"}))
+
+	// Label targets of disabled packs are not expected to run the pack
+	pack.Disabled = true
+	require.Nil(t, ds.SavePack(pack))
+	assert.Equal(t, []string{"h3.local"}, hostNames(opt))
+}
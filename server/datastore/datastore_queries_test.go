@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/kolide/fleet/server/test"
@@ -23,7 +24,7 @@ func testApplyQueries(t *testing.T, ds kolide.Datastore) {
 	err := ds.ApplyQueries(zwass.ID, expectedQueries)
 	require.Nil(t, err)
 
-	queries, err := ds.ListQueries(kolide.ListOptions{})
+	queries, err := ds.ListQueries(kolide.ListQueryOptions{})
 	require.Nil(t, err)
 	require.Len(t, queries, len(expectedQueries))
 	for i, q := range queries {
@@ -40,7 +41,7 @@ func testApplyQueries(t *testing.T, ds kolide.Datastore) {
 	err = ds.ApplyQueries(groob.ID, expectedQueries)
 	require.Nil(t, err)
 
-	queries, err = ds.ListQueries(kolide.ListOptions{})
+	queries, err = ds.ListQueries(kolide.ListQueryOptions{})
 	require.Nil(t, err)
 	require.Len(t, queries, len(expectedQueries))
 	for i, q := range queries {
@@ -58,7 +59,7 @@ func testApplyQueries(t *testing.T, ds kolide.Datastore) {
 	err = ds.ApplyQueries(zwass.ID, []*kolide.Query{expectedQueries[2]})
 	require.Nil(t, err)
 
-	queries, err = ds.ListQueries(kolide.ListOptions{})
+	queries, err = ds.ListQueries(kolide.ListQueryOptions{})
 	require.Nil(t, err)
 	require.Len(t, queries, len(expectedQueries))
 	for i, q := range queries {
@@ -114,7 +115,7 @@ func testDeleteQueries(t *testing.T, ds kolide.Datastore) {
 	q3 := test.NewQuery(t, ds, "q3", "select 1", user.ID, true)
 	q4 := test.NewQuery(t, ds, "q4", "select * from osquery_info", user.ID, true)
 
-	queries, err := ds.ListQueries(kolide.ListOptions{})
+	queries, err := ds.ListQueries(kolide.ListQueryOptions{})
 	require.Nil(t, err)
 	assert.Len(t, queries, 4)
 
@@ -122,7 +123,7 @@ func testDeleteQueries(t *testing.T, ds kolide.Datastore) {
 	require.Nil(t, err)
 	assert.Equal(t, uint(2), deleted)
 
-	queries, err = ds.ListQueries(kolide.ListOptions{})
+	queries, err = ds.ListQueries(kolide.ListQueryOptions{})
 	require.Nil(t, err)
 	assert.Len(t, queries, 2)
 
@@ -130,7 +131,7 @@ func testDeleteQueries(t *testing.T, ds kolide.Datastore) {
 	require.Nil(t, err)
 	assert.Equal(t, uint(1), deleted)
 
-	queries, err = ds.ListQueries(kolide.ListOptions{})
+	queries, err = ds.ListQueries(kolide.ListQueryOptions{})
 	require.Nil(t, err)
 	assert.Len(t, queries, 1)
 
@@ -138,12 +139,84 @@ func testDeleteQueries(t *testing.T, ds kolide.Datastore) {
 	require.Nil(t, err)
 	assert.Equal(t, uint(1), deleted)
 
-	queries, err = ds.ListQueries(kolide.ListOptions{})
+	queries, err = ds.ListQueries(kolide.ListQueryOptions{})
 	require.Nil(t, err)
 	assert.Len(t, queries, 0)
 
 }
 
+func testRestoreQuery(t *testing.T, ds kolide.Datastore) {
+	user := test.NewUser(t, ds, "Zach", "zwass", "zwass@kolide.co", true)
+	q1 := test.NewQuery(t, ds, "q1", "select * from time", user.ID, true)
+	test.NewQuery(t, ds, "q2", "select * from processes", user.ID, true)
+
+	err := ds.DeleteQuery(q1.Name)
+	require.Nil(t, err)
+
+	queries, err := ds.ListQueries(kolide.ListQueryOptions{})
+	require.Nil(t, err)
+	require.Len(t, queries, 1)
+	assert.Equal(t, "q2", queries[0].Name)
+
+	queries, err = ds.ListQueries(kolide.ListQueryOptions{IncludeDeleted: true})
+	require.Nil(t, err)
+	require.Len(t, queries, 2)
+	for _, q := range queries {
+		if q.Name == "q1" {
+			assert.True(t, q.Deleted)
+			assert.NotNil(t, q.DeletedAt)
+		}
+	}
+
+	err = ds.RestoreQuery(q1.ID)
+	require.Nil(t, err)
+
+	restored, err := ds.Query(q1.ID)
+	require.Nil(t, err)
+	assert.False(t, restored.Deleted)
+	assert.Nil(t, restored.DeletedAt)
+
+	// Restoring a query that is not deleted fails
+	err = ds.RestoreQuery(q1.ID)
+	assert.True(t, kolide.IsNotFound(err))
+}
+
+func testPurgeDeletedQueries(t *testing.T, ds kolide.Datastore) {
+	user := test.NewUser(t, ds, "Zach", "zwass", "zwass@kolide.co", true)
+	q1 := test.NewQuery(t, ds, "q1", "select * from time", user.ID, true)
+	test.NewQuery(t, ds, "q2", "select * from processes", user.ID, true)
+	test.NewQuery(t, ds, "q3", "select 1", user.ID, true)
+
+	// q2 is scheduled in a pack, and is removed from it when purged
+	err := ds.ApplyPackSpecs([]*kolide.PackSpec{
+		{Name: "p1", Queries: []kolide.PackSpecQuery{{QueryName: "q2", Interval: 60}}},
+	})
+	require.Nil(t, err)
+
+	require.Nil(t, ds.DeleteQuery("q1"))
+	require.Nil(t, ds.DeleteQuery("q2"))
+
+	// Nothing was deleted before the cutoff
+	purged, err := ds.PurgeDeletedQueries(time.Now().Add(-time.Hour))
+	require.Nil(t, err)
+	assert.Equal(t, uint(0), purged)
+
+	purged, err = ds.PurgeDeletedQueries(time.Now().Add(time.Hour))
+	require.Nil(t, err)
+	assert.Equal(t, uint(2), purged)
+
+	queries, err := ds.ListQueries(kolide.ListQueryOptions{IncludeDeleted: true})
+	require.Nil(t, err)
+	require.Len(t, queries, 1)
+
+	pack, err := ds.GetPackSpec("p1")
+	require.Nil(t, err)
+	assert.Len(t, pack.Queries, 0)
+
+	err = ds.RestoreQuery(q1.ID)
+	assert.True(t, kolide.IsNotFound(err))
+}
+
 func testSaveQuery(t *testing.T, ds kolide.Datastore) {
 	user := test.NewUser(t, ds, "Zach", "zwass", "zwass@kolide.co", true)
 
@@ -158,6 +231,7 @@ func testSaveQuery(t *testing.T, ds kolide.Datastore) {
 	assert.NotEqual(t, 0, query.ID)
 
 	query.Query = "baz"
+	query.CacheTTL = 300
 	err = ds.SaveQuery(query)
 
 	require.Nil(t, err)
@@ -166,6 +240,7 @@ func testSaveQuery(t *testing.T, ds kolide.Datastore) {
 	require.Nil(t, err)
 	require.NotNil(t, queryVerify)
 	assert.Equal(t, "baz", queryVerify.Query)
+	assert.Equal(t, uint(300), queryVerify.CacheTTL)
 	assert.Equal(t, "Zach", queryVerify.AuthorName)
 }
 
@@ -191,12 +266,123 @@ func testListQuery(t *testing.T, ds kolide.Datastore) {
 	})
 	require.Nil(t, err)
 
-	opts := kolide.ListOptions{}
+	opts := kolide.ListQueryOptions{}
 	results, err := ds.ListQueries(opts)
 	assert.Nil(t, err)
 	assert.Equal(t, 10, len(results))
 }
 
+func testListQueryFilters(t *testing.T, ds kolide.Datastore) {
+	zwass := test.NewUser(t, ds, "Zach", "zwass", "zwass@kolide.co", true)
+	admin := test.NewUser(t, ds, "Admin", "admin", "admin@kolide.co", true)
+
+	queries := []*kolide.Query{
+		{Name: "Users", Query: "select * from users", AuthorID: &zwass.ID},
+		{Name: "Processes", Query: "SELECT * FROM processes", AuthorID: &zwass.ID},
+		{Name: "Time", Query: "select * from time", AuthorID: &admin.ID},
+		{Name: "100%_complete", Query: "select 1", AuthorID: &admin.ID},
+	}
+	for _, q := range queries {
+		q.Saved = true
+		_, err := ds.NewQuery(q)
+		require.Nil(t, err)
+	}
+
+	names := func(opts kolide.ListQueryOptions) []string {
+		results, err := ds.ListQueries(opts)
+		require.Nil(t, err)
+		names := []string{}
+		for _, q := range results {
+			names = append(names, q.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"Users", "Processes"}, names(kolide.ListQueryOptions{AuthorID: &zwass.ID}))
+	assert.Equal(t, []string{"Time", "100%_complete"}, names(kolide.ListQueryOptions{AuthorID: &admin.ID}))
+
+	// Matching is against name or query, ignoring case
+	assert.Equal(t, []string{"Processes"}, names(kolide.ListQueryOptions{MatchQuery: "from P"}))
+	assert.Equal(t, []string{"Users"}, names(kolide.ListQueryOptions{MatchQuery: "USERS"}))
+	assert.Equal(t, []string{"Time"}, names(kolide.ListQueryOptions{MatchQuery: "tIM"}))
+
+	// Wildcard characters are matched literally
+	assert.Equal(t, []string{"100%_complete"}, names(kolide.ListQueryOptions{MatchQuery: "%_"}))
+	assert.Empty(t, names(kolide.ListQueryOptions{MatchQuery: "_x"}))
+
+	assert.Equal(t, []string{"Users"}, names(kolide.ListQueryOptions{
+		AuthorID:   &zwass.ID,
+		MatchQuery: "user",
+	}))
+	assert.Equal(t, []string{"Processes", "Users"}, names(kolide.ListQueryOptions{
+		ListOptions: kolide.ListOptions{OrderKey: "name"},
+		AuthorID:    &zwass.ID,
+	}))
+}
+
+func testQueryTags(t *testing.T, ds kolide.Datastore) {
+	user := test.NewUser(t, ds, "Zach", "zwass", "zwass@kolide.co", true)
+
+	queries := []*kolide.Query{
+		{Name: "Users", Query: "select * from users", Tags: []string{"accounts", "compliance"}},
+		{Name: "Processes", Query: "select * from processes", Tags: []string{"compliance"}},
+		{Name: "Time", Query: "select * from time"},
+		{Name: "Unsaved", Query: "select 1", Tags: []string{"hidden"}},
+	}
+	for _, q := range queries {
+		q.Saved = q.Name != "Unsaved"
+		q.AuthorID = &user.ID
+		_, err := ds.NewQuery(q)
+		require.Nil(t, err)
+	}
+
+	query, err := ds.Query(queries[0].ID)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"accounts", "compliance"}, query.Tags)
+	query, err = ds.QueryByName("Time")
+	require.Nil(t, err)
+	assert.Equal(t, []string{}, query.Tags)
+
+	names := func(tags ...string) []string {
+		results, err := ds.ListQueries(kolide.ListQueryOptions{
+			ListOptions: kolide.ListOptions{OrderKey: "name"},
+			Tags:        tags,
+		})
+		require.Nil(t, err)
+		names := []string{}
+		for _, q := range results {
+			names = append(names, q.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"Processes", "Time", "Users"}, names())
+	assert.Equal(t, []string{"Processes", "Users"}, names("compliance"))
+	assert.Equal(t, []string{"Users"}, names("accounts", "missing"))
+	assert.Empty(t, names("missing"))
+
+	tags, err := ds.ListQueryTags()
+	require.Nil(t, err)
+	assert.Equal(t, []kolide.QueryTag{
+		{Tag: "compliance", Count: 2},
+		{Tag: "accounts", Count: 1},
+	}, tags)
+
+	// Saving a query replaces its tags
+	query, err = ds.Query(queries[1].ID)
+	require.Nil(t, err)
+	query.Tags = []string{"processes"}
+	require.Nil(t, ds.SaveQuery(query))
+	query, err = ds.Query(queries[1].ID)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"processes"}, query.Tags)
+
+	// Deleted queries are not counted
+	require.Nil(t, ds.DeleteQuery("Users"))
+	tags, err = ds.ListQueryTags()
+	require.Nil(t, err)
+	assert.Equal(t, []kolide.QueryTag{{Tag: "processes", Count: 1}}, tags)
+}
+
 func testLoadPacksForQueries(t *testing.T, ds kolide.Datastore) {
 	zwass := test.NewUser(t, ds, "Zach", "zwass", "zwass@kolide.co", true)
 	queries := []*kolide.Query{
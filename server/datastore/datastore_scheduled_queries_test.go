@@ -2,6 +2,7 @@ package datastore
 
 import (
 	"testing"
+	"time"
 
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/kolide/fleet/server/test"
@@ -76,6 +77,49 @@ func testListScheduledQueriesInPack(t *testing.T, ds kolide.Datastore) {
 	require.Len(t, gotQueries, 3)
 }
 
+func testModifyScheduledQueriesInPack(t *testing.T, ds kolide.Datastore) {
+	u1 := test.NewUser(t, ds, "Admin", "admin", "admin@kolide.co", true)
+	q1 := test.NewQuery(t, ds, "foo", "select * from time;", u1.ID, true)
+	p1 := test.NewPack(t, ds, "baz")
+	p2 := test.NewPack(t, ds, "qux")
+	sq1 := test.NewScheduledQuery(t, ds, p1.ID, q1.ID, 60, false, false)
+	sq2 := test.NewScheduledQuery(t, ds, p1.ID, q1.ID, 120, false, false)
+	sq3 := test.NewScheduledQuery(t, ds, p2.ID, q1.ID, 60, false, false)
+
+	multiplier := uint(2)
+	snapshot := true
+	modified, err := ds.ModifyScheduledQueriesInPack(p1.ID, kolide.ScheduledQueryPatch{
+		IntervalMultiplier: &multiplier,
+		Snapshot:           &snapshot,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, 2, modified)
+
+	got, err := ds.ScheduledQuery(sq1.ID)
+	require.Nil(t, err)
+	assert.Equal(t, uint(120), got.Interval)
+	require.NotNil(t, got.Snapshot)
+	assert.True(t, *got.Snapshot)
+	got, err = ds.ScheduledQuery(sq2.ID)
+	require.Nil(t, err)
+	assert.Equal(t, uint(240), got.Interval)
+
+	// Queries in other packs are unchanged
+	got, err = ds.ScheduledQuery(sq3.ID)
+	require.Nil(t, err)
+	assert.Equal(t, uint(60), got.Interval)
+	require.NotNil(t, got.Snapshot)
+	assert.False(t, *got.Snapshot)
+
+	interval := uint(30)
+	modified, err = ds.ModifyScheduledQueriesInPack(p1.ID, kolide.ScheduledQueryPatch{Interval: &interval})
+	require.Nil(t, err)
+	assert.Equal(t, 2, modified)
+	got, err = ds.ScheduledQuery(sq2.ID)
+	require.Nil(t, err)
+	assert.Equal(t, uint(30), got.Interval)
+}
+
 func testNewScheduledQuery(t *testing.T, ds kolide.Datastore) {
 	u1 := test.NewUser(t, ds, "Admin", "admin", "admin@kolide.co", true)
 	q1 := test.NewQuery(t, ds, "foo", "select * from time;", u1.ID, true)
@@ -99,6 +143,54 @@ func testScheduledQuery(t *testing.T, ds kolide.Datastore) {
 	query, err := ds.ScheduledQuery(sq1.ID)
 	require.Nil(t, err)
 	assert.Equal(t, uint(60), query.Interval)
+	assert.False(t, query.Disabled)
+
+	query.Disabled = true
+	_, err = ds.SaveScheduledQuery(query)
+	require.Nil(t, err)
+
+	query, err = ds.ScheduledQuery(sq1.ID)
+	require.Nil(t, err)
+	assert.True(t, query.Disabled)
+
+	queries, err := ds.ListScheduledQueriesInPack(p1.ID, kolide.ListOptions{})
+	require.Nil(t, err)
+	require.Len(t, queries, 1)
+	assert.True(t, queries[0].Disabled)
+	assert.Nil(t, queries[0].MaxResultRows)
+
+	maxRows := uint(100)
+	query.MaxResultRows = &maxRows
+	_, err = ds.SaveScheduledQuery(query)
+	require.Nil(t, err)
+
+	query, err = ds.ScheduledQuery(sq1.ID)
+	require.Nil(t, err)
+	require.NotNil(t, query.MaxResultRows)
+	assert.Equal(t, uint(100), *query.MaxResultRows)
+	assert.Nil(t, query.SampleRate)
+
+	rate := 0.25
+	query.SampleRate = &rate
+	_, err = ds.SaveScheduledQuery(query)
+	require.Nil(t, err)
+
+	queries, err = ds.ListScheduledQueriesInPack(p1.ID, kolide.ListOptions{})
+	require.Nil(t, err)
+	require.Len(t, queries, 1)
+	require.NotNil(t, queries[0].SampleRate)
+	assert.Equal(t, 0.25, *queries[0].SampleRate)
+	assert.Nil(t, queries[0].ColumnMapping)
+
+	mapping := kolide.ColumnMapping{{Column: "hostname", As: "host"}, {Column: "uuid"}}
+	query.ColumnMapping = &mapping
+	_, err = ds.SaveScheduledQuery(query)
+	require.Nil(t, err)
+
+	query, err = ds.ScheduledQuery(query.ID)
+	require.Nil(t, err)
+	require.NotNil(t, query.ColumnMapping)
+	assert.Equal(t, mapping, *query.ColumnMapping)
 }
 
 func testDeleteScheduledQuery(t *testing.T, ds kolide.Datastore) {
@@ -118,6 +210,65 @@ func testDeleteScheduledQuery(t *testing.T, ds kolide.Datastore) {
 	require.NotNil(t, err)
 }
 
+func testScheduledQueryStats(t *testing.T, ds kolide.Datastore) {
+	u1 := test.NewUser(t, ds, "Admin", "admin", "admin@kolide.co", true)
+	q1 := test.NewQuery(t, ds, "foo", "select * from time;", u1.ID, true)
+	q2 := test.NewQuery(t, ds, "bar", "select * from processes;", u1.ID, true)
+	p1 := test.NewPack(t, ds, "baz")
+	sq1 := test.NewScheduledQuery(t, ds, p1.ID, q1.ID, 60, false, false)
+	sq2 := test.NewScheduledQuery(t, ds, p1.ID, q2.ID, 60, false, false)
+	h1 := test.NewHost(t, ds, "foo.local", "192.168.1.10", "1", "1", time.Now())
+	h2 := test.NewHost(t, ds, "bar.local", "192.168.1.11", "2", "2", time.Now())
+
+	executed := time.Date(2020, 7, 16, 12, 0, 0, 0, time.UTC)
+	require.Nil(t, ds.SaveScheduledQueryStats(h1.ID, []kolide.ScheduledQueryStats{
+		{ScheduledQueryID: sq1.ID, LastExecuted: executed, Executions: 10, WallTime: 1000, OutputSize: 100},
+	}))
+	require.Nil(t, ds.SaveScheduledQueryStats(h2.ID, []kolide.ScheduledQueryStats{
+		{ScheduledQueryID: sq1.ID, LastExecuted: executed.Add(time.Hour), Executions: 30, WallTime: 5000, OutputSize: 300},
+	}))
+
+	stats, err := ds.ScheduledQueryStats(p1.ID)
+	require.Nil(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, sq1.ID, stats[0].ScheduledQueryID)
+	assert.Equal(t, uint(2), stats[0].Hosts)
+	assert.Equal(t, uint64(40), stats[0].Executions)
+	assert.InDelta(t, 150, stats[0].AverageWallTime, 0.01)
+	assert.InDelta(t, 10, stats[0].AverageOutputSize, 0.01)
+	require.NotNil(t, stats[0].LastExecuted)
+	assert.True(t, executed.Add(time.Hour).Equal(*stats[0].LastExecuted))
+
+	// Queries without stats are included
+	assert.Equal(t, sq2.ID, stats[1].ScheduledQueryID)
+	assert.Equal(t, uint(0), stats[1].Hosts)
+	assert.Nil(t, stats[1].LastExecuted)
+
+	// Saving replaces the previous stats of the host
+	require.Nil(t, ds.SaveScheduledQueryStats(h2.ID, []kolide.ScheduledQueryStats{
+		{ScheduledQueryID: sq2.ID, LastExecuted: executed, Executions: 1, WallTime: 10, OutputSize: 1},
+	}))
+	hostStats, err := ds.ListScheduledQueryStatsForHost(h2.ID)
+	require.Nil(t, err)
+	require.Len(t, hostStats, 1)
+	assert.Equal(t, h2.ID, hostStats[0].HostID)
+	assert.Equal(t, sq2.ID, hostStats[0].ScheduledQueryID)
+	assert.Equal(t, uint(1), hostStats[0].Executions)
+	stats, err = ds.ScheduledQueryStats(p1.ID)
+	require.Nil(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, sq1.ID, stats[0].ScheduledQueryID)
+	assert.Equal(t, uint(1), stats[0].Hosts)
+	assert.Equal(t, uint(1), stats[1].Hosts)
+
+	// Stats are removed with the host
+	require.Nil(t, ds.DeleteHost(h1.ID))
+	stats, err = ds.ScheduledQueryStats(p1.ID)
+	require.Nil(t, err)
+	assert.Equal(t, sq2.ID, stats[0].ScheduledQueryID)
+	assert.Equal(t, uint(0), stats[1].Hosts)
+}
+
 func testCascadingDeletionOfQueries(t *testing.T, ds kolide.Datastore) {
 	zwass := test.NewUser(t, ds, "Zach", "zwass", "zwass@kolide.co", true)
 	queries := []*kolide.Query{
@@ -117,6 +117,13 @@ func testCountHostsInTargets(t *testing.T, ds kolide.Datastore) {
 	assert.Equal(t, uint(5), metrics.OfflineHosts)
 	assert.Equal(t, uint(1), metrics.MissingInActionHosts)
 
+	hostIDs, err := ds.HostIDsInTargets([]uint{h1.ID, h4.ID}, []uint{l2.ID})
+	require.Nil(t, err)
+	assert.Equal(t, []uint{h1.ID, h3.ID, h4.ID, h5.ID}, hostIDs)
+
+	hostIDs, err = ds.HostIDsInTargets(nil, nil)
+	require.Nil(t, err)
+	assert.Empty(t, hostIDs)
 }
 
 func testHostStatus(t *testing.T, ds kolide.Datastore) {
@@ -126,7 +133,7 @@ func testHostStatus(t *testing.T, ds kolide.Datastore) {
 
 	mockClock := clock.NewMockClock()
 
-	h, err := ds.EnrollHost("1", "key1", "default")
+	h, err := ds.EnrollHost("1", "key1", "default", 0, false)
 	require.Nil(t, err)
 
 	// Make host no longer appear new
@@ -20,6 +20,8 @@ var testFunctions = [...]func(*testing.T, kolide.Datastore){
 	testAdditionalQueries,
 	testEnrollSecrets,
 	testEnrollSecretRoundtrip,
+	testEnrollSecretScoped,
+	testEnrollSecretUsage,
 	testCreateInvite,
 	testInviteByEmail,
 	testInviteByToken,
@@ -28,10 +30,18 @@ var testFunctions = [...]func(*testing.T, kolide.Datastore){
 	testSaveInvite,
 	testDeleteQuery,
 	testDeleteQueries,
+	testRestoreQuery,
+	testPurgeDeletedQueries,
 	testSaveQuery,
 	testListQuery,
+	testListQueryFilters,
+	testQueryTags,
 	testDeletePack,
 	testEnrollHost,
+	testEnrollHostCooldown,
+	testEnrollHostPending,
+	testHostDiskEncryption,
+	testMergeHosts,
 	testAuthenticateHost,
 	testLabels,
 	testSaveLabel,
@@ -40,12 +50,16 @@ var testFunctions = [...]func(*testing.T, kolide.Datastore){
 	testCreateUser,
 	testSaveUser,
 	testUserByID,
+	testUserLockout,
 	testPasswordResetRequests,
 	testSearchHosts,
 	testSearchHostsLimit,
 	testSearchLabels,
 	testSearchLabelsLimit,
 	testListHostsInLabel,
+	testListHostsInComputedLabel,
+	testManualLabelMembership,
+	testTransferHostsToLabel,
 	testListUniqueHostsInLabels,
 	testDistributedQueriesForHost,
 	testSaveHosts,
@@ -53,16 +67,26 @@ var testFunctions = [...]func(*testing.T, kolide.Datastore){
 	testListHost,
 	testListHostsInPack,
 	testListPacksForHost,
+	testListPacksForHostManualLabel,
+	testListPacksForHostComputedLabel,
+	testListHostsMissingPack,
 	testHostIDsByName,
+	testHostIDsByIdentifier,
 	testListPacks,
 	testDistributedQueryCampaign,
 	testCleanupDistributedQueryCampaigns,
+	testPurgeCompletedCampaigns,
+	testCompleteDistributedQueryCampaign,
+	testMarkDistributedQueryCampaignDisconnected,
+	testListActiveDistributedQueryCampaigns,
 	testBuiltInLabels,
 	testLoadPacksForQueries,
 	testScheduledQuery,
 	testDeleteScheduledQuery,
+	testScheduledQueryStats,
 	testNewScheduledQuery,
 	testListScheduledQueriesInPack,
+	testModifyScheduledQueriesInPack,
 	testCascadingDeletionOfQueries,
 	testOptions,
 	testOptionsToConfig,
@@ -87,6 +111,8 @@ var testFunctions = [...]func(*testing.T, kolide.Datastore){
 	testApplyOsqueryOptions,
 	testApplyOsqueryOptionsNoOverrides,
 	testOsqueryOptionsForHost,
+	testOsqueryLabelOptionsForHost,
+	testOsqueryIntervalsForPlatform,
 	testApplyQueries,
 	testApplyPackSpecRoundtrip,
 	testApplyPackSpecMissingQueries,
@@ -94,7 +120,28 @@ var testFunctions = [...]func(*testing.T, kolide.Datastore){
 	testGetPackSpec,
 	testApplyLabelSpecsRoundtrip,
 	testGetLabelSpec,
+	testSyncLabelSpecs,
 	testLabelIDsByName,
+	testRefreshModifiedLabels,
 	testListLabelsForPack,
 	testHostAdditional,
+	testDeleteHostsByLabel,
+	testAggregateHosts,
+	testListHostsSeenStatus,
+	testListHostsMatchQuery,
+	testStreamHosts,
+	testListHostsCursor,
+	testSetHostsConfigRefresh,
+	testExpireHostDetails,
+	testNoisyHosts,
+	testRecordTruncatedResults,
+	testDecorators,
+	testHostDetailQueries,
+	testATCTables,
+	testHostExtraDetails,
+	testHostUptimeEvents,
+	testHostActivities,
+	testIngestionFailures,
+	testActivities,
+	testAPITokens,
 }
@@ -3,9 +3,11 @@ package datastore
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func testCreateUser(t *testing.T, ds kolide.Datastore) {
@@ -53,6 +55,47 @@ func testUserByID(t *testing.T, ds kolide.Datastore) {
 	assert.NotNil(t, err)
 }
 
+func testUserLockout(t *testing.T, ds kolide.Datastore) {
+	users := createTestUsers(t, ds)
+	user := users[0]
+
+	for i := uint(1); i <= 3; i++ {
+		count, err := ds.IncrementFailedLogins(user.ID)
+		require.Nil(t, err)
+		assert.Equal(t, i, count)
+	}
+	returned, err := ds.UserByID(user.ID)
+	require.Nil(t, err)
+	assert.Equal(t, uint(3), returned.FailedLoginCount)
+	assert.Nil(t, returned.LockedUntil)
+
+	until := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	require.Nil(t, ds.LockUser(user.ID, until))
+	returned, err = ds.UserByID(user.ID)
+	require.Nil(t, err)
+	assert.Zero(t, returned.FailedLoginCount)
+	require.NotNil(t, returned.LockedUntil)
+	assert.True(t, until.Equal(*returned.LockedUntil))
+	assert.True(t, returned.Locked(time.Now()))
+	assert.False(t, returned.Locked(until.Add(time.Second)))
+
+	_, err = ds.IncrementFailedLogins(user.ID)
+	require.Nil(t, err)
+	require.Nil(t, ds.ResetFailedLogins(user.ID))
+	returned, err = ds.UserByID(user.ID)
+	require.Nil(t, err)
+	assert.Zero(t, returned.FailedLoginCount)
+
+	// Other users are unaffected
+	returned, err = ds.UserByID(users[1].ID)
+	require.Nil(t, err)
+	assert.Zero(t, returned.FailedLoginCount)
+	assert.Nil(t, returned.LockedUntil)
+
+	_, err = ds.IncrementFailedLogins(10000000000)
+	assert.NotNil(t, err)
+}
+
 func createTestUsers(t *testing.T, ds kolide.Datastore) []*kolide.User {
 	var createTests = []struct {
 		username, password, email string
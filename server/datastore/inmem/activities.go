@@ -0,0 +1,54 @@
+package inmem
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (d *Datastore) NewActivity(activity *kolide.Activity) (*kolide.Activity, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	activity.ID = d.nextID(activity)
+	activity.CreatedAt = time.Now()
+	d.activities[activity.ID] = activity
+	return activity, nil
+}
+
+func (d *Datastore) ListActivities(opt kolide.ListOptions) ([]*kolide.Activity, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	// We need to sort by keys to provide reliable ordering
+	keys := []int{}
+	for k := range d.activities {
+		keys = append(keys, int(k))
+	}
+	sort.Ints(keys)
+
+	activities := []*kolide.Activity{}
+	for _, k := range keys {
+		activities = append(activities, d.activities[uint(k)])
+	}
+
+	// Apply ordering
+	if opt.OrderKey != "" {
+		var fields = map[string]string{
+			"id":             "ID",
+			"created_at":     "CreatedAt",
+			"actor_username": "ActorUsername",
+			"action":         "Action",
+		}
+		if err := sortResults(activities, opt, fields); err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply limit/offset
+	low, high := d.getLimitOffsetSliceBounds(opt, len(activities))
+	activities = activities[low:high]
+
+	return activities, nil
+}
@@ -0,0 +1,70 @@
+package inmem
+
+import (
+	"sort"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (d *Datastore) NewAPIToken(token *kolide.APIToken) (*kolide.APIToken, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	for _, t := range d.apiTokens {
+		if t.Key == token.Key {
+			return nil, alreadyExists("APIToken", t.ID)
+		}
+	}
+
+	token.ID = d.nextID(token)
+	d.apiTokens[token.ID] = token
+	return token, nil
+}
+
+func (d *Datastore) APIToken(id uint) (*kolide.APIToken, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	token, ok := d.apiTokens[id]
+	if !ok {
+		return nil, notFound("APIToken").WithID(id)
+	}
+	return token, nil
+}
+
+func (d *Datastore) APITokenByKey(key string) (*kolide.APIToken, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	for _, token := range d.apiTokens {
+		if token.Key == key {
+			return token, nil
+		}
+	}
+	return nil, notFound("APIToken")
+}
+
+func (d *Datastore) ListAPITokensForUser(userID uint) ([]*kolide.APIToken, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	tokens := []*kolide.APIToken{}
+	for _, token := range d.apiTokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].ID < tokens[j].ID })
+	return tokens, nil
+}
+
+func (d *Datastore) DeleteAPIToken(id uint) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if _, ok := d.apiTokens[id]; !ok {
+		return notFound("APIToken").WithID(id)
+	}
+	delete(d.apiTokens, id)
+	return nil
+}
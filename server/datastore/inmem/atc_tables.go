@@ -0,0 +1,66 @@
+package inmem
+
+import (
+	"sort"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (d *Datastore) NewATCTable(table *kolide.ATCTable) (*kolide.ATCTable, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	for _, t := range d.atcTables {
+		if t.Name == table.Name {
+			return nil, alreadyExists("ATCTable", t.ID)
+		}
+	}
+	table.ID = d.nextID(table)
+	d.atcTables[table.ID] = table
+	return table, nil
+}
+
+func (d *Datastore) ATCTable(id uint) (*kolide.ATCTable, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	table, ok := d.atcTables[id]
+	if !ok {
+		return nil, notFound("ATCTable").WithID(id)
+	}
+	return table, nil
+}
+
+func (d *Datastore) SaveATCTable(table *kolide.ATCTable) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if _, ok := d.atcTables[table.ID]; !ok {
+		return notFound("ATCTable").WithID(table.ID)
+	}
+	for _, t := range d.atcTables {
+		if t.Name == table.Name && t.ID != table.ID {
+			return alreadyExists("ATCTable", t.ID)
+		}
+	}
+	d.atcTables[table.ID] = table
+	return nil
+}
+
+func (d *Datastore) DeleteATCTable(id uint) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if _, ok := d.atcTables[id]; !ok {
+		return notFound("ATCTable").WithID(id)
+	}
+	delete(d.atcTables, id)
+	return nil
+}
+
+func (d *Datastore) ListATCTables() ([]*kolide.ATCTable, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	result := []*kolide.ATCTable{}
+	for _, t := range d.atcTables {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
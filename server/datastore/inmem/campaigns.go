@@ -2,6 +2,7 @@ package inmem
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/kolide/fleet/server/kolide"
@@ -29,6 +30,23 @@ func (d *Datastore) DistributedQueryCampaign(id uint) (*kolide.DistributedQueryC
 	return &campaign, nil
 }
 
+func (d *Datastore) ListActiveDistributedQueryCampaigns() ([]*kolide.DistributedQueryCampaign, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	campaigns := []*kolide.DistributedQueryCampaign{}
+	for _, campaign := range d.distributedQueryCampaigns {
+		if campaign.Status == kolide.QueryComplete {
+			continue
+		}
+		campaign := campaign
+		campaigns = append(campaigns, &campaign)
+	}
+	sort.Slice(campaigns, func(i, j int) bool { return campaigns[i].ID < campaigns[j].ID })
+
+	return campaigns, nil
+}
+
 func (d *Datastore) SaveDistributedQueryCampaign(camp *kolide.DistributedQueryCampaign) error {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
@@ -41,6 +59,53 @@ func (d *Datastore) SaveDistributedQueryCampaign(camp *kolide.DistributedQueryCa
 	return nil
 }
 
+func (d *Datastore) CompleteDistributedQueryCampaign(id uint) (bool, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	camp, ok := d.distributedQueryCampaigns[id]
+	if !ok {
+		return false, notFound("DistributedQueryCampaign").WithID(id)
+	}
+	if camp.Status == kolide.QueryComplete {
+		return false, nil
+	}
+	camp.Status = kolide.QueryComplete
+	d.distributedQueryCampaigns[id] = camp
+	return true, nil
+}
+
+func (d *Datastore) MarkDistributedQueryCampaignDisconnected(id uint, disconnectedAt *time.Time) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	camp, ok := d.distributedQueryCampaigns[id]
+	if !ok {
+		return notFound("DistributedQueryCampaign").WithID(id)
+	}
+	camp.DisconnectedAt = disconnectedAt
+	d.distributedQueryCampaigns[id] = camp
+	return nil
+}
+
+func (d *Datastore) DistributedQueryCampaignSummary(id uint) (*kolide.DistributedQueryCampaignSummary, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	summary := &kolide.DistributedQueryCampaignSummary{}
+	for _, e := range d.distributedQueryExecutions {
+		if e.DistributedQueryCampaignID != id {
+			continue
+		}
+		summary.HostsResponded++
+		if e.Status == kolide.ExecutionFailed {
+			summary.HostsFailed++
+		}
+		summary.Rows += e.RowCount
+	}
+	return summary, nil
+}
+
 func (d *Datastore) DistributedQueryCampaignTargetIDs(id uint) (hostIDs []uint, labelIDs []uint, err error) {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
@@ -89,6 +154,18 @@ func (d *Datastore) NewDistributedQueryExecution(exec *kolide.DistributedQueryEx
 	return exec, nil
 }
 
+func (d *Datastore) DistributedQueryExecutionExists(campaignID, hostID uint) (bool, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	for _, e := range d.distributedQueryExecutions {
+		if e.DistributedQueryCampaignID == campaignID && e.HostID == hostID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (d *Datastore) CleanupDistributedQueryCampaigns(now time.Time) (expired uint, deleted uint, err error) {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
@@ -114,3 +191,28 @@ func (d *Datastore) CleanupDistributedQueryCampaigns(now time.Time) (expired uin
 
 	return expired, deleted, nil
 }
+
+func (d *Datastore) PurgeCompletedCampaigns(before time.Time) (uint, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	purged := map[uint]bool{}
+	for id, c := range d.distributedQueryCampaigns {
+		if c.Status == kolide.QueryComplete && c.CreatedAt.Before(before) {
+			delete(d.distributedQueryCampaigns, id)
+			purged[id] = true
+		}
+	}
+	for id, t := range d.distributedQueryCampaignTargets {
+		if purged[t.DistributedQueryCampaignID] {
+			delete(d.distributedQueryCampaignTargets, id)
+		}
+	}
+	for id, e := range d.distributedQueryExecutions {
+		if purged[e.DistributedQueryCampaignID] {
+			delete(d.distributedQueryExecutions, id)
+		}
+	}
+
+	return uint(len(purged)), nil
+}
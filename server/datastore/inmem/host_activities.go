@@ -0,0 +1,56 @@
+package inmem
+
+import (
+	"sort"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (d *Datastore) NewHostActivity(activity *kolide.HostActivity) (*kolide.HostActivity, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	activity.ID = d.nextID(activity)
+	d.hostActivities[activity.ID] = activity
+	return activity, nil
+}
+
+func (d *Datastore) ListHostActivities(hostID uint, opt kolide.HostActivityListOptions) ([]*kolide.HostActivity, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	types := make(map[string]bool, len(opt.Types))
+	for _, t := range opt.Types {
+		types[t] = true
+	}
+
+	activities := []*kolide.HostActivity{}
+	for _, a := range d.hostActivities {
+		if a.HostID != hostID || (len(types) > 0 && !types[a.Type]) {
+			continue
+		}
+		activities = append(activities, a)
+	}
+	// Sort by ID to provide reliable ordering
+	sort.Slice(activities, func(i, j int) bool {
+		return activities[i].ID < activities[j].ID
+	})
+
+	// Apply ordering
+	if opt.OrderKey != "" {
+		var fields = map[string]string{
+			"id":         "ID",
+			"created_at": "CreatedAt",
+			"type":       "Type",
+		}
+		if err := sortResults(activities, opt.ListOptions, fields); err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply limit/offset
+	low, high := d.getLimitOffsetSliceBounds(opt.ListOptions, len(activities))
+	activities = activities[low:high]
+
+	return activities, nil
+}
@@ -0,0 +1,41 @@
+package inmem
+
+import (
+	"sort"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (d *Datastore) NewHostDetailQuery(query *kolide.HostDetailQuery) (*kolide.HostDetailQuery, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	for _, q := range d.hostDetailQueries {
+		if q.Name == query.Name {
+			return nil, alreadyExists("HostDetailQuery", q.ID)
+		}
+	}
+	query.ID = d.nextID(query)
+	d.hostDetailQueries[query.ID] = query
+	return query, nil
+}
+
+func (d *Datastore) DeleteHostDetailQuery(id uint) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if _, ok := d.hostDetailQueries[id]; !ok {
+		return notFound("HostDetailQuery").WithID(id)
+	}
+	delete(d.hostDetailQueries, id)
+	return nil
+}
+
+func (d *Datastore) ListHostDetailQueries() ([]*kolide.HostDetailQuery, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	result := []*kolide.HostDetailQuery{}
+	for _, q := range d.hostDetailQueries {
+		result = append(result, q)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
@@ -0,0 +1,33 @@
+package inmem
+
+import (
+	"sort"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (d *Datastore) NewHostUptimeEvent(event *kolide.HostUptimeEvent) (*kolide.HostUptimeEvent, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	event.ID = d.nextID(event)
+	d.hostUptimeEvents[event.ID] = event
+	return event, nil
+}
+
+func (d *Datastore) ListHostUptimeEvents(hostID uint) ([]*kolide.HostUptimeEvent, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	events := []*kolide.HostUptimeEvent{}
+	for _, e := range d.hostUptimeEvents {
+		if e.HostID == hostID {
+			events = append(events, e)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].DetectedAt.Equal(events[j].DetectedAt) {
+			return events[i].DetectedAt.After(events[j].DetectedAt)
+		}
+		return events[i].ID > events[j].ID
+	})
+	return events, nil
+}
@@ -51,6 +51,9 @@ func (d *Datastore) DeleteHost(hid uint) error {
 
 	if _, ok := d.hosts[hid]; ok {
 		delete(d.hosts, hid)
+		for _, members := range d.labelMembership {
+			delete(members, hid)
+		}
 	}
 
 	return nil
@@ -68,7 +71,63 @@ func (d *Datastore) Host(id uint) (*kolide.Host, error) {
 	return host, nil
 }
 
-func (d *Datastore) ListHosts(opt kolide.ListOptions) ([]*kolide.Host, error) {
+func hostMatchesListFilters(host *kolide.Host, opt kolide.HostListOptions, cutoff time.Time) bool {
+	if opt.MatchQuery != "" && !hostMatches(host, opt.MatchQuery, strings.HasPrefix) {
+		return false
+	}
+	if opt.Pending != nil && host.Pending != *opt.Pending {
+		return false
+	}
+	if opt.DiskEncryption != "" {
+		status := host.DiskEncryption
+		if status == "" {
+			status = kolide.DiskEncryptionUnknown
+		}
+		if status != opt.DiskEncryption {
+			return false
+		}
+	}
+	switch opt.SeenStatus {
+	case kolide.StatusOnline:
+		return !host.SeenTime.Before(cutoff)
+	case kolide.StatusOffline:
+		return host.SeenTime.Before(cutoff)
+	}
+	return true
+}
+
+// hostMatches returns whether match(field, query) is true for the hostname,
+// primary IP, hardware serial or UUID of the host.
+func hostMatches(host *kolide.Host, query string, match func(string, string) bool) bool {
+	fields := []string{host.HostName, host.HardwareSerial, host.UUID}
+	for _, nic := range host.NetworkInterfaces {
+		if host.PrimaryNetworkInterfaceID != nil && nic.ID == *host.PrimaryNetworkInterfaceID {
+			fields = append(fields, nic.IPAddress)
+		}
+	}
+	for _, field := range fields {
+		if match(field, query) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Datastore) CountHosts(opt kolide.HostListOptions) (int, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	cutoff := time.Now().Add(-opt.SeenWithin)
+	count := 0
+	for _, host := range d.hosts {
+		if hostMatchesListFilters(host, opt, cutoff) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (d *Datastore) ListHosts(opt kolide.HostListOptions) ([]*kolide.Host, error) {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
 
@@ -79,9 +138,14 @@ func (d *Datastore) ListHosts(opt kolide.ListOptions) ([]*kolide.Host, error) {
 	}
 	sort.Ints(keys)
 
+	cutoff := time.Now().Add(-opt.SeenWithin)
 	hosts := []*kolide.Host{}
 	for _, k := range keys {
-		hosts = append(hosts, d.hosts[uint(k)])
+		host := d.hosts[uint(k)]
+		if !hostMatchesListFilters(host, opt, cutoff) {
+			continue
+		}
+		hosts = append(hosts, host)
 	}
 
 	// Apply ordering
@@ -101,19 +165,47 @@ func (d *Datastore) ListHosts(opt kolide.ListOptions) ([]*kolide.Host, error) {
 			"mac":                "PrimaryMAC",
 			"ip":                 "PrimaryIP",
 		}
-		if err := sortResults(hosts, opt, fields); err != nil {
+		if err := sortResults(hosts, opt.ListOptions, fields); err != nil {
 			return nil, err
 		}
 	}
 
+	// Rank exact matches first, keeping the requested order otherwise
+	if opt.MatchQuery != "" && opt.After == nil {
+		exact := func(field, query string) bool { return field == query }
+		sort.SliceStable(hosts, func(i, j int) bool {
+			return hostMatches(hosts[i], opt.MatchQuery, exact) && !hostMatches(hosts[j], opt.MatchQuery, exact)
+		})
+	}
+
+	// Apply cursor, skipping past the host it points at
+	if opt.After != nil {
+		if *opt.After != "" {
+			cursor, err := kolide.ParseHostCursor(*opt.After)
+			if err != nil {
+				return nil, err
+			}
+			for i, host := range hosts {
+				if host.ID == cursor.ID {
+					hosts = hosts[i+1:]
+					break
+				}
+			}
+		}
+		if opt.PerPage > 0 && uint(len(hosts)) > opt.PerPage {
+			hosts = hosts[:opt.PerPage]
+		}
+		return hosts, nil
+	}
+
 	// Apply limit/offset
-	low, high := d.getLimitOffsetSliceBounds(opt, len(hosts))
+	low, high := d.getLimitOffsetSliceBounds(opt.ListOptions, len(hosts))
 	hosts = hosts[low:high]
 
 	return hosts, nil
 }
 
-func (d *Datastore) GenerateHostStatusStatistics(now time.Time) (online, offline, mia, new uint, err error) {
+func (d *Datastore) GenerateHostStatusStatistics(now time.Time) (online, offline, mia, maintenance, new uint, err error) {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
 
@@ -128,15 +220,17 @@ func (d *Datastore) GenerateHostStatusStatistics(now time.Time) (online, offline
 			mia++
 		case kolide.StatusOffline:
 			offline++
+		case kolide.StatusMaintenance:
+			maintenance++
 		default:
 			online++
 		}
 	}
 
-	return online, offline, mia, new, nil
+	return online, offline, mia, maintenance, new, nil
 }
 
-func (d *Datastore) EnrollHost(osQueryHostID, nodeKey, secretName string) (*kolide.Host, error) {
+func (d *Datastore) EnrollHost(osQueryHostID, nodeKey, secretName string, cooldown time.Duration, pending bool) (*kolide.Host, error) {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
 
@@ -144,18 +238,31 @@ func (d *Datastore) EnrollHost(osQueryHostID, nodeKey, secretName string) (*koli
 		return nil, errors.New("missing host identifier from osquery for host enrollment")
 	}
 
+	now := d.clock.Now().UTC()
+	if cooldown > 0 {
+		for _, h := range d.hosts {
+			if h.OsqueryHostID == osQueryHostID && now.Sub(h.LastEnrollTime) < cooldown {
+				return h, nil
+			}
+		}
+	}
+
 	host := kolide.Host{
 		OsqueryHostID:    osQueryHostID,
 		NodeKey:          nodeKey,
 		DetailUpdateTime: time.Unix(0, 0).Add(24 * time.Hour),
+		LastEnrollTime:   now,
+		Pending:          pending,
 	}
 
-	host.CreatedAt = time.Now().UTC()
+	host.CreatedAt = now
 	host.UpdatedAt = host.CreatedAt
 
 	for _, h := range d.hosts {
 		if h.OsqueryHostID == osQueryHostID {
 			host = *h
+			host.NodeKey = nodeKey
+			host.LastEnrollTime = now
 			break
 		}
 	}
@@ -265,3 +372,120 @@ func (d *Datastore) DistributedQueriesForHost(host *kolide.Host) (map[uint]strin
 
 	return queries, nil
 }
+
+func (d *Datastore) SetHostMaintenance(hostID uint, until *time.Time) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	host, ok := d.hosts[hostID]
+	if !ok {
+		return notFound("Host").WithID(hostID)
+	}
+	host.MaintenanceUntil = until
+	return nil
+}
+
+func (d *Datastore) ClearElapsedHostMaintenance(now time.Time) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	for _, host := range d.hosts {
+		if host.MaintenanceUntil != nil && !now.Before(*host.MaintenanceUntil) {
+			host.MaintenanceUntil = nil
+		}
+	}
+	return nil
+}
+
+func (d *Datastore) ApproveHost(hostID uint) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	host, ok := d.hosts[hostID]
+	if !ok {
+		return notFound("Host").WithID(hostID)
+	}
+	host.Pending = false
+	return nil
+}
+
+func (d *Datastore) SetHostNodeKey(hostID uint, nodeKey string) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	host, ok := d.hosts[hostID]
+	if !ok {
+		return notFound("Host").WithID(hostID)
+	}
+	host.NodeKey = nodeKey
+	return nil
+}
+
+func (d *Datastore) ExpireHostDetails(hostID uint) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	host, ok := d.hosts[hostID]
+	if !ok {
+		return notFound("Host").WithID(hostID)
+	}
+	host.DetailUpdateTime = time.Unix(0, 0).Add(24 * time.Hour)
+	return nil
+}
+
+func (d *Datastore) RecordNoisyHost(hostID uint, dropped uint, at time.Time) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	host, ok := d.hosts[hostID]
+	if !ok {
+		return notFound("Host").WithID(hostID)
+	}
+	host.NoisyTime = &at
+	host.DroppedLogRows += dropped
+	return nil
+}
+
+func (d *Datastore) RecordTruncatedResults(hostID uint, truncated uint, at time.Time) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	host, ok := d.hosts[hostID]
+	if !ok {
+		return notFound("Host").WithID(hostID)
+	}
+	host.TruncatedResultsTime = &at
+	host.TruncatedResultRows += truncated
+	return nil
+}
+
+func (d *Datastore) ListNoisyHosts() ([]*kolide.Host, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	hosts := []*kolide.Host{}
+	for _, host := range d.hosts {
+		if host.NoisyTime != nil {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if !hosts[i].NoisyTime.Equal(*hosts[j].NoisyTime) {
+			return hosts[i].NoisyTime.After(*hosts[j].NoisyTime)
+		}
+		return hosts[i].ID < hosts[j].ID
+	})
+	return hosts, nil
+}
+
+func (d *Datastore) SetHostsConfigRefresh(hostIDs []uint, requested bool) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	for _, id := range hostIDs {
+		if host, ok := d.hosts[id]; ok {
+			host.ConfigRefreshRequested = requested
+		}
+	}
+	return nil
+}
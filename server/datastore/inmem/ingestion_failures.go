@@ -0,0 +1,62 @@
+package inmem
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (d *Datastore) NewIngestionFailure(failure *kolide.IngestionFailure) (*kolide.IngestionFailure, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	failure.ID = d.nextID(failure)
+	d.ingestionFailures[failure.ID] = failure
+	return failure, nil
+}
+
+func (d *Datastore) ListIngestionFailures(opt kolide.ListOptions) ([]*kolide.IngestionFailure, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	failures := []*kolide.IngestionFailure{}
+	for _, f := range d.ingestionFailures {
+		failures = append(failures, f)
+	}
+	// Sort by ID to provide reliable ordering
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].ID < failures[j].ID
+	})
+
+	// Apply ordering
+	if opt.OrderKey != "" {
+		var fields = map[string]string{
+			"id":         "ID",
+			"host_id":    "HostID",
+			"query_name": "QueryName",
+			"created_at": "CreatedAt",
+		}
+		if err := sortResults(failures, opt, fields); err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply limit/offset
+	low, high := d.getLimitOffsetSliceBounds(opt, len(failures))
+	failures = failures[low:high]
+
+	return failures, nil
+}
+
+func (d *Datastore) CleanupIngestionFailures(before time.Time) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	for id, f := range d.ingestionFailures {
+		if f.CreatedAt.Before(before) {
+			delete(d.ingestionFailures, id)
+		}
+	}
+	return nil
+}
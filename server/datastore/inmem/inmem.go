@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/WatchBeam/clock"
 	"github.com/kolide/fleet/server/config"
 	"github.com/kolide/fleet/server/datastore/internal/appstate"
 	"github.com/kolide/fleet/server/kolide"
@@ -24,6 +25,7 @@ type Datastore struct {
 	invites                         map[uint]*kolide.Invite
 	labels                          map[uint]*kolide.Label
 	labelQueryExecutions            map[uint]*kolide.LabelQueryExecution
+	labelMembership                 map[uint]map[uint]bool
 	queries                         map[uint]*kolide.Query
 	packs                           map[uint]*kolide.Pack
 	hosts                           map[uint]*kolide.Host
@@ -34,21 +36,44 @@ type Datastore struct {
 	distributedQueryCampaignTargets map[uint]kolide.DistributedQueryCampaignTarget
 	options                         map[uint]*kolide.Option
 	decorators                      map[uint]*kolide.Decorator
+	hostDetailQueries               map[uint]*kolide.HostDetailQuery
+	atcTables                       map[uint]*kolide.ATCTable
+	hostUptimeEvents                map[uint]*kolide.HostUptimeEvent
+	hostActivities                  map[uint]*kolide.HostActivity
+	ingestionFailures               map[uint]*kolide.IngestionFailure
+	activities                      map[uint]*kolide.Activity
+	apiTokens                       map[uint]*kolide.APIToken
 	filePaths                       map[uint]*kolide.FIMSection
 	yaraFilePaths                   kolide.YARAFilePaths
 	yaraSignatureGroups             map[uint]*kolide.YARASignatureGroup
 	appConfig                       *kolide.AppConfig
 	config                          *config.KolideConfig
+	clock                           clock.Clock
 
 	// Embedded interface to avoid implementing new methods for (now
 	// deprecated) inmem.
 	kolide.Datastore
 }
 
-func New(config config.KolideConfig) (*Datastore, error) {
+// DBOption configures the in-memory datastore.
+type DBOption func(d *Datastore)
+
+// Clock sets the clock of the datastore, which is the system clock by
+// default.
+func Clock(c clock.Clock) DBOption {
+	return func(d *Datastore) {
+		d.clock = c
+	}
+}
+
+func New(config config.KolideConfig, opts ...DBOption) (*Datastore, error) {
 	ds := &Datastore{
 		Driver: "inmem",
 		config: &config,
+		clock:  clock.C,
+	}
+	for _, setOpt := range opts {
+		setOpt(ds)
 	}
 
 	if err := ds.MigrateTables(); err != nil {
@@ -97,6 +122,7 @@ func (d *Datastore) MigrateTables() error {
 	d.invites = make(map[uint]*kolide.Invite)
 	d.labels = make(map[uint]*kolide.Label)
 	d.labelQueryExecutions = make(map[uint]*kolide.LabelQueryExecution)
+	d.labelMembership = make(map[uint]map[uint]bool)
 	d.queries = make(map[uint]*kolide.Query)
 	d.packs = make(map[uint]*kolide.Pack)
 	d.hosts = make(map[uint]*kolide.Host)
@@ -107,6 +133,13 @@ func (d *Datastore) MigrateTables() error {
 	d.distributedQueryCampaignTargets = make(map[uint]kolide.DistributedQueryCampaignTarget)
 	d.options = make(map[uint]*kolide.Option)
 	d.decorators = make(map[uint]*kolide.Decorator)
+	d.hostDetailQueries = make(map[uint]*kolide.HostDetailQuery)
+	d.atcTables = make(map[uint]*kolide.ATCTable)
+	d.hostUptimeEvents = make(map[uint]*kolide.HostUptimeEvent)
+	d.hostActivities = make(map[uint]*kolide.HostActivity)
+	d.ingestionFailures = make(map[uint]*kolide.IngestionFailure)
+	d.activities = make(map[uint]*kolide.Activity)
+	d.apiTokens = make(map[uint]*kolide.APIToken)
 	d.filePaths = make(map[uint]*kolide.FIMSection)
 	d.yaraFilePaths = make(kolide.YARAFilePaths)
 	d.yaraSignatureGroups = make(map[uint]*kolide.YARASignatureGroup)
@@ -42,6 +42,12 @@ func (d *Datastore) ListLabelsForHost(hid uint) ([]kolide.Label, error) {
 		}
 	}
 
+	for lid, members := range d.labelMembership {
+		if label := d.labels[lid]; label != nil && members[hid] {
+			resLabels = append(resLabels, *label)
+		}
+	}
+
 	return resLabels, nil
 }
 
@@ -59,6 +65,9 @@ func (d *Datastore) LabelQueriesForHost(host *kolide.Host, cutoff time.Time) (ma
 
 	queries := map[string]string{}
 	for _, label := range d.labels {
+		if label.LabelType == kolide.LabelTypeComputed || label.LabelType == kolide.LabelTypeManual {
+			continue
+		}
 		if (label.Platform == "" || strings.Contains(label.Platform, host.Platform)) && !execedIDs[label.ID] {
 			queries[strconv.Itoa(int(label.ID))] = label.Query
 		}
@@ -183,6 +192,29 @@ func (d *Datastore) ListHostsInLabel(lid uint) ([]kolide.Host, error) {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
 
+	if label, ok := d.labels[lid]; ok && label.LabelType == kolide.LabelTypeComputed {
+		if label.Criteria == nil {
+			return hosts, nil
+		}
+		now := time.Now()
+		for _, h := range d.hosts {
+			if label.Criteria.Matches(h, now) {
+				hosts = append(hosts, *h)
+			}
+		}
+		return hosts, nil
+	}
+
+	if label, ok := d.labels[lid]; ok && label.LabelType == kolide.LabelTypeManual {
+		for hid := range d.labelMembership[lid] {
+			if h, ok := d.hosts[hid]; ok {
+				hosts = append(hosts, *h)
+			}
+		}
+		sortutil.AscByField(hosts, "ID")
+		return hosts, nil
+	}
+
 	for _, lqe := range d.labelQueryExecutions {
 		if lqe.LabelID == lid && lqe.Matches {
 			hosts = append(hosts, *d.hosts[lqe.HostID])
@@ -214,9 +246,52 @@ func (d *Datastore) ListUniqueHostsInLabels(labels []uint) ([]kolide.Host, error
 		}
 	}
 
+	for lid := range labelSet {
+		for hid := range d.labelMembership[lid] {
+			if h, ok := d.hosts[hid]; ok && !hostSet[hid] {
+				hosts = append(hosts, *h)
+				hostSet[hid] = true
+			}
+		}
+	}
+
 	return hosts, nil
 }
 
+func (d *Datastore) AddHostsToLabel(lid uint, hostIDs []uint) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if _, ok := d.labels[lid]; !ok {
+		return notFound("Label").WithID(lid)
+	}
+	for _, hid := range hostIDs {
+		if _, ok := d.hosts[hid]; !ok {
+			return notFound("Host").WithID(hid)
+		}
+	}
+
+	members, ok := d.labelMembership[lid]
+	if !ok {
+		members = make(map[uint]bool)
+		d.labelMembership[lid] = members
+	}
+	for _, hid := range hostIDs {
+		members[hid] = true
+	}
+	return nil
+}
+
+func (d *Datastore) RemoveHostsFromLabel(lid uint, hostIDs []uint) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	for _, hid := range hostIDs {
+		delete(d.labelMembership[lid], hid)
+	}
+	return nil
+}
+
 func (d *Datastore) SaveLabel(label *kolide.Label) (*kolide.Label, error) {
 	panic("inmem is being deprecated")
 }
@@ -2,6 +2,7 @@ package inmem
 
 import (
 	"sort"
+	"strings"
 
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/pkg/errors"
@@ -21,6 +22,9 @@ func (d *Datastore) NewQuery(query *kolide.Query, opts ...kolide.OptionalArg) (*
 
 	newQuery.ID = d.nextID(newQuery)
 	newQuery.Packs = []kolide.Pack{}
+	if newQuery.Tags == nil {
+		newQuery.Tags = []string{}
+	}
 	d.queries[newQuery.ID] = &newQuery
 
 	return &newQuery, nil
@@ -80,7 +84,7 @@ func (d *Datastore) Query(id uint) (*kolide.Query, error) {
 	return query, nil
 }
 
-func (d *Datastore) ListQueries(opt kolide.ListOptions) ([]*kolide.Query, error) {
+func (d *Datastore) ListQueries(opt kolide.ListQueryOptions) ([]*kolide.Query, error) {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
 
@@ -94,7 +98,20 @@ func (d *Datastore) ListQueries(opt kolide.ListOptions) ([]*kolide.Query, error)
 	queries := []*kolide.Query{}
 	for _, k := range keys {
 		q := d.queries[uint(k)]
-		if q.Saved {
+		if q.Saved && (opt.IncludeDeleted || !q.Deleted) {
+			if opt.AuthorID != nil && (q.AuthorID == nil || *q.AuthorID != *opt.AuthorID) {
+				continue
+			}
+			if opt.MatchQuery != "" {
+				match := strings.ToLower(opt.MatchQuery)
+				if !strings.Contains(strings.ToLower(q.Name), match) &&
+					!strings.Contains(strings.ToLower(q.Query), match) {
+					continue
+				}
+			}
+			if len(opt.Tags) > 0 && !queryHasAnyTag(q, opt.Tags) {
+				continue
+			}
 			q.AuthorName = d.getUserNameByID(*q.AuthorID)
 			queries = append(queries, q)
 		}
@@ -114,13 +131,13 @@ func (d *Datastore) ListQueries(opt kolide.ListOptions) ([]*kolide.Query, error)
 			"platform":     "Platform",
 			"version":      "Version",
 		}
-		if err := sortResults(queries, opt, fields); err != nil {
+		if err := sortResults(queries, opt.ListOptions, fields); err != nil {
 			return nil, err
 		}
 	}
 
 	// Apply limit/offset
-	low, high := d.getLimitOffsetSliceBounds(opt, len(queries))
+	low, high := d.getLimitOffsetSliceBounds(opt.ListOptions, len(queries))
 	queries = queries[low:high]
 
 	if err := d.loadPacksForQueries(queries); err != nil {
@@ -130,6 +147,44 @@ func (d *Datastore) ListQueries(opt kolide.ListOptions) ([]*kolide.Query, error)
 	return queries, nil
 }
 
+func queryHasAnyTag(q *kolide.Query, tags []string) bool {
+	for _, tag := range tags {
+		for _, qt := range q.Tags {
+			if strings.EqualFold(qt, tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (d *Datastore) ListQueryTags() ([]kolide.QueryTag, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	counts := map[string]uint{}
+	for _, q := range d.queries {
+		if !q.Saved || q.Deleted {
+			continue
+		}
+		for _, tag := range q.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := []kolide.QueryTag{}
+	for tag, count := range counts {
+		tags = append(tags, kolide.QueryTag{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	return tags, nil
+}
+
 // loadPacksForQueries loads the packs associated with the provided queries
 func (d *Datastore) loadPacksForQueries(queries []*kolide.Query) error {
 	for _, q := range queries {
@@ -82,4 +82,18 @@ func (d *Datastore) MarkSessionAccessed(session *kolide.Session) error {
 	return nil
 }
 
+func (d *Datastore) DestroyExpiredSessions(createdBefore, accessedBefore time.Time) (uint, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	var destroyed uint
+	for id, session := range d.sessions {
+		if session.CreatedAt.Before(createdBefore) || session.AccessedAt.Before(accessedBefore) {
+			delete(d.sessions, id)
+			destroyed++
+		}
+	}
+	return destroyed, nil
+}
+
 // TODO test session validation(expiration)
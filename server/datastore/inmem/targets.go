@@ -10,3 +10,8 @@ func (d *Datastore) CountHostsInTargets(hostIDs, labelIDs []uint, now time.Time)
 	// noop
 	return kolide.TargetMetrics{}, nil
 }
+
+func (d *Datastore) HostIDsInTargets(hostIDs, labelIDs []uint) ([]uint, error) {
+	// noop
+	return []uint{}, nil
+}
@@ -3,6 +3,7 @@ package inmem
 import (
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/kolide/fleet/server/kolide"
 )
@@ -114,3 +115,40 @@ func (d *Datastore) SaveUser(user *kolide.User) error {
 	d.users[user.ID] = user
 	return nil
 }
+
+func (d *Datastore) IncrementFailedLogins(userID uint) (uint, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	user, ok := d.users[userID]
+	if !ok {
+		return 0, notFound("User").WithID(userID)
+	}
+	user.FailedLoginCount++
+	return user.FailedLoginCount, nil
+}
+
+func (d *Datastore) LockUser(userID uint, until time.Time) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	user, ok := d.users[userID]
+	if !ok {
+		return notFound("User").WithID(userID)
+	}
+	user.LockedUntil = &until
+	user.FailedLoginCount = 0
+	return nil
+}
+
+func (d *Datastore) ResetFailedLogins(userID uint) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	user, ok := d.users[userID]
+	if !ok {
+		return notFound("User").WithID(userID)
+	}
+	user.FailedLoginCount = 0
+	return nil
+}
@@ -0,0 +1,17 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) NewActivity(activity *kolide.Activity) (result *kolide.Activity, err error) {
+	defer mw.observe("NewActivity", time.Now(), &err)
+	return mw.Datastore.NewActivity(activity)
+}
+
+func (mw metricsDatastore) ListActivities(opt kolide.ListOptions) (activitys []*kolide.Activity, err error) {
+	defer mw.observe("ListActivities", time.Now(), &err)
+	return mw.Datastore.ListActivities(opt)
+}
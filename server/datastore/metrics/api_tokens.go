@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) NewAPIToken(token *kolide.APIToken) (result *kolide.APIToken, err error) {
+	defer mw.observe("NewAPIToken", time.Now(), &err)
+	return mw.Datastore.NewAPIToken(token)
+}
+
+func (mw metricsDatastore) APIToken(id uint) (result *kolide.APIToken, err error) {
+	defer mw.observe("APIToken", time.Now(), &err)
+	return mw.Datastore.APIToken(id)
+}
+
+func (mw metricsDatastore) APITokenByKey(key string) (result *kolide.APIToken, err error) {
+	defer mw.observe("APITokenByKey", time.Now(), &err)
+	return mw.Datastore.APITokenByKey(key)
+}
+
+func (mw metricsDatastore) ListAPITokensForUser(userID uint) (tokens []*kolide.APIToken, err error) {
+	defer mw.observe("ListAPITokensForUser", time.Now(), &err)
+	return mw.Datastore.ListAPITokensForUser(userID)
+}
+
+func (mw metricsDatastore) DeleteAPIToken(id uint) (err error) {
+	defer mw.observe("DeleteAPIToken", time.Now(), &err)
+	return mw.Datastore.DeleteAPIToken(id)
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) NewAppConfig(info *kolide.AppConfig) (appConfig *kolide.AppConfig, err error) {
+	defer mw.observe("NewAppConfig", time.Now(), &err)
+	return mw.Datastore.NewAppConfig(info)
+}
+
+func (mw metricsDatastore) AppConfig() (appConfig *kolide.AppConfig, err error) {
+	defer mw.observe("AppConfig", time.Now(), &err)
+	return mw.Datastore.AppConfig()
+}
+
+func (mw metricsDatastore) SaveAppConfig(info *kolide.AppConfig) (err error) {
+	defer mw.observe("SaveAppConfig", time.Now(), &err)
+	return mw.Datastore.SaveAppConfig(info)
+}
+
+func (mw metricsDatastore) VerifyEnrollSecret(secret string) (enrollSecret *kolide.EnrollSecret, err error) {
+	defer mw.observe("VerifyEnrollSecret", time.Now(), &err)
+	return mw.Datastore.VerifyEnrollSecret(secret)
+}
+
+func (mw metricsDatastore) ApplyEnrollSecretSpec(spec *kolide.EnrollSecretSpec) (err error) {
+	defer mw.observe("ApplyEnrollSecretSpec", time.Now(), &err)
+	return mw.Datastore.ApplyEnrollSecretSpec(spec)
+}
+
+func (mw metricsDatastore) GetEnrollSecretSpec() (enrollSecretSpec *kolide.EnrollSecretSpec, err error) {
+	defer mw.observe("GetEnrollSecretSpec", time.Now(), &err)
+	return mw.Datastore.GetEnrollSecretSpec()
+}
+
+func (mw metricsDatastore) EnrollSecretUsage() (usage []kolide.EnrollSecretUsage, err error) {
+	defer mw.observe("EnrollSecretUsage", time.Now(), &err)
+	return mw.Datastore.EnrollSecretUsage()
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) NewATCTable(table *kolide.ATCTable) (result *kolide.ATCTable, err error) {
+	defer mw.observe("NewATCTable", time.Now(), &err)
+	return mw.Datastore.NewATCTable(table)
+}
+
+func (mw metricsDatastore) ATCTable(id uint) (table *kolide.ATCTable, err error) {
+	defer mw.observe("ATCTable", time.Now(), &err)
+	return mw.Datastore.ATCTable(id)
+}
+
+func (mw metricsDatastore) SaveATCTable(table *kolide.ATCTable) (err error) {
+	defer mw.observe("SaveATCTable", time.Now(), &err)
+	return mw.Datastore.SaveATCTable(table)
+}
+
+func (mw metricsDatastore) DeleteATCTable(id uint) (err error) {
+	defer mw.observe("DeleteATCTable", time.Now(), &err)
+	return mw.Datastore.DeleteATCTable(id)
+}
+
+func (mw metricsDatastore) ListATCTables() (tables []*kolide.ATCTable, err error) {
+	defer mw.observe("ListATCTables", time.Now(), &err)
+	return mw.Datastore.ListATCTables()
+}
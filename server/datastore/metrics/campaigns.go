@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) NewDistributedQueryCampaign(camp *kolide.DistributedQueryCampaign) (distributedQueryCampaign *kolide.DistributedQueryCampaign, err error) {
+	defer mw.observe("NewDistributedQueryCampaign", time.Now(), &err)
+	return mw.Datastore.NewDistributedQueryCampaign(camp)
+}
+
+func (mw metricsDatastore) DistributedQueryCampaign(id uint) (distributedQueryCampaign *kolide.DistributedQueryCampaign, err error) {
+	defer mw.observe("DistributedQueryCampaign", time.Now(), &err)
+	return mw.Datastore.DistributedQueryCampaign(id)
+}
+
+func (mw metricsDatastore) ListActiveDistributedQueryCampaigns() (campaigns []*kolide.DistributedQueryCampaign, err error) {
+	defer mw.observe("ListActiveDistributedQueryCampaigns", time.Now(), &err)
+	return mw.Datastore.ListActiveDistributedQueryCampaigns()
+}
+
+func (mw metricsDatastore) SaveDistributedQueryCampaign(camp *kolide.DistributedQueryCampaign) (err error) {
+	defer mw.observe("SaveDistributedQueryCampaign", time.Now(), &err)
+	return mw.Datastore.SaveDistributedQueryCampaign(camp)
+}
+
+func (mw metricsDatastore) CompleteDistributedQueryCampaign(id uint) (completed bool, err error) {
+	defer mw.observe("CompleteDistributedQueryCampaign", time.Now(), &err)
+	return mw.Datastore.CompleteDistributedQueryCampaign(id)
+}
+
+func (mw metricsDatastore) MarkDistributedQueryCampaignDisconnected(id uint, disconnectedAt *time.Time) (err error) {
+	defer mw.observe("MarkDistributedQueryCampaignDisconnected", time.Now(), &err)
+	return mw.Datastore.MarkDistributedQueryCampaignDisconnected(id, disconnectedAt)
+}
+
+func (mw metricsDatastore) DistributedQueryCampaignSummary(id uint) (summary *kolide.DistributedQueryCampaignSummary, err error) {
+	defer mw.observe("DistributedQueryCampaignSummary", time.Now(), &err)
+	return mw.Datastore.DistributedQueryCampaignSummary(id)
+}
+
+func (mw metricsDatastore) DistributedQueryCampaignTargetIDs(id uint) (hostIDs []uint, labelIDs []uint, err error) {
+	defer mw.observe("DistributedQueryCampaignTargetIDs", time.Now(), &err)
+	return mw.Datastore.DistributedQueryCampaignTargetIDs(id)
+}
+
+func (mw metricsDatastore) NewDistributedQueryCampaignTarget(target *kolide.DistributedQueryCampaignTarget) (distributedQueryCampaignTarget *kolide.DistributedQueryCampaignTarget, err error) {
+	defer mw.observe("NewDistributedQueryCampaignTarget", time.Now(), &err)
+	return mw.Datastore.NewDistributedQueryCampaignTarget(target)
+}
+
+func (mw metricsDatastore) NewDistributedQueryExecution(exec *kolide.DistributedQueryExecution) (distributedQueryExecution *kolide.DistributedQueryExecution, err error) {
+	defer mw.observe("NewDistributedQueryExecution", time.Now(), &err)
+	return mw.Datastore.NewDistributedQueryExecution(exec)
+}
+
+func (mw metricsDatastore) DistributedQueryExecutionExists(campaignID, hostID uint) (exists bool, err error) {
+	defer mw.observe("DistributedQueryExecutionExists", time.Now(), &err)
+	return mw.Datastore.DistributedQueryExecutionExists(campaignID, hostID)
+}
+
+func (mw metricsDatastore) CleanupDistributedQueryCampaigns(now time.Time) (expired uint, deleted uint, err error) {
+	defer mw.observe("CleanupDistributedQueryCampaigns", time.Now(), &err)
+	return mw.Datastore.CleanupDistributedQueryCampaigns(now)
+}
+
+func (mw metricsDatastore) PurgeCompletedCampaigns(before time.Time) (count uint, err error) {
+	defer mw.observe("PurgeCompletedCampaigns", time.Now(), &err)
+	return mw.Datastore.PurgeCompletedCampaigns(before)
+}
@@ -0,0 +1,50 @@
+// Package metrics provides a kolide.Datastore middleware that instruments
+// datastore calls.
+package metrics
+
+import (
+	"fmt"
+	"time"
+
+	kitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/kolide/fleet/server/health"
+	"github.com/kolide/fleet/server/kolide"
+)
+
+type metricsDatastore struct {
+	kolide.Datastore
+	callCount   kitmetrics.Counter
+	callLatency kitmetrics.Histogram
+}
+
+// NewDatastore takes an existing datastore and wraps it with instrumentation
+// middleware. Each call is recorded with labels for the method name and
+// whether it returned an error.
+func NewDatastore(
+	ds kolide.Datastore,
+	callCount kitmetrics.Counter,
+	callLatency kitmetrics.Histogram,
+) kolide.Datastore {
+	return metricsDatastore{
+		Datastore:   ds,
+		callCount:   callCount,
+		callLatency: callLatency,
+	}
+}
+
+// observe records a call to method that started at begin. It takes a pointer
+// to the error so that it can be deferred before the call returns.
+func (mw metricsDatastore) observe(method string, begin time.Time, err *error) {
+	lvs := []string{"method", method, "error", fmt.Sprint(*err != nil)}
+	mw.callCount.With(lvs...).Add(1)
+	mw.callLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+}
+
+// HealthCheck checks the wrapped datastore, so that instrumenting the
+// datastore does not remove it from the health checks.
+func (mw metricsDatastore) HealthCheck() error {
+	if hc, ok := mw.Datastore.(health.Checker); ok {
+		return hc.HealthCheck()
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	kitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/generic"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// labelCounter records the label values of each call to a counter.
+type labelCounter struct {
+	calls *[][]string
+	lvs   []string
+}
+
+func (c labelCounter) With(lvs ...string) kitmetrics.Counter {
+	return labelCounter{calls: c.calls, lvs: append(c.lvs, lvs...)}
+}
+
+func (c labelCounter) Add(delta float64) {
+	*c.calls = append(*c.calls, c.lvs)
+}
+
+func TestDatastoreMetrics(t *testing.T) {
+	store := new(mock.Store)
+	store.AuthenticateHostFunc = func(nodeKey string) (*kolide.Host, error) {
+		if nodeKey != "key" {
+			return nil, errors.New("invalid node key")
+		}
+		return &kolide.Host{ID: 1}, nil
+	}
+	store.ListHostsFunc = func(opt kolide.HostListOptions) ([]*kolide.Host, error) {
+		return []*kolide.Host{{ID: 1}, {ID: 2}}, nil
+	}
+
+	var calls [][]string
+	callCount := labelCounter{calls: &calls}
+	callLatency := generic.NewHistogram("call_latency_seconds", 10)
+	ds := NewDatastore(store, callCount, callLatency)
+
+	host, err := ds.AuthenticateHost("key")
+	require.Nil(t, err)
+	assert.Equal(t, uint(1), host.ID)
+	_, err = ds.AuthenticateHost("bad")
+	require.NotNil(t, err)
+	hosts, err := ds.ListHosts(kolide.HostListOptions{})
+	require.Nil(t, err)
+	assert.Len(t, hosts, 2)
+
+	assert.True(t, store.AuthenticateHostFuncInvoked)
+	assert.True(t, store.ListHostsFuncInvoked)
+	assert.Equal(t, [][]string{
+		{"method", "AuthenticateHost", "error", "false"},
+		{"method", "AuthenticateHost", "error", "true"},
+		{"method", "ListHosts", "error", "false"},
+	}, calls)
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) NewDecorator(decorator *kolide.Decorator, opts ...kolide.OptionalArg) (result *kolide.Decorator, err error) {
+	defer mw.observe("NewDecorator", time.Now(), &err)
+	return mw.Datastore.NewDecorator(decorator, opts...)
+}
+
+func (mw metricsDatastore) DeleteDecorator(id uint) (err error) {
+	defer mw.observe("DeleteDecorator", time.Now(), &err)
+	return mw.Datastore.DeleteDecorator(id)
+}
+
+func (mw metricsDatastore) Decorator(id uint) (decorator *kolide.Decorator, err error) {
+	defer mw.observe("Decorator", time.Now(), &err)
+	return mw.Datastore.Decorator(id)
+}
+
+func (mw metricsDatastore) ListDecorators(opts ...kolide.OptionalArg) (decorators []*kolide.Decorator, err error) {
+	defer mw.observe("ListDecorators", time.Now(), &err)
+	return mw.Datastore.ListDecorators(opts...)
+}
+
+func (mw metricsDatastore) SaveDecorator(dec *kolide.Decorator, opts ...kolide.OptionalArg) (err error) {
+	defer mw.observe("SaveDecorator", time.Now(), &err)
+	return mw.Datastore.SaveDecorator(dec, opts...)
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) NewFIMSection(path *kolide.FIMSection, opts ...kolide.OptionalArg) (fimSection *kolide.FIMSection, err error) {
+	defer mw.observe("NewFIMSection", time.Now(), &err)
+	return mw.Datastore.NewFIMSection(path, opts...)
+}
+
+func (mw metricsDatastore) FIMSections() (fimSections kolide.FIMSections, err error) {
+	defer mw.observe("FIMSections", time.Now(), &err)
+	return mw.Datastore.FIMSections()
+}
+
+func (mw metricsDatastore) ClearFIMSections() (err error) {
+	defer mw.observe("ClearFIMSections", time.Now(), &err)
+	return mw.Datastore.ClearFIMSections()
+}
@@ -0,0 +1,17 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) NewHostActivity(activity *kolide.HostActivity) (result *kolide.HostActivity, err error) {
+	defer mw.observe("NewHostActivity", time.Now(), &err)
+	return mw.Datastore.NewHostActivity(activity)
+}
+
+func (mw metricsDatastore) ListHostActivities(hostID uint, opt kolide.HostActivityListOptions) (activities []*kolide.HostActivity, err error) {
+	defer mw.observe("ListHostActivities", time.Now(), &err)
+	return mw.Datastore.ListHostActivities(hostID, opt)
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) NewHostDetailQuery(query *kolide.HostDetailQuery) (result *kolide.HostDetailQuery, err error) {
+	defer mw.observe("NewHostDetailQuery", time.Now(), &err)
+	return mw.Datastore.NewHostDetailQuery(query)
+}
+
+func (mw metricsDatastore) DeleteHostDetailQuery(id uint) (err error) {
+	defer mw.observe("DeleteHostDetailQuery", time.Now(), &err)
+	return mw.Datastore.DeleteHostDetailQuery(id)
+}
+
+func (mw metricsDatastore) ListHostDetailQueries() (queries []*kolide.HostDetailQuery, err error) {
+	defer mw.observe("ListHostDetailQueries", time.Now(), &err)
+	return mw.Datastore.ListHostDetailQueries()
+}
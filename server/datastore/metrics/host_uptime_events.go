@@ -0,0 +1,17 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) NewHostUptimeEvent(event *kolide.HostUptimeEvent) (result *kolide.HostUptimeEvent, err error) {
+	defer mw.observe("NewHostUptimeEvent", time.Now(), &err)
+	return mw.Datastore.NewHostUptimeEvent(event)
+}
+
+func (mw metricsDatastore) ListHostUptimeEvents(hostID uint) (events []*kolide.HostUptimeEvent, err error) {
+	defer mw.observe("ListHostUptimeEvents", time.Now(), &err)
+	return mw.Datastore.ListHostUptimeEvents(hostID)
+}
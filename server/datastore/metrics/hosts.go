@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) NewHost(host *kolide.Host) (result *kolide.Host, err error) {
+	defer mw.observe("NewHost", time.Now(), &err)
+	return mw.Datastore.NewHost(host)
+}
+
+func (mw metricsDatastore) SaveHost(host *kolide.Host) (err error) {
+	defer mw.observe("SaveHost", time.Now(), &err)
+	return mw.Datastore.SaveHost(host)
+}
+
+func (mw metricsDatastore) DeleteHost(hid uint) (err error) {
+	defer mw.observe("DeleteHost", time.Now(), &err)
+	return mw.Datastore.DeleteHost(hid)
+}
+
+func (mw metricsDatastore) DeleteHostsByLabel(lid uint) (count int, err error) {
+	defer mw.observe("DeleteHostsByLabel", time.Now(), &err)
+	return mw.Datastore.DeleteHostsByLabel(lid)
+}
+
+func (mw metricsDatastore) Host(id uint) (host *kolide.Host, err error) {
+	defer mw.observe("Host", time.Now(), &err)
+	return mw.Datastore.Host(id)
+}
+
+func (mw metricsDatastore) ListHosts(opt kolide.HostListOptions) (hosts []*kolide.Host, err error) {
+	defer mw.observe("ListHosts", time.Now(), &err)
+	return mw.Datastore.ListHosts(opt)
+}
+
+func (mw metricsDatastore) CountHosts(opt kolide.HostListOptions) (count int, err error) {
+	defer mw.observe("CountHosts", time.Now(), &err)
+	return mw.Datastore.CountHosts(opt)
+}
+
+func (mw metricsDatastore) StreamHosts(opt kolide.HostListOptions, fn func(*kolide.Host) error) (err error) {
+	defer mw.observe("StreamHosts", time.Now(), &err)
+	return mw.Datastore.StreamHosts(opt, fn)
+}
+
+func (mw metricsDatastore) EnrollHost(osqueryHostId string, nodeKey string, secretName string, cooldown time.Duration, pending bool) (host *kolide.Host, err error) {
+	defer mw.observe("EnrollHost", time.Now(), &err)
+	return mw.Datastore.EnrollHost(osqueryHostId, nodeKey, secretName, cooldown, pending)
+}
+
+func (mw metricsDatastore) AuthenticateHost(nodeKey string) (host *kolide.Host, err error) {
+	defer mw.observe("AuthenticateHost", time.Now(), &err)
+	return mw.Datastore.AuthenticateHost(nodeKey)
+}
+
+func (mw metricsDatastore) MarkHostSeen(host *kolide.Host, t time.Time) (err error) {
+	defer mw.observe("MarkHostSeen", time.Now(), &err)
+	return mw.Datastore.MarkHostSeen(host, t)
+}
+
+func (mw metricsDatastore) SearchHosts(query string, omit ...uint) (hosts []*kolide.Host, err error) {
+	defer mw.observe("SearchHosts", time.Now(), &err)
+	return mw.Datastore.SearchHosts(query, omit...)
+}
+
+func (mw metricsDatastore) CleanupIncomingHosts(now time.Time) (err error) {
+	defer mw.observe("CleanupIncomingHosts", time.Now(), &err)
+	return mw.Datastore.CleanupIncomingHosts(now)
+}
+
+func (mw metricsDatastore) GenerateHostStatusStatistics(now time.Time) (online uint, offline uint, mia uint, maintenance uint, new uint, err error) {
+	defer mw.observe("GenerateHostStatusStatistics", time.Now(), &err)
+	return mw.Datastore.GenerateHostStatusStatistics(now)
+}
+
+func (mw metricsDatastore) DistributedQueriesForHost(host *kolide.Host) (results map[uint]string, err error) {
+	defer mw.observe("DistributedQueriesForHost", time.Now(), &err)
+	return mw.Datastore.DistributedQueriesForHost(host)
+}
+
+func (mw metricsDatastore) HostIDsByName(hostnames []string) (ids []uint, err error) {
+	defer mw.observe("HostIDsByName", time.Now(), &err)
+	return mw.Datastore.HostIDsByName(hostnames)
+}
+
+func (mw metricsDatastore) HostIDsByIdentifier(identifiers []string) (ids []uint, err error) {
+	defer mw.observe("HostIDsByIdentifier", time.Now(), &err)
+	return mw.Datastore.HostIDsByIdentifier(identifiers)
+}
+
+func (mw metricsDatastore) SetHostsConfigRefresh(hostIDs []uint, requested bool) (err error) {
+	defer mw.observe("SetHostsConfigRefresh", time.Now(), &err)
+	return mw.Datastore.SetHostsConfigRefresh(hostIDs, requested)
+}
+
+func (mw metricsDatastore) SetHostMaintenance(hostID uint, until *time.Time) (err error) {
+	defer mw.observe("SetHostMaintenance", time.Now(), &err)
+	return mw.Datastore.SetHostMaintenance(hostID, until)
+}
+
+func (mw metricsDatastore) ClearElapsedHostMaintenance(now time.Time) (err error) {
+	defer mw.observe("ClearElapsedHostMaintenance", time.Now(), &err)
+	return mw.Datastore.ClearElapsedHostMaintenance(now)
+}
+
+func (mw metricsDatastore) ApproveHost(hostID uint) (err error) {
+	defer mw.observe("ApproveHost", time.Now(), &err)
+	return mw.Datastore.ApproveHost(hostID)
+}
+
+func (mw metricsDatastore) SetHostNodeKey(hostID uint, nodeKey string) (err error) {
+	defer mw.observe("SetHostNodeKey", time.Now(), &err)
+	return mw.Datastore.SetHostNodeKey(hostID, nodeKey)
+}
+
+func (mw metricsDatastore) ExpireHostDetails(hostID uint) (err error) {
+	defer mw.observe("ExpireHostDetails", time.Now(), &err)
+	return mw.Datastore.ExpireHostDetails(hostID)
+}
+
+func (mw metricsDatastore) RecordNoisyHost(hostID uint, dropped uint, at time.Time) (err error) {
+	defer mw.observe("RecordNoisyHost", time.Now(), &err)
+	return mw.Datastore.RecordNoisyHost(hostID, dropped, at)
+}
+
+func (mw metricsDatastore) RecordTruncatedResults(hostID uint, truncated uint, at time.Time) (err error) {
+	defer mw.observe("RecordTruncatedResults", time.Now(), &err)
+	return mw.Datastore.RecordTruncatedResults(hostID, truncated, at)
+}
+
+func (mw metricsDatastore) ListNoisyHosts() (hosts []*kolide.Host, err error) {
+	defer mw.observe("ListNoisyHosts", time.Now(), &err)
+	return mw.Datastore.ListNoisyHosts()
+}
+
+func (mw metricsDatastore) AggregateHosts(groupBy string) (aggregates []kolide.HostAggregate, err error) {
+	defer mw.observe("AggregateHosts", time.Now(), &err)
+	return mw.Datastore.AggregateHosts(groupBy)
+}
+
+func (mw metricsDatastore) MergeHosts(keepID uint, mergeIDs []uint) (merge kolide.HostMerge, err error) {
+	defer mw.observe("MergeHosts", time.Now(), &err)
+	return mw.Datastore.MergeHosts(keepID, mergeIDs)
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) NewIngestionFailure(failure *kolide.IngestionFailure) (result *kolide.IngestionFailure, err error) {
+	defer mw.observe("NewIngestionFailure", time.Now(), &err)
+	return mw.Datastore.NewIngestionFailure(failure)
+}
+
+func (mw metricsDatastore) ListIngestionFailures(opt kolide.ListOptions) (failures []*kolide.IngestionFailure, err error) {
+	defer mw.observe("ListIngestionFailures", time.Now(), &err)
+	return mw.Datastore.ListIngestionFailures(opt)
+}
+
+func (mw metricsDatastore) CleanupIngestionFailures(before time.Time) (err error) {
+	defer mw.observe("CleanupIngestionFailures", time.Now(), &err)
+	return mw.Datastore.CleanupIngestionFailures(before)
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) NewInvite(i *kolide.Invite) (invite *kolide.Invite, err error) {
+	defer mw.observe("NewInvite", time.Now(), &err)
+	return mw.Datastore.NewInvite(i)
+}
+
+func (mw metricsDatastore) ListInvites(opt kolide.ListOptions) (invites []*kolide.Invite, err error) {
+	defer mw.observe("ListInvites", time.Now(), &err)
+	return mw.Datastore.ListInvites(opt)
+}
+
+func (mw metricsDatastore) Invite(id uint) (invite *kolide.Invite, err error) {
+	defer mw.observe("Invite", time.Now(), &err)
+	return mw.Datastore.Invite(id)
+}
+
+func (mw metricsDatastore) InviteByEmail(email string) (invite *kolide.Invite, err error) {
+	defer mw.observe("InviteByEmail", time.Now(), &err)
+	return mw.Datastore.InviteByEmail(email)
+}
+
+func (mw metricsDatastore) InviteByToken(token string) (invite *kolide.Invite, err error) {
+	defer mw.observe("InviteByToken", time.Now(), &err)
+	return mw.Datastore.InviteByToken(token)
+}
+
+func (mw metricsDatastore) SaveInvite(i *kolide.Invite) (err error) {
+	defer mw.observe("SaveInvite", time.Now(), &err)
+	return mw.Datastore.SaveInvite(i)
+}
+
+func (mw metricsDatastore) DeleteInvite(id uint) (err error) {
+	defer mw.observe("DeleteInvite", time.Now(), &err)
+	return mw.Datastore.DeleteInvite(id)
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) ApplyLabelSpecs(specs []*kolide.LabelSpec) (err error) {
+	defer mw.observe("ApplyLabelSpecs", time.Now(), &err)
+	return mw.Datastore.ApplyLabelSpecs(specs)
+}
+
+func (mw metricsDatastore) SyncLabelSpecs(specs []*kolide.LabelSpec, prune bool) (result *kolide.ApplyLabelSpecsResult, err error) {
+	defer mw.observe("SyncLabelSpecs", time.Now(), &err)
+	return mw.Datastore.SyncLabelSpecs(specs, prune)
+}
+
+func (mw metricsDatastore) GetLabelSpecs() (labelSpecs []*kolide.LabelSpec, err error) {
+	defer mw.observe("GetLabelSpecs", time.Now(), &err)
+	return mw.Datastore.GetLabelSpecs()
+}
+
+func (mw metricsDatastore) GetLabelSpec(name string) (labelSpec *kolide.LabelSpec, err error) {
+	defer mw.observe("GetLabelSpec", time.Now(), &err)
+	return mw.Datastore.GetLabelSpec(name)
+}
+
+func (mw metricsDatastore) NewLabel(Label *kolide.Label, opts ...kolide.OptionalArg) (label *kolide.Label, err error) {
+	defer mw.observe("NewLabel", time.Now(), &err)
+	return mw.Datastore.NewLabel(Label, opts...)
+}
+
+func (mw metricsDatastore) SaveLabel(label *kolide.Label) (result *kolide.Label, err error) {
+	defer mw.observe("SaveLabel", time.Now(), &err)
+	return mw.Datastore.SaveLabel(label)
+}
+
+func (mw metricsDatastore) DeleteLabel(name string) (err error) {
+	defer mw.observe("DeleteLabel", time.Now(), &err)
+	return mw.Datastore.DeleteLabel(name)
+}
+
+func (mw metricsDatastore) Label(lid uint) (label *kolide.Label, err error) {
+	defer mw.observe("Label", time.Now(), &err)
+	return mw.Datastore.Label(lid)
+}
+
+func (mw metricsDatastore) ListLabels(opt kolide.ListOptions) (labels []*kolide.Label, err error) {
+	defer mw.observe("ListLabels", time.Now(), &err)
+	return mw.Datastore.ListLabels(opt)
+}
+
+func (mw metricsDatastore) LabelQueriesForHost(host *kolide.Host, cutoff time.Time) (results map[string]string, err error) {
+	defer mw.observe("LabelQueriesForHost", time.Now(), &err)
+	return mw.Datastore.LabelQueriesForHost(host, cutoff)
+}
+
+func (mw metricsDatastore) RecordLabelQueryExecutions(host *kolide.Host, results map[uint]bool, t time.Time) (err error) {
+	defer mw.observe("RecordLabelQueryExecutions", time.Now(), &err)
+	return mw.Datastore.RecordLabelQueryExecutions(host, results, t)
+}
+
+func (mw metricsDatastore) ListLabelsForHost(hid uint) (labels []kolide.Label, err error) {
+	defer mw.observe("ListLabelsForHost", time.Now(), &err)
+	return mw.Datastore.ListLabelsForHost(hid)
+}
+
+func (mw metricsDatastore) ListHostsInLabel(lid uint) (hosts []kolide.Host, err error) {
+	defer mw.observe("ListHostsInLabel", time.Now(), &err)
+	return mw.Datastore.ListHostsInLabel(lid)
+}
+
+func (mw metricsDatastore) ListUniqueHostsInLabels(labels []uint) (hosts []kolide.Host, err error) {
+	defer mw.observe("ListUniqueHostsInLabels", time.Now(), &err)
+	return mw.Datastore.ListUniqueHostsInLabels(labels)
+}
+
+func (mw metricsDatastore) SearchLabels(query string, omit ...uint) (labels []kolide.Label, err error) {
+	defer mw.observe("SearchLabels", time.Now(), &err)
+	return mw.Datastore.SearchLabels(query, omit...)
+}
+
+func (mw metricsDatastore) LabelIDsByName(labels []string) (ids []uint, err error) {
+	defer mw.observe("LabelIDsByName", time.Now(), &err)
+	return mw.Datastore.LabelIDsByName(labels)
+}
+
+func (mw metricsDatastore) AddHostsToLabel(lid uint, hostIDs []uint) (err error) {
+	defer mw.observe("AddHostsToLabel", time.Now(), &err)
+	return mw.Datastore.AddHostsToLabel(lid, hostIDs)
+}
+
+func (mw metricsDatastore) RemoveHostsFromLabel(lid uint, hostIDs []uint) (err error) {
+	defer mw.observe("RemoveHostsFromLabel", time.Now(), &err)
+	return mw.Datastore.RemoveHostsFromLabel(lid, hostIDs)
+}
+
+func (mw metricsDatastore) TransferHostsToLabel(lid uint, hostIDs []uint) (missing []uint, err error) {
+	defer mw.observe("TransferHostsToLabel", time.Now(), &err)
+	return mw.Datastore.TransferHostsToLabel(lid, hostIDs)
+}
+
+func (mw metricsDatastore) RefreshModifiedLabels(limit int) (ids []uint, err error) {
+	defer mw.observe("RefreshModifiedLabels", time.Now(), &err)
+	return mw.Datastore.RefreshModifiedLabels(limit)
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) SaveOptions(opts []kolide.Option, args ...kolide.OptionalArg) (err error) {
+	defer mw.observe("SaveOptions", time.Now(), &err)
+	return mw.Datastore.SaveOptions(opts, args...)
+}
+
+func (mw metricsDatastore) ListOptions() (options []kolide.Option, err error) {
+	defer mw.observe("ListOptions", time.Now(), &err)
+	return mw.Datastore.ListOptions()
+}
+
+func (mw metricsDatastore) Option(id uint) (option *kolide.Option, err error) {
+	defer mw.observe("Option", time.Now(), &err)
+	return mw.Datastore.Option(id)
+}
+
+func (mw metricsDatastore) OptionByName(name string, args ...kolide.OptionalArg) (option *kolide.Option, err error) {
+	defer mw.observe("OptionByName", time.Now(), &err)
+	return mw.Datastore.OptionByName(name, args...)
+}
+
+func (mw metricsDatastore) GetOsqueryConfigOptions() (results map[string]interface{}, err error) {
+	defer mw.observe("GetOsqueryConfigOptions", time.Now(), &err)
+	return mw.Datastore.GetOsqueryConfigOptions()
+}
+
+func (mw metricsDatastore) ResetOptions() (options []kolide.Option, err error) {
+	defer mw.observe("ResetOptions", time.Now(), &err)
+	return mw.Datastore.ResetOptions()
+}
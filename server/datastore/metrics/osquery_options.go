@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) ApplyOptions(options *kolide.OptionsSpec) (err error) {
+	defer mw.observe("ApplyOptions", time.Now(), &err)
+	return mw.Datastore.ApplyOptions(options)
+}
+
+func (mw metricsDatastore) GetOptions() (optionsSpec *kolide.OptionsSpec, err error) {
+	defer mw.observe("GetOptions", time.Now(), &err)
+	return mw.Datastore.GetOptions()
+}
+
+func (mw metricsDatastore) OptionsForPlatform(platform string) (rawMessage json.RawMessage, err error) {
+	defer mw.observe("OptionsForPlatform", time.Now(), &err)
+	return mw.Datastore.OptionsForPlatform(platform)
+}
+
+func (mw metricsDatastore) LabelOptionsForHost(hostID uint) (options map[string]json.RawMessage, err error) {
+	defer mw.observe("LabelOptionsForHost", time.Now(), &err)
+	return mw.Datastore.LabelOptionsForHost(hostID)
+}
+
+func (mw metricsDatastore) IntervalsForPlatform(platform string) (intervals *kolide.OsqueryIntervals, err error) {
+	defer mw.observe("IntervalsForPlatform", time.Now(), &err)
+	return mw.Datastore.IntervalsForPlatform(platform)
+}
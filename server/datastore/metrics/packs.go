@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) ApplyPackSpecs(specs []*kolide.PackSpec) (err error) {
+	defer mw.observe("ApplyPackSpecs", time.Now(), &err)
+	return mw.Datastore.ApplyPackSpecs(specs)
+}
+
+func (mw metricsDatastore) GetPackSpecs() (packSpecs []*kolide.PackSpec, err error) {
+	defer mw.observe("GetPackSpecs", time.Now(), &err)
+	return mw.Datastore.GetPackSpecs()
+}
+
+func (mw metricsDatastore) GetPackSpec(name string) (packSpec *kolide.PackSpec, err error) {
+	defer mw.observe("GetPackSpec", time.Now(), &err)
+	return mw.Datastore.GetPackSpec(name)
+}
+
+func (mw metricsDatastore) NewPack(pack *kolide.Pack, opts ...kolide.OptionalArg) (result *kolide.Pack, err error) {
+	defer mw.observe("NewPack", time.Now(), &err)
+	return mw.Datastore.NewPack(pack, opts...)
+}
+
+func (mw metricsDatastore) SavePack(pack *kolide.Pack) (err error) {
+	defer mw.observe("SavePack", time.Now(), &err)
+	return mw.Datastore.SavePack(pack)
+}
+
+func (mw metricsDatastore) DeletePack(name string) (err error) {
+	defer mw.observe("DeletePack", time.Now(), &err)
+	return mw.Datastore.DeletePack(name)
+}
+
+func (mw metricsDatastore) Pack(pid uint) (pack *kolide.Pack, err error) {
+	defer mw.observe("Pack", time.Now(), &err)
+	return mw.Datastore.Pack(pid)
+}
+
+func (mw metricsDatastore) ListPacks(opt kolide.ListOptions) (packs []*kolide.Pack, err error) {
+	defer mw.observe("ListPacks", time.Now(), &err)
+	return mw.Datastore.ListPacks(opt)
+}
+
+func (mw metricsDatastore) PackByName(name string, opts ...kolide.OptionalArg) (pack *kolide.Pack, ok bool, err error) {
+	defer mw.observe("PackByName", time.Now(), &err)
+	return mw.Datastore.PackByName(name, opts...)
+}
+
+func (mw metricsDatastore) AddLabelToPack(lid uint, pid uint, opts ...kolide.OptionalArg) (err error) {
+	defer mw.observe("AddLabelToPack", time.Now(), &err)
+	return mw.Datastore.AddLabelToPack(lid, pid, opts...)
+}
+
+func (mw metricsDatastore) RemoveLabelFromPack(lid uint, pid uint) (err error) {
+	defer mw.observe("RemoveLabelFromPack", time.Now(), &err)
+	return mw.Datastore.RemoveLabelFromPack(lid, pid)
+}
+
+func (mw metricsDatastore) ListLabelsForPack(pid uint) (labels []*kolide.Label, err error) {
+	defer mw.observe("ListLabelsForPack", time.Now(), &err)
+	return mw.Datastore.ListLabelsForPack(pid)
+}
+
+func (mw metricsDatastore) AddHostToPack(hid uint, pid uint) (err error) {
+	defer mw.observe("AddHostToPack", time.Now(), &err)
+	return mw.Datastore.AddHostToPack(hid, pid)
+}
+
+func (mw metricsDatastore) RemoveHostFromPack(hid uint, pid uint) (err error) {
+	defer mw.observe("RemoveHostFromPack", time.Now(), &err)
+	return mw.Datastore.RemoveHostFromPack(hid, pid)
+}
+
+func (mw metricsDatastore) ListPacksForHost(hid uint) (packs []*kolide.Pack, err error) {
+	defer mw.observe("ListPacksForHost", time.Now(), &err)
+	return mw.Datastore.ListPacksForHost(hid)
+}
+
+func (mw metricsDatastore) ListHostsInPack(pid uint, opt kolide.ListOptions) (ids []uint, err error) {
+	defer mw.observe("ListHostsInPack", time.Now(), &err)
+	return mw.Datastore.ListHostsInPack(pid, opt)
+}
+
+func (mw metricsDatastore) ListExplicitHostsInPack(pid uint, opt kolide.ListOptions) (ids []uint, err error) {
+	defer mw.observe("ListExplicitHostsInPack", time.Now(), &err)
+	return mw.Datastore.ListExplicitHostsInPack(pid, opt)
+}
+
+func (mw metricsDatastore) ListHostsMissingPack(pid uint, opt kolide.HostListOptions) (hosts []kolide.Host, err error) {
+	defer mw.observe("ListHostsMissingPack", time.Now(), &err)
+	return mw.Datastore.ListHostsMissingPack(pid, opt)
+}
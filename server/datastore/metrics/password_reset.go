@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) NewPasswordResetRequest(req *kolide.PasswordResetRequest) (passwordResetRequest *kolide.PasswordResetRequest, err error) {
+	defer mw.observe("NewPasswordResetRequest", time.Now(), &err)
+	return mw.Datastore.NewPasswordResetRequest(req)
+}
+
+func (mw metricsDatastore) SavePasswordResetRequest(req *kolide.PasswordResetRequest) (err error) {
+	defer mw.observe("SavePasswordResetRequest", time.Now(), &err)
+	return mw.Datastore.SavePasswordResetRequest(req)
+}
+
+func (mw metricsDatastore) DeletePasswordResetRequest(req *kolide.PasswordResetRequest) (err error) {
+	defer mw.observe("DeletePasswordResetRequest", time.Now(), &err)
+	return mw.Datastore.DeletePasswordResetRequest(req)
+}
+
+func (mw metricsDatastore) DeletePasswordResetRequestsForUser(userID uint) (err error) {
+	defer mw.observe("DeletePasswordResetRequestsForUser", time.Now(), &err)
+	return mw.Datastore.DeletePasswordResetRequestsForUser(userID)
+}
+
+func (mw metricsDatastore) FindPassswordResetByID(id uint) (passwordResetRequest *kolide.PasswordResetRequest, err error) {
+	defer mw.observe("FindPassswordResetByID", time.Now(), &err)
+	return mw.Datastore.FindPassswordResetByID(id)
+}
+
+func (mw metricsDatastore) FindPassswordResetsByUserID(id uint) (passwordResetRequests []*kolide.PasswordResetRequest, err error) {
+	defer mw.observe("FindPassswordResetsByUserID", time.Now(), &err)
+	return mw.Datastore.FindPassswordResetsByUserID(id)
+}
+
+func (mw metricsDatastore) FindPassswordResetByToken(token string) (passwordResetRequest *kolide.PasswordResetRequest, err error) {
+	defer mw.observe("FindPassswordResetByToken", time.Now(), &err)
+	return mw.Datastore.FindPassswordResetByToken(token)
+}
+
+func (mw metricsDatastore) FindPassswordResetByTokenAndUserID(token string, id uint) (passwordResetRequest *kolide.PasswordResetRequest, err error) {
+	defer mw.observe("FindPassswordResetByTokenAndUserID", time.Now(), &err)
+	return mw.Datastore.FindPassswordResetByTokenAndUserID(token, id)
+}
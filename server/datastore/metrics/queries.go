@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) ApplyQueries(authorID uint, queries []*kolide.Query) (err error) {
+	defer mw.observe("ApplyQueries", time.Now(), &err)
+	return mw.Datastore.ApplyQueries(authorID, queries)
+}
+
+func (mw metricsDatastore) NewQuery(query *kolide.Query, opts ...kolide.OptionalArg) (result *kolide.Query, err error) {
+	defer mw.observe("NewQuery", time.Now(), &err)
+	return mw.Datastore.NewQuery(query, opts...)
+}
+
+func (mw metricsDatastore) SaveQuery(query *kolide.Query) (err error) {
+	defer mw.observe("SaveQuery", time.Now(), &err)
+	return mw.Datastore.SaveQuery(query)
+}
+
+func (mw metricsDatastore) DeleteQuery(name string) (err error) {
+	defer mw.observe("DeleteQuery", time.Now(), &err)
+	return mw.Datastore.DeleteQuery(name)
+}
+
+func (mw metricsDatastore) DeleteQueries(ids []uint) (count uint, err error) {
+	defer mw.observe("DeleteQueries", time.Now(), &err)
+	return mw.Datastore.DeleteQueries(ids)
+}
+
+func (mw metricsDatastore) Query(id uint) (query *kolide.Query, err error) {
+	defer mw.observe("Query", time.Now(), &err)
+	return mw.Datastore.Query(id)
+}
+
+func (mw metricsDatastore) ListQueries(opt kolide.ListQueryOptions) (querys []*kolide.Query, err error) {
+	defer mw.observe("ListQueries", time.Now(), &err)
+	return mw.Datastore.ListQueries(opt)
+}
+
+func (mw metricsDatastore) QueryByName(name string, opts ...kolide.OptionalArg) (query *kolide.Query, err error) {
+	defer mw.observe("QueryByName", time.Now(), &err)
+	return mw.Datastore.QueryByName(name, opts...)
+}
+
+func (mw metricsDatastore) RestoreQuery(id uint) (err error) {
+	defer mw.observe("RestoreQuery", time.Now(), &err)
+	return mw.Datastore.RestoreQuery(id)
+}
+
+func (mw metricsDatastore) PurgeDeletedQueries(before time.Time) (count uint, err error) {
+	defer mw.observe("PurgeDeletedQueries", time.Now(), &err)
+	return mw.Datastore.PurgeDeletedQueries(before)
+}
+
+func (mw metricsDatastore) ListQueryTags() (tags []kolide.QueryTag, err error) {
+	defer mw.observe("ListQueryTags", time.Now(), &err)
+	return mw.Datastore.ListQueryTags()
+}
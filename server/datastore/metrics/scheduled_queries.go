@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) ListScheduledQueriesInPack(id uint, opts kolide.ListOptions) (scheduledQuerys []*kolide.ScheduledQuery, err error) {
+	defer mw.observe("ListScheduledQueriesInPack", time.Now(), &err)
+	return mw.Datastore.ListScheduledQueriesInPack(id, opts)
+}
+
+func (mw metricsDatastore) NewScheduledQuery(sq *kolide.ScheduledQuery, opts ...kolide.OptionalArg) (scheduledQuery *kolide.ScheduledQuery, err error) {
+	defer mw.observe("NewScheduledQuery", time.Now(), &err)
+	return mw.Datastore.NewScheduledQuery(sq, opts...)
+}
+
+func (mw metricsDatastore) SaveScheduledQuery(sq *kolide.ScheduledQuery) (scheduledQuery *kolide.ScheduledQuery, err error) {
+	defer mw.observe("SaveScheduledQuery", time.Now(), &err)
+	return mw.Datastore.SaveScheduledQuery(sq)
+}
+
+func (mw metricsDatastore) ModifyScheduledQueriesInPack(packID uint, patch kolide.ScheduledQueryPatch) (modified int, err error) {
+	defer mw.observe("ModifyScheduledQueriesInPack", time.Now(), &err)
+	return mw.Datastore.ModifyScheduledQueriesInPack(packID, patch)
+}
+
+func (mw metricsDatastore) DeleteScheduledQuery(id uint) (err error) {
+	defer mw.observe("DeleteScheduledQuery", time.Now(), &err)
+	return mw.Datastore.DeleteScheduledQuery(id)
+}
+
+func (mw metricsDatastore) ScheduledQuery(id uint) (scheduledQuery *kolide.ScheduledQuery, err error) {
+	defer mw.observe("ScheduledQuery", time.Now(), &err)
+	return mw.Datastore.ScheduledQuery(id)
+}
+
+func (mw metricsDatastore) SaveScheduledQueryStats(hostID uint, stats []kolide.ScheduledQueryStats) (err error) {
+	defer mw.observe("SaveScheduledQueryStats", time.Now(), &err)
+	return mw.Datastore.SaveScheduledQueryStats(hostID, stats)
+}
+
+func (mw metricsDatastore) ScheduledQueryStats(packID uint) (stats []*kolide.AggregatedScheduledQueryStats, err error) {
+	defer mw.observe("ScheduledQueryStats", time.Now(), &err)
+	return mw.Datastore.ScheduledQueryStats(packID)
+}
+
+func (mw metricsDatastore) ListScheduledQueryStatsForHost(hostID uint) (stats []kolide.ScheduledQueryStats, err error) {
+	defer mw.observe("ListScheduledQueryStatsForHost", time.Now(), &err)
+	return mw.Datastore.ListScheduledQueryStatsForHost(hostID)
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) SessionByKey(key string) (session *kolide.Session, err error) {
+	defer mw.observe("SessionByKey", time.Now(), &err)
+	return mw.Datastore.SessionByKey(key)
+}
+
+func (mw metricsDatastore) SessionByID(id uint) (session *kolide.Session, err error) {
+	defer mw.observe("SessionByID", time.Now(), &err)
+	return mw.Datastore.SessionByID(id)
+}
+
+func (mw metricsDatastore) ListSessionsForUser(id uint) (sessions []*kolide.Session, err error) {
+	defer mw.observe("ListSessionsForUser", time.Now(), &err)
+	return mw.Datastore.ListSessionsForUser(id)
+}
+
+func (mw metricsDatastore) NewSession(session *kolide.Session) (result *kolide.Session, err error) {
+	defer mw.observe("NewSession", time.Now(), &err)
+	return mw.Datastore.NewSession(session)
+}
+
+func (mw metricsDatastore) DestroySession(session *kolide.Session) (err error) {
+	defer mw.observe("DestroySession", time.Now(), &err)
+	return mw.Datastore.DestroySession(session)
+}
+
+func (mw metricsDatastore) DestroyAllSessionsForUser(id uint) (err error) {
+	defer mw.observe("DestroyAllSessionsForUser", time.Now(), &err)
+	return mw.Datastore.DestroyAllSessionsForUser(id)
+}
+
+func (mw metricsDatastore) MarkSessionAccessed(session *kolide.Session) (err error) {
+	defer mw.observe("MarkSessionAccessed", time.Now(), &err)
+	return mw.Datastore.MarkSessionAccessed(session)
+}
+
+func (mw metricsDatastore) DestroyExpiredSessions(createdBefore, accessedBefore time.Time) (destroyed uint, err error) {
+	defer mw.observe("DestroyExpiredSessions", time.Now(), &err)
+	return mw.Datastore.DestroyExpiredSessions(createdBefore, accessedBefore)
+}
@@ -0,0 +1,17 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) CountHostsInTargets(hostIDs []uint, labelIDs []uint, now time.Time) (targetMetrics kolide.TargetMetrics, err error) {
+	defer mw.observe("CountHostsInTargets", time.Now(), &err)
+	return mw.Datastore.CountHostsInTargets(hostIDs, labelIDs, now)
+}
+
+func (mw metricsDatastore) HostIDsInTargets(hostIDs []uint, labelIDs []uint) (ids []uint, err error) {
+	defer mw.observe("HostIDsInTargets", time.Now(), &err)
+	return mw.Datastore.HostIDsInTargets(hostIDs, labelIDs)
+}
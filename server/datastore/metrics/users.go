@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) NewUser(user *kolide.User) (result *kolide.User, err error) {
+	defer mw.observe("NewUser", time.Now(), &err)
+	return mw.Datastore.NewUser(user)
+}
+
+func (mw metricsDatastore) User(username string) (user *kolide.User, err error) {
+	defer mw.observe("User", time.Now(), &err)
+	return mw.Datastore.User(username)
+}
+
+func (mw metricsDatastore) ListUsers(opt kolide.ListOptions) (users []*kolide.User, err error) {
+	defer mw.observe("ListUsers", time.Now(), &err)
+	return mw.Datastore.ListUsers(opt)
+}
+
+func (mw metricsDatastore) UserByEmail(email string) (user *kolide.User, err error) {
+	defer mw.observe("UserByEmail", time.Now(), &err)
+	return mw.Datastore.UserByEmail(email)
+}
+
+func (mw metricsDatastore) UserByID(id uint) (user *kolide.User, err error) {
+	defer mw.observe("UserByID", time.Now(), &err)
+	return mw.Datastore.UserByID(id)
+}
+
+func (mw metricsDatastore) SaveUser(user *kolide.User) (err error) {
+	defer mw.observe("SaveUser", time.Now(), &err)
+	return mw.Datastore.SaveUser(user)
+}
+
+func (mw metricsDatastore) PendingEmailChange(userID uint, newEmail string, token string) (err error) {
+	defer mw.observe("PendingEmailChange", time.Now(), &err)
+	return mw.Datastore.PendingEmailChange(userID, newEmail, token)
+}
+
+func (mw metricsDatastore) ConfirmPendingEmailChange(userID uint, token string) (value string, err error) {
+	defer mw.observe("ConfirmPendingEmailChange", time.Now(), &err)
+	return mw.Datastore.ConfirmPendingEmailChange(userID, token)
+}
+
+func (mw metricsDatastore) IncrementFailedLogins(userID uint) (count uint, err error) {
+	defer mw.observe("IncrementFailedLogins", time.Now(), &err)
+	return mw.Datastore.IncrementFailedLogins(userID)
+}
+
+func (mw metricsDatastore) LockUser(userID uint, until time.Time) (err error) {
+	defer mw.observe("LockUser", time.Now(), &err)
+	return mw.Datastore.LockUser(userID, until)
+}
+
+func (mw metricsDatastore) ResetFailedLogins(userID uint) (err error) {
+	defer mw.observe("ResetFailedLogins", time.Now(), &err)
+	return mw.Datastore.ResetFailedLogins(userID)
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw metricsDatastore) NewYARASignatureGroup(ysg *kolide.YARASignatureGroup, opts ...kolide.OptionalArg) (yaraSignatureGroup *kolide.YARASignatureGroup, err error) {
+	defer mw.observe("NewYARASignatureGroup", time.Now(), &err)
+	return mw.Datastore.NewYARASignatureGroup(ysg, opts...)
+}
+
+func (mw metricsDatastore) NewYARAFilePath(fileSectionName string, sigGroupName string, opts ...kolide.OptionalArg) (err error) {
+	defer mw.observe("NewYARAFilePath", time.Now(), &err)
+	return mw.Datastore.NewYARAFilePath(fileSectionName, sigGroupName, opts...)
+}
+
+func (mw metricsDatastore) YARASection() (yaraSection *kolide.YARASection, err error) {
+	defer mw.observe("YARASection", time.Now(), &err)
+	return mw.Datastore.YARASection()
+}
@@ -0,0 +1,39 @@
+package mysql
+
+import (
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
+)
+
+func (d *Datastore) NewActivity(activity *kolide.Activity) (*kolide.Activity, error) {
+	activity.CreatedAt = d.clock.Now()
+	sqlStatement := `
+		INSERT INTO activities (
+			created_at,
+			actor_id,
+			actor_username,
+			action,
+			target_id,
+			target_name
+		) VALUES ( ?, ?, ?, ?, ?, ? )
+	`
+	result, err := d.db.Exec(sqlStatement, activity.CreatedAt, activity.ActorID,
+		activity.ActorUsername, activity.Action, activity.TargetID, activity.TargetName)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating activity")
+	}
+	id, _ := result.LastInsertId()
+	activity.ID = uint(id)
+	return activity, nil
+}
+
+// ListActivities lists the recorded activities. Supply query options using
+// the opt parameter. See kolide.ListOptions
+func (d *Datastore) ListActivities(opt kolide.ListOptions) ([]*kolide.Activity, error) {
+	activities := []*kolide.Activity{}
+	query := appendListOptionsToSQL("SELECT * FROM activities", opt)
+	if err := d.db.Select(&activities, query); err != nil {
+		return nil, errors.Wrap(err, "listing activities")
+	}
+	return activities, nil
+}
@@ -0,0 +1,73 @@
+package mysql
+
+import (
+	"database/sql"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
+)
+
+func (d *Datastore) NewAPIToken(token *kolide.APIToken) (*kolide.APIToken, error) {
+	sqlStatement := `
+		INSERT INTO api_tokens (
+			user_id,
+			name,
+			` + "`key`" + `,
+			scopes
+		) VALUES ( ?, ?, ?, ? )
+	`
+	result, err := d.db.Exec(sqlStatement, token.UserID, token.Name, token.Key, token.Scopes)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating api token")
+	}
+	id, _ := result.LastInsertId()
+	token.ID = uint(id)
+	return token, nil
+}
+
+func (d *Datastore) APIToken(id uint) (*kolide.APIToken, error) {
+	token := &kolide.APIToken{}
+	err := d.db.Get(token, "SELECT * FROM api_tokens WHERE id = ?", id)
+	if err == sql.ErrNoRows {
+		return nil, notFound("APIToken").WithID(id)
+	} else if err != nil {
+		return nil, errors.Wrap(err, "selecting api token")
+	}
+	return token, nil
+}
+
+func (d *Datastore) APITokenByKey(key string) (*kolide.APIToken, error) {
+	sqlStatement := `
+		SELECT * FROM api_tokens
+			WHERE ` + "`key`" + ` = ? LIMIT 1
+	`
+	token := &kolide.APIToken{}
+	err := d.db.Get(token, sqlStatement, key)
+	if err == sql.ErrNoRows {
+		return nil, notFound("APIToken")
+	} else if err != nil {
+		return nil, errors.Wrap(err, "selecting api token by key")
+	}
+	return token, nil
+}
+
+func (d *Datastore) ListAPITokensForUser(userID uint) ([]*kolide.APIToken, error) {
+	tokens := []*kolide.APIToken{}
+	err := d.db.Select(&tokens, "SELECT * FROM api_tokens WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing api tokens for user")
+	}
+	return tokens, nil
+}
+
+func (d *Datastore) DeleteAPIToken(id uint) error {
+	result, err := d.db.Exec("DELETE FROM api_tokens WHERE id = ?", id)
+	if err != nil {
+		return errors.Wrap(err, "deleting api token")
+	}
+	rows, _ := result.RowsAffected()
+	if rows != 1 {
+		return notFound("APIToken").WithID(id)
+	}
+	return nil
+}
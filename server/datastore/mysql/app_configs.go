@@ -1,6 +1,7 @@
 package mysql
 
 import (
+	"database/sql"
 	"fmt"
 
 	"github.com/VividCortex/mysqlerr"
@@ -119,9 +120,22 @@ func (d *Datastore) SaveAppConfig(info *kolide.AppConfig) error {
       host_expiry_enabled,
       host_expiry_window,
       live_query_disabled,
-      additional_queries
+      additional_queries,
+      enrollment_webhook_url,
+      enrollment_webhook_secret,
+      campaign_webhook_url,
+      campaign_webhook_secret,
+      campaign_webhook_require_results,
+      password_min_length,
+      password_require_number,
+      password_require_symbol,
+      password_require_mixed_case,
+      session_duration,
+      session_idle_timeout,
+      required_osquery_version,
+      query_deny_list
     )
-    VALUES( 1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ? )
+    VALUES( 1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ? )
     ON DUPLICATE KEY UPDATE
       org_name = VALUES(org_name),
       org_logo_url = VALUES(org_logo_url),
@@ -150,7 +164,20 @@ func (d *Datastore) SaveAppConfig(info *kolide.AppConfig) error {
       host_expiry_enabled = VALUES(host_expiry_enabled),
       host_expiry_window = VALUES(host_expiry_window),
       live_query_disabled = VALUES(live_query_disabled),
-      additional_queries = VALUES(additional_queries)
+      additional_queries = VALUES(additional_queries),
+      enrollment_webhook_url = VALUES(enrollment_webhook_url),
+      enrollment_webhook_secret = VALUES(enrollment_webhook_secret),
+      campaign_webhook_url = VALUES(campaign_webhook_url),
+      campaign_webhook_secret = VALUES(campaign_webhook_secret),
+      campaign_webhook_require_results = VALUES(campaign_webhook_require_results),
+      password_min_length = VALUES(password_min_length),
+      password_require_number = VALUES(password_require_number),
+      password_require_symbol = VALUES(password_require_symbol),
+      password_require_mixed_case = VALUES(password_require_mixed_case),
+      session_duration = VALUES(session_duration),
+      session_idle_timeout = VALUES(session_idle_timeout),
+      required_osquery_version = VALUES(required_osquery_version),
+      query_deny_list = VALUES(query_deny_list)
     `
 
 	_, err = d.db.Exec(insertStatement,
@@ -182,35 +209,71 @@ func (d *Datastore) SaveAppConfig(info *kolide.AppConfig) error {
 		info.HostExpiryWindow,
 		info.LiveQueryDisabled,
 		info.AdditionalQueries,
+		info.EnrollmentWebhookURL,
+		info.EnrollmentWebhookSecret,
+		info.CampaignWebhookURL,
+		info.CampaignWebhookSecret,
+		info.CampaignWebhookRequireResults,
+		info.PasswordMinLength,
+		info.PasswordRequireNumber,
+		info.PasswordRequireSymbol,
+		info.PasswordRequireMixedCase,
+		info.SessionDuration,
+		info.SessionIdleTimeout,
+		info.RequiredOsqueryVersion,
+		info.QueryDenyList,
 	)
 
 	return err
 }
 
-func (d *Datastore) VerifyEnrollSecret(secret string) (string, error) {
+func (d *Datastore) VerifyEnrollSecret(secret string) (*kolide.EnrollSecret, error) {
 	var s kolide.EnrollSecret
-	err := d.db.Get(&s, "SELECT name, active FROM enroll_secrets WHERE secret = ?", secret)
+	err := d.db.Get(&s, "SELECT name, active, expires_at, label_id FROM enroll_secrets WHERE secret = ?", secret)
 	if err != nil {
-		return "", errors.New("no matching secret found")
+		return nil, errors.New("no matching secret found")
 	}
 	if !s.Active {
-		return "", errors.New("secret is inactive")
+		return nil, errors.New("secret is inactive")
 	}
 
-	return s.Name, nil
+	return &s, nil
 }
 
 func (d *Datastore) ApplyEnrollSecretSpec(spec *kolide.EnrollSecretSpec) error {
 	err := d.withRetryTxx(func(tx *sqlx.Tx) error {
 		for _, secret := range spec.Secrets {
+			var labelID *uint
+			if secret.Label != nil {
+				var label struct {
+					ID        uint
+					LabelType kolide.LabelType `db:"label_type"`
+				}
+				query := `SELECT id, label_type FROM labels WHERE name = ? AND NOT deleted`
+				if err := tx.Get(&label, query, *secret.Label); err != nil {
+					if err == sql.ErrNoRows {
+						return errors.Errorf("unknown label '%s' for enroll secret '%s'", *secret.Label, secret.Name)
+					}
+					return errors.Wrap(err, "get label for secret")
+				}
+				// Enrolled hosts are added to the label explicitly, which
+				// only manual labels support.
+				if label.LabelType != kolide.LabelTypeManual {
+					return errors.Errorf("label '%s' for enroll secret '%s' is not a manual label", *secret.Label, secret.Name)
+				}
+				labelID = &label.ID
+			}
+
 			sql := `
-				INSERT INTO enroll_secrets (name, secret, active)
-				VALUES (?, ?, ?)
+				INSERT INTO enroll_secrets (name, secret, active, expires_at, label_id)
+				VALUES (?, ?, ?, ?, ?)
 				ON DUPLICATE KEY UPDATE
 					secret = VALUES(secret),
-					active = VALUES(active)
+					active = VALUES(active),
+					expires_at = VALUES(expires_at),
+					label_id = VALUES(label_id)
 			`
-			if _, err := tx.Exec(sql, secret.Name, secret.Secret, secret.Active); err != nil {
+			if _, err := tx.Exec(sql, secret.Name, secret.Secret, secret.Active, secret.ExpiresAt, labelID); err != nil {
 				return errors.Wrap(err, "upsert secret")
 			}
 		}
@@ -222,9 +285,30 @@ func (d *Datastore) ApplyEnrollSecretSpec(spec *kolide.EnrollSecretSpec) error {
 
 func (d *Datastore) GetEnrollSecretSpec() (*kolide.EnrollSecretSpec, error) {
 	var spec kolide.EnrollSecretSpec
-	sql := `SELECT * FROM enroll_secrets`
+	sql := `
+		SELECT es.name, es.secret, es.active, es.created_at, es.expires_at,
+			es.label_id, l.name AS label
+		FROM enroll_secrets es
+		LEFT JOIN labels l ON l.id = es.label_id AND NOT l.deleted
+	`
 	if err := d.db.Select(&spec.Secrets, sql); err != nil {
 		return nil, errors.Wrap(err, "get secrets")
 	}
 	return &spec, nil
 }
+
+func (d *Datastore) EnrollSecretUsage() ([]kolide.EnrollSecretUsage, error) {
+	sqlStatement := `
+		SELECT es.name, es.active, COUNT(h.id) AS hosts,
+			MAX(h.last_enroll_time) AS last_used
+		FROM enroll_secrets es
+		LEFT JOIN hosts h ON h.enroll_secret_name = es.name AND NOT h.deleted
+		GROUP BY es.name, es.active
+		ORDER BY es.name
+	`
+	usage := []kolide.EnrollSecretUsage{}
+	if err := d.db.Select(&usage, sqlStatement); err != nil {
+		return nil, errors.Wrap(err, "get enroll secret usage")
+	}
+	return usage, nil
+}
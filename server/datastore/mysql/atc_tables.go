@@ -0,0 +1,76 @@
+package mysql
+
+import (
+	"database/sql"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
+)
+
+func (d *Datastore) NewATCTable(table *kolide.ATCTable) (*kolide.ATCTable, error) {
+	sqlStatement := `
+		INSERT INTO atc_tables (name, path, query, columns, platform) VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := d.db.Exec(sqlStatement, table.Name, table.Path, table.Query, table.Columns, table.Platform)
+	if err != nil && isDuplicate(err) {
+		return nil, alreadyExists("ATCTable", 0)
+	} else if err != nil {
+		return nil, errors.Wrap(err, "creating atc table")
+	}
+	id, _ := result.LastInsertId()
+	table.ID = uint(id)
+	return table, nil
+}
+
+func (d *Datastore) ATCTable(id uint) (*kolide.ATCTable, error) {
+	table := &kolide.ATCTable{}
+	err := d.db.Get(table, "SELECT * FROM atc_tables WHERE id = ?", id)
+	if err == sql.ErrNoRows {
+		return nil, notFound("ATCTable").WithID(id)
+	} else if err != nil {
+		return nil, errors.Wrap(err, "getting atc table")
+	}
+	return table, nil
+}
+
+func (d *Datastore) SaveATCTable(table *kolide.ATCTable) error {
+	sqlStatement := `
+		UPDATE atc_tables
+		SET name = ?, path = ?, query = ?, columns = ?, platform = ?
+		WHERE id = ?
+	`
+	result, err := d.db.Exec(sqlStatement, table.Name, table.Path, table.Query, table.Columns, table.Platform, table.ID)
+	if err != nil && isDuplicate(err) {
+		return alreadyExists("ATCTable", table.ID)
+	} else if err != nil {
+		return errors.Wrap(err, "updating atc table")
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		// The row may be unchanged, so check that it exists
+		if _, err := d.ATCTable(table.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Datastore) DeleteATCTable(id uint) error {
+	result, err := d.db.Exec("DELETE FROM atc_tables WHERE id = ?", id)
+	if err != nil {
+		return errors.Wrap(err, "deleting atc table")
+	}
+	rows, _ := result.RowsAffected()
+	if rows != 1 {
+		return notFound("ATCTable").WithID(id)
+	}
+	return nil
+}
+
+func (d *Datastore) ListATCTables() ([]*kolide.ATCTable, error) {
+	results := []*kolide.ATCTable{}
+	if err := d.db.Select(&results, "SELECT * FROM atc_tables ORDER BY name"); err != nil {
+		return nil, errors.Wrap(err, "listing atc tables")
+	}
+	return results, nil
+}
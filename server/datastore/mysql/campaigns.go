@@ -1,9 +1,11 @@
 package mysql
 
 import (
+	"database/sql"
 	"fmt"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/pkg/errors"
 )
@@ -14,11 +16,13 @@ func (d *Datastore) NewDistributedQueryCampaign(camp *kolide.DistributedQueryCam
 		INSERT INTO distributed_query_campaigns (
 			query_id,
 			status,
-			user_id
+			user_id,
+			execution_timeout,
+			allow_resubmission
 		)
-		VALUES(?,?,?)
+		VALUES(?,?,?,?,?)
 	`
-	result, err := d.db.Exec(sqlStatement, camp.QueryID, camp.Status, camp.UserID)
+	result, err := d.db.Exec(sqlStatement, camp.QueryID, camp.Status, camp.UserID, camp.ExecutionTimeout, camp.AllowResubmission)
 	if err != nil {
 		return nil, errors.Wrap(err, "inserting distributed query campaign")
 	}
@@ -29,27 +33,46 @@ func (d *Datastore) NewDistributedQueryCampaign(camp *kolide.DistributedQueryCam
 }
 
 func (d *Datastore) DistributedQueryCampaign(id uint) (*kolide.DistributedQueryCampaign, error) {
-	sql := `
+	sqlStatement := `
 		SELECT * FROM distributed_query_campaigns WHERE id = ? AND NOT deleted
 	`
 	campaign := &kolide.DistributedQueryCampaign{}
-	if err := d.db.Get(campaign, sql, id); err != nil {
+	if err := d.db.Get(campaign, sqlStatement, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, notFound("DistributedQueryCampaign").WithID(id)
+		}
 		return nil, errors.Wrap(err, "selecting distributed query campaign")
 	}
 
 	return campaign, nil
 }
 
+func (d *Datastore) ListActiveDistributedQueryCampaigns() ([]*kolide.DistributedQueryCampaign, error) {
+	sqlStatement := `
+		SELECT * FROM distributed_query_campaigns
+		WHERE status IN (?, ?) AND NOT deleted
+		ORDER BY created_at, id
+	`
+	campaigns := []*kolide.DistributedQueryCampaign{}
+	if err := d.db.Select(&campaigns, sqlStatement, kolide.QueryWaiting, kolide.QueryRunning); err != nil {
+		return nil, errors.Wrap(err, "listing active distributed query campaigns")
+	}
+
+	return campaigns, nil
+}
+
 func (d *Datastore) SaveDistributedQueryCampaign(camp *kolide.DistributedQueryCampaign) error {
 	sqlStatement := `
 		UPDATE distributed_query_campaigns SET
 			query_id = ?,
 			status = ?,
-			user_id = ?
+			user_id = ?,
+			execution_timeout = ?,
+			allow_resubmission = ?
 		WHERE id = ?
 		AND NOT deleted
 	`
-	result, err := d.db.Exec(sqlStatement, camp.QueryID, camp.Status, camp.UserID, camp.ID)
+	result, err := d.db.Exec(sqlStatement, camp.QueryID, camp.Status, camp.UserID, camp.ExecutionTimeout, camp.AllowResubmission, camp.ID)
 	if err != nil {
 		return errors.Wrap(err, "updating distributed query campaign")
 	}
@@ -64,6 +87,66 @@ func (d *Datastore) SaveDistributedQueryCampaign(camp *kolide.DistributedQueryCa
 	return nil
 }
 
+func (d *Datastore) CompleteDistributedQueryCampaign(id uint) (bool, error) {
+	sqlStatement := `
+		UPDATE distributed_query_campaigns SET status = ?
+		WHERE id = ? AND status != ? AND NOT deleted
+	`
+	result, err := d.db.Exec(sqlStatement, kolide.QueryComplete, id, kolide.QueryComplete)
+	if err != nil {
+		return false, errors.Wrap(err, "completing distributed query campaign")
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "rows affected completing distributed query campaign")
+	}
+	if rowsAffected > 0 {
+		return true, nil
+	}
+
+	// Distinguish a campaign that was already completed from one that
+	// does not exist
+	if _, err := d.DistributedQueryCampaign(id); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+func (d *Datastore) MarkDistributedQueryCampaignDisconnected(id uint, disconnectedAt *time.Time) error {
+	sqlStatement := `
+		UPDATE distributed_query_campaigns SET disconnected_at = ?
+		WHERE id = ? AND NOT deleted
+	`
+	result, err := d.db.Exec(sqlStatement, disconnectedAt, id)
+	if err != nil {
+		return errors.Wrap(err, "marking distributed query campaign disconnected")
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected marking distributed query campaign disconnected")
+	}
+	if rowsAffected == 0 {
+		return notFound("DistributedQueryCampaign").WithID(id)
+	}
+	return nil
+}
+
+func (d *Datastore) DistributedQueryCampaignSummary(id uint) (*kolide.DistributedQueryCampaignSummary, error) {
+	sqlStatement := `
+		SELECT
+			COUNT(*) AS hosts_responded,
+			COALESCE(SUM(status = ?), 0) AS hosts_failed,
+			COALESCE(SUM(row_count), 0) AS total_rows
+		FROM distributed_query_executions
+		WHERE distributed_query_campaign_id = ?
+	`
+	summary := &kolide.DistributedQueryCampaignSummary{}
+	if err := d.db.Get(summary, sqlStatement, kolide.ExecutionFailed, id); err != nil {
+		return nil, errors.Wrap(err, "summarizing distributed query campaign")
+	}
+	return summary, nil
+}
+
 func (d *Datastore) DistributedQueryCampaignTargetIDs(id uint) (hostIDs []uint, labelIDs []uint, err error) {
 	sqlStatement := `
 		SELECT * FROM distributed_query_campaign_targets WHERE distributed_query_campaign_id = ?
@@ -115,11 +198,12 @@ func (d *Datastore) NewDistributedQueryExecution(exec *kolide.DistributedQueryEx
 			distributed_query_campaign_id,
 			status,
 			error,
-			execution_duration
-		) VALUES (?,?,?,?,?)
+			execution_duration,
+			row_count
+		) VALUES (?,?,?,?,?,?)
 	`
 	result, err := d.db.Exec(sqlStatement, exec.HostID, exec.DistributedQueryCampaignID,
-		exec.Status, exec.Error, exec.ExecutionDuration)
+		exec.Status, exec.Error, exec.ExecutionDuration, exec.RowCount)
 	if err != nil {
 		return nil, errors.Wrap(err, "insert distributed campaign target")
 	}
@@ -130,6 +214,20 @@ func (d *Datastore) NewDistributedQueryExecution(exec *kolide.DistributedQueryEx
 	return exec, nil
 }
 
+func (d *Datastore) DistributedQueryExecutionExists(campaignID, hostID uint) (bool, error) {
+	sqlStatement := `
+		SELECT EXISTS(
+			SELECT 1 FROM distributed_query_executions
+			WHERE distributed_query_campaign_id = ? AND host_id = ?
+		)
+	`
+	var exists bool
+	if err := d.db.Get(&exists, sqlStatement, campaignID, hostID); err != nil {
+		return false, errors.Wrap(err, "checking distributed query execution")
+	}
+	return exists, nil
+}
+
 func (d *Datastore) CleanupDistributedQueryCampaigns(now time.Time) (expired uint, deleted uint, err error) {
 	// First expire old waiting and running campaigns
 	sqlStatement := `
@@ -172,3 +270,50 @@ func (d *Datastore) CleanupDistributedQueryCampaigns(now time.Time) (expired uin
 
 	return expired, deleted, nil
 }
+
+func (d *Datastore) PurgeCompletedCampaigns(before time.Time) (uint, error) {
+	var purged uint
+	err := d.withRetryTxx(func(tx *sqlx.Tx) error {
+		sqlStatement := `
+			DELETE dqct
+			FROM distributed_query_campaign_targets dqct
+			JOIN distributed_query_campaigns dqc
+			ON dqct.distributed_query_campaign_id = dqc.id
+			WHERE dqc.status = ? AND dqc.created_at < ?
+		`
+		if _, err := tx.Exec(sqlStatement, kolide.QueryComplete, before); err != nil {
+			return errors.Wrap(err, "deleting distributed query campaign targets")
+		}
+
+		sqlStatement = `
+			DELETE dqe
+			FROM distributed_query_executions dqe
+			JOIN distributed_query_campaigns dqc
+			ON dqe.distributed_query_campaign_id = dqc.id
+			WHERE dqc.status = ? AND dqc.created_at < ?
+		`
+		if _, err := tx.Exec(sqlStatement, kolide.QueryComplete, before); err != nil {
+			return errors.Wrap(err, "deleting distributed query executions")
+		}
+
+		sqlStatement = `
+			DELETE FROM distributed_query_campaigns
+			WHERE status = ? AND created_at < ?
+		`
+		result, err := tx.Exec(sqlStatement, kolide.QueryComplete, before)
+		if err != nil {
+			return errors.Wrap(err, "deleting distributed query campaigns")
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "rows affected deleting distributed query campaigns")
+		}
+		purged = uint(rows)
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "purging completed campaigns")
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,88 @@
+package mysql
+
+import (
+	"database/sql"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
+)
+
+func (d *Datastore) NewDecorator(decorator *kolide.Decorator, opts ...kolide.OptionalArg) (*kolide.Decorator, error) {
+	db := d.getTransaction(opts)
+	sqlStatement := `
+		INSERT INTO decorators (
+			name,
+			query,
+			type,
+			` + "`interval`" + `,
+			built_in
+		) VALUES ( ?, ?, ?, ?, ? )
+	`
+	result, err := db.Exec(sqlStatement, decorator.Name, decorator.Query,
+		decorator.Type, decorator.Interval, decorator.BuiltIn)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating decorator")
+	}
+	id, _ := result.LastInsertId()
+	decorator.ID = uint(id)
+	return decorator, nil
+}
+
+func (d *Datastore) DeleteDecorator(id uint) error {
+	result, err := d.db.Exec("DELETE FROM decorators WHERE id = ?", id)
+	if err != nil {
+		return errors.Wrap(err, "deleting decorator")
+	}
+	rows, _ := result.RowsAffected()
+	if rows != 1 {
+		return notFound("Decorator").WithID(id)
+	}
+	return nil
+}
+
+func (d *Datastore) Decorator(id uint) (*kolide.Decorator, error) {
+	var result kolide.Decorator
+	err := d.db.Get(&result, "SELECT * FROM decorators WHERE id = ?", id)
+	if err == sql.ErrNoRows {
+		return nil, notFound("Decorator").WithID(id)
+	} else if err != nil {
+		return nil, errors.Wrap(err, "retrieving decorator")
+	}
+	return &result, nil
+}
+
+func (d *Datastore) ListDecorators(opts ...kolide.OptionalArg) ([]*kolide.Decorator, error) {
+	db := d.getTransaction(opts)
+	results := []*kolide.Decorator{}
+	sqlStatement := `
+		SELECT *
+		FROM decorators
+		ORDER BY built_in DESC, name ASC, id ASC
+	`
+	if err := db.Select(&results, sqlStatement); err != nil {
+		return nil, errors.Wrap(err, "listing decorators")
+	}
+	return results, nil
+}
+
+func (d *Datastore) SaveDecorator(dec *kolide.Decorator, opts ...kolide.OptionalArg) error {
+	db := d.getTransaction(opts)
+	sqlStatement := `
+		UPDATE decorators SET
+			name = ?,
+			query = ?,
+			type = ?,
+			` + "`interval`" + ` = ?
+		WHERE id = ?
+	`
+	result, err := db.Exec(sqlStatement, dec.Name, dec.Query, dec.Type,
+		dec.Interval, dec.ID)
+	if err != nil {
+		return errors.Wrap(err, "saving decorator")
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return notFound("Decorator").WithID(dec.ID)
+	}
+	return nil
+}
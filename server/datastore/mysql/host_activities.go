@@ -0,0 +1,41 @@
+package mysql
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
+)
+
+func (d *Datastore) NewHostActivity(activity *kolide.HostActivity) (*kolide.HostActivity, error) {
+	sqlStatement := `
+		INSERT INTO host_activities (host_id, created_at, type, details)
+		VALUES (?, ?, ?, ?)
+	`
+	result, err := d.db.Exec(sqlStatement, activity.HostID, activity.CreatedAt, activity.Type, activity.Details)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating host activity")
+	}
+	id, _ := result.LastInsertId()
+	activity.ID = uint(id)
+	return activity, nil
+}
+
+// ListHostActivities lists the events recorded for a host. Supply query
+// options using the opt parameter. See kolide.HostActivityListOptions
+func (d *Datastore) ListHostActivities(hostID uint, opt kolide.HostActivityListOptions) ([]*kolide.HostActivity, error) {
+	sqlStatement := "SELECT * FROM host_activities WHERE host_id = ?"
+	args := []interface{}{hostID}
+	if len(opt.Types) > 0 {
+		sqlStatement += " AND type IN (?)"
+		args = append(args, opt.Types)
+	}
+	query, args, err := sqlx.In(appendListOptionsToSQL(sqlStatement, opt.ListOptions), args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "building host activities query")
+	}
+	activities := []*kolide.HostActivity{}
+	if err := d.reader().Select(&activities, query, args...); err != nil {
+		return nil, errors.Wrap(err, "listing host activities")
+	}
+	return activities, nil
+}
@@ -0,0 +1,41 @@
+package mysql
+
+import (
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
+)
+
+func (d *Datastore) NewHostDetailQuery(query *kolide.HostDetailQuery) (*kolide.HostDetailQuery, error) {
+	sqlStatement := `
+		INSERT INTO host_detail_queries (name, query) VALUES (?, ?)
+	`
+	result, err := d.db.Exec(sqlStatement, query.Name, query.Query)
+	if err != nil && isDuplicate(err) {
+		return nil, alreadyExists("HostDetailQuery", 0)
+	} else if err != nil {
+		return nil, errors.Wrap(err, "creating host detail query")
+	}
+	id, _ := result.LastInsertId()
+	query.ID = uint(id)
+	return query, nil
+}
+
+func (d *Datastore) DeleteHostDetailQuery(id uint) error {
+	result, err := d.db.Exec("DELETE FROM host_detail_queries WHERE id = ?", id)
+	if err != nil {
+		return errors.Wrap(err, "deleting host detail query")
+	}
+	rows, _ := result.RowsAffected()
+	if rows != 1 {
+		return notFound("HostDetailQuery").WithID(id)
+	}
+	return nil
+}
+
+func (d *Datastore) ListHostDetailQueries() ([]*kolide.HostDetailQuery, error) {
+	results := []*kolide.HostDetailQuery{}
+	if err := d.db.Select(&results, "SELECT * FROM host_detail_queries ORDER BY name"); err != nil {
+		return nil, errors.Wrap(err, "listing host detail queries")
+	}
+	return results, nil
+}
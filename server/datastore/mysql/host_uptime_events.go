@@ -0,0 +1,33 @@
+package mysql
+
+import (
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
+)
+
+func (d *Datastore) NewHostUptimeEvent(event *kolide.HostUptimeEvent) (*kolide.HostUptimeEvent, error) {
+	sqlStatement := `
+		INSERT INTO host_uptime_events (host_id, boot_time, previous_boot_time, detected_at)
+		VALUES (?, ?, ?, ?)
+	`
+	result, err := d.db.Exec(sqlStatement, event.HostID, event.BootTime, event.PreviousBootTime, event.DetectedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating host uptime event")
+	}
+	id, _ := result.LastInsertId()
+	event.ID = uint(id)
+	return event, nil
+}
+
+func (d *Datastore) ListHostUptimeEvents(hostID uint) ([]*kolide.HostUptimeEvent, error) {
+	sqlStatement := `
+		SELECT * FROM host_uptime_events
+		WHERE host_id = ?
+		ORDER BY detected_at DESC, id DESC
+	`
+	events := []*kolide.HostUptimeEvent{}
+	if err := d.reader().Select(&events, sqlStatement, hostID); err != nil {
+		return nil, errors.Wrap(err, "listing host uptime events")
+	}
+	return events, nil
+}
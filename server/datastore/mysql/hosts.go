@@ -3,6 +3,7 @@ package mysql
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -173,7 +174,10 @@ func (d *Datastore) SaveHost(host *kolide.Host) error {
 			config_tls_refresh = ?,
 			logger_tls_period = ?,
 			additional = COALESCE(?, additional),
-			enroll_secret_name = ?
+			host_extra_details = COALESCE(?, host_extra_details),
+			enroll_secret_name = ?,
+			boot_time = COALESCE(?, boot_time),
+			disk_encryption = COALESCE(NULLIF(?, ''), disk_encryption)
 		WHERE id = ?
 	`
 	err := d.withRetryTxx(func(tx *sqlx.Tx) error {
@@ -206,7 +210,10 @@ func (d *Datastore) SaveHost(host *kolide.Host) error {
 			host.ConfigTLSRefresh,
 			host.LoggerTLSPeriod,
 			host.Additional,
+			host.ExtraDetails,
 			host.EnrollSecretName,
+			host.BootTime,
+			host.DiskEncryption,
 			host.ID,
 		)
 		if err != nil {
@@ -262,6 +269,60 @@ func (d *Datastore) DeleteHost(hid uint) error {
 	return nil
 }
 
+func (d *Datastore) DeleteHostsByLabel(lid uint) (int, error) {
+	// The members of query labels are recorded in label_query_executions,
+	// those of manual labels in label_membership, and those of computed
+	// labels are selected by their criteria.
+	computedCondition, computedArgs, err := d.computedLabelsCondition([]uint{lid}, d.clock.Now())
+	if err != nil {
+		return 0, errors.Wrap(err, "building computed label condition")
+	}
+	selectStmt := fmt.Sprintf(`
+		SELECT host_id FROM label_query_executions
+		WHERE label_id = ? AND matches = 1
+		UNION
+		SELECT host_id FROM label_membership
+		WHERE label_id = ?
+		UNION
+		SELECT id FROM hosts
+		WHERE %s
+	`, computedCondition)
+	selectArgs := append([]interface{}{lid, lid}, computedArgs...)
+
+	var deleted int64
+	err = d.withRetryTxx(func(tx *sqlx.Tx) error {
+		deleted = 0
+		var hostIDs []uint
+		if err := tx.Select(&hostIDs, selectStmt, selectArgs...); err != nil {
+			return errors.Wrap(err, "selecting hosts in label")
+		}
+		if len(hostIDs) == 0 {
+			return nil
+		}
+
+		// A single DELETE removes every member of the label without
+		// issuing a statement per host.
+		query, args, err := sqlx.In("DELETE FROM hosts WHERE id IN (?)", hostIDs)
+		if err != nil {
+			return errors.Wrap(err, "building delete hosts by label")
+		}
+		result, err := tx.Exec(query, args...)
+		if err != nil {
+			return errors.Wrap(err, "deleting hosts by label")
+		}
+		deleted, err = result.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "rows affected deleting hosts by label")
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "deleting hosts in label with id %d", lid)
+	}
+
+	return int(deleted), nil
+}
+
 func (d *Datastore) Host(id uint) (*kolide.Host, error) {
 	sqlStatement := `
 		SELECT * FROM hosts
@@ -281,25 +342,44 @@ func (d *Datastore) Host(id uint) (*kolide.Host, error) {
 
 }
 
-func (d *Datastore) ListHosts(opt kolide.ListOptions) ([]*kolide.Host, error) {
+func (d *Datastore) ListHosts(opt kolide.HostListOptions) ([]*kolide.Host, error) {
 	sqlStatement := `
 		SELECT * FROM hosts
 		WHERE NOT deleted
 	`
-	sqlStatement = appendListOptionsToSQL(sqlStatement, opt)
+	filterSQL, params := d.hostListFilterSQL(opt)
+	sqlStatement += filterSQL
+	if opt.After != nil {
+		cursorSQL, cursorParams, err := hostCursorSQL(opt)
+		if err != nil {
+			return nil, err
+		}
+		sqlStatement += cursorSQL
+		params = append(params, cursorParams...)
+	} else {
+		listOpt := opt.ListOptions
+		if opt.MatchQuery != "" {
+			rankSQL, rankParams := hostMatchRankSQL(opt)
+			sqlStatement += rankSQL
+			params = append(params, rankParams...)
+			listOpt.OrderKey = ""
+		}
+		sqlStatement = appendListOptionsToSQL(sqlStatement, listOpt)
+	}
+	db := d.reader()
 	hosts := []*kolide.Host{}
-	if err := d.db.Select(&hosts, sqlStatement); err != nil {
+	if err := db.Select(&hosts, sqlStatement, params...); err != nil {
 		return nil, errors.Wrap(err, "list hosts")
 	}
 
-	if opt.PerPage == 0 || (opt.Page == 0 && uint(len(hosts)) < opt.PerPage) {
+	if opt.PerPage == 0 || (opt.After == nil && opt.Page == 0 && uint(len(hosts)) < opt.PerPage) {
 		// If all hosts, we can use the optimized network interface retrieval function
-		if err := d.getNetInterfacesForAllHosts(hosts); err != nil {
+		if err := d.getNetInterfacesForAllHosts(db, hosts); err != nil {
 			return nil, err
 		}
 
 	} else {
-		if err := d.getNetInterfacesForHosts(hosts); err != nil {
+		if err := d.getNetInterfacesForHosts(db, hosts); err != nil {
 			return nil, err
 		}
 	}
@@ -307,6 +387,164 @@ func (d *Datastore) ListHosts(opt kolide.ListOptions) ([]*kolide.Host, error) {
 	return hosts, nil
 }
 
+func (d *Datastore) CountHosts(opt kolide.HostListOptions) (int, error) {
+	sqlStatement := `
+		SELECT COUNT(*) FROM hosts
+		WHERE NOT deleted
+	`
+	filterSQL, params := d.hostListFilterSQL(opt)
+	sqlStatement += filterSQL
+	var count int
+	if err := d.reader().Get(&count, sqlStatement, params...); err != nil {
+		return 0, errors.Wrap(err, "count hosts")
+	}
+	return count, nil
+}
+
+func (d *Datastore) StreamHosts(opt kolide.HostListOptions, fn func(*kolide.Host) error) error {
+	sqlStatement := `
+		SELECT * FROM hosts
+		WHERE NOT deleted
+	`
+	filterSQL, params := d.hostListFilterSQL(opt)
+	sqlStatement += filterSQL + " ORDER BY id"
+
+	// Rows are scanned one at a time so that the full set of hosts is
+	// never held in memory
+	rows, err := d.reader().Queryx(sqlStatement, params...)
+	if err != nil {
+		return errors.Wrap(err, "stream hosts")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		host := &kolide.Host{}
+		if err := rows.StructScan(host); err != nil {
+			return errors.Wrap(err, "scan host")
+		}
+		if err := fn(host); err != nil {
+			return err
+		}
+	}
+	return errors.Wrap(rows.Err(), "iterate hosts")
+}
+
+// hostListFilterSQL returns the conditions shared by ListHosts, CountHosts and
+// StreamHosts for the filters in opt.
+func (d *Datastore) hostListFilterSQL(opt kolide.HostListOptions) (string, []interface{}) {
+	var sqlStatement string
+	var params []interface{}
+	cutoff := d.clock.Now().Add(-opt.SeenWithin)
+	switch opt.SeenStatus {
+	case kolide.StatusOnline:
+		sqlStatement += " AND seen_time >= ?"
+		params = append(params, cutoff)
+	case kolide.StatusOffline:
+		// Hosts that have never reported a seen time count as offline
+		sqlStatement += " AND (seen_time IS NULL OR seen_time < ?)"
+		params = append(params, cutoff)
+	}
+	if opt.MatchQuery != "" {
+		// Only prefixes are matched, so that the indexes on these columns
+		// can be used
+		sqlStatement += `
+			AND (host_name LIKE ? OR uuid LIKE ? OR hardware_serial LIKE ?
+			OR primary_ip_id IN (SELECT id FROM network_interfaces WHERE ip_address LIKE ?))
+		`
+		pattern := escapeLike(opt.MatchQuery) + "%"
+		params = append(params, pattern, pattern, pattern, pattern)
+	}
+	if opt.Pending != nil {
+		sqlStatement += " AND pending = ?"
+		params = append(params, *opt.Pending)
+	}
+	if opt.DiskEncryption != "" {
+		sqlStatement += " AND disk_encryption = ?"
+		params = append(params, opt.DiskEncryption)
+	}
+	if len(opt.LabelIDs) > 0 {
+		// As with hostsInTargetsCondition, label IDs are bound once for
+		// query labels and once for manual labels
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(opt.LabelIDs)), ",")
+		sqlStatement += fmt.Sprintf(`
+			AND (id IN (SELECT host_id FROM label_query_executions WHERE label_id IN (%s) AND matches = 1)
+			OR id IN (SELECT host_id FROM label_membership WHERE label_id IN (%s)))
+		`, placeholders, placeholders)
+		for i := 0; i < 2; i++ {
+			for _, id := range opt.LabelIDs {
+				params = append(params, id)
+			}
+		}
+	}
+	return sqlStatement, params
+}
+
+// hostMatchRankSQL returns the ordering used when hosts are listed with
+// MatchQuery. Exact matches come first, followed by the requested order.
+func hostMatchRankSQL(opt kolide.HostListOptions) (string, []interface{}) {
+	sqlStatement := `
+		ORDER BY (host_name = ? OR uuid = ? OR hardware_serial = ?
+		OR primary_ip_id IN (SELECT id FROM network_interfaces WHERE ip_address = ?)) DESC
+	`
+	if opt.OrderKey != "" {
+		direction := "ASC"
+		if opt.OrderDirection == kolide.OrderDescending {
+			direction = "DESC"
+		}
+		sqlStatement += fmt.Sprintf(", %s %s", sanitizeColumn(opt.OrderKey), direction)
+	}
+	q := opt.MatchQuery
+	return sqlStatement, []interface{}{q, q, q, q}
+}
+
+// hostCursorSQL returns the condition, ordering and limit used for keyset
+// pagination of hosts. Hosts are ordered by the requested column with the ID
+// breaking ties, so that the position of the last host returned can be
+// encoded in a cursor.
+func hostCursorSQL(opt kolide.HostListOptions) (string, []interface{}, error) {
+	orderKey := sanitizeColumn(opt.OrderKey)
+	direction, cmp := "ASC", ">"
+	if opt.OrderDirection == kolide.OrderDescending {
+		direction, cmp = "DESC", "<"
+	}
+
+	var sqlStatement string
+	var params []interface{}
+	if *opt.After != "" {
+		cursor, err := kolide.ParseHostCursor(*opt.After)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "parse host cursor")
+		}
+		if cursor.OrderKey != opt.OrderKey {
+			return "", nil, errors.New("host cursor does not match order key")
+		}
+		if orderKey == "" || orderKey == "id" {
+			sqlStatement = fmt.Sprintf(" AND id %s ?", cmp)
+			params = append(params, cursor.ID)
+		} else {
+			value, err := cursor.SortValue()
+			if err != nil {
+				return "", nil, errors.Wrap(err, "host cursor value")
+			}
+			sqlStatement = fmt.Sprintf(" AND (%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", orderKey, cmp)
+			params = append(params, value, value, cursor.ID)
+		}
+	}
+
+	if orderKey == "" || orderKey == "id" {
+		sqlStatement += fmt.Sprintf(" ORDER BY id %s", direction)
+	} else {
+		sqlStatement += fmt.Sprintf(" ORDER BY %[1]s %[2]s, id %[2]s", orderKey, direction)
+	}
+
+	perPage := opt.PerPage
+	if perPage == 0 {
+		perPage = defaultSelectLimit
+	}
+	sqlStatement += fmt.Sprintf(" LIMIT %d", perPage)
+
+	return sqlStatement, params, nil
+}
+
 func (d *Datastore) CleanupIncomingHosts(now time.Time) error {
 	sqlStatement := `
 		DELETE FROM hosts
@@ -320,27 +558,29 @@ func (d *Datastore) CleanupIncomingHosts(now time.Time) error {
 	return nil
 }
 
-func (d *Datastore) GenerateHostStatusStatistics(now time.Time) (online, offline, mia, new uint, e error) {
+func (d *Datastore) GenerateHostStatusStatistics(now time.Time) (online, offline, mia, maintenance, new uint, e error) {
 	// The logic in this function should remain synchronized with
 	// host.Status and CountHostsInTargets
 
 	sqlStatement := fmt.Sprintf(`
 		SELECT
-			COALESCE(SUM(CASE WHEN DATE_ADD(seen_time, INTERVAL 30 DAY) <= ? THEN 1 ELSE 0 END), 0) mia,
-			COALESCE(SUM(CASE WHEN DATE_ADD(seen_time, INTERVAL LEAST(distributed_interval, config_tls_refresh) + %d SECOND) <= ? AND DATE_ADD(seen_time, INTERVAL 30 DAY) >= ? THEN 1 ELSE 0 END), 0) offline,
+			COALESCE(SUM(CASE WHEN DATE_ADD(seen_time, INTERVAL 30 DAY) <= ? AND NOT COALESCE(maintenance_until > ?, FALSE) THEN 1 ELSE 0 END), 0) mia,
+			COALESCE(SUM(CASE WHEN DATE_ADD(seen_time, INTERVAL LEAST(distributed_interval, config_tls_refresh) + %d SECOND) <= ? AND DATE_ADD(seen_time, INTERVAL 30 DAY) >= ? AND NOT COALESCE(maintenance_until > ?, FALSE) THEN 1 ELSE 0 END), 0) offline,
+			COALESCE(SUM(CASE WHEN DATE_ADD(seen_time, INTERVAL LEAST(distributed_interval, config_tls_refresh) + %d SECOND) <= ? AND maintenance_until > ? THEN 1 ELSE 0 END), 0) maintenance,
 			COALESCE(SUM(CASE WHEN DATE_ADD(seen_time, INTERVAL LEAST(distributed_interval, config_tls_refresh) + %d SECOND) > ? THEN 1 ELSE 0 END), 0) online,
 			COALESCE(SUM(CASE WHEN DATE_ADD(created_at, INTERVAL 1 DAY) >= ? THEN 1 ELSE 0 END), 0) new
 		FROM hosts
 		LIMIT 1;
-	`, kolide.OnlineIntervalBuffer, kolide.OnlineIntervalBuffer)
+	`, kolide.OnlineIntervalBuffer, kolide.OnlineIntervalBuffer, kolide.OnlineIntervalBuffer)
 
 	counts := struct {
-		MIA     uint `db:"mia"`
-		Offline uint `db:"offline"`
-		Online  uint `db:"online"`
-		New     uint `db:"new"`
+		MIA         uint `db:"mia"`
+		Offline     uint `db:"offline"`
+		Maintenance uint `db:"maintenance"`
+		Online      uint `db:"online"`
+		New         uint `db:"new"`
 	}{}
-	err := d.db.Get(&counts, sqlStatement, now, now, now, now, now)
+	err := d.reader().Get(&counts, sqlStatement, now, now, now, now, now, now, now, now, now)
 	if err != nil && err != sql.ErrNoRows {
 		e = errors.Wrap(err, "generating host statistics")
 		return
@@ -348,15 +588,50 @@ func (d *Datastore) GenerateHostStatusStatistics(now time.Time) (online, offline
 
 	mia = counts.MIA
 	offline = counts.Offline
+	maintenance = counts.Maintenance
 	online = counts.Online
 	new = counts.New
-	return online, offline, mia, new, nil
+	return online, offline, mia, maintenance, new, nil
+}
+
+func (d *Datastore) AggregateHosts(groupBy string) ([]kolide.HostAggregate, error) {
+	var sqlStatement string
+	switch {
+	case groupBy == kolide.HostAggregateLabel:
+		sqlStatement = `
+			SELECT l.name AS value, COUNT(*) AS count
+			FROM label_query_executions lqe
+			JOIN labels l ON l.id = lqe.label_id
+			JOIN hosts h ON h.id = lqe.host_id
+			WHERE lqe.matches AND NOT l.deleted AND NOT h.deleted
+			GROUP BY l.id, l.name
+			ORDER BY count DESC, value
+		`
+	case kolide.IsHostAggregateColumn(groupBy):
+		// The column is interpolated only after checking it against
+		// the allowed columns.
+		sqlStatement = fmt.Sprintf(`
+			SELECT %[1]s AS value, COUNT(*) AS count
+			FROM hosts
+			WHERE NOT deleted
+			GROUP BY %[1]s
+			ORDER BY count DESC, value
+		`, groupBy)
+	default:
+		return nil, errors.Errorf("cannot aggregate hosts by %q", groupBy)
+	}
+
+	aggregates := []kolide.HostAggregate{}
+	if err := d.reader().Select(&aggregates, sqlStatement); err != nil {
+		return nil, errors.Wrap(err, "aggregating hosts")
+	}
+	return aggregates, nil
 }
 
 // Optimized network interface fetch for sets of hosts.  Instead of looping
 // through hosts and doing a select for each host to get nics, we get all
 // nics at once, so 2 db calls, and then assign nics to hosts here.
-func (d *Datastore) getNetInterfacesForHosts(hosts []*kolide.Host) error {
+func (d *Datastore) getNetInterfacesForHosts(db *sqlx.DB, hosts []*kolide.Host) error {
 	if len(hosts) == 0 {
 		return nil
 	}
@@ -386,9 +661,9 @@ func (d *Datastore) getNetInterfacesForHosts(hosts []*kolide.Host) error {
 		return errors.Wrap(err, "select nics for hosts, in query")
 	}
 
-	query = d.db.Rebind(query)
+	query = db.Rebind(query)
 	nics := []*kolide.NetworkInterface{}
-	err = d.db.Select(&nics, query, args...)
+	err = db.Select(&nics, query, args...)
 	if err != nil {
 		return errors.Wrap(err, "select nics for hosts, rebound query")
 	}
@@ -407,7 +682,7 @@ func (d *Datastore) getNetInterfacesForHosts(hosts []*kolide.Host) error {
 // When we know we're loading the network interfaces for all hosts, we can skip
 // the IN clause and load them all. This allows us to load net interfaces
 // without error for larger sets of hosts.
-func (d *Datastore) getNetInterfacesForAllHosts(hosts []*kolide.Host) error {
+func (d *Datastore) getNetInterfacesForAllHosts(db *sqlx.DB, hosts []*kolide.Host) error {
 	if len(hosts) == 0 {
 		return nil
 	}
@@ -418,7 +693,7 @@ func (d *Datastore) getNetInterfacesForAllHosts(hosts []*kolide.Host) error {
 		ORDER BY host_id ASC
 	`
 	nics := []*kolide.NetworkInterface{}
-	err := d.db.Select(&nics, sqlStatement)
+	err := db.Select(&nics, sqlStatement)
 	if err != nil {
 		return errors.Wrap(err, "select nics for all hosts")
 	}
@@ -443,11 +718,22 @@ func (d *Datastore) getNetInterfacesForHost(host *kolide.Host) error {
 }
 
 // EnrollHost enrolls a host
-func (d *Datastore) EnrollHost(osqueryHostID, nodeKey, secretName string) (*kolide.Host, error) {
+func (d *Datastore) EnrollHost(osqueryHostID, nodeKey, secretName string, cooldown time.Duration, pending bool) (*kolide.Host, error) {
 	if osqueryHostID == "" {
 		return nil, fmt.Errorf("missing osquery host identifier")
 	}
 
+	now := d.clock.Now().UTC()
+	if cooldown > 0 {
+		host, err := d.recentlyEnrolledHost(osqueryHostID, now.Add(-cooldown))
+		if err != nil {
+			return nil, err
+		}
+		if host != nil {
+			return host, nil
+		}
+	}
+
 	detailUpdateTime := time.Unix(0, 0).Add(24 * time.Hour)
 	sqlInsert := `
 		INSERT INTO hosts (
@@ -455,16 +741,22 @@ func (d *Datastore) EnrollHost(osqueryHostID, nodeKey, secretName string) (*koli
 			osquery_host_id,
 			seen_time,
 			node_key,
-			enroll_secret_name
-		) VALUES (?, ?, ?, ?, ?)
+			enroll_secret_name,
+			last_enroll_time,
+			pending
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
 		ON DUPLICATE KEY UPDATE
+			id = LAST_INSERT_ID(id),
 			node_key = VALUES(node_key),
+			enroll_secret_name = VALUES(enroll_secret_name),
+			last_enroll_time = VALUES(last_enroll_time),
+			pending = IF(deleted, VALUES(pending), pending),
 			deleted = FALSE
 	`
 
 	var result sql.Result
 
-	result, err := d.db.Exec(sqlInsert, detailUpdateTime, osqueryHostID, time.Now().UTC(), nodeKey, secretName)
+	result, err := d.db.Exec(sqlInsert, detailUpdateTime, osqueryHostID, now, nodeKey, secretName, now, pending)
 
 	if err != nil {
 		return nil, errors.Wrap(err, "inserting")
@@ -484,6 +776,30 @@ func (d *Datastore) EnrollHost(osqueryHostID, nodeKey, secretName string) (*koli
 
 }
 
+// recentlyEnrolledHost returns the host with the osquery host identifier if it
+// enrolled after since, or nil if there is no such host. Hosts are not matched
+// on their hardware UUID, which is reported in host details, as any host with
+// an enroll secret could then obtain the node key of another host.
+func (d *Datastore) recentlyEnrolledHost(osqueryHostID string, since time.Time) (*kolide.Host, error) {
+	sqlStatement := `
+		SELECT * FROM hosts
+		WHERE osquery_host_id = ?
+		AND NOT deleted
+		AND last_enroll_time >= ?
+		LIMIT 1
+	`
+	host := &kolide.Host{}
+	err := d.db.Get(host, sqlStatement, osqueryHostID, since)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "looking up recently enrolled host")
+	}
+
+	return host, nil
+}
+
 func (d *Datastore) AuthenticateHost(nodeKey string) (*kolide.Host, error) {
 	sqlStatement := `
 		SELECT
@@ -520,7 +836,11 @@ func (d *Datastore) AuthenticateHost(nodeKey string) (*kolide.Host, error) {
 			distributed_interval,
 			logger_tls_period,
 			config_tls_refresh,
-			enroll_secret_name
+			enroll_secret_name,
+			config_refresh_requested,
+			boot_time,
+			pending,
+			disk_encryption
 		FROM hosts
 		WHERE node_key = ? AND NOT deleted
 		LIMIT 1
@@ -601,7 +921,7 @@ func (d *Datastore) searchHostsWithOmits(query string, omit ...uint) ([]*kolide.
 		return nil, errors.Wrap(err, "searching hosts rebound")
 	}
 
-	if err := d.getNetInterfacesForHosts(hosts); err != nil {
+	if err := d.getNetInterfacesForHosts(d.db, hosts); err != nil {
 		return nil, errors.Wrap(err, "getting network interfaces for hosts")
 	}
 
@@ -637,7 +957,7 @@ func (d *Datastore) searchHostsDefault(omit ...uint) ([]*kolide.Host, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "searching default hosts rebound")
 	}
-	if err := d.getNetInterfacesForHosts(hosts); err != nil {
+	if err := d.getNetInterfacesForHosts(d.db, hosts); err != nil {
 		return nil, errors.Wrap(err, "getting network interfaces for default search hosts")
 	}
 	return hosts, nil
@@ -686,7 +1006,7 @@ func (d *Datastore) SearchHosts(query string, omit ...uint) ([]*kolide.Host, err
 		return nil, errors.Wrap(err, "searching hosts")
 	}
 
-	if err := d.getNetInterfacesForHosts(hosts); err != nil {
+	if err := d.getNetInterfacesForHosts(d.db, hosts); err != nil {
 		return nil, errors.Wrap(err, "getting interfaces")
 	}
 
@@ -761,3 +1081,226 @@ func (d *Datastore) HostIDsByName(hostnames []string) ([]uint, error) {
 	return hostIDs, nil
 
 }
+
+func (d *Datastore) HostIDsByIdentifier(identifiers []string) ([]uint, error) {
+	if len(identifiers) == 0 {
+		return []uint{}, nil
+	}
+
+	sqlStatement := `
+		SELECT id FROM hosts
+		WHERE osquery_host_id IN (?) AND NOT deleted
+	`
+
+	sql, args, err := sqlx.In(sqlStatement, identifiers)
+	if err != nil {
+		return nil, errors.Wrap(err, "building query to get host IDs by identifier")
+	}
+
+	var hostIDs []uint
+	if err := d.db.Select(&hostIDs, sql, args...); err != nil {
+		return nil, errors.Wrap(err, "get host IDs by identifier")
+	}
+
+	return hostIDs, nil
+}
+
+func (d *Datastore) ExpireHostDetails(hostID uint) error {
+	// This is the detail update time of a newly enrolled host
+	detailUpdateTime := time.Unix(0, 0).Add(24 * time.Hour)
+	sqlStatement := `
+		UPDATE hosts SET detail_update_time = ?
+		WHERE id = ?
+	`
+	if _, err := d.db.Exec(sqlStatement, detailUpdateTime, hostID); err != nil {
+		return errors.Wrap(err, "expire host details")
+	}
+	return nil
+}
+
+func (d *Datastore) RecordNoisyHost(hostID uint, dropped uint, at time.Time) error {
+	sqlStatement := `
+		UPDATE hosts SET
+			noisy_time = ?,
+			dropped_log_rows = dropped_log_rows + ?
+		WHERE id = ?
+	`
+	if _, err := d.db.Exec(sqlStatement, at, dropped, hostID); err != nil {
+		return errors.Wrap(err, "record noisy host")
+	}
+	return nil
+}
+
+func (d *Datastore) RecordTruncatedResults(hostID uint, truncated uint, at time.Time) error {
+	sqlStatement := `
+		UPDATE hosts SET
+			truncated_results_time = ?,
+			truncated_result_rows = truncated_result_rows + ?
+		WHERE id = ?
+	`
+	if _, err := d.db.Exec(sqlStatement, at, truncated, hostID); err != nil {
+		return errors.Wrap(err, "record truncated results")
+	}
+	return nil
+}
+
+func (d *Datastore) ListNoisyHosts() ([]*kolide.Host, error) {
+	sqlStatement := `
+		SELECT * FROM hosts
+		WHERE noisy_time IS NOT NULL AND NOT deleted
+		ORDER BY noisy_time DESC, id
+	`
+	db := d.reader()
+	hosts := []*kolide.Host{}
+	if err := db.Select(&hosts, sqlStatement); err != nil {
+		return nil, errors.Wrap(err, "list noisy hosts")
+	}
+	if err := d.getNetInterfacesForHosts(db, hosts); err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
+
+func (d *Datastore) SetHostsConfigRefresh(hostIDs []uint, requested bool) error {
+	if len(hostIDs) == 0 {
+		return nil
+	}
+
+	sqlStatement := `
+		UPDATE hosts SET config_refresh_requested = ?
+		WHERE id IN (?)
+	`
+	query, args, err := sqlx.In(sqlStatement, requested, hostIDs)
+	if err != nil {
+		return errors.Wrap(err, "building query to set host config refresh")
+	}
+	if _, err := d.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "set host config refresh")
+	}
+	return nil
+}
+
+func (d *Datastore) SetHostMaintenance(hostID uint, until *time.Time) error {
+	sqlStatement := `
+		UPDATE hosts SET maintenance_until = ?
+		WHERE id = ? AND NOT deleted
+	`
+	if _, err := d.db.Exec(sqlStatement, until, hostID); err != nil {
+		return errors.Wrap(err, "set host maintenance")
+	}
+	return nil
+}
+
+func (d *Datastore) ClearElapsedHostMaintenance(now time.Time) error {
+	sqlStatement := `
+		UPDATE hosts SET maintenance_until = NULL
+		WHERE maintenance_until <= ?
+	`
+	if _, err := d.db.Exec(sqlStatement, now); err != nil {
+		return errors.Wrap(err, "clear elapsed host maintenance")
+	}
+	return nil
+}
+
+func (d *Datastore) ApproveHost(hostID uint) error {
+	sqlStatement := `
+		UPDATE hosts SET pending = FALSE
+		WHERE id = ? AND NOT deleted
+	`
+	if _, err := d.db.Exec(sqlStatement, hostID); err != nil {
+		return errors.Wrap(err, "approve host")
+	}
+	return nil
+}
+
+func (d *Datastore) SetHostNodeKey(hostID uint, nodeKey string) error {
+	sqlStatement := `
+		UPDATE hosts SET node_key = ?
+		WHERE id = ? AND NOT deleted
+	`
+	if _, err := d.db.Exec(sqlStatement, nodeKey, hostID); err != nil {
+		return errors.Wrap(err, "set host node key")
+	}
+	return nil
+}
+
+func (d *Datastore) MergeHosts(keepID uint, mergeIDs []uint) (kolide.HostMerge, error) {
+	merge := kolide.HostMerge{HostID: keepID, MergedHostIDs: mergeIDs}
+	if len(mergeIDs) == 0 {
+		return merge, nil
+	}
+
+	// reassign runs a statement reassigning rows from the merged hosts,
+	// returning the number of rows changed.
+	reassign := func(tx *sqlx.Tx, sqlStatement, desc string) (int, error) {
+		query, args, err := sqlx.In(sqlStatement, keepID, mergeIDs)
+		if err != nil {
+			return 0, errors.Wrapf(err, "building query reassigning %s", desc)
+		}
+		result, err := tx.Exec(query, args...)
+		if err != nil {
+			return 0, errors.Wrapf(err, "reassigning %s", desc)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, errors.Wrapf(err, "rows affected reassigning %s", desc)
+		}
+		return int(affected), nil
+	}
+
+	err := d.withRetryTxx(func(tx *sqlx.Tx) error {
+		var err error
+		// The memberships of the merged hosts are removed when they are
+		// deleted, so they are copied rather than moved.
+		merge.LabelMemberships, err = reassign(tx, `
+			INSERT IGNORE INTO label_membership (label_id, host_id)
+			SELECT label_id, ? FROM label_membership WHERE host_id IN (?)
+		`, "label memberships")
+		if err != nil {
+			return err
+		}
+		merge.UptimeEvents, err = reassign(tx, "UPDATE host_uptime_events SET host_id = ? WHERE host_id IN (?)", "uptime events")
+		if err != nil {
+			return err
+		}
+		merge.Activities, err = reassign(tx, "UPDATE host_activities SET host_id = ? WHERE host_id IN (?)", "activities")
+		if err != nil {
+			return err
+		}
+		// Executions of campaigns that the kept host also ran conflict
+		// with its own, and are skipped.
+		merge.QueryExecutions, err = reassign(tx, "UPDATE IGNORE distributed_query_executions SET host_id = ? WHERE host_id IN (?)", "query executions")
+		if err != nil {
+			return err
+		}
+
+		query, args, err := sqlx.In("DELETE FROM hosts WHERE id IN (?)", mergeIDs)
+		if err != nil {
+			return errors.Wrap(err, "building query deleting merged hosts")
+		}
+		result, err := tx.Exec(query, args...)
+		if err != nil {
+			return errors.Wrap(err, "deleting merged hosts")
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "rows affected deleting merged hosts")
+		}
+		if int(deleted) != len(mergeIDs) {
+			// Roll back rather than reassign the rows of missing hosts
+			return notFound("Host")
+		}
+		query, args, err = sqlx.In("DELETE FROM distributed_query_executions WHERE host_id IN (?)", mergeIDs)
+		if err != nil {
+			return errors.Wrap(err, "building query deleting conflicting query executions")
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return errors.Wrap(err, "deleting conflicting query executions")
+		}
+		return nil
+	})
+	if err != nil {
+		return kolide.HostMerge{}, err
+	}
+	return merge, nil
+}
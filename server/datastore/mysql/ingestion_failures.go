@@ -0,0 +1,41 @@
+package mysql
+
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
+)
+
+func (d *Datastore) NewIngestionFailure(failure *kolide.IngestionFailure) (*kolide.IngestionFailure, error) {
+	sqlStatement := `
+		INSERT INTO ingestion_failures (host_id, query_name, error, created_at)
+		VALUES (?, ?, ?, ?)
+	`
+	result, err := d.db.Exec(sqlStatement, failure.HostID, failure.QueryName, failure.Error, failure.CreatedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating ingestion failure")
+	}
+	id, _ := result.LastInsertId()
+	failure.ID = uint(id)
+	return failure, nil
+}
+
+// ListIngestionFailures lists the failures recorded for every host. Supply
+// query options using the opt parameter. See kolide.ListOptions
+func (d *Datastore) ListIngestionFailures(opt kolide.ListOptions) ([]*kolide.IngestionFailure, error) {
+	sqlStatement := appendListOptionsToSQL("SELECT * FROM ingestion_failures", opt)
+	failures := []*kolide.IngestionFailure{}
+	if err := d.reader().Select(&failures, sqlStatement); err != nil {
+		return nil, errors.Wrap(err, "listing ingestion failures")
+	}
+	return failures, nil
+}
+
+func (d *Datastore) CleanupIngestionFailures(before time.Time) error {
+	_, err := d.db.Exec("DELETE FROM ingestion_failures WHERE created_at < ?", before)
+	if err != nil {
+		return errors.Wrap(err, "cleaning up ingestion failures")
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package mysql
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -9,25 +11,31 @@ import (
 	"github.com/pkg/errors"
 )
 
+// applyLabelSpecSQL upserts a label spec by name.
+const applyLabelSpecSQL = `
+	INSERT INTO labels (
+		name,
+		description,
+		query,
+		platform,
+		label_type,
+		criteria,
+		detail_update_interval
+	) VALUES ( ?, ?, ?, ?, ?, ?, ? )
+	ON DUPLICATE KEY UPDATE
+		name = VALUES(name),
+		description = VALUES(description),
+		query = VALUES(query),
+		platform = VALUES(platform),
+		label_type = VALUES(label_type),
+		criteria = VALUES(criteria),
+		detail_update_interval = VALUES(detail_update_interval),
+		deleted = false
+`
+
 func (d *Datastore) ApplyLabelSpecs(specs []*kolide.LabelSpec) (err error) {
 	err = d.withRetryTxx(func(tx *sqlx.Tx) error {
-		sql := `
-		INSERT INTO labels (
-			name,
-			description,
-			query,
-			platform,
-			label_type
-		) VALUES ( ?, ?, ?, ?, ? )
-		ON DUPLICATE KEY UPDATE
-			name = VALUES(name),
-			description = VALUES(description),
-			query = VALUES(query),
-			platform = VALUES(platform),
-			label_type = VALUES(label_type),
-			deleted = false
-	`
-		stmt, err := tx.Prepare(sql)
+		stmt, err := tx.Prepare(applyLabelSpecSQL)
 		if err != nil {
 			return errors.Wrap(err, "prepare ApplyLabelSpecs insert")
 		}
@@ -36,7 +44,7 @@ func (d *Datastore) ApplyLabelSpecs(specs []*kolide.LabelSpec) (err error) {
 			if s.Name == "" {
 				return errors.New("label name must not be empty")
 			}
-			_, err := stmt.Exec(s.Name, s.Description, s.Query, s.Platform, s.LabelType)
+			_, err := stmt.Exec(s.Name, s.Description, s.Query, s.Platform, s.LabelType, s.Criteria, s.DetailUpdateInterval)
 			if err != nil {
 				return errors.Wrap(err, "exec ApplyLabelSpecs insert")
 			}
@@ -48,10 +56,104 @@ func (d *Datastore) ApplyLabelSpecs(specs []*kolide.LabelSpec) (err error) {
 	return errors.Wrap(err, "ApplyLabelSpecs transaction")
 }
 
+func (d *Datastore) SyncLabelSpecs(specs []*kolide.LabelSpec, prune bool) (*kolide.ApplyLabelSpecsResult, error) {
+	var result *kolide.ApplyLabelSpecsResult
+	err := d.withRetryTxx(func(tx *sqlx.Tx) error {
+		// The transaction may be retried, so the result is reset here
+		result = &kolide.ApplyLabelSpecsResult{Created: []string{}, Updated: []string{}, Deleted: []string{}}
+
+		var stored []struct {
+			kolide.LabelSpec
+			Deleted bool `db:"deleted"`
+		}
+		query := `
+			SELECT name, description, query, platform, label_type, criteria, detail_update_interval, deleted
+			FROM labels
+			ORDER BY name
+			FOR UPDATE
+		`
+		if err := tx.Select(&stored, query); err != nil {
+			return errors.Wrap(err, "select SyncLabelSpecs labels")
+		}
+		existing := make(map[string]*kolide.LabelSpec, len(stored))
+		for i := range stored {
+			if !stored[i].Deleted {
+				existing[stored[i].Name] = &stored[i].LabelSpec
+			}
+		}
+
+		stmt, err := tx.Prepare(applyLabelSpecSQL)
+		if err != nil {
+			return errors.Wrap(err, "prepare SyncLabelSpecs insert")
+		}
+		applied := make(map[string]bool, len(specs))
+		for _, s := range specs {
+			if s.Name == "" {
+				return errors.New("label name must not be empty")
+			}
+			applied[s.Name] = true
+			current, ok := existing[s.Name]
+			if ok && labelSpecsEqual(current, s) {
+				continue
+			}
+			if _, err := stmt.Exec(s.Name, s.Description, s.Query, s.Platform, s.LabelType, s.Criteria, s.DetailUpdateInterval); err != nil {
+				return errors.Wrap(err, "exec SyncLabelSpecs insert")
+			}
+			if ok {
+				result.Updated = append(result.Updated, s.Name)
+			} else {
+				result.Created = append(result.Created, s.Name)
+			}
+			existing[s.Name] = s
+		}
+
+		if !prune {
+			return nil
+		}
+		for _, s := range stored {
+			if s.Deleted || applied[s.Name] || s.LabelType == kolide.LabelTypeBuiltIn {
+				continue
+			}
+			result.Deleted = append(result.Deleted, s.Name)
+		}
+		if len(result.Deleted) == 0 {
+			return nil
+		}
+		query, args, err := sqlx.In("DELETE FROM labels WHERE name IN (?)", result.Deleted)
+		if err != nil {
+			return errors.Wrap(err, "build SyncLabelSpecs delete")
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			if isMySQLForeignKey(err) {
+				return foreignKey("labels", strings.Join(result.Deleted, ", "))
+			}
+			return errors.Wrap(err, "exec SyncLabelSpecs delete")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "SyncLabelSpecs transaction")
+	}
+	return result, nil
+}
+
+// labelSpecsEqual returns true if applying spec b would not change the label
+// stored with spec a.
+func labelSpecsEqual(a, b *kolide.LabelSpec) bool {
+	if a.Description != b.Description || a.Query != b.Query || a.Platform != b.Platform ||
+		a.LabelType != b.LabelType || a.DetailUpdateInterval != b.DetailUpdateInterval {
+		return false
+	}
+	if a.Criteria == nil || b.Criteria == nil {
+		return a.Criteria == nil && b.Criteria == nil
+	}
+	return *a.Criteria == *b.Criteria
+}
+
 func (d *Datastore) GetLabelSpecs() ([]*kolide.LabelSpec, error) {
 	var specs []*kolide.LabelSpec
 	// Get basic specs
-	query := "SELECT name, description, query, platform, label_type FROM labels"
+	query := "SELECT name, description, query, platform, label_type, criteria, detail_update_interval FROM labels"
 	if err := d.db.Select(&specs, query); err != nil {
 		return nil, errors.Wrap(err, "get labels")
 	}
@@ -62,7 +164,7 @@ func (d *Datastore) GetLabelSpecs() ([]*kolide.LabelSpec, error) {
 func (d *Datastore) GetLabelSpec(name string) (*kolide.LabelSpec, error) {
 	var specs []*kolide.LabelSpec
 	query := `
-SELECT name, description, query, platform, label_type
+SELECT name, description, query, platform, label_type, criteria, detail_update_interval
 FROM labels
 WHERE name = ?
 `
@@ -96,8 +198,10 @@ func (d *Datastore) NewLabel(label *kolide.Label, opts ...kolide.OptionalArg) (*
 			description,
 			query,
 			platform,
-			label_type
-		) VALUES ( ?, ?, ?, ?, ?)
+			label_type,
+			criteria,
+			detail_update_interval
+		) VALUES ( ?, ?, ?, ?, ?, ?, ?)
 	`
 	case sql.ErrNoRows:
 		query = `
@@ -106,13 +210,15 @@ func (d *Datastore) NewLabel(label *kolide.Label, opts ...kolide.OptionalArg) (*
 			description,
 			query,
 			platform,
-			label_type
-		) VALUES ( ?, ?, ?, ?, ?)
+			label_type,
+			criteria,
+			detail_update_interval
+		) VALUES ( ?, ?, ?, ?, ?, ?, ?)
 	`
 	default:
 		return nil, errors.Wrap(err, "check for existing label")
 	}
-	result, err := db.Exec(query, label.Name, label.Description, label.Query, label.Platform, label.LabelType)
+	result, err := db.Exec(query, label.Name, label.Description, label.Query, label.Platform, label.LabelType, label.Criteria, label.DetailUpdateInterval)
 	if err != nil {
 		return nil, errors.Wrap(err, "inserting label")
 	}
@@ -127,10 +233,11 @@ func (d *Datastore) SaveLabel(label *kolide.Label) (*kolide.Label, error) {
 	query := `
 		UPDATE labels SET
 			name = ?,
-			description = ?
+			description = ?,
+			detail_update_interval = ?
 		WHERE id = ?
 	`
-	_, err := d.db.Exec(query, label.Name, label.Description, label.ID)
+	_, err := d.db.Exec(query, label.Name, label.Description, label.DetailUpdateInterval, label.ID)
 	if err != nil {
 		return nil, errors.Wrap(err, "saving label")
 	}
@@ -181,6 +288,7 @@ func (d *Datastore) LabelQueriesForHost(host *kolide.Host, cutoff time.Time) (ma
 			SELECT l.id, l.query
 			FROM labels l
 			WHERE (l.platform = ? OR l.platform = '')
+			AND l.label_type NOT IN (?, ?)
 			AND NOT l.deleted
 			AND l.id NOT IN /* subtract the set of executions that are recent enough */
 			(
@@ -188,10 +296,10 @@ func (d *Datastore) LabelQueriesForHost(host *kolide.Host, cutoff time.Time) (ma
 			  FROM labels l
 			  JOIN label_query_executions lqe
 			  ON lqe.label_id = l.id
-			  WHERE lqe.host_id = ? AND lqe.updated_at > ?
+			  WHERE lqe.host_id = ? AND lqe.updated_at > ? AND NOT lqe.refresh
 			)
 	`
-	rows, err := d.db.Query(sqlStatment, host.Platform, host.ID, cutoff)
+	rows, err := d.db.Query(sqlStatment, host.Platform, kolide.LabelTypeComputed, kolide.LabelTypeManual, host.ID, cutoff)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, errors.Wrap(err, "selecting label queries for host")
 	}
@@ -233,7 +341,8 @@ func (d *Datastore) RecordLabelQueryExecutions(host *kolide.Host, results map[ui
 	sqlStatement += `
 		ON DUPLICATE KEY UPDATE
 		updated_at = VALUES(updated_at),
-		matches = VALUES(matches)
+		matches = VALUES(matches),
+		refresh = FALSE
 	`
 
 	_, err := d.db.Exec(sqlStatement, vals...)
@@ -244,6 +353,57 @@ func (d *Datastore) RecordLabelQueryExecutions(host *kolide.Host, results map[ui
 	return nil
 }
 
+func (d *Datastore) RefreshModifiedLabels(limit int) ([]uint, error) {
+	var ids []uint
+	err := d.withRetryTxx(func(tx *sqlx.Tx) error {
+		// The transaction may be retried, so the IDs are reset here
+		ids = []uint{}
+		selectStmt := `
+			SELECT l.id
+			FROM labels l
+			JOIN label_query_executions lqe
+			ON lqe.label_id = l.id
+			WHERE l.label_type NOT IN (?, ?)
+			AND NOT l.deleted
+			AND NOT lqe.refresh
+			AND lqe.updated_at < l.updated_at
+			GROUP BY l.id, l.updated_at
+			ORDER BY l.updated_at DESC
+			LIMIT ?
+		`
+		if err := tx.Select(&ids, selectStmt, kolide.LabelTypeComputed, kolide.LabelTypeManual, limit); err != nil {
+			return errors.Wrap(err, "selecting modified labels")
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		// updated_at is set to itself to keep it from being updated to
+		// the current time, as it records when the host evaluated the
+		// label
+		updateStmt := `
+			UPDATE label_query_executions lqe
+			JOIN labels l
+			ON lqe.label_id = l.id
+			SET lqe.refresh = TRUE, lqe.updated_at = lqe.updated_at
+			WHERE lqe.label_id IN (?)
+			AND lqe.updated_at < l.updated_at
+		`
+		query, args, err := sqlx.In(updateStmt, ids)
+		if err != nil {
+			return errors.Wrap(err, "building refresh label executions query")
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return errors.Wrap(err, "refreshing label executions")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "RefreshModifiedLabels transaction")
+	}
+	return ids, nil
+}
+
 // ListLabelsForHost returns a list of kolide.Label for a given host id.
 func (d *Datastore) ListLabelsForHost(hid uint) ([]kolide.Label, error) {
 	sqlStatement := `
@@ -252,21 +412,183 @@ func (d *Datastore) ListLabelsForHost(hid uint) ([]kolide.Label, error) {
 		AND lqe.label_id = labels.id
 		AND lqe.matches
 		AND NOT labels.deleted
+		UNION
+		SELECT labels.* from labels, label_membership lm
+		WHERE lm.host_id = ?
+		AND lm.label_id = labels.id
+		AND NOT labels.deleted
 	`
 
 	labels := []kolide.Label{}
-	err := d.db.Select(&labels, sqlStatement, hid)
+	err := d.db.Select(&labels, sqlStatement, hid, hid)
 	if err != nil {
 		return nil, errors.Wrap(err, "selecting host labels")
 	}
 
+	computed, err := d.computedLabels()
+	if err != nil {
+		return nil, err
+	}
+	if len(computed) == 0 {
+		return labels, nil
+	}
+
+	host, err := d.Host(hid)
+	if err != nil {
+		return nil, errors.Wrap(err, "get host for computed labels")
+	}
+	now := d.clock.Now()
+	for _, label := range computed {
+		if label.Criteria.Matches(host, now) {
+			labels = append(labels, label)
+		}
+	}
+
 	return labels, nil
 
 }
 
+// computedLabels returns all of the labels whose membership is computed from
+// host attributes.
+func (d *Datastore) computedLabels() ([]kolide.Label, error) {
+	sqlStatement := `
+		SELECT * FROM labels
+		WHERE label_type = ?
+		AND criteria IS NOT NULL
+		AND NOT deleted
+	`
+	labels := []kolide.Label{}
+	if err := d.db.Select(&labels, sqlStatement, kolide.LabelTypeComputed); err != nil {
+		return nil, errors.Wrap(err, "selecting computed labels")
+	}
+	return labels, nil
+}
+
+// hostsMatchingCriteria returns the hosts that currently satisfy the criteria
+// of a computed label.
+func (d *Datastore) hostsMatchingCriteria(criteria *kolide.LabelCriteria) ([]kolide.Host, error) {
+	hosts := []kolide.Host{}
+	if criteria == nil {
+		return hosts, nil
+	}
+
+	condition, args := criteriaCondition(criteria, d.clock.Now())
+	sqlStatement := "SELECT * FROM hosts WHERE NOT deleted AND " + condition
+	if err := d.db.Select(&hosts, sqlStatement, args...); err != nil {
+		return nil, errors.Wrap(err, "selecting hosts for computed label")
+	}
+	return hosts, nil
+}
+
+// criteriaCondition returns the condition on the columns of the hosts table
+// selecting the hosts that satisfy criteria at the given time. The logic
+// should remain synchronized with LabelCriteria.Matches, and the status
+// conditions with CountHostsInTargets.
+func criteriaCondition(criteria *kolide.LabelCriteria, now time.Time) (string, []interface{}) {
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	if criteria.Platform != "" {
+		conditions = append(conditions, "platform = ?")
+		args = append(args, criteria.Platform)
+	}
+	if criteria.OsqueryVersion != "" {
+		conditions = append(conditions, "osquery_version = ?")
+		args = append(args, criteria.OsqueryVersion)
+	}
+	if criteria.OfflineFor > 0 {
+		conditions = append(conditions, "DATE_ADD(seen_time, INTERVAL ? SECOND) <= ?")
+		args = append(args, criteria.OfflineFor, now)
+	}
+	if criteria.Status != "" {
+		offline := fmt.Sprintf("DATE_ADD(seen_time, INTERVAL LEAST(distributed_interval, config_tls_refresh) + %d SECOND) <= ?", kolide.OnlineIntervalBuffer)
+		switch criteria.Status {
+		case kolide.StatusOnline:
+			conditions = append(conditions, "NOT "+offline)
+			args = append(args, now)
+		case kolide.StatusMaintenance:
+			conditions = append(conditions, offline+" AND maintenance_until > ?")
+			args = append(args, now, now)
+		case kolide.StatusMIA:
+			conditions = append(conditions, "DATE_ADD(seen_time, INTERVAL 30 DAY) <= ? AND NOT COALESCE(maintenance_until > ?, FALSE)")
+			args = append(args, now, now)
+		case kolide.StatusOffline:
+			conditions = append(conditions, offline+" AND DATE_ADD(seen_time, INTERVAL 30 DAY) >= ? AND NOT COALESCE(maintenance_until > ?, FALSE)")
+			args = append(args, now, now, now)
+		default:
+			conditions = append(conditions, "FALSE")
+		}
+	}
+	return "(" + strings.Join(conditions, " AND ") + ")", args
+}
+
+// computedLabelIDsForHost returns the IDs of the computed labels whose
+// criteria the host currently satisfies.
+func (d *Datastore) computedLabelIDsForHost(hid uint) ([]int, error) {
+	computed, err := d.computedLabels()
+	if err != nil {
+		return nil, err
+	}
+	ids := []int{}
+	if len(computed) == 0 {
+		return ids, nil
+	}
+
+	host, err := d.Host(hid)
+	if err != nil {
+		return nil, errors.Wrap(err, "get host for computed labels")
+	}
+	now := d.clock.Now()
+	for _, label := range computed {
+		if label.Criteria.Matches(host, now) {
+			ids = append(ids, int(label.ID))
+		}
+	}
+	return ids, nil
+}
+
+// computedLabelsCondition returns the condition on the columns of the hosts
+// table selecting the members of the computed labels among labelIDs.
+func (d *Datastore) computedLabelsCondition(labelIDs []uint, now time.Time) (string, []interface{}, error) {
+	if len(labelIDs) == 0 {
+		return "FALSE", nil, nil
+	}
+	computed, err := d.computedLabels()
+	if err != nil {
+		return "", nil, err
+	}
+	requested := make(map[uint]bool, len(labelIDs))
+	for _, lid := range labelIDs {
+		requested[lid] = true
+	}
+
+	conditions := []string{"FALSE"}
+	args := []interface{}{}
+	for _, label := range computed {
+		if !requested[label.ID] {
+			continue
+		}
+		condition, criteriaArgs := criteriaCondition(label.Criteria, now)
+		conditions = append(conditions, condition)
+		args = append(args, criteriaArgs...)
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args, nil
+}
+
 // ListHostsInLabel returns a list of kolide.Host that are associated
 // with kolide.Label referened by Label ID
 func (d *Datastore) ListHostsInLabel(lid uint) ([]kolide.Host, error) {
+	var label kolide.Label
+	err := d.db.Get(&label, "SELECT * FROM labels WHERE id = ?", lid)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrap(err, "get label for hosts")
+	}
+	switch label.LabelType {
+	case kolide.LabelTypeComputed:
+		return d.hostsMatchingCriteria(label.Criteria)
+	case kolide.LabelTypeManual:
+		return d.manualLabelMembers(lid)
+	}
+
 	sqlStatement := `
 		SELECT h.*
 		FROM label_query_executions lqe
@@ -277,13 +599,147 @@ func (d *Datastore) ListHostsInLabel(lid uint) ([]kolide.Host, error) {
 		AND NOT h.deleted
 	`
 	hosts := []kolide.Host{}
-	err := d.db.Select(&hosts, sqlStatement, lid)
+	err = d.db.Select(&hosts, sqlStatement, lid)
 	if err != nil {
 		return nil, errors.Wrap(err, "selecting label query executions")
 	}
 	return hosts, nil
 }
 
+// manualLabelMembers returns the hosts explicitly added to a manual label.
+func (d *Datastore) manualLabelMembers(lid uint) ([]kolide.Host, error) {
+	sqlStatement := `
+		SELECT h.*
+		FROM label_membership lm
+		JOIN hosts h
+		ON lm.host_id = h.id
+		WHERE lm.label_id = ?
+		AND NOT h.deleted
+	`
+	hosts := []kolide.Host{}
+	if err := d.db.Select(&hosts, sqlStatement, lid); err != nil {
+		return nil, errors.Wrap(err, "selecting manual label members")
+	}
+	return hosts, nil
+}
+
+func (d *Datastore) AddHostsToLabel(lid uint, hostIDs []uint) error {
+	if len(hostIDs) == 0 {
+		return nil
+	}
+	return insertLabelMembership(d.db, lid, hostIDs)
+}
+
+func insertLabelMembership(db sqlx.Execer, lid uint, hostIDs []uint) error {
+	sqlStatement := `
+		INSERT INTO label_membership (label_id, host_id) VALUES
+	`
+	vals := []interface{}{}
+	bindvars := ""
+	for _, hid := range hostIDs {
+		if bindvars != "" {
+			bindvars += ","
+		}
+		bindvars += "(?,?)"
+		vals = append(vals, lid, hid)
+	}
+	// Adding an existing member is a no-op. INSERT IGNORE is avoided so
+	// that unknown host IDs still fail the foreign key constraint.
+	sqlStatement += bindvars + `
+		ON DUPLICATE KEY UPDATE label_id = label_id
+	`
+
+	if _, err := db.Exec(sqlStatement, vals...); err != nil {
+		if isChildForeignKeyError(err) {
+			return notFound("Host").WithMessage("one or more hosts do not exist")
+		}
+		return errors.Wrap(err, "adding hosts to label")
+	}
+	return nil
+}
+
+func (d *Datastore) RemoveHostsFromLabel(lid uint, hostIDs []uint) error {
+	if len(hostIDs) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In("DELETE FROM label_membership WHERE label_id = ? AND host_id IN (?)", lid, hostIDs)
+	if err != nil {
+		return errors.Wrap(err, "building query removing hosts from label")
+	}
+	if _, err := d.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "removing hosts from label")
+	}
+	return nil
+}
+
+func (d *Datastore) TransferHostsToLabel(lid uint, hostIDs []uint) ([]uint, error) {
+	if len(hostIDs) == 0 {
+		return nil, nil
+	}
+
+	var missing []uint
+	err := d.withRetryTxx(func(tx *sqlx.Tx) error {
+		missing = nil
+
+		query, args, err := sqlx.In("SELECT id FROM hosts WHERE id IN (?) AND NOT deleted", hostIDs)
+		if err != nil {
+			return errors.Wrap(err, "building query selecting hosts to transfer")
+		}
+		var found []uint
+		if err := tx.Select(&found, query, args...); err != nil {
+			return errors.Wrap(err, "selecting hosts to transfer")
+		}
+		exists := make(map[uint]bool, len(found))
+		for _, hid := range found {
+			exists[hid] = true
+		}
+		for _, hid := range hostIDs {
+			if !exists[hid] {
+				missing = append(missing, hid)
+			}
+		}
+		if len(missing) > 0 {
+			return nil
+		}
+
+		// Only manual labels have rows in label_membership, so this
+		// removes the hosts from every other manual label.
+		query, args, err = sqlx.In("DELETE FROM label_membership WHERE host_id IN (?) AND label_id != ?", hostIDs, lid)
+		if err != nil {
+			return errors.Wrap(err, "building query removing hosts from labels")
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return errors.Wrap(err, "removing hosts from labels")
+		}
+
+		if err := insertLabelMembership(tx, lid, hostIDs); err != nil {
+			return err
+		}
+
+		var secretName string
+		err = tx.Get(&secretName, "SELECT name FROM enroll_secrets WHERE label_id = ? ORDER BY name LIMIT 1", lid)
+		switch {
+		case err == sql.ErrNoRows:
+			return nil
+		case err != nil:
+			return errors.Wrap(err, "selecting enroll secret for label")
+		}
+		query, args, err = sqlx.In("UPDATE hosts SET enroll_secret_name = ? WHERE id IN (?)", secretName, hostIDs)
+		if err != nil {
+			return errors.Wrap(err, "building query updating host enroll secrets")
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return errors.Wrap(err, "updating host enroll secrets")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return missing, nil
+}
+
 func (d *Datastore) ListUniqueHostsInLabels(labels []uint) ([]kolide.Host, error) {
 	if len(labels) == 0 {
 		return []kolide.Host{}, nil
@@ -297,8 +753,15 @@ func (d *Datastore) ListUniqueHostsInLabels(labels []uint) ([]kolide.Host, error
 		WHERE lqe.label_id IN (?)
 		AND lqe.matches = 1
 		AND NOT h.deleted
+		UNION
+		SELECT h.*
+		FROM label_membership lm
+		JOIN hosts h
+		ON lm.host_id = h.id
+		WHERE lm.label_id IN (?)
+		AND NOT h.deleted
 	`
-	query, args, err := sqlx.In(sqlStatement, labels)
+	query, args, err := sqlx.In(sqlStatement, labels, labels)
 	if err != nil {
 		return nil, errors.Wrap(err, "building query listing unique hosts in labels")
 	}
@@ -310,6 +773,34 @@ func (d *Datastore) ListUniqueHostsInLabels(labels []uint) ([]kolide.Host, error
 		return nil, errors.Wrap(err, "listing unique hosts in labels")
 	}
 
+	computed, err := d.computedLabels()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[uint]bool, len(hosts))
+	for _, h := range hosts {
+		seen[h.ID] = true
+	}
+	requested := make(map[uint]bool, len(labels))
+	for _, lid := range labels {
+		requested[lid] = true
+	}
+	for _, label := range computed {
+		if !requested[label.ID] {
+			continue
+		}
+		matching, err := d.hostsMatchingCriteria(label.Criteria)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range matching {
+			if !seen[h.ID] {
+				seen[h.ID] = true
+				hosts = append(hosts, h)
+			}
+		}
+	}
+
 	return hosts, nil
 
 }
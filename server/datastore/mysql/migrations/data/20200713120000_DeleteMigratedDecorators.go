@@ -0,0 +1,27 @@
+package data
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200713120000, Down20200713120000)
+}
+
+// Up20200713120000 removes the legacy decorator rows. These were copied into
+// the osquery options by Up_20171212182458, so leaving them in place would
+// duplicate them in the config now that the decorators table is served again.
+func Up20200713120000(tx *sql.Tx) error {
+	sql := `DELETE FROM decorators`
+	if _, err := tx.Exec(sql); err != nil {
+		return errors.Wrap(err, "delete migrated decorators")
+	}
+
+	return nil
+}
+
+func Down20200713120000(tx *sql.Tx) error {
+	return nil
+}
@@ -0,0 +1,25 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200601120000, Down20200601120000)
+}
+
+func Up20200601120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `distributed_query_campaigns` " +
+			"ADD COLUMN `execution_timeout` INT UNSIGNED NOT NULL DEFAULT 0;",
+	)
+	return err
+}
+
+func Down20200601120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `distributed_query_campaigns` " +
+			"DROP COLUMN `execution_timeout`;",
+	)
+	return err
+}
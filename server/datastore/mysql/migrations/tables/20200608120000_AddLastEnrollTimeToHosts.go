@@ -0,0 +1,35 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200608120000, Down20200608120000)
+}
+
+func Up20200608120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"ADD COLUMN `last_enroll_time` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;",
+	)
+	if err != nil {
+		return err
+	}
+
+	// Existing hosts last enrolled no later than they were created. Leaving
+	// them at the time of the migration would have the enroll cooldown
+	// treat every host as just enrolled.
+	_, err = tx.Exec(
+		"UPDATE `hosts` SET `last_enroll_time` = `created_at` WHERE `created_at` IS NOT NULL;",
+	)
+	return err
+}
+
+func Down20200608120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"DROP COLUMN `last_enroll_time`;",
+	)
+	return err
+}
@@ -0,0 +1,27 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200615120000, Down20200615120000)
+}
+
+func Up20200615120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `app_configs` " +
+			"ADD COLUMN `enrollment_webhook_url` VARCHAR(255) NOT NULL DEFAULT '', " +
+			"ADD COLUMN `enrollment_webhook_secret` VARCHAR(255) NOT NULL DEFAULT '';",
+	)
+	return err
+}
+
+func Down20200615120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `app_configs` " +
+			"DROP COLUMN `enrollment_webhook_url`, " +
+			"DROP COLUMN `enrollment_webhook_secret`;",
+	)
+	return err
+}
@@ -0,0 +1,25 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200622120000, Down20200622120000)
+}
+
+func Up20200622120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `queries` " +
+			"ADD COLUMN `cache_ttl` INT UNSIGNED NOT NULL DEFAULT 0;",
+	)
+	return err
+}
+
+func Down20200622120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `queries` " +
+			"DROP COLUMN `cache_ttl`;",
+	)
+	return err
+}
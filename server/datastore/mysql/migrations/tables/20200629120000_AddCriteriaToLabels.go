@@ -0,0 +1,25 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200629120000, Down20200629120000)
+}
+
+func Up20200629120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `labels` " +
+			"ADD COLUMN `criteria` JSON DEFAULT NULL;",
+	)
+	return err
+}
+
+func Down20200629120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `labels` " +
+			"DROP COLUMN `criteria`;",
+	)
+	return err
+}
@@ -0,0 +1,25 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200706120000, Down20200706120000)
+}
+
+func Up20200706120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"ADD COLUMN `config_refresh_requested` BOOLEAN NOT NULL DEFAULT FALSE;",
+	)
+	return err
+}
+
+func Down20200706120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"DROP COLUMN `config_refresh_requested`;",
+	)
+	return err
+}
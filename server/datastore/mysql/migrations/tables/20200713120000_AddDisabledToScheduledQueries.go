@@ -0,0 +1,25 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200713120000, Down20200713120000)
+}
+
+func Up20200713120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `scheduled_queries` " +
+			"ADD COLUMN `disabled` BOOLEAN NOT NULL DEFAULT FALSE;",
+	)
+	return err
+}
+
+func Down20200713120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `scheduled_queries` " +
+			"DROP COLUMN `disabled`;",
+	)
+	return err
+}
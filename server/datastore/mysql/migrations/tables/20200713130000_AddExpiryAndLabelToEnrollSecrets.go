@@ -0,0 +1,30 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200713130000, Down20200713130000)
+}
+
+func Up20200713130000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `enroll_secrets` " +
+			"ADD COLUMN `expires_at` TIMESTAMP NULL DEFAULT NULL, " +
+			"ADD COLUMN `label_id` INT(10) UNSIGNED DEFAULT NULL, " +
+			"ADD CONSTRAINT `enroll_secrets_label_id` FOREIGN KEY (`label_id`) " +
+			"REFERENCES `labels` (`id`) ON DELETE SET NULL;",
+	)
+	return err
+}
+
+func Down20200713130000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `enroll_secrets` " +
+			"DROP FOREIGN KEY `enroll_secrets_label_id`, " +
+			"DROP COLUMN `label_id`, " +
+			"DROP COLUMN `expires_at`;",
+	)
+	return err
+}
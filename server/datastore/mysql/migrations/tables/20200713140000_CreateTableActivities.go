@@ -0,0 +1,31 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200713140000, Down20200713140000)
+}
+
+func Up20200713140000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"CREATE TABLE `activities` (" +
+			"`id` INT(10) UNSIGNED NOT NULL AUTO_INCREMENT," +
+			"`created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+			"`actor_id` INT(10) UNSIGNED DEFAULT NULL," +
+			"`actor_username` VARCHAR(255) NOT NULL DEFAULT ''," +
+			"`action` VARCHAR(255) NOT NULL," +
+			"`target_id` INT(10) UNSIGNED DEFAULT NULL," +
+			"`target_name` VARCHAR(255) NOT NULL DEFAULT ''," +
+			"PRIMARY KEY (`id`)," +
+			"KEY `idx_activities_created_at` (`created_at`)" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+	)
+	return err
+}
+
+func Down20200713140000(tx *sql.Tx) error {
+	_, err := tx.Exec("DROP TABLE IF EXISTS `activities`;")
+	return err
+}
@@ -0,0 +1,25 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200713150000, Down20200713150000)
+}
+
+func Up20200713150000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `labels` " +
+			"ADD COLUMN `detail_update_interval` INT(10) UNSIGNED NOT NULL DEFAULT 0;",
+	)
+	return err
+}
+
+func Down20200713150000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `labels` " +
+			"DROP COLUMN `detail_update_interval`;",
+	)
+	return err
+}
@@ -0,0 +1,32 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200714120000, Down20200714120000)
+}
+
+func Up20200714120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"CREATE TABLE `api_tokens` (" +
+			"`id` INT(10) UNSIGNED NOT NULL AUTO_INCREMENT," +
+			"`created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+			"`updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP," +
+			"`user_id` INT(10) UNSIGNED NOT NULL," +
+			"`name` VARCHAR(255) NOT NULL DEFAULT ''," +
+			"`key` VARCHAR(255) NOT NULL," +
+			"`scopes` JSON NOT NULL," +
+			"PRIMARY KEY (`id`)," +
+			"UNIQUE KEY `idx_api_tokens_key` (`key`)," +
+			"FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+	)
+	return err
+}
+
+func Down20200714120000(tx *sql.Tx) error {
+	_, err := tx.Exec("DROP TABLE IF EXISTS `api_tokens`;")
+	return err
+}
@@ -0,0 +1,31 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200715120000, Down20200715120000)
+}
+
+func Up20200715120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `app_configs` " +
+			"ADD COLUMN `password_min_length` INT NOT NULL DEFAULT 7, " +
+			"ADD COLUMN `password_require_number` TINYINT(1) NOT NULL DEFAULT TRUE, " +
+			"ADD COLUMN `password_require_symbol` TINYINT(1) NOT NULL DEFAULT TRUE, " +
+			"ADD COLUMN `password_require_mixed_case` TINYINT(1) NOT NULL DEFAULT FALSE;",
+	)
+	return err
+}
+
+func Down20200715120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `app_configs` " +
+			"DROP COLUMN `password_min_length`, " +
+			"DROP COLUMN `password_require_number`, " +
+			"DROP COLUMN `password_require_symbol`, " +
+			"DROP COLUMN `password_require_mixed_case`;",
+	)
+	return err
+}
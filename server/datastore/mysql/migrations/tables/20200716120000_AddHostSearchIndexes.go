@@ -0,0 +1,30 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200716120000, Down20200716120000)
+}
+
+func Up20200716120000(tx *sql.Tx) error {
+	// Host searches match prefixes of these columns
+	_, err := tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"ADD INDEX `idx_hosts_host_name` (`host_name`), " +
+			"ADD INDEX `idx_hosts_uuid` (`uuid`), " +
+			"ADD INDEX `idx_hosts_hardware_serial` (`hardware_serial`);",
+	)
+	return err
+}
+
+func Down20200716120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"DROP INDEX `idx_hosts_host_name`, " +
+			"DROP INDEX `idx_hosts_uuid`, " +
+			"DROP INDEX `idx_hosts_hardware_serial`;",
+	)
+	return err
+}
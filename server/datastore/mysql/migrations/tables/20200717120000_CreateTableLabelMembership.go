@@ -0,0 +1,29 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200717120000, Down20200717120000)
+}
+
+func Up20200717120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"CREATE TABLE `label_membership` (" +
+			"`created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+			"`label_id` INT(10) UNSIGNED NOT NULL," +
+			"`host_id` INT(10) UNSIGNED NOT NULL," +
+			"PRIMARY KEY (`label_id`, `host_id`)," +
+			"KEY `idx_label_membership_host_id` (`host_id`)," +
+			"FOREIGN KEY (`label_id`) REFERENCES `labels`(`id`) ON DELETE CASCADE," +
+			"FOREIGN KEY (`host_id`) REFERENCES `hosts`(`id`) ON DELETE CASCADE" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+	)
+	return err
+}
+
+func Down20200717120000(tx *sql.Tx) error {
+	_, err := tx.Exec("DROP TABLE IF EXISTS `label_membership`;")
+	return err
+}
@@ -0,0 +1,32 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200718120000, Down20200718120000)
+}
+
+func Up20200718120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"CREATE TABLE `scheduled_query_stats` (" +
+			"`host_id` INT(10) UNSIGNED NOT NULL," +
+			"`scheduled_query_id` INT(10) UNSIGNED NOT NULL," +
+			"`last_executed` TIMESTAMP NULL DEFAULT NULL," +
+			"`executions` INT(10) UNSIGNED NOT NULL DEFAULT 0," +
+			"`wall_time` BIGINT UNSIGNED NOT NULL DEFAULT 0," +
+			"`output_size` BIGINT UNSIGNED NOT NULL DEFAULT 0," +
+			"PRIMARY KEY (`host_id`, `scheduled_query_id`)," +
+			"KEY `idx_scheduled_query_stats_scheduled_query_id` (`scheduled_query_id`)," +
+			"FOREIGN KEY (`host_id`) REFERENCES `hosts`(`id`) ON DELETE CASCADE," +
+			"FOREIGN KEY (`scheduled_query_id`) REFERENCES `scheduled_queries`(`id`) ON DELETE CASCADE" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+	)
+	return err
+}
+
+func Down20200718120000(tx *sql.Tx) error {
+	_, err := tx.Exec("DROP TABLE IF EXISTS `scheduled_query_stats`;")
+	return err
+}
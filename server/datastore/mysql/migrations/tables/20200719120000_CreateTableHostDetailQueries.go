@@ -0,0 +1,45 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200719120000, Down20200719120000)
+}
+
+func Up20200719120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"CREATE TABLE `host_detail_queries` (" +
+			"`id` INT(10) UNSIGNED NOT NULL AUTO_INCREMENT," +
+			"`created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+			"`updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP," +
+			"`name` VARCHAR(255) NOT NULL," +
+			"`query` TEXT NOT NULL," +
+			"PRIMARY KEY (`id`)," +
+			"UNIQUE KEY `idx_host_detail_queries_unique_name` (`name`)" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+	)
+	if err != nil {
+		return errors.Wrap(err, "create host_detail_queries table")
+	}
+
+	_, err = tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"ADD COLUMN `host_extra_details` JSON DEFAULT NULL;",
+	)
+	if err != nil {
+		return errors.Wrap(err, "add host_extra_details column")
+	}
+	return nil
+}
+
+func Down20200719120000(tx *sql.Tx) error {
+	if _, err := tx.Exec("ALTER TABLE `hosts` DROP COLUMN `host_extra_details`;"); err != nil {
+		return errors.Wrap(err, "drop host_extra_details column")
+	}
+	_, err := tx.Exec("DROP TABLE IF EXISTS `host_detail_queries`;")
+	return errors.Wrap(err, "drop host_detail_queries table")
+}
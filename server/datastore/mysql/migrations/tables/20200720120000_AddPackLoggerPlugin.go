@@ -0,0 +1,25 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200720120000, Down20200720120000)
+}
+
+func Up20200720120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `packs` " +
+			"ADD COLUMN `logger_plugin` VARCHAR(255) NOT NULL DEFAULT '';",
+	)
+	return err
+}
+
+func Down20200720120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `packs` " +
+			"DROP COLUMN `logger_plugin`;",
+	)
+	return err
+}
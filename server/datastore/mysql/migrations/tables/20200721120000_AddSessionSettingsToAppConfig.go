@@ -0,0 +1,27 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200721120000, Down20200721120000)
+}
+
+func Up20200721120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `app_configs` " +
+			"ADD COLUMN `session_duration` INT NOT NULL DEFAULT 0, " +
+			"ADD COLUMN `session_idle_timeout` INT NOT NULL DEFAULT 0;",
+	)
+	return err
+}
+
+func Down20200721120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `app_configs` " +
+			"DROP COLUMN `session_duration`, " +
+			"DROP COLUMN `session_idle_timeout`;",
+	)
+	return err
+}
@@ -0,0 +1,46 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200722120000, Down20200722120000)
+}
+
+func Up20200722120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"CREATE TABLE `host_uptime_events` (" +
+			"`id` INT(10) UNSIGNED NOT NULL AUTO_INCREMENT," +
+			"`host_id` INT(10) UNSIGNED NOT NULL," +
+			"`boot_time` TIMESTAMP NULL DEFAULT NULL," +
+			"`previous_boot_time` TIMESTAMP NULL DEFAULT NULL," +
+			"`detected_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+			"PRIMARY KEY (`id`)," +
+			"KEY `idx_host_uptime_events_host_id` (`host_id`, `detected_at`)," +
+			"FOREIGN KEY (`host_id`) REFERENCES `hosts`(`id`) ON DELETE CASCADE" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+	)
+	if err != nil {
+		return errors.Wrap(err, "create host_uptime_events table")
+	}
+
+	_, err = tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"ADD COLUMN `boot_time` TIMESTAMP NULL DEFAULT NULL;",
+	)
+	if err != nil {
+		return errors.Wrap(err, "add boot_time column")
+	}
+	return nil
+}
+
+func Down20200722120000(tx *sql.Tx) error {
+	if _, err := tx.Exec("ALTER TABLE `hosts` DROP COLUMN `boot_time`;"); err != nil {
+		return errors.Wrap(err, "drop boot_time column")
+	}
+	_, err := tx.Exec("DROP TABLE IF EXISTS `host_uptime_events`;")
+	return errors.Wrap(err, "drop host_uptime_events table")
+}
@@ -0,0 +1,45 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200723120000, Down20200723120000)
+}
+
+func Up20200723120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `app_configs` " +
+			"ADD COLUMN `campaign_webhook_url` VARCHAR(255) NOT NULL DEFAULT '', " +
+			"ADD COLUMN `campaign_webhook_secret` VARCHAR(255) NOT NULL DEFAULT '', " +
+			"ADD COLUMN `campaign_webhook_require_results` TINYINT(1) NOT NULL DEFAULT FALSE;",
+	)
+	if err != nil {
+		return errors.Wrap(err, "add campaign webhook columns")
+	}
+
+	_, err = tx.Exec(
+		"ALTER TABLE `distributed_query_executions` " +
+			"ADD COLUMN `row_count` INT(10) UNSIGNED NOT NULL DEFAULT 0;",
+	)
+	if err != nil {
+		return errors.Wrap(err, "add row_count column")
+	}
+	return nil
+}
+
+func Down20200723120000(tx *sql.Tx) error {
+	if _, err := tx.Exec("ALTER TABLE `distributed_query_executions` DROP COLUMN `row_count`;"); err != nil {
+		return errors.Wrap(err, "drop row_count column")
+	}
+	_, err := tx.Exec(
+		"ALTER TABLE `app_configs` " +
+			"DROP COLUMN `campaign_webhook_url`, " +
+			"DROP COLUMN `campaign_webhook_secret`, " +
+			"DROP COLUMN `campaign_webhook_require_results`;",
+	)
+	return errors.Wrap(err, "drop campaign webhook columns")
+}
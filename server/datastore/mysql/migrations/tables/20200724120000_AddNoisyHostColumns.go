@@ -0,0 +1,29 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200724120000, Down20200724120000)
+}
+
+func Up20200724120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"ADD COLUMN `noisy_time` TIMESTAMP NULL DEFAULT NULL, " +
+			"ADD COLUMN `dropped_log_rows` BIGINT(20) UNSIGNED NOT NULL DEFAULT 0;",
+	)
+	return errors.Wrap(err, "add noisy host columns")
+}
+
+func Down20200724120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"DROP COLUMN `noisy_time`, " +
+			"DROP COLUMN `dropped_log_rows`;",
+	)
+	return errors.Wrap(err, "drop noisy host columns")
+}
@@ -0,0 +1,29 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200725120000, Down20200725120000)
+}
+
+func Up20200725120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"CREATE TABLE `query_tags` (" +
+			"`query_id` INT(10) UNSIGNED NOT NULL," +
+			"`tag` VARCHAR(255) NOT NULL," +
+			"PRIMARY KEY (`query_id`, `tag`)," +
+			"KEY `idx_query_tags_tag` (`tag`)," +
+			"FOREIGN KEY (`query_id`) REFERENCES `queries`(`id`) ON DELETE CASCADE" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+	)
+	return errors.Wrap(err, "create query_tags table")
+}
+
+func Down20200725120000(tx *sql.Tx) error {
+	_, err := tx.Exec("DROP TABLE IF EXISTS `query_tags`;")
+	return errors.Wrap(err, "drop query_tags table")
+}
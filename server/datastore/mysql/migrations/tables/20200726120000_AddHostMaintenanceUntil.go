@@ -0,0 +1,27 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200726120000, Down20200726120000)
+}
+
+func Up20200726120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"ADD COLUMN `maintenance_until` TIMESTAMP NULL DEFAULT NULL;",
+	)
+	return errors.Wrap(err, "add maintenance_until to hosts")
+}
+
+func Down20200726120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"DROP COLUMN `maintenance_until`;",
+	)
+	return errors.Wrap(err, "drop maintenance_until from hosts")
+}
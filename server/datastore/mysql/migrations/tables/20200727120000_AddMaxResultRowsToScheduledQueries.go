@@ -0,0 +1,27 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200727120000, Down20200727120000)
+}
+
+func Up20200727120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `scheduled_queries` " +
+			"ADD COLUMN `max_result_rows` INT(10) UNSIGNED NULL DEFAULT NULL;",
+	)
+	return errors.Wrap(err, "add max_result_rows to scheduled queries")
+}
+
+func Down20200727120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `scheduled_queries` " +
+			"DROP COLUMN `max_result_rows`;",
+	)
+	return errors.Wrap(err, "drop max_result_rows from scheduled queries")
+}
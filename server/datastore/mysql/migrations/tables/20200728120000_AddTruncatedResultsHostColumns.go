@@ -0,0 +1,29 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200728120000, Down20200728120000)
+}
+
+func Up20200728120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"ADD COLUMN `truncated_results_time` TIMESTAMP NULL DEFAULT NULL, " +
+			"ADD COLUMN `truncated_result_rows` BIGINT(20) UNSIGNED NOT NULL DEFAULT 0;",
+	)
+	return errors.Wrap(err, "add truncated results host columns")
+}
+
+func Down20200728120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"DROP COLUMN `truncated_results_time`, " +
+			"DROP COLUMN `truncated_result_rows`;",
+	)
+	return errors.Wrap(err, "drop truncated results host columns")
+}
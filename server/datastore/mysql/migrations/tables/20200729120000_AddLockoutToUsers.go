@@ -0,0 +1,29 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200729120000, Down20200729120000)
+}
+
+func Up20200729120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `users` " +
+			"ADD COLUMN `failed_login_count` INT UNSIGNED NOT NULL DEFAULT 0, " +
+			"ADD COLUMN `locked_until` TIMESTAMP NULL DEFAULT NULL;",
+	)
+	return errors.Wrap(err, "add lockout user columns")
+}
+
+func Down20200729120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `users` " +
+			"DROP COLUMN `failed_login_count`, " +
+			"DROP COLUMN `locked_until`;",
+	)
+	return errors.Wrap(err, "drop lockout user columns")
+}
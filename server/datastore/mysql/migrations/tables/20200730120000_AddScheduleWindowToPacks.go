@@ -0,0 +1,31 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200730120000, Down20200730120000)
+}
+
+func Up20200730120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `packs` " +
+			"ADD COLUMN `window_start_hour` TINYINT UNSIGNED NULL DEFAULT NULL, " +
+			"ADD COLUMN `window_end_hour` TINYINT UNSIGNED NULL DEFAULT NULL, " +
+			"ADD COLUMN `window_days` VARCHAR(255) NOT NULL DEFAULT '';",
+	)
+	return errors.Wrap(err, "add schedule window pack columns")
+}
+
+func Down20200730120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `packs` " +
+			"DROP COLUMN `window_start_hour`, " +
+			"DROP COLUMN `window_end_hour`, " +
+			"DROP COLUMN `window_days`;",
+	)
+	return errors.Wrap(err, "drop schedule window pack columns")
+}
@@ -0,0 +1,34 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200731120000, Down20200731120000)
+}
+
+func Up20200731120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"CREATE TABLE `atc_tables` (" +
+			"`id` INT(10) UNSIGNED NOT NULL AUTO_INCREMENT," +
+			"`created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+			"`updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP," +
+			"`name` VARCHAR(255) NOT NULL," +
+			"`path` TEXT NOT NULL," +
+			"`query` TEXT NOT NULL," +
+			"`columns` JSON NOT NULL," +
+			"`platform` VARCHAR(255) NOT NULL DEFAULT ''," +
+			"PRIMARY KEY (`id`)," +
+			"UNIQUE KEY `idx_atc_tables_unique_name` (`name`)" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+	)
+	return errors.Wrap(err, "create atc_tables table")
+}
+
+func Down20200731120000(tx *sql.Tx) error {
+	_, err := tx.Exec("DROP TABLE IF EXISTS `atc_tables`;")
+	return errors.Wrap(err, "drop atc_tables table")
+}
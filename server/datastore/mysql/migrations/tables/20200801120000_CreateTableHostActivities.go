@@ -0,0 +1,30 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200801120000, Down20200801120000)
+}
+
+func Up20200801120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"CREATE TABLE `host_activities` (" +
+			"`id` INT(10) UNSIGNED NOT NULL AUTO_INCREMENT," +
+			"`host_id` INT(10) UNSIGNED NOT NULL," +
+			"`created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+			"`type` VARCHAR(255) NOT NULL," +
+			"`details` JSON DEFAULT NULL," +
+			"PRIMARY KEY (`id`)," +
+			"KEY `idx_host_activities_host_id` (`host_id`, `created_at`)," +
+			"FOREIGN KEY (`host_id`) REFERENCES `hosts`(`id`) ON DELETE CASCADE" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+	)
+	return err
+}
+
+func Down20200801120000(tx *sql.Tx) error {
+	_, err := tx.Exec("DROP TABLE IF EXISTS `host_activities`;")
+	return err
+}
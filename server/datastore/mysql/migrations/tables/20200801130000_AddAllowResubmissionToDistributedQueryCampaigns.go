@@ -0,0 +1,25 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200801130000, Down20200801130000)
+}
+
+func Up20200801130000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `distributed_query_campaigns` " +
+			"ADD COLUMN `allow_resubmission` TINYINT(1) NOT NULL DEFAULT FALSE;",
+	)
+	return err
+}
+
+func Down20200801130000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `distributed_query_campaigns` " +
+			"DROP COLUMN `allow_resubmission`;",
+	)
+	return err
+}
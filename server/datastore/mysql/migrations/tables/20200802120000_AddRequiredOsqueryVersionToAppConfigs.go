@@ -0,0 +1,27 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200802120000, Down20200802120000)
+}
+
+func Up20200802120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `app_configs` " +
+			"ADD COLUMN `required_osquery_version` VARCHAR(255) NOT NULL DEFAULT '';",
+	)
+	return errors.Wrap(err, "add required_osquery_version column")
+}
+
+func Down20200802120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `app_configs` " +
+			"DROP COLUMN `required_osquery_version`;",
+	)
+	return errors.Wrap(err, "drop required_osquery_version column")
+}
@@ -0,0 +1,27 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200803120000, Down20200803120000)
+}
+
+func Up20200803120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"ADD COLUMN `pending` BOOLEAN NOT NULL DEFAULT FALSE;",
+	)
+	return errors.Wrap(err, "add pending column")
+}
+
+func Down20200803120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"DROP COLUMN `pending`;",
+	)
+	return errors.Wrap(err, "drop pending column")
+}
@@ -0,0 +1,27 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200804120000, Down20200804120000)
+}
+
+func Up20200804120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `scheduled_queries` " +
+			"ADD COLUMN `sample_rate` DOUBLE NULL DEFAULT NULL;",
+	)
+	return errors.Wrap(err, "add sample_rate to scheduled queries")
+}
+
+func Down20200804120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `scheduled_queries` " +
+			"DROP COLUMN `sample_rate`;",
+	)
+	return errors.Wrap(err, "drop sample_rate from scheduled queries")
+}
@@ -0,0 +1,27 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200805120000, Down20200805120000)
+}
+
+func Up20200805120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"ADD COLUMN `disk_encryption` VARCHAR(16) NOT NULL DEFAULT 'unknown';",
+	)
+	return errors.Wrap(err, "add disk_encryption to hosts")
+}
+
+func Down20200805120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `hosts` " +
+			"DROP COLUMN `disk_encryption`;",
+	)
+	return errors.Wrap(err, "drop disk_encryption from hosts")
+}
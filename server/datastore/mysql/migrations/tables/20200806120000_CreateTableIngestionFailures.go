@@ -0,0 +1,30 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200806120000, Down20200806120000)
+}
+
+func Up20200806120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"CREATE TABLE `ingestion_failures` (" +
+			"`id` INT(10) UNSIGNED NOT NULL AUTO_INCREMENT," +
+			"`host_id` INT(10) UNSIGNED NOT NULL," +
+			"`query_name` VARCHAR(255) NOT NULL," +
+			"`error` TEXT NOT NULL," +
+			"`created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+			"PRIMARY KEY (`id`)," +
+			"KEY `idx_ingestion_failures_created_at` (`created_at`)," +
+			"FOREIGN KEY (`host_id`) REFERENCES `hosts`(`id`) ON DELETE CASCADE" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+	)
+	return err
+}
+
+func Down20200806120000(tx *sql.Tx) error {
+	_, err := tx.Exec("DROP TABLE IF EXISTS `ingestion_failures`;")
+	return err
+}
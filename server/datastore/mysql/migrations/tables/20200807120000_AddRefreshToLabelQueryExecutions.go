@@ -0,0 +1,25 @@
+package tables
+
+import (
+	"database/sql"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200807120000, Down20200807120000)
+}
+
+func Up20200807120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `label_query_executions` " +
+			"ADD COLUMN `refresh` TINYINT(1) NOT NULL DEFAULT FALSE;",
+	)
+	return err
+}
+
+func Down20200807120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `label_query_executions` " +
+			"DROP COLUMN `refresh`;",
+	)
+	return err
+}
@@ -0,0 +1,27 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200808120000, Down20200808120000)
+}
+
+func Up20200808120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `scheduled_queries` " +
+			"ADD COLUMN `column_mapping` JSON DEFAULT NULL;",
+	)
+	return errors.Wrap(err, "add column_mapping to scheduled queries")
+}
+
+func Down20200808120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `scheduled_queries` " +
+			"DROP COLUMN `column_mapping`;",
+	)
+	return errors.Wrap(err, "drop column_mapping from scheduled queries")
+}
@@ -0,0 +1,27 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200809120000, Down20200809120000)
+}
+
+func Up20200809120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `app_configs` " +
+			"ADD COLUMN `query_deny_list` JSON DEFAULT NULL;",
+	)
+	return errors.Wrap(err, "add query_deny_list column")
+}
+
+func Down20200809120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `app_configs` " +
+			"DROP COLUMN `query_deny_list`;",
+	)
+	return errors.Wrap(err, "drop query_deny_list column")
+}
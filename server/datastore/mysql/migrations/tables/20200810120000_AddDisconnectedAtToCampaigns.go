@@ -0,0 +1,27 @@
+package tables
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MigrationClient.AddMigration(Up20200810120000, Down20200810120000)
+}
+
+func Up20200810120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `distributed_query_campaigns` " +
+			"ADD COLUMN `disconnected_at` TIMESTAMP NULL DEFAULT NULL;",
+	)
+	return errors.Wrap(err, "add disconnected_at column")
+}
+
+func Down20200810120000(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"ALTER TABLE `distributed_query_campaigns` " +
+			"DROP COLUMN `disconnected_at`;",
+	)
+	return errors.Wrap(err, "drop disconnected_at column")
+}
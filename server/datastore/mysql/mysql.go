@@ -8,6 +8,8 @@ import (
 	"io/ioutil"
 	"net/url"
 	"regexp"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/WatchBeam/clock"
@@ -33,10 +35,26 @@ var (
 // Datastore is an implementation of kolide.Datastore interface backed by
 // MySQL
 type Datastore struct {
-	db     *sqlx.DB
-	logger log.Logger
-	clock  clock.Clock
-	config config.MysqlConfig
+	db *sqlx.DB
+	// replicas are the connection pools of the read replicas, used by
+	// reader. There are none if no replica is configured.
+	replicas    []*sqlx.DB
+	nextReplica uint32
+	logger      log.Logger
+	clock       clock.Clock
+	config      config.MysqlConfig
+}
+
+// reader returns the connection pool for read-only queries that tolerate
+// replication lag, such as host and query listings. Reads are spread across
+// the replicas in turn, and go to the primary if no replica is configured.
+// Queries that must observe preceding writes use d.db instead.
+func (d *Datastore) reader() *sqlx.DB {
+	if len(d.replicas) == 0 {
+		return d.db
+	}
+	n := atomic.AddUint32(&d.nextReplica, 1)
+	return d.replicas[n%uint32(len(d.replicas))]
 }
 
 type dbfunctions interface {
@@ -109,14 +127,50 @@ func New(config config.MysqlConfig, c clock.Clock, opts ...DBOption) (*Datastore
 		}
 	}
 
-	dsn := generateMysqlConnectionString(config)
+	db, err := openDB(config, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var replicas []*sqlx.DB
+	for _, address := range replicaAddresses(config) {
+		replicaConfig := config
+		replicaConfig.Address = address
+		replica, err := openDB(replicaConfig, options)
+		if err != nil {
+			db.Close()
+			for _, r := range replicas {
+				r.Close()
+			}
+			return nil, errors.Wrapf(err, "connect to replica %s", address)
+		}
+		replicas = append(replicas, replica)
+	}
+
+	ds := &Datastore{
+		db:       db,
+		replicas: replicas,
+		logger:   options.logger,
+		clock:    c,
+		config:   config,
+	}
+
+	return ds, nil
+
+}
+
+// openDB opens a connection pool to the MySQL server at conf.Address,
+// retrying until the server is reachable or the attempts are exhausted.
+func openDB(conf config.MysqlConfig, options *dbOptions) (*sqlx.DB, error) {
+	dsn := generateMysqlConnectionString(conf)
 	db, err := sqlx.Open("mysql", dsn)
 	if err != nil {
 		return nil, err
 	}
 
-	db.SetMaxIdleConns(config.MaxIdleConns)
-	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(conf.MaxIdleConns)
+	db.SetMaxOpenConns(conf.MaxOpenConns)
+	db.SetConnMaxLifetime(conf.ConnMaxLifetime)
 
 	var dbError error
 	for attempt := 0; attempt < options.maxAttempts; attempt++ {
@@ -127,23 +181,28 @@ func New(config config.MysqlConfig, c clock.Clock, opts ...DBOption) (*Datastore
 		}
 		interval := time.Duration(attempt) * time.Second
 		options.logger.Log("mysql", fmt.Sprintf(
-			"could not connect to db: %v, sleeping %v", dbError, interval))
+			"could not connect to db %s: %v, sleeping %v", conf.Address, dbError, interval))
 		time.Sleep(interval)
 	}
 
 	if dbError != nil {
+		db.Close()
 		return nil, dbError
 	}
 
-	ds := &Datastore{
-		db:     db,
-		logger: options.logger,
-		clock:  c,
-		config: config,
-	}
-
-	return ds, nil
+	return db, nil
+}
 
+// replicaAddresses returns the addresses in the comma-separated list of read
+// replicas.
+func replicaAddresses(conf config.MysqlConfig) []string {
+	var addresses []string
+	for _, address := range strings.Split(conf.ReplicaAddresses, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
 }
 
 func (d *Datastore) Begin() (kolide.Transaction, error) {
@@ -239,15 +298,35 @@ func (d *Datastore) Drop() error {
 	return tx.Commit()
 }
 
-// HealthCheck returns an error if the MySQL backend is not healthy.
+// HealthCheck returns an error if the MySQL backend is not healthy. Each of
+// the read replicas must also be healthy.
 func (d *Datastore) HealthCheck() error {
-	_, err := d.db.Exec("select 1")
-	return err
+	if _, err := d.db.Exec("select 1"); err != nil {
+		return err
+	}
+	for i, replica := range d.replicas {
+		if _, err := replica.Exec("select 1"); err != nil {
+			return errors.Wrapf(err, "replica %d", i)
+		}
+	}
+	return nil
+}
+
+// OpenConnections returns the number of connections to the primary that are
+// open, both in use and idle.
+func (d *Datastore) OpenConnections() int {
+	return d.db.Stats().OpenConnections
 }
 
 // Close frees resources associated with underlying mysql connection
 func (d *Datastore) Close() error {
-	return d.db.Close()
+	err := d.db.Close()
+	for _, replica := range d.replicas {
+		if rErr := replica.Close(); rErr != nil && err == nil {
+			err = rErr
+		}
+	}
+	return err
 }
 
 func (d *Datastore) log(msg string) {
@@ -258,6 +337,14 @@ func sanitizeColumn(col string) string {
 	return columnCharsRegexp.ReplaceAllString(col, "")
 }
 
+// escapeLike escapes the LIKE wildcard characters in s so that it matches
+// literally within a LIKE pattern.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 func appendListOptionsToSQL(sql string, opts kolide.ListOptions) string {
 	if opts.OrderKey != "" {
 		direction := "ASC"
@@ -1,9 +1,14 @@
 package mysql
 
 import (
+	"database/sql"
 	"testing"
 
+	"github.com/jmoiron/sqlx"
+	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/kolide"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSanitizeColumn(t *testing.T) {
@@ -24,3 +29,52 @@ func TestSanitizeColumn(t *testing.T) {
 		})
 	}
 }
+
+func TestHostCursorSQL(t *testing.T) {
+	empty := ""
+	sql, params, err := hostCursorSQL(kolide.HostListOptions{After: &empty})
+	require.Nil(t, err)
+	assert.Equal(t, " ORDER BY id ASC LIMIT 100000", sql)
+	assert.Empty(t, params)
+
+	cursor, err := kolide.NewHostCursor(&kolide.Host{ID: 7, HostName: "foo"}, "host_name")
+	require.Nil(t, err)
+	sql, params, err = hostCursorSQL(kolide.HostListOptions{
+		ListOptions: kolide.ListOptions{
+			PerPage:        10,
+			OrderKey:       "host_name",
+			OrderDirection: kolide.OrderDescending,
+		},
+		After: &cursor,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, " AND (host_name < ? OR (host_name = ? AND id < ?)) ORDER BY host_name DESC, id DESC LIMIT 10", sql)
+	assert.Equal(t, []interface{}{"foo", "foo", uint(7)}, params)
+
+	_, _, err = hostCursorSQL(kolide.HostListOptions{After: &cursor})
+	assert.NotNil(t, err)
+}
+
+func TestReplicaAddresses(t *testing.T) {
+	assert.Empty(t, replicaAddresses(config.MysqlConfig{}))
+	assert.Equal(t,
+		[]string{"replica1:3306", "replica2:3306"},
+		replicaAddresses(config.MysqlConfig{ReplicaAddresses: " replica1:3306,,replica2:3306 "}),
+	)
+}
+
+func TestReader(t *testing.T) {
+	primary := sqlx.NewDb(&sql.DB{}, "mysql")
+	ds := &Datastore{db: primary}
+	// Reads go to the primary without replicas
+	assert.Same(t, primary, ds.reader())
+
+	r1 := sqlx.NewDb(&sql.DB{}, "mysql")
+	r2 := sqlx.NewDb(&sql.DB{}, "mysql")
+	ds.replicas = []*sqlx.DB{r1, r2}
+	seen := map[*sqlx.DB]int{}
+	for i := 0; i < 4; i++ {
+		seen[ds.reader()]++
+	}
+	assert.Equal(t, map[*sqlx.DB]int{r1: 2, r2: 2}, seen)
+}
@@ -64,6 +64,26 @@ func (d *Datastore) ApplyOptions(spec *kolide.OptionsSpec) (err error) {
 
 	}
 
+	// Label overrides
+	for label, opts := range spec.Overrides.Labels {
+		_, err = tx.Exec(sql, kolide.OptionOverrideTypeLabel, label, string(opts))
+		if err != nil {
+			return errors.Wrapf(err, "saving %s label options", label)
+		}
+	}
+
+	// Interval overrides
+	for platform, intervals := range spec.Overrides.Intervals {
+		opts, err := json.Marshal(intervals)
+		if err != nil {
+			return errors.Wrapf(err, "marshal %s platform intervals", platform)
+		}
+		_, err = tx.Exec(sql, kolide.OptionOverrideTypeIntervals, platform, string(opts))
+		if err != nil {
+			return errors.Wrapf(err, "saving %s platform intervals", platform)
+		}
+	}
+
 	// Success!
 	err = tx.Commit()
 	if err != nil {
@@ -82,6 +102,7 @@ func (d *Datastore) GetOptions() (*kolide.OptionsSpec, error) {
 	spec := &kolide.OptionsSpec{
 		Overrides: kolide.OptionsOverrides{
 			Platforms: make(map[string]json.RawMessage),
+			Labels:    make(map[string]json.RawMessage),
 		},
 	}
 	for _, row := range rows {
@@ -92,6 +113,19 @@ func (d *Datastore) GetOptions() (*kolide.OptionsSpec, error) {
 		case kolide.OptionOverrideTypePlatform:
 			spec.Overrides.Platforms[row.OverrideIdentifier] = json.RawMessage(row.Options)
 
+		case kolide.OptionOverrideTypeLabel:
+			spec.Overrides.Labels[row.OverrideIdentifier] = json.RawMessage(row.Options)
+
+		case kolide.OptionOverrideTypeIntervals:
+			var intervals kolide.OsqueryIntervals
+			if err := json.Unmarshal([]byte(row.Options), &intervals); err != nil {
+				return nil, errors.Wrapf(err, "parsing %s platform intervals", row.OverrideIdentifier)
+			}
+			if spec.Overrides.Intervals == nil {
+				spec.Overrides.Intervals = make(map[string]kolide.OsqueryIntervals)
+			}
+			spec.Overrides.Intervals[row.OverrideIdentifier] = intervals
+
 		default:
 			level.Info(d.logger).Log(
 				"err", "ignoring unkown override type",
@@ -130,3 +164,49 @@ func (d *Datastore) OptionsForPlatform(platform string) (json.RawMessage, error)
 
 	return json.RawMessage(row.Options), nil
 }
+
+func (d *Datastore) LabelOptionsForHost(hostID uint) (map[string]json.RawMessage, error) {
+	// Label overrides reference the label by name, and apply to the hosts
+	// that matched the label query or were added to a manual label.
+	sql := `
+		SELECT o.* FROM osquery_options o
+		JOIN labels l
+			ON l.name = o.override_identifier AND NOT l.deleted
+		WHERE o.override_type = ? AND (
+			l.id IN (SELECT label_id FROM label_query_executions WHERE host_id = ? AND matches)
+			OR l.id IN (SELECT label_id FROM label_membership WHERE host_id = ?)
+		)
+	`
+	var rows []optionsRow
+	if err := d.db.Select(&rows, sql, kolide.OptionOverrideTypeLabel, hostID, hostID); err != nil {
+		return nil, errors.Wrapf(err, "retrieving label osquery options for host %d", hostID)
+	}
+
+	options := make(map[string]json.RawMessage, len(rows))
+	for _, row := range rows {
+		options[row.OverrideIdentifier] = json.RawMessage(row.Options)
+	}
+	return options, nil
+}
+
+func (d *Datastore) IntervalsForPlatform(platform string) (*kolide.OsqueryIntervals, error) {
+	sqlStatement := `
+		SELECT * FROM osquery_options
+		WHERE override_type = ? AND override_identifier = ?
+		LIMIT 1
+	`
+	var row optionsRow
+	err := d.db.Get(&row, sqlStatement, kolide.OptionOverrideTypeIntervals, platform)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, errors.Wrapf(err, "retrieving osquery intervals for platform '%s'", platform)
+	}
+
+	var intervals kolide.OsqueryIntervals
+	if err := json.Unmarshal([]byte(row.Options), &intervals); err != nil {
+		return nil, errors.Wrapf(err, "parsing osquery intervals for platform '%s'", platform)
+	}
+	return &intervals, nil
+}
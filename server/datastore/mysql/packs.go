@@ -29,15 +29,21 @@ func applyPackSpec(tx *sqlx.Tx, spec *kolide.PackSpec) error {
 	}
 	// Insert/update pack
 	query := `
-		INSERT INTO packs (name, description, platform)
-		VALUES (?, ?, ?)
+		INSERT INTO packs (name, description, platform, logger_plugin,
+			window_start_hour, window_end_hour, window_days)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
 		ON DUPLICATE KEY UPDATE
 			name = VALUES(name),
 			description = VALUES(description),
 			platform = VALUES(platform),
+			logger_plugin = VALUES(logger_plugin),
+			window_start_hour = VALUES(window_start_hour),
+			window_end_hour = VALUES(window_end_hour),
+			window_days = VALUES(window_days),
 			deleted = false
 	`
-	if _, err := tx.Exec(query, spec.Name, spec.Description, spec.Platform); err != nil {
+	if _, err := tx.Exec(query, spec.Name, spec.Description, spec.Platform, spec.LoggerPlugin,
+		spec.WindowStartHour, spec.WindowEndHour, spec.WindowDays); err != nil {
 		return errors.Wrap(err, "insert/update pack")
 	}
 
@@ -65,16 +71,19 @@ func applyPackSpec(tx *sqlx.Tx, spec *kolide.PackSpec) error {
 		query = `
 			INSERT INTO scheduled_queries (
 				pack_id, query_name, name, description, ` + "`interval`" + `,
-				snapshot, removed, shard, platform, version
+				snapshot, removed, shard, platform, version, disabled,
+				max_result_rows, sample_rate, column_mapping
 			)
 			VALUES (
 				?, ?, ?, ?, ?,
-				?, ?, ?, ?, ?
+				?, ?, ?, ?, ?, ?,
+				?, ?, ?
 			)
 		`
 		_, err := tx.Exec(query,
 			packID, q.QueryName, q.Name, q.Description, q.Interval,
-			q.Snapshot, q.Removed, q.Shard, q.Platform, q.Version,
+			q.Snapshot, q.Removed, q.Shard, q.Platform, q.Version, q.Disabled,
+			q.MaxResultRows, q.SampleRate, q.ColumnMapping,
 		)
 		switch {
 		case isChildForeignKeyError(err):
@@ -107,7 +116,7 @@ func applyPackSpec(tx *sqlx.Tx, spec *kolide.PackSpec) error {
 func (d *Datastore) GetPackSpecs() (specs []*kolide.PackSpec, err error) {
 	err = d.withRetryTxx(func(tx *sqlx.Tx) error {
 		// Get basic specs
-		query := "SELECT id, name, description, platform FROM packs"
+		query := "SELECT id, name, description, platform, logger_plugin, window_start_hour, window_end_hour, window_days FROM packs"
 		if err := tx.Select(&specs, query); err != nil {
 			return errors.Wrap(err, "get packs")
 		}
@@ -129,7 +138,8 @@ WHERE pack_id = ? AND pt.type = ? AND pt.target_id = l.id
 			query = `
 SELECT
 query_name, name, description, ` + "`interval`" + `,
-snapshot, removed, shard, platform, version
+snapshot, removed, shard, platform, version, disabled, max_result_rows, sample_rate,
+column_mapping
 FROM scheduled_queries
 WHERE pack_id = ?
 `
@@ -152,7 +162,7 @@ func (d *Datastore) GetPackSpec(name string) (spec *kolide.PackSpec, err error)
 	err = d.withRetryTxx(func(tx *sqlx.Tx) error {
 		// Get basic spec
 		var specs []*kolide.PackSpec
-		query := "SELECT id, name, description, platform FROM packs WHERE name = ?"
+		query := "SELECT id, name, description, platform, logger_plugin, window_start_hour, window_end_hour, window_days FROM packs WHERE name = ?"
 		if err := tx.Select(&specs, query, name); err != nil {
 			return errors.Wrap(err, "get packs")
 		}
@@ -179,7 +189,8 @@ WHERE pack_id = ? AND pt.type = ? AND pt.target_id = l.id
 		query = `
 SELECT
 query_name, name, description, ` + "`interval`" + `,
-snapshot, removed, shard, platform, version
+snapshot, removed, shard, platform, version, disabled, max_result_rows, sample_rate,
+column_mapping
 FROM scheduled_queries
 WHERE pack_id = ?
 `
@@ -229,21 +240,24 @@ func (d *Datastore) NewPack(pack *kolide.Pack, opts ...kolide.OptionalArg) (*kol
 	case nil:
 		query = `
 		REPLACE INTO packs
-			( name, description, platform, disabled, deleted)
-			VALUES ( ?, ?, ?, ?, ?)
+			( name, description, platform, disabled, logger_plugin,
+			window_start_hour, window_end_hour, window_days, deleted)
+			VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`
 	case sql.ErrNoRows:
 		query = `
 		INSERT INTO packs
-			( name, description, platform, disabled, deleted)
-			VALUES ( ?, ?, ?, ?, ?)
+			( name, description, platform, disabled, logger_plugin,
+			window_start_hour, window_end_hour, window_days, deleted)
+			VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`
 	default:
 		return nil, errors.Wrap(err, "check for existing pack")
 	}
 
 	deleted := false
-	result, err := db.Exec(query, pack.Name, pack.Description, pack.Platform, pack.Disabled, deleted)
+	result, err := db.Exec(query, pack.Name, pack.Description, pack.Platform, pack.Disabled, pack.LoggerPlugin,
+		pack.WindowStartHour, pack.WindowEndHour, pack.WindowDays, deleted)
 	if err != nil && isDuplicate(err) {
 		return nil, alreadyExists("Pack", deletedPack.ID)
 	} else if err != nil {
@@ -259,11 +273,13 @@ func (d *Datastore) NewPack(pack *kolide.Pack, opts ...kolide.OptionalArg) (*kol
 func (d *Datastore) SavePack(pack *kolide.Pack) error {
 	query := `
 			UPDATE packs
-			SET name = ?, platform = ?, disabled = ?, description = ?
+			SET name = ?, platform = ?, disabled = ?, description = ?, logger_plugin = ?,
+				window_start_hour = ?, window_end_hour = ?, window_days = ?
 			WHERE id = ? AND NOT deleted
 	`
 
-	results, err := d.db.Exec(query, pack.Name, pack.Platform, pack.Disabled, pack.Description, pack.ID)
+	results, err := d.db.Exec(query, pack.Name, pack.Platform, pack.Disabled, pack.Description, pack.LoggerPlugin,
+		pack.WindowStartHour, pack.WindowEndHour, pack.WindowDays, pack.ID)
 	if err != nil {
 		return errors.Wrap(err, "updating pack")
 	}
@@ -402,6 +418,15 @@ func (d *Datastore) ListLabelsForPack(pid uint) ([]*kolide.Label, error) {
 }
 
 func (d *Datastore) ListPacksForHost(hid uint) ([]*kolide.Pack, error) {
+	// Membership in computed labels is not recorded, so the computed labels
+	// matching the host are targeted by ID. -1 keeps the IN clause valid
+	// when there are none.
+	computedIDs, err := d.computedLabelIDsForHost(hid)
+	if err != nil {
+		return nil, err
+	}
+	computedIDs = append(computedIDs, -1)
+
 	query := `
 		SELECT DISTINCT packs.*
 		FROM
@@ -416,15 +441,34 @@ func (d *Datastore) ListPacksForHost(hid uint) ([]*kolide.Pack, error) {
 		)
 		WHERE lqe.host_id = ? AND NOT p.disabled)
 		UNION ALL
+		(SELECT p.* FROM packs p
+		JOIN pack_targets pt
+		JOIN label_membership lm
+		ON (
+		  p.id = pt.pack_id
+		  AND pt.target_id = lm.label_id
+		  AND pt.type = ?
+		)
+		WHERE lm.host_id = ? AND NOT p.disabled)
+		UNION ALL
+		(SELECT p.* FROM packs p
+		JOIN pack_targets pt
+		ON (p.id = pt.pack_id AND pt.type = ? AND pt.target_id IN (?))
+		WHERE NOT p.disabled)
+		UNION ALL
 		(SELECT p.*
 		FROM packs p
 		JOIN pack_targets pt
 		ON (p.id = pt.pack_id AND pt.type = ? AND pt.target_id = ?))
 		) packs
 	`
+	query, args, err := sqlx.In(query, kolide.TargetLabel, hid, kolide.TargetLabel, hid, kolide.TargetLabel, computedIDs, kolide.TargetHost, hid)
+	if err != nil {
+		return nil, errors.Wrap(err, "building query listing packs for host")
+	}
 
 	packs := []*kolide.Pack{}
-	if err := d.db.Select(&packs, query, kolide.TargetLabel, hid, kolide.TargetHost, hid); err != nil && err != sql.ErrNoRows {
+	if err := d.db.Select(&packs, query, args...); err != nil && err != sql.ErrNoRows {
 		return nil, errors.Wrap(err, "listing hosts in pack")
 	}
 	return packs, nil
@@ -473,3 +517,47 @@ func (d *Datastore) ListExplicitHostsInPack(pid uint, opt kolide.ListOptions) ([
 	return hosts, nil
 
 }
+
+func (d *Datastore) ListHostsMissingPack(pid uint, opt kolide.HostListOptions) ([]kolide.Host, error) {
+	// The targeted hosts are selected as in ListPacksForHost. A host
+	// reports stats for every query in its osquery schedule, so a host with
+	// no stats for the pack's queries has not applied a config including it.
+	sqlStatement := `
+		SELECT * FROM hosts
+		WHERE NOT deleted
+		AND id IN (
+			SELECT lqe.host_id
+			FROM packs p
+			JOIN pack_targets pt
+			JOIN label_query_executions lqe
+			ON (
+			  p.id = pt.pack_id
+			  AND pt.target_id = lqe.label_id
+			  AND pt.type = ?
+			  AND lqe.matches
+			)
+			WHERE p.id = ? AND NOT p.disabled
+			UNION
+			SELECT pt.target_id
+			FROM pack_targets pt
+			WHERE pt.pack_id = ? AND pt.type = ?
+		)
+		AND NOT EXISTS (
+			SELECT 1
+			FROM scheduled_query_stats sqs
+			JOIN scheduled_queries sq
+			ON sqs.scheduled_query_id = sq.id
+			WHERE sqs.host_id = hosts.id AND sq.pack_id = ?
+		)
+	`
+	params := []interface{}{kolide.TargetLabel, pid, pid, kolide.TargetHost, pid}
+	filterSQL, filterParams := d.hostListFilterSQL(opt)
+	sqlStatement = appendListOptionsToSQL(sqlStatement+filterSQL, opt.ListOptions)
+	params = append(params, filterParams...)
+
+	hosts := []kolide.Host{}
+	if err := d.reader().Select(&hosts, sqlStatement, params...); err != nil {
+		return nil, errors.Wrap(err, "listing hosts missing pack")
+	}
+	return hosts, nil
+}
@@ -3,6 +3,8 @@ package mysql
 import (
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/kolide/fleet/server/kolide"
@@ -43,7 +45,8 @@ func (d *Datastore) ApplyQueries(authorID uint, queries []*kolide.Query) (err er
 			query = VALUES(query),
 			author_id = VALUES(author_id),
 			saved = VALUES(saved),
-			deleted = VALUES(deleted)
+			deleted = VALUES(deleted),
+			deleted_at = NULL
 	`
 	stmt, err := tx.Prepare(sql)
 	if err != nil {
@@ -80,9 +83,12 @@ func (d *Datastore) QueryByName(name string, opts ...kolide.OptionalArg) (*kolid
 		return nil, errors.Wrap(err, "selecting query by name")
 	}
 
-	if err := d.loadPacksForQueries([]*kolide.Query{&query}); err != nil {
+	if err := d.loadPacksForQueries(d.db, []*kolide.Query{&query}); err != nil {
 		return nil, errors.Wrap(err, "loading packs for query")
 	}
+	if err := d.loadTagsForQueries(d.db, []*kolide.Query{&query}); err != nil {
+		return nil, errors.Wrap(err, "loading tags for query")
+	}
 
 	return &query, nil
 }
@@ -106,8 +112,9 @@ func (d *Datastore) NewQuery(query *kolide.Query, opts ...kolide.OptionalArg) (*
 				query,
 				saved,
 				author_id,
-				deleted
-			) VALUES ( ?, ?, ?, ?, ?, ? )
+				deleted,
+				cache_ttl
+			) VALUES ( ?, ?, ?, ?, ?, ?, ? )
 		`
 	case sql.ErrNoRows:
 		sqlStatement = `
@@ -117,14 +124,15 @@ func (d *Datastore) NewQuery(query *kolide.Query, opts ...kolide.OptionalArg) (*
 				query,
 				saved,
 				author_id,
-				deleted
-			) VALUES ( ?, ?, ?, ?, ?, ? )
+				deleted,
+				cache_ttl
+			) VALUES ( ?, ?, ?, ?, ?, ?, ? )
 		`
 	default:
 		return nil, errors.Wrap(err, "check for existing Query")
 	}
 	deleted := false
-	result, err := db.Exec(sqlStatement, query.Name, query.Description, query.Query, query.Saved, query.AuthorID, deleted)
+	result, err := db.Exec(sqlStatement, query.Name, query.Description, query.Query, query.Saved, query.AuthorID, deleted, query.CacheTTL)
 	if err != nil && isDuplicate(err) {
 		return nil, alreadyExists("Query", deletedQuery.ID)
 	} else if err != nil {
@@ -134,6 +142,12 @@ func (d *Datastore) NewQuery(query *kolide.Query, opts ...kolide.OptionalArg) (*
 	id, _ := result.LastInsertId()
 	query.ID = uint(id)
 	query.Packs = []kolide.Pack{}
+	if err := saveQueryTags(db, query.ID, query.Tags); err != nil {
+		return nil, err
+	}
+	if query.Tags == nil {
+		query.Tags = []string{}
+	}
 	return query, nil
 }
 
@@ -141,10 +155,10 @@ func (d *Datastore) NewQuery(query *kolide.Query, opts ...kolide.OptionalArg) (*
 func (d *Datastore) SaveQuery(q *kolide.Query) error {
 	sql := `
 		UPDATE queries
-			SET name = ?, description = ?, query = ?, author_id = ?, saved = ?
+			SET name = ?, description = ?, query = ?, author_id = ?, saved = ?, cache_ttl = ?
 			WHERE id = ? AND NOT deleted
 	`
-	result, err := d.db.Exec(sql, q.Name, q.Description, q.Query, q.AuthorID, q.Saved, q.ID)
+	result, err := d.db.Exec(sql, q.Name, q.Description, q.Query, q.AuthorID, q.Saved, q.CacheTTL, q.ID)
 	if err != nil {
 		return errors.Wrap(err, "updating query")
 	}
@@ -156,12 +170,108 @@ func (d *Datastore) SaveQuery(q *kolide.Query) error {
 		return notFound("Query").WithID(q.ID)
 	}
 
+	return saveQueryTags(d.db, q.ID, q.Tags)
+}
+
+// saveQueryTags replaces the tags of the query with the provided tags.
+func saveQueryTags(db dbfunctions, queryID uint, tags []string) error {
+	if _, err := db.Exec("DELETE FROM query_tags WHERE query_id = ?", queryID); err != nil {
+		return errors.Wrap(err, "deleting query tags")
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	sql := "INSERT INTO query_tags (query_id, tag) VALUES " +
+		strings.TrimSuffix(strings.Repeat("(?, ?),", len(tags)), ",")
+	args := make([]interface{}, 0, 2*len(tags))
+	for _, tag := range tags {
+		args = append(args, queryID, tag)
+	}
+	if _, err := db.Exec(sql, args...); err != nil {
+		return errors.Wrap(err, "inserting query tags")
+	}
 	return nil
 }
 
-// DeleteQuery soft deletes Query identified by Query.ID
+// DeleteQuery soft deletes Query identified by Query.Name
 func (d *Datastore) DeleteQuery(name string) error {
-	return d.deleteEntityByName("queries", name)
+	sql := `
+		UPDATE queries
+			SET deleted_at = ?, deleted = true
+			WHERE name = ? AND NOT deleted
+	`
+	result, err := d.db.Exec(sql, d.clock.Now(), name)
+	if err != nil {
+		return errors.Wrap(err, "soft deleting query")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected soft deleting query")
+	}
+	if rows == 0 {
+		return notFound("Query").WithName(name)
+	}
+
+	return nil
+}
+
+// RestoreQuery restores the soft deleted Query identified by id
+func (d *Datastore) RestoreQuery(id uint) error {
+	sql := `
+		UPDATE queries
+			SET deleted_at = NULL, deleted = false
+			WHERE id = ? AND deleted
+	`
+	result, err := d.db.Exec(sql, id)
+	if err != nil {
+		return errors.Wrap(err, "restoring query")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected restoring query")
+	}
+	if rows == 0 {
+		return notFound("Query").WithID(id)
+	}
+
+	return nil
+}
+
+// PurgeDeletedQueries permanently deletes queries that were soft deleted
+// before the provided time, along with the pack schedules that reference
+// them. Soft deleted queries are no longer sent to hosts, so removing their
+// schedules does not change the config of any host.
+func (d *Datastore) PurgeDeletedQueries(before time.Time) (uint, error) {
+	var purged int64
+	err := d.withRetryTxx(func(tx *sqlx.Tx) error {
+		// The schedules are deleted first, as they reference the
+		// queries by name.
+		sql := `
+			DELETE sq FROM scheduled_queries sq
+			JOIN queries q ON (sq.query_name = q.name)
+			WHERE q.deleted AND q.deleted_at < ?
+		`
+		if _, err := tx.Exec(sql, before); err != nil {
+			return errors.Wrap(err, "deleting schedules of purged queries")
+		}
+
+		sql = `
+			DELETE FROM queries
+				WHERE deleted AND deleted_at < ?
+		`
+		result, err := tx.Exec(sql, before)
+		if err != nil {
+			return errors.Wrap(err, "purging deleted queries")
+		}
+		purged, err = result.RowsAffected()
+		return errors.Wrap(err, "rows affected purging deleted queries")
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return uint(purged), nil
 }
 
 // DeleteQueries (soft) deletes the existing query objects with the provided
@@ -206,40 +316,129 @@ func (d *Datastore) Query(id uint) (*kolide.Query, error) {
 		return nil, errors.Wrap(err, "selecting query")
 	}
 
-	if err := d.loadPacksForQueries([]*kolide.Query{query}); err != nil {
+	if err := d.loadPacksForQueries(d.db, []*kolide.Query{query}); err != nil {
 		return nil, errors.Wrap(err, "loading packs for queries")
 	}
+	if err := d.loadTagsForQueries(d.db, []*kolide.Query{query}); err != nil {
+		return nil, errors.Wrap(err, "loading tags for queries")
+	}
 
 	return query, nil
 }
 
 // ListQueries returns a list of queries with sort order and results limit
-// determined by passed in kolide.ListOptions
-func (d *Datastore) ListQueries(opt kolide.ListOptions) ([]*kolide.Query, error) {
+// determined by passed in kolide.ListQueryOptions
+func (d *Datastore) ListQueries(opt kolide.ListQueryOptions) ([]*kolide.Query, error) {
 	sql := `
 		SELECT q.*, COALESCE(NULLIF(u.name, ''), u.username, '') AS author_name
 		FROM queries q
 		LEFT JOIN users u
 			ON q.author_id = u.id
 		WHERE saved = true
-		AND NOT q.deleted
 	`
-	sql = appendListOptionsToSQL(sql, opt)
+	args := []interface{}{}
+	if !opt.IncludeDeleted {
+		sql += " AND NOT q.deleted"
+	}
+	if opt.AuthorID != nil {
+		sql += " AND q.author_id = ?"
+		args = append(args, *opt.AuthorID)
+	}
+	if opt.MatchQuery != "" {
+		// The leading wildcard prevents the use of an index on name, but
+		// the case-insensitive collation of both columns means that no
+		// case conversion is needed.
+		sql += " AND (q.name LIKE ? OR q.query LIKE ?)"
+		pattern := "%" + escapeLike(opt.MatchQuery) + "%"
+		args = append(args, pattern, pattern)
+	}
+	if len(opt.Tags) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(opt.Tags)), ",")
+		sql += fmt.Sprintf(" AND q.id IN (SELECT query_id FROM query_tags WHERE tag IN (%s))", placeholders)
+		for _, tag := range opt.Tags {
+			args = append(args, tag)
+		}
+	}
+	sql = appendListOptionsToSQL(sql, opt.ListOptions)
+	db := d.reader()
 	results := []*kolide.Query{}
 
-	if err := d.db.Select(&results, sql); err != nil {
+	if err := db.Select(&results, sql, args...); err != nil {
 		return nil, errors.Wrap(err, "listing queries")
 	}
 
-	if err := d.loadPacksForQueries(results); err != nil {
+	if err := d.loadPacksForQueries(db, results); err != nil {
 		return nil, errors.Wrap(err, "loading packs for queries")
 	}
+	if err := d.loadTagsForQueries(db, results); err != nil {
+		return nil, errors.Wrap(err, "loading tags for queries")
+	}
 
 	return results, nil
 }
 
+// ListQueryTags returns the distinct tags of saved queries that are not
+// deleted, with the number of queries that have each tag.
+func (d *Datastore) ListQueryTags() ([]kolide.QueryTag, error) {
+	sql := `
+		SELECT qt.tag, COUNT(*) AS count
+		FROM query_tags qt
+		JOIN queries q
+			ON q.id = qt.query_id
+		WHERE q.saved AND NOT q.deleted
+		GROUP BY qt.tag
+		ORDER BY count DESC, qt.tag
+	`
+	tags := []kolide.QueryTag{}
+	if err := d.reader().Select(&tags, sql); err != nil {
+		return nil, errors.Wrap(err, "listing query tags")
+	}
+	return tags, nil
+}
+
+// loadTagsForQueries loads the tags of the provided queries
+func (d *Datastore) loadTagsForQueries(db *sqlx.DB, queries []*kolide.Query) error {
+	if len(queries) == 0 {
+		return nil
+	}
+
+	idQueries := map[uint]*kolide.Query{}
+	ids := []uint{}
+	for _, q := range queries {
+		q.Tags = []string{}
+		ids = append(ids, q.ID)
+		idQueries[q.ID] = q
+	}
+
+	sql := `
+		SELECT query_id, tag
+		FROM query_tags
+		WHERE query_id IN (?)
+		ORDER BY tag
+	`
+	query, args, err := sqlx.In(sql, ids)
+	if err != nil {
+		return errors.Wrap(err, "building query in load tags for queries")
+	}
+
+	rows := []struct {
+		QueryID uint   `db:"query_id"`
+		Tag     string `db:"tag"`
+	}{}
+	if err := db.Select(&rows, query, args...); err != nil {
+		return errors.Wrap(err, "selecting load tags for queries")
+	}
+
+	for _, row := range rows {
+		q := idQueries[row.QueryID]
+		q.Tags = append(q.Tags, row.Tag)
+	}
+
+	return nil
+}
+
 // loadPacksForQueries loads the packs associated with the provided queries
-func (d *Datastore) loadPacksForQueries(queries []*kolide.Query) error {
+func (d *Datastore) loadPacksForQueries(db *sqlx.DB, queries []*kolide.Query) error {
 	if len(queries) == 0 {
 		return nil
 	}
@@ -273,7 +472,7 @@ func (d *Datastore) loadPacksForQueries(queries []*kolide.Query) error {
 		kolide.Pack
 	}{}
 
-	err = d.db.Select(&rows, query, args...)
+	err = db.Select(&rows, query, args...)
 	if err != nil {
 		return errors.Wrap(err, "selecting load packs for queries")
 	}
@@ -2,7 +2,9 @@ package mysql
 
 import (
 	"database/sql"
+	"strings"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/pkg/errors"
 )
@@ -21,6 +23,10 @@ func (d *Datastore) ListScheduledQueriesInPack(id uint, opts kolide.ListOptions)
 			sq.platform,
 			sq.version,
 			sq.shard,
+			sq.disabled,
+			sq.max_result_rows,
+			sq.sample_rate,
+			sq.column_mapping,
 			q.query,
 			q.id AS query_id
 		FROM scheduled_queries sq
@@ -28,6 +34,7 @@ func (d *Datastore) ListScheduledQueriesInPack(id uint, opts kolide.ListOptions)
 		ON sq.query_name = q.name
 		WHERE sq.pack_id = ?
 		AND NOT sq.deleted
+		AND NOT q.deleted
 	`
 	query = appendListOptionsToSQL(query, opts)
 	results := []*kolide.ScheduledQuery{}
@@ -54,13 +61,17 @@ func (d *Datastore) NewScheduledQuery(sq *kolide.ScheduledQuery, opts ...kolide.
 			` + "`interval`" + `,
 			platform,
 			version,
-			shard
+			shard,
+			disabled,
+			max_result_rows,
+			sample_rate,
+			column_mapping
 		)
-		SELECT name, ?, ?, ?, ?, ?, ?, ?, ?
+		SELECT name, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
 		FROM queries
 		WHERE id = ?
 		`
-	result, err := db.Exec(query, sq.Name, sq.PackID, sq.Snapshot, sq.Removed, sq.Interval, sq.Platform, sq.Version, sq.Shard, sq.QueryID)
+	result, err := db.Exec(query, sq.Name, sq.PackID, sq.Snapshot, sq.Removed, sq.Interval, sq.Platform, sq.Version, sq.Shard, sq.Disabled, sq.MaxResultRows, sq.SampleRate, sq.ColumnMapping, sq.QueryID)
 	if err != nil {
 		return nil, errors.Wrap(err, "inserting scheduled query")
 	}
@@ -94,10 +105,10 @@ func (d *Datastore) NewScheduledQuery(sq *kolide.ScheduledQuery, opts ...kolide.
 func (d *Datastore) SaveScheduledQuery(sq *kolide.ScheduledQuery) (*kolide.ScheduledQuery, error) {
 	query := `
 		UPDATE scheduled_queries
-			SET pack_id = ?, query_id = ?, ` + "`interval`" + ` = ?, snapshot = ?, removed = ?, platform = ?, version = ?, shard = ?
+			SET pack_id = ?, query_id = ?, ` + "`interval`" + ` = ?, snapshot = ?, removed = ?, platform = ?, version = ?, shard = ?, disabled = ?, max_result_rows = ?, sample_rate = ?, column_mapping = ?
 			WHERE id = ? AND NOT deleted
 	`
-	result, err := d.db.Exec(query, sq.PackID, sq.QueryID, sq.Interval, sq.Snapshot, sq.Removed, sq.Platform, sq.Version, sq.Shard, sq.ID)
+	result, err := d.db.Exec(query, sq.PackID, sq.QueryID, sq.Interval, sq.Snapshot, sq.Removed, sq.Platform, sq.Version, sq.Shard, sq.Disabled, sq.MaxResultRows, sq.SampleRate, sq.ColumnMapping, sq.ID)
 	if err != nil {
 		return nil, errors.Wrap(err, "saving a scheduled query")
 	}
@@ -111,6 +122,50 @@ func (d *Datastore) SaveScheduledQuery(sq *kolide.ScheduledQuery) (*kolide.Sched
 	return sq, nil
 }
 
+func (d *Datastore) ModifyScheduledQueriesInPack(packID uint, patch kolide.ScheduledQueryPatch) (int, error) {
+	var (
+		sets []string
+		args []interface{}
+	)
+	if patch.Interval != nil {
+		sets = append(sets, "`interval` = ?")
+		args = append(args, *patch.Interval)
+	}
+	if patch.IntervalMultiplier != nil {
+		sets = append(sets, "`interval` = `interval` * ?")
+		args = append(args, *patch.IntervalMultiplier)
+	}
+	if patch.Snapshot != nil {
+		sets = append(sets, "snapshot = ?")
+		args = append(args, *patch.Snapshot)
+	}
+	if patch.Removed != nil {
+		sets = append(sets, "removed = ?")
+		args = append(args, *patch.Removed)
+	}
+	if len(sets) == 0 {
+		return 0, nil
+	}
+
+	// The connection sets clientFoundRows, so the rows affected include
+	// the scheduled queries that already matched the patch
+	query := `
+		UPDATE scheduled_queries
+			SET ` + strings.Join(sets, ", ") + `
+			WHERE pack_id = ? AND NOT deleted
+	`
+	args = append(args, packID)
+	result, err := d.db.Exec(query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "modifying scheduled queries in pack")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "rows affected modifying scheduled queries in pack")
+	}
+	return int(rows), nil
+}
+
 func (d *Datastore) DeleteScheduledQuery(id uint) error {
 	return d.deleteEntity("scheduled_queries", id)
 }
@@ -128,6 +183,10 @@ func (d *Datastore) ScheduledQuery(id uint) (*kolide.ScheduledQuery, error) {
 			sq.platform,
 			sq.version,
 			sq.shard,
+			sq.disabled,
+			sq.max_result_rows,
+			sq.sample_rate,
+			sq.column_mapping,
 			sq.query_name,
 			sq.description,
 			q.query,
@@ -146,3 +205,85 @@ func (d *Datastore) ScheduledQuery(id uint) (*kolide.ScheduledQuery, error) {
 
 	return sq, nil
 }
+
+func (d *Datastore) SaveScheduledQueryStats(hostID uint, stats []kolide.ScheduledQueryStats) error {
+	err := d.withRetryTxx(func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec("DELETE FROM scheduled_query_stats WHERE host_id = ?", hostID); err != nil {
+			return errors.Wrap(err, "deleting previous scheduled query stats")
+		}
+		if len(stats) == 0 {
+			return nil
+		}
+
+		sqlStatement := `
+			INSERT INTO scheduled_query_stats (
+				host_id,
+				scheduled_query_id,
+				last_executed,
+				executions,
+				wall_time,
+				output_size
+			) VALUES
+		`
+		vals := []interface{}{}
+		bindvars := ""
+		for _, s := range stats {
+			if bindvars != "" {
+				bindvars += ","
+			}
+			bindvars += "(?,?,?,?,?,?)"
+			vals = append(vals, hostID, s.ScheduledQueryID, s.LastExecuted, s.Executions, s.WallTime, s.OutputSize)
+		}
+		if _, err := tx.Exec(sqlStatement+bindvars, vals...); err != nil {
+			return errors.Wrap(err, "inserting scheduled query stats")
+		}
+		return nil
+	})
+	return errors.Wrapf(err, "saving scheduled query stats for host %d", hostID)
+}
+
+func (d *Datastore) ScheduledQueryStats(packID uint) ([]*kolide.AggregatedScheduledQueryStats, error) {
+	// The most expensive queries are listed first. Queries that no host
+	// has reported stats for are included with empty stats.
+	query := `
+		SELECT
+			sq.id AS scheduled_query_id,
+			sq.name AS scheduled_query_name,
+			COUNT(s.host_id) AS hosts,
+			COALESCE(SUM(s.executions), 0) AS executions,
+			COALESCE(SUM(s.wall_time) / NULLIF(SUM(s.executions), 0), 0) AS average_wall_time,
+			COALESCE(SUM(s.output_size) / NULLIF(SUM(s.executions), 0), 0) AS average_output_size,
+			MAX(s.last_executed) AS last_executed
+		FROM scheduled_queries sq
+		LEFT JOIN (
+			SELECT sqs.*
+			FROM scheduled_query_stats sqs
+			JOIN hosts h ON h.id = sqs.host_id
+			WHERE NOT h.deleted
+		) s
+		ON s.scheduled_query_id = sq.id
+		WHERE sq.pack_id = ?
+		AND NOT sq.deleted
+		GROUP BY sq.id, sq.name
+		ORDER BY average_wall_time DESC, sq.id ASC
+	`
+	results := []*kolide.AggregatedScheduledQueryStats{}
+	if err := d.db.Select(&results, query, packID); err != nil {
+		return nil, errors.Wrap(err, "selecting scheduled query stats")
+	}
+	return results, nil
+}
+
+func (d *Datastore) ListScheduledQueryStatsForHost(hostID uint) ([]kolide.ScheduledQueryStats, error) {
+	query := `
+		SELECT host_id, scheduled_query_id, last_executed, executions, wall_time, output_size
+		FROM scheduled_query_stats
+		WHERE host_id = ?
+		ORDER BY scheduled_query_id
+	`
+	stats := []kolide.ScheduledQueryStats{}
+	if err := d.db.Select(&stats, query, hostID); err != nil {
+		return nil, errors.Wrapf(err, "selecting scheduled query stats for host %d", hostID)
+	}
+	return stats, nil
+}
@@ -1,6 +1,10 @@
 package mysql
 
 import (
+	"database/sql"
+	"strings"
+	"time"
+
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/pkg/errors"
 )
@@ -12,7 +16,9 @@ func (d *Datastore) SessionByKey(key string) (*kolide.Session, error) {
 	`
 	session := &kolide.Session{}
 	err := d.db.Get(session, sqlStatement, key)
-	if err != nil {
+	if err == sql.ErrNoRows {
+		return nil, notFound("Session")
+	} else if err != nil {
 		return nil, errors.Wrap(err, "selecting sessions")
 	}
 
@@ -27,7 +33,9 @@ func (d *Datastore) SessionByID(id uint) (*kolide.Session, error) {
 	`
 	session := &kolide.Session{}
 	err := d.db.Get(session, sqlStatement, id)
-	if err != nil {
+	if err == sql.ErrNoRows {
+		return nil, notFound("Session").WithID(id)
+	} else if err != nil {
 		return nil, errors.Wrap(err, "selecting session by id")
 	}
 
@@ -110,3 +118,31 @@ func (d *Datastore) MarkSessionAccessed(session *kolide.Session) error {
 	}
 	return nil
 }
+
+func (d *Datastore) DestroyExpiredSessions(createdBefore, accessedBefore time.Time) (uint, error) {
+	var conditions []string
+	var args []interface{}
+	if !createdBefore.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, createdBefore)
+	}
+	if !accessedBefore.IsZero() {
+		conditions = append(conditions, "accessed_at < ?")
+		args = append(args, accessedBefore)
+	}
+	if len(conditions) == 0 {
+		return 0, nil
+	}
+
+	sqlStatement := `
+		DELETE FROM sessions WHERE ` + strings.Join(conditions, " OR ")
+	result, err := d.db.Exec(sqlStatement, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "deleting expired sessions")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "rows affected deleting expired sessions")
+	}
+	return uint(rows), nil
+}
@@ -9,6 +9,35 @@ import (
 	"github.com/pkg/errors"
 )
 
+// hostsInTargetsCondition selects the hosts in the explicit host IDs and the
+// label IDs provided as arguments from targetIDArgs. The label IDs are bound
+// twice, once for query labels and once for manual labels. The condition
+// selecting the members of computed labels, from computedLabelsCondition, is
+// formatted into it and its arguments follow the label IDs.
+const hostsInTargetsCondition = `(id IN (?)
+		OR (id IN (SELECT DISTINCT host_id FROM label_query_executions WHERE label_id IN (?) AND matches = 1))
+		OR (id IN (SELECT host_id FROM label_membership WHERE label_id IN (?)))
+		OR %s)
+		AND NOT deleted`
+
+// targetIDArgs returns the host and label ID arguments for
+// hostsInTargetsCondition.
+func targetIDArgs(hostIDs []uint, labelIDs []uint) ([]int, []int) {
+	// Using -1 in the ID slices for the IN clause allows us to include the
+	// IN clause even if we have no IDs to use. -1 will not match the
+	// auto-increment IDs, and will also allow us to use the same query in
+	// all situations (no need to remove the clause when there are no values)
+	queryHostIDs := []int{-1}
+	for _, id := range hostIDs {
+		queryHostIDs = append(queryHostIDs, int(id))
+	}
+	queryLabelIDs := []int{-1}
+	for _, id := range labelIDs {
+		queryLabelIDs = append(queryLabelIDs, int(id))
+	}
+	return queryHostIDs, queryLabelIDs
+}
+
 func (d *Datastore) CountHostsInTargets(hostIDs []uint, labelIDs []uint, now time.Time) (kolide.TargetMetrics, error) {
 	// The logic in this function should remain synchronized with
 	// host.Status and GenerateHostStatusStatistics
@@ -18,41 +47,68 @@ func (d *Datastore) CountHostsInTargets(hostIDs []uint, labelIDs []uint, now tim
 		return kolide.TargetMetrics{}, nil
 	}
 
+	computedCondition, computedArgs, err := d.computedLabelsCondition(labelIDs, now)
+	if err != nil {
+		return kolide.TargetMetrics{}, errors.Wrap(err, "computed labels CountHostsInTargets")
+	}
+
 	sql := fmt.Sprintf(`
 		SELECT
 			COUNT(*) total,
-			COALESCE(SUM(CASE WHEN DATE_ADD(seen_time, INTERVAL 30 DAY) <= ? THEN 1 ELSE 0 END), 0) mia,
-			COALESCE(SUM(CASE WHEN DATE_ADD(seen_time, INTERVAL LEAST(distributed_interval, config_tls_refresh) + %d SECOND) <= ? AND DATE_ADD(seen_time, INTERVAL 30 DAY) >= ? THEN 1 ELSE 0 END), 0) offline,
+			COALESCE(SUM(CASE WHEN DATE_ADD(seen_time, INTERVAL 30 DAY) <= ? AND NOT COALESCE(maintenance_until > ?, FALSE) THEN 1 ELSE 0 END), 0) mia,
+			COALESCE(SUM(CASE WHEN DATE_ADD(seen_time, INTERVAL LEAST(distributed_interval, config_tls_refresh) + %d SECOND) <= ? AND DATE_ADD(seen_time, INTERVAL 30 DAY) >= ? AND NOT COALESCE(maintenance_until > ?, FALSE) THEN 1 ELSE 0 END), 0) offline,
+			COALESCE(SUM(CASE WHEN DATE_ADD(seen_time, INTERVAL LEAST(distributed_interval, config_tls_refresh) + %d SECOND) <= ? AND maintenance_until > ? THEN 1 ELSE 0 END), 0) maintenance,
 			COALESCE(SUM(CASE WHEN DATE_ADD(seen_time, INTERVAL LEAST(distributed_interval, config_tls_refresh) + %d SECOND) > ? THEN 1 ELSE 0 END), 0) online,
 			COALESCE(SUM(CASE WHEN DATE_ADD(created_at, INTERVAL 1 DAY) >= ? THEN 1 ELSE 0 END), 0) new
 		FROM hosts h
-		WHERE (id IN (?) OR (id IN (SELECT DISTINCT host_id FROM label_query_executions WHERE label_id IN (?) AND matches = 1)))
-		AND NOT deleted
-`, kolide.OnlineIntervalBuffer, kolide.OnlineIntervalBuffer)
+		WHERE %s
+`, kolide.OnlineIntervalBuffer, kolide.OnlineIntervalBuffer, kolide.OnlineIntervalBuffer, fmt.Sprintf(hostsInTargetsCondition, computedCondition))
 
-	// Using -1 in the ID slices for the IN clause allows us to include the
-	// IN clause even if we have no IDs to use. -1 will not match the
-	// auto-increment IDs, and will also allow us to use the same query in
-	// all situations (no need to remove the clause when there are no values)
-	queryLabelIDs := []int{-1}
-	for _, id := range labelIDs {
-		queryLabelIDs = append(queryLabelIDs, int(id))
-	}
-	queryHostIDs := []int{-1}
-	for _, id := range hostIDs {
-		queryHostIDs = append(queryHostIDs, int(id))
-	}
-
-	query, args, err := sqlx.In(sql, now, now, now, now, now, queryHostIDs, queryLabelIDs)
+	queryHostIDs, queryLabelIDs := targetIDArgs(hostIDs, labelIDs)
+	args := []interface{}{now, now, now, now, now, now, now, now, now, queryHostIDs, queryLabelIDs, queryLabelIDs}
+	query, args, err := sqlx.In(sql, append(args, computedArgs...)...)
 	if err != nil {
 		return kolide.TargetMetrics{}, errors.Wrap(err, "sqlx.In CountHostsInTargets")
 	}
 
 	res := kolide.TargetMetrics{}
-	err = d.db.Get(&res, query, args...)
+	err = d.reader().Get(&res, query, args...)
 	if err != nil {
 		return kolide.TargetMetrics{}, errors.Wrap(err, "sqlx.Get CountHostsInTargets")
 	}
 
 	return res, nil
 }
+
+func (d *Datastore) HostIDsInTargets(hostIDs []uint, labelIDs []uint) ([]uint, error) {
+	if len(hostIDs) == 0 && len(labelIDs) == 0 {
+		// No need to query if no targets selected
+		return []uint{}, nil
+	}
+
+	computedCondition, computedArgs, err := d.computedLabelsCondition(labelIDs, d.clock.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "computed labels HostIDsInTargets")
+	}
+
+	sql := fmt.Sprintf(`
+		SELECT id
+		FROM hosts
+		WHERE %s
+		ORDER BY id ASC
+`, fmt.Sprintf(hostsInTargetsCondition, computedCondition))
+
+	queryHostIDs, queryLabelIDs := targetIDArgs(hostIDs, labelIDs)
+	args := []interface{}{queryHostIDs, queryLabelIDs, queryLabelIDs}
+	query, args, err := sqlx.In(sql, append(args, computedArgs...)...)
+	if err != nil {
+		return nil, errors.Wrap(err, "sqlx.In HostIDsInTargets")
+	}
+
+	ids := []uint{}
+	if err := d.db.Select(&ids, query, args...); err != nil {
+		return nil, errors.Wrap(err, "sqlx.Select HostIDsInTargets")
+	}
+
+	return ids, nil
+}
@@ -3,6 +3,7 @@ package mysql
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/pkg/errors"
@@ -119,3 +120,53 @@ func (d *Datastore) SaveUser(user *kolide.User) error {
 
 	return nil
 }
+
+func (d *Datastore) IncrementFailedLogins(userID uint) (uint, error) {
+	// Setting the count through LAST_INSERT_ID makes the new count
+	// available from the result, so that it is read atomically with the
+	// increment
+	sqlStatement := `
+		UPDATE users SET
+			failed_login_count = LAST_INSERT_ID(failed_login_count + 1)
+		WHERE id = ?
+	`
+	result, err := d.db.Exec(sqlStatement, userID)
+	if err != nil {
+		return 0, errors.Wrap(err, "increment failed logins")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "rows affected increment failed logins")
+	}
+	if rows == 0 {
+		return 0, notFound("User").WithID(userID)
+	}
+	count, err := result.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed login count")
+	}
+	return uint(count), nil
+}
+
+func (d *Datastore) LockUser(userID uint, until time.Time) error {
+	sqlStatement := `
+		UPDATE users SET
+			locked_until = ?,
+			failed_login_count = 0
+		WHERE id = ?
+	`
+	if _, err := d.db.Exec(sqlStatement, until, userID); err != nil {
+		return errors.Wrap(err, "lock user")
+	}
+	return nil
+}
+
+func (d *Datastore) ResetFailedLogins(userID uint) error {
+	sqlStatement := `
+		UPDATE users SET failed_login_count = 0 WHERE id = ?
+	`
+	if _, err := d.db.Exec(sqlStatement, userID); err != nil {
+		return errors.Wrap(err, "reset failed logins")
+	}
+	return nil
+}
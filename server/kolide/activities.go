@@ -0,0 +1,46 @@
+package kolide
+
+import (
+	"context"
+)
+
+// ActivityStore stores the audit log of actions performed by Fleet users.
+type ActivityStore interface {
+	// NewActivity records an activity in the audit log.
+	NewActivity(activity *Activity) (*Activity, error)
+	// ListActivities returns the recorded activities. Supply query options
+	// using the opt parameter. See ListOptions.
+	ListActivities(opt ListOptions) ([]*Activity, error)
+}
+
+// ActivityService provides access to the audit log of actions performed by
+// Fleet users.
+type ActivityService interface {
+	// ListActivities returns the recorded activities, most recent first
+	// unless another order is requested in opt. Only admins may list
+	// activities.
+	ListActivities(ctx context.Context, opt ListOptions) ([]*Activity, error)
+	// RecordActivity records an activity performed by the user in the
+	// viewer context. Actions performed without a logged in user (such as
+	// creating a user from an invite) are recorded without an actor.
+	RecordActivity(ctx context.Context, activity *Activity) error
+}
+
+// Activity is a single entry in the audit log.
+type Activity struct {
+	ID uint `json:"id"`
+	CreateTimestamp
+	// ActorID is the ID of the user that performed the action, if the
+	// action was performed by a logged in user.
+	ActorID *uint `json:"actor_id" db:"actor_id"`
+	// ActorUsername is the username of the actor at the time of the action.
+	ActorUsername string `json:"actor_username" db:"actor_username"`
+	// Action identifies the performed action, e.g. "modify_app_config".
+	Action string `json:"action"`
+	// TargetID is the ID of the resource the action was performed on, if
+	// the resource is identified by ID.
+	TargetID *uint `json:"target_id" db:"target_id"`
+	// TargetName is the name of the resource the action was performed on,
+	// if the resource is identified by name.
+	TargetName string `json:"target_name,omitempty" db:"target_name"`
+}
@@ -0,0 +1,120 @@
+package kolide
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// APITokenStore stores the long-lived API tokens used for automation.
+type APITokenStore interface {
+	// NewAPIToken stores a new API token.
+	NewAPIToken(token *APIToken) (*APIToken, error)
+	// APIToken returns the API token with the given ID.
+	APIToken(id uint) (*APIToken, error)
+	// APITokenByKey returns the API token with the given key.
+	APITokenByKey(key string) (*APIToken, error)
+	// ListAPITokensForUser returns the API tokens owned by the user.
+	ListAPITokensForUser(userID uint) ([]*APIToken, error)
+	// DeleteAPIToken deletes the API token with the given ID.
+	DeleteAPIToken(id uint) error
+}
+
+// APITokenService manages API tokens, which authenticate automation with a
+// limited set of scopes instead of the full rights of a user.
+type APITokenService interface {
+	// CreateAPIToken creates an API token owned by the user in the viewer
+	// context, and returns it with the bearer token used to authenticate
+	// with it. The bearer token cannot be retrieved later. Only admins may
+	// create API tokens, and a viewer authenticated by an API token may
+	// only grant a subset of that token's scopes.
+	CreateAPIToken(ctx context.Context, name string, scopes []string) (token *APIToken, bearer string, err error)
+	// ListAPITokens returns the API tokens owned by the user in the viewer
+	// context.
+	ListAPITokens(ctx context.Context) (tokens []*APIToken, err error)
+	// DeleteAPIToken deletes an API token. Users may delete their own
+	// tokens, and admins may delete any token.
+	DeleteAPIToken(ctx context.Context, id uint) (err error)
+	// GetAPITokenByKey returns the API token with the given key. It is
+	// used to authenticate requests that present an API token.
+	GetAPITokenByKey(ctx context.Context, key string) (token *APIToken, err error)
+}
+
+const (
+	ScopeHostsRead    = "hosts:read"
+	ScopeHostsWrite   = "hosts:write"
+	ScopeLabelsRead   = "labels:read"
+	ScopeLabelsWrite  = "labels:write"
+	ScopePacksRead    = "packs:read"
+	ScopePacksWrite   = "packs:write"
+	ScopeQueriesRead  = "queries:read"
+	ScopeQueriesWrite = "queries:write"
+	// ScopeQueriesRun allows running live queries.
+	ScopeQueriesRun = "queries:run"
+)
+
+// APITokenScopes lists every scope that can be granted to an API token.
+var APITokenScopes = []string{
+	ScopeHostsRead,
+	ScopeHostsWrite,
+	ScopeLabelsRead,
+	ScopeLabelsWrite,
+	ScopePacksRead,
+	ScopePacksWrite,
+	ScopeQueriesRead,
+	ScopeQueriesWrite,
+	ScopeQueriesRun,
+}
+
+// IsValidAPITokenScope returns true if scope can be granted to an API token.
+func IsValidAPITokenScope(scope string) bool {
+	for _, s := range APITokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Scopes is a set of API token scopes.
+type Scopes []string
+
+// Value is called by the DB driver. Scopes are stored as JSON.
+func (s Scopes) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan reads the JSON scopes stored in the database.
+func (s *Scopes) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	}
+	return nil
+}
+
+// Contains returns true if scope is in the set.
+func (s Scopes) Contains(scope string) bool {
+	for _, t := range s {
+		if t == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIToken is a long-lived credential for automation. Requests authenticated
+// by an API token act as the owning user, but only on endpoints that require
+// one of the token's scopes.
+type APIToken struct {
+	UpdateCreateTimestamps
+	ID     uint   `json:"id"`
+	UserID uint   `json:"user_id" db:"user_id"`
+	Name   string `json:"name"`
+	// Key is the secret embedded in the bearer token. It is never returned
+	// by the API.
+	Key    string `json:"-"`
+	Scopes Scopes `json:"scopes"`
+}
@@ -2,6 +2,7 @@ package kolide
 
 import (
 	"context"
+	"database/sql/driver"
 	"encoding/json"
 	"time"
 )
@@ -14,14 +15,19 @@ type AppConfigStore interface {
 	SaveAppConfig(info *AppConfig) error
 
 	// VerifyEnrollSecret checks that the provided secret matches an active
-	// enroll secret. If it is successfully matched, the name of the secret is
-	// returned. Otherwise an error is returned.
-	VerifyEnrollSecret(secret string) (string, error)
+	// enroll secret. If it is successfully matched, the secret is returned.
+	// Otherwise an error is returned. Expiry is not checked, so that callers
+	// can apply a grace period.
+	VerifyEnrollSecret(secret string) (*EnrollSecret, error)
 	// ApplyEnrollSecretSpec adds and updates the enroll secrets specified in
 	// the spec.
 	ApplyEnrollSecretSpec(spec *EnrollSecretSpec) error
 	// GetEnrollSecretSpec gets the spec for the current enroll secrets.
 	GetEnrollSecretSpec() (*EnrollSecretSpec, error)
+	// EnrollSecretUsage returns the number of hosts enrolled with each
+	// enroll secret and the last time each secret was used, ordered by
+	// name.
+	EnrollSecretUsage() ([]EnrollSecretUsage, error)
 }
 
 // AppConfigService provides methods for configuring
@@ -31,12 +37,22 @@ type AppConfigService interface {
 	AppConfig(ctx context.Context) (info *AppConfig, err error)
 	ModifyAppConfig(ctx context.Context, p AppConfigPayload) (info *AppConfig, err error)
 	SendTestEmail(ctx context.Context, config *AppConfig) error
+	// TestSMTPSettings checks that Fleet can connect and authenticate to
+	// the SMTP server using the provided settings, without saving them.
+	// Settings that are not provided are taken from the current
+	// configuration. If sendTestEmail is true, a test email is also sent to
+	// the requesting user.
+	TestSMTPSettings(ctx context.Context, settings SMTPSettingsPayload, sendTestEmail bool) error
 
 	// ApplyEnrollSecretSpec adds and updates the enroll secrets specified in
 	// the spec.
 	ApplyEnrollSecretSpec(ctx context.Context, spec *EnrollSecretSpec) error
 	// GetEnrollSecretSpec gets the spec for the current enroll secrets.
 	GetEnrollSecretSpec(ctx context.Context) (*EnrollSecretSpec, error)
+	// EnrollSecretUsage returns the number of hosts enrolled with each
+	// enroll secret and the last time each secret was used, so that the
+	// impact of rotating a secret can be assessed.
+	EnrollSecretUsage(ctx context.Context) ([]EnrollSecretUsage, error)
 
 	// Certificate returns the PEM encoded certificate chain for osqueryd TLS termination.
 	// For cases where the connection is self-signed, the server will attempt to
@@ -160,6 +176,92 @@ type AppConfig struct {
 	// AdditionalQueries is the set of additional queries that should be run
 	// when collecting details from hosts.
 	AdditionalQueries *json.RawMessage `db:"additional_queries"`
+
+	// EnrollmentWebhookURL is the URL notified when a host enrolls. No
+	// notification is sent when it is empty.
+	EnrollmentWebhookURL string `db:"enrollment_webhook_url"`
+	// EnrollmentWebhookSecret is the key used to sign enrollment webhook
+	// payloads.
+	EnrollmentWebhookSecret string `db:"enrollment_webhook_secret"`
+	// CampaignWebhookURL is the URL notified when a distributed query
+	// campaign completes. No notification is sent when it is empty.
+	CampaignWebhookURL string `db:"campaign_webhook_url"`
+	// CampaignWebhookSecret is the key used to sign campaign webhook
+	// payloads.
+	CampaignWebhookSecret string `db:"campaign_webhook_secret"`
+	// CampaignWebhookRequireResults defines whether the campaign webhook is
+	// only notified of campaigns that returned at least one row.
+	CampaignWebhookRequireResults bool `db:"campaign_webhook_require_results"`
+
+	// PasswordMinLength is the minimum number of characters in a user
+	// password. DefaultPasswordMinLength is used if it is not positive.
+	PasswordMinLength int `db:"password_min_length"`
+	// PasswordRequireNumber defines whether user passwords must contain a
+	// number.
+	PasswordRequireNumber bool `db:"password_require_number"`
+	// PasswordRequireSymbol defines whether user passwords must contain a
+	// symbol or punctuation character.
+	PasswordRequireSymbol bool `db:"password_require_symbol"`
+	// PasswordRequireMixedCase defines whether user passwords must contain
+	// both upper and lower case letters.
+	PasswordRequireMixedCase bool `db:"password_require_mixed_case"`
+
+	// SessionDuration is the number of minutes after which user sessions
+	// expire, however recently they were used. Sessions do not expire by
+	// age when it is zero.
+	SessionDuration int `db:"session_duration"`
+	// SessionIdleTimeout is the number of minutes after which user sessions
+	// that have not been used expire. The session.duration server option is
+	// used when it is zero.
+	SessionIdleTimeout int `db:"session_idle_timeout"`
+
+	// RequiredOsqueryVersion is the minimum osquery version hosts are
+	// expected to run. Hosts are not checked when it is empty.
+	RequiredOsqueryVersion string `db:"required_osquery_version"`
+
+	// QueryDenyList are the patterns of the queries that may not be saved or
+	// run as live queries.
+	QueryDenyList QueryDenyList `db:"query_deny_list"`
+}
+
+// QueryDenyPattern is a pattern matched against the SQL of queries. Patterns
+// are matched as case insensitive substrings, unless Regex is set.
+type QueryDenyPattern struct {
+	Pattern string `json:"pattern"`
+	Regex   bool   `json:"regex,omitempty"`
+	// Reason is included in the error returned for matching queries.
+	Reason string `json:"reason,omitempty"`
+}
+
+// QueryDenyList is the list of patterns of denied queries.
+type QueryDenyList []QueryDenyPattern
+
+// Value is called by the DB driver. Patterns are stored as JSON.
+func (l QueryDenyList) Value() (driver.Value, error) {
+	return json.Marshal(l)
+}
+
+// Scan reads the JSON patterns stored in the database.
+func (l *QueryDenyList) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	}
+	return nil
+}
+
+// DefaultPasswordMinLength is the minimum password length used when the
+// app config does not set one.
+const DefaultPasswordMinLength = 7
+
+// MinPasswordLength returns the minimum length of user passwords.
+func (c AppConfig) MinPasswordLength() int {
+	if c.PasswordMinLength <= 0 {
+		return DefaultPasswordMinLength
+	}
+	return c.PasswordMinLength
 }
 
 // ModifyAppConfigRequest contains application configuration information
@@ -239,6 +341,14 @@ type AppConfigPayload struct {
 	SMTPTest *bool `json:"smtp_test,omitempty"`
 	// SSOSettings single sign settings
 	SSOSettings *SSOSettingsPayload `json:"sso_settings"`
+	// WebhookSettings configures notifications sent by Fleet
+	WebhookSettings *WebhookSettings `json:"webhook_settings"`
+	// PasswordPolicySettings configures the requirements for user passwords
+	PasswordPolicySettings *PasswordPolicySettings `json:"password_policy_settings"`
+	// SessionSettings configures the expiry of user sessions
+	SessionSettings *SessionSettings `json:"session_settings"`
+	// QuerySettings configures the queries that may be saved and run
+	QuerySettings *QuerySettings `json:"query_settings"`
 }
 
 // OrgInfo contains general info about the organization using Fleet.
@@ -259,8 +369,38 @@ type HostExpirySettings struct {
 	HostExpiryWindow  *int  `json:"host_expiry_window,omitempty"`
 }
 
+// WebhookSettings contains settings for the webhooks Fleet sends.
+type WebhookSettings struct {
+	EnrollmentWebhookURL          *string `json:"enrollment_webhook_url,omitempty"`
+	EnrollmentWebhookSecret       *string `json:"enrollment_webhook_secret,omitempty"`
+	CampaignWebhookURL            *string `json:"campaign_webhook_url,omitempty"`
+	CampaignWebhookSecret         *string `json:"campaign_webhook_secret,omitempty"`
+	CampaignWebhookRequireResults *bool   `json:"campaign_webhook_require_results,omitempty"`
+}
+
+// PasswordPolicySettings contains the requirements for user passwords.
+type PasswordPolicySettings struct {
+	MinLength        *int  `json:"min_length,omitempty"`
+	RequireNumber    *bool `json:"require_number,omitempty"`
+	RequireSymbol    *bool `json:"require_symbol,omitempty"`
+	RequireMixedCase *bool `json:"require_mixed_case,omitempty"`
+}
+
+// SessionSettings contains the expiry of user sessions, in minutes.
+type SessionSettings struct {
+	SessionDuration    *int `json:"session_duration,omitempty"`
+	SessionIdleTimeout *int `json:"session_idle_timeout,omitempty"`
+}
+
+// QuerySettings contains the restrictions on the queries that may be saved
+// and run.
+type QuerySettings struct {
+	DenyList *QueryDenyList `json:"deny_list,omitempty"`
+}
+
 type HostSettings struct {
-	AdditionalQueries *json.RawMessage `json:"additional_queries"`
+	AdditionalQueries      *json.RawMessage `json:"additional_queries"`
+	RequiredOsqueryVersion *string          `json:"required_osquery_version,omitempty"`
 }
 
 type OrderDirection int
@@ -296,6 +436,28 @@ type EnrollSecret struct {
 	Active bool `json:"active" db:"active"`
 	// CreatedAt is the time this enroll secret was first added.
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// ExpiresAt, when set, is the time after which the secret can no longer
+	// be used to enroll hosts.
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	// Label, when set, is the name of a label that hosts enrolling with this
+	// secret are added to. Membership of labels with a query is still
+	// updated by the query results once the host checks in.
+	Label *string `json:"label,omitempty" db:"label"`
+	// LabelID is the ID of Label.
+	LabelID *uint `json:"-" db:"label_id"`
+}
+
+// EnrollSecretUsage summarizes the hosts enrolled with an enroll secret.
+type EnrollSecretUsage struct {
+	// Name is the name of the secret.
+	Name string `json:"name" db:"name"`
+	// Active is whether the secret can currently be used to enroll hosts.
+	Active bool `json:"active" db:"active"`
+	// Hosts is the number of hosts that last enrolled with the secret.
+	Hosts uint `json:"hosts" db:"hosts"`
+	// LastUsed is the most recent enrollment of one of those hosts. It is
+	// nil if no host is enrolled with the secret.
+	LastUsed *time.Time `json:"last_used,omitempty" db:"last_used"`
 }
 
 // EnrollSecretSpec is the fleetctl spec type for enroll secrets.
@@ -0,0 +1,96 @@
+package kolide
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// ATCTableStore methods to manipulate the osquery auto table construction
+// tables defined by admins.
+type ATCTableStore interface {
+	// NewATCTable creates an ATC table.
+	NewATCTable(table *ATCTable) (*ATCTable, error)
+	// ATCTable returns the ATC table with the given ID.
+	ATCTable(id uint) (*ATCTable, error)
+	// SaveATCTable updates an existing ATC table.
+	SaveATCTable(table *ATCTable) error
+	// DeleteATCTable removes an ATC table.
+	DeleteATCTable(id uint) error
+	// ListATCTables returns all ATC tables, sorted by name.
+	ListATCTables() ([]*ATCTable, error)
+}
+
+// ATCTableService manages the osquery auto table construction (ATC) tables
+// defined by admins. ATC tables expose the contents of SQLite databases on
+// hosts as osquery tables, and are added to the auto_table_construction
+// section of the osquery config served to hosts.
+type ATCTableService interface {
+	// ListATCTables returns all ATC tables.
+	ListATCTables(ctx context.Context) ([]*ATCTable, error)
+	// GetATCTable returns the ATC table with the given ID.
+	GetATCTable(ctx context.Context, id uint) (*ATCTable, error)
+	// NewATCTable creates an ATC table.
+	NewATCTable(ctx context.Context, payload ATCTablePayload) (*ATCTable, error)
+	// ModifyATCTable updates the fields of the ATC table that are set in
+	// the payload.
+	ModifyATCTable(ctx context.Context, id uint, payload ATCTablePayload) (*ATCTable, error)
+	// DeleteATCTable removes an ATC table. Hosts drop the table at their
+	// next config refresh.
+	DeleteATCTable(ctx context.Context, id uint) error
+}
+
+// ATCTable is an osquery auto table construction table, reading the rows
+// returned by Query from the SQLite database at Path on hosts.
+type ATCTable struct {
+	UpdateCreateTimestamps
+	ID uint `json:"id"`
+	// Name is the name of the osquery table.
+	Name string `json:"name"`
+	// Path is the absolute path of the SQLite database on hosts. osquery
+	// expands % wildcards in the path.
+	Path string `json:"path"`
+	// Query is run against the SQLite database, and must return the
+	// columns of the table.
+	Query   string     `json:"query"`
+	Columns ATCColumns `json:"columns"`
+	// Platform limits the table to hosts of the osquery platform, such as
+	// darwin. The table is created on all platforms when it is empty.
+	Platform string `json:"platform"`
+}
+
+type ATCTablePayload struct {
+	Name     *string     `json:"name"`
+	Path     *string     `json:"path"`
+	Query    *string     `json:"query"`
+	Columns  *ATCColumns `json:"columns"`
+	Platform *string     `json:"platform"`
+}
+
+// ATCColumns are the names of the columns of an ATC table.
+type ATCColumns []string
+
+// Value is called by the DB driver. Columns are stored as JSON.
+func (c ATCColumns) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan reads the JSON columns stored in the database.
+func (c *ATCColumns) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	}
+	return nil
+}
+
+// ATCTableConfig is an entry of the auto_table_construction section of the
+// osquery config, keyed by table name.
+type ATCTableConfig struct {
+	Query    string   `json:"query"`
+	Path     string   `json:"path"`
+	Columns  []string `json:"columns"`
+	Platform string   `json:"platform,omitempty"`
+}
@@ -3,8 +3,6 @@ package kolide
 import (
 	"context"
 	"time"
-
-	"github.com/kolide/fleet/server/websocket"
 )
 
 // CampaignStore defines the distributed query campaign related datastore
@@ -14,9 +12,23 @@ type CampaignStore interface {
 	NewDistributedQueryCampaign(camp *DistributedQueryCampaign) (*DistributedQueryCampaign, error)
 	// DistributedQueryCampaign loads a distributed query campaign by ID
 	DistributedQueryCampaign(id uint) (*DistributedQueryCampaign, error)
+	// ListActiveDistributedQueryCampaigns returns the campaigns that are
+	// waiting or running, oldest first.
+	ListActiveDistributedQueryCampaigns() ([]*DistributedQueryCampaign, error)
 	// SaveDistributedQueryCampaign updates an existing distributed query
 	// campaign
 	SaveDistributedQueryCampaign(camp *DistributedQueryCampaign) error
+	// CompleteDistributedQueryCampaign marks the campaign completed. It
+	// returns true if the campaign was not already completed, so that
+	// callers racing to complete a campaign can tell which completed it.
+	CompleteDistributedQueryCampaign(id uint) (bool, error)
+	// MarkDistributedQueryCampaignDisconnected records when the last client
+	// streaming the campaign disconnected. A nil time clears it when a
+	// client resumes the stream.
+	MarkDistributedQueryCampaignDisconnected(id uint, disconnectedAt *time.Time) error
+	// DistributedQueryCampaignSummary summarizes the executions recorded
+	// for the campaign.
+	DistributedQueryCampaignSummary(id uint) (*DistributedQueryCampaignSummary, error)
 	// DistributedQueryCampaignTargetIDs gets the IDs of the targets for
 	// the query campaign of the provided ID
 	DistributedQueryCampaignTargetIDs(id uint) (hostIDs []uint, labelIDs []uint, err error)
@@ -29,6 +41,10 @@ type CampaignStore interface {
 	// distributed query campaign
 	NewDistributedQueryExecution(exec *DistributedQueryExecution) (*DistributedQueryExecution, error)
 
+	// DistributedQueryExecutionExists returns true if an execution of the
+	// campaign by the host has been recorded.
+	DistributedQueryExecutionExists(campaignID, hostID uint) (bool, error)
+
 	// CleanupDistributedQueryCampaigns will clean and trim metadata for
 	// old distributed query campaigns. Any campaign in the QueryWaiting
 	// state will be moved to QueryComplete after one minute. Any campaign
@@ -39,6 +55,12 @@ type CampaignStore interface {
 	// indicate how many campaigns were expired, how many executions were
 	// deleted, and any error.
 	CleanupDistributedQueryCampaigns(now time.Time) (expired uint, deleted uint, err error)
+
+	// PurgeCompletedCampaigns permanently deletes the completed campaigns
+	// created before the provided time, along with their targets and
+	// executions. The number of purged campaigns is returned along with
+	// any error.
+	PurgeCompletedCampaigns(before time.Time) (uint, error)
 }
 
 // CampaignService defines the distributed query campaign related service
@@ -46,17 +68,78 @@ type CampaignStore interface {
 type CampaignService interface {
 	// NewDistributedQueryCampaign creates a new distributed query campaign
 	// with the provided query and host/label targets (specified by name).
-	NewDistributedQueryCampaignByNames(ctx context.Context, queryString string, hosts []string, labels []string) (*DistributedQueryCampaign, error)
+	NewDistributedQueryCampaignByNames(ctx context.Context, queryString string, hosts []string, labels []string, executionTimeout uint, allowResubmission bool) (*DistributedQueryCampaign, error)
 
 	// NewDistributedQueryCampaign creates a new distributed query campaign
-	// with the provided query and host/label targets
-	NewDistributedQueryCampaign(ctx context.Context, queryString string, hosts []uint, labels []uint) (*DistributedQueryCampaign, error)
+	// with the provided query and host/label targets. If executionTimeout
+	// is non-zero, hosts stop receiving the query that many seconds after
+	// the campaign is created. If allowResubmission is true, hosts may
+	// report results more than once (see
+	// DistributedQueryCampaign.AllowResubmission).
+	NewDistributedQueryCampaign(ctx context.Context, queryString string, hosts []uint, labels []uint, executionTimeout uint, allowResubmission bool) (*DistributedQueryCampaign, error)
+
+	// NewSavedQueryCampaign creates a new distributed query campaign
+	// running the saved query with the given ID. If the query has a cache
+	// TTL and was recently run against the same targets, the campaign is
+	// returned already complete with the cached results.
+	NewSavedQueryCampaign(ctx context.Context, queryID uint, hosts []uint, labels []uint, executionTimeout uint, allowResubmission bool) (*DistributedQueryCampaign, error)
+
+	// NewSavedQueryCampaignByNames is like NewSavedQueryCampaign, but the
+	// saved query and the host/label targets are specified by name. Unlike
+	// NewDistributedQueryCampaignByNames, an error listing every name that
+	// could not be resolved is returned instead of ignoring those names.
+	NewSavedQueryCampaignByNames(ctx context.Context, queryName string, hosts []string, labels []string, executionTimeout uint, allowResubmission bool) (*DistributedQueryCampaign, error)
+
+	// DistributedQueryCampaignTargetsCount resolves the host and label
+	// targets in the same way as creating a campaign would, and returns
+	// the number and IDs of the targeted hosts. No campaign is created.
+	DistributedQueryCampaignTargetsCount(ctx context.Context, hosts []uint, labels []uint) (hostCount int, hostIDs []uint, err error)
+
+	// ListRunningCampaigns returns the campaigns that have not completed.
+	ListRunningCampaigns(ctx context.Context) ([]DistributedQueryCampaign, error)
+
+	// StopCampaign marks the campaign completed, so that its query is no
+	// longer sent to hosts, and ends the results streams of its
+	// subscribers. Only the user that created the campaign or an admin
+	// may stop it.
+	StopCampaign(ctx context.Context, id uint) error
+
+	// EstimateQueryCost runs the query as a short live campaign against
+	// the sample hosts, and extrapolates the cost of running it on every
+	// host from the results returned before the estimate times out.
+	EstimateQueryCost(ctx context.Context, sql string, sampleHostIDs []uint) (CostEstimate, error)
 
 	// StreamCampaignResults streams updates with query results and
-	// expected host totals over the provided websocket. Note that the type
+	// expected host totals over the provided connection, which is a
+	// websocket or a server-sent events stream. The stream ends when the
+	// campaign is stopped or ctx is done. Note that the type
 	// signature is somewhat inconsistent due to this being a streaming API
-	// and not the typical go-kit RPC style.
-	StreamCampaignResults(ctx context.Context, conn *websocket.Conn, campaignID uint)
+	// and not the typical go-kit RPC style. If lastSequence is non-nil, the
+	// client is resuming a previous stream and any retained results with a
+	// greater sequence number are replayed before live results. If
+	// aggregate is true, the counts of hosts returning each distinct row
+	// are also streamed as results arrive.
+	StreamCampaignResults(ctx context.Context, conn CampaignResultsWriter, campaignID uint, lastSequence *uint64, aggregate bool)
+
+	// DrainCampaigns stops every campaign results stream because the
+	// server is shutting down. Subscribers are sent a final message and
+	// their campaigns are marked completed. No new streams are accepted
+	// after DrainCampaigns is called. It returns once every stream has
+	// finished, or with an error if ctx is done first.
+	DrainCampaigns(ctx context.Context) error
+
+	// ReapCampaigns periodically purges the completed campaigns that are
+	// older than the configured campaign retention. It blocks until ctx is
+	// done.
+	ReapCampaigns(ctx context.Context)
+}
+
+// CampaignResultsWriter writes the messages of a campaign results stream to
+// the client. Each message has a type, such as "result" or "status", and JSON
+// data.
+type CampaignResultsWriter interface {
+	WriteJSONMessage(typ string, data interface{}) error
+	WriteJSONError(data interface{}) error
 }
 
 // DistributedQueryStatus is the lifecycle status of a distributed query
@@ -79,6 +162,31 @@ type DistributedQueryCampaign struct {
 	QueryID uint                   `json:"query_id" db:"query_id"`
 	Status  DistributedQueryStatus `json:"status"`
 	UserID  uint                   `json:"user_id" db:"user_id"`
+	// ExecutionTimeout is the number of seconds after creation that the
+	// campaign's query continues to be sent to hosts. Once it elapses the
+	// campaign is completed. Zero means no timeout.
+	ExecutionTimeout uint `json:"execution_timeout" db:"execution_timeout"`
+	// AllowResubmission allows hosts to report results for the campaign
+	// more than once. By default, results from a host that has already
+	// reported are discarded, as osquery may resend identical results after
+	// reconnecting.
+	AllowResubmission bool `json:"allow_resubmission" db:"allow_resubmission"`
+	// DisconnectedAt is when the last client streaming the campaign
+	// disconnected, or nil while a client is streaming it.
+	DisconnectedAt *time.Time `json:"-" db:"disconnected_at"`
+	// CachedResults holds the results of a saved query campaign served
+	// from the results cache. Such campaigns are complete when created.
+	CachedResults []DistributedQueryResult `json:"cached_results,omitempty" db:"-"`
+}
+
+// TimedOut returns true if the campaign has an execution timeout that has
+// elapsed as of now.
+func (c DistributedQueryCampaign) TimedOut(now time.Time) bool {
+	if c.ExecutionTimeout == 0 {
+		return false
+	}
+	timeout := time.Duration(c.ExecutionTimeout) * time.Second
+	return !now.Before(c.CreatedAt.Add(timeout))
 }
 
 // DistributedQueryCampaignTarget stores a target (host or label) for a
@@ -113,6 +221,40 @@ type DistributedQueryResult struct {
 	// that we can't use the error interface here because something
 	// implementing that interface may not (un)marshal properly
 	Error *string `json:"error"`
+	// Stats are the resources the host reported using to run the query, if
+	// it reports them.
+	Stats *OsqueryDistributedQueryStats `json:"stats,omitempty"`
+	// Sequence is a monotonically increasing number assigned by the
+	// QueryResultStore to each result in a campaign. Clients use it as a
+	// cursor when resuming a stream of results.
+	Sequence uint64 `json:"sequence"`
+}
+
+// CostEstimate is the estimated cost of running a query on every host,
+// extrapolated from running it against a sample of hosts.
+type CostEstimate struct {
+	// SampledHosts is the number of hosts the query was sent to.
+	SampledHosts uint `json:"sampled_hosts"`
+	// RespondedHosts is the number of sampled hosts that returned results
+	// before the estimate timed out, including those in FailedHosts.
+	RespondedHosts uint `json:"responded_hosts"`
+	FailedHosts    uint `json:"failed_hosts"`
+	// TotalHosts is the number of hosts the estimate is extrapolated to.
+	TotalHosts uint `json:"total_hosts"`
+	// TimedHosts is the number of successful sampled hosts that reported
+	// how long the query took to run. Only osquery 5 and later report it.
+	TimedHosts uint `json:"timed_hosts"`
+	// AverageWallTime is in milliseconds, averaged over TimedHosts. It is
+	// the time the hosts reported running the query took, so it does not
+	// include the time they took to check in.
+	AverageWallTime float64 `json:"average_wall_time"`
+	AverageRows     float64 `json:"average_rows"`
+	// AverageResultSize is in bytes.
+	AverageResultSize float64 `json:"average_result_size"`
+	// The estimated totals are the averages multiplied by TotalHosts.
+	EstimatedWallTime   float64 `json:"estimated_wall_time"`
+	EstimatedRows       uint64  `json:"estimated_rows"`
+	EstimatedResultSize uint64  `json:"estimated_result_size"`
 }
 
 // DistributedQueryExecution is the metadata associated with a distributed
@@ -124,4 +266,18 @@ type DistributedQueryExecution struct {
 	Status                     DistributedQueryExecutionStatus
 	Error                      string
 	ExecutionDuration          time.Duration `db:"execution_duration"`
+	// RowCount is the number of rows returned by the host.
+	RowCount uint `db:"row_count"`
+}
+
+// DistributedQueryCampaignSummary summarizes the results returned by the
+// hosts targeted by a campaign.
+type DistributedQueryCampaignSummary struct {
+	// HostsResponded is the number of hosts that returned results,
+	// including those that failed.
+	HostsResponded uint `json:"hosts_responded" db:"hosts_responded"`
+	// HostsFailed is the number of hosts on which the query failed.
+	HostsFailed uint `json:"hosts_failed" db:"hosts_failed"`
+	// Rows is the number of rows returned by all hosts.
+	Rows uint `json:"rows" db:"total_rows"`
 }
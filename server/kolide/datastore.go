@@ -18,6 +18,14 @@ type Datastore interface {
 	FileIntegrityMonitoringStore
 	YARAStore
 	OsqueryOptionsStore
+	DecoratorStore
+	HostDetailQueryStore
+	ATCTableStore
+	HostActivityStore
+	IngestionFailureStore
+	HostUptimeEventStore
+	ActivityStore
+	APITokenStore
 	Name() string
 	Drop() error
 	// MigrateTables creates and migrates the table schemas
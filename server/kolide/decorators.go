@@ -1,13 +1,40 @@
 package kolide
 
 import (
+	"context"
 	"errors"
+	"strconv"
 	"strings"
 )
 
-// DEPRECATED
-// Decorators are now stored as JSON in the config, so these types are only
-// useful for migrating existing Fleet installations.
+// DecoratorStore methods to manipulate decorator queries.
+type DecoratorStore interface {
+	// NewDecorator creates a decorator query.
+	NewDecorator(decorator *Decorator, opts ...OptionalArg) (*Decorator, error)
+	// DeleteDecorator removes a decorator query.
+	DeleteDecorator(id uint) error
+	// Decorator retrieves a decorator query with supplied ID.
+	Decorator(id uint) (*Decorator, error)
+	// ListDecorators returns all decorator queries.
+	ListDecorators(opts ...OptionalArg) ([]*Decorator, error)
+	// SaveDecorator updates an existing decorator query.
+	SaveDecorator(dec *Decorator, opts ...OptionalArg) error
+}
+
+// DecoratorService manages decorator queries. Decorators are included in the
+// decorators section of the config served to every host, in addition to any
+// decorators set in the osquery options.
+type DecoratorService interface {
+	// ListDecorators returns all decorator queries.
+	ListDecorators(ctx context.Context) ([]*Decorator, error)
+	// NewDecorator creates a decorator query.
+	NewDecorator(ctx context.Context, payload DecoratorPayload) (*Decorator, error)
+	// ModifyDecorator updates the decorator query identified by payload.ID.
+	ModifyDecorator(ctx context.Context, payload DecoratorPayload) (*Decorator, error)
+	// DeleteDecorator removes a decorator query. Built in decorators may not
+	// be deleted.
+	DeleteDecorator(ctx context.Context, id uint) error
+}
 
 // DecoratorType refers to the allowable types of decorator queries.
 // See https://osquery.readthedocs.io/en/stable/deployment/configuration/
@@ -37,7 +64,7 @@ func (dt DecoratorType) String() string {
 	}
 }
 
-func (dt *DecoratorType) MarshalJSON() ([]byte, error) {
+func (dt DecoratorType) MarshalJSON() ([]byte, error) {
 	name := dt.String()
 	if name == "" {
 		return nil, errors.New("Invalid decorator type")
@@ -87,3 +114,23 @@ type DecoratorPayload struct {
 	Interval      *uint          `json:"interval"`
 	Query         *string        `json:"query"`
 }
+
+// Append returns the decorators section with the provided decorator queries
+// added.
+func (d Decorators) Append(decorators []*Decorator) Decorators {
+	for _, dec := range decorators {
+		switch dec.Type {
+		case DecoratorLoad:
+			d.Load = append(d.Load, dec.Query)
+		case DecoratorAlways:
+			d.Always = append(d.Always, dec.Query)
+		case DecoratorInterval:
+			if d.Interval == nil {
+				d.Interval = make(map[string][]string)
+			}
+			key := strconv.Itoa(int(dec.Interval))
+			d.Interval[key] = append(d.Interval[key], dec.Query)
+		}
+	}
+	return d
+}
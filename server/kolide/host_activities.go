@@ -0,0 +1,77 @@
+package kolide
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// HostActivityStore records and retrieves the lifecycle events of hosts.
+type HostActivityStore interface {
+	// NewHostActivity records an event in the activity feed of a host.
+	NewHostActivity(activity *HostActivity) (*HostActivity, error)
+	// ListHostActivities returns the events recorded for the host. Supply
+	// query options using the opt parameter. See HostActivityListOptions.
+	ListHostActivities(hostID uint, opt HostActivityListOptions) ([]*HostActivity, error)
+}
+
+// HostActivityService provides access to the activity feed of hosts.
+type HostActivityService interface {
+	// HostActivities returns the events recorded for the host, most recent
+	// first unless another order is requested in opt. Unlike the audit log,
+	// the feed is available to every user that can read hosts.
+	HostActivities(ctx context.Context, hostID uint, opt HostActivityListOptions) ([]*HostActivity, error)
+}
+
+// The types of event recorded in the activity feed of a host.
+const (
+	// HostActivityEnrolled is recorded when the host enrolls with a new
+	// node key.
+	HostActivityEnrolled = "enrolled"
+	// HostActivityLabelAdded is recorded when a label query first matches
+	// the host.
+	HostActivityLabelAdded = "label_added"
+	// HostActivityLabelRemoved is recorded when a label query that matched
+	// the host stops matching it.
+	HostActivityLabelRemoved = "label_removed"
+	// HostActivityMaintenanceStarted is recorded when the host is put in
+	// maintenance.
+	HostActivityMaintenanceStarted = "maintenance_started"
+	// HostActivityMaintenanceEnded is recorded when the maintenance window
+	// of the host is ended by a user.
+	HostActivityMaintenanceEnded = "maintenance_ended"
+	// HostActivityApproved is recorded when an admin approves the
+	// enrollment of a host that was pending approval.
+	HostActivityApproved = "approved"
+	// HostActivityRebooted is recorded when a reboot of the host is detected
+	// from its uptime.
+	HostActivityRebooted = "rebooted"
+	// HostActivityMerged is recorded on the kept host when duplicate
+	// records of the host are merged into it.
+	HostActivityMerged = "merged"
+	// HostActivityNodeKeyRotated is recorded when an admin rotates the node
+	// key of the host, forcing it to enroll again.
+	HostActivityNodeKeyRotated = "node_key_rotated"
+)
+
+// HostActivityListOptions holds the options for listing the activity feed of
+// a host.
+type HostActivityListOptions struct {
+	ListOptions
+	// Types restricts the feed to the given event types. An empty list
+	// includes every type.
+	Types []string
+}
+
+// HostActivity is a single event in the activity feed of a host.
+type HostActivity struct {
+	ID     uint `json:"id"`
+	HostID uint `json:"host_id" db:"host_id"`
+	// CreatedAt is the time at which the event happened.
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// Type is the type of the event, one of the HostActivity constants.
+	Type string `json:"type"`
+	// Details holds information specific to the type of the event, such as
+	// the label that was added or the end of a maintenance window.
+	Details *json.RawMessage `json:"details,omitempty"`
+}
@@ -0,0 +1,52 @@
+package kolide
+
+import "context"
+
+// HostDetailQueryStore methods to manipulate the detail queries defined by
+// admins.
+type HostDetailQueryStore interface {
+	// NewHostDetailQuery creates a host detail query.
+	NewHostDetailQuery(query *HostDetailQuery) (*HostDetailQuery, error)
+	// DeleteHostDetailQuery removes a host detail query.
+	DeleteHostDetailQuery(id uint) error
+	// ListHostDetailQueries returns all host detail queries.
+	ListHostDetailQueries() ([]*HostDetailQuery, error)
+}
+
+// HostDetailQueryService manages the detail queries defined by admins.
+// These queries are sent to hosts along with the built in detail queries,
+// and their results are stored in the extra details of each host.
+type HostDetailQueryService interface {
+	// ListHostDetailQueries returns all host detail queries.
+	ListHostDetailQueries(ctx context.Context) ([]*HostDetailQuery, error)
+	// NewHostDetailQuery creates a host detail query.
+	NewHostDetailQuery(ctx context.Context, payload HostDetailQueryPayload) (*HostDetailQuery, error)
+	// DeleteHostDetailQuery removes a host detail query. Results already
+	// stored for hosts are removed the next time their details are
+	// updated.
+	DeleteHostDetailQuery(ctx context.Context, id uint) error
+	// HostExtraDetails returns the most recent results of the host detail
+	// queries for the host, keyed by query name.
+	HostExtraDetails(ctx context.Context, hostID uint) (HostExtraDetails, error)
+}
+
+// HostDetailQuery is a query defined by an admin to collect additional
+// details about hosts. The query must return at most one row.
+type HostDetailQuery struct {
+	UpdateCreateTimestamps
+	ID uint `json:"id"`
+	// Name identifies the results of the query in the extra details of
+	// each host.
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+type HostDetailQueryPayload struct {
+	Name  *string `json:"name"`
+	Query *string `json:"query"`
+}
+
+// HostExtraDetails maps the name of each host detail query to the columns of
+// the row it returned. A query that returned no rows maps to an empty set of
+// columns.
+type HostExtraDetails map[string]map[string]string
@@ -0,0 +1,28 @@
+package kolide
+
+import "time"
+
+// HostUptimeEventStore methods to record and retrieve the reboots detected
+// from the uptime reported by hosts.
+type HostUptimeEventStore interface {
+	// NewHostUptimeEvent records a reboot of a host.
+	NewHostUptimeEvent(event *HostUptimeEvent) (*HostUptimeEvent, error)
+	// ListHostUptimeEvents returns the reboots recorded for the host, most
+	// recent first.
+	ListHostUptimeEvents(hostID uint) ([]*HostUptimeEvent, error)
+}
+
+// HostUptimeEvent records a reboot of a host, detected when the boot time
+// derived from its uptime changes between two reports.
+type HostUptimeEvent struct {
+	ID     uint `json:"id"`
+	HostID uint `json:"host_id" db:"host_id"`
+	// BootTime is the estimated time at which the host booted.
+	BootTime time.Time `json:"boot_time" db:"boot_time"`
+	// PreviousBootTime is the estimated time of the boot preceding the
+	// reboot.
+	PreviousBootTime time.Time `json:"previous_boot_time" db:"previous_boot_time"`
+	// DetectedAt is the time at which the host reported the uptime from
+	// which the reboot was detected.
+	DetectedAt time.Time `json:"detected_at" db:"detected_at"`
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"io"
 	"net"
 	"time"
 )
@@ -19,6 +20,10 @@ const (
 	// StatusMIA no communication with host for MIADuration.
 	StatusMIA = "mia"
 
+	// StatusMaintenance host is not online, but is in a maintenance window
+	// (see Host.MaintenanceUntil), so it is not considered offline or MIA.
+	StatusMaintenance = "maintenance"
+
 	// NewDuration if a host has been created within this time period it's
 	// considered new.
 	NewDuration = 24 * time.Hour
@@ -39,9 +44,25 @@ type HostStore interface {
 	NewHost(host *Host) (*Host, error)
 	SaveHost(host *Host) error
 	DeleteHost(hid uint) error
+	// DeleteHostsByLabel deletes all of the hosts that are members of the
+	// label with the given ID, returning the number of hosts deleted.
+	DeleteHostsByLabel(lid uint) (int, error)
 	Host(id uint) (*Host, error)
-	ListHosts(opt ListOptions) ([]*Host, error)
-	EnrollHost(osqueryHostId, nodeKey, secretName string) (*Host, error)
+	ListHosts(opt HostListOptions) ([]*Host, error)
+	// CountHosts returns the number of hosts ListHosts would return for opt
+	// across all pages. Pagination and ordering options are ignored.
+	CountHosts(opt HostListOptions) (int, error)
+	// StreamHosts calls fn for each host matching the filters in opt, in
+	// order of ID, without loading all of the hosts into memory. Pagination
+	// and ordering options are ignored, and network interfaces are not
+	// loaded. Iteration stops at the first error returned by fn.
+	StreamHosts(opt HostListOptions, fn func(*Host) error) error
+	// EnrollHost enrolls a host with the given node key. When cooldown is
+	// non-zero and a host with the same osquery host identifier enrolled
+	// within the cooldown window, that host is returned with its existing
+	// node key instead. A newly created host is pending
+	// approval if pending is true, as is a deleted host enrolling again.
+	EnrollHost(osqueryHostId, nodeKey, secretName string, cooldown time.Duration, pending bool) (*Host, error)
 	// AuthenticateHost authenticates and returns host metadata by node key.
 	// This method should not return the host "additional" information as this
 	// is not typically necessary for the operations performed by the osquery
@@ -58,21 +79,248 @@ type HostStore interface {
 	// osquery queries failed to populate details.
 	CleanupIncomingHosts(now time.Time) error
 	// GenerateHostStatusStatistics retrieves the count of online, offline,
-	// MIA and new hosts.
-	GenerateHostStatusStatistics(now time.Time) (online, offline, mia, new uint, err error)
+	// MIA, in maintenance and new hosts.
+	GenerateHostStatusStatistics(now time.Time) (online, offline, mia, maintenance, new uint, err error)
 	// DistributedQueriesForHost retrieves the distributed queries that the
 	// given host should run. The result map is a mapping from campaign ID
 	// to query text.
 	DistributedQueriesForHost(host *Host) (map[uint]string, error)
 	// HostIDsByName Retrieve the IDs associated with the given hostnames
 	HostIDsByName(hostnames []string) ([]uint, error)
+	// HostIDsByIdentifier retrieves the IDs of the hosts that are not
+	// deleted and that enrolled with the given osquery host identifiers.
+	// Identifiers that do not match a host are ignored.
+	HostIDsByIdentifier(identifiers []string) ([]uint, error)
+	// SetHostsConfigRefresh sets whether the given hosts have a pending
+	// config refresh. IDs that do not match a host are ignored.
+	SetHostsConfigRefresh(hostIDs []uint, requested bool) error
+	// ExpireHostDetails marks the details of the host as out of date, so
+	// that its detail queries are sent on the next distributed read.
+	ExpireHostDetails(hostID uint) error
+	// AggregateHosts returns the number of hosts for each value of
+	// groupBy, which is either HostAggregateLabel or one of
+	// HostAggregateColumns.
+	AggregateHosts(groupBy string) ([]HostAggregate, error)
+	// RecordNoisyHost flags the host as having exceeded the log rate limit
+	// at the given time, and adds dropped to its count of dropped log rows.
+	RecordNoisyHost(hostID uint, dropped uint, at time.Time) error
+	// ListNoisyHosts returns the hosts that have exceeded the log rate
+	// limit, most recently flagged first.
+	ListNoisyHosts() ([]*Host, error)
+	// RecordTruncatedResults flags the host as having returned more result
+	// rows than allowed by the max result rows of a query at the given
+	// time, and adds truncated to its count of truncated rows.
+	RecordTruncatedResults(hostID uint, truncated uint, at time.Time) error
+	// SetHostMaintenance sets the end of the maintenance window of the
+	// host, or clears it if until is nil.
+	SetHostMaintenance(hostID uint, until *time.Time) error
+	// ClearElapsedHostMaintenance clears the maintenance windows that
+	// ended before now.
+	ClearElapsedHostMaintenance(now time.Time) error
+	// ApproveHost clears the pending enrollment approval of the host.
+	ApproveHost(hostID uint) error
+	// SetHostNodeKey replaces the node key of the host.
+	SetHostNodeKey(hostID uint, nodeKey string) error
+	// MergeHosts reassigns the manual label memberships, reboot history,
+	// activity feed and distributed query executions of the hosts in
+	// mergeIDs to the host keepID, then deletes them, in a single
+	// transaction.
+	MergeHosts(keepID uint, mergeIDs []uint) (HostMerge, error)
 }
 
 type HostService interface {
-	ListHosts(ctx context.Context, opt ListOptions) (hosts []*Host, err error)
+	// ListHosts returns the hosts matching opt. When cursor pagination is
+	// enabled and more hosts may follow, nextCursor can be used as the
+	// After option to retrieve the next page.
+	ListHosts(ctx context.Context, opt HostListOptions) (hosts []*Host, nextCursor string, err error)
+	// CountHosts returns the number of hosts matching the filters in opt.
+	CountHosts(ctx context.Context, opt HostListOptions) (count int, err error)
+	// StreamHosts writes each host matching the filters in opt to w as
+	// newline-delimited JSON. Pagination and ordering options are ignored.
+	StreamHosts(ctx context.Context, opt HostListOptions, w io.Writer) (err error)
 	GetHost(ctx context.Context, id uint) (host *Host, err error)
 	GetHostSummary(ctx context.Context) (summary *HostSummary, err error)
 	DeleteHost(ctx context.Context, id uint) (err error)
+	// DeleteHostsByLabel deletes all of the hosts that are members of the
+	// label with the given ID, returning the number of hosts deleted.
+	DeleteHostsByLabel(ctx context.Context, labelID uint) (deleted int, err error)
+	// RefreshHostConfig flags the given hosts as having a pending config
+	// refresh. The flag is cleared once the host has been served the
+	// current config. IDs that do not match a host are ignored.
+	RefreshHostConfig(ctx context.Context, hostIDs []uint) error
+	// TransferHosts moves the hosts to the manual label with the given ID,
+	// removing them from their other manual labels in the same
+	// transaction, and flags them for a config refresh. It fails without
+	// changes if the label is not manual or any of the hosts do not exist.
+	TransferHosts(ctx context.Context, hostIDs []uint, targetLabelID uint) error
+	// RefreshHostDetails marks the details of the host as out of date, so
+	// that its detail queries are requested again the next time the host
+	// checks for distributed queries, rather than after the detail update
+	// interval.
+	RefreshHostDetails(ctx context.Context, hostID uint) error
+	// SetHostMaintenance puts the host in maintenance until the given
+	// time, so that it is reported as in maintenance rather than offline
+	// while it is unreachable. A zero until ends the maintenance window.
+	SetHostMaintenance(ctx context.Context, hostID uint, until time.Time) error
+	// ApproveHost approves the enrollment of a host that is pending
+	// approval, so that it is served its config. Approving a host that is
+	// not pending has no effect.
+	ApproveHost(ctx context.Context, hostID uint) error
+	// RotateHostNodeKey replaces the node key of the host with one that is
+	// not given to the host, such as when its node key may have been
+	// stolen. The next request the host makes with its old node key fails
+	// with node_invalid, so that it must enroll again with a valid enroll
+	// secret. The host record and its history are kept, and are used by
+	// the host when it enrolls again.
+	RotateHostNodeKey(ctx context.Context, hostID uint) error
+	// MergeHosts merges duplicate records of the same machine into the
+	// host keepID, reassigning the label memberships and history of the
+	// hosts in mergeIDs before deleting them. Hosts with conflicting
+	// platforms are not merged.
+	MergeHosts(ctx context.Context, keepID uint, mergeIDs []uint) (HostMerge, error)
+	// HostScheduledQueries returns every scheduled query that is sent to
+	// the host in its osquery configuration, so that the data collected
+	// from a host can be disclosed to its user.
+	HostScheduledQueries(ctx context.Context, hostID uint) ([]ScheduledQuery, error)
+	// HostPacks returns the packs that target the host, ordered by name,
+	// with the labels of the host and the host target that cause each
+	// pack to apply. Disabled packs that target the host are included
+	// and marked as disabled.
+	HostPacks(ctx context.Context, hostID uint) ([]HostPackInfo, error)
+	// AggregateHosts returns the number of hosts for each value of
+	// groupBy, most common first. groupBy must be HostAggregateLabel or
+	// one of HostAggregateColumns.
+	AggregateHosts(ctx context.Context, groupBy string) ([]HostAggregate, error)
+	// HostRebootHistory returns the reboots detected for the host, most
+	// recent first.
+	HostRebootHistory(ctx context.Context, hostID uint) ([]*HostUptimeEvent, error)
+	// HostClientConfig returns the osquery config that would be served to
+	// the host by GetClientConfig, given its current label membership.
+	HostClientConfig(ctx context.Context, hostID uint) (json.RawMessage, error)
+	// NoisyHosts returns the hosts that have exceeded the log rate limit,
+	// most recently flagged first.
+	NoisyHosts(ctx context.Context) ([]*Host, error)
+	// DiffHosts compares the stored details of host B against those of
+	// host A, such as a suspect host against a known-good baseline.
+	DiffHosts(ctx context.Context, hostAID, hostBID uint) (HostDiff, error)
+	// HostExport returns the complete stored record of the host in a
+	// single document, such as for attaching to a ticket.
+	HostExport(ctx context.Context, hostID uint) (HostExport, error)
+	// HostsByOsqueryVersion returns the number of hosts running each
+	// osquery version, most common first, flagging the versions below the
+	// required osquery version of the app config.
+	HostsByOsqueryVersion(ctx context.Context) ([]OsqueryVersionCount, error)
+	// HostsByEncryptionStatus returns the number of hosts with each disk
+	// encryption status, including the statuses that no host has.
+	HostsByEncryptionStatus(ctx context.Context) ([]DiskEncryptionCount, error)
+}
+
+// HostMerge reports what was reassigned to the kept host by MergeHosts.
+type HostMerge struct {
+	HostID        uint   `json:"host_id"`
+	MergedHostIDs []uint `json:"merged_host_ids"`
+	// LabelMemberships is the number of manual labels the kept host was
+	// added to. Labels it was already a member of are not counted.
+	LabelMemberships int `json:"label_memberships"`
+	UptimeEvents     int `json:"uptime_events"`
+	Activities       int `json:"activities"`
+	// QueryExecutions is the number of distributed query executions
+	// reassigned. Executions of campaigns the kept host also ran are
+	// removed with the merged hosts.
+	QueryExecutions int `json:"query_executions"`
+}
+
+// DiskEncryptionCount is the number of hosts with a disk encryption status.
+type DiskEncryptionCount struct {
+	Status string `json:"status"`
+	Hosts  uint   `json:"hosts"`
+}
+
+// OsqueryVersionCount is the number of hosts running an osquery version.
+type OsqueryVersionCount struct {
+	Version string `json:"version"`
+	Hosts   uint   `json:"hosts"`
+	// Outdated is true when the version is below the required osquery
+	// version of the app config.
+	Outdated bool `json:"outdated"`
+}
+
+// HostExport is the complete stored record of a host at the time it was
+// exported.
+type HostExport struct {
+	ExportedAt   time.Time        `json:"exported_at"`
+	Host         *Host            `json:"host"`
+	Status       string           `json:"status"`
+	ExtraDetails HostExtraDetails `json:"extra_details"`
+	Labels       []Label          `json:"labels"`
+	Packs        []*Pack          `json:"packs"`
+	// ScheduledQueries are the queries sent to the host in its osquery
+	// config, and ScheduledQueryStats the performance stats the host
+	// reported for them.
+	ScheduledQueries    []ScheduledQuery      `json:"scheduled_queries"`
+	ScheduledQueryStats []ScheduledQueryStats `json:"scheduled_query_stats"`
+	Reboots             []*HostUptimeEvent    `json:"reboots"`
+	// Activities are the most recent entries of the activity feed of the
+	// host, most recent first.
+	Activities []*HostActivity `json:"activities"`
+}
+
+// HostDiff is the difference between the stored details of two hosts. Extra
+// details are identified by the name of the host detail query and the column,
+// separated by a dot.
+type HostDiff struct {
+	HostAID uint `json:"host_a_id"`
+	HostBID uint `json:"host_b_id"`
+	// ChangedDetails lists the details that have different values on the
+	// two hosts, sorted by field.
+	ChangedDetails []HostDetailChange `json:"changed_details"`
+	// AddedExtraDetails lists the extra details reported by host B but not
+	// by host A, and RemovedExtraDetails those reported by host A but not
+	// by host B.
+	AddedExtraDetails   []HostDetailChange `json:"added_extra_details"`
+	RemovedExtraDetails []HostDetailChange `json:"removed_extra_details"`
+	// NoExtraDetailsHostIDs lists the hosts that have not reported extra
+	// details yet. Extra details are only compared when both hosts have
+	// reported them.
+	NoExtraDetailsHostIDs []uint `json:"no_extra_details_host_ids"`
+}
+
+// HostDetailChange holds the values of a detail on the hosts of a HostDiff.
+// A is empty for added details and B for removed details.
+type HostDetailChange struct {
+	Field string `json:"field"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+}
+
+// HostListOptions is used to paginate and filter the results of ListHosts.
+type HostListOptions struct {
+	ListOptions
+	// SeenStatus, when set to StatusOnline or StatusOffline, filters hosts
+	// by whether they have been seen within SeenWithin of the current time.
+	// Hosts that have never been seen are considered offline.
+	SeenStatus string
+	// SeenWithin is the staleness threshold applied when SeenStatus is set.
+	SeenWithin time.Duration
+	// After, when non-nil, enables cursor pagination. Hosts are returned
+	// starting after the position encoded in the cursor (see HostCursor),
+	// or from the beginning if the cursor is empty. Page is ignored in
+	// cursor mode.
+	After *string
+	// MatchQuery, when non-empty, limits the results to hosts with a
+	// hostname, primary IP, hardware serial or UUID starting with this
+	// string. Unless paginating with a cursor, hosts matching one of these
+	// exactly are returned first.
+	MatchQuery string
+	// LabelIDs, when non-empty, limits the results to hosts that are
+	// members of at least one of these labels.
+	LabelIDs []uint
+	// Pending, when non-nil, limits the results to hosts that are (or are
+	// not) pending enrollment approval.
+	Pending *bool
+	// DiskEncryption, when set to one of the DiskEncryption constants,
+	// limits the results to hosts with this disk encryption status.
+	DiskEncryption string
 }
 
 type Host struct {
@@ -85,6 +333,7 @@ type Host struct {
 	OsqueryHostID    string        `json:"-" db:"osquery_host_id"`
 	DetailUpdateTime time.Time     `json:"detail_updated_at" db:"detail_update_time"` // Time that the host details were last updated
 	SeenTime         time.Time     `json:"seen_time" db:"seen_time"`                  // Time that the host was last "seen"
+	LastEnrollTime   time.Time     `json:"last_enroll_time" db:"last_enroll_time"`    // Time that the host last received a new node key
 	NodeKey          string        `json:"-" db:"node_key"`
 	HostName         string        `json:"hostname" db:"host_name"` // there is a fulltext index on this field
 	UUID             string        `json:"uuid" db:"uuid"`          // there is a fulltext index on this field
@@ -115,7 +364,66 @@ type Host struct {
 	ConfigTLSRefresh          uint                `json:"config_tls_refresh" db:"config_tls_refresh"`
 	LoggerTLSPeriod           uint                `json:"logger_tls_period" db:"logger_tls_period"`
 	Additional                *json.RawMessage    `json:"additional,omitempty" db:"additional"`
-	EnrollSecretName          string              `json:"enroll_secret_name" db:"enroll_secret_name"`
+	// ExtraDetails holds the HostExtraDetails collected by the host detail
+	// queries. It is served separately, see HostExtraDetails.
+	ExtraDetails     *json.RawMessage `json:"-" db:"host_extra_details"`
+	EnrollSecretName string           `json:"enroll_secret_name" db:"enroll_secret_name"`
+	// ConfigRefreshRequested is set when an admin has requested that the
+	// host refresh its config, and cleared once the host fetches it.
+	ConfigRefreshRequested bool `json:"config_refresh_requested" db:"config_refresh_requested"`
+	// BootTime is the time at which the host last booted, estimated from
+	// its uptime. It is nil until the host has reported its uptime.
+	BootTime *time.Time `json:"boot_time,omitempty" db:"boot_time"`
+	// NoisyTime is the last time the host exceeded the log rate limit. It
+	// is nil if the host has never exceeded the limit.
+	NoisyTime *time.Time `json:"noisy_time,omitempty" db:"noisy_time"`
+	// DroppedLogRows is the number of log rows from the host that were
+	// dropped for exceeding the log rate limit.
+	DroppedLogRows uint `json:"dropped_log_rows" db:"dropped_log_rows"`
+	// TruncatedResultsTime is the last time results from the host were
+	// truncated for exceeding the max result rows of a query. It is nil if
+	// the results of the host have never been truncated.
+	TruncatedResultsTime *time.Time `json:"truncated_results_time,omitempty" db:"truncated_results_time"`
+	// TruncatedResultRows is the number of result rows from the host that
+	// were removed for exceeding the max result rows of a query.
+	TruncatedResultRows uint `json:"truncated_result_rows" db:"truncated_result_rows"`
+	// MaintenanceUntil is the end of the maintenance window of the host,
+	// during which it is not considered offline. It is nil if the host is
+	// not in maintenance.
+	MaintenanceUntil *time.Time `json:"maintenance_until,omitempty" db:"maintenance_until"`
+	// Pending is set for hosts that enrolled while enrollment approval was
+	// required, until they are approved by an admin. Pending hosts are
+	// served an empty config.
+	Pending bool `json:"pending" db:"pending"`
+	// DiskEncryption is whether the system disk of the host is encrypted,
+	// as reported by FileVault on macOS and BitLocker on Windows. It is
+	// one of the DiskEncryption constants.
+	DiskEncryption string `json:"disk_encryption" db:"disk_encryption"`
+}
+
+// The disk encryption statuses of hosts. Hosts are unknown until they
+// report the status, and remain so on platforms where it is not collected.
+const (
+	DiskEncryptionEncrypted   = "encrypted"
+	DiskEncryptionUnencrypted = "unencrypted"
+	DiskEncryptionUnknown     = "unknown"
+)
+
+// DiskEncryptionStatuses are the disk encryption statuses of hosts.
+var DiskEncryptionStatuses = []string{
+	DiskEncryptionEncrypted,
+	DiskEncryptionUnencrypted,
+	DiskEncryptionUnknown,
+}
+
+// IsDiskEncryptionStatus returns true if status is a disk encryption status.
+func IsDiskEncryptionStatus(status string) bool {
+	for _, s := range DiskEncryptionStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
 }
 
 // HostSummary is a structure which represents a data summary about the total
@@ -125,7 +433,45 @@ type HostSummary struct {
 	OnlineCount  uint `json:"online_count"`
 	OfflineCount uint `json:"offline_count"`
 	MIACount     uint `json:"mia_count"`
-	NewCount     uint `json:"new_count"`
+	// MaintenanceCount is the number of hosts that would otherwise be
+	// offline or MIA, but are in a maintenance window.
+	MaintenanceCount uint `json:"maintenance_count"`
+	NewCount         uint `json:"new_count"`
+}
+
+// HostAggregateLabel groups hosts by the labels they are members of in
+// AggregateHosts.
+const HostAggregateLabel = "label"
+
+// HostAggregateColumns are the host columns that AggregateHosts can group by.
+// The column name is used in the SQL query, so only these values are allowed.
+var HostAggregateColumns = []string{
+	"platform",
+	"platform_like",
+	"os_version",
+	"osquery_version",
+	"cpu_type",
+	"hardware_vendor",
+	"hardware_model",
+	"enroll_secret_name",
+	"disk_encryption",
+}
+
+// IsHostAggregateColumn returns true if hosts can be aggregated by column.
+func IsHostAggregateColumn(column string) bool {
+	for _, c := range HostAggregateColumns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// HostAggregate is the number of hosts sharing a value of the grouped column
+// or label.
+type HostAggregate struct {
+	Value string `json:"value" db:"value"`
+	Count uint   `json:"count" db:"count"`
 }
 
 // ResetPrimaryNetwork determines the primary network interface by picking the
@@ -198,17 +544,26 @@ func (h *Host) Status(now time.Time) string {
 
 	// Add a small buffer to prevent flapping
 	onlineInterval += OnlineIntervalBuffer
+	offline := h.SeenTime.Add(time.Duration(onlineInterval) * time.Second).Before(now)
 
 	switch {
+	case offline && h.InMaintenance(now):
+		return StatusMaintenance
 	case h.SeenTime.Add(MIADuration).Before(now):
 		return StatusMIA
-	case h.SeenTime.Add(time.Duration(onlineInterval) * time.Second).Before(now):
+	case offline:
 		return StatusOffline
 	default:
 		return StatusOnline
 	}
 }
 
+// InMaintenance returns true if the maintenance window of the host has not
+// ended as of now.
+func (h *Host) InMaintenance(now time.Time) bool {
+	return h.MaintenanceUntil != nil && now.Before(*h.MaintenanceUntil)
+}
+
 func (h *Host) IsNew(now time.Time) bool {
 	withDuration := h.CreatedAt.Add(NewDuration)
 	if withDuration.After(now) ||
@@ -0,0 +1,100 @@
+package kolide
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// HostCursor identifies the position of a host within a sorted list of
+// hosts. It is used for keyset pagination of ListHosts, and is passed to
+// clients as an opaque string.
+type HostCursor struct {
+	// OrderKey is the column that the hosts were sorted by.
+	OrderKey string `json:"k,omitempty"`
+	// Value is the JSON encoded value of the OrderKey column for the host.
+	Value json.RawMessage `json:"v,omitempty"`
+	// ID is the ID of the host, used to break ties between hosts with the
+	// same Value.
+	ID uint `json:"id"`
+}
+
+// NewHostCursor returns the encoded cursor pointing at host when hosts are
+// sorted by orderKey.
+func NewHostCursor(host *Host, orderKey string) (string, error) {
+	cursor := HostCursor{OrderKey: orderKey, ID: host.ID}
+	if orderKey != "" {
+		field, ok := hostColumnField(orderKey)
+		if !ok {
+			return "", errors.Errorf("unknown host column %q", orderKey)
+		}
+		value, err := json.Marshal(reflect.ValueOf(host).Elem().FieldByIndex(field.Index).Interface())
+		if err != nil {
+			return "", errors.Wrap(err, "marshal cursor value")
+		}
+		cursor.Value = value
+	}
+
+	b, err := json.Marshal(cursor)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal cursor")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// ParseHostCursor decodes a cursor previously returned by NewHostCursor.
+func ParseHostCursor(s string) (*HostCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode cursor")
+	}
+	var cursor HostCursor
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return nil, errors.Wrap(err, "unmarshal cursor")
+	}
+	return &cursor, nil
+}
+
+// SortValue returns the value of the OrderKey column, decoded into the type
+// of the corresponding Host field.
+func (c *HostCursor) SortValue() (interface{}, error) {
+	field, ok := hostColumnField(c.OrderKey)
+	if !ok {
+		return nil, errors.Errorf("unknown host column %q", c.OrderKey)
+	}
+	value := reflect.New(field.Type)
+	if err := json.Unmarshal(c.Value, value.Interface()); err != nil {
+		return nil, errors.Wrap(err, "unmarshal cursor value")
+	}
+	return value.Elem().Interface(), nil
+}
+
+// hostColumnField finds the field of Host that is stored in the given
+// database column, following the same naming rules as sqlx.
+func hostColumnField(column string) (reflect.StructField, bool) {
+	var find func(t reflect.Type, index []int) (reflect.StructField, bool)
+	find = func(t reflect.Type, index []int) (reflect.StructField, bool) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			f.Index = append(append([]int{}, index...), i)
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				if found, ok := find(f.Type, f.Index); ok {
+					return found, true
+				}
+				continue
+			}
+			name := strings.Split(f.Tag.Get("db"), ",")[0]
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			if name == column {
+				return f, true
+			}
+		}
+		return reflect.StructField{}, false
+	}
+	return find(reflect.TypeOf(Host{}), nil)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/WatchBeam/clock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestResetPrimaryNetworkNoInterfaces(t *testing.T) {
@@ -168,6 +169,29 @@ func TestHostStatus(t *testing.T) {
 
 }
 
+func TestHostStatusMaintenance(t *testing.T) {
+	mockClock := clock.NewMockClock()
+	until := mockClock.Now().Add(time.Hour)
+
+	h := Host{
+		DistributedInterval: 10,
+		ConfigTLSRefresh:    10,
+		SeenTime:            mockClock.Now().Add(-1 * time.Second),
+		MaintenanceUntil:    &until,
+	}
+	// Online hosts are reported as online during maintenance
+	assert.Equal(t, StatusOnline, h.Status(mockClock.Now()))
+
+	h.SeenTime = mockClock.Now().Add(-1 * time.Minute)
+	assert.Equal(t, StatusMaintenance, h.Status(mockClock.Now()))
+
+	h.SeenTime = mockClock.Now().Add(-31 * 24 * time.Hour)
+	assert.Equal(t, StatusMaintenance, h.Status(mockClock.Now()))
+
+	// The window has ended
+	assert.Equal(t, StatusMIA, h.Status(mockClock.Now().Add(time.Hour)))
+}
+
 func TestHostIsNew(t *testing.T) {
 	mockClock := clock.NewMockClock()
 
@@ -179,3 +203,35 @@ func TestHostIsNew(t *testing.T) {
 	host.CreatedAt = mockClock.Now().AddDate(0, 0, -2)
 	assert.False(t, host.IsNew(mockClock.Now()))
 }
+
+func TestHostCursorRoundtrip(t *testing.T) {
+	seen := time.Date(2020, 6, 1, 12, 30, 0, 0, time.UTC)
+	host := &Host{ID: 42, SeenTime: seen, HostName: "foo.local"}
+	host.CreatedAt = seen.Add(-time.Hour)
+
+	for _, tt := range []struct {
+		orderKey string
+		value    interface{}
+	}{
+		{"seen_time", seen},
+		{"host_name", "foo.local"},
+		{"created_at", seen.Add(-time.Hour)},
+	} {
+		encoded, err := NewHostCursor(host, tt.orderKey)
+		require.Nil(t, err)
+
+		cursor, err := ParseHostCursor(encoded)
+		require.Nil(t, err)
+		assert.Equal(t, uint(42), cursor.ID)
+		assert.Equal(t, tt.orderKey, cursor.OrderKey)
+
+		value, err := cursor.SortValue()
+		require.Nil(t, err)
+		assert.Equal(t, tt.value, value)
+	}
+
+	_, err := NewHostCursor(host, "not_a_column")
+	assert.NotNil(t, err)
+	_, err = ParseHostCursor("!!!")
+	assert.NotNil(t, err)
+}
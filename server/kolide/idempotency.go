@@ -0,0 +1,32 @@
+package kolide
+
+import "time"
+
+// IdempotencyCache stores the ID of the resource created by a request that
+// supplied an idempotency key, so that retries of the request return the
+// original resource rather than creating a duplicate.
+type IdempotencyCache interface {
+	// Reserve claims key for a request whose payload has the given hash,
+	// until ttl has elapsed. If the key is already claimed and has not
+	// expired, its entry is returned and reserved is false.
+	Reserve(key, hash string, ttl time.Duration) (entry IdempotencyEntry, reserved bool, err error)
+
+	// Set stores id as the resource created for the key reserved by the
+	// request, until ttl has elapsed.
+	Set(key string, id uint, ttl time.Duration) error
+
+	// Release removes the reservation of a request that failed, so that it
+	// may be retried.
+	Release(key string) error
+}
+
+// IdempotencyEntry is the state of a claimed idempotency key.
+type IdempotencyEntry struct {
+	// Hash is the hash of the payload of the request that claimed the key.
+	Hash string
+	// ID is the ID of the resource created by the request. It is only set
+	// once the request completes.
+	ID uint
+	// Pending is true while the request that claimed the key is running.
+	Pending bool
+}
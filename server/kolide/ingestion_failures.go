@@ -0,0 +1,42 @@
+package kolide
+
+import (
+	"context"
+	"time"
+)
+
+// IngestionFailureStore records the detail query results that could not be
+// ingested.
+type IngestionFailureStore interface {
+	// NewIngestionFailure records a detail query result that could not be
+	// ingested.
+	NewIngestionFailure(failure *IngestionFailure) (*IngestionFailure, error)
+	// ListIngestionFailures returns the recorded failures of every host.
+	// Supply query options using the opt parameter. See ListOptions.
+	ListIngestionFailures(opt ListOptions) ([]*IngestionFailure, error)
+	// CleanupIngestionFailures deletes the failures recorded before the
+	// given time.
+	CleanupIngestionFailures(before time.Time) error
+}
+
+// IngestionFailureService provides access to the detail query results that
+// could not be ingested.
+type IngestionFailureService interface {
+	// ListIngestionFailures returns the recorded failures, most recent first
+	// unless another order is requested in opt.
+	ListIngestionFailures(ctx context.Context, opt ListOptions) ([]*IngestionFailure, error)
+}
+
+// IngestionFailure is a detail query result reported by a host that could not
+// be parsed or stored.
+type IngestionFailure struct {
+	ID     uint `json:"id"`
+	HostID uint `json:"host_id" db:"host_id"`
+	// QueryName is the name of the detail query, without the prefix sent
+	// to osquery.
+	QueryName string `json:"query_name" db:"query_name"`
+	// Error describes why the result could not be ingested.
+	Error string `json:"error"`
+	// CreatedAt is the time at which the result was received.
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
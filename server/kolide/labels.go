@@ -2,6 +2,8 @@ package kolide
 
 import (
 	"context"
+	"database/sql/driver"
+	"encoding/json"
 	"time"
 )
 
@@ -9,6 +11,12 @@ type LabelStore interface {
 	// ApplyLabelSpecs applies a list of LabelSpecs to the datastore,
 	// creating and updating labels as necessary.
 	ApplyLabelSpecs(specs []*LabelSpec) error
+	// SyncLabelSpecs applies a list of LabelSpecs like ApplyLabelSpecs, but
+	// leaves the labels whose spec is unchanged untouched and reports the
+	// labels that were changed. When prune is true, the labels that are not
+	// in specs are deleted, other than the built-in labels. All of the
+	// changes are made in a single transaction.
+	SyncLabelSpecs(specs []*LabelSpec, prune bool) (*ApplyLabelSpecsResult, error)
 	// GetLabelSpecs returns all of the stored LabelSpecs.
 	GetLabelSpecs() ([]*LabelSpec, error)
 	// GetLabelSpec returns the spec for the named label.
@@ -24,8 +32,9 @@ type LabelStore interface {
 	// LabelQueriesForHost returns the label queries that should be executed
 	// for the given host. The cutoff is the minimum timestamp a query
 	// execution should have to be considered "fresh". Executions that are
-	// not fresh will be repeated. Results are returned in a map of label
-	// id -> query
+	// not fresh, or that were marked by RefreshModifiedLabels, will be
+	// repeated. Computed and manual labels are never included.
+	// Results are returned in a map of label id -> query
 	LabelQueriesForHost(host *Host, cutoff time.Time) (map[string]string, error)
 
 	// RecordLabelQueryExecutions saves the results of label queries. The
@@ -34,11 +43,20 @@ type LabelStore interface {
 	// execution.
 	RecordLabelQueryExecutions(host *Host, results map[uint]bool, t time.Time) error
 
+	// RefreshModifiedLabels marks the query executions of labels that were
+	// modified after the host last evaluated them, so that the label query
+	// is sent again at the next check in of the host rather than once the
+	// execution is no longer fresh. At most limit labels are marked, most
+	// recently modified first. It returns the IDs of the marked labels.
+	RefreshModifiedLabels(limit int) ([]uint, error)
+
 	// LabelsForHost returns the labels that the given host is in.
 	ListLabelsForHost(hid uint) ([]Label, error)
 
 	// ListHostsInLabel returns a slice of hosts in the label with the
-	// given ID.
+	// given ID. Membership of computed labels is evaluated against the
+	// current host attributes rather than recorded query executions, and
+	// manual labels return the hosts explicitly added to them.
 	ListHostsInLabel(lid uint) ([]Host, error)
 
 	// ListUniqueHostsInLabels returns a slice of all of the hosts in the
@@ -50,12 +68,28 @@ type LabelStore interface {
 
 	// LabelIDsByName Retrieve the IDs associated with the given labels
 	LabelIDsByName(labels []string) ([]uint, error)
+
+	// AddHostsToLabel adds the hosts to the members of a manual label.
+	// Hosts that are already members are ignored.
+	AddHostsToLabel(lid uint, hostIDs []uint) error
+	// RemoveHostsFromLabel removes the hosts from the members of a manual
+	// label. Hosts that are not members are ignored.
+	RemoveHostsFromLabel(lid uint, hostIDs []uint) error
+	// TransferHostsToLabel makes the hosts members of the manual label
+	// and removes them from every other manual label. If an enroll secret
+	// is associated with the label, the hosts' enroll secret name is set
+	// to it. If any of the hosts do not exist, nothing is changed and
+	// their IDs are returned.
+	TransferHostsToLabel(lid uint, hostIDs []uint) (missing []uint, err error)
 }
 
 type LabelService interface {
 	// ApplyLabelSpecs applies a list of LabelSpecs to the datastore,
-	// creating and updating labels as necessary.
-	ApplyLabelSpecs(ctx context.Context, specs []*LabelSpec) error
+	// creating and updating labels as necessary, and reports the labels that
+	// were changed. When prune is true, the labels that are not in specs are
+	// deleted, other than the built-in labels. Either every spec is applied
+	// or none are.
+	ApplyLabelSpecs(ctx context.Context, specs []*LabelSpec, prune bool) (*ApplyLabelSpecsResult, error)
 	// GetLabelSpecs returns all of the stored LabelSpecs.
 	GetLabelSpecs(ctx context.Context) ([]*LabelSpec, error)
 	// GetLabelSpec gets the spec for the label with the given name.
@@ -73,19 +107,43 @@ type LabelService interface {
 	// HostIDsForLabel returns ids of hosts that belong to the label identified
 	// by lid
 	HostIDsForLabel(lid uint) ([]uint, error)
+
+	// AddHostsToLabel adds the hosts to the members of the manual label
+	// identified by lid.
+	AddHostsToLabel(ctx context.Context, lid uint, hostIDs []uint) error
+	// RemoveHostsFromLabel removes the hosts from the members of the manual
+	// label identified by lid.
+	RemoveHostsFromLabel(ctx context.Context, lid uint, hostIDs []uint) error
+
+	// SyncExternalLabels periodically replaces the members of the manual
+	// labels listed by the configured label sync URL, until the context is
+	// canceled. It returns immediately when no URL is configured.
+	SyncExternalLabels(ctx context.Context)
+
+	// RecalculateLabels periodically sends the queries of labels that were
+	// modified since hosts last evaluated them back to those hosts, until
+	// the context is canceled. It returns immediately when the label
+	// recalculation interval is not positive.
+	RecalculateLabels(ctx context.Context)
 }
 
 // ModifyLabelPayload is used to change editable fields for a Label
 type ModifyLabelPayload struct {
-	Name        *string `json:"name"`
-	Description *string `json:"description"`
+	Name                 *string `json:"name"`
+	Description          *string `json:"description"`
+	DetailUpdateInterval *uint   `json:"detail_update_interval"`
 }
 
 type LabelPayload struct {
-	Name        *string `json:"name"`
-	Query       *string `json:"query"`
-	Platform    *string `json:"platform"`
-	Description *string `json:"description"`
+	Name                 *string        `json:"name"`
+	Query                *string        `json:"query"`
+	Platform             *string        `json:"platform"`
+	Description          *string        `json:"description"`
+	Criteria             *LabelCriteria `json:"criteria"`
+	DetailUpdateInterval *uint          `json:"detail_update_interval"`
+	// Manual creates a label whose members are added explicitly rather
+	// than selected by a query.
+	Manual bool `json:"manual"`
 }
 
 // LabelType is used to catagorize the kind of label
@@ -97,17 +155,75 @@ const (
 	// LabelTypeBuiltIn is for labels built into Fleet that cannot be
 	// modified by users.
 	LabelTypeBuiltIn
+	// LabelTypeComputed is for labels whose membership is computed by the
+	// server from host attributes instead of a query run by osquery.
+	LabelTypeComputed
+	// LabelTypeManual is for labels whose members are an explicit list of
+	// hosts maintained by admins.
+	LabelTypeManual
 )
 
+// LabelCriteria describes the host attributes evaluated for membership in a
+// computed label. A host must satisfy every criterion that is set.
+type LabelCriteria struct {
+	// Platform matches hosts reporting the given platform.
+	Platform string `json:"platform,omitempty"`
+	// Status matches hosts with the given online status (see Host.Status).
+	Status string `json:"status,omitempty"`
+	// OfflineFor matches hosts that have not been seen for at least the
+	// given number of seconds.
+	OfflineFor uint `json:"offline_for,omitempty"`
+	// OsqueryVersion matches hosts running the given osquery version.
+	OsqueryVersion string `json:"osquery_version,omitempty"`
+}
+
+// Matches returns true if the host satisfies the criteria at the given time.
+func (c LabelCriteria) Matches(host *Host, now time.Time) bool {
+	if c.Platform != "" && c.Platform != host.Platform {
+		return false
+	}
+	if c.Status != "" && c.Status != host.Status(now) {
+		return false
+	}
+	if c.OfflineFor > 0 && host.SeenTime.Add(time.Duration(c.OfflineFor)*time.Second).After(now) {
+		return false
+	}
+	if c.OsqueryVersion != "" && c.OsqueryVersion != host.OsqueryVersion {
+		return false
+	}
+	return true
+}
+
+// Value is called by the DB driver. Criteria are stored as JSON.
+func (c LabelCriteria) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan reads the JSON criteria stored in the database.
+func (c *LabelCriteria) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	}
+	return nil
+}
+
 type Label struct {
 	UpdateCreateTimestamps
 	DeleteFields
-	ID          uint      `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Query       string    `json:"query"`
-	Platform    string    `json:"platform"`
-	LabelType   LabelType `json:"label_type" db:"label_type"`
+	ID          uint           `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Query       string         `json:"query"`
+	Platform    string         `json:"platform"`
+	LabelType   LabelType      `json:"label_type" db:"label_type"`
+	Criteria    *LabelCriteria `json:"criteria,omitempty" db:"criteria"`
+	// DetailUpdateInterval overrides the interval (in seconds) at which
+	// hosts in the label refresh their details. Hosts in several labels
+	// use the shortest override. Zero means no override.
+	DetailUpdateInterval uint `json:"detail_update_interval" db:"detail_update_interval"`
 }
 
 type LabelQueryExecution struct {
@@ -118,11 +234,23 @@ type LabelQueryExecution struct {
 	HostID    uint
 }
 
+// ApplyLabelSpecsResult holds the names of the labels changed by applying
+// label specs.
+type ApplyLabelSpecsResult struct {
+	Created []string `json:"created"`
+	Updated []string `json:"updated"`
+	Deleted []string `json:"deleted"`
+}
+
 type LabelSpec struct {
 	ID          uint
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Query       string    `json:"query"`
-	Platform    string    `json:"platform,omitempty"`
-	LabelType   LabelType `json:"label_type" db:"label_type"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Query       string         `json:"query"`
+	Platform    string         `json:"platform,omitempty"`
+	LabelType   LabelType      `json:"label_type" db:"label_type"`
+	Criteria    *LabelCriteria `json:"criteria,omitempty" db:"criteria"`
+	// DetailUpdateInterval overrides the interval (in seconds) at which
+	// hosts in the label refresh their details. See Label.
+	DetailUpdateInterval uint `json:"detail_update_interval,omitempty" db:"detail_update_interval"`
 }
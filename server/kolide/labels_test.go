@@ -0,0 +1,40 @@
+package kolide
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelCriteriaMatches(t *testing.T) {
+	now := time.Now()
+	host := &Host{
+		Platform:            "darwin",
+		OsqueryVersion:      "4.3.0",
+		SeenTime:            now.Add(-48 * time.Hour),
+		DistributedInterval: 10,
+		ConfigTLSRefresh:    10,
+	}
+
+	var testCases = []struct {
+		criteria LabelCriteria
+		matches  bool
+	}{
+		{LabelCriteria{}, true},
+		{LabelCriteria{Platform: "darwin"}, true},
+		{LabelCriteria{Platform: "ubuntu"}, false},
+		{LabelCriteria{OfflineFor: 24 * 60 * 60}, true},
+		{LabelCriteria{OfflineFor: 72 * 60 * 60}, false},
+		{LabelCriteria{Status: StatusOffline}, true},
+		{LabelCriteria{Status: StatusOnline}, false},
+		{LabelCriteria{Platform: "darwin", OsqueryVersion: "4.3.0"}, true},
+		{LabelCriteria{Platform: "darwin", OsqueryVersion: "4.4.0"}, false},
+	}
+
+	for _, tt := range testCases {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.matches, tt.criteria.Matches(host, now))
+		})
+	}
+}
@@ -3,6 +3,9 @@ package kolide
 import (
 	"context"
 	"encoding/json"
+	"strconv"
+
+	"github.com/pkg/errors"
 )
 
 type OsqueryService interface {
@@ -17,7 +20,7 @@ type OsqueryService interface {
 	// for) should be returned. Returning 0 for this will not activate the
 	// feature.
 	GetDistributedQueries(ctx context.Context) (queries map[string]string, accelerate uint, err error)
-	SubmitDistributedQueryResults(ctx context.Context, results OsqueryDistributedQueryResults, statuses map[string]OsqueryStatus) (err error)
+	SubmitDistributedQueryResults(ctx context.Context, results OsqueryDistributedQueryResults, statuses map[string]OsqueryStatus, stats map[string]*OsqueryDistributedQueryStats) (err error)
 	SubmitStatusLogs(ctx context.Context, logs []json.RawMessage) (err error)
 	SubmitResultLogs(ctx context.Context, logs []json.RawMessage) (err error)
 }
@@ -35,6 +38,17 @@ const (
 	StatusOK OsqueryStatus = 0
 )
 
+// OsqueryDistributedQueryStats are the resources a host reports having used
+// to run a distributed query. osquery only reports them from version 5.
+type OsqueryDistributedQueryStats struct {
+	// WallTimeMs is the time the host took to run the query, in
+	// milliseconds.
+	WallTimeMs uint64 `json:"wall_time_ms"`
+	UserTime   uint64 `json:"user_time"`
+	SystemTime uint64 `json:"system_time"`
+	Memory     uint64 `json:"memory"`
+}
+
 // QueryContent is the format of a query stanza in an osquery configuration.
 type QueryContent struct {
 	Query       string  `json:"query"`
@@ -52,6 +66,24 @@ type PermissiveQueryContent struct {
 	Interval interface{} `json:"interval"`
 }
 
+// IntervalSeconds returns the query interval, which osquery accepts as
+// either a number or a string.
+func (q PermissiveQueryContent) IntervalSeconds() (uint, error) {
+	switch i := q.Interval.(type) {
+	case string:
+		u64, err := strconv.ParseUint(i, 10, 32)
+		if err != nil {
+			return 0, errors.Wrap(err, "converting interval from string to uint")
+		}
+		return uint(u64), nil
+	case uint:
+		return i, nil
+	case float64:
+		return uint(i), nil
+	}
+	return 0, nil
+}
+
 // Queries is a helper which represents the format of a set of queries in a pack.
 type Queries map[string]QueryContent
 
@@ -9,11 +9,23 @@ type OsqueryOptionsStore interface {
 	ApplyOptions(options *OptionsSpec) error
 	GetOptions() (*OptionsSpec, error)
 	OptionsForPlatform(platform string) (json.RawMessage, error)
+	// LabelOptionsForHost returns the options overrides of the labels that
+	// the host is a member of, keyed by label name.
+	LabelOptionsForHost(hostID uint) (map[string]json.RawMessage, error)
+	// IntervalsForPlatform returns the interval overrides of the platform,
+	// one of IntervalPlatforms, or nil if none are set.
+	IntervalsForPlatform(platform string) (*OsqueryIntervals, error)
 }
 
 type OsqueryOptionsService interface {
 	ApplyOptionsSpec(ctx context.Context, spec *OptionsSpec) error
 	GetOptionsSpec(ctx context.Context) (*OptionsSpec, error)
+	// GenerateOsqueryFlagfile returns an osquery flagfile configuring osqueryd
+	// to enroll with and be managed by this Fleet server. When enrollSecret is
+	// provided it must be an active enroll secret. The secret itself is not
+	// included in the flagfile, and should be deployed to the
+	// --enroll_secret_path location.
+	GenerateOsqueryFlagfile(ctx context.Context, enrollSecret string) ([]byte, error)
 }
 
 type OptionsObject struct {
@@ -28,15 +40,36 @@ type OptionsSpec struct {
 
 type OptionsOverrides struct {
 	Platforms map[string]json.RawMessage `json:"platforms,omitempty"`
+	// Labels holds osquery options keyed by label name. The options of
+	// each label the host is a member of are merged on top of the options
+	// of the default or platform config, in order of label name, so that
+	// the label that sorts last takes precedence.
+	Labels map[string]json.RawMessage `json:"labels,omitempty"`
+	// Intervals holds polling intervals keyed by one of IntervalPlatforms.
+	// They are set on top of the options of the default or platform config
+	// of the hosts with the platform, and below the label options.
+	Intervals map[string]OsqueryIntervals `json:"intervals,omitempty"`
 }
 
+// OsqueryIntervals are the intervals, in seconds, at which osquery polls
+// for its config and distributed queries and sends its logs. The intervals
+// that are not set are left to the osquery options.
+type OsqueryIntervals struct {
+	ConfigRefresh       *int `json:"config_refresh,omitempty"`
+	DistributedInterval *int `json:"distributed_interval,omitempty"`
+	LoggerTLSPeriod     *int `json:"logger_tls_period,omitempty"`
+}
+
+// IntervalPlatforms are the platforms that interval overrides can be set
+// for. Hosts on any Linux distribution use the linux intervals.
+var IntervalPlatforms = []string{"darwin", "windows", "linux"}
+
 const (
 	OptionsKind = "Options"
 )
 
 // OptionOverrideType is used to designate which override type a given set of
-// options is used for. Currently the only supported override type is by
-// platform.
+// options is used for.
 type OptionOverrideType int
 
 const (
@@ -47,4 +80,11 @@ const (
 	// platform-specific config override (with precedence over the default
 	// config).
 	OptionOverrideTypePlatform
+	// OptionOverrideTypeLabel indicates that this is a set of options
+	// merged into the config of the hosts that are members of a label.
+	OptionOverrideTypeLabel
+	// OptionOverrideTypeIntervals indicates that this is a set of
+	// OsqueryIntervals set on top of the config of the hosts on a
+	// platform.
+	OptionOverrideTypeIntervals
 )
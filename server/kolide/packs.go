@@ -2,6 +2,11 @@ package kolide
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/guregu/null.v3"
 )
 
 // PackStore is the datastore interface for managing query packs.
@@ -60,6 +65,11 @@ type PackStore interface {
 	// ListExplicitHostsInPack lists the IDs of hosts that have been manually
 	// associated with a query pack.
 	ListExplicitHostsInPack(pid uint, opt ListOptions) ([]uint, error)
+
+	// ListHostsMissingPack lists the hosts matching opt that should execute
+	// the pack, but have not reported stats for any scheduled query in the
+	// pack. Cursor pagination is not supported.
+	ListHostsMissingPack(pid uint, opt HostListOptions) ([]Host, error)
 }
 
 // PackService is the service interface for managing query packs.
@@ -71,6 +81,17 @@ type PackService interface {
 	GetPackSpecs(ctx context.Context) ([]*PackSpec, error)
 	// GetPackSpec gets the spec for the pack with the given name.
 	GetPackSpec(ctx context.Context, name string) (*PackSpec, error)
+	// ExportPack returns a portable spec for the pack with the given ID,
+	// including its scheduled queries and label targets.
+	ExportPack(ctx context.Context, id uint) (*PackSpec, error)
+	// ImportPack creates or updates the pack described by the spec,
+	// matching existing packs by name. Queries and labels are referenced by
+	// name and must already exist.
+	ImportPack(ctx context.Context, spec *PackSpec) (*Pack, error)
+	// ImportPackFromURL fetches the osquery pack file at url and imports
+	// it, creating any of its queries that do not already exist by name.
+	// The file may also be an osquery config containing inline packs.
+	ImportPackFromURL(ctx context.Context, url string) ([]*Pack, error)
 
 	// NewPack creates a new pack in the datastore.
 	NewPack(ctx context.Context, p PackPayload) (pack *Pack, err error)
@@ -78,6 +99,10 @@ type PackService interface {
 	// ModifyPack modifies an existing pack in the datastore.
 	ModifyPack(ctx context.Context, id uint, p PackPayload) (pack *Pack, err error)
 
+	// ClonePack copies the pack with the given ID, along with its scheduled
+	// queries and targets, to a new disabled pack named newName.
+	ClonePack(ctx context.Context, sourcePackID uint, newName string) (pack *Pack, err error)
+
 	// ListPacks lists all packs in the application.
 	ListPacks(ctx context.Context, opt ListOptions) (packs []*Pack, err error)
 
@@ -117,6 +142,11 @@ type PackService interface {
 	// ListExplicitHostsInPack lists the IDs of hosts that have been manually associated
 	// with a query pack.
 	ListExplicitHostsInPack(ctx context.Context, pid uint, opt ListOptions) (hosts []uint, err error)
+
+	// HostsMissingPack lists the hosts that should execute the pack based on
+	// its targets, but whose last reported osquery schedule does not include
+	// any of the pack's queries. These hosts have a stale or stuck config.
+	HostsMissingPack(ctx context.Context, packID uint, opt HostListOptions) (hosts []Host, err error)
 }
 
 // Pack is the structure which represents an osquery query pack.
@@ -128,25 +158,112 @@ type Pack struct {
 	Description string `json:"description"`
 	Platform    string `json:"platform"`
 	Disabled    bool   `json:"disabled"`
+	// LoggerPlugin is the result log plugin that the results of the pack's
+	// queries are written to. The default result log plugin is used when
+	// it is empty.
+	LoggerPlugin string `json:"logger_plugin" db:"logger_plugin"`
+	// WindowStartHour and WindowEndHour, when set, limit the scheduling of
+	// the pack's queries on hosts to the hours from the start hour up to
+	// the end hour, in UTC. See InScheduleWindow.
+	WindowStartHour *uint `json:"window_start_hour" db:"window_start_hour"`
+	WindowEndHour   *uint `json:"window_end_hour" db:"window_end_hour"`
+	// WindowDays is a comma-separated list of the days of the week, such as
+	// "sat,sun", on which the schedule window applies. The window applies
+	// every day when it is empty.
+	WindowDays string `json:"window_days" db:"window_days"`
+}
+
+// InScheduleWindow returns true if the pack's queries are scheduled at t. The
+// window is evaluated in UTC, as the timezones of hosts are not known. A
+// window with an end hour before its start hour spans midnight, and belongs
+// to the day on which it starts.
+func (p Pack) InScheduleWindow(t time.Time) bool {
+	if p.WindowStartHour == nil || p.WindowEndHour == nil {
+		return true
+	}
+	t = t.UTC()
+	start, end, hour := *p.WindowStartHour, *p.WindowEndHour, uint(t.Hour())
+	day := t.Weekday()
+	switch {
+	case start < end:
+		if hour < start || hour >= end {
+			return false
+		}
+	case hour >= start:
+	case hour < end:
+		// The window started on the previous day
+		day = (day + 6) % 7
+	default:
+		return false
+	}
+	if p.WindowDays == "" {
+		return true
+	}
+	days, err := ParseWeekdays(p.WindowDays)
+	if err != nil {
+		return false
+	}
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseWeekdays parses a comma-separated list of three letter day names, such
+// as "mon,tue".
+func ParseWeekdays(days string) ([]time.Weekday, error) {
+	var weekdays []time.Weekday
+	for _, name := range strings.Split(days, ",") {
+		day, ok := weekdayNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown day '%s', must be one of sun, mon, tue, wed, thu, fri or sat", name)
+		}
+		weekdays = append(weekdays, day)
+	}
+	return weekdays, nil
 }
 
 // PackPayload is the struct which is used to create/update packs.
 type PackPayload struct {
-	Name        *string `json:"name"`
-	Description *string `json:"description"`
-	Platform    *string `json:"platform"`
-	Disabled    *bool   `json:"disabled"`
-	HostIDs     *[]uint `json:"host_ids"`
-	LabelIDs    *[]uint `json:"label_ids"`
+	Name         *string `json:"name"`
+	Description  *string `json:"description"`
+	Platform     *string `json:"platform"`
+	Disabled     *bool   `json:"disabled"`
+	LoggerPlugin *string `json:"logger_plugin"`
+	HostIDs      *[]uint `json:"host_ids"`
+	LabelIDs     *[]uint `json:"label_ids"`
+	// WindowStartHour and WindowEndHour are cleared by null, so that the
+	// pack is scheduled at all times.
+	WindowStartHour *null.Int `json:"window_start_hour"`
+	WindowEndHour   *null.Int `json:"window_end_hour"`
+	WindowDays      *string   `json:"window_days"`
 }
 
 type PackSpec struct {
-	ID          uint            `json:"id,omitempty"`
-	Name        string          `json:"name"`
-	Description string          `json:"description,omitempty"`
-	Platform    string          `json:"platform,omitempty"`
-	Targets     PackSpecTargets `json:"targets,omitempty"`
-	Queries     []PackSpecQuery `json:"queries,omitempty"`
+	ID           uint   `json:"id,omitempty"`
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	Platform     string `json:"platform,omitempty"`
+	LoggerPlugin string `json:"logger_plugin,omitempty" db:"logger_plugin"`
+	// WindowStartHour, WindowEndHour and WindowDays define the schedule
+	// window of the pack, as in Pack.
+	WindowStartHour *uint           `json:"window_start_hour,omitempty" db:"window_start_hour"`
+	WindowEndHour   *uint           `json:"window_end_hour,omitempty" db:"window_end_hour"`
+	WindowDays      string          `json:"window_days,omitempty" db:"window_days"`
+	Targets         PackSpecTargets `json:"targets,omitempty"`
+	Queries         []PackSpecQuery `json:"queries,omitempty"`
 }
 
 type PackSpecTargets struct {
@@ -163,6 +280,27 @@ type PackSpecQuery struct {
 	Shard       *uint   `json:"shard,omitempty"`
 	Platform    *string `json:"platform,omitempty"`
 	Version     *string `json:"version,omitempty"`
+	Disabled    bool    `json:"disabled,omitempty"`
+	// MaxResultRows overrides the osquery.max_result_rows default.
+	MaxResultRows *uint `json:"max_result_rows,omitempty" db:"max_result_rows"`
+	// SampleRate is the fraction of result rows kept, between 0 and 1.
+	SampleRate *float64 `json:"sample_rate,omitempty" db:"sample_rate"`
+	// ColumnMapping selects, renames and orders the logged columns.
+	ColumnMapping *ColumnMapping `json:"column_mapping,omitempty" db:"column_mapping"`
+}
+
+// HostPackInfo is a pack that targets a host, with the targets of the pack
+// that cause it to apply to the host.
+type HostPackInfo struct {
+	Pack *Pack `json:"pack"`
+	// Labels are the labels targeted by the pack that the host is a
+	// member of.
+	Labels []*Label `json:"labels"`
+	// HostTargeted is true if the pack targets the host itself.
+	HostTargeted bool `json:"host_targeted"`
+	// Disabled is true if the pack is disabled, in which case its queries
+	// are not run by the host.
+	Disabled bool `json:"disabled"`
 }
 
 // PackTarget associates a pack with either a host or a label
@@ -0,0 +1,50 @@
+package kolide
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackInScheduleWindow(t *testing.T) {
+	hour := func(h uint) *uint { return &h }
+	// 2020-07-29 is a Wednesday
+	at := func(day, h int) time.Time { return time.Date(2020, 7, day, h, 30, 0, 0, time.UTC) }
+
+	var windowTests = []struct {
+		pack     Pack
+		at       time.Time
+		expected bool
+	}{
+		{Pack{}, at(29, 12), true},
+		{Pack{WindowStartHour: hour(1), WindowEndHour: hour(5)}, at(29, 1), true},
+		{Pack{WindowStartHour: hour(1), WindowEndHour: hour(5)}, at(29, 5), false},
+		{Pack{WindowStartHour: hour(1), WindowEndHour: hour(5)}, at(29, 0), false},
+		{Pack{WindowStartHour: hour(22), WindowEndHour: hour(6)}, at(29, 23), true},
+		{Pack{WindowStartHour: hour(22), WindowEndHour: hour(6)}, at(29, 3), true},
+		{Pack{WindowStartHour: hour(22), WindowEndHour: hour(6)}, at(29, 12), false},
+		{Pack{WindowStartHour: hour(0), WindowEndHour: hour(0), WindowDays: "sat,sun"}, at(29, 12), false},
+		{Pack{WindowStartHour: hour(0), WindowEndHour: hour(0), WindowDays: "sat,sun"}, at(26, 12), true},
+		{Pack{WindowStartHour: hour(1), WindowEndHour: hour(5), WindowDays: "wed"}, at(29, 2), true},
+		{Pack{WindowStartHour: hour(1), WindowEndHour: hour(5), WindowDays: "thu"}, at(29, 2), false},
+		// The early hours of Thursday belong to the window starting on Wednesday
+		{Pack{WindowStartHour: hour(22), WindowEndHour: hour(6), WindowDays: "wed"}, at(30, 3), true},
+		{Pack{WindowStartHour: hour(22), WindowEndHour: hour(6), WindowDays: "wed"}, at(29, 3), false},
+		// Times are compared in UTC
+		{Pack{WindowStartHour: hour(1), WindowEndHour: hour(5)}, at(29, 2).In(time.FixedZone("PDT", -7*3600)), true},
+	}
+	for _, tt := range windowTests {
+		assert.Equal(t, tt.expected, tt.pack.InScheduleWindow(tt.at), "%+v at %s", tt.pack, tt.at)
+	}
+}
+
+func TestParseWeekdays(t *testing.T) {
+	days, err := ParseWeekdays("mon, Tue,sun")
+	require.Nil(t, err)
+	assert.Equal(t, []time.Weekday{time.Monday, time.Tuesday, time.Sunday}, days)
+
+	_, err = ParseWeekdays("monday")
+	assert.NotNil(t, err)
+}
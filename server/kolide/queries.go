@@ -3,6 +3,7 @@ package kolide
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/pkg/errors"
@@ -19,7 +20,8 @@ type QueryStore interface {
 	NewQuery(query *Query, opts ...OptionalArg) (*Query, error)
 	// SaveQuery saves changes to an existing query object.
 	SaveQuery(query *Query) error
-	// DeleteQuery deletes an existing query object.
+	// DeleteQuery soft deletes an existing query object. Soft deleted
+	// queries can be restored with RestoreQuery until they are purged.
 	DeleteQuery(name string) error
 	// DeleteQueries (soft) deletes the existing query objects with the
 	// provided IDs. The number of deleted queries is returned along with
@@ -30,9 +32,19 @@ type QueryStore interface {
 	Query(id uint) (*Query, error)
 	// ListQueries returns a list of queries with the provided sorting and
 	// paging options. Associated packs should also be loaded.
-	ListQueries(opt ListOptions) ([]*Query, error)
+	ListQueries(opt ListQueryOptions) ([]*Query, error)
 	// QueryByName looks up a query by name.
 	QueryByName(name string, opts ...OptionalArg) (*Query, error)
+	// RestoreQuery restores the soft deleted query with the provided ID.
+	RestoreQuery(id uint) error
+	// PurgeDeletedQueries permanently deletes queries that were soft
+	// deleted before the provided time, and removes them from the packs
+	// that schedule them. The number of purged queries is returned along
+	// with any error.
+	PurgeDeletedQueries(before time.Time) (uint, error)
+	// ListQueryTags returns the distinct tags of saved queries, with the
+	// number of queries that have each tag.
+	ListQueryTags() ([]QueryTag, error)
 }
 
 type QueryService interface {
@@ -47,7 +59,7 @@ type QueryService interface {
 	// ListQueries returns a list of saved queries. Note only saved queries
 	// should be returned (those that are created for distributed queries
 	// but not saved should not be returned).
-	ListQueries(ctx context.Context, opt ListOptions) ([]*Query, error)
+	ListQueries(ctx context.Context, opt ListQueryOptions) ([]*Query, error)
 	GetQuery(ctx context.Context, id uint) (*Query, error)
 	NewQuery(ctx context.Context, p QueryPayload) (*Query, error)
 	ModifyQuery(ctx context.Context, id uint, p QueryPayload) (*Query, error)
@@ -58,12 +70,43 @@ type QueryService interface {
 	// provided IDs. The number of deleted queries is returned along with
 	// any error.
 	DeleteQueries(ctx context.Context, ids []uint) (uint, error)
+	// RestoreQuery restores a soft deleted query, returning the restored
+	// query.
+	RestoreQuery(ctx context.Context, id uint) (*Query, error)
+	// ListQueryTags returns the distinct tags of saved queries, with the
+	// number of queries that have each tag, most used first.
+	ListQueryTags(ctx context.Context) ([]QueryTag, error)
+	// ValidateQuerySQL checks the SQL of a query for syntax errors and
+	// unknown tables. The SQL is valid if it has no syntax errors, and
+	// the problems include unknown tables as warnings.
+	ValidateQuerySQL(ctx context.Context, sql string) (valid bool, problems []string, err error)
+}
+
+// ListQueryOptions is used to paginate and filter the results of
+// ListQueries.
+type ListQueryOptions struct {
+	ListOptions
+	// IncludeDeleted includes soft deleted queries in the results.
+	IncludeDeleted bool
+	// AuthorID, when non-nil, limits the results to queries written by the
+	// user with this ID.
+	AuthorID *uint
+	// MatchQuery, when non-empty, limits the results to queries with a name
+	// or SQL text containing this string, ignoring case.
+	MatchQuery string
+	// Tags, when non-empty, limits the results to queries that have at
+	// least one of these tags.
+	Tags []string
 }
 
 type QueryPayload struct {
 	Name        *string
 	Description *string
 	Query       *string
+	CacheTTL    *uint `json:"cache_ttl"`
+	// Tags replaces the tags of the query. Tags that are not yet used by
+	// any query are created.
+	Tags *[]string
 }
 
 type Query struct {
@@ -74,13 +117,26 @@ type Query struct {
 	Description string `json:"description"`
 	Query       string `json:"query"`
 	Saved       bool   `json:"saved"`
-	AuthorID    *uint  `json:"author_id" db:"author_id"`
+	// CacheTTL is the number of seconds for which the results of running
+	// the saved query are cached and returned for campaigns with the same
+	// targets. Zero disables caching.
+	CacheTTL uint  `json:"cache_ttl" db:"cache_ttl"`
+	AuthorID *uint `json:"author_id" db:"author_id"`
 	// AuthorName is retrieved with a join to the users table in the MySQL
 	// backend (using AuthorID)
 	AuthorName string `json:"author_name" db:"author_name"`
 	// Packs is loaded when retrieving queries, but is stored in a join
 	// table in the MySQL backend.
 	Packs []Pack `json:"packs" db:"-"`
+	// Tags organize saved queries. They are stored in a join table in the
+	// MySQL backend.
+	Tags []string `json:"tags" db:"-"`
+}
+
+// QueryTag is a tag of saved queries and the number of queries that have it.
+type QueryTag struct {
+	Tag   string `json:"tag" db:"tag"`
+	Count uint   `json:"count" db:"count"`
 }
 
 const (
@@ -2,6 +2,7 @@ package kolide
 
 import (
 	"context"
+	"time"
 )
 
 // QueryResultStore defines functions for sending and receiving distributed
@@ -9,7 +10,9 @@ import (
 // pubsub.
 type QueryResultStore interface {
 	// WriteResult writes a distributed query result submitted by an
-	// osqueryd client
+	// osqueryd client. The result is assigned the next sequence number for
+	// its campaign and retained so that it can be replayed to a client that
+	// reconnects.
 	WriteResult(result DistributedQueryResult) error
 
 	// ReadChannel returns a channel to be read for incoming distributed
@@ -17,7 +20,22 @@ type QueryResultStore interface {
 	// DistributedQueryResult or error
 	ReadChannel(ctx context.Context, query DistributedQueryCampaign) (<-chan interface{}, error)
 
+	// ReplayResults returns the retained results for the campaign with a
+	// sequence number greater than after, ordered by sequence number. Only
+	// the most recent results of each campaign are retained.
+	ReplayResults(campaignID uint, after uint64) ([]DistributedQueryResult, error)
+
 	// HealthCheck returns nil if the store is functioning properly, or an
 	// error describing the problem.
 	HealthCheck() error
 }
+
+// ResultsCache stores the aggregated results of saved query campaigns so that
+// they can be returned without running the query again.
+type ResultsCache interface {
+	// Get returns the results stored for key, if they have not expired.
+	Get(key string) (results []DistributedQueryResult, ok bool, err error)
+
+	// Set stores results for key until ttl has elapsed.
+	Set(key string, results []DistributedQueryResult, ttl time.Duration) error
+}
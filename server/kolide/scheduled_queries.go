@@ -2,6 +2,9 @@ package kolide
 
 import (
 	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"time"
 
 	"gopkg.in/guregu/null.v3"
 )
@@ -12,6 +15,20 @@ type ScheduledQueryStore interface {
 	SaveScheduledQuery(sq *ScheduledQuery) (*ScheduledQuery, error)
 	DeleteScheduledQuery(id uint) error
 	ScheduledQuery(id uint) (*ScheduledQuery, error)
+	// ModifyScheduledQueriesInPack applies the patch to every scheduled
+	// query in the pack in a single statement, returning the number of
+	// scheduled queries in the pack.
+	ModifyScheduledQueriesInPack(packID uint, patch ScheduledQueryPatch) (int, error)
+
+	// SaveScheduledQueryStats replaces the stats reported by the host for
+	// its scheduled queries.
+	SaveScheduledQueryStats(hostID uint, stats []ScheduledQueryStats) error
+	// ScheduledQueryStats returns the stats of the scheduled queries in the
+	// pack, aggregated across hosts.
+	ScheduledQueryStats(packID uint) ([]*AggregatedScheduledQueryStats, error)
+	// ListScheduledQueryStatsForHost returns the stats reported by the host
+	// for its scheduled queries, in order of scheduled query ID.
+	ListScheduledQueryStatsForHost(hostID uint) ([]ScheduledQueryStats, error)
 }
 
 type ScheduledQueryService interface {
@@ -20,6 +37,13 @@ type ScheduledQueryService interface {
 	ScheduleQuery(ctx context.Context, sq *ScheduledQuery) (query *ScheduledQuery, err error)
 	DeleteScheduledQuery(ctx context.Context, id uint) (err error)
 	ModifyScheduledQuery(ctx context.Context, id uint, p ScheduledQueryPayload) (query *ScheduledQuery, err error)
+	// ModifyScheduledQueriesInPack applies the patch to every scheduled
+	// query in the pack at once, returning the number of scheduled queries
+	// modified.
+	ModifyScheduledQueriesInPack(ctx context.Context, packID uint, patch ScheduledQueryPatch) (modified int, err error)
+	// ScheduledQueryStats returns the performance stats of the scheduled
+	// queries in the pack, aggregated across the hosts that reported them.
+	ScheduledQueryStats(ctx context.Context, packID uint) (stats []*AggregatedScheduledQueryStats, err error)
 }
 
 type ScheduledQuery struct {
@@ -38,6 +62,58 @@ type ScheduledQuery struct {
 	Platform    *string `json:"platform,omitempty"`
 	Version     *string `json:"version,omitempty"`
 	Shard       *uint   `json:"shard"`
+	// Disabled scheduled queries remain in the pack but are not sent to
+	// hosts.
+	Disabled bool `json:"disabled"`
+	// MaxResultRows is the maximum number of rows kept from each result
+	// of the query on a host, overriding the osquery.max_result_rows
+	// default when set. Zero allows any number of rows.
+	MaxResultRows *uint `json:"max_result_rows" db:"max_result_rows"`
+	// SampleRate is the fraction, between 0 and 1, of the result rows of
+	// the query that are kept. All rows are kept when it is not set.
+	SampleRate *float64 `json:"sample_rate" db:"sample_rate"`
+	// ColumnMapping selects, renames and orders the columns of the result
+	// rows of the query before they are logged. Rows are logged as
+	// reported by osquery when it is not set.
+	ColumnMapping *ColumnMapping `json:"column_mapping" db:"column_mapping"`
+}
+
+// ColumnMapping is the list of columns kept in the result rows of a scheduled
+// query, in the order they are logged. Columns that are not listed are
+// dropped.
+type ColumnMapping []ColumnMap
+
+// ColumnMap keeps a column of the result rows of a scheduled query.
+type ColumnMap struct {
+	// Column is the name of the column in the rows reported by osquery.
+	Column string `json:"column"`
+	// As is the name the column is logged with. The column keeps its
+	// name when it is empty.
+	As string `json:"as,omitempty"`
+}
+
+// Name returns the name the column is logged with.
+func (m ColumnMap) Name() string {
+	if m.As != "" {
+		return m.As
+	}
+	return m.Column
+}
+
+// Value is called by the DB driver. The mapping is stored as JSON.
+func (m ColumnMapping) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// Scan reads the JSON mapping stored in the database.
+func (m *ColumnMapping) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	}
+	return nil
 }
 
 type ScheduledQueryPayload struct {
@@ -49,4 +125,55 @@ type ScheduledQueryPayload struct {
 	Platform *string   `json:"platform"`
 	Version  *string   `json:"version"`
 	Shard    *null.Int `json:"shard"`
+	Disabled *bool     `json:"disabled"`
+	// MaxResultRows is cleared by null, so that the default applies.
+	MaxResultRows *null.Int `json:"max_result_rows"`
+	// SampleRate is cleared by null, so that all rows are kept.
+	SampleRate *null.Float `json:"sample_rate"`
+	// ColumnMapping is cleared by an empty list, so that rows are logged
+	// as reported by osquery.
+	ColumnMapping *ColumnMapping `json:"column_mapping"`
+}
+
+// ScheduledQueryPatch holds the changes applied to all of the scheduled
+// queries in a pack. Nil fields are left unchanged.
+type ScheduledQueryPatch struct {
+	// Interval replaces the interval of each query.
+	Interval *uint `json:"interval"`
+	// IntervalMultiplier multiplies the current interval of each query,
+	// and cannot be combined with Interval.
+	IntervalMultiplier *uint `json:"interval_multiplier"`
+	Snapshot           *bool `json:"snapshot"`
+	Removed            *bool `json:"removed"`
+}
+
+// ScheduledQueryStats are the performance stats a host reports for a
+// scheduled query in the osquery_schedule table. The totals accumulate from
+// the time osqueryd started on the host.
+type ScheduledQueryStats struct {
+	HostID           uint      `json:"host_id" db:"host_id"`
+	ScheduledQueryID uint      `json:"scheduled_query_id" db:"scheduled_query_id"`
+	LastExecuted     time.Time `json:"last_executed" db:"last_executed"`
+	Executions       uint      `json:"executions" db:"executions"`
+	// WallTime is the total wall time of the executions in milliseconds.
+	WallTime uint64 `json:"wall_time" db:"wall_time"`
+	// OutputSize is the total size of the results in bytes.
+	OutputSize uint64 `json:"output_size" db:"output_size"`
+}
+
+// AggregatedScheduledQueryStats summarizes the stats of a scheduled query
+// across hosts. The averages are per execution.
+type AggregatedScheduledQueryStats struct {
+	ScheduledQueryID   uint   `json:"scheduled_query_id" db:"scheduled_query_id"`
+	ScheduledQueryName string `json:"scheduled_query_name" db:"scheduled_query_name"`
+	// Hosts is the number of hosts that reported stats for the query.
+	Hosts      uint   `json:"hosts" db:"hosts"`
+	Executions uint64 `json:"executions" db:"executions"`
+	// AverageWallTime is in milliseconds.
+	AverageWallTime float64 `json:"average_wall_time" db:"average_wall_time"`
+	// AverageOutputSize is in bytes.
+	AverageOutputSize float64 `json:"average_output_size" db:"average_output_size"`
+	// LastExecuted is the most recent execution on any host, or nil if no
+	// host has reported stats.
+	LastExecuted *time.Time `json:"last_executed" db:"last_executed"`
 }
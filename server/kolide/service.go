@@ -17,5 +17,12 @@ type Service interface {
 	ScheduledQueryService
 	OptionService
 	FileIntegrityMonitoringService
+	DecoratorService
+	HostDetailQueryService
+	ATCTableService
+	HostActivityService
+	IngestionFailureService
+	ActivityService
+	APITokenService
 	StatusService
 }
@@ -30,6 +30,11 @@ type SessionStore interface {
 
 	// Mark the currently tracked session as access to extend expiration
 	MarkSessionAccessed(session *Session) error
+
+	// DestroyExpiredSessions destroys the sessions created before
+	// createdBefore or last accessed before accessedBefore, and returns the
+	// number of sessions destroyed. A zero time applies no bound.
+	DestroyExpiredSessions(createdBefore, accessedBefore time.Time) (uint, error)
 }
 
 type Auth interface {
@@ -50,6 +55,9 @@ type SessionService interface {
 	// SSOSettings returns non sensitive single sign on information used before
 	// authentication
 	SSOSettings(ctx context.Context) (*SSOSettings, error)
+	// GenerateSAMLMetadata returns the SAML service provider metadata XML
+	// for Fleet, which can be provided to the IDP when configuring SSO.
+	GenerateSAMLMetadata(ctx context.Context) ([]byte, error)
 	Login(ctx context.Context, username, password string) (user *User, token string, err error)
 	Logout(ctx context.Context) (err error)
 	DestroySession(ctx context.Context) (err error)
@@ -58,6 +66,11 @@ type SessionService interface {
 	GetInfoAboutSession(ctx context.Context, id uint) (session *Session, err error)
 	GetSessionByKey(ctx context.Context, key string) (session *Session, err error)
 	DeleteSession(ctx context.Context, id uint) (err error)
+
+	// ReapSessions periodically destroys the sessions that have expired
+	// according to the session settings of the app config. It blocks until
+	// ctx is done.
+	ReapSessions(ctx context.Context)
 }
 
 type SSOSession struct {
@@ -10,4 +10,26 @@ type StatusService interface {
 	// StatusLiveQuery returns nil if live queries are enabled, or an
 	// error indicating the problem.
 	StatusLiveQuery(ctx context.Context) error
+
+	// Healthz checks the dependencies of the server, such as the
+	// datastore and the result store, and returns the status of each.
+	Healthz(ctx context.Context) (HealthStatus, error)
+}
+
+// HealthStatus is the health of the Fleet server and its dependencies.
+type HealthStatus struct {
+	// Healthy is true if all of the critical dependencies are healthy.
+	Healthy      bool               `json:"healthy"`
+	Dependencies []DependencyHealth `json:"dependencies"`
+}
+
+// DependencyHealth is the result of checking one dependency of the server.
+type DependencyHealth struct {
+	Name string `json:"name"`
+	// Critical dependencies must be healthy for the server to be able to
+	// serve requests. Others, such as the osquery log destinations, are
+	// reported without affecting the overall health.
+	Critical bool   `json:"critical"`
+	Healthy  bool   `json:"healthy"`
+	Error    string `json:"error,omitempty"`
 }
@@ -14,7 +14,8 @@ type TargetSearchResults struct {
 // hosts.
 type TargetMetrics struct {
 	// TotalHosts is the total hosts in any status. It should equal
-	// OnlineHosts + OfflineHosts + MissingInActionHosts.
+	// OnlineHosts + OfflineHosts + MissingInActionHosts +
+	// MaintenanceHosts.
 	TotalHosts uint `db:"total"`
 	// OnlineHosts is the count of hosts that have checked in within their
 	// expected checkin interval (based on the configuration interval
@@ -26,6 +27,9 @@ type TargetMetrics struct {
 	// MissingInActionHosts is the count of hosts that have not checked in
 	// within the last 30 days.
 	MissingInActionHosts uint `db:"mia"`
+	// MaintenanceHosts is the count of hosts that have not checked in
+	// within their expected interval, but are in a maintenance window.
+	MaintenanceHosts uint `db:"maintenance"`
 	// NewHosts is the count of hosts that have enrolled in the last 24
 	// hours.
 	NewHosts uint `db:"new"`
@@ -46,6 +50,10 @@ type TargetStore interface {
 	// CountHostsInTargets returns the metrics of the hosts in the provided
 	// label and explicit host IDs.
 	CountHostsInTargets(hostIDs []uint, labelIDs []uint, now time.Time) (TargetMetrics, error)
+	// HostIDsInTargets returns the IDs of the hosts in the provided label
+	// and explicit host IDs. Hosts in several of the targets are returned
+	// once.
+	HostIDsInTargets(hostIDs []uint, labelIDs []uint) ([]uint, error)
 }
 
 type TargetType int
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -25,6 +26,14 @@ type UserStore interface {
 	// The new email will be written to user record. userID is the ID of the
 	// user whose e-mail is being changed.
 	ConfirmPendingEmailChange(userID uint, token string) (string, error)
+	// IncrementFailedLogins adds one to the count of consecutive failed
+	// logins of the user, and returns the new count.
+	IncrementFailedLogins(userID uint) (uint, error)
+	// LockUser locks the account of the user until the given time, and
+	// resets its count of failed logins.
+	LockUser(userID uint, until time.Time) error
+	// ResetFailedLogins resets the count of failed logins of the user.
+	ResetFailedLogins(userID uint) error
 }
 
 // UserService contains methods for managing a Fleet User.
@@ -36,6 +45,13 @@ type UserService interface {
 	// first creating and validating invite tokens.
 	NewAdminCreatedUser(ctx context.Context, p UserPayload) (user *User, err error)
 
+	// CreateUsers creates a user from each of the payloads, as an admin
+	// would with NewAdminCreatedUser, and emails each created user. The
+	// results are returned per payload: the user at an index is nil if it
+	// could not be created, and the error at an index is non-nil if the
+	// user could not be created or emailed.
+	CreateUsers(ctx context.Context, users []UserPayload) ([]*User, []error)
+
 	// User returns a valid User given a User ID.
 	User(ctx context.Context, id uint) (user *User, err error)
 
@@ -101,6 +117,18 @@ type User struct {
 	Position                 string `json:"position,omitempty"` // job role
 	// SSOEnabled if true, the single siqn on is used to log in
 	SSOEnabled bool `json:"sso_enabled" db:"sso_enabled"`
+	// FailedLoginCount is the number of consecutive failed password logins
+	// since the last successful login or lockout.
+	FailedLoginCount uint `json:"-" db:"failed_login_count"`
+	// LockedUntil is the end of the lockout of the account that follows
+	// repeated failed logins. The account is not locked if it is nil or in
+	// the past.
+	LockedUntil *time.Time `json:"locked_until,omitempty" db:"locked_until"`
+}
+
+// Locked returns true if the account is locked out as of now.
+func (u User) Locked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
 }
 
 // UserPayload is used to modify an existing user
@@ -120,7 +120,8 @@ func (svc *launcherWrapper) PublishResults(ctx context.Context, nodeKey string,
 		osqueryResults[result.QueryName] = result.Rows
 	}
 
-	err = svc.tls.SubmitDistributedQueryResults(newCtx, osqueryResults, statuses)
+	// Launcher does not report the resources used by queries
+	err = svc.tls.SubmitDistributedQueryResults(newCtx, osqueryResults, statuses, nil)
 	return "", "", false, errors.Wrap(err, "submit launcher results")
 }
 
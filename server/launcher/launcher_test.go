@@ -66,7 +66,8 @@ func TestLauncherPublishResults(t *testing.T) {
 	tls.SubmitDistributedQueryResultsFunc = func(
 		ctx context.Context,
 		results kolide.OsqueryDistributedQueryResults,
-		statuses map[string]kolide.OsqueryStatus) (err error) {
+		statuses map[string]kolide.OsqueryStatus,
+		stats map[string]*kolide.OsqueryDistributedQueryStats) (err error) {
 		assert.Equal(t, results["query"][0], result)
 		return nil
 	}
@@ -146,6 +147,7 @@ func newTLSService(t *testing.T) *mock.TLSService {
 			ctx context.Context,
 			results kolide.OsqueryDistributedQueryResults,
 			statuses map[string]kolide.OsqueryStatus,
+			stats map[string]*kolide.OsqueryDistributedQueryStats,
 		) (err error) {
 			return
 		},
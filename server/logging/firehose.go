@@ -79,6 +79,11 @@ func (f *firehoseLogWriter) validateStream() error {
 	return nil
 }
 
+// HealthCheck returns an error if the delivery stream is not active.
+func (f *firehoseLogWriter) HealthCheck() error {
+	return f.validateStream()
+}
+
 func (f *firehoseLogWriter) Write(ctx context.Context, logs []json.RawMessage) error {
 	var records []*firehose.Record
 	totalBytes := 0
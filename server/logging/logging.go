@@ -3,6 +3,8 @@
 package logging
 
 import (
+	"strings"
+
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/kolide/fleet/server/config"
@@ -13,6 +15,9 @@ import (
 type OsqueryLogger struct {
 	Status kolide.JSONLogger
 	Result kolide.JSONLogger
+	// PackResults holds the writers for the additional result log plugins
+	// that packs can send their results to, keyed by plugin name.
+	PackResults map[string]kolide.JSONLogger
 }
 
 func New(config config.KolideConfig, logger log.Logger) (*OsqueryLogger, error) {
@@ -59,13 +64,48 @@ func New(config config.KolideConfig, logger log.Logger) (*OsqueryLogger, error)
 		)
 	}
 
-	switch config.Osquery.ResultLogPlugin {
-	case "":
+	resultPlugin := config.Osquery.ResultLogPlugin
+	if resultPlugin == "" {
 		// Allow "" to mean filesystem for backwards compatibility
 		level.Info(logger).Log("msg", "kolide_result_log_plugin not explicitly specified. Assuming 'filesystem'")
-		fallthrough
+		resultPlugin = "filesystem"
+	}
+	result, err = newResultLogWriter(resultPlugin, config, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	packResults := map[string]kolide.JSONLogger{}
+	for _, plugin := range PackResultLogPlugins(config) {
+		// Packs using the default plugin share its writer
+		if plugin == resultPlugin {
+			continue
+		}
+		packResults[plugin], err = newResultLogWriter(plugin, config, logger)
+		if err != nil {
+			return nil, errors.Wrapf(err, "create pack result logger %s", plugin)
+		}
+	}
+
+	return &OsqueryLogger{Status: status, Result: result, PackResults: packResults}, nil
+}
+
+// PackResultLogPlugins returns the additional result log plugins that packs
+// can send their results to.
+func PackResultLogPlugins(config config.KolideConfig) []string {
+	var plugins []string
+	for _, plugin := range strings.Split(config.Osquery.PackResultLogPlugins, ",") {
+		if plugin = strings.TrimSpace(plugin); plugin != "" {
+			plugins = append(plugins, plugin)
+		}
+	}
+	return plugins
+}
+
+func newResultLogWriter(plugin string, config config.KolideConfig, logger log.Logger) (kolide.JSONLogger, error) {
+	switch plugin {
 	case "filesystem":
-		result, err = NewFilesystemLogWriter(
+		result, err := NewFilesystemLogWriter(
 			config.Filesystem.ResultLogFile,
 			logger,
 			config.Filesystem.EnableLogRotation,
@@ -73,8 +113,9 @@ func New(config config.KolideConfig, logger log.Logger) (*OsqueryLogger, error)
 		if err != nil {
 			return nil, errors.Wrap(err, "create filesystem result logger")
 		}
+		return result, nil
 	case "firehose":
-		result, err = NewFirehoseLogWriter(
+		result, err := NewFirehoseLogWriter(
 			config.Firehose.Region,
 			config.Firehose.AccessKeyID,
 			config.Firehose.SecretAccessKey,
@@ -84,8 +125,9 @@ func New(config config.KolideConfig, logger log.Logger) (*OsqueryLogger, error)
 		if err != nil {
 			return nil, errors.Wrap(err, "create firehose result logger")
 		}
+		return result, nil
 	case "pubsub":
-		result, err = NewPubSubLogWriter(
+		result, err := NewPubSubLogWriter(
 			config.PubSub.Project,
 			config.PubSub.ResultTopic,
 			logger,
@@ -93,10 +135,8 @@ func New(config config.KolideConfig, logger log.Logger) (*OsqueryLogger, error)
 		if err != nil {
 			return nil, errors.Wrap(err, "create pubsub result logger")
 		}
+		return result, nil
 	default:
-		return nil, errors.Errorf(
-			"unknown result log plugin: %s", config.Osquery.StatusLogPlugin,
-		)
+		return nil, errors.Errorf("unknown result log plugin: %s", plugin)
 	}
-	return &OsqueryLogger{Status: status, Result: result}, nil
 }
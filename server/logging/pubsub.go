@@ -3,6 +3,7 @@ package logging
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"cloud.google.com/go/pubsub"
 	"github.com/go-kit/kit/log"
@@ -37,6 +38,21 @@ func NewPubSubLogWriter(projectId string, topicName string, logger log.Logger) (
 	}, nil
 }
 
+// HealthCheck returns an error if the topic cannot be found.
+func (w *pubSubLogWriter) HealthCheck() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	exists, err := w.topic.Exists(ctx)
+	if err != nil {
+		return errors.Wrap(err, "check pubsub topic")
+	}
+	if !exists {
+		return errors.Errorf("pubsub topic %s does not exist", w.topic.ID())
+	}
+	return nil
+}
+
 func (w *pubSubLogWriter) Write(ctx context.Context, logs []json.RawMessage) error {
 	results := make([]*pubsub.PublishResult, len(logs))
 
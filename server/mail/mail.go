@@ -26,6 +26,29 @@ type sender interface {
 	sendMail(e kolide.Email, msg []byte) error
 }
 
+type connector interface {
+	checkConnection(config *kolide.AppConfig) error
+}
+
+// Kinds of Error, identifying the stage of the SMTP exchange that failed.
+const (
+	ErrorKindConnection     = "connection"
+	ErrorKindTLS            = "tls"
+	ErrorKindAuthentication = "authentication"
+)
+
+// Error is returned when communicating with the SMTP server fails, so that
+// a connection failure can be distinguished from a TLS or authentication
+// failure.
+type Error struct {
+	Kind string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("smtp %s error: %s", e.Kind, e.Err)
+}
+
 func Test(mailer kolide.MailService, e kolide.Email) error {
 	mailBody, err := getMessageBody(e)
 	if err != nil {
@@ -45,6 +68,16 @@ func Test(mailer kolide.MailService, e kolide.Email) error {
 	return nil
 }
 
+// TestConnection connects and authenticates to the SMTP server described by
+// config without sending a message.
+func TestConnection(mailer kolide.MailService, config *kolide.AppConfig) error {
+	svc, ok := mailer.(connector)
+	if !ok {
+		return nil
+	}
+	return svc.checkConnection(config)
+}
+
 const (
 	PortSSL = 465
 	PortTLS = 587
@@ -149,27 +182,11 @@ func (m mailService) sendMail(e kolide.Email, msg []byte) error {
 		return nil
 	}
 
-	client, err := dialTimeout(smtpHost)
+	client, err := openClient(e.Config, auth)
 	if err != nil {
-		return errors.Wrap(err, "could not dial smtp host")
+		return err
 	}
 	defer client.Close()
-	if e.Config.SMTPEnableStartTLS {
-		if ok, _ := client.Extension("STARTTLS"); ok {
-			config := &tls.Config{
-				ServerName:         e.Config.SMTPServer,
-				InsecureSkipVerify: !e.Config.SMTPVerifySSLCerts,
-			}
-			if err = client.StartTLS(config); err != nil {
-				return errors.Wrap(err, "startTLS error")
-			}
-		}
-	}
-	if auth != nil {
-		if err = client.Auth(auth); err != nil {
-			return errors.Wrap(err, "client auth error")
-		}
-	}
 	if err = client.Mail(e.Config.SMTPSenderAddress); err != nil {
 		return errors.Wrap(err, "could not issue mail to provided address")
 	}
@@ -198,6 +215,51 @@ func (m mailService) sendMail(e kolide.Email, msg []byte) error {
 	return nil
 }
 
+func (m mailService) checkConnection(config *kolide.AppConfig) error {
+	auth, err := smtpAuth(kolide.Email{Config: config})
+	if err != nil {
+		return errors.Wrap(err, "failed to get smtp auth")
+	}
+	client, err := openClient(config, auth)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := client.Quit(); err != nil {
+		return errors.Wrap(err, "error on client quit")
+	}
+	return nil
+}
+
+// openClient dials the SMTP server, upgrading the connection with STARTTLS
+// and authenticating as configured.
+func openClient(config *kolide.AppConfig, auth smtp.Auth) (*smtp.Client, error) {
+	smtpHost := fmt.Sprintf("%s:%d", config.SMTPServer, config.SMTPPort)
+	client, err := dialTimeout(smtpHost)
+	if err != nil {
+		return nil, &Error{Kind: ErrorKindConnection, Err: errors.Wrap(err, "could not dial smtp host")}
+	}
+	if config.SMTPEnableStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			tlsConfig := &tls.Config{
+				ServerName:         config.SMTPServer,
+				InsecureSkipVerify: !config.SMTPVerifySSLCerts,
+			}
+			if err = client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return nil, &Error{Kind: ErrorKindTLS, Err: errors.Wrap(err, "startTLS error")}
+			}
+		}
+	}
+	if auth != nil {
+		if err = client.Auth(auth); err != nil {
+			client.Close()
+			return nil, &Error{Kind: ErrorKindAuthentication, Err: errors.Wrap(err, "client auth error")}
+		}
+	}
+	return client, nil
+}
+
 // dialTimeout sets a timeout on net.Dial to prevent email from attempting to
 // send indefinitely.
 func dialTimeout(addr string) (client *smtp.Client, err error) {
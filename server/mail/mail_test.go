@@ -1,6 +1,9 @@
 package mail
 
 import (
+	"bufio"
+	"fmt"
+	"net"
 	"os"
 	"reflect"
 	"runtime"
@@ -155,3 +158,59 @@ func TestTemplateProcessor(t *testing.T) {
 	require.Nil(t, err)
 	assert.NotNil(t, out)
 }
+
+// serveSMTP accepts a single connection on l and rejects any authentication
+// attempt.
+func serveSMTP(l net.Listener) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "220 localhost ESMTP\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch {
+		case strings.HasPrefix(line, "EHLO"):
+			fmt.Fprint(conn, "250-localhost\r\n250 AUTH PLAIN\r\n")
+		case strings.HasPrefix(line, "AUTH"):
+			fmt.Fprint(conn, "535 authentication failed\r\n")
+		case strings.HasPrefix(line, "QUIT"):
+			fmt.Fprint(conn, "221 bye\r\n")
+			return
+		default:
+			fmt.Fprint(conn, "250 ok\r\n")
+		}
+	}
+}
+
+func TestTestConnectionAuthError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer l.Close()
+	go serveSMTP(l)
+
+	port := l.Addr().(*net.TCPAddr).Port
+	config := &kolide.AppConfig{
+		SMTPAuthenticationType:   kolide.AuthTypeUserNamePassword,
+		SMTPAuthenticationMethod: kolide.AuthMethodPlain,
+		SMTPUserName:             "bob",
+		SMTPPassword:             "wrong",
+		SMTPPort:                 uint(port),
+		SMTPServer:               "localhost",
+	}
+
+	err = TestConnection(NewService(), config)
+	require.NotNil(t, err)
+	mailErr, ok := err.(*Error)
+	require.True(t, ok)
+	assert.Equal(t, ErrorKindAuthentication, mailErr.Kind)
+
+	// Services that cannot connect are not tested
+	assert.Nil(t, TestConnection(&mockMailer{}, config))
+}
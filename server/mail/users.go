@@ -3,6 +3,8 @@ package mail
 import (
 	"bytes"
 	"html/template"
+
+	"github.com/kolide/fleet/server/kolide"
 )
 
 type ChangeEmailMailer struct {
@@ -45,3 +47,51 @@ func (r PasswordResetMailer) Message() ([]byte, error) {
 	}
 	return msg.Bytes(), nil
 }
+
+// UserCreatedMailer is used to build the email sent to users created by an
+// admin.
+type UserCreatedMailer struct {
+	*kolide.User
+	BaseURL           template.URL
+	AssetURL          template.URL
+	CreatedByUsername string
+	OrgName           string
+}
+
+func (m *UserCreatedMailer) Message() ([]byte, error) {
+	t, err := getTemplate("server/mail/templates/user_created.html")
+	if err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	if err = t.Execute(&msg, m); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+// AccountLockedMailer is used to build the email sent to a user, and to the
+// configured security address, when the account of the user is locked after
+// repeated failed logins.
+type AccountLockedMailer struct {
+	BaseURL     template.URL
+	AssetURL    template.URL
+	Username    string
+	SourceIP    string
+	LockedUntil string
+	OrgName     string
+}
+
+func (m *AccountLockedMailer) Message() ([]byte, error) {
+	t, err := getTemplate("server/mail/templates/account_locked.html")
+	if err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	if err = t.Execute(&msg, m); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
@@ -14,6 +14,14 @@ package mock
 //go:generate mockimpl -o datastore_query_results.go "s *QueryResultStore" "kolide.QueryResultStore"
 //go:generate mockimpl -o datastore_campaigns.go "s *CampaignStore" "kolide.CampaignStore"
 //go:generate mockimpl -o datastore_sessions.go "s *SessionStore" "kolide.SessionStore"
+//go:generate mockimpl -o datastore_decorators.go "s *DecoratorStore" "kolide.DecoratorStore"
+//go:generate mockimpl -o datastore_host_detail_queries.go "s *HostDetailQueryStore" "kolide.HostDetailQueryStore"
+//go:generate mockimpl -o datastore_atc_tables.go "s *ATCTableStore" "kolide.ATCTableStore"
+//go:generate mockimpl -o datastore_host_activities.go "s *HostActivityStore" "kolide.HostActivityStore"
+//go:generate mockimpl -o datastore_ingestion_failures.go "s *IngestionFailureStore" "kolide.IngestionFailureStore"
+//go:generate mockimpl -o datastore_host_uptime_events.go "s *HostUptimeEventStore" "kolide.HostUptimeEventStore"
+//go:generate mockimpl -o datastore_activities.go "s *ActivityStore" "kolide.ActivityStore"
+//go:generate mockimpl -o datastore_api_tokens.go "s *APITokenStore" "kolide.APITokenStore"
 
 import "github.com/kolide/fleet/server/kolide"
 
@@ -37,6 +45,14 @@ type Store struct {
 	UserStore
 	QueryStore
 	QueryResultStore
+	DecoratorStore
+	HostDetailQueryStore
+	ATCTableStore
+	HostActivityStore
+	IngestionFailureStore
+	HostUptimeEventStore
+	ActivityStore
+	APITokenStore
 }
 
 func (m *Store) Drop() error {
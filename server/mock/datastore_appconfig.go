@@ -12,12 +12,14 @@ type AppConfigFunc func() (*kolide.AppConfig, error)
 
 type SaveAppConfigFunc func(info *kolide.AppConfig) error
 
-type VerifyEnrollSecretFunc func(secret string) (string, error)
+type VerifyEnrollSecretFunc func(secret string) (*kolide.EnrollSecret, error)
 
 type ApplyEnrollSecretSpecFunc func(spec *kolide.EnrollSecretSpec) error
 
 type GetEnrollSecretSpecFunc func() (*kolide.EnrollSecretSpec, error)
 
+type EnrollSecretUsageFunc func() ([]kolide.EnrollSecretUsage, error)
+
 type AppConfigStore struct {
 	NewAppConfigFunc        NewAppConfigFunc
 	NewAppConfigFuncInvoked bool
@@ -36,6 +38,9 @@ type AppConfigStore struct {
 
 	GetEnrollSecretSpecFunc        GetEnrollSecretSpecFunc
 	GetEnrollSecretSpecFuncInvoked bool
+
+	EnrollSecretUsageFunc        EnrollSecretUsageFunc
+	EnrollSecretUsageFuncInvoked bool
 }
 
 func (s *AppConfigStore) NewAppConfig(info *kolide.AppConfig) (*kolide.AppConfig, error) {
@@ -53,7 +58,7 @@ func (s *AppConfigStore) SaveAppConfig(info *kolide.AppConfig) error {
 	return s.SaveAppConfigFunc(info)
 }
 
-func (s *AppConfigStore) VerifyEnrollSecret(secret string) (string, error) {
+func (s *AppConfigStore) VerifyEnrollSecret(secret string) (*kolide.EnrollSecret, error) {
 	s.VerifyEnrollSecretFuncInvoked = true
 	return s.VerifyEnrollSecretFunc(secret)
 }
@@ -67,3 +72,8 @@ func (s *AppConfigStore) GetEnrollSecretSpec() (*kolide.EnrollSecretSpec, error)
 	s.GetEnrollSecretSpecFuncInvoked = true
 	return s.GetEnrollSecretSpecFunc()
 }
+
+func (s *AppConfigStore) EnrollSecretUsage() ([]kolide.EnrollSecretUsage, error) {
+	s.EnrollSecretUsageFuncInvoked = true
+	return s.EnrollSecretUsageFunc()
+}
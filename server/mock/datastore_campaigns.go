@@ -14,16 +14,28 @@ type NewDistributedQueryCampaignFunc func(camp *kolide.DistributedQueryCampaign)
 
 type DistributedQueryCampaignFunc func(id uint) (*kolide.DistributedQueryCampaign, error)
 
+type ListActiveDistributedQueryCampaignsFunc func() ([]*kolide.DistributedQueryCampaign, error)
+
 type SaveDistributedQueryCampaignFunc func(camp *kolide.DistributedQueryCampaign) error
 
+type CompleteDistributedQueryCampaignFunc func(id uint) (bool, error)
+
+type MarkDistributedQueryCampaignDisconnectedFunc func(id uint, disconnectedAt *time.Time) error
+
+type DistributedQueryCampaignSummaryFunc func(id uint) (*kolide.DistributedQueryCampaignSummary, error)
+
 type DistributedQueryCampaignTargetIDsFunc func(id uint) (hostIDs []uint, labelIDs []uint, err error)
 
 type NewDistributedQueryCampaignTargetFunc func(target *kolide.DistributedQueryCampaignTarget) (*kolide.DistributedQueryCampaignTarget, error)
 
 type NewDistributedQueryExecutionFunc func(exec *kolide.DistributedQueryExecution) (*kolide.DistributedQueryExecution, error)
 
+type DistributedQueryExecutionExistsFunc func(campaignID uint, hostID uint) (bool, error)
+
 type CleanupDistributedQueryCampaignsFunc func(now time.Time) (expired uint, deleted uint, err error)
 
+type PurgeCompletedCampaignsFunc func(before time.Time) (uint, error)
+
 type CampaignStore struct {
 	NewDistributedQueryCampaignFunc        NewDistributedQueryCampaignFunc
 	NewDistributedQueryCampaignFuncInvoked bool
@@ -31,9 +43,21 @@ type CampaignStore struct {
 	DistributedQueryCampaignFunc        DistributedQueryCampaignFunc
 	DistributedQueryCampaignFuncInvoked bool
 
+	ListActiveDistributedQueryCampaignsFunc        ListActiveDistributedQueryCampaignsFunc
+	ListActiveDistributedQueryCampaignsFuncInvoked bool
+
 	SaveDistributedQueryCampaignFunc        SaveDistributedQueryCampaignFunc
 	SaveDistributedQueryCampaignFuncInvoked bool
 
+	CompleteDistributedQueryCampaignFunc        CompleteDistributedQueryCampaignFunc
+	CompleteDistributedQueryCampaignFuncInvoked bool
+
+	MarkDistributedQueryCampaignDisconnectedFunc        MarkDistributedQueryCampaignDisconnectedFunc
+	MarkDistributedQueryCampaignDisconnectedFuncInvoked bool
+
+	DistributedQueryCampaignSummaryFunc        DistributedQueryCampaignSummaryFunc
+	DistributedQueryCampaignSummaryFuncInvoked bool
+
 	DistributedQueryCampaignTargetIDsFunc        DistributedQueryCampaignTargetIDsFunc
 	DistributedQueryCampaignTargetIDsFuncInvoked bool
 
@@ -43,8 +67,14 @@ type CampaignStore struct {
 	NewDistributedQueryExecutionFunc        NewDistributedQueryExecutionFunc
 	NewDistributedQueryExecutionFuncInvoked bool
 
+	DistributedQueryExecutionExistsFunc        DistributedQueryExecutionExistsFunc
+	DistributedQueryExecutionExistsFuncInvoked bool
+
 	CleanupDistributedQueryCampaignsFunc        CleanupDistributedQueryCampaignsFunc
 	CleanupDistributedQueryCampaignsFuncInvoked bool
+
+	PurgeCompletedCampaignsFunc        PurgeCompletedCampaignsFunc
+	PurgeCompletedCampaignsFuncInvoked bool
 }
 
 func (s *CampaignStore) NewDistributedQueryCampaign(camp *kolide.DistributedQueryCampaign) (*kolide.DistributedQueryCampaign, error) {
@@ -57,11 +87,31 @@ func (s *CampaignStore) DistributedQueryCampaign(id uint) (*kolide.DistributedQu
 	return s.DistributedQueryCampaignFunc(id)
 }
 
+func (s *CampaignStore) ListActiveDistributedQueryCampaigns() ([]*kolide.DistributedQueryCampaign, error) {
+	s.ListActiveDistributedQueryCampaignsFuncInvoked = true
+	return s.ListActiveDistributedQueryCampaignsFunc()
+}
+
 func (s *CampaignStore) SaveDistributedQueryCampaign(camp *kolide.DistributedQueryCampaign) error {
 	s.SaveDistributedQueryCampaignFuncInvoked = true
 	return s.SaveDistributedQueryCampaignFunc(camp)
 }
 
+func (s *CampaignStore) CompleteDistributedQueryCampaign(id uint) (bool, error) {
+	s.CompleteDistributedQueryCampaignFuncInvoked = true
+	return s.CompleteDistributedQueryCampaignFunc(id)
+}
+
+func (s *CampaignStore) MarkDistributedQueryCampaignDisconnected(id uint, disconnectedAt *time.Time) error {
+	s.MarkDistributedQueryCampaignDisconnectedFuncInvoked = true
+	return s.MarkDistributedQueryCampaignDisconnectedFunc(id, disconnectedAt)
+}
+
+func (s *CampaignStore) DistributedQueryCampaignSummary(id uint) (*kolide.DistributedQueryCampaignSummary, error) {
+	s.DistributedQueryCampaignSummaryFuncInvoked = true
+	return s.DistributedQueryCampaignSummaryFunc(id)
+}
+
 func (s *CampaignStore) DistributedQueryCampaignTargetIDs(id uint) (hostIDs []uint, labelIDs []uint, err error) {
 	s.DistributedQueryCampaignTargetIDsFuncInvoked = true
 	return s.DistributedQueryCampaignTargetIDsFunc(id)
@@ -77,7 +127,17 @@ func (s *CampaignStore) NewDistributedQueryExecution(exec *kolide.DistributedQue
 	return s.NewDistributedQueryExecutionFunc(exec)
 }
 
+func (s *CampaignStore) DistributedQueryExecutionExists(campaignID uint, hostID uint) (bool, error) {
+	s.DistributedQueryExecutionExistsFuncInvoked = true
+	return s.DistributedQueryExecutionExistsFunc(campaignID, hostID)
+}
+
 func (s *CampaignStore) CleanupDistributedQueryCampaigns(now time.Time) (expired uint, deleted uint, err error) {
 	s.CleanupDistributedQueryCampaignsFuncInvoked = true
 	return s.CleanupDistributedQueryCampaignsFunc(now)
 }
+
+func (s *CampaignStore) PurgeCompletedCampaigns(before time.Time) (uint, error) {
+	s.PurgeCompletedCampaignsFuncInvoked = true
+	return s.PurgeCompletedCampaignsFunc(before)
+}
@@ -16,11 +16,17 @@ type SaveHostFunc func(host *kolide.Host) error
 
 type DeleteHostFunc func(hid uint) error
 
+type DeleteHostsByLabelFunc func(lid uint) (int, error)
+
 type HostFunc func(id uint) (*kolide.Host, error)
 
-type ListHostsFunc func(opt kolide.ListOptions) ([]*kolide.Host, error)
+type ListHostsFunc func(opt kolide.HostListOptions) ([]*kolide.Host, error)
+
+type CountHostsFunc func(opt kolide.HostListOptions) (int, error)
 
-type EnrollHostFunc func(osqueryHostId, nodeKey, secretName string) (*kolide.Host, error)
+type StreamHostsFunc func(opt kolide.HostListOptions, fn func(*kolide.Host) error) error
+
+type EnrollHostFunc func(osqueryHostId, nodeKey, secretName string, cooldown time.Duration, pending bool) (*kolide.Host, error)
 
 type AuthenticateHostFunc func(nodeKey string) (*kolide.Host, error)
 
@@ -30,12 +36,36 @@ type CleanupIncomingHostsFunc func(t time.Time) error
 
 type SearchHostsFunc func(query string, omit ...uint) ([]*kolide.Host, error)
 
-type GenerateHostStatusStatisticsFunc func(now time.Time) (online uint, offline uint, mia uint, new uint, err error)
+type GenerateHostStatusStatisticsFunc func(now time.Time) (online uint, offline uint, mia uint, maintenance uint, new uint, err error)
 
 type DistributedQueriesForHostFunc func(host *kolide.Host) (map[uint]string, error)
 
 type HostIDsByNameFunc func(hostnames []string) ([]uint, error)
 
+type HostIDsByIdentifierFunc func(identifiers []string) ([]uint, error)
+
+type SetHostsConfigRefreshFunc func(hostIDs []uint, requested bool) error
+
+type ExpireHostDetailsFunc func(hostID uint) error
+
+type SetHostMaintenanceFunc func(hostID uint, until *time.Time) error
+
+type ClearElapsedHostMaintenanceFunc func(now time.Time) error
+
+type ApproveHostFunc func(hostID uint) error
+
+type SetHostNodeKeyFunc func(hostID uint, nodeKey string) error
+
+type MergeHostsFunc func(keepID uint, mergeIDs []uint) (kolide.HostMerge, error)
+
+type AggregateHostsFunc func(groupBy string) ([]kolide.HostAggregate, error)
+
+type RecordNoisyHostFunc func(hostID uint, dropped uint, at time.Time) error
+
+type ListNoisyHostsFunc func() ([]*kolide.Host, error)
+
+type RecordTruncatedResultsFunc func(hostID uint, truncated uint, at time.Time) error
+
 type HostStore struct {
 	NewHostFunc        NewHostFunc
 	NewHostFuncInvoked bool
@@ -46,12 +76,21 @@ type HostStore struct {
 	DeleteHostFunc        DeleteHostFunc
 	DeleteHostFuncInvoked bool
 
+	DeleteHostsByLabelFunc        DeleteHostsByLabelFunc
+	DeleteHostsByLabelFuncInvoked bool
+
 	HostFunc        HostFunc
 	HostFuncInvoked bool
 
 	ListHostsFunc        ListHostsFunc
 	ListHostsFuncInvoked bool
 
+	CountHostsFunc        CountHostsFunc
+	CountHostsFuncInvoked bool
+
+	StreamHostsFunc        StreamHostsFunc
+	StreamHostsFuncInvoked bool
+
 	EnrollHostFunc        EnrollHostFunc
 	EnrollHostFuncInvoked bool
 
@@ -75,6 +114,42 @@ type HostStore struct {
 
 	HostIDsByNameFunc        HostIDsByNameFunc
 	HostIDsByNameFuncInvoked bool
+
+	HostIDsByIdentifierFunc        HostIDsByIdentifierFunc
+	HostIDsByIdentifierFuncInvoked bool
+
+	SetHostsConfigRefreshFunc        SetHostsConfigRefreshFunc
+	SetHostsConfigRefreshFuncInvoked bool
+
+	ExpireHostDetailsFunc        ExpireHostDetailsFunc
+	ExpireHostDetailsFuncInvoked bool
+
+	SetHostMaintenanceFunc        SetHostMaintenanceFunc
+	SetHostMaintenanceFuncInvoked bool
+
+	ClearElapsedHostMaintenanceFunc        ClearElapsedHostMaintenanceFunc
+	ClearElapsedHostMaintenanceFuncInvoked bool
+
+	ApproveHostFunc        ApproveHostFunc
+	ApproveHostFuncInvoked bool
+
+	SetHostNodeKeyFunc        SetHostNodeKeyFunc
+	SetHostNodeKeyFuncInvoked bool
+
+	MergeHostsFunc        MergeHostsFunc
+	MergeHostsFuncInvoked bool
+
+	AggregateHostsFunc        AggregateHostsFunc
+	AggregateHostsFuncInvoked bool
+
+	RecordNoisyHostFunc        RecordNoisyHostFunc
+	RecordNoisyHostFuncInvoked bool
+
+	ListNoisyHostsFunc        ListNoisyHostsFunc
+	ListNoisyHostsFuncInvoked bool
+
+	RecordTruncatedResultsFunc        RecordTruncatedResultsFunc
+	RecordTruncatedResultsFuncInvoked bool
 }
 
 func (s *HostStore) NewHost(host *kolide.Host) (*kolide.Host, error) {
@@ -92,19 +167,34 @@ func (s *HostStore) DeleteHost(hid uint) error {
 	return s.DeleteHostFunc(hid)
 }
 
+func (s *HostStore) DeleteHostsByLabel(lid uint) (int, error) {
+	s.DeleteHostsByLabelFuncInvoked = true
+	return s.DeleteHostsByLabelFunc(lid)
+}
+
 func (s *HostStore) Host(id uint) (*kolide.Host, error) {
 	s.HostFuncInvoked = true
 	return s.HostFunc(id)
 }
 
-func (s *HostStore) ListHosts(opt kolide.ListOptions) ([]*kolide.Host, error) {
+func (s *HostStore) ListHosts(opt kolide.HostListOptions) ([]*kolide.Host, error) {
 	s.ListHostsFuncInvoked = true
 	return s.ListHostsFunc(opt)
 }
 
-func (s *HostStore) EnrollHost(osqueryHostId, nodeKey, secretName string) (*kolide.Host, error) {
+func (s *HostStore) CountHosts(opt kolide.HostListOptions) (int, error) {
+	s.CountHostsFuncInvoked = true
+	return s.CountHostsFunc(opt)
+}
+
+func (s *HostStore) StreamHosts(opt kolide.HostListOptions, fn func(*kolide.Host) error) error {
+	s.StreamHostsFuncInvoked = true
+	return s.StreamHostsFunc(opt, fn)
+}
+
+func (s *HostStore) EnrollHost(osqueryHostId, nodeKey, secretName string, cooldown time.Duration, pending bool) (*kolide.Host, error) {
 	s.EnrollHostFuncInvoked = true
-	return s.EnrollHostFunc(osqueryHostId, nodeKey, secretName)
+	return s.EnrollHostFunc(osqueryHostId, nodeKey, secretName, cooldown, pending)
 }
 
 func (s *HostStore) AuthenticateHost(nodeKey string) (*kolide.Host, error) {
@@ -127,7 +217,7 @@ func (s *HostStore) SearchHosts(query string, omit ...uint) ([]*kolide.Host, err
 	return s.SearchHostsFunc(query, omit...)
 }
 
-func (s *HostStore) GenerateHostStatusStatistics(now time.Time) (online uint, offline uint, mia uint, new uint, err error) {
+func (s *HostStore) GenerateHostStatusStatistics(now time.Time) (online uint, offline uint, mia uint, maintenance uint, new uint, err error) {
 	s.GenerateHostStatusStatisticsFuncInvoked = true
 	return s.GenerateHostStatusStatisticsFunc(now)
 }
@@ -141,3 +231,63 @@ func (s *HostStore) HostIDsByName(hostnames []string) ([]uint, error) {
 	s.HostIDsByNameFuncInvoked = true
 	return s.HostIDsByNameFunc(hostnames)
 }
+
+func (s *HostStore) HostIDsByIdentifier(identifiers []string) ([]uint, error) {
+	s.HostIDsByIdentifierFuncInvoked = true
+	return s.HostIDsByIdentifierFunc(identifiers)
+}
+
+func (s *HostStore) SetHostsConfigRefresh(hostIDs []uint, requested bool) error {
+	s.SetHostsConfigRefreshFuncInvoked = true
+	return s.SetHostsConfigRefreshFunc(hostIDs, requested)
+}
+
+func (s *HostStore) ExpireHostDetails(hostID uint) error {
+	s.ExpireHostDetailsFuncInvoked = true
+	return s.ExpireHostDetailsFunc(hostID)
+}
+
+func (s *HostStore) SetHostMaintenance(hostID uint, until *time.Time) error {
+	s.SetHostMaintenanceFuncInvoked = true
+	return s.SetHostMaintenanceFunc(hostID, until)
+}
+
+func (s *HostStore) ClearElapsedHostMaintenance(now time.Time) error {
+	s.ClearElapsedHostMaintenanceFuncInvoked = true
+	return s.ClearElapsedHostMaintenanceFunc(now)
+}
+
+func (s *HostStore) ApproveHost(hostID uint) error {
+	s.ApproveHostFuncInvoked = true
+	return s.ApproveHostFunc(hostID)
+}
+
+func (s *HostStore) SetHostNodeKey(hostID uint, nodeKey string) error {
+	s.SetHostNodeKeyFuncInvoked = true
+	return s.SetHostNodeKeyFunc(hostID, nodeKey)
+}
+
+func (s *HostStore) MergeHosts(keepID uint, mergeIDs []uint) (kolide.HostMerge, error) {
+	s.MergeHostsFuncInvoked = true
+	return s.MergeHostsFunc(keepID, mergeIDs)
+}
+
+func (s *HostStore) AggregateHosts(groupBy string) ([]kolide.HostAggregate, error) {
+	s.AggregateHostsFuncInvoked = true
+	return s.AggregateHostsFunc(groupBy)
+}
+
+func (s *HostStore) RecordNoisyHost(hostID uint, dropped uint, at time.Time) error {
+	s.RecordNoisyHostFuncInvoked = true
+	return s.RecordNoisyHostFunc(hostID, dropped, at)
+}
+
+func (s *HostStore) ListNoisyHosts() ([]*kolide.Host, error) {
+	s.ListNoisyHostsFuncInvoked = true
+	return s.ListNoisyHostsFunc()
+}
+
+func (s *HostStore) RecordTruncatedResults(hostID uint, truncated uint, at time.Time) error {
+	s.RecordTruncatedResultsFuncInvoked = true
+	return s.RecordTruncatedResultsFunc(hostID, truncated, at)
+}
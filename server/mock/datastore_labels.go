@@ -12,6 +12,8 @@ var _ kolide.LabelStore = (*LabelStore)(nil)
 
 type ApplyLabelSpecsFunc func(specs []*kolide.LabelSpec) error
 
+type SyncLabelSpecsFunc func(specs []*kolide.LabelSpec, prune bool) (*kolide.ApplyLabelSpecsResult, error)
+
 type GetLabelSpecsFunc func() ([]*kolide.LabelSpec, error)
 
 type GetLabelSpecFunc func(name string) (*kolide.LabelSpec, error)
@@ -30,6 +32,8 @@ type LabelQueriesForHostFunc func(host *kolide.Host, cutoff time.Time) (map[stri
 
 type RecordLabelQueryExecutionsFunc func(host *kolide.Host, results map[uint]bool, t time.Time) error
 
+type RefreshModifiedLabelsFunc func(limit int) ([]uint, error)
+
 type ListLabelsForHostFunc func(hid uint) ([]kolide.Label, error)
 
 type ListHostsInLabelFunc func(lid uint) ([]kolide.Host, error)
@@ -40,10 +44,19 @@ type SearchLabelsFunc func(query string, omit ...uint) ([]kolide.Label, error)
 
 type LabelIDsByNameFunc func(labels []string) ([]uint, error)
 
+type AddHostsToLabelFunc func(lid uint, hostIDs []uint) error
+
+type RemoveHostsFromLabelFunc func(lid uint, hostIDs []uint) error
+
+type TransferHostsToLabelFunc func(lid uint, hostIDs []uint) ([]uint, error)
+
 type LabelStore struct {
 	ApplyLabelSpecsFunc        ApplyLabelSpecsFunc
 	ApplyLabelSpecsFuncInvoked bool
 
+	SyncLabelSpecsFunc        SyncLabelSpecsFunc
+	SyncLabelSpecsFuncInvoked bool
+
 	GetLabelSpecsFunc        GetLabelSpecsFunc
 	GetLabelSpecsFuncInvoked bool
 
@@ -71,6 +84,9 @@ type LabelStore struct {
 	RecordLabelQueryExecutionsFunc        RecordLabelQueryExecutionsFunc
 	RecordLabelQueryExecutionsFuncInvoked bool
 
+	RefreshModifiedLabelsFunc        RefreshModifiedLabelsFunc
+	RefreshModifiedLabelsFuncInvoked bool
+
 	ListLabelsForHostFunc        ListLabelsForHostFunc
 	ListLabelsForHostFuncInvoked bool
 
@@ -85,6 +101,15 @@ type LabelStore struct {
 
 	LabelIDsByNameFunc        LabelIDsByNameFunc
 	LabelIDsByNameFuncInvoked bool
+
+	AddHostsToLabelFunc        AddHostsToLabelFunc
+	AddHostsToLabelFuncInvoked bool
+
+	RemoveHostsFromLabelFunc        RemoveHostsFromLabelFunc
+	RemoveHostsFromLabelFuncInvoked bool
+
+	TransferHostsToLabelFunc        TransferHostsToLabelFunc
+	TransferHostsToLabelFuncInvoked bool
 }
 
 func (s *LabelStore) ApplyLabelSpecs(specs []*kolide.LabelSpec) error {
@@ -92,6 +117,11 @@ func (s *LabelStore) ApplyLabelSpecs(specs []*kolide.LabelSpec) error {
 	return s.ApplyLabelSpecsFunc(specs)
 }
 
+func (s *LabelStore) SyncLabelSpecs(specs []*kolide.LabelSpec, prune bool) (*kolide.ApplyLabelSpecsResult, error) {
+	s.SyncLabelSpecsFuncInvoked = true
+	return s.SyncLabelSpecsFunc(specs, prune)
+}
+
 func (s *LabelStore) GetLabelSpecs() ([]*kolide.LabelSpec, error) {
 	s.GetLabelSpecsFuncInvoked = true
 	return s.GetLabelSpecsFunc()
@@ -137,6 +167,11 @@ func (s *LabelStore) RecordLabelQueryExecutions(host *kolide.Host, results map[u
 	return s.RecordLabelQueryExecutionsFunc(host, results, t)
 }
 
+func (s *LabelStore) RefreshModifiedLabels(limit int) ([]uint, error) {
+	s.RefreshModifiedLabelsFuncInvoked = true
+	return s.RefreshModifiedLabelsFunc(limit)
+}
+
 func (s *LabelStore) ListLabelsForHost(hid uint) ([]kolide.Label, error) {
 	s.ListLabelsForHostFuncInvoked = true
 	return s.ListLabelsForHostFunc(hid)
@@ -161,3 +196,18 @@ func (s *LabelStore) LabelIDsByName(labels []string) ([]uint, error) {
 	s.LabelIDsByNameFuncInvoked = true
 	return s.LabelIDsByNameFunc(labels)
 }
+
+func (s *LabelStore) AddHostsToLabel(lid uint, hostIDs []uint) error {
+	s.AddHostsToLabelFuncInvoked = true
+	return s.AddHostsToLabelFunc(lid, hostIDs)
+}
+
+func (s *LabelStore) RemoveHostsFromLabel(lid uint, hostIDs []uint) error {
+	s.RemoveHostsFromLabelFuncInvoked = true
+	return s.RemoveHostsFromLabelFunc(lid, hostIDs)
+}
+
+func (s *LabelStore) TransferHostsToLabel(lid uint, hostIDs []uint) ([]uint, error) {
+	s.TransferHostsToLabelFuncInvoked = true
+	return s.TransferHostsToLabelFunc(lid, hostIDs)
+}
@@ -16,6 +16,10 @@ type GetOptionsFunc func() (*kolide.OptionsSpec, error)
 
 type OptionsForPlatformFunc func(platform string) (json.RawMessage, error)
 
+type LabelOptionsForHostFunc func(hostID uint) (map[string]json.RawMessage, error)
+
+type IntervalsForPlatformFunc func(platform string) (*kolide.OsqueryIntervals, error)
+
 type OsqueryOptionsStore struct {
 	ApplyOptionsFunc        ApplyOptionsFunc
 	ApplyOptionsFuncInvoked bool
@@ -25,6 +29,12 @@ type OsqueryOptionsStore struct {
 
 	OptionsForPlatformFunc        OptionsForPlatformFunc
 	OptionsForPlatformFuncInvoked bool
+
+	LabelOptionsForHostFunc        LabelOptionsForHostFunc
+	LabelOptionsForHostFuncInvoked bool
+
+	IntervalsForPlatformFunc        IntervalsForPlatformFunc
+	IntervalsForPlatformFuncInvoked bool
 }
 
 func (s *OsqueryOptionsStore) ApplyOptions(options *kolide.OptionsSpec) error {
@@ -41,3 +51,13 @@ func (s *OsqueryOptionsStore) OptionsForPlatform(platform string) (json.RawMessa
 	s.OptionsForPlatformFuncInvoked = true
 	return s.OptionsForPlatformFunc(platform)
 }
+
+func (s *OsqueryOptionsStore) LabelOptionsForHost(hostID uint) (map[string]json.RawMessage, error) {
+	s.LabelOptionsForHostFuncInvoked = true
+	return s.LabelOptionsForHostFunc(hostID)
+}
+
+func (s *OsqueryOptionsStore) IntervalsForPlatform(platform string) (*kolide.OsqueryIntervals, error) {
+	s.IntervalsForPlatformFuncInvoked = true
+	return s.IntervalsForPlatformFunc(platform)
+}
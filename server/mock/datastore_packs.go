@@ -40,6 +40,8 @@ type ListHostsInPackFunc func(pid uint, opt kolide.ListOptions) ([]uint, error)
 
 type ListExplicitHostsInPackFunc func(pid uint, opt kolide.ListOptions) ([]uint, error)
 
+type ListHostsMissingPackFunc func(pid uint, opt kolide.HostListOptions) ([]kolide.Host, error)
+
 type PackStore struct {
 	ApplyPackSpecsFunc        ApplyPackSpecsFunc
 	ApplyPackSpecsFuncInvoked bool
@@ -91,6 +93,9 @@ type PackStore struct {
 
 	ListExplicitHostsInPackFunc        ListExplicitHostsInPackFunc
 	ListExplicitHostsInPackFuncInvoked bool
+
+	ListHostsMissingPackFunc        ListHostsMissingPackFunc
+	ListHostsMissingPackFuncInvoked bool
 }
 
 func (s *PackStore) ApplyPackSpecs(specs []*kolide.PackSpec) error {
@@ -177,3 +182,8 @@ func (s *PackStore) ListExplicitHostsInPack(pid uint, opt kolide.ListOptions) ([
 	s.ListExplicitHostsInPackFuncInvoked = true
 	return s.ListExplicitHostsInPackFunc(pid, opt)
 }
+
+func (s *PackStore) ListHostsMissingPack(pid uint, opt kolide.HostListOptions) ([]kolide.Host, error) {
+	s.ListHostsMissingPackFuncInvoked = true
+	return s.ListHostsMissingPackFunc(pid, opt)
+}
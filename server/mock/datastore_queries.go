@@ -2,7 +2,11 @@
 
 package mock
 
-import "github.com/kolide/fleet/server/kolide"
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
 
 var _ kolide.QueryStore = (*QueryStore)(nil)
 
@@ -18,10 +22,16 @@ type DeleteQueriesFunc func(ids []uint) (uint, error)
 
 type QueryFunc func(id uint) (*kolide.Query, error)
 
-type ListQueriesFunc func(opt kolide.ListOptions) ([]*kolide.Query, error)
+type ListQueriesFunc func(opt kolide.ListQueryOptions) ([]*kolide.Query, error)
 
 type QueryByNameFunc func(name string, opts ...kolide.OptionalArg) (*kolide.Query, error)
 
+type RestoreQueryFunc func(id uint) error
+
+type PurgeDeletedQueriesFunc func(before time.Time) (uint, error)
+
+type ListQueryTagsFunc func() ([]kolide.QueryTag, error)
+
 type QueryStore struct {
 	ApplyQueriesFunc        ApplyQueriesFunc
 	ApplyQueriesFuncInvoked bool
@@ -46,6 +56,15 @@ type QueryStore struct {
 
 	QueryByNameFunc        QueryByNameFunc
 	QueryByNameFuncInvoked bool
+
+	RestoreQueryFunc        RestoreQueryFunc
+	RestoreQueryFuncInvoked bool
+
+	PurgeDeletedQueriesFunc        PurgeDeletedQueriesFunc
+	PurgeDeletedQueriesFuncInvoked bool
+
+	ListQueryTagsFunc        ListQueryTagsFunc
+	ListQueryTagsFuncInvoked bool
 }
 
 func (s *QueryStore) ApplyQueries(authorID uint, queries []*kolide.Query) error {
@@ -78,7 +97,7 @@ func (s *QueryStore) Query(id uint) (*kolide.Query, error) {
 	return s.QueryFunc(id)
 }
 
-func (s *QueryStore) ListQueries(opt kolide.ListOptions) ([]*kolide.Query, error) {
+func (s *QueryStore) ListQueries(opt kolide.ListQueryOptions) ([]*kolide.Query, error) {
 	s.ListQueriesFuncInvoked = true
 	return s.ListQueriesFunc(opt)
 }
@@ -87,3 +106,18 @@ func (s *QueryStore) QueryByName(name string, opts ...kolide.OptionalArg) (*koli
 	s.QueryByNameFuncInvoked = true
 	return s.QueryByNameFunc(name, opts...)
 }
+
+func (s *QueryStore) RestoreQuery(id uint) error {
+	s.RestoreQueryFuncInvoked = true
+	return s.RestoreQueryFunc(id)
+}
+
+func (s *QueryStore) PurgeDeletedQueries(before time.Time) (uint, error) {
+	s.PurgeDeletedQueriesFuncInvoked = true
+	return s.PurgeDeletedQueriesFunc(before)
+}
+
+func (s *QueryStore) ListQueryTags() ([]kolide.QueryTag, error) {
+	s.ListQueryTagsFuncInvoked = true
+	return s.ListQueryTagsFunc()
+}
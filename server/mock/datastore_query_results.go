@@ -14,6 +14,8 @@ type WriteResultFunc func(result kolide.DistributedQueryResult) error
 
 type ReadChannelFunc func(ctx context.Context, query kolide.DistributedQueryCampaign) (<-chan interface{}, error)
 
+type ReplayResultsFunc func(campaignID uint, after uint64) ([]kolide.DistributedQueryResult, error)
+
 type HealthCheckFunc func() error
 
 type QueryResultStore struct {
@@ -23,6 +25,9 @@ type QueryResultStore struct {
 	ReadChannelFunc        ReadChannelFunc
 	ReadChannelFuncInvoked bool
 
+	ReplayResultsFunc        ReplayResultsFunc
+	ReplayResultsFuncInvoked bool
+
 	HealthCheckFunc        HealthCheckFunc
 	HealthCheckFuncInvoked bool
 }
@@ -37,6 +42,11 @@ func (s *QueryResultStore) ReadChannel(ctx context.Context, query kolide.Distrib
 	return s.ReadChannelFunc(ctx, query)
 }
 
+func (s *QueryResultStore) ReplayResults(campaignID uint, after uint64) ([]kolide.DistributedQueryResult, error) {
+	s.ReplayResultsFuncInvoked = true
+	return s.ReplayResultsFunc(campaignID, after)
+}
+
 func (s *QueryResultStore) HealthCheck() error {
 	s.HealthCheckFuncInvoked = true
 	return s.HealthCheckFunc()
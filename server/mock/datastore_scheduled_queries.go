@@ -16,6 +16,14 @@ type DeleteScheduledQueryFunc func(id uint) error
 
 type ScheduledQueryFunc func(id uint) (*kolide.ScheduledQuery, error)
 
+type ModifyScheduledQueriesInPackFunc func(packID uint, patch kolide.ScheduledQueryPatch) (int, error)
+
+type SaveScheduledQueryStatsFunc func(hostID uint, stats []kolide.ScheduledQueryStats) error
+
+type ScheduledQueryStatsFunc func(packID uint) ([]*kolide.AggregatedScheduledQueryStats, error)
+
+type ListScheduledQueryStatsForHostFunc func(hostID uint) ([]kolide.ScheduledQueryStats, error)
+
 type ScheduledQueryStore struct {
 	ListScheduledQueriesInPackFunc        ListScheduledQueriesInPackFunc
 	ListScheduledQueriesInPackFuncInvoked bool
@@ -31,6 +39,18 @@ type ScheduledQueryStore struct {
 
 	ScheduledQueryFunc        ScheduledQueryFunc
 	ScheduledQueryFuncInvoked bool
+
+	ModifyScheduledQueriesInPackFunc        ModifyScheduledQueriesInPackFunc
+	ModifyScheduledQueriesInPackFuncInvoked bool
+
+	SaveScheduledQueryStatsFunc        SaveScheduledQueryStatsFunc
+	SaveScheduledQueryStatsFuncInvoked bool
+
+	ScheduledQueryStatsFunc        ScheduledQueryStatsFunc
+	ScheduledQueryStatsFuncInvoked bool
+
+	ListScheduledQueryStatsForHostFunc        ListScheduledQueryStatsForHostFunc
+	ListScheduledQueryStatsForHostFuncInvoked bool
 }
 
 func (s *ScheduledQueryStore) ListScheduledQueriesInPack(id uint, opts kolide.ListOptions) ([]*kolide.ScheduledQuery, error) {
@@ -57,3 +77,23 @@ func (s *ScheduledQueryStore) ScheduledQuery(id uint) (*kolide.ScheduledQuery, e
 	s.ScheduledQueryFuncInvoked = true
 	return s.ScheduledQueryFunc(id)
 }
+
+func (s *ScheduledQueryStore) ModifyScheduledQueriesInPack(packID uint, patch kolide.ScheduledQueryPatch) (int, error) {
+	s.ModifyScheduledQueriesInPackFuncInvoked = true
+	return s.ModifyScheduledQueriesInPackFunc(packID, patch)
+}
+
+func (s *ScheduledQueryStore) SaveScheduledQueryStats(hostID uint, stats []kolide.ScheduledQueryStats) error {
+	s.SaveScheduledQueryStatsFuncInvoked = true
+	return s.SaveScheduledQueryStatsFunc(hostID, stats)
+}
+
+func (s *ScheduledQueryStore) ScheduledQueryStats(packID uint) ([]*kolide.AggregatedScheduledQueryStats, error) {
+	s.ScheduledQueryStatsFuncInvoked = true
+	return s.ScheduledQueryStatsFunc(packID)
+}
+
+func (s *ScheduledQueryStore) ListScheduledQueryStatsForHost(hostID uint) ([]kolide.ScheduledQueryStats, error) {
+	s.ListScheduledQueryStatsForHostFuncInvoked = true
+	return s.ListScheduledQueryStatsForHostFunc(hostID)
+}
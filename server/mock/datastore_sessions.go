@@ -2,7 +2,11 @@
 
 package mock
 
-import "github.com/kolide/fleet/server/kolide"
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
 
 var _ kolide.SessionStore = (*SessionStore)(nil)
 
@@ -20,6 +24,8 @@ type DestroyAllSessionsForUserFunc func(id uint) error
 
 type MarkSessionAccessedFunc func(session *kolide.Session) error
 
+type DestroyExpiredSessionsFunc func(createdBefore, accessedBefore time.Time) (uint, error)
+
 type SessionStore struct {
 	SessionByKeyFunc        SessionByKeyFunc
 	SessionByKeyFuncInvoked bool
@@ -41,6 +47,9 @@ type SessionStore struct {
 
 	MarkSessionAccessedFunc        MarkSessionAccessedFunc
 	MarkSessionAccessedFuncInvoked bool
+
+	DestroyExpiredSessionsFunc        DestroyExpiredSessionsFunc
+	DestroyExpiredSessionsFuncInvoked bool
 }
 
 func (s *SessionStore) SessionByKey(key string) (*kolide.Session, error) {
@@ -77,3 +86,8 @@ func (s *SessionStore) MarkSessionAccessed(session *kolide.Session) error {
 	s.MarkSessionAccessedFuncInvoked = true
 	return s.MarkSessionAccessedFunc(session)
 }
+
+func (s *SessionStore) DestroyExpiredSessions(createdBefore, accessedBefore time.Time) (uint, error) {
+	s.DestroyExpiredSessionsFuncInvoked = true
+	return s.DestroyExpiredSessionsFunc(createdBefore, accessedBefore)
+}
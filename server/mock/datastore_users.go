@@ -2,7 +2,11 @@
 
 package mock
 
-import "github.com/kolide/fleet/server/kolide"
+import (
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+)
 
 var _ kolide.UserStore = (*UserStore)(nil)
 
@@ -22,6 +26,12 @@ type PendingEmailChangeFunc func(userID uint, newEmail string, token string) err
 
 type ConfirmPendingEmailChangeFunc func(userID uint, token string) (string, error)
 
+type IncrementFailedLoginsFunc func(userID uint) (uint, error)
+
+type LockUserFunc func(userID uint, until time.Time) error
+
+type ResetFailedLoginsFunc func(userID uint) error
+
 type UserStore struct {
 	NewUserFunc        NewUserFunc
 	NewUserFuncInvoked bool
@@ -46,6 +56,15 @@ type UserStore struct {
 
 	ConfirmPendingEmailChangeFunc        ConfirmPendingEmailChangeFunc
 	ConfirmPendingEmailChangeFuncInvoked bool
+
+	IncrementFailedLoginsFunc        IncrementFailedLoginsFunc
+	IncrementFailedLoginsFuncInvoked bool
+
+	LockUserFunc        LockUserFunc
+	LockUserFuncInvoked bool
+
+	ResetFailedLoginsFunc        ResetFailedLoginsFunc
+	ResetFailedLoginsFuncInvoked bool
 }
 
 func (s *UserStore) NewUser(user *kolide.User) (*kolide.User, error) {
@@ -87,3 +106,18 @@ func (s *UserStore) ConfirmPendingEmailChange(userID uint, token string) (string
 	s.ConfirmPendingEmailChangeFuncInvoked = true
 	return s.ConfirmPendingEmailChangeFunc(userID, token)
 }
+
+func (s *UserStore) IncrementFailedLogins(userID uint) (uint, error) {
+	s.IncrementFailedLoginsFuncInvoked = true
+	return s.IncrementFailedLoginsFunc(userID)
+}
+
+func (s *UserStore) LockUser(userID uint, until time.Time) error {
+	s.LockUserFuncInvoked = true
+	return s.LockUserFunc(userID, until)
+}
+
+func (s *UserStore) ResetFailedLogins(userID uint) error {
+	s.ResetFailedLoginsFuncInvoked = true
+	return s.ResetFailedLoginsFunc(userID)
+}
@@ -1,6 +1,17 @@
 // Package pubsub implements pub/sub interfaces defined in package kolide.
 package pubsub
 
+import "time"
+
+// replayBufferSize is the number of most recent results retained for each
+// campaign so that they can be replayed to reconnecting clients.
+const replayBufferSize = 1000
+
+// replayBufferTTL is how long the replay buffer of a campaign is kept after
+// the last result is written. Campaigns are expired after one day, so there is
+// no use in keeping results for longer.
+const replayBufferTTL = 24 * time.Hour
+
 // Error defines the interface of errors specific to the pubsub package
 type Error interface {
 	error
@@ -4,6 +4,7 @@ import (
 	"context"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/kolide/fleet/server/kolide"
 )
@@ -11,6 +12,20 @@ import (
 type inmemQueryResults struct {
 	resultChannels map[uint]chan interface{}
 	channelMutex   sync.Mutex
+
+	buffers     map[uint]*resultBuffer
+	bufferMutex sync.Mutex
+
+	now func() time.Time
+}
+
+// resultBuffer retains the most recent results of a campaign along with the
+// last sequence number assigned.
+type resultBuffer struct {
+	sequence uint64
+	results  []kolide.DistributedQueryResult
+	// updated is when the last result was written
+	updated time.Time
 }
 
 var _ kolide.QueryResultStore = &inmemQueryResults{}
@@ -18,7 +33,11 @@ var _ kolide.QueryResultStore = &inmemQueryResults{}
 // NewInmemQueryResults initializes a new in-memory implementation of the
 // QueryResultStore interface.
 func NewInmemQueryResults() *inmemQueryResults {
-	return &inmemQueryResults{resultChannels: map[uint]chan interface{}{}}
+	return &inmemQueryResults{
+		resultChannels: map[uint]chan interface{}{},
+		buffers:        map[uint]*resultBuffer{},
+		now:            time.Now,
+	}
 }
 
 func (im *inmemQueryResults) getChannel(id uint) chan interface{} {
@@ -33,7 +52,48 @@ func (im *inmemQueryResults) getChannel(id uint) chan interface{} {
 	return channel
 }
 
+// bufferResult assigns the next sequence number to the result and retains it
+// in the campaign's replay buffer.
+func (im *inmemQueryResults) bufferResult(result *kolide.DistributedQueryResult) {
+	im.bufferMutex.Lock()
+	defer im.bufferMutex.Unlock()
+
+	now := im.now()
+	im.pruneBuffers(now)
+
+	buf, ok := im.buffers[result.DistributedQueryCampaignID]
+	if !ok {
+		buf = &resultBuffer{}
+		im.buffers[result.DistributedQueryCampaignID] = buf
+	}
+	buf.sequence++
+	buf.updated = now
+	result.Sequence = buf.sequence
+	buf.results = append(buf.results, *result)
+	if len(buf.results) > replayBufferSize {
+		buf.results = buf.results[len(buf.results)-replayBufferSize:]
+	}
+}
+
+// pruneBuffers deletes the replay buffers of campaigns that have not had a
+// result written within the replay buffer TTL, as the redis store expires
+// them. The caller must hold the buffer lock.
+func (im *inmemQueryResults) pruneBuffers(now time.Time) {
+	for id, buf := range im.buffers {
+		if now.Sub(buf.updated) > replayBufferTTL {
+			delete(im.buffers, id)
+		}
+	}
+}
+
 func (im *inmemQueryResults) WriteResult(result kolide.DistributedQueryResult) error {
+	im.bufferResult(&result)
+
+	// The lock is held while sending so that the channel is not closed by
+	// a reader that is unsubscribing
+	im.channelMutex.Lock()
+	defer im.channelMutex.Unlock()
+
 	channel, ok := im.resultChannels[result.DistributedQueryCampaignID]
 	if !ok {
 		return noSubscriberError{strconv.Itoa(int(result.DistributedQueryCampaignID))}
@@ -53,14 +113,33 @@ func (im *inmemQueryResults) ReadChannel(ctx context.Context, query kolide.Distr
 	channel := im.getChannel(query.ID)
 	go func() {
 		<-ctx.Done()
-		close(channel)
 		im.channelMutex.Lock()
 		delete(im.resultChannels, query.ID)
+		close(channel)
 		im.channelMutex.Unlock()
 	}()
 	return channel, nil
 }
 
+func (im *inmemQueryResults) ReplayResults(campaignID uint, after uint64) ([]kolide.DistributedQueryResult, error) {
+	im.bufferMutex.Lock()
+	defer im.bufferMutex.Unlock()
+
+	im.pruneBuffers(im.now())
+
+	buf, ok := im.buffers[campaignID]
+	if !ok {
+		return nil, nil
+	}
+	var results []kolide.DistributedQueryResult
+	for _, res := range buf.results {
+		if res.Sequence > after {
+			results = append(results, res)
+		}
+	}
+	return results, nil
+}
+
 func (im *inmemQueryResults) HealthCheck() error {
 	return nil
 }
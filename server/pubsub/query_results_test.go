@@ -41,6 +41,7 @@ func functionName(f func(*testing.T, kolide.QueryResultStore)) string {
 var testFunctions = [...]func(*testing.T, kolide.QueryResultStore){
 	testQueryResultsStore,
 	testQueryResultsStoreErrors,
+	testQueryResultsReplay,
 }
 
 func TestRedis(t *testing.T) {
@@ -68,6 +69,31 @@ func TestInmem(t *testing.T) {
 	}
 }
 
+func TestInmemPrunesReplayBuffers(t *testing.T) {
+	store := NewInmemQueryResults()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	store.WriteResult(kolide.DistributedQueryResult{DistributedQueryCampaignID: 1})
+	now = now.Add(replayBufferTTL / 2)
+	store.WriteResult(kolide.DistributedQueryResult{DistributedQueryCampaignID: 2})
+
+	// Buffers are kept until no result was written for the TTL
+	now = now.Add(replayBufferTTL / 2)
+	replayed, err := store.ReplayResults(1, 0)
+	require.Nil(t, err)
+	assert.Len(t, replayed, 1)
+
+	now = now.Add(time.Second)
+	replayed, err = store.ReplayResults(1, 0)
+	require.Nil(t, err)
+	assert.Empty(t, replayed)
+	replayed, err = store.ReplayResults(2, 0)
+	require.Nil(t, err)
+	assert.Len(t, replayed, 1)
+	assert.Len(t, store.buffers, 1)
+}
+
 func setupRedis(t *testing.T) (store *redisQueryResults, teardown func()) {
 	var (
 		addr     = "127.0.0.1:6379"
@@ -234,9 +260,14 @@ func testQueryResultsStore(t *testing.T, store kolide.QueryResultStore) {
 	readerWg.Add(1)
 	go func() {
 		defer readerWg.Done()
+		var lastSequence uint64
 		for res := range channel1 {
 			switch res := res.(type) {
 			case kolide.DistributedQueryResult:
+				// Sequence numbers are assigned by the store
+				assert.True(t, res.Sequence > lastSequence)
+				lastSequence = res.Sequence
+				res.Sequence = 0
 				results1 = append(results1, res)
 			}
 		}
@@ -245,9 +276,14 @@ func testQueryResultsStore(t *testing.T, store kolide.QueryResultStore) {
 	readerWg.Add(1)
 	go func() {
 		defer readerWg.Done()
+		var lastSequence uint64
 		for res := range channel2 {
 			switch res := res.(type) {
 			case kolide.DistributedQueryResult:
+				// Sequence numbers are assigned by the store
+				assert.True(t, res.Sequence > lastSequence)
+				lastSequence = res.Sequence
+				res.Sequence = 0
 				results2 = append(results2, res)
 			}
 		}
@@ -287,3 +323,44 @@ func testQueryResultsStore(t *testing.T, store kolide.QueryResultStore) {
 	assert.EqualValues(t, expected1, results1)
 	assert.EqualValues(t, expected2, results2)
 }
+
+func testQueryResultsReplay(t *testing.T, store kolide.QueryResultStore) {
+	campaignID := uint(3)
+
+	// Results are retained for replay even when there is no subscriber
+	for _, val := range []string{"a", "b", "c"} {
+		err := store.WriteResult(kolide.DistributedQueryResult{
+			DistributedQueryCampaignID: campaignID,
+			Rows:                       []map[string]string{{"val": val}},
+		})
+		if err != nil {
+			castErr, ok := err.(Error)
+			require.True(t, ok, "err should be pubsub.Error")
+			require.True(t, castErr.NoSubscriber(), "NoSubscriber() should be true")
+		}
+	}
+
+	all, err := store.ReplayResults(campaignID, 0)
+	require.Nil(t, err)
+	require.True(t, len(all) >= 3)
+	all = all[len(all)-3:]
+	assert.Equal(t, "a", all[0].Rows[0]["val"])
+	assert.Equal(t, all[0].Sequence+1, all[1].Sequence)
+	assert.Equal(t, all[1].Sequence+1, all[2].Sequence)
+
+	// Only results after the cursor are replayed
+	replayed, err := store.ReplayResults(campaignID, all[0].Sequence)
+	require.Nil(t, err)
+	require.Len(t, replayed, 2)
+	assert.Equal(t, "b", replayed[0].Rows[0]["val"])
+	assert.Equal(t, "c", replayed[1].Rows[0]["val"])
+
+	replayed, err = store.ReplayResults(campaignID, all[2].Sequence)
+	require.Nil(t, err)
+	assert.Len(t, replayed, 0)
+
+	// Campaigns without results have nothing to replay
+	replayed, err = store.ReplayResults(9998, 0)
+	require.Nil(t, err)
+	assert.Len(t, replayed, 0)
+}
@@ -57,17 +57,45 @@ func pubSubForID(id uint) string {
 	return fmt.Sprintf("results_%d", id)
 }
 
+func sequenceKeyForID(id uint) string {
+	return fmt.Sprintf("results_sequence_%d", id)
+}
+
+func bufferKeyForID(id uint) string {
+	return fmt.Sprintf("results_buffer_%d", id)
+}
+
 func (r *redisQueryResults) WriteResult(result kolide.DistributedQueryResult) error {
 	conn := r.pool.Get()
 	defer conn.Close()
 
 	channelName := pubSubForID(result.DistributedQueryCampaignID)
+	sequenceKey := sequenceKeyForID(result.DistributedQueryCampaignID)
+	bufferKey := bufferKeyForID(result.DistributedQueryCampaignID)
+
+	seq, err := redis.Uint64(conn.Do("INCR", sequenceKey))
+	if err != nil {
+		return errors.Wrap(err, "INCR failed for "+sequenceKey)
+	}
+	result.Sequence = seq
 
 	jsonVal, err := json.Marshal(&result)
 	if err != nil {
 		return errors.Wrap(err, "marshalling JSON for result")
 	}
 
+	// Retain the result for replay, trimming the buffer to the most recent
+	// results
+	ttl := int(replayBufferTTL.Seconds())
+	conn.Send("MULTI")
+	conn.Send("RPUSH", bufferKey, string(jsonVal))
+	conn.Send("LTRIM", bufferKey, -replayBufferSize, -1)
+	conn.Send("EXPIRE", bufferKey, ttl)
+	conn.Send("EXPIRE", sequenceKey, ttl)
+	if _, err := conn.Do("EXEC"); err != nil {
+		return errors.Wrap(err, "buffering result for "+bufferKey)
+	}
+
 	n, err := redis.Int(conn.Do("PUBLISH", channelName, string(jsonVal)))
 	if err != nil {
 		return errors.Wrap(err, "PUBLISH failed to channel "+channelName)
@@ -154,6 +182,29 @@ func (r *redisQueryResults) ReadChannel(ctx context.Context, query kolide.Distri
 	return outChannel, nil
 }
 
+func (r *redisQueryResults) ReplayResults(campaignID uint, after uint64) ([]kolide.DistributedQueryResult, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	bufferKey := bufferKeyForID(campaignID)
+	vals, err := redis.ByteSlices(conn.Do("LRANGE", bufferKey, 0, -1))
+	if err != nil {
+		return nil, errors.Wrap(err, "LRANGE failed for "+bufferKey)
+	}
+
+	var results []kolide.DistributedQueryResult
+	for _, val := range vals {
+		var res kolide.DistributedQueryResult
+		if err := json.Unmarshal(val, &res); err != nil {
+			return nil, errors.Wrap(err, "unmarshalling buffered result")
+		}
+		if res.Sequence > after {
+			results = append(results, res)
+		}
+	}
+	return results, nil
+}
+
 // HealthCheck verifies that the redis backend can be pinged, returning an error
 // otherwise.
 func (r *redisQueryResults) HealthCheck() error {
@@ -0,0 +1,174 @@
+// Package ratelimit provides request rate limiting for Fleet endpoints.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/WatchBeam/clock"
+)
+
+// Limiter decides whether a request identified by key may proceed.
+// Implementations must be safe for concurrent use. The in-memory TokenBucket
+// is suitable for a single Fleet server, while a shared backend (such as
+// Redis) can be used to enforce a budget across multiple servers.
+type Limiter interface {
+	// Allow consumes a token for key if one is available. When the request
+	// is not allowed, retryAfter indicates how long the caller should wait
+	// before a token will become available.
+	Allow(key string) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// TokenBucket is an in-memory Limiter that maintains an independent bucket
+// of tokens for each key. Buckets hold at most one second worth of tokens and
+// are refilled continuously at the configured rate.
+type TokenBucket struct {
+	mtx     sync.Mutex
+	clock   clock.Clock
+	rate    float64
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a TokenBucket allowing perSecond requests per second
+// for each key.
+func NewTokenBucket(perSecond int, c clock.Clock) *TokenBucket {
+	return &TokenBucket{
+		clock:   c,
+		rate:    float64(perSecond),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow implements Limiter.
+func (tb *TokenBucket) Allow(key string) (bool, time.Duration, error) {
+	tb.mtx.Lock()
+	defer tb.mtx.Unlock()
+
+	now := tb.clock.Now()
+	b, ok := tb.buckets[key]
+	if !ok {
+		b = &bucket{tokens: tb.rate, last: now}
+		tb.buckets[key] = b
+	}
+
+	// Refill tokens for the time elapsed since the last request, never
+	// exceeding the burst capacity.
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * tb.rate
+		if b.tokens > tb.rate {
+			b.tokens = tb.rate
+		}
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+
+	wait := time.Duration((1 - b.tokens) / tb.rate * float64(time.Second))
+	return false, wait, nil
+}
+
+// Budget is an in-memory allowance of units, such as log rows, that each key
+// may consume per fixed window. Unlike TokenBucket, a single call may consume
+// many units at once. As a Limiter, each request consumes a single unit.
+type Budget struct {
+	mtx     sync.Mutex
+	clock   clock.Clock
+	limit   int
+	window  time.Duration
+	windows map[string]*budgetWindow
+	// swept is when the expired windows were last removed, so that keys
+	// seen once, such as client IPs, are not kept forever.
+	swept time.Time
+}
+
+type budgetWindow struct {
+	start time.Time
+	used  int
+}
+
+// NewBudget creates a Budget allowing limit units per window for each key.
+func NewBudget(limit int, window time.Duration, c clock.Clock) *Budget {
+	return &Budget{
+		clock:   c,
+		limit:   limit,
+		window:  window,
+		windows: make(map[string]*budgetWindow),
+	}
+}
+
+// Limit returns the number of units allowed per window for each key.
+func (b *Budget) Limit() int {
+	return b.limit
+}
+
+// Take consumes up to n units for key and returns the number consumed. When
+// fewer than n units were consumed, retryAfter indicates how long until the
+// budget for key is renewed.
+func (b *Budget) Take(key string, n int) (taken int, retryAfter time.Duration) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	now := b.clock.Now()
+	w := b.currentWindow(key, now)
+
+	taken = b.limit - w.used
+	if taken > n {
+		taken = n
+	}
+	w.used += taken
+	if taken < n {
+		retryAfter = w.start.Add(b.window).Sub(now)
+	}
+	return taken, retryAfter
+}
+
+// TakeAll consumes n units for key only if all of them are within the
+// budget. Otherwise nothing is consumed, and retryAfter indicates how long
+// until the budget for key is renewed.
+func (b *Budget) TakeAll(key string, n int) (ok bool, retryAfter time.Duration) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	now := b.clock.Now()
+	w := b.currentWindow(key, now)
+
+	if b.limit-w.used < n {
+		return false, w.start.Add(b.window).Sub(now)
+	}
+	w.used += n
+	return true, 0
+}
+
+// currentWindow returns the window of key at now, starting a new window when
+// the previous one has expired. b.mtx must be held.
+func (b *Budget) currentWindow(key string, now time.Time) *budgetWindow {
+	if now.Sub(b.swept) >= b.window {
+		for k, w := range b.windows {
+			if now.Sub(w.start) >= b.window {
+				delete(b.windows, k)
+			}
+		}
+		b.swept = now
+	}
+
+	w, ok := b.windows[key]
+	if !ok || now.Sub(w.start) >= b.window {
+		w = &budgetWindow{start: now}
+		b.windows[key] = w
+	}
+	return w
+}
+
+// Allow implements Limiter.
+func (b *Budget) Allow(key string) (bool, time.Duration, error) {
+	taken, retryAfter := b.Take(key, 1)
+	return taken == 1, retryAfter, nil
+}
@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/WatchBeam/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	c := clock.NewMockClock()
+	tb := NewTokenBucket(2, c)
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := tb.Allow("enroll")
+		require.Nil(t, err)
+		assert.True(t, allowed)
+	}
+
+	allowed, retryAfter, err := tb.Allow("enroll")
+	require.Nil(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// Other keys have their own budget
+	allowed, _, err = tb.Allow("config")
+	require.Nil(t, err)
+	assert.True(t, allowed)
+
+	c.AddTime(500 * time.Millisecond)
+	allowed, _, err = tb.Allow("enroll")
+	require.Nil(t, err)
+	assert.True(t, allowed)
+
+	// Tokens do not accumulate beyond the burst capacity
+	c.AddTime(time.Hour)
+	for i := 0; i < 2; i++ {
+		allowed, _, err = tb.Allow("enroll")
+		require.Nil(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, _, err = tb.Allow("enroll")
+	require.Nil(t, err)
+	assert.False(t, allowed)
+}
+
+func TestBudget(t *testing.T) {
+	c := clock.NewMockClock()
+	b := NewBudget(10, time.Minute, c)
+
+	taken, retryAfter := b.Take("host1", 6)
+	assert.Equal(t, 6, taken)
+	assert.Equal(t, time.Duration(0), retryAfter)
+
+	// Only the remainder of the budget is taken
+	c.AddTime(15 * time.Second)
+	taken, retryAfter = b.Take("host1", 6)
+	assert.Equal(t, 4, taken)
+	assert.Equal(t, 45*time.Second, retryAfter)
+
+	taken, _ = b.Take("host1", 1)
+	assert.Equal(t, 0, taken)
+
+	// Other keys have their own budget
+	taken, _ = b.Take("host2", 10)
+	assert.Equal(t, 10, taken)
+
+	// The budget is renewed after the window
+	c.AddTime(45 * time.Second)
+	taken, retryAfter = b.Take("host1", 3)
+	assert.Equal(t, 3, taken)
+	assert.Equal(t, time.Duration(0), retryAfter)
+}
+
+func TestBudgetTakeAll(t *testing.T) {
+	c := clock.NewMockClock()
+	b := NewBudget(10, time.Minute, c)
+
+	ok, retryAfter := b.TakeAll("host1", 6)
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), retryAfter)
+
+	// Nothing is taken when the units do not all fit
+	c.AddTime(15 * time.Second)
+	ok, retryAfter = b.TakeAll("host1", 6)
+	assert.False(t, ok)
+	assert.Equal(t, 45*time.Second, retryAfter)
+
+	ok, _ = b.TakeAll("host1", 4)
+	assert.True(t, ok)
+	ok, _ = b.TakeAll("host1", 1)
+	assert.False(t, ok)
+
+	// The budget is renewed after the window
+	c.AddTime(45 * time.Second)
+	ok, _ = b.TakeAll("host1", 10)
+	assert.True(t, ok)
+}
+
+func TestBudgetAllow(t *testing.T) {
+	c := clock.NewMockClock()
+	b := NewBudget(2, time.Minute, c)
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := b.Allow("login:10.0.0.1")
+		require.Nil(t, err)
+		assert.True(t, allowed)
+	}
+
+	c.AddTime(20 * time.Second)
+	allowed, retryAfter, err := b.Allow("login:10.0.0.1")
+	require.Nil(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 40*time.Second, retryAfter)
+
+	allowed, _, err = b.Allow("login:10.0.0.2")
+	require.Nil(t, err)
+	assert.True(t, allowed)
+
+	// Expired windows are removed
+	c.AddTime(2 * time.Minute)
+	allowed, _, err = b.Allow("login:10.0.0.1")
+	require.Nil(t, err)
+	assert.True(t, allowed)
+	assert.Len(t, b.windows, 1)
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+// campaignAggregate groups the identical rows returned by the hosts in a
+// campaign, counting how many hosts returned each distinct row. It is updated
+// as each result arrives, so that clients do not need to deduplicate the raw
+// results themselves.
+type campaignAggregate struct {
+	// rows is keyed by the JSON encoding of the row, which has sorted keys
+	rows map[string]*aggregateRow
+}
+
+// aggregateRow is a distinct result row and the number of hosts that returned
+// it.
+type aggregateRow struct {
+	Row   map[string]string `json:"row"`
+	Count uint              `json:"count"`
+}
+
+// aggregateUpdate is sent to clients that requested aggregation, holding the
+// current counts of the rows that changed.
+type aggregateUpdate struct {
+	Rows []aggregateRow `json:"rows"`
+}
+
+func newCampaignAggregate() *campaignAggregate {
+	return &campaignAggregate{rows: make(map[string]*aggregateRow)}
+}
+
+// add counts the rows of res and returns the updated counts of those rows.
+// Rows repeated within a single result count once, and results with an error
+// are ignored.
+func (a *campaignAggregate) add(res kolide.DistributedQueryResult) []aggregateRow {
+	if res.Error != nil {
+		return nil
+	}
+	var changed []aggregateRow
+	seen := make(map[string]bool)
+	for _, row := range res.Rows {
+		b, err := json.Marshal(row)
+		if err != nil {
+			continue
+		}
+		key := string(b)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		agg, ok := a.rows[key]
+		if !ok {
+			// Copy the row, as the raw result rows are modified before
+			// being sent to the client
+			copied := make(map[string]string, len(row))
+			for k, v := range row {
+				copied[k] = v
+			}
+			agg = &aggregateRow{Row: copied}
+			a.rows[key] = agg
+		}
+		agg.Count++
+		changed = append(changed, *agg)
+	}
+	return changed
+}
+
+// all returns the counts of every distinct row, most common first.
+func (a *campaignAggregate) all() []aggregateRow {
+	keys := make([]string, 0, len(a.rows))
+	for key := range a.rows {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ci, cj := a.rows[keys[i]].Count, a.rows[keys[j]].Count
+		if ci != cj {
+			return ci > cj
+		}
+		return keys[i] < keys[j]
+	})
+	rows := make([]aggregateRow, len(keys))
+	for i, key := range keys {
+		rows[i] = *a.rows[key]
+	}
+	return rows
+}
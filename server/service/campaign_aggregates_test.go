@@ -0,0 +1,45 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCampaignAggregate(t *testing.T) {
+	agg := newCampaignAggregate()
+
+	set := map[string]string{"name": "EnableLUA", "data": "1"}
+	unset := map[string]string{"name": "EnableLUA", "data": "0"}
+
+	changed := agg.add(kolide.DistributedQueryResult{
+		Host: kolide.Host{HostName: "foo"},
+		Rows: []map[string]string{{"name": "EnableLUA", "data": "1"}, {"data": "1", "name": "EnableLUA"}},
+	})
+	// Identical rows from the same host count once
+	assert.Equal(t, []aggregateRow{{Row: set, Count: 1}}, changed)
+
+	changed = agg.add(kolide.DistributedQueryResult{
+		Host: kolide.Host{HostName: "bar"},
+		Rows: []map[string]string{{"name": "EnableLUA", "data": "1"}},
+	})
+	assert.Equal(t, []aggregateRow{{Row: set, Count: 2}}, changed)
+
+	rows := []map[string]string{{"name": "EnableLUA", "data": "0"}}
+	changed = agg.add(kolide.DistributedQueryResult{Host: kolide.Host{HostName: "baz"}, Rows: rows})
+	assert.Equal(t, []aggregateRow{{Row: unset, Count: 1}}, changed)
+
+	// Modifying the raw rows does not modify the aggregate
+	mapHostnameRows("baz", rows)
+
+	errMsg := "failed"
+	changed = agg.add(kolide.DistributedQueryResult{
+		Host:  kolide.Host{HostName: "qux"},
+		Rows:  []map[string]string{{"name": "EnableLUA", "data": "0"}},
+		Error: &errMsg,
+	})
+	assert.Empty(t, changed)
+
+	assert.Equal(t, []aggregateRow{{Row: set, Count: 2}, {Row: unset, Count: 1}}, agg.all())
+}
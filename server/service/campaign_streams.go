@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/WatchBeam/clock"
+)
+
+// campaignStreams tracks the campaign result streams served by this
+// process, so that they can be drained before the server shuts down.
+type campaignStreams struct {
+	// ctx is cancelled when draining begins. Each stream derives its
+	// context from ctx.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mtx      sync.Mutex
+	draining bool
+	wg       sync.WaitGroup
+	// watchers holds, for each campaign, the channels of the streams that
+	// are closed when the campaign is stopped.
+	watchers map[uint][]chan struct{}
+	// abandoned holds the timers of the campaigns whose streams all
+	// disconnected, which complete the campaign unless a client resumes
+	// streaming it first.
+	abandoned map[uint]clock.Timer
+	clock     clock.Clock
+}
+
+func newCampaignStreams(c clock.Clock) *campaignStreams {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &campaignStreams{
+		ctx:       ctx,
+		cancel:    cancel,
+		watchers:  make(map[uint][]chan struct{}),
+		abandoned: make(map[uint]clock.Timer),
+		clock:     c,
+	}
+}
+
+// add registers a new stream. It returns false if the streams are draining,
+// in which case the stream must not be started.
+func (s *campaignStreams) add() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.draining {
+		return false
+	}
+	s.wg.Add(1)
+	return true
+}
+
+// done marks a stream registered with add as finished.
+func (s *campaignStreams) done() {
+	s.wg.Done()
+}
+
+// drain cancels the context of every stream and waits for the streams to
+// finish, or for ctx to be done.
+func (s *campaignStreams) drain(ctx context.Context) error {
+	s.mtx.Lock()
+	s.draining = true
+	s.mtx.Unlock()
+	s.cancel()
+
+	finished := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// watch returns a channel that is closed when the campaign is stopped, and a
+// function that the stream must call once it no longer watches the campaign.
+func (s *campaignStreams) watch(campaignID uint) (<-chan struct{}, func()) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// A resuming client keeps the campaign running
+	if timer, ok := s.abandoned[campaignID]; ok {
+		timer.Stop()
+		delete(s.abandoned, campaignID)
+	}
+
+	stopped := make(chan struct{})
+	s.watchers[campaignID] = append(s.watchers[campaignID], stopped)
+
+	unwatch := func() {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+		watchers := s.watchers[campaignID]
+		for i, w := range watchers {
+			if w == stopped {
+				watchers = append(watchers[:i], watchers[i+1:]...)
+				break
+			}
+		}
+		if len(watchers) == 0 {
+			delete(s.watchers, campaignID)
+		} else {
+			s.watchers[campaignID] = watchers
+		}
+	}
+	return stopped, unwatch
+}
+
+// abandon calls complete once no stream has watched the campaign for the
+// given window. It does nothing while other streams still watch the
+// campaign, as the last of them to finish abandons it.
+func (s *campaignStreams) abandon(campaignID uint, window time.Duration, complete func()) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if len(s.watchers[campaignID]) > 0 {
+		return
+	}
+	if timer, ok := s.abandoned[campaignID]; ok {
+		timer.Stop()
+	}
+
+	var timer clock.Timer
+	timer = s.clock.AfterFunc(window, func() {
+		s.mtx.Lock()
+		if s.abandoned[campaignID] != timer || len(s.watchers[campaignID]) > 0 {
+			s.mtx.Unlock()
+			return
+		}
+		delete(s.abandoned, campaignID)
+		s.mtx.Unlock()
+		complete()
+	})
+	s.abandoned[campaignID] = timer
+}
+
+// stop notifies the streams of the campaign in this process that it was
+// stopped.
+func (s *campaignStreams) stop(campaignID uint) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for _, stopped := range s.watchers[campaignID] {
+		close(stopped)
+	}
+	delete(s.watchers, campaignID)
+}
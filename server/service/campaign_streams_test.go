@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/WatchBeam/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCampaignStreamsDrain(t *testing.T) {
+	streams := newCampaignStreams(clock.NewMockClock())
+
+	require.True(t, streams.add())
+	stopped := make(chan struct{})
+	go func() {
+		defer streams.done()
+		<-streams.ctx.Done()
+		close(stopped)
+	}()
+
+	require.Nil(t, streams.drain(context.Background()))
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("drain returned before the stream finished")
+	}
+
+	// No new streams are accepted once draining has begun
+	assert.False(t, streams.add())
+}
+
+func TestCampaignStreamsDrainTimeout(t *testing.T) {
+	streams := newCampaignStreams(clock.NewMockClock())
+
+	// A stream that never finishes
+	require.True(t, streams.add())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, streams.drain(ctx))
+}
+
+func TestCampaignStreamsStop(t *testing.T) {
+	streams := newCampaignStreams(clock.NewMockClock())
+
+	first, unwatchFirst := streams.watch(1)
+	second, unwatchSecond := streams.watch(1)
+	other, unwatchOther := streams.watch(2)
+	defer unwatchOther()
+
+	// Streams that no longer watch the campaign are not notified
+	unwatchSecond()
+	streams.stop(1)
+	unwatchFirst()
+
+	select {
+	case <-first:
+	default:
+		t.Fatal("stream of the stopped campaign was not notified")
+	}
+	select {
+	case <-second:
+		t.Fatal("unwatched stream was notified")
+	case <-other:
+		t.Fatal("stream of another campaign was notified")
+	default:
+	}
+
+	// Stopping a campaign without streams does nothing
+	streams.stop(3)
+}
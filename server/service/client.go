@@ -21,6 +21,9 @@ type Client struct {
 	token              string
 	http               *http.Client
 	insecureSkipVerify bool
+	// liveQueryTransport is the transport used to stream live query
+	// results, the websocket by default
+	liveQueryTransport string
 }
 
 func NewClient(addr string, insecureSkipVerify bool, rootCA, urlPrefix string) (*Client, error) {
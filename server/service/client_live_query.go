@@ -3,17 +3,44 @@ package service
 import (
 	"crypto/tls"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"sync/atomic"
 	"time"
 
 	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/sse"
 	ws "github.com/kolide/fleet/server/websocket"
 
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 )
 
+// Transports for streaming live query results
+const (
+	LiveQueryTransportWebsocket = "websocket"
+	LiveQueryTransportSSE       = "sse"
+)
+
+// SetLiveQueryTransport sets the transport used to stream live query
+// results. Server-sent events can be used where proxies do not support
+// websockets.
+func (c *Client) SetLiveQueryTransport(transport string) error {
+	switch transport {
+	case LiveQueryTransportWebsocket, LiveQueryTransportSSE:
+	default:
+		return errors.Errorf("unknown live query transport %q", transport)
+	}
+	c.liveQueryTransport = transport
+	return nil
+}
+
+// liveQueryMessage is a message of a live query results stream.
+type liveQueryMessage struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
 // LiveQueryResultsHandler provides access to all of the information about an
 // incoming stream of live query results.
 type LiveQueryResultsHandler struct {
@@ -87,53 +114,25 @@ func (c *Client) LiveQuery(query string, labels []string, hosts []string) (*Live
 		return nil, errors.Errorf("create live query: %s", responseBody.Err)
 	}
 
-	// Copy default dialer but skip cert verification if set.
-	dialer := &websocket.Dialer{
-		Proxy:            http.ProxyFromEnvironment,
-		HandshakeTimeout: 45 * time.Second,
-		TLSClientConfig:  &tls.Config{InsecureSkipVerify: c.insecureSkipVerify},
+	var read func() (liveQueryMessage, error)
+	var closeStream func() error
+	if c.liveQueryTransport == LiveQueryTransportSSE {
+		read, closeStream, err = c.streamLiveQueryEvents(responseBody.Campaign.ID)
+	} else {
+		read, closeStream, err = c.streamLiveQueryWebsocket(responseBody.Campaign.ID)
 	}
-
-	wssURL := *c.baseURL
-	wssURL.Scheme = "wss"
-	wssURL.Path = c.urlPrefix + "/api/v1/kolide/results/websocket"
-	conn, _, err := dialer.Dial(wssURL.String(), nil)
 	if err != nil {
-		return nil, errors.Wrap(err, "upgrade live query result websocket")
-	}
-	// Cannot defer connection closing here because we need it to remain
-	// open for the goroutine below. Manually close for the couple of error
-	// cases below until we enter that goroutine.
-
-	err = conn.WriteJSON(ws.JSONMessage{
-		Type: "auth",
-		Data: map[string]interface{}{"token": c.token},
-	})
-	if err != nil {
-		_ = conn.Close()
-		return nil, errors.Wrap(err, "auth for results")
-	}
-
-	err = conn.WriteJSON(ws.JSONMessage{
-		Type: "select_campaign",
-		Data: map[string]interface{}{"campaign_id": responseBody.Campaign.ID},
-	})
-	if err != nil {
-		_ = conn.Close()
-		return nil, errors.Wrap(err, "auth for results")
+		return nil, err
 	}
 
 	resHandler := NewLiveQueryResultsHandler()
 	go func() {
-		defer conn.Close()
+		defer closeStream()
 		for {
-			msg := struct {
-				Type string          `json:"type"`
-				Data json.RawMessage `json:"data"`
-			}{}
-			err := conn.ReadJSON(&msg)
+			msg, err := read()
 			if err != nil {
-				resHandler.errors <- errors.Wrap(err, "receive ws message")
+				resHandler.errors <- errors.Wrap(err, "receive results message")
+				return
 			}
 
 			switch msg.Type {
@@ -166,3 +165,88 @@ func (c *Client) LiveQuery(query string, labels []string, hosts []string) (*Live
 
 	return resHandler, nil
 }
+
+// streamLiveQueryWebsocket opens the websocket streaming the results of the
+// campaign, returning functions to read its messages and to close it.
+func (c *Client) streamLiveQueryWebsocket(campaignID uint) (func() (liveQueryMessage, error), func() error, error) {
+	// Copy default dialer but skip cert verification if set.
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 45 * time.Second,
+		TLSClientConfig:  &tls.Config{InsecureSkipVerify: c.insecureSkipVerify},
+	}
+
+	wssURL := *c.baseURL
+	wssURL.Scheme = "wss"
+	wssURL.Path = c.urlPrefix + "/api/v1/kolide/results/websocket"
+	conn, _, err := dialer.Dial(wssURL.String(), nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "upgrade live query result websocket")
+	}
+	// Cannot defer connection closing here because we need it to remain
+	// open for the reader. Manually close for the couple of error cases
+	// below.
+
+	err = conn.WriteJSON(ws.JSONMessage{
+		Type: "auth",
+		Data: map[string]interface{}{"token": c.token},
+	})
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, errors.Wrap(err, "auth for results")
+	}
+
+	err = conn.WriteJSON(ws.JSONMessage{
+		Type: "select_campaign",
+		Data: map[string]interface{}{"campaign_id": campaignID},
+	})
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, errors.Wrap(err, "auth for results")
+	}
+
+	read := func() (liveQueryMessage, error) {
+		var msg liveQueryMessage
+		err := conn.ReadJSON(&msg)
+		return msg, err
+	}
+	return read, conn.Close, nil
+}
+
+// streamLiveQueryEvents requests the server-sent events streaming the
+// results of the campaign, returning functions to read its messages and to
+// close it.
+func (c *Client) streamLiveQueryEvents(campaignID uint) (func() (liveQueryMessage, error), func() error, error) {
+	path := fmt.Sprintf("/api/v1/kolide/queries/campaigns/%d/results", campaignID)
+	response, err := c.doWithHeaders("GET", path, nil, map[string]string{
+		"Accept":        "text/event-stream",
+		"Authorization": fmt.Sprintf("Bearer %s", c.token),
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "GET "+path)
+	}
+	if response.StatusCode != http.StatusOK {
+		defer response.Body.Close()
+		return nil, nil, errors.Errorf(
+			"stream live query results received status %d %s",
+			response.StatusCode,
+			extractServerErrorText(response.Body),
+		)
+	}
+
+	reader := sse.NewReader(response.Body)
+	read := func() (liveQueryMessage, error) {
+		msg, err := reader.ReadJSONMessage()
+		if err != nil {
+			return liveQueryMessage{}, err
+		}
+		// A null data field leaves no raw message, so it is passed on as
+		// null, as the websocket stream does.
+		data, ok := msg.Data.(*json.RawMessage)
+		if !ok || data == nil {
+			return liveQueryMessage{Type: msg.Type, Data: json.RawMessage("null")}, nil
+		}
+		return liveQueryMessage{Type: msg.Type, Data: *data}, nil
+	}
+	return read, response.Body.Close, nil
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kolide/fleet/server/kolide"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// List Activities
+////////////////////////////////////////////////////////////////////////////////
+
+type listActivitiesRequest struct {
+	ListOptions kolide.ListOptions
+}
+
+type listActivitiesResponse struct {
+	Activities []kolide.Activity `json:"activities"`
+	Err        error             `json:"error,omitempty"`
+}
+
+func (r listActivitiesResponse) error() error { return r.Err }
+
+func makeListActivitiesEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listActivitiesRequest)
+		activities, err := svc.ListActivities(ctx, req.ListOptions)
+		if err != nil {
+			return listActivitiesResponse{Err: err}, nil
+		}
+
+		resp := listActivitiesResponse{Activities: []kolide.Activity{}}
+		for _, activity := range activities {
+			resp.Activities = append(resp.Activities, *activity)
+		}
+		return resp, nil
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kolide/fleet/server/kolide"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Create API Token
+////////////////////////////////////////////////////////////////////////////////
+
+type createAPITokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type createAPITokenResponse struct {
+	APIToken *kolide.APIToken `json:"api_token,omitempty"`
+	// Token is the bearer token used to authenticate with the API token.
+	// It is only returned when the API token is created.
+	Token string `json:"token,omitempty"`
+	Err   error  `json:"error,omitempty"`
+}
+
+func (r createAPITokenResponse) error() error { return r.Err }
+
+func (r createAPITokenResponse) activityTargetID() uint { return r.APIToken.ID }
+
+func makeCreateAPITokenEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createAPITokenRequest)
+		apiToken, token, err := svc.CreateAPIToken(ctx, req.Name, req.Scopes)
+		if err != nil {
+			return createAPITokenResponse{Err: err}, nil
+		}
+		return createAPITokenResponse{APIToken: apiToken, Token: token}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// List API Tokens
+////////////////////////////////////////////////////////////////////////////////
+
+type listAPITokensResponse struct {
+	APITokens []kolide.APIToken `json:"api_tokens"`
+	Err       error             `json:"error,omitempty"`
+}
+
+func (r listAPITokensResponse) error() error { return r.Err }
+
+func makeListAPITokensEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		apiTokens, err := svc.ListAPITokens(ctx)
+		if err != nil {
+			return listAPITokensResponse{Err: err}, nil
+		}
+
+		resp := listAPITokensResponse{APITokens: []kolide.APIToken{}}
+		for _, apiToken := range apiTokens {
+			resp.APITokens = append(resp.APITokens, *apiToken)
+		}
+		return resp, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Delete API Token
+////////////////////////////////////////////////////////////////////////////////
+
+type deleteAPITokenRequest struct {
+	ID uint
+}
+
+type deleteAPITokenResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r deleteAPITokenResponse) error() error { return r.Err }
+
+func makeDeleteAPITokenEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deleteAPITokenRequest)
+		err := svc.DeleteAPIToken(ctx, req.ID)
+		if err != nil {
+			return deleteAPITokenResponse{Err: err}, nil
+		}
+		return deleteAPITokenResponse{}, nil
+	}
+}
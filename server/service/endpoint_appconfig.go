@@ -14,13 +14,17 @@ type appConfigRequest struct {
 }
 
 type appConfigResponse struct {
-	OrgInfo            *kolide.OrgInfo             `json:"org_info,omitempty"`
-	ServerSettings     *kolide.ServerSettings      `json:"server_settings,omitempty"`
-	SMTPSettings       *kolide.SMTPSettingsPayload `json:"smtp_settings,omitempty"`
-	SSOSettings        *kolide.SSOSettingsPayload  `json:"sso_settings,omitempty"`
-	HostExpirySettings *kolide.HostExpirySettings  `json:"host_expiry_settings,omitempty"`
-	HostSettings       *kolide.HostSettings        `json:"host_settings,omitempty"`
-	Err                error                       `json:"error,omitempty"`
+	OrgInfo            *kolide.OrgInfo                `json:"org_info,omitempty"`
+	ServerSettings     *kolide.ServerSettings         `json:"server_settings,omitempty"`
+	SMTPSettings       *kolide.SMTPSettingsPayload    `json:"smtp_settings,omitempty"`
+	SSOSettings        *kolide.SSOSettingsPayload     `json:"sso_settings,omitempty"`
+	HostExpirySettings *kolide.HostExpirySettings     `json:"host_expiry_settings,omitempty"`
+	HostSettings       *kolide.HostSettings           `json:"host_settings,omitempty"`
+	WebhookSettings    *kolide.WebhookSettings        `json:"webhook_settings,omitempty"`
+	PasswordPolicy     *kolide.PasswordPolicySettings `json:"password_policy_settings,omitempty"`
+	SessionSettings    *kolide.SessionSettings        `json:"session_settings,omitempty"`
+	QuerySettings      *kolide.QuerySettings          `json:"query_settings,omitempty"`
+	Err                error                          `json:"error,omitempty"`
 }
 
 func (r appConfigResponse) error() error { return r.Err }
@@ -38,7 +42,10 @@ func makeGetAppConfigEndpoint(svc kolide.Service) endpoint.Endpoint {
 		var smtpSettings *kolide.SMTPSettingsPayload
 		var ssoSettings *kolide.SSOSettingsPayload
 		var hostExpirySettings *kolide.HostExpirySettings
-		// only admin can see smtp, sso, and host expiry settings
+		var webhookSettings *kolide.WebhookSettings
+		var sessionSettings *kolide.SessionSettings
+		// only admin can see smtp, sso, host expiry, webhook and session
+		// settings
 		if vc.CanPerformAdminActions() {
 			smtpSettings = smtpSettingsFromAppConfig(config)
 			if smtpSettings.SMTPPassword != nil {
@@ -57,6 +64,11 @@ func makeGetAppConfigEndpoint(svc kolide.Service) endpoint.Endpoint {
 				HostExpiryEnabled: &config.HostExpiryEnabled,
 				HostExpiryWindow:  &config.HostExpiryWindow,
 			}
+			webhookSettings = webhookSettingsFromAppConfig(config)
+			sessionSettings = &kolide.SessionSettings{
+				SessionDuration:    &config.SessionDuration,
+				SessionIdleTimeout: &config.SessionIdleTimeout,
+			}
 		}
 		response := appConfigResponse{
 			OrgInfo: &kolide.OrgInfo{
@@ -71,8 +83,13 @@ func makeGetAppConfigEndpoint(svc kolide.Service) endpoint.Endpoint {
 			SSOSettings:        ssoSettings,
 			HostExpirySettings: hostExpirySettings,
 			HostSettings: &kolide.HostSettings{
-				AdditionalQueries: config.AdditionalQueries,
+				AdditionalQueries:      config.AdditionalQueries,
+				RequiredOsqueryVersion: &config.RequiredOsqueryVersion,
 			},
+			WebhookSettings: webhookSettings,
+			PasswordPolicy:  passwordPolicyFromAppConfig(config),
+			SessionSettings: sessionSettings,
+			QuerySettings:   &kolide.QuerySettings{DenyList: &config.QueryDenyList},
 		}
 		return response, nil
 	}
@@ -108,6 +125,17 @@ func makeModifyAppConfigEndpoint(svc kolide.Service) endpoint.Endpoint {
 				HostExpiryEnabled: &config.HostExpiryEnabled,
 				HostExpiryWindow:  &config.HostExpiryWindow,
 			},
+			HostSettings: &kolide.HostSettings{
+				AdditionalQueries:      config.AdditionalQueries,
+				RequiredOsqueryVersion: &config.RequiredOsqueryVersion,
+			},
+			WebhookSettings: webhookSettingsFromAppConfig(config),
+			PasswordPolicy:  passwordPolicyFromAppConfig(config),
+			SessionSettings: &kolide.SessionSettings{
+				SessionDuration:    &config.SessionDuration,
+				SessionIdleTimeout: &config.SessionIdleTimeout,
+			},
+			QuerySettings: &kolide.QuerySettings{DenyList: &config.QueryDenyList},
 		}
 		if response.SMTPSettings.SMTPPassword != nil {
 			*response.SMTPSettings.SMTPPassword = "********"
@@ -116,6 +144,38 @@ func makeModifyAppConfigEndpoint(svc kolide.Service) endpoint.Endpoint {
 	}
 }
 
+// webhookSettingsFromAppConfig returns the webhook settings with the secret
+// masked, so that it is never returned by the API.
+func webhookSettingsFromAppConfig(config *kolide.AppConfig) *kolide.WebhookSettings {
+	enrollmentSecret, campaignSecret := "", ""
+	if config.EnrollmentWebhookSecret != "" {
+		enrollmentSecret = "********"
+	}
+	if config.CampaignWebhookSecret != "" {
+		campaignSecret = "********"
+	}
+	return &kolide.WebhookSettings{
+		EnrollmentWebhookURL:          &config.EnrollmentWebhookURL,
+		EnrollmentWebhookSecret:       &enrollmentSecret,
+		CampaignWebhookURL:            &config.CampaignWebhookURL,
+		CampaignWebhookSecret:         &campaignSecret,
+		CampaignWebhookRequireResults: &config.CampaignWebhookRequireResults,
+	}
+}
+
+// passwordPolicyFromAppConfig returns the password policy settings. They are
+// returned to all users so that clients can describe the requirements when a
+// password is chosen.
+func passwordPolicyFromAppConfig(config *kolide.AppConfig) *kolide.PasswordPolicySettings {
+	minLength := config.MinPasswordLength()
+	return &kolide.PasswordPolicySettings{
+		MinLength:        &minLength,
+		RequireNumber:    &config.PasswordRequireNumber,
+		RequireSymbol:    &config.PasswordRequireSymbol,
+		RequireMixedCase: &config.PasswordRequireMixedCase,
+	}
+}
+
 func smtpSettingsFromAppConfig(config *kolide.AppConfig) *kolide.SMTPSettingsPayload {
 	authType := config.SMTPAuthenticationType.String()
 	authMethod := config.SMTPAuthenticationMethod.String()
@@ -136,6 +196,32 @@ func smtpSettingsFromAppConfig(config *kolide.AppConfig) *kolide.SMTPSettingsPay
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// Test SMTP Settings
+////////////////////////////////////////////////////////////////////////////////
+
+type testSMTPSettingsRequest struct {
+	SMTPSettings  kolide.SMTPSettingsPayload `json:"smtp_settings"`
+	SendTestEmail bool                       `json:"send_test_email"`
+}
+
+type testSMTPSettingsResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r testSMTPSettingsResponse) error() error { return r.Err }
+
+func makeTestSMTPSettingsEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(testSMTPSettingsRequest)
+		err := svc.TestSMTPSettings(ctx, req.SMTPSettings, req.SendTestEmail)
+		if err != nil {
+			return testSMTPSettingsResponse{Err: err}, nil
+		}
+		return testSMTPSettingsResponse{}, nil
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Apply Enroll Secret Spec
 ////////////////////////////////////////////////////////////////////////////////
@@ -181,3 +267,24 @@ func makeGetEnrollSecretSpecEndpoint(svc kolide.Service) endpoint.Endpoint {
 		return getEnrollSecretSpecResponse{Spec: specs}, nil
 	}
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// Enroll Secret Usage
+////////////////////////////////////////////////////////////////////////////////
+
+type enrollSecretUsageResponse struct {
+	Secrets []kolide.EnrollSecretUsage `json:"secrets"`
+	Err     error                      `json:"error,omitempty"`
+}
+
+func (r enrollSecretUsageResponse) error() error { return r.Err }
+
+func makeEnrollSecretUsageEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		usage, err := svc.EnrollSecretUsage(ctx)
+		if err != nil {
+			return enrollSecretUsageResponse{Err: err}, nil
+		}
+		return enrollSecretUsageResponse{Secrets: usage}, nil
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kolide/fleet/server/kolide"
+)
+
+type atcTableResponse struct {
+	ATCTable *kolide.ATCTable `json:"atc_table,omitempty"`
+	Err      error            `json:"error,omitempty"`
+}
+
+func (r atcTableResponse) error() error { return r.Err }
+
+////////////////////////////////////////////////////////////////////////////////
+// List ATC Tables
+////////////////////////////////////////////////////////////////////////////////
+
+type listATCTablesResponse struct {
+	ATCTables []*kolide.ATCTable `json:"atc_tables"`
+	Err       error              `json:"error,omitempty"`
+}
+
+func (r listATCTablesResponse) error() error { return r.Err }
+
+func makeListATCTablesEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		tables, err := svc.ListATCTables(ctx)
+		if err != nil {
+			return listATCTablesResponse{Err: err}, nil
+		}
+		return listATCTablesResponse{ATCTables: tables}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Get ATC Table
+////////////////////////////////////////////////////////////////////////////////
+
+type getATCTableRequest struct {
+	ID uint
+}
+
+func makeGetATCTableEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getATCTableRequest)
+		table, err := svc.GetATCTable(ctx, req.ID)
+		if err != nil {
+			return atcTableResponse{Err: err}, nil
+		}
+		return atcTableResponse{ATCTable: table}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// New ATC Table
+////////////////////////////////////////////////////////////////////////////////
+
+type newATCTableRequest struct {
+	Payload kolide.ATCTablePayload `json:"payload"`
+}
+
+func makeNewATCTableEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(newATCTableRequest)
+		table, err := svc.NewATCTable(ctx, req.Payload)
+		if err != nil {
+			return atcTableResponse{Err: err}, nil
+		}
+		return atcTableResponse{ATCTable: table}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Modify ATC Table
+////////////////////////////////////////////////////////////////////////////////
+
+type modifyATCTableRequest struct {
+	ID      uint
+	Payload kolide.ATCTablePayload `json:"payload"`
+}
+
+func makeModifyATCTableEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(modifyATCTableRequest)
+		table, err := svc.ModifyATCTable(ctx, req.ID, req.Payload)
+		if err != nil {
+			return atcTableResponse{Err: err}, nil
+		}
+		return atcTableResponse{ATCTable: table}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Delete ATC Table
+////////////////////////////////////////////////////////////////////////////////
+
+type deleteATCTableRequest struct {
+	ID uint
+}
+
+type deleteATCTableResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r deleteATCTableResponse) error() error { return r.Err }
+
+func makeDeleteATCTableEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deleteATCTableRequest)
+		err := svc.DeleteATCTable(ctx, req.ID)
+		if err != nil {
+			return deleteATCTableResponse{Err: err}, nil
+		}
+		return deleteATCTableResponse{}, nil
+	}
+}
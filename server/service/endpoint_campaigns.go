@@ -4,12 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/go-kit/kit/endpoint"
 	kitlog "github.com/go-kit/kit/log"
 	"github.com/igm/sockjs-go/sockjs"
+	"github.com/kolide/fleet/server/contexts/token"
 	"github.com/kolide/fleet/server/contexts/viewer"
 	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/sse"
 	"github.com/kolide/fleet/server/websocket"
 )
 
@@ -18,8 +21,11 @@ import (
 ////////////////////////////////////////////////////////////////////////////////
 
 type createDistributedQueryCampaignRequest struct {
-	Query    string                          `json:"query"`
-	Selected distributedQueryCampaignTargets `json:"selected"`
+	Query            string                          `json:"query"`
+	Selected         distributedQueryCampaignTargets `json:"selected"`
+	ExecutionTimeout uint                            `json:"execution_timeout"`
+	// AllowResubmission allows hosts to report results more than once
+	AllowResubmission bool `json:"allow_resubmission"`
 }
 
 type distributedQueryCampaignTargets struct {
@@ -37,7 +43,7 @@ func (r createDistributedQueryCampaignResponse) error() error { return r.Err }
 func makeCreateDistributedQueryCampaignEndpoint(svc kolide.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(createDistributedQueryCampaignRequest)
-		campaign, err := svc.NewDistributedQueryCampaign(ctx, req.Query, req.Selected.Hosts, req.Selected.Labels)
+		campaign, err := svc.NewDistributedQueryCampaign(ctx, req.Query, req.Selected.Hosts, req.Selected.Labels, req.ExecutionTimeout, req.AllowResubmission)
 		if err != nil {
 			return createDistributedQueryCampaignResponse{Err: err}, nil
 		}
@@ -50,8 +56,11 @@ func makeCreateDistributedQueryCampaignEndpoint(svc kolide.Service) endpoint.End
 ////////////////////////////////////////////////////////////////////////////////
 
 type createDistributedQueryCampaignByNamesRequest struct {
-	Query    string                                 `json:"query"`
-	Selected distributedQueryCampaignTargetsByNames `json:"selected"`
+	Query            string                                 `json:"query"`
+	Selected         distributedQueryCampaignTargetsByNames `json:"selected"`
+	ExecutionTimeout uint                                   `json:"execution_timeout"`
+	// AllowResubmission allows hosts to report results more than once
+	AllowResubmission bool `json:"allow_resubmission"`
 }
 
 type distributedQueryCampaignTargetsByNames struct {
@@ -62,7 +71,53 @@ type distributedQueryCampaignTargetsByNames struct {
 func makeCreateDistributedQueryCampaignByNamesEndpoint(svc kolide.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(createDistributedQueryCampaignByNamesRequest)
-		campaign, err := svc.NewDistributedQueryCampaignByNames(ctx, req.Query, req.Selected.Hosts, req.Selected.Labels)
+		campaign, err := svc.NewDistributedQueryCampaignByNames(ctx, req.Query, req.Selected.Hosts, req.Selected.Labels, req.ExecutionTimeout, req.AllowResubmission)
+		if err != nil {
+			return createDistributedQueryCampaignResponse{Err: err}, nil
+		}
+		return createDistributedQueryCampaignResponse{Campaign: campaign}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Create Saved Query Campaign
+////////////////////////////////////////////////////////////////////////////////
+
+type createSavedQueryCampaignRequest struct {
+	ID               uint
+	Selected         distributedQueryCampaignTargets `json:"selected"`
+	ExecutionTimeout uint                            `json:"execution_timeout"`
+	// AllowResubmission allows hosts to report results more than once
+	AllowResubmission bool `json:"allow_resubmission"`
+}
+
+func makeCreateSavedQueryCampaignEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createSavedQueryCampaignRequest)
+		campaign, err := svc.NewSavedQueryCampaign(ctx, req.ID, req.Selected.Hosts, req.Selected.Labels, req.ExecutionTimeout, req.AllowResubmission)
+		if err != nil {
+			return createDistributedQueryCampaignResponse{Err: err}, nil
+		}
+		return createDistributedQueryCampaignResponse{Campaign: campaign}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Create Saved Query Campaign By Names
+////////////////////////////////////////////////////////////////////////////////
+
+type createSavedQueryCampaignByNamesRequest struct {
+	QueryName        string                                 `json:"query_name"`
+	Selected         distributedQueryCampaignTargetsByNames `json:"selected"`
+	ExecutionTimeout uint                                   `json:"execution_timeout"`
+	// AllowResubmission allows hosts to report results more than once
+	AllowResubmission bool `json:"allow_resubmission"`
+}
+
+func makeCreateSavedQueryCampaignByNamesEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createSavedQueryCampaignByNamesRequest)
+		campaign, err := svc.NewSavedQueryCampaignByNames(ctx, req.QueryName, req.Selected.Hosts, req.Selected.Labels, req.ExecutionTimeout, req.AllowResubmission)
 		if err != nil {
 			return createDistributedQueryCampaignResponse{Err: err}, nil
 		}
@@ -70,6 +125,105 @@ func makeCreateDistributedQueryCampaignByNamesEndpoint(svc kolide.Service) endpo
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// Distributed Query Campaign Targets Count
+////////////////////////////////////////////////////////////////////////////////
+
+type distributedQueryCampaignTargetsCountRequest struct {
+	Selected distributedQueryCampaignTargets `json:"selected"`
+}
+
+type distributedQueryCampaignTargetsCountResponse struct {
+	TargetsCount int    `json:"targets_count"`
+	HostIDs      []uint `json:"host_ids"`
+	Err          error  `json:"error,omitempty"`
+}
+
+func (r distributedQueryCampaignTargetsCountResponse) error() error { return r.Err }
+
+func makeDistributedQueryCampaignTargetsCountEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(distributedQueryCampaignTargetsCountRequest)
+		count, hostIDs, err := svc.DistributedQueryCampaignTargetsCount(ctx, req.Selected.Hosts, req.Selected.Labels)
+		if err != nil {
+			return distributedQueryCampaignTargetsCountResponse{Err: err}, nil
+		}
+		return distributedQueryCampaignTargetsCountResponse{TargetsCount: count, HostIDs: hostIDs}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Estimate Query Cost
+////////////////////////////////////////////////////////////////////////////////
+
+type estimateQueryCostRequest struct {
+	Query         string `json:"query"`
+	SampleHostIDs []uint `json:"sample_host_ids"`
+}
+
+type estimateQueryCostResponse struct {
+	Estimate kolide.CostEstimate `json:"estimate"`
+	Err      error               `json:"error,omitempty"`
+}
+
+func (r estimateQueryCostResponse) error() error { return r.Err }
+
+func makeEstimateQueryCostEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(estimateQueryCostRequest)
+		estimate, err := svc.EstimateQueryCost(ctx, req.Query, req.SampleHostIDs)
+		if err != nil {
+			return estimateQueryCostResponse{Err: err}, nil
+		}
+		return estimateQueryCostResponse{Estimate: estimate}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// List Running Campaigns
+////////////////////////////////////////////////////////////////////////////////
+
+type listRunningCampaignsResponse struct {
+	Campaigns []kolide.DistributedQueryCampaign `json:"campaigns"`
+	Err       error                             `json:"error,omitempty"`
+}
+
+func (r listRunningCampaignsResponse) error() error { return r.Err }
+
+func makeListRunningCampaignsEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		campaigns, err := svc.ListRunningCampaigns(ctx)
+		if err != nil {
+			return listRunningCampaignsResponse{Err: err}, nil
+		}
+		return listRunningCampaignsResponse{Campaigns: campaigns}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Stop Campaign
+////////////////////////////////////////////////////////////////////////////////
+
+type stopCampaignRequest struct {
+	ID uint
+}
+
+type stopCampaignResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r stopCampaignResponse) error() error { return r.Err }
+
+func makeStopCampaignEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(stopCampaignRequest)
+		if err := svc.StopCampaign(ctx, req.ID); err != nil {
+			return stopCampaignResponse{Err: err}, nil
+		}
+		return stopCampaignResponse{}, nil
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Stream Distributed Query Campaign Results and Metadata
 ////////////////////////////////////////////////////////////////////////////////
@@ -97,6 +251,11 @@ func makeStreamDistributedQueryCampaignResultsHandler(svc kolide.Service, jwtKey
 			conn.WriteJSONError("unauthorized")
 			return
 		}
+		if err := checkAPITokenScope(*vc, kolide.ScopeQueriesRun); err != nil {
+			logger.Log("err", err, "msg", "unauthorized api token")
+			conn.WriteJSONError("unauthorized")
+			return
+		}
 
 		ctx := viewer.NewContext(context.Background(), *vc)
 
@@ -114,6 +273,12 @@ func makeStreamDistributedQueryCampaignResultsHandler(svc kolide.Service, jwtKey
 
 		var info struct {
 			CampaignID uint `json:"campaign_id"`
+			// LastSequence is set by clients resuming a
+			// previous stream of results
+			LastSequence *uint64 `json:"last_sequence"`
+			// Aggregate is set by clients that also want the
+			// counts of hosts returning each distinct row
+			Aggregate bool `json:"aggregate"`
 		}
 		err = json.Unmarshal(*(msg.Data.(*json.RawMessage)), &info)
 		if err != nil {
@@ -127,7 +292,73 @@ func makeStreamDistributedQueryCampaignResultsHandler(svc kolide.Service, jwtKey
 			return
 		}
 
-		svc.StreamCampaignResults(ctx, conn, info.CampaignID)
+		svc.StreamCampaignResults(ctx, conn, info.CampaignID, info.LastSequence, info.Aggregate)
+
+	})
+}
+
+// makeStreamCampaignResultsEventsHandler returns a handler streaming the same
+// messages as the websocket handler as server-sent events. The campaign is
+// selected by the id in the path, and the last_sequence and aggregate query
+// parameters have the meaning of the select_campaign fields. A done event is
+// sent when the stream ends, and the stream stops when the client
+// disconnects.
+func makeStreamCampaignResultsEventsHandler(svc kolide.Service, jwtKey string, logger kitlog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		campaignID, err := idFromRequest(r, "id")
+		if err != nil || campaignID == 0 {
+			http.Error(w, "invalid campaign ID", http.StatusBadRequest)
+			return
+		}
+
+		var lastSequence *uint64
+		if s := r.URL.Query().Get("last_sequence"); s != "" {
+			seq, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				http.Error(w, "invalid last_sequence", http.StatusBadRequest)
+				return
+			}
+			lastSequence = &seq
+		}
+		aggregate := false
+		if s := r.URL.Query().Get("aggregate"); s != "" {
+			aggregate, err = strconv.ParseBool(s)
+			if err != nil {
+				http.Error(w, "invalid aggregate", http.StatusBadRequest)
+				return
+			}
+		}
+
+		vc, err := authViewer(r.Context(), jwtKey, token.FromHTTPRequest(r), svc)
+		if err != nil || !vc.CanPerformActions() {
+			logger.Log("err", err, "msg", "unauthorized viewer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err := checkAPITokenScope(*vc, kolide.ScopeQueriesRun); err != nil {
+			logger.Log("err", err, "msg", "unauthorized api token")
+			http.Error(w, "unauthorized", http.StatusForbidden)
+			return
+		}
+
+		conn, err := sse.NewWriter(w, r)
+		if err != nil {
+			logger.Log("err", err, "msg", "starting event stream")
+			return
+		}
+		defer conn.Close()
+
+		ctx, cancel := context.WithCancel(viewer.NewContext(context.Background(), *vc))
+		defer cancel()
+		go func() {
+			select {
+			case <-conn.Closed():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
 
+		svc.StreamCampaignResults(ctx, conn, campaignID, lastSequence, aggregate)
+		conn.WriteDone()
 	})
 }
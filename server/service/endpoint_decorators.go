@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kolide/fleet/server/kolide"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// List Decorators
+////////////////////////////////////////////////////////////////////////////////
+
+type listDecoratorsResponse struct {
+	Decorators []*kolide.Decorator `json:"decorators"`
+	Err        error               `json:"error,omitempty"`
+}
+
+func (r listDecoratorsResponse) error() error { return r.Err }
+
+func makeListDecoratorsEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		decs, err := svc.ListDecorators(ctx)
+		if err != nil {
+			return listDecoratorsResponse{Err: err}, nil
+		}
+		return listDecoratorsResponse{Decorators: decs}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// New Decorator
+////////////////////////////////////////////////////////////////////////////////
+
+type newDecoratorRequest struct {
+	Payload kolide.DecoratorPayload `json:"payload"`
+}
+
+type decoratorResponse struct {
+	Decorator *kolide.Decorator `json:"decorator,omitempty"`
+	Err       error             `json:"error,omitempty"`
+}
+
+func (r decoratorResponse) error() error { return r.Err }
+
+func makeNewDecoratorEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(newDecoratorRequest)
+		dec, err := svc.NewDecorator(ctx, req.Payload)
+		if err != nil {
+			return decoratorResponse{Err: err}, nil
+		}
+		return decoratorResponse{Decorator: dec}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Modify Decorator
+////////////////////////////////////////////////////////////////////////////////
+
+type modifyDecoratorRequest struct {
+	Payload kolide.DecoratorPayload `json:"payload"`
+}
+
+func makeModifyDecoratorEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(modifyDecoratorRequest)
+		dec, err := svc.ModifyDecorator(ctx, req.Payload)
+		if err != nil {
+			return decoratorResponse{Err: err}, nil
+		}
+		return decoratorResponse{Decorator: dec}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Delete Decorator
+////////////////////////////////////////////////////////////////////////////////
+
+type deleteDecoratorRequest struct {
+	ID uint
+}
+
+type deleteDecoratorResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r deleteDecoratorResponse) error() error { return r.Err }
+
+func makeDeleteDecoratorEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deleteDecoratorRequest)
+		err := svc.DeleteDecorator(ctx, req.ID)
+		if err != nil {
+			return deleteDecoratorResponse{Err: err}, nil
+		}
+		return deleteDecoratorResponse{}, nil
+	}
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kolide/fleet/server/kolide"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Host Activities
+////////////////////////////////////////////////////////////////////////////////
+
+type hostActivitiesRequest struct {
+	ID          uint
+	ListOptions kolide.HostActivityListOptions
+}
+
+type hostActivitiesResponse struct {
+	Activities []kolide.HostActivity `json:"activities"`
+	Err        error                 `json:"error,omitempty"`
+}
+
+func (r hostActivitiesResponse) error() error { return r.Err }
+
+func makeHostActivitiesEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(hostActivitiesRequest)
+		activities, err := svc.HostActivities(ctx, req.ID, req.ListOptions)
+		if err != nil {
+			return hostActivitiesResponse{Err: err}, nil
+		}
+
+		resp := hostActivitiesResponse{Activities: []kolide.HostActivity{}}
+		for _, activity := range activities {
+			resp.Activities = append(resp.Activities, *activity)
+		}
+		return resp, nil
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kolide/fleet/server/kolide"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// List Host Detail Queries
+////////////////////////////////////////////////////////////////////////////////
+
+type listHostDetailQueriesResponse struct {
+	HostDetailQueries []*kolide.HostDetailQuery `json:"host_detail_queries"`
+	Err               error                     `json:"error,omitempty"`
+}
+
+func (r listHostDetailQueriesResponse) error() error { return r.Err }
+
+func makeListHostDetailQueriesEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		queries, err := svc.ListHostDetailQueries(ctx)
+		if err != nil {
+			return listHostDetailQueriesResponse{Err: err}, nil
+		}
+		return listHostDetailQueriesResponse{HostDetailQueries: queries}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// New Host Detail Query
+////////////////////////////////////////////////////////////////////////////////
+
+type newHostDetailQueryRequest struct {
+	Payload kolide.HostDetailQueryPayload `json:"payload"`
+}
+
+type newHostDetailQueryResponse struct {
+	HostDetailQuery *kolide.HostDetailQuery `json:"host_detail_query,omitempty"`
+	Err             error                   `json:"error,omitempty"`
+}
+
+func (r newHostDetailQueryResponse) error() error { return r.Err }
+
+func makeNewHostDetailQueryEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(newHostDetailQueryRequest)
+		query, err := svc.NewHostDetailQuery(ctx, req.Payload)
+		if err != nil {
+			return newHostDetailQueryResponse{Err: err}, nil
+		}
+		return newHostDetailQueryResponse{HostDetailQuery: query}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Delete Host Detail Query
+////////////////////////////////////////////////////////////////////////////////
+
+type deleteHostDetailQueryRequest struct {
+	ID uint
+}
+
+type deleteHostDetailQueryResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r deleteHostDetailQueryResponse) error() error { return r.Err }
+
+func makeDeleteHostDetailQueryEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deleteHostDetailQueryRequest)
+		err := svc.DeleteHostDetailQuery(ctx, req.ID)
+		if err != nil {
+			return deleteHostDetailQueryResponse{Err: err}, nil
+		}
+		return deleteHostDetailQueryResponse{}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Get Host Extra Details
+////////////////////////////////////////////////////////////////////////////////
+
+type getHostExtraDetailsRequest struct {
+	ID uint
+}
+
+type getHostExtraDetailsResponse struct {
+	ExtraDetails kolide.HostExtraDetails `json:"extra_details"`
+	Err          error                   `json:"error,omitempty"`
+}
+
+func (r getHostExtraDetailsResponse) error() error { return r.Err }
+
+func makeGetHostExtraDetailsEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getHostExtraDetailsRequest)
+		details, err := svc.HostExtraDetails(ctx, req.ID)
+		if err != nil {
+			return getHostExtraDetailsResponse{Err: err}, nil
+		}
+		return getHostExtraDetailsResponse{ExtraDetails: details}, nil
+	}
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"time"
 
 	"github.com/go-kit/kit/endpoint"
@@ -64,12 +66,13 @@ func makeGetHostEndpoint(svc kolide.Service) endpoint.Endpoint {
 ////////////////////////////////////////////////////////////////////////////////
 
 type listHostsRequest struct {
-	ListOptions kolide.ListOptions
+	ListOptions kolide.HostListOptions
 }
 
 type listHostsResponse struct {
-	Hosts []HostResponse `json:"hosts"`
-	Err   error          `json:"error,omitempty"`
+	Hosts      []HostResponse `json:"hosts"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Err        error          `json:"error,omitempty"`
 }
 
 func (r listHostsResponse) error() error { return r.Err }
@@ -77,7 +80,7 @@ func (r listHostsResponse) error() error { return r.Err }
 func makeListHostsEndpoint(svc kolide.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(listHostsRequest)
-		hosts, err := svc.ListHosts(ctx, req.ListOptions)
+		hosts, nextCursor, err := svc.ListHosts(ctx, req.ListOptions)
 		if err != nil {
 			return listHostsResponse{Err: err}, nil
 		}
@@ -91,7 +94,62 @@ func makeListHostsEndpoint(svc kolide.Service) endpoint.Endpoint {
 
 			hostResponses[i] = *h
 		}
-		return listHostsResponse{Hosts: hostResponses}, nil
+		return listHostsResponse{Hosts: hostResponses, NextCursor: nextCursor}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Count Hosts
+////////////////////////////////////////////////////////////////////////////////
+
+type countHostsRequest struct {
+	ListOptions kolide.HostListOptions
+}
+
+type countHostsResponse struct {
+	Count int   `json:"count"`
+	Err   error `json:"error,omitempty"`
+}
+
+func (r countHostsResponse) error() error { return r.Err }
+
+func makeCountHostsEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(countHostsRequest)
+		count, err := svc.CountHosts(ctx, req.ListOptions)
+		if err != nil {
+			return countHostsResponse{Err: err}, nil
+		}
+		return countHostsResponse{Count: count}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Stream Hosts
+////////////////////////////////////////////////////////////////////////////////
+
+type streamHostsRequest struct {
+	ListOptions kolide.HostListOptions
+}
+
+// streamHostsResponse defers the call to StreamHosts until the response is
+// encoded, so that hosts are written as they are read from the datastore.
+type streamHostsResponse struct {
+	ctx context.Context
+	svc kolide.Service
+	opt kolide.HostListOptions
+}
+
+func (r streamHostsResponse) contentType() string { return "application/x-ndjson" }
+
+func (r streamHostsResponse) stream(w io.Writer) error {
+	return r.svc.StreamHosts(r.ctx, r.opt, w)
+}
+
+func makeStreamHostsEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(streamHostsRequest)
+		return streamHostsResponse{ctx: ctx, svc: svc, opt: req.ListOptions}, nil
 	}
 }
 
@@ -144,3 +202,464 @@ func makeDeleteHostEndpoint(svc kolide.Service) endpoint.Endpoint {
 		return deleteHostResponse{}, nil
 	}
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// Delete Hosts By Label
+////////////////////////////////////////////////////////////////////////////////
+
+type deleteHostsByLabelRequest struct {
+	LabelID uint `json:"label_id"`
+}
+
+type deleteHostsByLabelResponse struct {
+	Deleted int   `json:"deleted"`
+	Err     error `json:"error,omitempty"`
+}
+
+func (r deleteHostsByLabelResponse) error() error { return r.Err }
+
+func makeDeleteHostsByLabelEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deleteHostsByLabelRequest)
+		deleted, err := svc.DeleteHostsByLabel(ctx, req.LabelID)
+		if err != nil {
+			return deleteHostsByLabelResponse{Err: err}, nil
+		}
+		return deleteHostsByLabelResponse{Deleted: deleted}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Refresh Host Config
+////////////////////////////////////////////////////////////////////////////////
+
+type refreshHostConfigRequest struct {
+	HostIDs []uint `json:"host_ids"`
+}
+
+type refreshHostConfigResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r refreshHostConfigResponse) error() error { return r.Err }
+
+func makeRefreshHostConfigEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(refreshHostConfigRequest)
+		err := svc.RefreshHostConfig(ctx, req.HostIDs)
+		if err != nil {
+			return refreshHostConfigResponse{Err: err}, nil
+		}
+		return refreshHostConfigResponse{}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Transfer Hosts
+////////////////////////////////////////////////////////////////////////////////
+
+type transferHostsRequest struct {
+	HostIDs []uint `json:"host_ids"`
+	LabelID uint   `json:"label_id"`
+}
+
+type transferHostsResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r transferHostsResponse) error() error { return r.Err }
+
+func makeTransferHostsEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(transferHostsRequest)
+		err := svc.TransferHosts(ctx, req.HostIDs, req.LabelID)
+		if err != nil {
+			return transferHostsResponse{Err: err}, nil
+		}
+		return transferHostsResponse{}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Refresh Host Details
+////////////////////////////////////////////////////////////////////////////////
+
+type refreshHostDetailsRequest struct {
+	ID uint `json:"id"`
+}
+
+type refreshHostDetailsResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r refreshHostDetailsResponse) error() error { return r.Err }
+
+func makeRefreshHostDetailsEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(refreshHostDetailsRequest)
+		err := svc.RefreshHostDetails(ctx, req.ID)
+		if err != nil {
+			return refreshHostDetailsResponse{Err: err}, nil
+		}
+		return refreshHostDetailsResponse{}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Set Host Maintenance
+////////////////////////////////////////////////////////////////////////////////
+
+type setHostMaintenanceRequest struct {
+	ID    uint
+	Until time.Time `json:"until"`
+}
+
+type setHostMaintenanceResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r setHostMaintenanceResponse) error() error { return r.Err }
+
+func makeSetHostMaintenanceEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(setHostMaintenanceRequest)
+		err := svc.SetHostMaintenance(ctx, req.ID, req.Until)
+		if err != nil {
+			return setHostMaintenanceResponse{Err: err}, nil
+		}
+		return setHostMaintenanceResponse{}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Approve Host
+////////////////////////////////////////////////////////////////////////////////
+
+type approveHostRequest struct {
+	ID uint
+}
+
+type approveHostResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r approveHostResponse) error() error { return r.Err }
+
+func makeApproveHostEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(approveHostRequest)
+		err := svc.ApproveHost(ctx, req.ID)
+		if err != nil {
+			return approveHostResponse{Err: err}, nil
+		}
+		return approveHostResponse{}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Rotate Host Node Key
+////////////////////////////////////////////////////////////////////////////////
+
+type rotateHostNodeKeyRequest struct {
+	ID uint
+}
+
+type rotateHostNodeKeyResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r rotateHostNodeKeyResponse) error() error { return r.Err }
+
+func makeRotateHostNodeKeyEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(rotateHostNodeKeyRequest)
+		err := svc.RotateHostNodeKey(ctx, req.ID)
+		if err != nil {
+			return rotateHostNodeKeyResponse{Err: err}, nil
+		}
+		return rotateHostNodeKeyResponse{}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Merge Hosts
+////////////////////////////////////////////////////////////////////////////////
+
+type mergeHostsRequest struct {
+	ID      uint
+	HostIDs []uint `json:"host_ids"`
+}
+
+type mergeHostsResponse struct {
+	Merge *kolide.HostMerge `json:"merge,omitempty"`
+	Err   error             `json:"error,omitempty"`
+}
+
+func (r mergeHostsResponse) error() error { return r.Err }
+
+func makeMergeHostsEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(mergeHostsRequest)
+		merge, err := svc.MergeHosts(ctx, req.ID, req.HostIDs)
+		if err != nil {
+			return mergeHostsResponse{Err: err}, nil
+		}
+		return mergeHostsResponse{Merge: &merge}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Aggregate Hosts
+////////////////////////////////////////////////////////////////////////////////
+
+type aggregateHostsRequest struct {
+	GroupBy string
+}
+
+type aggregateHostsResponse struct {
+	Aggregates []kolide.HostAggregate `json:"aggregates"`
+	Err        error                  `json:"error,omitempty"`
+}
+
+func (r aggregateHostsResponse) error() error { return r.Err }
+
+func makeAggregateHostsEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(aggregateHostsRequest)
+		aggregates, err := svc.AggregateHosts(ctx, req.GroupBy)
+		if err != nil {
+			return aggregateHostsResponse{Err: err}, nil
+		}
+		return aggregateHostsResponse{Aggregates: aggregates}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Noisy Hosts
+////////////////////////////////////////////////////////////////////////////////
+
+type noisyHostsResponse struct {
+	Hosts []HostResponse `json:"hosts"`
+	Err   error          `json:"error,omitempty"`
+}
+
+func (r noisyHostsResponse) error() error { return r.Err }
+
+func makeNoisyHostsEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		hosts, err := svc.NoisyHosts(ctx)
+		if err != nil {
+			return noisyHostsResponse{Err: err}, nil
+		}
+
+		hostResponses := make([]HostResponse, len(hosts))
+		for i, host := range hosts {
+			h, err := hostResponseForHost(ctx, svc, host)
+			if err != nil {
+				return noisyHostsResponse{Err: err}, nil
+			}
+
+			hostResponses[i] = *h
+		}
+		return noisyHostsResponse{Hosts: hostResponses}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Host Reboot History
+////////////////////////////////////////////////////////////////////////////////
+
+type hostRebootHistoryRequest struct {
+	ID uint `json:"id"`
+}
+
+type hostRebootHistoryResponse struct {
+	Reboots []*kolide.HostUptimeEvent `json:"reboots"`
+	Err     error                     `json:"error,omitempty"`
+}
+
+func (r hostRebootHistoryResponse) error() error { return r.Err }
+
+func makeHostRebootHistoryEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(hostRebootHistoryRequest)
+		reboots, err := svc.HostRebootHistory(ctx, req.ID)
+		if err != nil {
+			return hostRebootHistoryResponse{Err: err}, nil
+		}
+		return hostRebootHistoryResponse{Reboots: reboots}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Host Client Config
+////////////////////////////////////////////////////////////////////////////////
+
+type hostClientConfigRequest struct {
+	ID uint `json:"id"`
+}
+
+type hostClientConfigResponse struct {
+	Config json.RawMessage `json:"config,omitempty"`
+	Err    error           `json:"error,omitempty"`
+}
+
+func (r hostClientConfigResponse) error() error { return r.Err }
+
+func makeHostClientConfigEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(hostClientConfigRequest)
+		config, err := svc.HostClientConfig(ctx, req.ID)
+		if err != nil {
+			return hostClientConfigResponse{Err: err}, nil
+		}
+		return hostClientConfigResponse{Config: config}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Host Scheduled Queries
+////////////////////////////////////////////////////////////////////////////////
+
+type hostScheduledQueriesRequest struct {
+	ID uint `json:"id"`
+}
+
+type hostScheduledQueriesResponse struct {
+	ScheduledQueries []kolide.ScheduledQuery `json:"scheduled_queries"`
+	Err              error                   `json:"error,omitempty"`
+}
+
+func (r hostScheduledQueriesResponse) error() error { return r.Err }
+
+func makeHostScheduledQueriesEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(hostScheduledQueriesRequest)
+		queries, err := svc.HostScheduledQueries(ctx, req.ID)
+		if err != nil {
+			return hostScheduledQueriesResponse{Err: err}, nil
+		}
+		return hostScheduledQueriesResponse{ScheduledQueries: queries}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Host Packs
+////////////////////////////////////////////////////////////////////////////////
+
+type hostPacksRequest struct {
+	ID uint `json:"id"`
+}
+
+type hostPacksResponse struct {
+	Packs []kolide.HostPackInfo `json:"packs"`
+	Err   error                 `json:"error,omitempty"`
+}
+
+func (r hostPacksResponse) error() error { return r.Err }
+
+func makeHostPacksEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(hostPacksRequest)
+		packs, err := svc.HostPacks(ctx, req.ID)
+		if err != nil {
+			return hostPacksResponse{Err: err}, nil
+		}
+		return hostPacksResponse{Packs: packs}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Diff Hosts
+////////////////////////////////////////////////////////////////////////////////
+
+type diffHostsRequest struct {
+	HostAID uint
+	HostBID uint
+}
+
+type diffHostsResponse struct {
+	Diff *kolide.HostDiff `json:"diff,omitempty"`
+	Err  error            `json:"error,omitempty"`
+}
+
+func (r diffHostsResponse) error() error { return r.Err }
+
+func makeDiffHostsEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(diffHostsRequest)
+		diff, err := svc.DiffHosts(ctx, req.HostAID, req.HostBID)
+		if err != nil {
+			return diffHostsResponse{Err: err}, nil
+		}
+		return diffHostsResponse{Diff: &diff}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Host Export
+////////////////////////////////////////////////////////////////////////////////
+
+type hostExportRequest struct {
+	ID uint `json:"id"`
+}
+
+type hostExportResponse struct {
+	*kolide.HostExport
+	Err error `json:"error,omitempty"`
+}
+
+func (r hostExportResponse) error() error { return r.Err }
+
+func makeHostExportEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(hostExportRequest)
+		export, err := svc.HostExport(ctx, req.ID)
+		if err != nil {
+			return hostExportResponse{Err: err}, nil
+		}
+		return hostExportResponse{HostExport: &export}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Hosts By Osquery Version
+////////////////////////////////////////////////////////////////////////////////
+
+type hostsByOsqueryVersionResponse struct {
+	OsqueryVersions []kolide.OsqueryVersionCount `json:"osquery_versions"`
+	Err             error                        `json:"error,omitempty"`
+}
+
+func (r hostsByOsqueryVersionResponse) error() error { return r.Err }
+
+func makeHostsByOsqueryVersionEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		versions, err := svc.HostsByOsqueryVersion(ctx)
+		if err != nil {
+			return hostsByOsqueryVersionResponse{Err: err}, nil
+		}
+		return hostsByOsqueryVersionResponse{OsqueryVersions: versions}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Hosts By Encryption Status
+////////////////////////////////////////////////////////////////////////////////
+
+type hostsByEncryptionStatusResponse struct {
+	DiskEncryption []kolide.DiskEncryptionCount `json:"disk_encryption"`
+	Err            error                        `json:"error,omitempty"`
+}
+
+func (r hostsByEncryptionStatusResponse) error() error { return r.Err }
+
+func makeHostsByEncryptionStatusEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		counts, err := svc.HostsByEncryptionStatus(ctx)
+		if err != nil {
+			return hostsByEncryptionStatusResponse{Err: err}, nil
+		}
+		return hostsByEncryptionStatusResponse{DiskEncryption: counts}, nil
+	}
+}
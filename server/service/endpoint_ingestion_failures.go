@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kolide/fleet/server/kolide"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// List Ingestion Failures
+////////////////////////////////////////////////////////////////////////////////
+
+type listIngestionFailuresRequest struct {
+	ListOptions kolide.ListOptions
+}
+
+type listIngestionFailuresResponse struct {
+	Failures []kolide.IngestionFailure `json:"ingestion_failures"`
+	Err      error                     `json:"error,omitempty"`
+}
+
+func (r listIngestionFailuresResponse) error() error { return r.Err }
+
+func makeListIngestionFailuresEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listIngestionFailuresRequest)
+		failures, err := svc.ListIngestionFailures(ctx, req.ListOptions)
+		if err != nil {
+			return listIngestionFailuresResponse{Err: err}, nil
+		}
+
+		resp := listIngestionFailuresResponse{Failures: []kolide.IngestionFailure{}}
+		for _, failure := range failures {
+			resp.Failures = append(resp.Failures, *failure)
+		}
+		return resp, nil
+	}
+}
@@ -18,6 +18,8 @@ type createInviteResponse struct {
 
 func (r createInviteResponse) error() error { return r.Err }
 
+func (r createInviteResponse) activityTargetID() uint { return r.Invite.ID }
+
 func makeCreateInviteEndpoint(svc kolide.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(createInviteRequest)
@@ -224,9 +224,12 @@ func makeDeleteLabelByIDEndpoint(svc kolide.Service) endpoint.Endpoint {
 
 type applyLabelSpecsRequest struct {
 	Specs []*kolide.LabelSpec `json:"specs"`
+	// Prune deletes the labels that are not in Specs
+	Prune bool `json:"prune,omitempty"`
 }
 
 type applyLabelSpecsResponse struct {
+	*kolide.ApplyLabelSpecsResult
 	Err error `json:"error,omitempty"`
 }
 
@@ -235,11 +238,11 @@ func (r applyLabelSpecsResponse) error() error { return r.Err }
 func makeApplyLabelSpecsEndpoint(svc kolide.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(applyLabelSpecsRequest)
-		err := svc.ApplyLabelSpecs(ctx, req.Specs)
+		result, err := svc.ApplyLabelSpecs(ctx, req.Specs, req.Prune)
 		if err != nil {
 			return applyLabelSpecsResponse{Err: err}, nil
 		}
-		return applyLabelSpecsResponse{}, nil
+		return applyLabelSpecsResponse{ApplyLabelSpecsResult: result}, nil
 	}
 }
 
@@ -285,3 +288,50 @@ func makeGetLabelSpecEndpoint(svc kolide.Service) endpoint.Endpoint {
 		return getLabelSpecResponse{Spec: spec}, nil
 	}
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// Add Hosts To Label
+////////////////////////////////////////////////////////////////////////////////
+
+type labelMembersRequest struct {
+	ID      uint
+	HostIDs []uint `json:"host_ids"`
+}
+
+type addHostsToLabelResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r addHostsToLabelResponse) error() error { return r.Err }
+
+func makeAddHostsToLabelEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(labelMembersRequest)
+		err := svc.AddHostsToLabel(ctx, req.ID, req.HostIDs)
+		if err != nil {
+			return addHostsToLabelResponse{Err: err}, nil
+		}
+		return addHostsToLabelResponse{}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Remove Hosts From Label
+////////////////////////////////////////////////////////////////////////////////
+
+type removeHostsFromLabelResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r removeHostsFromLabelResponse) error() error { return r.Err }
+
+func makeRemoveHostsFromLabelEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(labelMembersRequest)
+		err := svc.RemoveHostsFromLabel(ctx, req.ID, req.HostIDs)
+		if err != nil {
+			return removeHostsFromLabelResponse{Err: err}, nil
+		}
+		return removeHostsFromLabelResponse{}, nil
+	}
+}
@@ -2,14 +2,18 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"reflect"
+	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/go-kit/kit/endpoint"
+	kitlog "github.com/go-kit/kit/log"
 	hostctx "github.com/kolide/fleet/server/contexts/host"
 	"github.com/kolide/fleet/server/contexts/token"
 	"github.com/kolide/fleet/server/contexts/viewer"
 	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/ratelimit"
 	"github.com/pkg/errors"
 )
 
@@ -58,31 +62,162 @@ func getNodeKey(r interface{}) (string, error) {
 	return nodeKeyField.String(), nil
 }
 
+// rateLimit wraps an endpoint and rejects requests with a retryable
+// osqueryError when the limiter's budget for key has been exhausted.
+func rateLimit(limiter ratelimit.Limiter, key string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			allowed, retryAfter, err := limiter.Allow(key)
+			if err != nil {
+				return nil, osqueryError{message: "rate limit: " + err.Error()}
+			}
+			if !allowed {
+				return nil, osqueryError{
+					message:    fmt.Sprintf("rate limit exceeded, retry after %s", retryAfter),
+					retryAfter: retryAfter,
+				}
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// throttledError is returned for requests rejected by throttleBySourceIP.
+type throttledError struct {
+	retryAfter time.Duration
+}
+
+func (e throttledError) Error() string {
+	return fmt.Sprintf("too many attempts, retry after %s", e.retryAfter)
+}
+
+func (e throttledError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// throttleBySourceIP wraps an endpoint and rejects requests with a
+// throttledError when the limiter's budget for key and the source IP of the
+// request has been exhausted.
+func throttleBySourceIP(limiter ratelimit.Limiter, key string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			allowed, retryAfter, err := limiter.Allow(key + ":" + requestSourceIP(ctx))
+			if err != nil {
+				return nil, errors.Wrap(err, "rate limit")
+			}
+			if !allowed {
+				return nil, throttledError{retryAfter: retryAfter}
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// activityTarget is implemented by responses of endpoints that create a
+// resource, so that the audit log can record the ID of the new resource.
+type activityTarget interface {
+	activityTargetID() uint
+}
+
+// logActivity wraps a mutating endpoint and records action in the audit log
+// when the request succeeds. The target of the action is taken from the
+// response if it implements activityTarget, and otherwise from the ID or
+// Name field of the request. The change has already been made when the
+// activity is recorded, so failing to record it is logged rather than
+// reported to the client.
+func logActivity(svc kolide.Service, logger kitlog.Logger, action string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			response, err := next(ctx, request)
+			if err != nil {
+				return response, err
+			}
+			if e, ok := response.(errorer); ok && e.error() != nil {
+				return response, nil
+			}
+
+			activity := &kolide.Activity{Action: action}
+			if t, ok := response.(activityTarget); ok {
+				id := t.activityTargetID()
+				activity.TargetID = &id
+			} else {
+				activity.TargetID, activity.TargetName = getActivityTarget(request)
+			}
+			if err := svc.RecordActivity(ctx, activity); err != nil {
+				logger.Log("err", err, "msg", "failed to record activity", "action", action)
+			}
+			return response, nil
+		}
+	}
+}
+
+func getActivityTarget(r interface{}) (*uint, string) {
+	// Retrieve the target by reflection, as with getNodeKey
+	v := reflect.ValueOf(r)
+	if v.Kind() != reflect.Struct {
+		return nil, ""
+	}
+	if f := v.FieldByName("ID"); f.IsValid() && f.Kind() == reflect.Uint {
+		id := uint(f.Uint())
+		return &id, ""
+	}
+	if f := v.FieldByName("Name"); f.IsValid() && f.Kind() == reflect.String {
+		return nil, f.String()
+	}
+	return nil, ""
+}
+
 // authenticatedUser wraps an endpoint, requires that the Fleet user is
 // authenticated, and populates the context with a Viewer struct for that user.
+// API tokens are rejected; endpoints that accept them use scopedUser.
 func authenticatedUser(jwtKey string, svc kolide.Service, next endpoint.Endpoint) endpoint.Endpoint {
+	return scopedUser(jwtKey, svc, "", next)
+}
+
+// scopedUser is like authenticatedUser, but also accepts API tokens that
+// hold scope. The scope does not restrict users authenticated by a session.
+func scopedUser(jwtKey string, svc kolide.Service, scope string, next endpoint.Endpoint) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		// first check if already successfully set
-		if _, ok := viewer.FromContext(ctx); ok {
-			return next(ctx, request)
-		}
-
-		// if not succesful, try again this time with errors
-		bearer, ok := token.FromContext(ctx)
+		vc, ok := viewer.FromContext(ctx)
 		if !ok {
-			return nil, authError{reason: "no auth token"}
+			// if not succesful, try again this time with errors
+			bearer, ok := token.FromContext(ctx)
+			if !ok {
+				return nil, authError{reason: "no auth token"}
+			}
+
+			v, err := authViewer(ctx, jwtKey, bearer, svc)
+			if err != nil {
+				return nil, err
+			}
+
+			vc = *v
+			ctx = viewer.NewContext(ctx, vc)
 		}
 
-		v, err := authViewer(ctx, jwtKey, bearer, svc)
-		if err != nil {
+		if err := checkAPITokenScope(vc, scope); err != nil {
 			return nil, err
 		}
-
-		ctx = viewer.NewContext(ctx, *v)
 		return next(ctx, request)
 	}
 }
 
+// checkAPITokenScope returns an error if the viewer was authenticated by an
+// API token that does not hold scope. An empty scope is held by no token.
+func checkAPITokenScope(vc viewer.Viewer, scope string) error {
+	if vc.APIToken == nil {
+		return nil
+	}
+	if scope == "" {
+		return permissionError{message: "API tokens cannot access this endpoint"}
+	}
+	if !vc.APIToken.Scopes.Contains(scope) {
+		return permissionError{message: fmt.Sprintf("API token does not have the %s scope", scope)}
+	}
+	return nil
+}
+
 // authViewer creates an authenticated viewer by validating a JWT token.
 func authViewer(ctx context.Context, jwtKey string, bearerToken token.Token, svc kolide.Service) (*viewer.Viewer, error) {
 	jwtToken, err := jwt.Parse(string(bearerToken), func(token *jwt.Token) (interface{}, error) {
@@ -101,6 +236,9 @@ func authViewer(ctx context.Context, jwtKey string, bearerToken token.Token, svc
 	if !ok {
 		return nil, authError{reason: "no jwt claims"}
 	}
+	if _, ok := claims["api_token_key"]; ok {
+		return apiTokenViewer(ctx, claims, svc)
+	}
 	sessionKeyClaim, ok := claims["session_key"]
 	if !ok {
 		return nil, authError{reason: "no session_key in JWT claims"}
@@ -111,7 +249,11 @@ func authViewer(ctx context.Context, jwtKey string, bearerToken token.Token, svc
 	}
 	session, err := svc.GetSessionByKey(ctx, sessionKey)
 	if err != nil {
-		return nil, authError{reason: err.Error()}
+		// Keep the client reason of sessions that were rejected as expired
+		if e, ok := err.(authError); ok {
+			return nil, e
+		}
+		return nil, authError{reason: err.Error(), clientReason: "invalid session"}
 	}
 	user, err := svc.User(ctx, session.UserID)
 	if err != nil {
@@ -120,6 +262,28 @@ func authViewer(ctx context.Context, jwtKey string, bearerToken token.Token, svc
 	return &viewer.Viewer{User: user, Session: session}, nil
 }
 
+// apiTokenViewer creates a viewer for the owner of the API token identified
+// by the JWT claims.
+func apiTokenViewer(ctx context.Context, claims jwt.MapClaims, svc kolide.Service) (*viewer.Viewer, error) {
+	apiTokenKey, ok := claims["api_token_key"].(string)
+	if !ok {
+		return nil, authError{reason: "non-string key in api_token_key claim"}
+	}
+	apiToken, err := svc.GetAPITokenByKey(ctx, apiTokenKey)
+	if err != nil {
+		return nil, authError{reason: err.Error()}
+	}
+	user, err := svc.User(ctx, apiToken.UserID)
+	if err != nil {
+		return nil, authError{reason: err.Error()}
+	}
+	// Tokens of disabled users stop working along with their sessions
+	if !user.Enabled {
+		return nil, authError{reason: "account disabled", clientReason: "account disabled"}
+	}
+	return &viewer.Viewer{User: user, APIToken: apiToken}, nil
+}
+
 func mustBeAdmin(next endpoint.Endpoint) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		vc, ok := viewer.FromContext(ctx)
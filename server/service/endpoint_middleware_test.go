@@ -2,16 +2,21 @@ package service
 
 import (
 	"context"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/WatchBeam/clock"
 	"github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/kolide/fleet/server/config"
 	hostctx "github.com/kolide/fleet/server/contexts/host"
+	"github.com/kolide/fleet/server/contexts/sourceip"
 	"github.com/kolide/fleet/server/contexts/viewer"
 	"github.com/kolide/fleet/server/datastore/inmem"
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/kolide/fleet/server/mock"
+	"github.com/kolide/fleet/server/ratelimit"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -139,6 +144,32 @@ func TestEndpointPermissions(t *testing.T) {
 	}
 }
 
+// TestAPITokenViewer tests that API tokens authenticate their owner only
+// while the owner is enabled
+func TestAPITokenViewer(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	users := createTestUsers(t, ds)
+	enabled := users["user1"]
+	disabled := users["disabled1"]
+	_, err = ds.NewAPIToken(&kolide.APIToken{UserID: enabled.ID, Key: "enabled"})
+	require.Nil(t, err)
+	_, err = ds.NewAPIToken(&kolide.APIToken{UserID: disabled.ID, Key: "disabled"})
+	require.Nil(t, err)
+
+	ctx := context.Background()
+	vc, err := apiTokenViewer(ctx, map[string]interface{}{"api_token_key": "enabled"}, svc)
+	require.Nil(t, err)
+	assert.Equal(t, enabled.ID, vc.UserID())
+	assert.NotNil(t, vc.APIToken)
+
+	_, err = apiTokenViewer(ctx, map[string]interface{}{"api_token_key": "disabled"}, svc)
+	assert.IsType(t, authError{}, err)
+}
+
 // TestGetNodeKey tests the reflection logic for pulling the node key from
 // various (fake) request types
 func TestGetNodeKey(t *testing.T) {
@@ -262,3 +293,84 @@ func TestAuthenticatedHost(t *testing.T) {
 	}
 
 }
+
+func TestRateLimit(t *testing.T) {
+	c := clock.NewMockClock()
+	e := rateLimit(ratelimit.NewTokenBucket(1, c), "enroll_agent")(endpoint.Nop)
+
+	_, err := e(context.Background(), struct{}{})
+	require.Nil(t, err)
+
+	_, err = e(context.Background(), struct{}{})
+	require.NotNil(t, err)
+	oe, ok := err.(osqueryError)
+	require.True(t, ok)
+	assert.False(t, oe.NodeInvalid())
+	assert.Equal(t, time.Second, oe.RetryAfter())
+
+	c.AddTime(time.Second)
+	_, err = e(context.Background(), struct{}{})
+	assert.Nil(t, err)
+}
+
+func TestThrottleBySourceIP(t *testing.T) {
+	c := clock.NewMockClock()
+	e := throttleBySourceIP(ratelimit.NewBudget(2, time.Minute, c), "login")(endpoint.Nop)
+	fromIP := func(remoteAddr string) context.Context {
+		return context.WithValue(context.Background(), kithttp.ContextKeyRequestRemoteAddr, remoteAddr)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := e(fromIP("10.0.0.1:5000"), struct{}{})
+		require.Nil(t, err)
+	}
+
+	// The port does not distinguish clients
+	c.AddTime(15 * time.Second)
+	_, err := e(fromIP("10.0.0.1:5001"), struct{}{})
+	require.NotNil(t, err)
+	te, ok := err.(throttledError)
+	require.True(t, ok)
+	assert.Equal(t, 45*time.Second, te.RetryAfter())
+
+	_, err = e(fromIP("10.0.0.2:5000"), struct{}{})
+	require.Nil(t, err)
+
+	c.AddTime(45 * time.Second)
+	_, err = e(fromIP("10.0.0.1:5000"), struct{}{})
+	assert.Nil(t, err)
+}
+
+func TestThrottleBySourceIPSpoofedHeader(t *testing.T) {
+	c := clock.NewMockClock()
+	e := throttleBySourceIP(ratelimit.NewBudget(2, time.Minute, c), "login")(endpoint.Nop)
+	proxies, err := sourceip.ParseTrustedProxies("10.0.0.0/8")
+	require.Nil(t, err)
+	request := func(remoteAddr, forwarded string) error {
+		r := httptest.NewRequest("POST", "/api/v1/kolide/login", nil)
+		r.RemoteAddr = remoteAddr
+		r.Header.Set("X-Forwarded-For", forwarded)
+		ctx := setSourceIP(proxies)(context.Background(), r)
+		_, err := e(ctx, struct{}{})
+		return err
+	}
+
+	// A new header on each attempt does not reset the throttle
+	require.Nil(t, request("192.168.1.5:5000", "1.1.1.1"))
+	require.Nil(t, request("192.168.1.5:5000", "2.2.2.2"))
+	err = request("192.168.1.5:5000", "3.3.3.3")
+	require.NotNil(t, err)
+	_, ok := err.(throttledError)
+	assert.True(t, ok)
+
+	// Behind a trusted proxy, addresses prepended by the client are skipped
+	require.Nil(t, request("10.0.0.1:5000", "1.1.1.1, 172.16.0.9"))
+	require.Nil(t, request("10.0.0.1:5000", "2.2.2.2, 172.16.0.9"))
+	err = request("10.0.0.1:5000", "3.3.3.3, 172.16.0.9")
+	require.NotNil(t, err)
+	_, ok = err.(throttledError)
+	assert.True(t, ok)
+
+	// Other clients behind the proxy are not throttled
+	assert.Nil(t, request("10.0.0.1:5000", "172.16.0.10"))
+}
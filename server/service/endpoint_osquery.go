@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 
 	"github.com/go-kit/kit/endpoint"
+	hostctx "github.com/kolide/fleet/server/contexts/host"
 	"github.com/kolide/fleet/server/kolide"
 )
 
@@ -64,6 +65,30 @@ func makeGetClientConfigEndpoint(svc kolide.Service) endpoint.Endpoint {
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// Get Scheduled Queries
+////////////////////////////////////////////////////////////////////////////////
+
+type getScheduledQueriesRequest struct {
+	NodeKey string `json:"node_key"`
+}
+
+// makeGetScheduledQueriesEndpoint lets an enrolled host see which of its
+// scheduled queries are collected by Fleet.
+func makeGetScheduledQueriesEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		host, ok := hostctx.FromContext(ctx)
+		if !ok {
+			return hostScheduledQueriesResponse{Err: osqueryError{message: "internal error: missing host from request context"}}, nil
+		}
+		queries, err := svc.HostScheduledQueries(ctx, host.ID)
+		if err != nil {
+			return hostScheduledQueriesResponse{Err: err}, nil
+		}
+		return hostScheduledQueriesResponse{ScheduledQueries: queries}, nil
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Get Distributed Queries
 ////////////////////////////////////////////////////////////////////////////////
@@ -98,6 +123,8 @@ type submitDistributedQueryResultsRequest struct {
 	NodeKey  string                                `json:"node_key"`
 	Results  kolide.OsqueryDistributedQueryResults `json:"queries"`
 	Statuses map[string]kolide.OsqueryStatus       `json:"statuses"`
+	// Stats are only sent by osquery 5 and later
+	Stats map[string]*kolide.OsqueryDistributedQueryStats `json:"stats"`
 }
 
 type submitDistributedQueryResultsResponse struct {
@@ -109,7 +136,7 @@ func (r submitDistributedQueryResultsResponse) error() error { return r.Err }
 func makeSubmitDistributedQueryResultsEndpoint(svc kolide.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(submitDistributedQueryResultsRequest)
-		err := svc.SubmitDistributedQueryResults(ctx, req.Results, req.Statuses)
+		err := svc.SubmitDistributedQueryResults(ctx, req.Results, req.Statuses, req.Stats)
 		if err != nil {
 			return submitDistributedQueryResultsResponse{Err: err}, nil
 		}
@@ -52,3 +52,31 @@ func makeGetOsqueryOptionsSpecEndpoint(svc kolide.Service) endpoint.Endpoint {
 		return getOsqueryOptionsSpecResponse{Spec: spec}, nil
 	}
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// Generate Osquery Flagfile
+////////////////////////////////////////////////////////////////////////////////
+
+type generateOsqueryFlagfileRequest struct {
+	EnrollSecret string `json:"enroll_secret"`
+}
+
+type generateOsqueryFlagfileResponse struct {
+	Flagfile []byte
+	Err      error `json:"error,omitempty"`
+}
+
+func (r generateOsqueryFlagfileResponse) error() error { return r.Err }
+
+func (r generateOsqueryFlagfileResponse) text() []byte { return r.Flagfile }
+
+func makeGenerateOsqueryFlagfileEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(generateOsqueryFlagfileRequest)
+		flagfile, err := svc.GenerateOsqueryFlagfile(ctx, req.EnrollSecret)
+		if err != nil {
+			return generateOsqueryFlagfileResponse{Err: err}, nil
+		}
+		return generateOsqueryFlagfileResponse{Flagfile: flagfile}, nil
+	}
+}
@@ -96,15 +96,20 @@ func makeGetPackEndpoint(svc kolide.Service) endpoint.Endpoint {
 
 type listPacksRequest struct {
 	ListOptions kolide.ListOptions
+	IfNoneMatch string
 }
 
 type listPacksResponse struct {
 	Packs []packResponse `json:"packs"`
 	Err   error          `json:"error,omitempty"`
+	// ifNoneMatchHeader is the If-None-Match header of the request
+	ifNoneMatchHeader string
 }
 
 func (r listPacksResponse) error() error { return r.Err }
 
+func (r listPacksResponse) ifNoneMatch() string { return r.ifNoneMatchHeader }
+
 func makeListPacksEndpoint(svc kolide.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(listPacksRequest)
@@ -113,7 +118,7 @@ func makeListPacksEndpoint(svc kolide.Service) endpoint.Endpoint {
 			return getPackResponse{Err: err}, nil
 		}
 
-		resp := listPacksResponse{Packs: make([]packResponse, len(packs))}
+		resp := listPacksResponse{Packs: make([]packResponse, len(packs)), ifNoneMatchHeader: req.IfNoneMatch}
 		for i, pack := range packs {
 			packResp, err := packResponseForPack(ctx, svc, *pack)
 			if err != nil {
@@ -125,6 +130,42 @@ func makeListPacksEndpoint(svc kolide.Service) endpoint.Endpoint {
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// List Hosts Missing Pack
+////////////////////////////////////////////////////////////////////////////////
+
+type listHostsMissingPackRequest struct {
+	ID          uint
+	ListOptions kolide.HostListOptions
+}
+
+type listHostsMissingPackResponse struct {
+	Hosts []HostResponse `json:"hosts"`
+	Err   error          `json:"error,omitempty"`
+}
+
+func (r listHostsMissingPackResponse) error() error { return r.Err }
+
+func makeListHostsMissingPackEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listHostsMissingPackRequest)
+		hosts, err := svc.HostsMissingPack(ctx, req.ID, req.ListOptions)
+		if err != nil {
+			return listHostsMissingPackResponse{Err: err}, nil
+		}
+
+		resp := listHostsMissingPackResponse{Hosts: make([]HostResponse, len(hosts))}
+		for i := range hosts {
+			h, err := hostResponseForHost(ctx, svc, &hosts[i])
+			if err != nil {
+				return listHostsMissingPackResponse{Err: err}, nil
+			}
+			resp.Hosts[i] = *h
+		}
+		return resp, nil
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Create Pack
 ////////////////////////////////////////////////////////////////////////////////
@@ -140,6 +181,8 @@ type createPackResponse struct {
 
 func (r createPackResponse) error() error { return r.Err }
 
+func (r createPackResponse) activityTargetID() uint { return r.Pack.ID }
+
 func makeCreatePackEndpoint(svc kolide.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(createPackRequest)
@@ -159,6 +202,41 @@ func makeCreatePackEndpoint(svc kolide.Service) endpoint.Endpoint {
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// Clone Pack
+////////////////////////////////////////////////////////////////////////////////
+
+type clonePackRequest struct {
+	ID   uint   `json:"-"`
+	Name string `json:"name"`
+}
+
+type clonePackResponse struct {
+	Pack packResponse `json:"pack,omitempty"`
+	Err  error        `json:"error,omitempty"`
+}
+
+func (r clonePackResponse) error() error { return r.Err }
+
+func (r clonePackResponse) activityTargetID() uint { return r.Pack.ID }
+
+func makeClonePackEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(clonePackRequest)
+		pack, err := svc.ClonePack(ctx, req.ID, req.Name)
+		if err != nil {
+			return clonePackResponse{Err: err}, nil
+		}
+
+		resp, err := packResponseForPack(ctx, svc, *pack)
+		if err != nil {
+			return clonePackResponse{Err: err}, nil
+		}
+
+		return clonePackResponse{Pack: *resp}, nil
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Modify Pack
 ////////////////////////////////////////////////////////////////////////////////
@@ -290,6 +368,101 @@ func makeGetPackSpecsEndpoint(svc kolide.Service) endpoint.Endpoint {
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// Export Pack
+////////////////////////////////////////////////////////////////////////////////
+
+type exportPackRequest struct {
+	ID uint
+}
+
+type exportPackResponse struct {
+	Spec *kolide.PackSpec `json:"spec,omitempty"`
+	Err  error            `json:"error,omitempty"`
+}
+
+func (r exportPackResponse) error() error { return r.Err }
+
+func makeExportPackEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(exportPackRequest)
+		spec, err := svc.ExportPack(ctx, req.ID)
+		if err != nil {
+			return exportPackResponse{Err: err}, nil
+		}
+		return exportPackResponse{Spec: spec}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Import Pack
+////////////////////////////////////////////////////////////////////////////////
+
+type importPackRequest struct {
+	Spec *kolide.PackSpec `json:"spec"`
+}
+
+type importPackResponse struct {
+	Pack packResponse `json:"pack,omitempty"`
+	Err  error        `json:"error,omitempty"`
+}
+
+func (r importPackResponse) error() error { return r.Err }
+
+func (r importPackResponse) activityTargetID() uint { return r.Pack.ID }
+
+func makeImportPackEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(importPackRequest)
+		pack, err := svc.ImportPack(ctx, req.Spec)
+		if err != nil {
+			return importPackResponse{Err: err}, nil
+		}
+
+		resp, err := packResponseForPack(ctx, svc, *pack)
+		if err != nil {
+			return importPackResponse{Err: err}, nil
+		}
+
+		return importPackResponse{Pack: *resp}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Import Pack From URL
+////////////////////////////////////////////////////////////////////////////////
+
+type importPackFromURLRequest struct {
+	URL string `json:"url"`
+}
+
+type importPackFromURLResponse struct {
+	Packs []packResponse `json:"packs"`
+	Err   error          `json:"error,omitempty"`
+}
+
+func (r importPackFromURLResponse) error() error { return r.Err }
+
+func makeImportPackFromURLEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(importPackFromURLRequest)
+		packs, err := svc.ImportPackFromURL(ctx, req.URL)
+		if err != nil {
+			return importPackFromURLResponse{Err: err}, nil
+		}
+
+		resp := importPackFromURLResponse{Packs: []packResponse{}}
+		for _, pack := range packs {
+			packResp, err := packResponseForPack(ctx, svc, *pack)
+			if err != nil {
+				return importPackFromURLResponse{Err: err}, nil
+			}
+			resp.Packs = append(resp.Packs, *packResp)
+		}
+		return resp, nil
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Get Pack Spec
 ////////////////////////////////////////////////////////////////////////////////
@@ -37,16 +37,21 @@ func makeGetQueryEndpoint(svc kolide.Service) endpoint.Endpoint {
 // List Queries
 ////////////////////////////////////////////////////////////////////////////////
 type listQueriesRequest struct {
-	ListOptions kolide.ListOptions
+	ListOptions kolide.ListQueryOptions
+	IfNoneMatch string
 }
 
 type listQueriesResponse struct {
 	Queries []kolide.Query `json:"queries"`
 	Err     error          `json:"error,omitempty"`
+	// ifNoneMatchHeader is the If-None-Match header of the request
+	ifNoneMatchHeader string
 }
 
 func (r listQueriesResponse) error() error { return r.Err }
 
+func (r listQueriesResponse) ifNoneMatch() string { return r.ifNoneMatchHeader }
+
 func makeListQueriesEndpoint(svc kolide.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(listQueriesRequest)
@@ -55,7 +60,7 @@ func makeListQueriesEndpoint(svc kolide.Service) endpoint.Endpoint {
 			return listQueriesResponse{Err: err}, nil
 		}
 
-		resp := listQueriesResponse{Queries: []kolide.Query{}}
+		resp := listQueriesResponse{Queries: []kolide.Query{}, ifNoneMatchHeader: req.IfNoneMatch}
 		for _, query := range queries {
 			resp.Queries = append(resp.Queries, *query)
 		}
@@ -192,6 +197,53 @@ func makeDeleteQueriesEndpoint(svc kolide.Service) endpoint.Endpoint {
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// Restore Query
+////////////////////////////////////////////////////////////////////////////////
+
+type restoreQueryRequest struct {
+	ID uint
+}
+
+type restoreQueryResponse struct {
+	Query *kolide.Query `json:"query,omitempty"`
+	Err   error         `json:"error,omitempty"`
+}
+
+func (r restoreQueryResponse) error() error { return r.Err }
+
+func makeRestoreQueryEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(restoreQueryRequest)
+		query, err := svc.RestoreQuery(ctx, req.ID)
+		if err != nil {
+			return restoreQueryResponse{Err: err}, nil
+		}
+		return restoreQueryResponse{Query: query}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// List Query Tags
+////////////////////////////////////////////////////////////////////////////////
+
+type listQueryTagsResponse struct {
+	Tags []kolide.QueryTag `json:"tags"`
+	Err  error             `json:"error,omitempty"`
+}
+
+func (r listQueryTagsResponse) error() error { return r.Err }
+
+func makeListQueryTagsEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		tags, err := svc.ListQueryTags(ctx)
+		if err != nil {
+			return listQueryTagsResponse{Err: err}, nil
+		}
+		return listQueryTagsResponse{Tags: tags}, nil
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Apply Query Specs
 ////////////////////////////////////////////////////////////////////////////////
@@ -259,3 +311,33 @@ func makeGetQuerySpecEndpoint(svc kolide.Service) endpoint.Endpoint {
 		return getQuerySpecResponse{Spec: spec}, nil
 	}
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// Validate Query SQL
+////////////////////////////////////////////////////////////////////////////////
+
+type validateQuerySQLRequest struct {
+	Query string `json:"query"`
+}
+
+type validateQuerySQLResponse struct {
+	Valid    bool     `json:"valid"`
+	Problems []string `json:"problems"`
+	Err      error    `json:"error,omitempty"`
+}
+
+func (r validateQuerySQLResponse) error() error { return r.Err }
+
+func makeValidateQuerySQLEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(validateQuerySQLRequest)
+		valid, problems, err := svc.ValidateQuerySQL(ctx, req.Query)
+		if err != nil {
+			return validateQuerySQLResponse{Err: err}, nil
+		}
+		if problems == nil {
+			problems = []string{}
+		}
+		return validateQuerySQLResponse{Valid: valid, Problems: problems}, nil
+	}
+}
@@ -84,14 +84,17 @@ func makeGetScheduledQueryEndpoint(svc kolide.Service) endpoint.Endpoint {
 ////////////////////////////////////////////////////////////////////////////////
 
 type scheduleQueryRequest struct {
-	PackID   uint    `json:"pack_id"`
-	QueryID  uint    `json:"query_id"`
-	Interval uint    `json:"interval"`
-	Snapshot *bool   `json:"snapshot"`
-	Removed  *bool   `json:"removed"`
-	Platform *string `json:"platform"`
-	Version  *string `json:"version"`
-	Shard    *uint   `json:"shard"`
+	PackID        uint                  `json:"pack_id"`
+	QueryID       uint                  `json:"query_id"`
+	Interval      uint                  `json:"interval"`
+	Snapshot      *bool                 `json:"snapshot"`
+	Removed       *bool                 `json:"removed"`
+	Platform      *string               `json:"platform"`
+	Version       *string               `json:"version"`
+	Shard         *uint                 `json:"shard"`
+	MaxResultRows *uint                 `json:"max_result_rows"`
+	SampleRate    *float64              `json:"sample_rate"`
+	ColumnMapping *kolide.ColumnMapping `json:"column_mapping"`
 }
 
 type scheduleQueryResponse struct {
@@ -101,19 +104,24 @@ type scheduleQueryResponse struct {
 
 func (r scheduleQueryResponse) error() error { return r.Err }
 
+func (r scheduleQueryResponse) activityTargetID() uint { return r.Scheduled.ID }
+
 func makeScheduleQueryEndpoint(svc kolide.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(scheduleQueryRequest)
 
 		scheduled, err := svc.ScheduleQuery(ctx, &kolide.ScheduledQuery{
-			PackID:   req.PackID,
-			QueryID:  req.QueryID,
-			Interval: req.Interval,
-			Snapshot: req.Snapshot,
-			Removed:  req.Removed,
-			Platform: req.Platform,
-			Version:  req.Version,
-			Shard:    req.Shard,
+			PackID:        req.PackID,
+			QueryID:       req.QueryID,
+			Interval:      req.Interval,
+			Snapshot:      req.Snapshot,
+			Removed:       req.Removed,
+			Platform:      req.Platform,
+			Version:       req.Version,
+			Shard:         req.Shard,
+			MaxResultRows: req.MaxResultRows,
+			SampleRate:    req.SampleRate,
+			ColumnMapping: req.ColumnMapping,
 		})
 		if err != nil {
 			return scheduleQueryResponse{Err: err}, nil
@@ -157,6 +165,33 @@ func makeModifyScheduledQueryEndpoint(svc kolide.Service) endpoint.Endpoint {
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// Modify Scheduled Queries In Pack
+////////////////////////////////////////////////////////////////////////////////
+
+type modifyScheduledQueriesInPackRequest struct {
+	ID    uint
+	patch kolide.ScheduledQueryPatch
+}
+
+type modifyScheduledQueriesInPackResponse struct {
+	Modified int   `json:"modified"`
+	Err      error `json:"error,omitempty"`
+}
+
+func (r modifyScheduledQueriesInPackResponse) error() error { return r.Err }
+
+func makeModifyScheduledQueriesInPackEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(modifyScheduledQueriesInPackRequest)
+		modified, err := svc.ModifyScheduledQueriesInPack(ctx, req.ID, req.patch)
+		if err != nil {
+			return modifyScheduledQueriesInPackResponse{Err: err}, nil
+		}
+		return modifyScheduledQueriesInPackResponse{Modified: modified}, nil
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Delete Scheduled Query
 ////////////////////////////////////////////////////////////////////////////////
@@ -183,3 +218,29 @@ func makeDeleteScheduledQueryEndpoint(svc kolide.Service) endpoint.Endpoint {
 		return deleteScheduledQueryResponse{}, nil
 	}
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// Get Scheduled Query Stats
+////////////////////////////////////////////////////////////////////////////////
+
+type getScheduledQueryStatsRequest struct {
+	ID uint
+}
+
+type getScheduledQueryStatsResponse struct {
+	Stats []*kolide.AggregatedScheduledQueryStats `json:"stats"`
+	Err   error                                   `json:"error,omitempty"`
+}
+
+func (r getScheduledQueryStatsResponse) error() error { return r.Err }
+
+func makeGetScheduledQueryStatsEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getScheduledQueryStatsRequest)
+		stats, err := svc.ScheduledQueryStats(ctx, req.ID)
+		if err != nil {
+			return getScheduledQueryStatsResponse{Err: err}, nil
+		}
+		return getScheduledQueryStatsResponse{Stats: stats}, nil
+	}
+}
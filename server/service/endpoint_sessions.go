@@ -262,3 +262,22 @@ func makeSSOSettingsEndpoint(svc kolide.Service) endpoint.Endpoint {
 		return ssoSettingsResponse{Settings: settings}, nil
 	}
 }
+
+type samlMetadataResponse struct {
+	metadata []byte
+	Err      error `json:"error,omitempty"`
+}
+
+func (r samlMetadataResponse) error() error { return r.Err }
+
+func (r samlMetadataResponse) xml() []byte { return r.metadata }
+
+func makeSAMLMetadataEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, unused interface{}) (interface{}, error) {
+		metadata, err := svc.GenerateSAMLMetadata(ctx)
+		if err != nil {
+			return samlMetadataResponse{Err: err}, nil
+		}
+		return samlMetadataResponse{metadata: metadata}, nil
+	}
+}
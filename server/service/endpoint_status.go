@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/kolide/fleet/server/kolide"
@@ -32,3 +33,26 @@ func makeStatusResultStoreEndpoint(svc kolide.Service) endpoint.Endpoint {
 		return resp, nil
 	}
 }
+
+type healthzResponse struct {
+	kolide.HealthStatus
+}
+
+// status returns 503 when a critical dependency is unhealthy, so that load
+// balancers stop routing requests to the server.
+func (r healthzResponse) status() int {
+	if !r.Healthy {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+func makeHealthzEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		status, err := svc.Healthz(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return healthzResponse{status}, nil
+	}
+}
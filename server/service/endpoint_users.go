@@ -23,6 +23,8 @@ type createUserResponse struct {
 
 func (r createUserResponse) error() error { return r.Err }
 
+func (r createUserResponse) activityTargetID() uint { return r.User.ID }
+
 func makeCreateUserEndpoint(svc kolide.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(createUserRequest)
@@ -34,6 +36,43 @@ func makeCreateUserEndpoint(svc kolide.Service) endpoint.Endpoint {
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// Create Users
+////////////////////////////////////////////////////////////////////////////////
+
+type createUsersRequest struct {
+	Users []kolide.UserPayload `json:"users"`
+}
+
+// createUsersResult is the outcome of creating one user of the batch. The
+// error is encoded as it would be for a single user.
+type createUsersResult struct {
+	User  *kolide.User `json:"user,omitempty"`
+	Error *jsonError   `json:"error,omitempty"`
+}
+
+type createUsersResponse struct {
+	Results []createUsersResult `json:"results"`
+	Err     error               `json:"error,omitempty"`
+}
+
+func (r createUsersResponse) error() error { return r.Err }
+
+func makeCreateUsersEndpoint(svc kolide.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createUsersRequest)
+		users, errs := svc.CreateUsers(ctx, req.Users)
+		results := make([]createUsersResult, len(users))
+		for i := range users {
+			results[i].User = users[i]
+			if errs[i] != nil {
+				results[i].Error = batchError(errs[i])
+			}
+		}
+		return createUsersResponse{Results: results}, nil
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Get User
 ////////////////////////////////////////////////////////////////////////////////
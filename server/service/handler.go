@@ -5,12 +5,15 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/WatchBeam/clock"
 	"github.com/go-kit/kit/endpoint"
 	kitlog "github.com/go-kit/kit/log"
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
 	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/contexts/sourceip"
 	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/ratelimit"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -23,6 +26,7 @@ type KolideEndpoints struct {
 	Me                                    endpoint.Endpoint
 	ChangePassword                        endpoint.Endpoint
 	CreateUser                            endpoint.Endpoint
+	CreateUsers                           endpoint.Endpoint
 	GetUser                               endpoint.Endpoint
 	ListUsers                             endpoint.Endpoint
 	ModifyUser                            endpoint.Endpoint
@@ -36,8 +40,10 @@ type KolideEndpoints struct {
 	DeleteSession                         endpoint.Endpoint
 	GetAppConfig                          endpoint.Endpoint
 	ModifyAppConfig                       endpoint.Endpoint
+	TestSMTPSettings                      endpoint.Endpoint
 	ApplyEnrollSecretSpec                 endpoint.Endpoint
 	GetEnrollSecretSpec                   endpoint.Endpoint
+	EnrollSecretUsage                     endpoint.Endpoint
 	CreateInvite                          endpoint.Endpoint
 	ListInvites                           endpoint.Endpoint
 	DeleteInvite                          endpoint.Endpoint
@@ -49,27 +55,44 @@ type KolideEndpoints struct {
 	DeleteQuery                           endpoint.Endpoint
 	DeleteQueryByID                       endpoint.Endpoint
 	DeleteQueries                         endpoint.Endpoint
+	RestoreQuery                          endpoint.Endpoint
+	ListQueryTags                         endpoint.Endpoint
+	ValidateQuerySQL                      endpoint.Endpoint
 	ApplyQuerySpecs                       endpoint.Endpoint
 	GetQuerySpecs                         endpoint.Endpoint
 	GetQuerySpec                          endpoint.Endpoint
 	CreateDistributedQueryCampaign        endpoint.Endpoint
 	CreateDistributedQueryCampaignByNames endpoint.Endpoint
+	CreateSavedQueryCampaign              endpoint.Endpoint
+	CreateSavedQueryCampaignByNames       endpoint.Endpoint
+	DistributedQueryCampaignTargetsCount  endpoint.Endpoint
+	EstimateQueryCost                     endpoint.Endpoint
+	ListRunningCampaigns                  endpoint.Endpoint
+	StopCampaign                          endpoint.Endpoint
 	CreatePack                            endpoint.Endpoint
+	ClonePack                             endpoint.Endpoint
 	ModifyPack                            endpoint.Endpoint
 	GetPack                               endpoint.Endpoint
 	ListPacks                             endpoint.Endpoint
 	DeletePack                            endpoint.Endpoint
 	DeletePackByID                        endpoint.Endpoint
 	GetScheduledQueriesInPack             endpoint.Endpoint
+	ListHostsMissingPack                  endpoint.Endpoint
+	GetScheduledQueryStats                endpoint.Endpoint
 	ScheduleQuery                         endpoint.Endpoint
 	GetScheduledQuery                     endpoint.Endpoint
 	ModifyScheduledQuery                  endpoint.Endpoint
+	ModifyScheduledQueriesInPack          endpoint.Endpoint
 	DeleteScheduledQuery                  endpoint.Endpoint
 	ApplyPackSpecs                        endpoint.Endpoint
 	GetPackSpecs                          endpoint.Endpoint
 	GetPackSpec                           endpoint.Endpoint
+	ExportPack                            endpoint.Endpoint
+	ImportPack                            endpoint.Endpoint
+	ImportPackFromURL                     endpoint.Endpoint
 	EnrollAgent                           endpoint.Endpoint
 	GetClientConfig                       endpoint.Endpoint
+	GetScheduledQueries                   endpoint.Endpoint
 	GetDistributedQueries                 endpoint.Endpoint
 	SubmitDistributedQueryResults         endpoint.Endpoint
 	SubmitLogs                            endpoint.Endpoint
@@ -82,9 +105,32 @@ type KolideEndpoints struct {
 	ApplyLabelSpecs                       endpoint.Endpoint
 	GetLabelSpecs                         endpoint.Endpoint
 	GetLabelSpec                          endpoint.Endpoint
+	AddHostsToLabel                       endpoint.Endpoint
+	RemoveHostsFromLabel                  endpoint.Endpoint
 	GetHost                               endpoint.Endpoint
 	DeleteHost                            endpoint.Endpoint
+	DeleteHostsByLabel                    endpoint.Endpoint
+	RefreshHostConfig                     endpoint.Endpoint
+	TransferHosts                         endpoint.Endpoint
+	RefreshHostDetails                    endpoint.Endpoint
+	SetHostMaintenance                    endpoint.Endpoint
+	ApproveHost                           endpoint.Endpoint
+	RotateHostNodeKey                     endpoint.Endpoint
+	MergeHosts                            endpoint.Endpoint
+	HostScheduledQueries                  endpoint.Endpoint
+	HostPacks                             endpoint.Endpoint
+	DiffHosts                             endpoint.Endpoint
+	HostExport                            endpoint.Endpoint
+	HostActivities                        endpoint.Endpoint
+	HostRebootHistory                     endpoint.Endpoint
+	HostClientConfig                      endpoint.Endpoint
+	AggregateHosts                        endpoint.Endpoint
+	NoisyHosts                            endpoint.Endpoint
+	HostsByOsqueryVersion                 endpoint.Endpoint
+	HostsByEncryptionStatus               endpoint.Endpoint
 	ListHosts                             endpoint.Endpoint
+	CountHosts                            endpoint.Endpoint
+	StreamHosts                           endpoint.Endpoint
 	GetHostSummary                        endpoint.Endpoint
 	SearchTargets                         endpoint.Endpoint
 	GetOptions                            endpoint.Endpoint
@@ -92,29 +138,50 @@ type KolideEndpoints struct {
 	ResetOptions                          endpoint.Endpoint
 	ApplyOsqueryOptionsSpec               endpoint.Endpoint
 	GetOsqueryOptionsSpec                 endpoint.Endpoint
+	GenerateOsqueryFlagfile               endpoint.Endpoint
 	GetCertificate                        endpoint.Endpoint
 	ChangeEmail                           endpoint.Endpoint
 	InitiateSSO                           endpoint.Endpoint
 	CallbackSSO                           endpoint.Endpoint
 	SSOSettings                           endpoint.Endpoint
+	SAMLMetadata                          endpoint.Endpoint
 	GetFIM                                endpoint.Endpoint
 	ModifyFIM                             endpoint.Endpoint
+	ListDecorators                        endpoint.Endpoint
+	NewDecorator                          endpoint.Endpoint
+	ModifyDecorator                       endpoint.Endpoint
+	DeleteDecorator                       endpoint.Endpoint
+	ListHostDetailQueries                 endpoint.Endpoint
+	NewHostDetailQuery                    endpoint.Endpoint
+	DeleteHostDetailQuery                 endpoint.Endpoint
+	GetHostExtraDetails                   endpoint.Endpoint
+	ListATCTables                         endpoint.Endpoint
+	GetATCTable                           endpoint.Endpoint
+	NewATCTable                           endpoint.Endpoint
+	ModifyATCTable                        endpoint.Endpoint
+	DeleteATCTable                        endpoint.Endpoint
+	ListActivities                        endpoint.Endpoint
+	ListIngestionFailures                 endpoint.Endpoint
+	CreateAPIToken                        endpoint.Endpoint
+	ListAPITokens                         endpoint.Endpoint
+	DeleteAPIToken                        endpoint.Endpoint
 	StatusResultStore                     endpoint.Endpoint
 	StatusLiveQuery                       endpoint.Endpoint
 }
 
 // MakeKolideServerEndpoints creates the Kolide API endpoints.
-func MakeKolideServerEndpoints(svc kolide.Service, jwtKey, urlPrefix string) KolideEndpoints {
+func MakeKolideServerEndpoints(svc kolide.Service, jwtKey, urlPrefix string, logger kitlog.Logger) KolideEndpoints {
 	return KolideEndpoints{
 		Login:          makeLoginEndpoint(svc),
 		Logout:         makeLogoutEndpoint(svc),
 		ForgotPassword: makeForgotPasswordEndpoint(svc),
 		ResetPassword:  makeResetPasswordEndpoint(svc),
-		CreateUser:     makeCreateUserEndpoint(svc),
+		CreateUser:     logActivity(svc, logger, "create_user")(makeCreateUserEndpoint(svc)),
 		VerifyInvite:   makeVerifyInviteEndpoint(svc),
 		InitiateSSO:    makeInitiateSSOEndpoint(svc),
 		CallbackSSO:    makeCallbackSSOEndpoint(svc, urlPrefix),
 		SSOSettings:    makeSSOSettingsEndpoint(svc),
+		SAMLMetadata:   makeSAMLMetadataEndpoint(svc),
 
 		// Authenticated user endpoints
 		// Each of these endpoints should have exactly one
@@ -122,78 +189,141 @@ func MakeKolideServerEndpoints(svc kolide.Service, jwtKey, urlPrefix string) Kol
 		// minimum, canPerformActions. Some endpoints use
 		// stricter/different checks and should NOT also use
 		// canPerformActions (these other checks should also call
-		// canPerformActions if that is appropriate).
+		// canPerformActions if that is appropriate). Endpoints that
+		// API tokens may access use scopedUser with the scope the token
+		// must hold.
 		Me:                   authenticatedUser(jwtKey, svc, canPerformActions(makeGetSessionUserEndpoint(svc))),
-		ChangePassword:       authenticatedUser(jwtKey, svc, canPerformActions(makeChangePasswordEndpoint(svc))),
+		ChangePassword:       authenticatedUser(jwtKey, svc, canPerformActions(logActivity(svc, logger, "change_password")(makeChangePasswordEndpoint(svc)))),
 		GetUser:              authenticatedUser(jwtKey, svc, canReadUser(makeGetUserEndpoint(svc))),
 		ListUsers:            authenticatedUser(jwtKey, svc, canPerformActions(makeListUsersEndpoint(svc))),
-		ModifyUser:           authenticatedUser(jwtKey, svc, canModifyUser(makeModifyUserEndpoint(svc))),
-		AdminUser:            authenticatedUser(jwtKey, svc, mustBeAdmin(makeAdminUserEndpoint(svc))),
-		EnableUser:           authenticatedUser(jwtKey, svc, mustBeAdmin(makeEnableUserEndpoint(svc))),
-		RequirePasswordReset: authenticatedUser(jwtKey, svc, mustBeAdmin(makeRequirePasswordResetEndpoint(svc))),
+		ModifyUser:           authenticatedUser(jwtKey, svc, canModifyUser(logActivity(svc, logger, "modify_user")(makeModifyUserEndpoint(svc)))),
+		AdminUser:            authenticatedUser(jwtKey, svc, mustBeAdmin(logActivity(svc, logger, "admin_user")(makeAdminUserEndpoint(svc)))),
+		EnableUser:           authenticatedUser(jwtKey, svc, mustBeAdmin(logActivity(svc, logger, "enable_user")(makeEnableUserEndpoint(svc)))),
+		RequirePasswordReset: authenticatedUser(jwtKey, svc, mustBeAdmin(logActivity(svc, logger, "require_password_reset")(makeRequirePasswordResetEndpoint(svc)))),
+		CreateUsers:          authenticatedUser(jwtKey, svc, mustBeAdmin(logActivity(svc, logger, "create_users")(makeCreateUsersEndpoint(svc)))),
 		// PerformRequiredPasswordReset needs only to authenticate the
 		// logged in user
 		PerformRequiredPasswordReset:          authenticatedUser(jwtKey, svc, canPerformPasswordReset(makePerformRequiredPasswordResetEndpoint(svc))),
 		GetSessionsForUserInfo:                authenticatedUser(jwtKey, svc, canReadUser(makeGetInfoAboutSessionsForUserEndpoint(svc))),
-		DeleteSessionsForUser:                 authenticatedUser(jwtKey, svc, canModifyUser(makeDeleteSessionsForUserEndpoint(svc))),
+		DeleteSessionsForUser:                 authenticatedUser(jwtKey, svc, canModifyUser(logActivity(svc, logger, "delete_sessions_for_user")(makeDeleteSessionsForUserEndpoint(svc)))),
 		GetSessionInfo:                        authenticatedUser(jwtKey, svc, mustBeAdmin(makeGetInfoAboutSessionEndpoint(svc))),
-		DeleteSession:                         authenticatedUser(jwtKey, svc, mustBeAdmin(makeDeleteSessionEndpoint(svc))),
+		DeleteSession:                         authenticatedUser(jwtKey, svc, mustBeAdmin(logActivity(svc, logger, "delete_session")(makeDeleteSessionEndpoint(svc)))),
 		GetAppConfig:                          authenticatedUser(jwtKey, svc, canPerformActions(makeGetAppConfigEndpoint(svc))),
-		ModifyAppConfig:                       authenticatedUser(jwtKey, svc, mustBeAdmin(makeModifyAppConfigEndpoint(svc))),
-		ApplyEnrollSecretSpec:                 authenticatedUser(jwtKey, svc, mustBeAdmin(makeApplyEnrollSecretSpecEndpoint(svc))),
+		ModifyAppConfig:                       authenticatedUser(jwtKey, svc, mustBeAdmin(logActivity(svc, logger, "modify_app_config")(makeModifyAppConfigEndpoint(svc)))),
+		TestSMTPSettings:                      authenticatedUser(jwtKey, svc, mustBeAdmin(makeTestSMTPSettingsEndpoint(svc))),
+		ApplyEnrollSecretSpec:                 authenticatedUser(jwtKey, svc, mustBeAdmin(logActivity(svc, logger, "apply_enroll_secret_spec")(makeApplyEnrollSecretSpecEndpoint(svc)))),
 		GetEnrollSecretSpec:                   authenticatedUser(jwtKey, svc, canPerformActions(makeGetEnrollSecretSpecEndpoint(svc))),
-		CreateInvite:                          authenticatedUser(jwtKey, svc, mustBeAdmin(makeCreateInviteEndpoint(svc))),
+		EnrollSecretUsage:                     authenticatedUser(jwtKey, svc, mustBeAdmin(makeEnrollSecretUsageEndpoint(svc))),
+		CreateInvite:                          authenticatedUser(jwtKey, svc, mustBeAdmin(logActivity(svc, logger, "create_invite")(makeCreateInviteEndpoint(svc)))),
 		ListInvites:                           authenticatedUser(jwtKey, svc, mustBeAdmin(makeListInvitesEndpoint(svc))),
-		DeleteInvite:                          authenticatedUser(jwtKey, svc, mustBeAdmin(makeDeleteInviteEndpoint(svc))),
-		GetQuery:                              authenticatedUser(jwtKey, svc, makeGetQueryEndpoint(svc)),
-		ListQueries:                           authenticatedUser(jwtKey, svc, makeListQueriesEndpoint(svc)),
-		CreateQuery:                           authenticatedUser(jwtKey, svc, makeCreateQueryEndpoint(svc)),
-		ModifyQuery:                           authenticatedUser(jwtKey, svc, makeModifyQueryEndpoint(svc)),
-		DeleteQuery:                           authenticatedUser(jwtKey, svc, makeDeleteQueryEndpoint(svc)),
-		DeleteQueryByID:                       authenticatedUser(jwtKey, svc, makeDeleteQueryByIDEndpoint(svc)),
-		DeleteQueries:                         authenticatedUser(jwtKey, svc, makeDeleteQueriesEndpoint(svc)),
-		ApplyQuerySpecs:                       authenticatedUser(jwtKey, svc, makeApplyQuerySpecsEndpoint(svc)),
-		GetQuerySpecs:                         authenticatedUser(jwtKey, svc, makeGetQuerySpecsEndpoint(svc)),
-		GetQuerySpec:                          authenticatedUser(jwtKey, svc, makeGetQuerySpecEndpoint(svc)),
-		CreateDistributedQueryCampaign:        authenticatedUser(jwtKey, svc, makeCreateDistributedQueryCampaignEndpoint(svc)),
-		CreateDistributedQueryCampaignByNames: authenticatedUser(jwtKey, svc, makeCreateDistributedQueryCampaignByNamesEndpoint(svc)),
-		CreatePack:                            authenticatedUser(jwtKey, svc, makeCreatePackEndpoint(svc)),
-		ModifyPack:                            authenticatedUser(jwtKey, svc, makeModifyPackEndpoint(svc)),
-		GetPack:                               authenticatedUser(jwtKey, svc, makeGetPackEndpoint(svc)),
-		ListPacks:                             authenticatedUser(jwtKey, svc, makeListPacksEndpoint(svc)),
-		DeletePack:                            authenticatedUser(jwtKey, svc, makeDeletePackEndpoint(svc)),
-		DeletePackByID:                        authenticatedUser(jwtKey, svc, makeDeletePackByIDEndpoint(svc)),
-		GetScheduledQueriesInPack:             authenticatedUser(jwtKey, svc, makeGetScheduledQueriesInPackEndpoint(svc)),
-		ScheduleQuery:                         authenticatedUser(jwtKey, svc, makeScheduleQueryEndpoint(svc)),
-		GetScheduledQuery:                     authenticatedUser(jwtKey, svc, makeGetScheduledQueryEndpoint(svc)),
-		ModifyScheduledQuery:                  authenticatedUser(jwtKey, svc, makeModifyScheduledQueryEndpoint(svc)),
-		DeleteScheduledQuery:                  authenticatedUser(jwtKey, svc, makeDeleteScheduledQueryEndpoint(svc)),
-		ApplyPackSpecs:                        authenticatedUser(jwtKey, svc, makeApplyPackSpecsEndpoint(svc)),
-		GetPackSpecs:                          authenticatedUser(jwtKey, svc, makeGetPackSpecsEndpoint(svc)),
-		GetPackSpec:                           authenticatedUser(jwtKey, svc, makeGetPackSpecEndpoint(svc)),
-		GetHost:                               authenticatedUser(jwtKey, svc, makeGetHostEndpoint(svc)),
-		ListHosts:                             authenticatedUser(jwtKey, svc, makeListHostsEndpoint(svc)),
-		GetHostSummary:                        authenticatedUser(jwtKey, svc, makeGetHostSummaryEndpoint(svc)),
-		DeleteHost:                            authenticatedUser(jwtKey, svc, makeDeleteHostEndpoint(svc)),
-		CreateLabel:                           authenticatedUser(jwtKey, svc, makeCreateLabelEndpoint(svc)),
-		ModifyLabel:                           authenticatedUser(jwtKey, svc, makeModifyLabelEndpoint(svc)),
-		GetLabel:                              authenticatedUser(jwtKey, svc, makeGetLabelEndpoint(svc)),
-		ListLabels:                            authenticatedUser(jwtKey, svc, makeListLabelsEndpoint(svc)),
-		DeleteLabel:                           authenticatedUser(jwtKey, svc, makeDeleteLabelEndpoint(svc)),
-		DeleteLabelByID:                       authenticatedUser(jwtKey, svc, makeDeleteLabelByIDEndpoint(svc)),
-		ApplyLabelSpecs:                       authenticatedUser(jwtKey, svc, makeApplyLabelSpecsEndpoint(svc)),
-		GetLabelSpecs:                         authenticatedUser(jwtKey, svc, makeGetLabelSpecsEndpoint(svc)),
-		GetLabelSpec:                          authenticatedUser(jwtKey, svc, makeGetLabelSpecEndpoint(svc)),
-		SearchTargets:                         authenticatedUser(jwtKey, svc, makeSearchTargetsEndpoint(svc)),
+		DeleteInvite:                          authenticatedUser(jwtKey, svc, mustBeAdmin(logActivity(svc, logger, "delete_invite")(makeDeleteInviteEndpoint(svc)))),
+		GetQuery:                              scopedUser(jwtKey, svc, kolide.ScopeQueriesRead, makeGetQueryEndpoint(svc)),
+		ListQueries:                           scopedUser(jwtKey, svc, kolide.ScopeQueriesRead, makeListQueriesEndpoint(svc)),
+		CreateQuery:                           scopedUser(jwtKey, svc, kolide.ScopeQueriesWrite, makeCreateQueryEndpoint(svc)),
+		ModifyQuery:                           scopedUser(jwtKey, svc, kolide.ScopeQueriesWrite, makeModifyQueryEndpoint(svc)),
+		DeleteQuery:                           scopedUser(jwtKey, svc, kolide.ScopeQueriesWrite, makeDeleteQueryEndpoint(svc)),
+		DeleteQueryByID:                       scopedUser(jwtKey, svc, kolide.ScopeQueriesWrite, makeDeleteQueryByIDEndpoint(svc)),
+		DeleteQueries:                         scopedUser(jwtKey, svc, kolide.ScopeQueriesWrite, makeDeleteQueriesEndpoint(svc)),
+		RestoreQuery:                          scopedUser(jwtKey, svc, kolide.ScopeQueriesWrite, makeRestoreQueryEndpoint(svc)),
+		ListQueryTags:                         scopedUser(jwtKey, svc, kolide.ScopeQueriesRead, makeListQueryTagsEndpoint(svc)),
+		ValidateQuerySQL:                      scopedUser(jwtKey, svc, kolide.ScopeQueriesRead, makeValidateQuerySQLEndpoint(svc)),
+		ApplyQuerySpecs:                       scopedUser(jwtKey, svc, kolide.ScopeQueriesWrite, makeApplyQuerySpecsEndpoint(svc)),
+		GetQuerySpecs:                         scopedUser(jwtKey, svc, kolide.ScopeQueriesRead, makeGetQuerySpecsEndpoint(svc)),
+		GetQuerySpec:                          scopedUser(jwtKey, svc, kolide.ScopeQueriesRead, makeGetQuerySpecEndpoint(svc)),
+		CreateDistributedQueryCampaign:        scopedUser(jwtKey, svc, kolide.ScopeQueriesRun, makeCreateDistributedQueryCampaignEndpoint(svc)),
+		CreateDistributedQueryCampaignByNames: scopedUser(jwtKey, svc, kolide.ScopeQueriesRun, makeCreateDistributedQueryCampaignByNamesEndpoint(svc)),
+		CreateSavedQueryCampaign:              scopedUser(jwtKey, svc, kolide.ScopeQueriesRun, makeCreateSavedQueryCampaignEndpoint(svc)),
+		CreateSavedQueryCampaignByNames:       scopedUser(jwtKey, svc, kolide.ScopeQueriesRun, makeCreateSavedQueryCampaignByNamesEndpoint(svc)),
+		DistributedQueryCampaignTargetsCount:  scopedUser(jwtKey, svc, kolide.ScopeQueriesRun, makeDistributedQueryCampaignTargetsCountEndpoint(svc)),
+		EstimateQueryCost:                     scopedUser(jwtKey, svc, kolide.ScopeQueriesRun, makeEstimateQueryCostEndpoint(svc)),
+		ListRunningCampaigns:                  scopedUser(jwtKey, svc, kolide.ScopeQueriesRun, makeListRunningCampaignsEndpoint(svc)),
+		StopCampaign:                          scopedUser(jwtKey, svc, kolide.ScopeQueriesRun, logActivity(svc, logger, "stop_campaign")(makeStopCampaignEndpoint(svc))),
+		CreatePack:                            scopedUser(jwtKey, svc, kolide.ScopePacksWrite, logActivity(svc, logger, "create_pack")(makeCreatePackEndpoint(svc))),
+		ClonePack:                             scopedUser(jwtKey, svc, kolide.ScopePacksWrite, logActivity(svc, logger, "clone_pack")(makeClonePackEndpoint(svc))),
+		ModifyPack:                            scopedUser(jwtKey, svc, kolide.ScopePacksWrite, logActivity(svc, logger, "modify_pack")(makeModifyPackEndpoint(svc))),
+		GetPack:                               scopedUser(jwtKey, svc, kolide.ScopePacksRead, makeGetPackEndpoint(svc)),
+		ListPacks:                             scopedUser(jwtKey, svc, kolide.ScopePacksRead, makeListPacksEndpoint(svc)),
+		DeletePack:                            scopedUser(jwtKey, svc, kolide.ScopePacksWrite, logActivity(svc, logger, "delete_pack")(makeDeletePackEndpoint(svc))),
+		DeletePackByID:                        scopedUser(jwtKey, svc, kolide.ScopePacksWrite, logActivity(svc, logger, "delete_pack")(makeDeletePackByIDEndpoint(svc))),
+		GetScheduledQueriesInPack:             scopedUser(jwtKey, svc, kolide.ScopePacksRead, makeGetScheduledQueriesInPackEndpoint(svc)),
+		ListHostsMissingPack:                  scopedUser(jwtKey, svc, kolide.ScopeHostsRead, makeListHostsMissingPackEndpoint(svc)),
+		GetScheduledQueryStats:                scopedUser(jwtKey, svc, kolide.ScopePacksRead, makeGetScheduledQueryStatsEndpoint(svc)),
+		ScheduleQuery:                         scopedUser(jwtKey, svc, kolide.ScopePacksWrite, logActivity(svc, logger, "schedule_query")(makeScheduleQueryEndpoint(svc))),
+		GetScheduledQuery:                     scopedUser(jwtKey, svc, kolide.ScopePacksRead, makeGetScheduledQueryEndpoint(svc)),
+		ModifyScheduledQuery:                  scopedUser(jwtKey, svc, kolide.ScopePacksWrite, logActivity(svc, logger, "modify_scheduled_query")(makeModifyScheduledQueryEndpoint(svc))),
+		ModifyScheduledQueriesInPack:          scopedUser(jwtKey, svc, kolide.ScopePacksWrite, logActivity(svc, logger, "modify_scheduled_queries_in_pack")(makeModifyScheduledQueriesInPackEndpoint(svc))),
+		DeleteScheduledQuery:                  scopedUser(jwtKey, svc, kolide.ScopePacksWrite, logActivity(svc, logger, "delete_scheduled_query")(makeDeleteScheduledQueryEndpoint(svc))),
+		ApplyPackSpecs:                        scopedUser(jwtKey, svc, kolide.ScopePacksWrite, logActivity(svc, logger, "apply_pack_specs")(makeApplyPackSpecsEndpoint(svc))),
+		GetPackSpecs:                          scopedUser(jwtKey, svc, kolide.ScopePacksRead, makeGetPackSpecsEndpoint(svc)),
+		GetPackSpec:                           scopedUser(jwtKey, svc, kolide.ScopePacksRead, makeGetPackSpecEndpoint(svc)),
+		ExportPack:                            scopedUser(jwtKey, svc, kolide.ScopePacksRead, makeExportPackEndpoint(svc)),
+		ImportPack:                            scopedUser(jwtKey, svc, kolide.ScopePacksWrite, logActivity(svc, logger, "import_pack")(makeImportPackEndpoint(svc))),
+		ImportPackFromURL:                     scopedUser(jwtKey, svc, kolide.ScopePacksWrite, mustBeAdmin(logActivity(svc, logger, "import_pack_from_url")(makeImportPackFromURLEndpoint(svc)))),
+		GetHost:                               scopedUser(jwtKey, svc, kolide.ScopeHostsRead, makeGetHostEndpoint(svc)),
+		ListHosts:                             scopedUser(jwtKey, svc, kolide.ScopeHostsRead, makeListHostsEndpoint(svc)),
+		CountHosts:                            scopedUser(jwtKey, svc, kolide.ScopeHostsRead, makeCountHostsEndpoint(svc)),
+		StreamHosts:                           scopedUser(jwtKey, svc, kolide.ScopeHostsRead, mustBeAdmin(makeStreamHostsEndpoint(svc))),
+		GetHostSummary:                        scopedUser(jwtKey, svc, kolide.ScopeHostsRead, makeGetHostSummaryEndpoint(svc)),
+		DeleteHost:                            scopedUser(jwtKey, svc, kolide.ScopeHostsWrite, makeDeleteHostEndpoint(svc)),
+		DeleteHostsByLabel:                    scopedUser(jwtKey, svc, kolide.ScopeHostsWrite, mustBeAdmin(makeDeleteHostsByLabelEndpoint(svc))),
+		RefreshHostConfig:                     scopedUser(jwtKey, svc, kolide.ScopeHostsWrite, mustBeAdmin(makeRefreshHostConfigEndpoint(svc))),
+		TransferHosts:                         scopedUser(jwtKey, svc, kolide.ScopeHostsWrite, mustBeAdmin(makeTransferHostsEndpoint(svc))),
+		RefreshHostDetails:                    scopedUser(jwtKey, svc, kolide.ScopeHostsWrite, mustBeAdmin(makeRefreshHostDetailsEndpoint(svc))),
+		SetHostMaintenance:                    scopedUser(jwtKey, svc, kolide.ScopeHostsWrite, mustBeAdmin(makeSetHostMaintenanceEndpoint(svc))),
+		ApproveHost:                           scopedUser(jwtKey, svc, kolide.ScopeHostsWrite, mustBeAdmin(makeApproveHostEndpoint(svc))),
+		RotateHostNodeKey:                     scopedUser(jwtKey, svc, kolide.ScopeHostsWrite, mustBeAdmin(makeRotateHostNodeKeyEndpoint(svc))),
+		MergeHosts:                            scopedUser(jwtKey, svc, kolide.ScopeHostsWrite, mustBeAdmin(makeMergeHostsEndpoint(svc))),
+		HostScheduledQueries:                  scopedUser(jwtKey, svc, kolide.ScopeHostsRead, canPerformActions(makeHostScheduledQueriesEndpoint(svc))),
+		HostPacks:                             scopedUser(jwtKey, svc, kolide.ScopeHostsRead, canPerformActions(makeHostPacksEndpoint(svc))),
+		DiffHosts:                             scopedUser(jwtKey, svc, kolide.ScopeHostsRead, canPerformActions(makeDiffHostsEndpoint(svc))),
+		HostExport:                            scopedUser(jwtKey, svc, kolide.ScopeHostsRead, canPerformActions(makeHostExportEndpoint(svc))),
+		HostActivities:                        scopedUser(jwtKey, svc, kolide.ScopeHostsRead, canPerformActions(makeHostActivitiesEndpoint(svc))),
+		HostRebootHistory:                     scopedUser(jwtKey, svc, kolide.ScopeHostsRead, canPerformActions(makeHostRebootHistoryEndpoint(svc))),
+		HostClientConfig:                      authenticatedUser(jwtKey, svc, mustBeAdmin(makeHostClientConfigEndpoint(svc))),
+		AggregateHosts:                        scopedUser(jwtKey, svc, kolide.ScopeHostsRead, canPerformActions(makeAggregateHostsEndpoint(svc))),
+		NoisyHosts:                            scopedUser(jwtKey, svc, kolide.ScopeHostsRead, canPerformActions(makeNoisyHostsEndpoint(svc))),
+		HostsByOsqueryVersion:                 scopedUser(jwtKey, svc, kolide.ScopeHostsRead, canPerformActions(makeHostsByOsqueryVersionEndpoint(svc))),
+		HostsByEncryptionStatus:               scopedUser(jwtKey, svc, kolide.ScopeHostsRead, canPerformActions(makeHostsByEncryptionStatusEndpoint(svc))),
+		CreateLabel:                           scopedUser(jwtKey, svc, kolide.ScopeLabelsWrite, makeCreateLabelEndpoint(svc)),
+		ModifyLabel:                           scopedUser(jwtKey, svc, kolide.ScopeLabelsWrite, makeModifyLabelEndpoint(svc)),
+		GetLabel:                              scopedUser(jwtKey, svc, kolide.ScopeLabelsRead, makeGetLabelEndpoint(svc)),
+		ListLabels:                            scopedUser(jwtKey, svc, kolide.ScopeLabelsRead, makeListLabelsEndpoint(svc)),
+		DeleteLabel:                           scopedUser(jwtKey, svc, kolide.ScopeLabelsWrite, makeDeleteLabelEndpoint(svc)),
+		DeleteLabelByID:                       scopedUser(jwtKey, svc, kolide.ScopeLabelsWrite, makeDeleteLabelByIDEndpoint(svc)),
+		ApplyLabelSpecs:                       scopedUser(jwtKey, svc, kolide.ScopeLabelsWrite, makeApplyLabelSpecsEndpoint(svc)),
+		GetLabelSpecs:                         scopedUser(jwtKey, svc, kolide.ScopeLabelsRead, makeGetLabelSpecsEndpoint(svc)),
+		GetLabelSpec:                          scopedUser(jwtKey, svc, kolide.ScopeLabelsRead, makeGetLabelSpecEndpoint(svc)),
+		AddHostsToLabel:                       scopedUser(jwtKey, svc, kolide.ScopeLabelsWrite, mustBeAdmin(makeAddHostsToLabelEndpoint(svc))),
+		RemoveHostsFromLabel:                  scopedUser(jwtKey, svc, kolide.ScopeLabelsWrite, mustBeAdmin(makeRemoveHostsFromLabelEndpoint(svc))),
+		SearchTargets:                         scopedUser(jwtKey, svc, kolide.ScopeHostsRead, makeSearchTargetsEndpoint(svc)),
 		GetOptions:                            authenticatedUser(jwtKey, svc, mustBeAdmin(makeGetOptionsEndpoint(svc))),
 		ModifyOptions:                         authenticatedUser(jwtKey, svc, mustBeAdmin(makeModifyOptionsEndpoint(svc))),
 		ResetOptions:                          authenticatedUser(jwtKey, svc, mustBeAdmin(makeResetOptionsEndpoint(svc))),
 		ApplyOsqueryOptionsSpec:               authenticatedUser(jwtKey, svc, makeApplyOsqueryOptionsSpecEndpoint(svc)),
 		GetOsqueryOptionsSpec:                 authenticatedUser(jwtKey, svc, makeGetOsqueryOptionsSpecEndpoint(svc)),
+		GenerateOsqueryFlagfile:               authenticatedUser(jwtKey, svc, canPerformActions(makeGenerateOsqueryFlagfileEndpoint(svc))),
 		GetCertificate:                        authenticatedUser(jwtKey, svc, makeCertificateEndpoint(svc)),
 		ChangeEmail:                           authenticatedUser(jwtKey, svc, makeChangeEmailEndpoint(svc)),
 		GetFIM:                                authenticatedUser(jwtKey, svc, makeGetFIMEndpoint(svc)),
 		ModifyFIM:                             authenticatedUser(jwtKey, svc, makeModifyFIMEndpoint(svc)),
+		ListDecorators:                        authenticatedUser(jwtKey, svc, makeListDecoratorsEndpoint(svc)),
+		NewDecorator:                          authenticatedUser(jwtKey, svc, mustBeAdmin(makeNewDecoratorEndpoint(svc))),
+		ModifyDecorator:                       authenticatedUser(jwtKey, svc, mustBeAdmin(makeModifyDecoratorEndpoint(svc))),
+		DeleteDecorator:                       authenticatedUser(jwtKey, svc, mustBeAdmin(makeDeleteDecoratorEndpoint(svc))),
+		ListHostDetailQueries:                 authenticatedUser(jwtKey, svc, makeListHostDetailQueriesEndpoint(svc)),
+		NewHostDetailQuery:                    authenticatedUser(jwtKey, svc, mustBeAdmin(makeNewHostDetailQueryEndpoint(svc))),
+		DeleteHostDetailQuery:                 authenticatedUser(jwtKey, svc, mustBeAdmin(makeDeleteHostDetailQueryEndpoint(svc))),
+		GetHostExtraDetails:                   scopedUser(jwtKey, svc, kolide.ScopeHostsRead, makeGetHostExtraDetailsEndpoint(svc)),
+		ListATCTables:                         authenticatedUser(jwtKey, svc, makeListATCTablesEndpoint(svc)),
+		GetATCTable:                           authenticatedUser(jwtKey, svc, makeGetATCTableEndpoint(svc)),
+		NewATCTable:                           authenticatedUser(jwtKey, svc, mustBeAdmin(makeNewATCTableEndpoint(svc))),
+		ModifyATCTable:                        authenticatedUser(jwtKey, svc, mustBeAdmin(makeModifyATCTableEndpoint(svc))),
+		DeleteATCTable:                        authenticatedUser(jwtKey, svc, mustBeAdmin(makeDeleteATCTableEndpoint(svc))),
+		ListActivities:                        authenticatedUser(jwtKey, svc, mustBeAdmin(makeListActivitiesEndpoint(svc))),
+		ListIngestionFailures:                 authenticatedUser(jwtKey, svc, mustBeAdmin(makeListIngestionFailuresEndpoint(svc))),
+		CreateAPIToken:                        authenticatedUser(jwtKey, svc, mustBeAdmin(logActivity(svc, logger, "create_api_token")(makeCreateAPITokenEndpoint(svc)))),
+		ListAPITokens:                         authenticatedUser(jwtKey, svc, canPerformActions(makeListAPITokensEndpoint(svc))),
+		DeleteAPIToken:                        authenticatedUser(jwtKey, svc, canPerformActions(logActivity(svc, logger, "delete_api_token")(makeDeleteAPITokenEndpoint(svc)))),
 
 		// Authenticated status endpoints
 		StatusResultStore: authenticatedUser(jwtKey, svc, makeStatusResultStoreEndpoint(svc)),
@@ -202,6 +332,7 @@ func MakeKolideServerEndpoints(svc kolide.Service, jwtKey, urlPrefix string) Kol
 		// Osquery endpoints
 		EnrollAgent:                   makeEnrollAgentEndpoint(svc),
 		GetClientConfig:               authenticatedHost(svc, makeGetClientConfigEndpoint(svc)),
+		GetScheduledQueries:           authenticatedHost(svc, makeGetScheduledQueriesEndpoint(svc)),
 		GetDistributedQueries:         authenticatedHost(svc, makeGetDistributedQueriesEndpoint(svc)),
 		SubmitDistributedQueryResults: authenticatedHost(svc, makeSubmitDistributedQueryResultsEndpoint(svc)),
 		SubmitLogs:                    authenticatedHost(svc, makeSubmitLogsEndpoint(svc)),
@@ -216,6 +347,7 @@ type kolideHandlers struct {
 	Me                                    http.Handler
 	ChangePassword                        http.Handler
 	CreateUser                            http.Handler
+	CreateUsers                           http.Handler
 	GetUser                               http.Handler
 	ListUsers                             http.Handler
 	ModifyUser                            http.Handler
@@ -229,8 +361,10 @@ type kolideHandlers struct {
 	DeleteSession                         http.Handler
 	GetAppConfig                          http.Handler
 	ModifyAppConfig                       http.Handler
+	TestSMTPSettings                      http.Handler
 	ApplyEnrollSecretSpec                 http.Handler
 	GetEnrollSecretSpec                   http.Handler
+	EnrollSecretUsage                     http.Handler
 	CreateInvite                          http.Handler
 	ListInvites                           http.Handler
 	DeleteInvite                          http.Handler
@@ -242,27 +376,44 @@ type kolideHandlers struct {
 	DeleteQuery                           http.Handler
 	DeleteQueryByID                       http.Handler
 	DeleteQueries                         http.Handler
+	RestoreQuery                          http.Handler
+	ListQueryTags                         http.Handler
+	ValidateQuerySQL                      http.Handler
 	ApplyQuerySpecs                       http.Handler
 	GetQuerySpecs                         http.Handler
 	GetQuerySpec                          http.Handler
 	CreateDistributedQueryCampaign        http.Handler
 	CreateDistributedQueryCampaignByNames http.Handler
+	CreateSavedQueryCampaign              http.Handler
+	CreateSavedQueryCampaignByNames       http.Handler
+	DistributedQueryCampaignTargetsCount  http.Handler
+	EstimateQueryCost                     http.Handler
+	ListRunningCampaigns                  http.Handler
+	StopCampaign                          http.Handler
 	CreatePack                            http.Handler
+	ClonePack                             http.Handler
 	ModifyPack                            http.Handler
 	GetPack                               http.Handler
 	ListPacks                             http.Handler
 	DeletePack                            http.Handler
 	DeletePackByID                        http.Handler
 	GetScheduledQueriesInPack             http.Handler
+	ListHostsMissingPack                  http.Handler
+	GetScheduledQueryStats                http.Handler
 	ScheduleQuery                         http.Handler
 	GetScheduledQuery                     http.Handler
 	ModifyScheduledQuery                  http.Handler
+	ModifyScheduledQueriesInPack          http.Handler
 	DeleteScheduledQuery                  http.Handler
 	ApplyPackSpecs                        http.Handler
 	GetPackSpecs                          http.Handler
 	GetPackSpec                           http.Handler
+	ExportPack                            http.Handler
+	ImportPack                            http.Handler
+	ImportPackFromURL                     http.Handler
 	EnrollAgent                           http.Handler
 	GetClientConfig                       http.Handler
+	GetScheduledQueries                   http.Handler
 	GetDistributedQueries                 http.Handler
 	SubmitDistributedQueryResults         http.Handler
 	SubmitLogs                            http.Handler
@@ -275,9 +426,32 @@ type kolideHandlers struct {
 	ApplyLabelSpecs                       http.Handler
 	GetLabelSpecs                         http.Handler
 	GetLabelSpec                          http.Handler
+	AddHostsToLabel                       http.Handler
+	RemoveHostsFromLabel                  http.Handler
 	GetHost                               http.Handler
 	DeleteHost                            http.Handler
+	DeleteHostsByLabel                    http.Handler
+	RefreshHostConfig                     http.Handler
+	TransferHosts                         http.Handler
+	RefreshHostDetails                    http.Handler
+	SetHostMaintenance                    http.Handler
+	ApproveHost                           http.Handler
+	RotateHostNodeKey                     http.Handler
+	MergeHosts                            http.Handler
+	HostScheduledQueries                  http.Handler
+	HostPacks                             http.Handler
+	DiffHosts                             http.Handler
+	HostExport                            http.Handler
+	HostActivities                        http.Handler
+	HostRebootHistory                     http.Handler
+	HostClientConfig                      http.Handler
+	AggregateHosts                        http.Handler
+	NoisyHosts                            http.Handler
+	HostsByOsqueryVersion                 http.Handler
+	HostsByEncryptionStatus               http.Handler
 	ListHosts                             http.Handler
+	CountHosts                            http.Handler
+	StreamHosts                           http.Handler
 	GetHostSummary                        http.Handler
 	SearchTargets                         http.Handler
 	GetOptions                            http.Handler
@@ -285,18 +459,41 @@ type kolideHandlers struct {
 	ResetOptions                          http.Handler
 	ApplyOsqueryOptionsSpec               http.Handler
 	GetOsqueryOptionsSpec                 http.Handler
+	GenerateOsqueryFlagfile               http.Handler
 	GetCertificate                        http.Handler
 	ChangeEmail                           http.Handler
 	InitiateSSO                           http.Handler
 	CallbackSSO                           http.Handler
 	SettingsSSO                           http.Handler
+	SAMLMetadata                          http.Handler
 	ModifyFIM                             http.Handler
 	GetFIM                                http.Handler
+	ListDecorators                        http.Handler
+	NewDecorator                          http.Handler
+	ModifyDecorator                       http.Handler
+	DeleteDecorator                       http.Handler
+	ListHostDetailQueries                 http.Handler
+	NewHostDetailQuery                    http.Handler
+	DeleteHostDetailQuery                 http.Handler
+	GetHostExtraDetails                   http.Handler
+	ListATCTables                         http.Handler
+	GetATCTable                           http.Handler
+	NewATCTable                           http.Handler
+	ModifyATCTable                        http.Handler
+	DeleteATCTable                        http.Handler
+	ListActivities                        http.Handler
+	ListIngestionFailures                 http.Handler
+	CreateAPIToken                        http.Handler
+	ListAPITokens                         http.Handler
+	DeleteAPIToken                        http.Handler
 	StatusResultStore                     http.Handler
 	StatusLiveQuery                       http.Handler
 }
 
-func makeKolideKitHandlers(e KolideEndpoints, opts []kithttp.ServerOption) *kolideHandlers {
+// makeKolideKitHandlers creates the HTTP handlers for the endpoints. The
+// bodies of osquery log and distributed query result requests are limited to
+// maxBodySize bytes, or unlimited if it is zero.
+func makeKolideKitHandlers(e KolideEndpoints, opts []kithttp.ServerOption, maxBodySize int64) *kolideHandlers {
 	newServer := func(e endpoint.Endpoint, decodeFn kithttp.DecodeRequestFunc) http.Handler {
 		return kithttp.NewServer(e, decodeFn, encodeResponse, opts...)
 	}
@@ -308,6 +505,7 @@ func makeKolideKitHandlers(e KolideEndpoints, opts []kithttp.ServerOption) *koli
 		Me:                                    newServer(e.Me, decodeNoParamsRequest),
 		ChangePassword:                        newServer(e.ChangePassword, decodeChangePasswordRequest),
 		CreateUser:                            newServer(e.CreateUser, decodeCreateUserRequest),
+		CreateUsers:                           newServer(e.CreateUsers, decodeCreateUsersRequest),
 		GetUser:                               newServer(e.GetUser, decodeGetUserRequest),
 		ListUsers:                             newServer(e.ListUsers, decodeListUsersRequest),
 		ModifyUser:                            newServer(e.ModifyUser, decodeModifyUserRequest),
@@ -321,8 +519,10 @@ func makeKolideKitHandlers(e KolideEndpoints, opts []kithttp.ServerOption) *koli
 		DeleteSession:                         newServer(e.DeleteSession, decodeDeleteSessionRequest),
 		GetAppConfig:                          newServer(e.GetAppConfig, decodeNoParamsRequest),
 		ModifyAppConfig:                       newServer(e.ModifyAppConfig, decodeModifyAppConfigRequest),
+		TestSMTPSettings:                      newServer(e.TestSMTPSettings, decodeTestSMTPSettingsRequest),
 		ApplyEnrollSecretSpec:                 newServer(e.ApplyEnrollSecretSpec, decodeApplyEnrollSecretSpecRequest),
 		GetEnrollSecretSpec:                   newServer(e.GetEnrollSecretSpec, decodeNoParamsRequest),
+		EnrollSecretUsage:                     newServer(e.EnrollSecretUsage, decodeNoParamsRequest),
 		CreateInvite:                          newServer(e.CreateInvite, decodeCreateInviteRequest),
 		ListInvites:                           newServer(e.ListInvites, decodeListInvitesRequest),
 		DeleteInvite:                          newServer(e.DeleteInvite, decodeDeleteInviteRequest),
@@ -334,30 +534,47 @@ func makeKolideKitHandlers(e KolideEndpoints, opts []kithttp.ServerOption) *koli
 		DeleteQuery:                           newServer(e.DeleteQuery, decodeDeleteQueryRequest),
 		DeleteQueryByID:                       newServer(e.DeleteQueryByID, decodeDeleteQueryByIDRequest),
 		DeleteQueries:                         newServer(e.DeleteQueries, decodeDeleteQueriesRequest),
+		RestoreQuery:                          newServer(e.RestoreQuery, decodeRestoreQueryRequest),
+		ListQueryTags:                         newServer(e.ListQueryTags, decodeNoParamsRequest),
+		ValidateQuerySQL:                      newServer(e.ValidateQuerySQL, decodeValidateQuerySQLRequest),
 		ApplyQuerySpecs:                       newServer(e.ApplyQuerySpecs, decodeApplyQuerySpecsRequest),
 		GetQuerySpecs:                         newServer(e.GetQuerySpecs, decodeNoParamsRequest),
 		GetQuerySpec:                          newServer(e.GetQuerySpec, decodeGetGenericSpecRequest),
 		CreateDistributedQueryCampaign:        newServer(e.CreateDistributedQueryCampaign, decodeCreateDistributedQueryCampaignRequest),
 		CreateDistributedQueryCampaignByNames: newServer(e.CreateDistributedQueryCampaignByNames, decodeCreateDistributedQueryCampaignByNamesRequest),
+		CreateSavedQueryCampaign:              newServer(e.CreateSavedQueryCampaign, decodeCreateSavedQueryCampaignRequest),
+		CreateSavedQueryCampaignByNames:       newServer(e.CreateSavedQueryCampaignByNames, decodeCreateSavedQueryCampaignByNamesRequest),
+		DistributedQueryCampaignTargetsCount:  newServer(e.DistributedQueryCampaignTargetsCount, decodeDistributedQueryCampaignTargetsCountRequest),
+		EstimateQueryCost:                     newServer(e.EstimateQueryCost, decodeEstimateQueryCostRequest),
+		ListRunningCampaigns:                  newServer(e.ListRunningCampaigns, decodeNoParamsRequest),
+		StopCampaign:                          newServer(e.StopCampaign, decodeStopCampaignRequest),
 		CreatePack:                            newServer(e.CreatePack, decodeCreatePackRequest),
+		ClonePack:                             newServer(e.ClonePack, decodeClonePackRequest),
 		ModifyPack:                            newServer(e.ModifyPack, decodeModifyPackRequest),
 		GetPack:                               newServer(e.GetPack, decodeGetPackRequest),
 		ListPacks:                             newServer(e.ListPacks, decodeListPacksRequest),
 		DeletePack:                            newServer(e.DeletePack, decodeDeletePackRequest),
 		DeletePackByID:                        newServer(e.DeletePackByID, decodeDeletePackByIDRequest),
 		GetScheduledQueriesInPack:             newServer(e.GetScheduledQueriesInPack, decodeGetScheduledQueriesInPackRequest),
+		ListHostsMissingPack:                  newServer(e.ListHostsMissingPack, decodeListHostsMissingPackRequest),
+		GetScheduledQueryStats:                newServer(e.GetScheduledQueryStats, decodeGetScheduledQueryStatsRequest),
 		ScheduleQuery:                         newServer(e.ScheduleQuery, decodeScheduleQueryRequest),
 		GetScheduledQuery:                     newServer(e.GetScheduledQuery, decodeGetScheduledQueryRequest),
 		ModifyScheduledQuery:                  newServer(e.ModifyScheduledQuery, decodeModifyScheduledQueryRequest),
+		ModifyScheduledQueriesInPack:          newServer(e.ModifyScheduledQueriesInPack, decodeModifyScheduledQueriesInPackRequest),
 		DeleteScheduledQuery:                  newServer(e.DeleteScheduledQuery, decodeDeleteScheduledQueryRequest),
 		ApplyPackSpecs:                        newServer(e.ApplyPackSpecs, decodeApplyPackSpecsRequest),
 		GetPackSpecs:                          newServer(e.GetPackSpecs, decodeNoParamsRequest),
 		GetPackSpec:                           newServer(e.GetPackSpec, decodeGetGenericSpecRequest),
+		ExportPack:                            newServer(e.ExportPack, decodeExportPackRequest),
+		ImportPack:                            newServer(e.ImportPack, decodeImportPackRequest),
+		ImportPackFromURL:                     newServer(e.ImportPackFromURL, decodeImportPackFromURLRequest),
 		EnrollAgent:                           newServer(e.EnrollAgent, decodeEnrollAgentRequest),
 		GetClientConfig:                       newServer(e.GetClientConfig, decodeGetClientConfigRequest),
+		GetScheduledQueries:                   newServer(e.GetScheduledQueries, decodeGetScheduledQueriesRequest),
 		GetDistributedQueries:                 newServer(e.GetDistributedQueries, decodeGetDistributedQueriesRequest),
-		SubmitDistributedQueryResults:         newServer(e.SubmitDistributedQueryResults, decodeSubmitDistributedQueryResultsRequest),
-		SubmitLogs:                            newServer(e.SubmitLogs, decodeSubmitLogsRequest),
+		SubmitDistributedQueryResults:         newServer(e.SubmitDistributedQueryResults, makeDecodeSubmitDistributedQueryResultsRequest(maxBodySize)),
+		SubmitLogs:                            newServer(e.SubmitLogs, makeDecodeSubmitLogsRequest(maxBodySize)),
 		CreateLabel:                           newServer(e.CreateLabel, decodeCreateLabelRequest),
 		ModifyLabel:                           newServer(e.ModifyLabel, decodeModifyLabelRequest),
 		GetLabel:                              newServer(e.GetLabel, decodeGetLabelRequest),
@@ -367,9 +584,32 @@ func makeKolideKitHandlers(e KolideEndpoints, opts []kithttp.ServerOption) *koli
 		ApplyLabelSpecs:                       newServer(e.ApplyLabelSpecs, decodeApplyLabelSpecsRequest),
 		GetLabelSpecs:                         newServer(e.GetLabelSpecs, decodeNoParamsRequest),
 		GetLabelSpec:                          newServer(e.GetLabelSpec, decodeGetGenericSpecRequest),
+		AddHostsToLabel:                       newServer(e.AddHostsToLabel, decodeLabelMembersRequest),
+		RemoveHostsFromLabel:                  newServer(e.RemoveHostsFromLabel, decodeLabelMembersRequest),
 		GetHost:                               newServer(e.GetHost, decodeGetHostRequest),
 		DeleteHost:                            newServer(e.DeleteHost, decodeDeleteHostRequest),
+		DeleteHostsByLabel:                    newServer(e.DeleteHostsByLabel, decodeDeleteHostsByLabelRequest),
+		RefreshHostConfig:                     newServer(e.RefreshHostConfig, decodeRefreshHostConfigRequest),
+		TransferHosts:                         newServer(e.TransferHosts, decodeTransferHostsRequest),
+		RefreshHostDetails:                    newServer(e.RefreshHostDetails, decodeRefreshHostDetailsRequest),
+		SetHostMaintenance:                    newServer(e.SetHostMaintenance, decodeSetHostMaintenanceRequest),
+		ApproveHost:                           newServer(e.ApproveHost, decodeApproveHostRequest),
+		RotateHostNodeKey:                     newServer(e.RotateHostNodeKey, decodeRotateHostNodeKeyRequest),
+		MergeHosts:                            newServer(e.MergeHosts, decodeMergeHostsRequest),
+		HostScheduledQueries:                  newServer(e.HostScheduledQueries, decodeHostScheduledQueriesRequest),
+		HostPacks:                             newServer(e.HostPacks, decodeHostPacksRequest),
+		DiffHosts:                             newServer(e.DiffHosts, decodeDiffHostsRequest),
+		HostExport:                            newServer(e.HostExport, decodeHostExportRequest),
+		HostActivities:                        newServer(e.HostActivities, decodeHostActivitiesRequest),
+		HostRebootHistory:                     newServer(e.HostRebootHistory, decodeHostRebootHistoryRequest),
+		HostClientConfig:                      newServer(e.HostClientConfig, decodeHostClientConfigRequest),
+		AggregateHosts:                        newServer(e.AggregateHosts, decodeAggregateHostsRequest),
+		NoisyHosts:                            newServer(e.NoisyHosts, decodeNoParamsRequest),
+		HostsByOsqueryVersion:                 newServer(e.HostsByOsqueryVersion, decodeNoParamsRequest),
+		HostsByEncryptionStatus:               newServer(e.HostsByEncryptionStatus, decodeNoParamsRequest),
 		ListHosts:                             newServer(e.ListHosts, decodeListHostsRequest),
+		CountHosts:                            newServer(e.CountHosts, decodeCountHostsRequest),
+		StreamHosts:                           newServer(e.StreamHosts, decodeStreamHostsRequest),
 		GetHostSummary:                        newServer(e.GetHostSummary, decodeNoParamsRequest),
 		SearchTargets:                         newServer(e.SearchTargets, decodeSearchTargetsRequest),
 		GetOptions:                            newServer(e.GetOptions, decodeNoParamsRequest),
@@ -377,13 +617,33 @@ func makeKolideKitHandlers(e KolideEndpoints, opts []kithttp.ServerOption) *koli
 		ResetOptions:                          newServer(e.ResetOptions, decodeNoParamsRequest),
 		ApplyOsqueryOptionsSpec:               newServer(e.ApplyOsqueryOptionsSpec, decodeApplyOsqueryOptionsSpecRequest),
 		GetOsqueryOptionsSpec:                 newServer(e.GetOsqueryOptionsSpec, decodeNoParamsRequest),
+		GenerateOsqueryFlagfile:               newServer(e.GenerateOsqueryFlagfile, decodeGenerateOsqueryFlagfileRequest),
 		GetCertificate:                        newServer(e.GetCertificate, decodeNoParamsRequest),
 		ChangeEmail:                           newServer(e.ChangeEmail, decodeChangeEmailRequest),
 		InitiateSSO:                           newServer(e.InitiateSSO, decodeInitiateSSORequest),
 		CallbackSSO:                           newServer(e.CallbackSSO, decodeCallbackSSORequest),
 		SettingsSSO:                           newServer(e.SSOSettings, decodeNoParamsRequest),
+		SAMLMetadata:                          newServer(e.SAMLMetadata, decodeNoParamsRequest),
 		ModifyFIM:                             newServer(e.ModifyFIM, decodeModifyFIMRequest),
 		GetFIM:                                newServer(e.GetFIM, decodeNoParamsRequest),
+		ListDecorators:                        newServer(e.ListDecorators, decodeNoParamsRequest),
+		NewDecorator:                          newServer(e.NewDecorator, decodeNewDecoratorRequest),
+		ModifyDecorator:                       newServer(e.ModifyDecorator, decodeModifyDecoratorRequest),
+		DeleteDecorator:                       newServer(e.DeleteDecorator, decodeDeleteDecoratorRequest),
+		ListHostDetailQueries:                 newServer(e.ListHostDetailQueries, decodeNoParamsRequest),
+		NewHostDetailQuery:                    newServer(e.NewHostDetailQuery, decodeNewHostDetailQueryRequest),
+		DeleteHostDetailQuery:                 newServer(e.DeleteHostDetailQuery, decodeDeleteHostDetailQueryRequest),
+		GetHostExtraDetails:                   newServer(e.GetHostExtraDetails, decodeGetHostExtraDetailsRequest),
+		ListATCTables:                         newServer(e.ListATCTables, decodeNoParamsRequest),
+		GetATCTable:                           newServer(e.GetATCTable, decodeGetATCTableRequest),
+		NewATCTable:                           newServer(e.NewATCTable, decodeNewATCTableRequest),
+		ModifyATCTable:                        newServer(e.ModifyATCTable, decodeModifyATCTableRequest),
+		DeleteATCTable:                        newServer(e.DeleteATCTable, decodeDeleteATCTableRequest),
+		ListActivities:                        newServer(e.ListActivities, decodeListActivitiesRequest),
+		ListIngestionFailures:                 newServer(e.ListIngestionFailures, decodeListIngestionFailuresRequest),
+		CreateAPIToken:                        newServer(e.CreateAPIToken, decodeCreateAPITokenRequest),
+		ListAPITokens:                         newServer(e.ListAPITokens, decodeNoParamsRequest),
+		DeleteAPIToken:                        newServer(e.DeleteAPIToken, decodeDeleteAPITokenRequest),
 		StatusResultStore:                     newServer(e.StatusResultStore, decodeNoParamsRequest),
 		StatusLiveQuery:                       newServer(e.StatusLiveQuery, decodeNoParamsRequest),
 	}
@@ -391,10 +651,16 @@ func makeKolideKitHandlers(e KolideEndpoints, opts []kithttp.ServerOption) *koli
 
 // MakeHandler creates an HTTP handler for the Fleet server endpoints.
 func MakeHandler(svc kolide.Service, config config.KolideConfig, logger kitlog.Logger) http.Handler {
+	// The trusted proxies are validated by NewService
+	proxies, err := sourceip.ParseTrustedProxies(config.Server.TrustedProxies)
+	if err != nil {
+		logger.Log("err", err, "msg", "ignoring server.trusted_proxies")
+	}
 	kolideAPIOptions := []kithttp.ServerOption{
 		kithttp.ServerBefore(
 			kithttp.PopulateRequestContext, // populate the request context with common fields
 			setRequestsContexts(svc, config.Auth.JwtKey),
+			setSourceIP(proxies),
 		),
 		kithttp.ServerErrorLogger(logger),
 		kithttp.ServerErrorEncoder(encodeError),
@@ -403,18 +669,47 @@ func MakeHandler(svc kolide.Service, config config.KolideConfig, logger kitlog.L
 		),
 	}
 
-	kolideEndpoints := MakeKolideServerEndpoints(svc, config.Auth.JwtKey, config.Server.URLPrefix)
-	kolideHandlers := makeKolideKitHandlers(kolideEndpoints, kolideAPIOptions)
+	kolideEndpoints := MakeKolideServerEndpoints(svc, config.Auth.JwtKey, config.Server.URLPrefix, logger)
+	if config.Osquery.EnrollRateLimit > 0 {
+		limiter := ratelimit.NewTokenBucket(config.Osquery.EnrollRateLimit, clock.C)
+		kolideEndpoints.EnrollAgent = rateLimit(limiter, "enroll_agent")(kolideEndpoints.EnrollAgent)
+		kolideEndpoints.GetClientConfig = rateLimit(limiter, "get_client_config")(kolideEndpoints.GetClientConfig)
+	}
+	if config.Auth.LoginRateLimit > 0 {
+		limiter := ratelimit.NewBudget(config.Auth.LoginRateLimit, config.Auth.LoginRateLimitWindow, clock.C)
+		kolideEndpoints.Login = throttleBySourceIP(limiter, "login")(kolideEndpoints.Login)
+	}
+	kolideHandlers := makeKolideKitHandlers(kolideEndpoints, kolideAPIOptions, int64(config.Osquery.MaxRequestBodySize))
 
 	r := mux.NewRouter()
 	attachKolideAPIRoutes(r, kolideHandlers)
 	addMetrics(r)
 
+	r.Handle("/api/v1/kolide/queries/campaigns/{id}/results",
+		makeStreamCampaignResultsEventsHandler(svc, config.Auth.JwtKey, logger)).
+		Methods("GET").
+		Name("stream_campaign_results_events")
+
 	r.PathPrefix("/api/v1/kolide/results/").
 		Handler(makeStreamDistributedQueryCampaignResultsHandler(svc, config.Auth.JwtKey, logger)).
 		Name("distributed_query_results")
 
-	return r
+	return withRequestID(withCORS(config.Server, r))
+}
+
+// MakeHealthzHandler returns the unauthenticated handler reporting the health
+// of the server and its dependencies, for use as a readiness probe.
+func MakeHealthzHandler(svc kolide.Service, logger kitlog.Logger) http.Handler {
+	return kithttp.NewServer(
+		makeHealthzEndpoint(svc),
+		decodeNoParamsRequest,
+		encodeResponse,
+		kithttp.ServerErrorLogger(logger),
+		kithttp.ServerErrorEncoder(encodeError),
+		kithttp.ServerAfter(
+			kithttp.SetContentType("application/json; charset=utf-8"),
+		),
+	)
 }
 
 // addMetrics decorates each hander with prometheus instrumentation
@@ -438,8 +733,10 @@ func attachKolideAPIRoutes(r *mux.Router, h *kolideHandlers) {
 	r.Handle("/api/v1/kolide/sso", h.InitiateSSO).Methods("POST").Name("intiate_sso")
 	r.Handle("/api/v1/kolide/sso", h.SettingsSSO).Methods("GET").Name("sso_config")
 	r.Handle("/api/v1/kolide/sso/callback", h.CallbackSSO).Methods("POST").Name("callback_sso")
+	r.Handle("/api/v1/kolide/sso/metadata", h.SAMLMetadata).Methods("GET").Name("sso_metadata")
 	r.Handle("/api/v1/kolide/users", h.ListUsers).Methods("GET").Name("list_users")
 	r.Handle("/api/v1/kolide/users", h.CreateUser).Methods("POST").Name("create_user")
+	r.Handle("/api/v1/kolide/users/batch", h.CreateUsers).Methods("POST").Name("create_users")
 	r.Handle("/api/v1/kolide/users/{id}", h.GetUser).Methods("GET").Name("get_user")
 	r.Handle("/api/v1/kolide/users/{id}", h.ModifyUser).Methods("PATCH").Name("modify_user")
 	r.Handle("/api/v1/kolide/users/{id}/enable", h.EnableUser).Methods("POST").Name("enable_user")
@@ -454,8 +751,10 @@ func attachKolideAPIRoutes(r *mux.Router, h *kolideHandlers) {
 	r.Handle("/api/v1/kolide/config/certificate", h.GetCertificate).Methods("GET").Name("get_certificate")
 	r.Handle("/api/v1/kolide/config", h.GetAppConfig).Methods("GET").Name("get_app_config")
 	r.Handle("/api/v1/kolide/config", h.ModifyAppConfig).Methods("PATCH").Name("modify_app_config")
+	r.Handle("/api/v1/kolide/config/smtp/test", h.TestSMTPSettings).Methods("POST").Name("test_smtp_settings")
 	r.Handle("/api/v1/kolide/spec/enroll_secret", h.ApplyEnrollSecretSpec).Methods("POST").Name("apply_enroll_secret_spec")
 	r.Handle("/api/v1/kolide/spec/enroll_secret", h.GetEnrollSecretSpec).Methods("GET").Name("get_enroll_secret_spec")
+	r.Handle("/api/v1/kolide/enroll_secret/usage", h.EnrollSecretUsage).Methods("GET").Name("enroll_secret_usage")
 	r.Handle("/api/v1/kolide/invites", h.CreateInvite).Methods("POST").Name("create_invite")
 	r.Handle("/api/v1/kolide/invites", h.ListInvites).Methods("GET").Name("list_invites")
 	r.Handle("/api/v1/kolide/invites/{id}", h.DeleteInvite).Methods("DELETE").Name("delete_invite")
@@ -463,6 +762,9 @@ func attachKolideAPIRoutes(r *mux.Router, h *kolideHandlers) {
 
 	r.Handle("/api/v1/kolide/email/change/{token}", h.ChangeEmail).Methods("GET").Name("change_email")
 
+	r.Handle("/api/v1/kolide/queries/tags", h.ListQueryTags).Methods("GET").Name("list_query_tags")
+	r.Handle("/api/v1/kolide/queries/validate", h.ValidateQuerySQL).Methods("POST").Name("validate_query_sql")
+	r.Handle("/api/v1/kolide/queries/campaigns", h.ListRunningCampaigns).Methods("GET").Name("list_running_campaigns")
 	r.Handle("/api/v1/kolide/queries/{id}", h.GetQuery).Methods("GET").Name("get_query")
 	r.Handle("/api/v1/kolide/queries", h.ListQueries).Methods("GET").Name("list_queries")
 	r.Handle("/api/v1/kolide/queries", h.CreateQuery).Methods("POST").Name("create_query")
@@ -470,19 +772,32 @@ func attachKolideAPIRoutes(r *mux.Router, h *kolideHandlers) {
 	r.Handle("/api/v1/kolide/queries/{name}", h.DeleteQuery).Methods("DELETE").Name("delete_query")
 	r.Handle("/api/v1/kolide/queries/id/{id}", h.DeleteQueryByID).Methods("DELETE").Name("delete_query_by_id")
 	r.Handle("/api/v1/kolide/queries/delete", h.DeleteQueries).Methods("POST").Name("delete_queries")
+	r.Handle("/api/v1/kolide/queries/{id}/restore", h.RestoreQuery).Methods("POST").Name("restore_query")
 	r.Handle("/api/v1/kolide/spec/queries", h.ApplyQuerySpecs).Methods("POST").Name("apply_query_specs")
 	r.Handle("/api/v1/kolide/spec/queries", h.GetQuerySpecs).Methods("GET").Name("get_query_specs")
 	r.Handle("/api/v1/kolide/spec/queries/{name}", h.GetQuerySpec).Methods("GET").Name("get_query_spec")
 	r.Handle("/api/v1/kolide/queries/run", h.CreateDistributedQueryCampaign).Methods("POST").Name("create_distributed_query_campaign")
 	r.Handle("/api/v1/kolide/queries/run_by_names", h.CreateDistributedQueryCampaignByNames).Methods("POST").Name("create_distributed_query_campaign_by_names")
+	r.Handle("/api/v1/kolide/queries/run_saved_by_names", h.CreateSavedQueryCampaignByNames).Methods("POST").Name("create_saved_query_campaign_by_names")
+	r.Handle("/api/v1/kolide/queries/run/targets", h.DistributedQueryCampaignTargetsCount).Methods("POST").Name("distributed_query_campaign_targets_count")
+	r.Handle("/api/v1/kolide/queries/run/estimate", h.EstimateQueryCost).Methods("POST").Name("estimate_query_cost")
+	r.Handle("/api/v1/kolide/queries/{id}/run", h.CreateSavedQueryCampaign).Methods("POST").Name("create_saved_query_campaign")
+	r.Handle("/api/v1/kolide/queries/campaigns/{id}/stop", h.StopCampaign).Methods("POST").Name("stop_campaign")
 
 	r.Handle("/api/v1/kolide/packs", h.CreatePack).Methods("POST").Name("create_pack")
+	r.Handle("/api/v1/kolide/packs/{id}/clone", h.ClonePack).Methods("POST").Name("clone_pack")
 	r.Handle("/api/v1/kolide/packs/{id}", h.ModifyPack).Methods("PATCH").Name("modify_pack")
 	r.Handle("/api/v1/kolide/packs/{id}", h.GetPack).Methods("GET").Name("get_pack")
 	r.Handle("/api/v1/kolide/packs", h.ListPacks).Methods("GET").Name("list_packs")
 	r.Handle("/api/v1/kolide/packs/{name}", h.DeletePack).Methods("DELETE").Name("delete_pack")
 	r.Handle("/api/v1/kolide/packs/id/{id}", h.DeletePackByID).Methods("DELETE").Name("delete_pack_by_id")
 	r.Handle("/api/v1/kolide/packs/{id}/scheduled", h.GetScheduledQueriesInPack).Methods("GET").Name("get_scheduled_queries_in_pack")
+	r.Handle("/api/v1/kolide/packs/{id}/scheduled", h.ModifyScheduledQueriesInPack).Methods("PATCH").Name("modify_scheduled_queries_in_pack")
+	r.Handle("/api/v1/kolide/packs/{id}/scheduled/stats", h.GetScheduledQueryStats).Methods("GET").Name("get_scheduled_query_stats")
+	r.Handle("/api/v1/kolide/packs/{id}/export", h.ExportPack).Methods("GET").Name("export_pack")
+	r.Handle("/api/v1/kolide/packs/{id}/missing_hosts", h.ListHostsMissingPack).Methods("GET").Name("list_hosts_missing_pack")
+	r.Handle("/api/v1/kolide/packs/import", h.ImportPack).Methods("POST").Name("import_pack")
+	r.Handle("/api/v1/kolide/packs/import_url", h.ImportPackFromURL).Methods("POST").Name("import_pack_from_url")
 	r.Handle("/api/v1/kolide/schedule", h.ScheduleQuery).Methods("POST").Name("schedule_query")
 	r.Handle("/api/v1/kolide/schedule/{id}", h.GetScheduledQuery).Methods("GET").Name("get_scheduled_query")
 	r.Handle("/api/v1/kolide/schedule/{id}", h.ModifyScheduledQuery).Methods("PATCH").Name("modify_scheduled_query")
@@ -496,24 +811,71 @@ func attachKolideAPIRoutes(r *mux.Router, h *kolideHandlers) {
 	r.Handle("/api/v1/kolide/labels/{id}", h.GetLabel).Methods("GET").Name("get_label")
 	r.Handle("/api/v1/kolide/labels", h.ListLabels).Methods("GET").Name("list_labels")
 	r.Handle("/api/v1/kolide/labels/{name}", h.DeleteLabel).Methods("DELETE").Name("delete_label")
+	r.Handle("/api/v1/kolide/labels/{id}/hosts", h.DeleteHostsByLabel).Methods("DELETE").Name("delete_hosts_by_label")
 	r.Handle("/api/v1/kolide/labels/id/{id}", h.DeleteLabelByID).Methods("DELETE").Name("delete_label_by_id")
+	r.Handle("/api/v1/kolide/labels/{id}/members", h.AddHostsToLabel).Methods("POST").Name("add_hosts_to_label")
+	r.Handle("/api/v1/kolide/labels/{id}/members", h.RemoveHostsFromLabel).Methods("DELETE").Name("remove_hosts_from_label")
 	r.Handle("/api/v1/kolide/spec/labels", h.ApplyLabelSpecs).Methods("POST").Name("apply_label_specs")
 	r.Handle("/api/v1/kolide/spec/labels", h.GetLabelSpecs).Methods("GET").Name("get_label_specs")
 	r.Handle("/api/v1/kolide/spec/labels/{name}", h.GetLabelSpec).Methods("GET").Name("get_label_spec")
 
 	r.Handle("/api/v1/kolide/hosts", h.ListHosts).Methods("GET").Name("list_hosts")
+	r.Handle("/api/v1/kolide/hosts/count", h.CountHosts).Methods("GET").Name("count_hosts")
+	r.Handle("/api/v1/kolide/hosts/export", h.StreamHosts).Methods("GET").Name("stream_hosts")
 	r.Handle("/api/v1/kolide/host_summary", h.GetHostSummary).Methods("GET").Name("get_host_summary")
+	r.Handle("/api/v1/kolide/hosts/refresh_config", h.RefreshHostConfig).Methods("POST").Name("refresh_host_config")
+	r.Handle("/api/v1/kolide/hosts/transfer", h.TransferHosts).Methods("POST").Name("transfer_hosts")
+	r.Handle("/api/v1/kolide/hosts/aggregate", h.AggregateHosts).Methods("GET").Name("aggregate_hosts")
+	r.Handle("/api/v1/kolide/hosts/noisy", h.NoisyHosts).Methods("GET").Name("noisy_hosts")
+	r.Handle("/api/v1/kolide/hosts/osquery_versions", h.HostsByOsqueryVersion).Methods("GET").Name("hosts_by_osquery_version")
+	r.Handle("/api/v1/kolide/hosts/disk_encryption", h.HostsByEncryptionStatus).Methods("GET").Name("hosts_by_encryption_status")
 	r.Handle("/api/v1/kolide/hosts/{id}", h.GetHost).Methods("GET").Name("get_host")
+	r.Handle("/api/v1/kolide/hosts/{id}/scheduled_queries", h.HostScheduledQueries).Methods("GET").Name("host_scheduled_queries")
+	r.Handle("/api/v1/kolide/hosts/{id}/packs", h.HostPacks).Methods("GET").Name("host_packs")
+	r.Handle("/api/v1/kolide/hosts/{id}/reboots", h.HostRebootHistory).Methods("GET").Name("host_reboot_history")
+	r.Handle("/api/v1/kolide/hosts/{id}/config", h.HostClientConfig).Methods("GET").Name("host_client_config")
+	r.Handle("/api/v1/kolide/hosts/{id}/diff/{other_id}", h.DiffHosts).Methods("GET").Name("diff_hosts")
+	r.Handle("/api/v1/kolide/hosts/{id}/export", h.HostExport).Methods("GET").Name("host_export")
+	r.Handle("/api/v1/kolide/hosts/{id}/activities", h.HostActivities).Methods("GET").Name("host_activities")
+	r.Handle("/api/v1/kolide/hosts/{id}/extra_details", h.GetHostExtraDetails).Methods("GET").Name("get_host_extra_details")
+	r.Handle("/api/v1/kolide/hosts/{id}/refresh_details", h.RefreshHostDetails).Methods("POST").Name("refresh_host_details")
+	r.Handle("/api/v1/kolide/hosts/{id}/maintenance", h.SetHostMaintenance).Methods("POST").Name("set_host_maintenance")
+	r.Handle("/api/v1/kolide/hosts/{id}/approve", h.ApproveHost).Methods("POST").Name("approve_host")
+	r.Handle("/api/v1/kolide/hosts/{id}/rotate_node_key", h.RotateHostNodeKey).Methods("POST").Name("rotate_host_node_key")
+	r.Handle("/api/v1/kolide/hosts/{id}/merge", h.MergeHosts).Methods("POST").Name("merge_hosts")
 	r.Handle("/api/v1/kolide/hosts/{id}", h.DeleteHost).Methods("DELETE").Name("delete_host")
 
 	r.Handle("/api/v1/kolide/fim", h.GetFIM).Methods("GET").Name("get_fim")
 	r.Handle("/api/v1/kolide/fim", h.ModifyFIM).Methods("PATCH").Name("post_fim")
 
+	r.Handle("/api/v1/kolide/decorators", h.ListDecorators).Methods("GET").Name("list_decorators")
+	r.Handle("/api/v1/kolide/decorators", h.NewDecorator).Methods("POST").Name("create_decorator")
+	r.Handle("/api/v1/kolide/decorators/{id}", h.ModifyDecorator).Methods("PATCH").Name("modify_decorator")
+	r.Handle("/api/v1/kolide/decorators/{id}", h.DeleteDecorator).Methods("DELETE").Name("delete_decorator")
+
+	r.Handle("/api/v1/kolide/host_detail_queries", h.ListHostDetailQueries).Methods("GET").Name("list_host_detail_queries")
+	r.Handle("/api/v1/kolide/host_detail_queries", h.NewHostDetailQuery).Methods("POST").Name("create_host_detail_query")
+	r.Handle("/api/v1/kolide/host_detail_queries/{id}", h.DeleteHostDetailQuery).Methods("DELETE").Name("delete_host_detail_query")
+
+	r.Handle("/api/v1/kolide/atc_tables", h.ListATCTables).Methods("GET").Name("list_atc_tables")
+	r.Handle("/api/v1/kolide/atc_tables", h.NewATCTable).Methods("POST").Name("create_atc_table")
+	r.Handle("/api/v1/kolide/atc_tables/{id}", h.GetATCTable).Methods("GET").Name("get_atc_table")
+	r.Handle("/api/v1/kolide/atc_tables/{id}", h.ModifyATCTable).Methods("PATCH").Name("modify_atc_table")
+	r.Handle("/api/v1/kolide/atc_tables/{id}", h.DeleteATCTable).Methods("DELETE").Name("delete_atc_table")
+
+	r.Handle("/api/v1/kolide/activities", h.ListActivities).Methods("GET").Name("list_activities")
+	r.Handle("/api/v1/kolide/ingestion_failures", h.ListIngestionFailures).Methods("GET").Name("list_ingestion_failures")
+
+	r.Handle("/api/v1/kolide/api_tokens", h.CreateAPIToken).Methods("POST").Name("create_api_token")
+	r.Handle("/api/v1/kolide/api_tokens", h.ListAPITokens).Methods("GET").Name("list_api_tokens")
+	r.Handle("/api/v1/kolide/api_tokens/{id}", h.DeleteAPIToken).Methods("DELETE").Name("delete_api_token")
+
 	r.Handle("/api/v1/kolide/options", h.GetOptions).Methods("GET").Name("get_options")
 	r.Handle("/api/v1/kolide/options", h.ModifyOptions).Methods("PATCH").Name("modify_options")
 	r.Handle("/api/v1/kolide/options/reset", h.ResetOptions).Methods("GET").Name("reset_options")
 	r.Handle("/api/v1/kolide/spec/osquery_options", h.ApplyOsqueryOptionsSpec).Methods("POST").Name("apply_osquery_options_spec")
 	r.Handle("/api/v1/kolide/spec/osquery_options", h.GetOsqueryOptionsSpec).Methods("GET").Name("get_osquery_options_spec")
+	r.Handle("/api/v1/kolide/osquery_flagfile", h.GenerateOsqueryFlagfile).Methods("POST").Name("generate_osquery_flagfile")
 
 	r.Handle("/api/v1/kolide/targets", h.SearchTargets).Methods("POST").Name("search_targets")
 
@@ -522,6 +884,7 @@ func attachKolideAPIRoutes(r *mux.Router, h *kolideHandlers) {
 
 	r.Handle("/api/v1/osquery/enroll", h.EnrollAgent).Methods("POST").Name("enroll_agent")
 	r.Handle("/api/v1/osquery/config", h.GetClientConfig).Methods("POST").Name("get_client_config")
+	r.Handle("/api/v1/osquery/scheduled_queries", h.GetScheduledQueries).Methods("POST").Name("get_scheduled_queries")
 	r.Handle("/api/v1/osquery/distributed/read", h.GetDistributedQueries).Methods("POST").Name("get_distributed_queries")
 	r.Handle("/api/v1/osquery/distributed/write", h.SubmitDistributedQueryResults).Methods("POST").Name("submit_distributed_query_results")
 	r.Handle("/api/v1/osquery/log", h.SubmitLogs).Methods("POST").Name("submit_logs")
@@ -25,8 +25,8 @@ func TestAPIRoutes(t *testing.T) {
 	assert.Nil(t, err)
 
 	r := mux.NewRouter()
-	ke := MakeKolideServerEndpoints(svc, "CHANGEME", "")
-	kh := makeKolideKitHandlers(ke, nil)
+	ke := MakeKolideServerEndpoints(svc, "CHANGEME", "", log.NewNopLogger())
+	kh := makeKolideKitHandlers(ke, nil, 0)
 	attachKolideAPIRoutes(r, kh)
 	handler := mux.NewRouter()
 	handler.PathPrefix("/").Handler(r)
@@ -39,6 +39,10 @@ func TestAPIRoutes(t *testing.T) {
 			verb: "POST",
 			uri:  "/api/v1/kolide/users",
 		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/users/batch",
+		},
 		{
 			verb: "GET",
 			uri:  "/api/v1/kolide/users",
@@ -75,6 +79,10 @@ func TestAPIRoutes(t *testing.T) {
 			verb: "PATCH",
 			uri:  "/api/v1/kolide/config",
 		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/config/smtp/test",
+		},
 		{
 			verb: "GET",
 			uri:  "/api/v1/kolide/invites",
@@ -91,6 +99,14 @@ func TestAPIRoutes(t *testing.T) {
 			verb: "GET",
 			uri:  "/api/v1/kolide/queries/1",
 		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/queries/tags",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/queries/validate",
+		},
 		{
 			verb: "GET",
 			uri:  "/api/v1/kolide/queries",
@@ -111,10 +127,50 @@ func TestAPIRoutes(t *testing.T) {
 			verb: "POST",
 			uri:  "/api/v1/kolide/queries/delete",
 		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/queries/1/restore",
+		},
 		{
 			verb: "POST",
 			uri:  "/api/v1/kolide/queries/run",
 		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/sso/metadata",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/enroll_secret/usage",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/packs/1/export",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/packs/1/missing_hosts",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/packs/import",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/packs/import_url",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/packs/1/clone",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/queries/1/run",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/queries/run_saved_by_names",
+		},
 		{
 			verb: "GET",
 			uri:  "/api/v1/kolide/packs/1",
@@ -139,6 +195,14 @@ func TestAPIRoutes(t *testing.T) {
 			verb: "GET",
 			uri:  "/api/v1/kolide/packs/1/scheduled",
 		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/packs/1/scheduled/stats",
+		},
+		{
+			verb: "PATCH",
+			uri:  "/api/v1/kolide/packs/1/scheduled",
+		},
 		{
 			verb: "POST",
 			uri:  "/api/v1/kolide/schedule",
@@ -158,6 +222,10 @@ func TestAPIRoutes(t *testing.T) {
 			verb: "POST",
 			uri:  "/api/v1/osquery/config",
 		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/osquery/scheduled_queries",
+		},
 		{
 			verb: "POST",
 			uri:  "/api/v1/osquery/distributed/read",
@@ -190,6 +258,178 @@ func TestAPIRoutes(t *testing.T) {
 			verb: "GET",
 			uri:  "/api/v1/kolide/hosts/1",
 		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/hosts/1/scheduled_queries",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/hosts/1/packs",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/hosts/1/diff/2",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/hosts/1/export",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/hosts/1/activities",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/hosts/1/reboots",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/hosts/1/config",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/hosts/1/refresh_details",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/hosts/1/maintenance",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/hosts/1/approve",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/hosts/1/rotate_node_key",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/hosts/1/merge",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/hosts/aggregate?group_by=platform",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/hosts/noisy",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/hosts/osquery_versions",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/hosts/disk_encryption",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/hosts/refresh_config",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/hosts/transfer",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/hosts/count",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/hosts/export",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/activities",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/ingestion_failures",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/api_tokens",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/api_tokens",
+		},
+		{
+			verb: "DELETE",
+			uri:  "/api/v1/kolide/api_tokens/1",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/queries/run/targets",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/queries/run/estimate",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/queries/campaigns",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/queries/campaigns/1/stop",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/osquery_flagfile",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/decorators",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/decorators",
+		},
+		{
+			verb: "PATCH",
+			uri:  "/api/v1/kolide/decorators/1",
+		},
+		{
+			verb: "DELETE",
+			uri:  "/api/v1/kolide/decorators/1",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/host_detail_queries",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/host_detail_queries",
+		},
+		{
+			verb: "DELETE",
+			uri:  "/api/v1/kolide/host_detail_queries/1",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/atc_tables",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/atc_tables",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/atc_tables/1",
+		},
+		{
+			verb: "PATCH",
+			uri:  "/api/v1/kolide/atc_tables/1",
+		},
+		{
+			verb: "DELETE",
+			uri:  "/api/v1/kolide/atc_tables/1",
+		},
+		{
+			verb: "GET",
+			uri:  "/api/v1/kolide/hosts/1/extra_details",
+		},
 		{
 			verb: "GET",
 			uri:  "/api/v1/kolide/hosts",
@@ -202,6 +442,18 @@ func TestAPIRoutes(t *testing.T) {
 			verb: "GET",
 			uri:  "/api/v1/kolide/host_summary",
 		},
+		{
+			verb: "DELETE",
+			uri:  "/api/v1/kolide/labels/1/hosts",
+		},
+		{
+			verb: "POST",
+			uri:  "/api/v1/kolide/labels/1/members",
+		},
+		{
+			verb: "DELETE",
+			uri:  "/api/v1/kolide/labels/1/members",
+		},
 	}
 
 	for _, route := range routes {
@@ -231,6 +483,7 @@ func TestModifyUserPermissions(t *testing.T) {
 	ms.MarkSessionAccessedFunc = func(session *kolide.Session) error {
 		return nil
 	}
+	ms.AppConfigFunc = mock.ReturnFakeAppConfig(&kolide.AppConfig{})
 	ms.UserByIDFunc = func(id uint) (*kolide.User, error) {
 		return &kolide.User{ID: id, Enabled: enabled, Admin: admin}, nil
 	}
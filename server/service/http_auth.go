@@ -6,6 +6,9 @@ import (
 	"strings"
 
 	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/kolide/fleet/server/contexts/clientcert"
+	"github.com/kolide/fleet/server/contexts/idempotency"
+	"github.com/kolide/fleet/server/contexts/sourceip"
 	"github.com/kolide/fleet/server/contexts/token"
 	"github.com/kolide/fleet/server/contexts/viewer"
 	"github.com/kolide/fleet/server/kolide"
@@ -21,6 +24,12 @@ func setRequestsContexts(svc kolide.Service, jwtKey string) kithttp.RequestFunc
 			ctx = viewer.NewContext(ctx, *v)
 		}
 
+		ctx = idempotency.NewContext(ctx, idempotency.FromHTTPRequest(r))
+
+		if cert, ok := clientcert.FromHTTPRequest(r); ok {
+			ctx = clientcert.NewContext(ctx, cert)
+		}
+
 		// get the user-id for request
 		if strings.Contains(r.URL.Path, "users/") {
 			ctx = withUserIDFromRequest(r, ctx)
@@ -29,6 +38,15 @@ func setRequestsContexts(svc kolide.Service, jwtKey string) kithttp.RequestFunc
 	}
 }
 
+// setSourceIP sets the address of the client that made the request in the
+// context, using the X-Forwarded-For header of requests from the trusted
+// proxies.
+func setSourceIP(proxies sourceip.TrustedProxies) kithttp.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		return sourceip.NewContext(ctx, sourceip.FromHTTPRequest(r, proxies))
+	}
+}
+
 func withUserIDFromRequest(r *http.Request, ctx context.Context) context.Context {
 	id, _ := idFromRequest(r, "id")
 	return context.WithValue(ctx, "request-id", id)
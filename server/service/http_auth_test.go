@@ -25,6 +25,7 @@ import (
 func TestLogin(t *testing.T) {
 	ds, _ := inmem.New(config.TestConfig())
 	svc, _ := newTestService(ds, nil)
+	createTestAppConfig(t, ds)
 	users := createTestUsers(t, ds)
 	logger := kitlog.NewLogfmtLogger(os.Stdout)
 
@@ -38,8 +39,8 @@ func TestLogin(t *testing.T) {
 		),
 	}
 	r := mux.NewRouter()
-	ke := MakeKolideServerEndpoints(svc, "CHANGEME", "")
-	kh := makeKolideKitHandlers(ke, opts)
+	ke := MakeKolideServerEndpoints(svc, "CHANGEME", "", logger)
+	kh := makeKolideKitHandlers(ke, opts, 0)
 	attachKolideAPIRoutes(r, kh)
 	r.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprint(w, "index")
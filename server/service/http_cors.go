@@ -0,0 +1,68 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/kolide/fleet/server/config"
+)
+
+// withCORS wraps the API handler to allow cross-origin requests from the
+// origins configured in conf. Requests from other origins are passed through
+// without CORS headers, so that browsers only allow same-origin requests.
+// Preflight requests from allowed origins are answered without being passed
+// on to next.
+func withCORS(conf config.ServerConfig, next http.Handler) http.Handler {
+	origins := splitConfigList(conf.CORSAllowedOrigins)
+	if len(origins) == 0 {
+		return next
+	}
+	allowAny := false
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		if origin == "*" {
+			allowAny = true
+		}
+		allowed[strings.ToLower(origin)] = true
+	}
+	methods := strings.ToUpper(strings.Join(splitConfigList(conf.CORSAllowedMethods), ", "))
+	headers := strings.Join(splitConfigList(conf.CORSAllowedHeaders), ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+		if origin == "" || !(allowAny || allowed[strings.ToLower(origin)]) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+
+		// Preflight requests are OPTIONS requests naming the method of
+		// the actual request
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			if headers != "" {
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// splitConfigList splits a comma-separated config value, ignoring empty
+// items.
+func splitConfigList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kolide/fleet/server/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	request := func(h http.Handler, method, origin string, preflight bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/kolide/me", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", "PATCH")
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// No cross-origin requests are allowed by default
+	h := withCORS(config.ServerConfig{
+		CORSAllowedMethods: "GET,POST",
+		CORSAllowedHeaders: "Authorization",
+	}, next)
+	rec := request(h, "OPTIONS", "https://admin.example.com", true)
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+	h = withCORS(config.ServerConfig{
+		CORSAllowedOrigins: "https://admin.example.com, https://other.example.com",
+		CORSAllowedMethods: "get,PATCH",
+		CORSAllowedHeaders: "Authorization, Content-Type",
+	}, next)
+
+	rec = request(h, "OPTIONS", "https://admin.example.com", true)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://admin.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, PATCH", rec.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Authorization, Content-Type", rec.Header().Get("Access-Control-Allow-Headers"))
+	assert.Contains(t, rec.Header()["Vary"], "Origin")
+
+	rec = request(h, "GET", "https://admin.example.com", false)
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+	assert.Equal(t, "https://admin.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Methods"))
+
+	// Other origins and same-origin requests receive no CORS headers
+	rec = request(h, "OPTIONS", "https://evil.example.com", true)
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	rec = request(h, "GET", "", false)
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+	// Any origin may be allowed
+	h = withCORS(config.ServerConfig{CORSAllowedOrigins: "*", CORSAllowedMethods: "GET"}, next)
+	rec = request(h, "OPTIONS", "https://evil.example.com", true)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://evil.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Headers"))
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/kolide/fleet/server/contexts/requestid"
+)
+
+// requestIDHeader holds the ID of a request, both when set by the client or
+// a proxy in front of Fleet, and in the response.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the length of request IDs accepted from clients,
+// as they are included in every log line for the request.
+const maxRequestIDLength = 128
+
+// withRequestID wraps the API handler to attach an ID to every request, so
+// that the log lines for a request can be correlated. The ID is read from
+// the request's X-Request-ID header if it is valid, and is otherwise
+// generated. It is echoed in the response's X-Request-ID header.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			var err error
+			if id, err = generateRequestID(); err != nil {
+				// Serve the request without an ID rather than
+				// failing it
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
+
+// validRequestID returns true if id is non-empty, no longer than
+// maxRequestIDLength, and made only of letters, digits, '-', '_' and '.'.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func generateRequestID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	kitlog "github.com/go-kit/kit/log"
+	hostctx "github.com/kolide/fleet/server/contexts/host"
+	"github.com/kolide/fleet/server/contexts/requestid"
+	"github.com/kolide/fleet/server/contexts/viewer"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	var gotID string
+	h := withRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID, _ = requestid.FromContext(r.Context())
+	}))
+	request := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/kolide/me", nil)
+		if id != "" {
+			req.Header.Set(requestIDHeader, id)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The ID of the client is used
+	rec := request("abc-123")
+	assert.Equal(t, "abc-123", gotID)
+	assert.Equal(t, "abc-123", rec.Header().Get(requestIDHeader))
+
+	// Missing and invalid IDs are replaced by generated IDs
+	for _, id := range []string{"", "bad id\n", strings.Repeat("a", maxRequestIDLength+1)} {
+		rec = request(id)
+		assert.Len(t, gotID, 32)
+		assert.NotEqual(t, id, gotID)
+		assert.Equal(t, gotID, rec.Header().Get(requestIDHeader))
+	}
+	first := gotID
+	request("")
+	assert.NotEqual(t, first, gotID)
+}
+
+func TestContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := kitlog.NewLogfmtLogger(&buf)
+
+	ctx := context.Background()
+	require.Nil(t, contextLogger(ctx, logger).Log("msg", "none"))
+	assert.Equal(t, "msg=none\n", buf.String())
+
+	buf.Reset()
+	ctx = requestid.NewContext(ctx, "abc")
+	ctx = hostctx.NewContext(ctx, kolide.Host{ID: 7})
+	ctx = viewer.NewContext(ctx, viewer.Viewer{User: &kolide.User{ID: 3}})
+	require.Nil(t, contextLogger(ctx, logger).Log("msg", "all"))
+	assert.Equal(t, "request_id=abc host_id=7 user_id=3 msg=all\n", buf.String())
+}
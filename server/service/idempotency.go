@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kolide/fleet/server/contexts/idempotency"
+	"github.com/kolide/fleet/server/contexts/viewer"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
+)
+
+// idempotencyMiddleware returns the resource created by an earlier request
+// when a create request is retried with the same idempotency key, rather
+// than creating a duplicate. It wraps validationMiddleware so that retries
+// are not rejected by checks that only pass once, such as an invite token
+// being unused.
+type idempotencyMiddleware struct {
+	kolide.Service
+	ds     kolide.Datastore
+	cache  kolide.IdempotencyCache
+	window time.Duration
+}
+
+// reserve claims the idempotency key of the request in ctx for a request to
+// create a resource of kind with payload. If an earlier request with the same
+// key created a resource, its ID is returned and found is true. The returned
+// cache key is empty if the request is not deduplicated.
+//
+// Keys are scoped so that clients cannot retrieve resources created by others
+// by guessing their keys. Requests without a scope are not deduplicated.
+func (mw idempotencyMiddleware) reserve(ctx context.Context, kind, scope string, payload interface{}) (cacheKey string, id uint, found bool, err error) {
+	key, ok := idempotency.FromContext(ctx)
+	if !ok || scope == "" {
+		return "", 0, false, nil
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", 0, false, errors.Wrap(err, "marshal idempotent payload")
+	}
+	sum := sha256.Sum256(b)
+	hash := hex.EncodeToString(sum[:])
+	cacheKey = fmt.Sprintf("%s:%s:%s", kind, scope, key)
+
+	entry, reserved, err := mw.cache.Reserve(cacheKey, hash, mw.window)
+	if err != nil {
+		return "", 0, false, errors.Wrap(err, "reserve idempotency key")
+	}
+	switch {
+	case reserved:
+		return cacheKey, 0, false, nil
+	case entry.Hash != hash:
+		return "", 0, false, newInvalidArgumentError("Idempotency-Key", "was already used for a different request")
+	case entry.Pending:
+		return "", 0, false, newInvalidArgumentError("Idempotency-Key", "is in use by a request that has not completed")
+	}
+	return "", entry.ID, true, nil
+}
+
+// complete records id as the resource created for cacheKey, or releases
+// cacheKey if the request failed so that it may be retried.
+func (mw idempotencyMiddleware) complete(cacheKey string, id uint, err error) error {
+	if cacheKey == "" {
+		return err
+	}
+	if err != nil {
+		mw.cache.Release(cacheKey)
+		return err
+	}
+	return errors.Wrap(mw.cache.Set(cacheKey, id, mw.window), "set idempotency key")
+}
+
+// viewerScope scopes idempotency keys to the logged in user.
+func viewerScope(ctx context.Context) string {
+	vc, ok := viewer.FromContext(ctx)
+	if !ok || vc.UserID() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("user:%d", vc.UserID())
+}
+
+func (mw idempotencyMiddleware) NewPack(ctx context.Context, p kolide.PackPayload) (*kolide.Pack, error) {
+	cacheKey, id, found, err := mw.reserve(ctx, "pack", viewerScope(ctx), p)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return mw.ds.Pack(id)
+	}
+	pack, err := mw.Service.NewPack(ctx, p)
+	if err != nil {
+		return nil, mw.complete(cacheKey, 0, err)
+	}
+	return pack, mw.complete(cacheKey, pack.ID, nil)
+}
+
+func (mw idempotencyMiddleware) NewQuery(ctx context.Context, p kolide.QueryPayload) (*kolide.Query, error) {
+	cacheKey, id, found, err := mw.reserve(ctx, "query", viewerScope(ctx), p)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return mw.ds.Query(id)
+	}
+	query, err := mw.Service.NewQuery(ctx, p)
+	if err != nil {
+		return nil, mw.complete(cacheKey, 0, err)
+	}
+	return query, mw.complete(cacheKey, query.ID, nil)
+}
+
+func (mw idempotencyMiddleware) NewUser(ctx context.Context, p kolide.UserPayload) (*kolide.User, error) {
+	// Users signing up with an invite are not logged in, so their keys are
+	// scoped to the invite instead
+	scope := viewerScope(ctx)
+	if p.InviteToken != nil && *p.InviteToken != "" {
+		token := sha256.Sum256([]byte(*p.InviteToken))
+		scope = "invite:" + hex.EncodeToString(token[:])
+	}
+	cacheKey, id, found, err := mw.reserve(ctx, "user", scope, p)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return mw.ds.UserByID(id)
+	}
+	user, err := mw.Service.NewUser(ctx, p)
+	if err != nil {
+		return nil, mw.complete(cacheKey, 0, err)
+	}
+	return user, mw.complete(cacheKey, user.ID, nil)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/WatchBeam/clock"
+	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/contexts/idempotency"
+	"github.com/kolide/fleet/server/contexts/viewer"
+	"github.com/kolide/fleet/server/datastore/inmem"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotentNewPack(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+	mockClock := clock.NewMockClock()
+	svc, err := newTestServiceWithClock(ds, nil, mockClock)
+	require.Nil(t, err)
+
+	ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: &kolide.User{ID: 1}})
+	keyed := idempotency.NewContext(ctx, "abc")
+
+	pack, err := svc.NewPack(keyed, kolide.PackPayload{Name: stringPtr("foo")})
+	require.Nil(t, err)
+
+	// A retry with the same key returns the original pack
+	retry, err := svc.NewPack(keyed, kolide.PackPayload{Name: stringPtr("foo")})
+	require.Nil(t, err)
+	assert.Equal(t, pack.ID, retry.ID)
+	packs, err := ds.ListPacks(kolide.ListOptions{})
+	require.Nil(t, err)
+	assert.Len(t, packs, 1)
+
+	// Reusing the key for a different request is rejected
+	_, err = svc.NewPack(keyed, kolide.PackPayload{Name: stringPtr("different")})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Idempotency-Key was already used for a different request")
+
+	// Keys are scoped to the user
+	other := viewer.NewContext(context.Background(), viewer.Viewer{User: &kolide.User{ID: 2}})
+	otherPack, err := svc.NewPack(idempotency.NewContext(other, "abc"), kolide.PackPayload{Name: stringPtr("bar")})
+	require.Nil(t, err)
+	assert.NotEqual(t, pack.ID, otherPack.ID)
+
+	// Requests without a key are not deduplicated
+	unkeyed, err := svc.NewPack(ctx, kolide.PackPayload{Name: stringPtr("baz")})
+	require.Nil(t, err)
+	assert.NotEqual(t, pack.ID, unkeyed.ID)
+
+	// Keys expire after the configured window
+	mockClock.AddTime(config.TestConfig().App.IdempotencyWindow + time.Second)
+	expired, err := svc.NewPack(keyed, kolide.PackPayload{Name: stringPtr("qux")})
+	require.Nil(t, err)
+	assert.NotEqual(t, pack.ID, expired.ID)
+}
+
+func TestIdempotentNewUser(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+	invites := setupInvites(t, ds, []string{"admin2@example.com", "admin3@example.com"})
+
+	ctx := idempotency.NewContext(context.Background(), "abc")
+	payload := kolide.UserPayload{
+		Username:    stringPtr("admin2"),
+		Password:    stringPtr("foobarbaz1234!"),
+		Email:       stringPtr("admin2@example.com"),
+		InviteToken: &invites["admin2@example.com"].Token,
+	}
+	user, err := svc.NewUser(ctx, payload)
+	require.Nil(t, err)
+
+	// The invite has been used, but the retry returns the original user
+	retry, err := svc.NewUser(ctx, payload)
+	require.Nil(t, err)
+	assert.Equal(t, user.ID, retry.ID)
+
+	_, err = svc.NewUser(context.Background(), payload)
+	assert.NotNil(t, err)
+
+	// Keys are scoped to the invite, so another invitee may use the same key
+	other, err := svc.NewUser(ctx, kolide.UserPayload{
+		Username:    stringPtr("admin3"),
+		Password:    stringPtr("foobarbaz1234!"),
+		Email:       stringPtr("admin3@example.com"),
+		InviteToken: &invites["admin3@example.com"].Token,
+	})
+	require.Nil(t, err)
+	assert.NotEqual(t, user.ID, other.ID)
+}
+
+func TestIdempotentFailedRequest(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: &kolide.User{ID: 1}})
+	keyed := idempotency.NewContext(ctx, "abc")
+
+	existing, err := svc.NewPack(ctx, kolide.PackPayload{Name: stringPtr("foo")})
+	require.Nil(t, err)
+
+	// The key of a failed request is released, so the request can be retried
+	_, err = svc.NewPack(keyed, kolide.PackPayload{Name: stringPtr("foo")})
+	require.NotNil(t, err)
+	existing.Name = "bar"
+	require.Nil(t, ds.SavePack(existing))
+	pack, err := svc.NewPack(keyed, kolide.PackPayload{Name: stringPtr("foo")})
+	require.Nil(t, err)
+	assert.Equal(t, "foo", pack.Name)
+}
@@ -1,8 +1,13 @@
 package service
 
 import (
+	"context"
+
 	kitlog "github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	hostctx "github.com/kolide/fleet/server/contexts/host"
+	"github.com/kolide/fleet/server/contexts/requestid"
+	"github.com/kolide/fleet/server/contexts/viewer"
 	"github.com/kolide/fleet/server/kolide"
 )
 
@@ -18,11 +23,30 @@ func NewLoggingService(svc kolide.Service, logger kitlog.Logger) kolide.Service
 }
 
 // loggerDebug returns the debug level
-func (mw loggingMiddleware) loggerDebug(err error) kitlog.Logger {
-	return level.Debug(mw.logger)
+func (mw loggingMiddleware) loggerDebug(ctx context.Context, err error) kitlog.Logger {
+	return level.Debug(contextLogger(ctx, mw.logger))
 }
 
 // loggerInfo returns the info level
-func (mw loggingMiddleware) loggerInfo(err error) kitlog.Logger {
-	return level.Info(mw.logger)
+func (mw loggingMiddleware) loggerInfo(ctx context.Context, err error) kitlog.Logger {
+	return level.Info(contextLogger(ctx, mw.logger))
+}
+
+// contextLogger returns logger with the request ID, host ID and user ID from
+// ctx added to every log line, when they are present.
+func contextLogger(ctx context.Context, logger kitlog.Logger) kitlog.Logger {
+	var keyvals []interface{}
+	if id, ok := requestid.FromContext(ctx); ok {
+		keyvals = append(keyvals, "request_id", id)
+	}
+	if host, ok := hostctx.FromContext(ctx); ok {
+		keyvals = append(keyvals, "host_id", host.ID)
+	}
+	if vc, ok := viewer.FromContext(ctx); ok && vc.User != nil {
+		keyvals = append(keyvals, "user_id", vc.UserID())
+	}
+	if len(keyvals) == 0 {
+		return logger
+	}
+	return kitlog.With(logger, keyvals...)
 }
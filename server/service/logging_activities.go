@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kolide/fleet/server/contexts/viewer"
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw loggingMiddleware) ListActivities(ctx context.Context, opt kolide.ListOptions) (activities []*kolide.Activity, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "ListActivities",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	activities, err = mw.Service.ListActivities(ctx, opt)
+	return activities, err
+}
+
+func (mw loggingMiddleware) RecordActivity(ctx context.Context, activity *kolide.Activity) (err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "RecordActivity",
+			"action", activity.Action,
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	err = mw.Service.RecordActivity(ctx, activity)
+	return err
+}
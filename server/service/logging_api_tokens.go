@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kolide/fleet/server/contexts/viewer"
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw loggingMiddleware) CreateAPIToken(ctx context.Context, name string, scopes []string) (token *kolide.APIToken, bearer string, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "CreateAPIToken",
+			"name", name,
+			"scopes", len(scopes),
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	token, bearer, err = mw.Service.CreateAPIToken(ctx, name, scopes)
+	return token, bearer, err
+}
+
+func (mw loggingMiddleware) ListAPITokens(ctx context.Context) (tokens []*kolide.APIToken, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "ListAPITokens",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	tokens, err = mw.Service.ListAPITokens(ctx)
+	return tokens, err
+}
+
+func (mw loggingMiddleware) DeleteAPIToken(ctx context.Context, id uint) (err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "DeleteAPIToken",
+			"id", id,
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	err = mw.Service.DeleteAPIToken(ctx, id)
+	return err
+}
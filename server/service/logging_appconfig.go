@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/kolide/fleet/server/contexts/viewer"
 	"github.com/kolide/fleet/server/kolide"
 )
 
@@ -14,7 +15,7 @@ func (mw loggingMiddleware) NewAppConfig(ctx context.Context, p kolide.AppConfig
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "NewAppConfig",
 			"err", err,
 			"took", time.Since(begin),
@@ -32,7 +33,7 @@ func (mw loggingMiddleware) AppConfig(ctx context.Context) (*kolide.AppConfig, e
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "AppConfig",
 			"err", err,
 			"took", time.Since(begin),
@@ -43,6 +44,30 @@ func (mw loggingMiddleware) AppConfig(ctx context.Context) (*kolide.AppConfig, e
 	return info, err
 }
 
+func (mw loggingMiddleware) TestSMTPSettings(ctx context.Context, settings kolide.SMTPSettingsPayload, sendTestEmail bool) error {
+	var (
+		loggedInUser = "unauthenticated"
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "TestSMTPSettings",
+			"err", err,
+			"user", loggedInUser,
+			"send_test_email", sendTestEmail,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	err = mw.Service.TestSMTPSettings(ctx, settings, sendTestEmail)
+	return err
+}
+
 func (mw loggingMiddleware) ModifyAppConfig(ctx context.Context, p kolide.AppConfigPayload) (*kolide.AppConfig, error) {
 	var (
 		info *kolide.AppConfig
@@ -50,7 +75,7 @@ func (mw loggingMiddleware) ModifyAppConfig(ctx context.Context, p kolide.AppCon
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "ModifyAppConfig",
 			"err", err,
 			"took", time.Since(begin),
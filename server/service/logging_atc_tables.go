@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kolide/fleet/server/contexts/viewer"
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw loggingMiddleware) ListATCTables(ctx context.Context) (tables []*kolide.ATCTable, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "ListATCTables",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	tables, err = mw.Service.ListATCTables(ctx)
+	return tables, err
+}
+
+func (mw loggingMiddleware) GetATCTable(ctx context.Context, id uint) (table *kolide.ATCTable, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "GetATCTable",
+			"id", id,
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	table, err = mw.Service.GetATCTable(ctx, id)
+	return table, err
+}
+
+func (mw loggingMiddleware) NewATCTable(ctx context.Context, payload kolide.ATCTablePayload) (table *kolide.ATCTable, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "NewATCTable",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	table, err = mw.Service.NewATCTable(ctx, payload)
+	return table, err
+}
+
+func (mw loggingMiddleware) ModifyATCTable(ctx context.Context, id uint, payload kolide.ATCTablePayload) (table *kolide.ATCTable, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "ModifyATCTable",
+			"id", id,
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	table, err = mw.Service.ModifyATCTable(ctx, id, payload)
+	return table, err
+}
+
+func (mw loggingMiddleware) DeleteATCTable(ctx context.Context, id uint) (err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "DeleteATCTable",
+			"id", id,
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	err = mw.Service.DeleteATCTable(ctx, id)
+	return err
+}
@@ -6,10 +6,9 @@ import (
 
 	"github.com/kolide/fleet/server/contexts/viewer"
 	"github.com/kolide/fleet/server/kolide"
-	"github.com/kolide/fleet/server/websocket"
 )
 
-func (mw loggingMiddleware) NewDistributedQueryCampaign(ctx context.Context, queryString string, hosts []uint, labels []uint) (*kolide.DistributedQueryCampaign, error) {
+func (mw loggingMiddleware) NewDistributedQueryCampaign(ctx context.Context, queryString string, hosts []uint, labels []uint, executionTimeout uint, allowResubmission bool) (*kolide.DistributedQueryCampaign, error) {
 	var (
 		loggedInUser = "unauthenticated"
 		campaign     *kolide.DistributedQueryCampaign
@@ -24,7 +23,7 @@ func (mw loggingMiddleware) NewDistributedQueryCampaign(ctx context.Context, que
 		if campaign != nil {
 			numHosts = campaign.Metrics.TotalHosts
 		}
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "NewDistributedQueryCampaign",
 			"err", err,
 			"user", loggedInUser,
@@ -33,11 +32,88 @@ func (mw loggingMiddleware) NewDistributedQueryCampaign(ctx context.Context, que
 			"took", time.Since(begin),
 		)
 	}(time.Now())
-	campaign, err = mw.Service.NewDistributedQueryCampaign(ctx, queryString, hosts, labels)
+	campaign, err = mw.Service.NewDistributedQueryCampaign(ctx, queryString, hosts, labels, executionTimeout, allowResubmission)
 	return campaign, err
 }
 
-func (mw loggingMiddleware) NewDistributedQueryCampaignByNames(ctx context.Context, queryString string, hosts []string, labels []string) (*kolide.DistributedQueryCampaign, error) {
+func (mw loggingMiddleware) DistributedQueryCampaignTargetsCount(ctx context.Context, hosts []uint, labels []uint) (int, []uint, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		count        int
+		hostIDs      []uint
+		err          error
+	)
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "DistributedQueryCampaignTargetsCount",
+			"err", err,
+			"user", loggedInUser,
+			"numHosts", count,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	count, hostIDs, err = mw.Service.DistributedQueryCampaignTargetsCount(ctx, hosts, labels)
+	return count, hostIDs, err
+}
+
+func (mw loggingMiddleware) EstimateQueryCost(ctx context.Context, sql string, sampleHostIDs []uint) (kolide.CostEstimate, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		estimate     kolide.CostEstimate
+		err          error
+	)
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "EstimateQueryCost",
+			"err", err,
+			"user", loggedInUser,
+			"sql", sql,
+			"numHosts", len(sampleHostIDs),
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	estimate, err = mw.Service.EstimateQueryCost(ctx, sql, sampleHostIDs)
+	return estimate, err
+}
+
+func (mw loggingMiddleware) NewSavedQueryCampaign(ctx context.Context, queryID uint, hosts []uint, labels []uint, executionTimeout uint, allowResubmission bool) (*kolide.DistributedQueryCampaign, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		campaign     *kolide.DistributedQueryCampaign
+		err          error
+	)
+	if vc, ok := viewer.FromContext(ctx); ok {
+
+		loggedInUser = vc.Username()
+	}
+	defer func(begin time.Time) {
+		var numHosts uint = 0
+		cached := false
+		if campaign != nil {
+			numHosts = campaign.Metrics.TotalHosts
+			cached = campaign.CachedResults != nil
+		}
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "NewSavedQueryCampaign",
+			"err", err,
+			"user", loggedInUser,
+			"queryID", queryID,
+			"numHosts", numHosts,
+			"cached", cached,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	campaign, err = mw.Service.NewSavedQueryCampaign(ctx, queryID, hosts, labels, executionTimeout, allowResubmission)
+	return campaign, err
+}
+
+func (mw loggingMiddleware) NewSavedQueryCampaignByNames(ctx context.Context, queryName string, hosts []string, labels []string, executionTimeout uint, allowResubmission bool) (*kolide.DistributedQueryCampaign, error) {
 	var (
 		loggedInUser = "unauthenticated"
 		campaign     *kolide.DistributedQueryCampaign
@@ -49,10 +125,41 @@ func (mw loggingMiddleware) NewDistributedQueryCampaignByNames(ctx context.Conte
 	}
 	defer func(begin time.Time) {
 		var numHosts uint = 0
+		cached := false
 		if campaign != nil {
 			numHosts = campaign.Metrics.TotalHosts
+			cached = campaign.CachedResults != nil
 		}
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "NewSavedQueryCampaignByNames",
+			"err", err,
+			"user", loggedInUser,
+			"queryName", queryName,
+			"numHosts", numHosts,
+			"cached", cached,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	campaign, err = mw.Service.NewSavedQueryCampaignByNames(ctx, queryName, hosts, labels, executionTimeout, allowResubmission)
+	return campaign, err
+}
+
+func (mw loggingMiddleware) NewDistributedQueryCampaignByNames(ctx context.Context, queryString string, hosts []string, labels []string, executionTimeout uint, allowResubmission bool) (*kolide.DistributedQueryCampaign, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		campaign     *kolide.DistributedQueryCampaign
+		err          error
+	)
+	if vc, ok := viewer.FromContext(ctx); ok {
+
+		loggedInUser = vc.Username()
+	}
+	defer func(begin time.Time) {
+		var numHosts uint = 0
+		if campaign != nil {
+			numHosts = campaign.Metrics.TotalHosts
+		}
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "NewDistributedQueryCampaignByNames",
 			"err", err,
 			"user", loggedInUser,
@@ -60,11 +167,24 @@ func (mw loggingMiddleware) NewDistributedQueryCampaignByNames(ctx context.Conte
 			"took", time.Since(begin),
 		)
 	}(time.Now())
-	campaign, err = mw.Service.NewDistributedQueryCampaignByNames(ctx, queryString, hosts, labels)
+	campaign, err = mw.Service.NewDistributedQueryCampaignByNames(ctx, queryString, hosts, labels, executionTimeout, allowResubmission)
 	return campaign, err
 }
 
-func (mw loggingMiddleware) StreamCampaignResults(ctx context.Context, conn *websocket.Conn, campaignID uint) {
+func (mw loggingMiddleware) DrainCampaigns(ctx context.Context) error {
+	var err error
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "DrainCampaigns",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	err = mw.Service.DrainCampaigns(ctx)
+	return err
+}
+
+func (mw loggingMiddleware) StreamCampaignResults(ctx context.Context, conn kolide.CampaignResultsWriter, campaignID uint, lastSequence *uint64, aggregate bool) {
 	var (
 		loggedInUser = "unauthenticated"
 		err          error
@@ -74,7 +194,7 @@ func (mw loggingMiddleware) StreamCampaignResults(ctx context.Context, conn *web
 		loggedInUser = vc.Username()
 	}
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "StreamCampaignResults",
 			"campaignID", campaignID,
 			"err", err,
@@ -82,5 +202,47 @@ func (mw loggingMiddleware) StreamCampaignResults(ctx context.Context, conn *web
 			"took", time.Since(begin),
 		)
 	}(time.Now())
-	mw.Service.StreamCampaignResults(ctx, conn, campaignID)
+	mw.Service.StreamCampaignResults(ctx, conn, campaignID, lastSequence, aggregate)
+}
+
+func (mw loggingMiddleware) ListRunningCampaigns(ctx context.Context) ([]kolide.DistributedQueryCampaign, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		campaigns    []kolide.DistributedQueryCampaign
+		err          error
+	)
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "ListRunningCampaigns",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	campaigns, err = mw.Service.ListRunningCampaigns(ctx)
+	return campaigns, err
+}
+
+func (mw loggingMiddleware) StopCampaign(ctx context.Context, id uint) error {
+	var (
+		loggedInUser = "unauthenticated"
+		err          error
+	)
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "StopCampaign",
+			"err", err,
+			"user", loggedInUser,
+			"campaign_id", id,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	err = mw.Service.StopCampaign(ctx, id)
+	return err
 }
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kolide/fleet/server/contexts/viewer"
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw loggingMiddleware) ListDecorators(ctx context.Context) (decs []*kolide.Decorator, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "ListDecorators",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	decs, err = mw.Service.ListDecorators(ctx)
+	return decs, err
+}
+
+func (mw loggingMiddleware) NewDecorator(ctx context.Context, payload kolide.DecoratorPayload) (dec *kolide.Decorator, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "NewDecorator",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	dec, err = mw.Service.NewDecorator(ctx, payload)
+	return dec, err
+}
+
+func (mw loggingMiddleware) ModifyDecorator(ctx context.Context, payload kolide.DecoratorPayload) (dec *kolide.Decorator, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "ModifyDecorator",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	dec, err = mw.Service.ModifyDecorator(ctx, payload)
+	return dec, err
+}
+
+func (mw loggingMiddleware) DeleteDecorator(ctx context.Context, id uint) (err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "DeleteDecorator",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	err = mw.Service.DeleteDecorator(ctx, id)
+	return err
+}
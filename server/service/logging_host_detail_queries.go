@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kolide/fleet/server/contexts/viewer"
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw loggingMiddleware) ListHostDetailQueries(ctx context.Context) (queries []*kolide.HostDetailQuery, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "ListHostDetailQueries",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	queries, err = mw.Service.ListHostDetailQueries(ctx)
+	return queries, err
+}
+
+func (mw loggingMiddleware) NewHostDetailQuery(ctx context.Context, payload kolide.HostDetailQueryPayload) (query *kolide.HostDetailQuery, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "NewHostDetailQuery",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	query, err = mw.Service.NewHostDetailQuery(ctx, payload)
+	return query, err
+}
+
+func (mw loggingMiddleware) DeleteHostDetailQuery(ctx context.Context, id uint) (err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "DeleteHostDetailQuery",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	err = mw.Service.DeleteHostDetailQuery(ctx, id)
+	return err
+}
+
+func (mw loggingMiddleware) HostExtraDetails(ctx context.Context, hostID uint) (details kolide.HostExtraDetails, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "HostExtraDetails",
+			"host_id", hostID,
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	details, err = mw.Service.HostExtraDetails(ctx, hostID)
+	return details, err
+}
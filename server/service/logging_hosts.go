@@ -2,27 +2,73 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"time"
 
+	"github.com/kolide/fleet/server/contexts/viewer"
 	"github.com/kolide/fleet/server/kolide"
 )
 
-func (mw loggingMiddleware) ListHosts(ctx context.Context, opt kolide.ListOptions) ([]*kolide.Host, error) {
+func (mw loggingMiddleware) ListHosts(ctx context.Context, opt kolide.HostListOptions) ([]*kolide.Host, string, error) {
 	var (
-		hosts []*kolide.Host
-		err   error
+		hosts      []*kolide.Host
+		nextCursor string
+		err        error
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "ListHosts",
 			"err", err,
 			"took", time.Since(begin),
 		)
 	}(time.Now())
 
-	hosts, err = mw.Service.ListHosts(ctx, opt)
-	return hosts, err
+	hosts, nextCursor, err = mw.Service.ListHosts(ctx, opt)
+	return hosts, nextCursor, err
+}
+
+func (mw loggingMiddleware) StreamHosts(ctx context.Context, opt kolide.HostListOptions, w io.Writer) error {
+	var (
+		loggedInUser = "unauthenticated"
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "StreamHosts",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	err = mw.Service.StreamHosts(ctx, opt, w)
+	return err
+}
+
+func (mw loggingMiddleware) CountHosts(ctx context.Context, opt kolide.HostListOptions) (int, error) {
+	var (
+		count int
+		err   error
+	)
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "CountHosts",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	count, err = mw.Service.CountHosts(ctx, opt)
+	return count, err
 }
 
 func (mw loggingMiddleware) GetHost(ctx context.Context, id uint) (*kolide.Host, error) {
@@ -32,7 +78,7 @@ func (mw loggingMiddleware) GetHost(ctx context.Context, id uint) (*kolide.Host,
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "GetHost",
 			"err", err,
 			"took", time.Since(begin),
@@ -50,7 +96,7 @@ func (mw loggingMiddleware) GetHostSummary(ctx context.Context) (*kolide.HostSum
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "GetHostSummary",
 			"err", err,
 			"took", time.Since(begin),
@@ -67,7 +113,7 @@ func (mw loggingMiddleware) DeleteHost(ctx context.Context, id uint) error {
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "DeleteHost",
 			"err", err,
 			"took", time.Since(begin),
@@ -77,3 +123,447 @@ func (mw loggingMiddleware) DeleteHost(ctx context.Context, id uint) error {
 	err = mw.Service.DeleteHost(ctx, id)
 	return err
 }
+
+func (mw loggingMiddleware) DeleteHostsByLabel(ctx context.Context, labelID uint) (int, error) {
+	var (
+		deleted int
+		err     error
+	)
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "DeleteHostsByLabel",
+			"label_id", labelID,
+			"deleted", deleted,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	deleted, err = mw.Service.DeleteHostsByLabel(ctx, labelID)
+	return deleted, err
+}
+
+func (mw loggingMiddleware) RefreshHostConfig(ctx context.Context, hostIDs []uint) error {
+	var (
+		loggedInUser = "unauthenticated"
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "RefreshHostConfig",
+			"host_ids", fmt.Sprint(hostIDs),
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	err = mw.Service.RefreshHostConfig(ctx, hostIDs)
+	return err
+}
+
+func (mw loggingMiddleware) TransferHosts(ctx context.Context, hostIDs []uint, targetLabelID uint) error {
+	var (
+		loggedInUser = "unauthenticated"
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "TransferHosts",
+			"host_ids", fmt.Sprint(hostIDs),
+			"label_id", targetLabelID,
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	err = mw.Service.TransferHosts(ctx, hostIDs, targetLabelID)
+	return err
+}
+
+func (mw loggingMiddleware) RefreshHostDetails(ctx context.Context, hostID uint) error {
+	var (
+		loggedInUser = "unauthenticated"
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "RefreshHostDetails",
+			"host_id", hostID,
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	err = mw.Service.RefreshHostDetails(ctx, hostID)
+	return err
+}
+
+func (mw loggingMiddleware) SetHostMaintenance(ctx context.Context, hostID uint, until time.Time) error {
+	var (
+		loggedInUser = "unauthenticated"
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "SetHostMaintenance",
+			"host_id", hostID,
+			"until", until,
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	err = mw.Service.SetHostMaintenance(ctx, hostID, until)
+	return err
+}
+
+func (mw loggingMiddleware) ApproveHost(ctx context.Context, hostID uint) error {
+	var (
+		loggedInUser = "unauthenticated"
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "ApproveHost",
+			"host_id", hostID,
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	err = mw.Service.ApproveHost(ctx, hostID)
+	return err
+}
+
+func (mw loggingMiddleware) RotateHostNodeKey(ctx context.Context, hostID uint) error {
+	var (
+		loggedInUser = "unauthenticated"
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "RotateHostNodeKey",
+			"host_id", hostID,
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	err = mw.Service.RotateHostNodeKey(ctx, hostID)
+	return err
+}
+
+func (mw loggingMiddleware) MergeHosts(ctx context.Context, keepID uint, mergeIDs []uint) (kolide.HostMerge, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		merge        kolide.HostMerge
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "MergeHosts",
+			"host_id", keepID,
+			"merged_host_ids", fmt.Sprint(merge.MergedHostIDs),
+			"label_memberships", merge.LabelMemberships,
+			"uptime_events", merge.UptimeEvents,
+			"activities", merge.Activities,
+			"query_executions", merge.QueryExecutions,
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	merge, err = mw.Service.MergeHosts(ctx, keepID, mergeIDs)
+	return merge, err
+}
+
+func (mw loggingMiddleware) AggregateHosts(ctx context.Context, groupBy string) ([]kolide.HostAggregate, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		aggregates   []kolide.HostAggregate
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "AggregateHosts",
+			"group_by", groupBy,
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	aggregates, err = mw.Service.AggregateHosts(ctx, groupBy)
+	return aggregates, err
+}
+
+func (mw loggingMiddleware) NoisyHosts(ctx context.Context) ([]*kolide.Host, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		hosts        []*kolide.Host
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "NoisyHosts",
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	hosts, err = mw.Service.NoisyHosts(ctx)
+	return hosts, err
+}
+
+func (mw loggingMiddleware) HostScheduledQueries(ctx context.Context, hostID uint) ([]kolide.ScheduledQuery, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		queries      []kolide.ScheduledQuery
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "HostScheduledQueries",
+			"host_id", hostID,
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	queries, err = mw.Service.HostScheduledQueries(ctx, hostID)
+	return queries, err
+}
+
+func (mw loggingMiddleware) HostPacks(ctx context.Context, hostID uint) ([]kolide.HostPackInfo, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		packs        []kolide.HostPackInfo
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "HostPacks",
+			"host_id", hostID,
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	packs, err = mw.Service.HostPacks(ctx, hostID)
+	return packs, err
+}
+
+func (mw loggingMiddleware) DiffHosts(ctx context.Context, hostAID, hostBID uint) (kolide.HostDiff, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		diff         kolide.HostDiff
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "DiffHosts",
+			"host_a_id", hostAID,
+			"host_b_id", hostBID,
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	diff, err = mw.Service.DiffHosts(ctx, hostAID, hostBID)
+	return diff, err
+}
+
+func (mw loggingMiddleware) HostClientConfig(ctx context.Context, hostID uint) (json.RawMessage, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		config       json.RawMessage
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "HostClientConfig",
+			"host_id", hostID,
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	config, err = mw.Service.HostClientConfig(ctx, hostID)
+	return config, err
+}
+
+func (mw loggingMiddleware) HostRebootHistory(ctx context.Context, hostID uint) ([]*kolide.HostUptimeEvent, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		events       []*kolide.HostUptimeEvent
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "HostRebootHistory",
+			"host_id", hostID,
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	events, err = mw.Service.HostRebootHistory(ctx, hostID)
+	return events, err
+}
+
+func (mw loggingMiddleware) HostExport(ctx context.Context, hostID uint) (kolide.HostExport, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		export       kolide.HostExport
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "HostExport",
+			"host_id", hostID,
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	export, err = mw.Service.HostExport(ctx, hostID)
+	return export, err
+}
+
+func (mw loggingMiddleware) HostsByOsqueryVersion(ctx context.Context) ([]kolide.OsqueryVersionCount, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		versions     []kolide.OsqueryVersionCount
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "HostsByOsqueryVersion",
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	versions, err = mw.Service.HostsByOsqueryVersion(ctx)
+	return versions, err
+}
+
+func (mw loggingMiddleware) HostsByEncryptionStatus(ctx context.Context) ([]kolide.DiskEncryptionCount, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		counts       []kolide.DiskEncryptionCount
+		err          error
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "HostsByEncryptionStatus",
+			"user", loggedInUser,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	counts, err = mw.Service.HostsByEncryptionStatus(ctx)
+	return counts, err
+}
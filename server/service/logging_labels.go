@@ -21,7 +21,7 @@ func (mw loggingMiddleware) NewLabel(ctx context.Context, p kolide.LabelPayload)
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "NewLabel",
 			"err", err,
 			"user", loggedInUser,
@@ -46,7 +46,7 @@ func (mw loggingMiddleware) ModifyLabel(ctx context.Context, id uint, p kolide.M
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "ModifyLabel",
 			"err", err,
 			"user", loggedInUser,
@@ -65,7 +65,7 @@ func (mw loggingMiddleware) ListLabels(ctx context.Context, opt kolide.ListOptio
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "ListLabels",
 			"err", err,
 			"took", time.Since(begin),
@@ -83,7 +83,7 @@ func (mw loggingMiddleware) GetLabel(ctx context.Context, id uint) (*kolide.Labe
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "GetLabel",
 			"err", err,
 			"took", time.Since(begin),
@@ -106,7 +106,7 @@ func (mw loggingMiddleware) DeleteLabel(ctx context.Context, name string) error
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "DeleteLabel",
 			"err", err,
 			"user", loggedInUser,
@@ -120,7 +120,7 @@ func (mw loggingMiddleware) DeleteLabel(ctx context.Context, name string) error
 
 func (mw loggingMiddleware) GetLabelSpec(ctx context.Context, name string) (spec *kolide.LabelSpec, err error) {
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "GetLabelSpec",
 			"err", err,
 			"took", time.Since(begin),
@@ -132,7 +132,7 @@ func (mw loggingMiddleware) GetLabelSpec(ctx context.Context, name string) (spec
 
 func (mw loggingMiddleware) GetLabelSpecs(ctx context.Context) (specs []*kolide.LabelSpec, err error) {
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "GetLabelSpecs",
 			"err", err,
 			"took", time.Since(begin),
@@ -142,7 +142,7 @@ func (mw loggingMiddleware) GetLabelSpecs(ctx context.Context) (specs []*kolide.
 	return specs, err
 }
 
-func (mw loggingMiddleware) ApplyLabelSpecs(ctx context.Context, specs []*kolide.LabelSpec) (err error) {
+func (mw loggingMiddleware) ApplyLabelSpecs(ctx context.Context, specs []*kolide.LabelSpec, prune bool) (result *kolide.ApplyLabelSpecsResult, err error) {
 	var (
 		loggedInUser = "unauthenticated"
 	)
@@ -153,13 +153,62 @@ func (mw loggingMiddleware) ApplyLabelSpecs(ctx context.Context, specs []*kolide
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "ApplyLabelSpecs",
+			"prune", prune,
 			"err", err,
 			"user", loggedInUser,
 			"took", time.Since(begin),
 		)
 	}(time.Now())
-	err = mw.Service.ApplyLabelSpecs(ctx, specs)
+	result, err = mw.Service.ApplyLabelSpecs(ctx, specs, prune)
+	return result, err
+}
+
+func (mw loggingMiddleware) AddHostsToLabel(ctx context.Context, lid uint, hostIDs []uint) (err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "AddHostsToLabel",
+			"label", lid,
+			"hosts", len(hostIDs),
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	err = mw.Service.AddHostsToLabel(ctx, lid, hostIDs)
+	return err
+}
+
+func (mw loggingMiddleware) RemoveHostsFromLabel(ctx context.Context, lid uint, hostIDs []uint) (err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "RemoveHostsFromLabel",
+			"label", lid,
+			"hosts", len(hostIDs),
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	err = mw.Service.RemoveHostsFromLabel(ctx, lid, hostIDs)
 	return err
 }
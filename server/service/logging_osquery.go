@@ -17,7 +17,7 @@ func (mw loggingMiddleware) EnrollAgent(ctx context.Context, enrollSecret string
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "EnrollAgent",
 			"ip_addr", ctx.Value(kithttp.ContextKeyRequestRemoteAddr).(string),
 			"x_for_ip_addr", ctx.Value(kithttp.ContextKeyRequestXForwardedFor).(string),
@@ -37,7 +37,7 @@ func (mw loggingMiddleware) AuthenticateHost(ctx context.Context, nodeKey string
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "AuthenticateHost",
 			"ip_addr", ctx.Value(kithttp.ContextKeyRequestRemoteAddr).(string),
 			"x_for_ip_addr", ctx.Value(kithttp.ContextKeyRequestXForwardedFor).(string),
@@ -57,7 +57,7 @@ func (mw loggingMiddleware) GetClientConfig(ctx context.Context) (map[string]int
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "GetClientConfig",
 			"ip_addr", ctx.Value(kithttp.ContextKeyRequestRemoteAddr).(string),
 			"x_for_ip_addr", ctx.Value(kithttp.ContextKeyRequestXForwardedFor).(string),
@@ -78,7 +78,7 @@ func (mw loggingMiddleware) GetDistributedQueries(ctx context.Context) (map[stri
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "GetDistributedQueries",
 			"ip_addr", ctx.Value(kithttp.ContextKeyRequestRemoteAddr).(string),
 			"x_for_ip_addr", ctx.Value(kithttp.ContextKeyRequestXForwardedFor).(string),
@@ -91,13 +91,13 @@ func (mw loggingMiddleware) GetDistributedQueries(ctx context.Context) (map[stri
 	return queries, accelerate, err
 }
 
-func (mw loggingMiddleware) SubmitDistributedQueryResults(ctx context.Context, results kolide.OsqueryDistributedQueryResults, statuses map[string]kolide.OsqueryStatus) error {
+func (mw loggingMiddleware) SubmitDistributedQueryResults(ctx context.Context, results kolide.OsqueryDistributedQueryResults, statuses map[string]kolide.OsqueryStatus, stats map[string]*kolide.OsqueryDistributedQueryStats) error {
 	var (
 		err error
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "SubmitDistributedQueryResults",
 			"ip_addr", ctx.Value(kithttp.ContextKeyRequestRemoteAddr).(string),
 			"x_for_ip_addr", ctx.Value(kithttp.ContextKeyRequestXForwardedFor).(string),
@@ -106,7 +106,7 @@ func (mw loggingMiddleware) SubmitDistributedQueryResults(ctx context.Context, r
 		)
 	}(time.Now())
 
-	err = mw.Service.SubmitDistributedQueryResults(ctx, results, statuses)
+	err = mw.Service.SubmitDistributedQueryResults(ctx, results, statuses, stats)
 	return err
 }
 
@@ -116,7 +116,7 @@ func (mw loggingMiddleware) SubmitStatusLogs(ctx context.Context, logs []json.Ra
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "SubmitStatusLogs",
 			"ip_addr", ctx.Value(kithttp.ContextKeyRequestRemoteAddr).(string),
 			"x_for_ip_addr", ctx.Value(kithttp.ContextKeyRequestXForwardedFor).(string),
@@ -135,7 +135,7 @@ func (mw loggingMiddleware) SubmitResultLogs(ctx context.Context, logs []json.Ra
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "SubmitResultLogs",
 			"ip_addr", ctx.Value(kithttp.ContextKeyRequestRemoteAddr).(string),
 			"x_for_ip_addr", ctx.Value(kithttp.ContextKeyRequestXForwardedFor).(string),
@@ -10,7 +10,7 @@ import (
 
 func (mw loggingMiddleware) GetOptionsSpec(ctx context.Context) (spec *kolide.OptionsSpec, err error) {
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "GetOptionsSpec",
 			"err", err,
 			"took", time.Since(begin),
@@ -30,7 +30,7 @@ func (mw loggingMiddleware) ApplyOptionsSpec(ctx context.Context, spec *kolide.O
 		loggedInUser = vc.Username()
 	}
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "ApplyOptionsSpec",
 			"err", err,
 			"user", loggedInUser,
@@ -40,3 +40,23 @@ func (mw loggingMiddleware) ApplyOptionsSpec(ctx context.Context, spec *kolide.O
 	err = mw.Service.ApplyOptionsSpec(ctx, spec)
 	return err
 }
+
+func (mw loggingMiddleware) GenerateOsqueryFlagfile(ctx context.Context, enrollSecret string) (flagfile []byte, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "GenerateOsqueryFlagfile",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	flagfile, err = mw.Service.GenerateOsqueryFlagfile(ctx, enrollSecret)
+	return flagfile, err
+}
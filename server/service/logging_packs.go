@@ -21,7 +21,7 @@ func (mw loggingMiddleware) NewPack(ctx context.Context, p kolide.PackPayload) (
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "NewPack",
 			"err", err,
 			"user", loggedInUser,
@@ -46,7 +46,7 @@ func (mw loggingMiddleware) ModifyPack(ctx context.Context, id uint, p kolide.Pa
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "ModifyPack",
 			"err", err,
 			"user", loggedInUser,
@@ -65,7 +65,7 @@ func (mw loggingMiddleware) ListPacks(ctx context.Context, opt kolide.ListOption
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "ListPacks",
 			"err", err,
 			"took", time.Since(begin),
@@ -83,7 +83,7 @@ func (mw loggingMiddleware) GetPack(ctx context.Context, id uint) (*kolide.Pack,
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "GetPack",
 			"err", err,
 			"took", time.Since(begin),
@@ -106,7 +106,7 @@ func (mw loggingMiddleware) DeletePack(ctx context.Context, name string) error {
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "DeletePack",
 			"err", err,
 			"user", loggedInUser,
@@ -123,7 +123,7 @@ func (mw loggingMiddleware) AddLabelToPack(ctx context.Context, lid uint, pid ui
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "AddLabelToPack",
 			"err", err,
 			"took", time.Since(begin),
@@ -140,7 +140,7 @@ func (mw loggingMiddleware) RemoveLabelFromPack(ctx context.Context, lid uint, p
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "RemoveLabelFromPack",
 			"err", err,
 			"took", time.Since(begin),
@@ -158,7 +158,7 @@ func (mw loggingMiddleware) ListLabelsForPack(ctx context.Context, pid uint) ([]
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "ListLabelsForPack",
 			"err", err,
 			"took", time.Since(begin),
@@ -175,7 +175,7 @@ func (mw loggingMiddleware) AddHostToPack(ctx context.Context, hid uint, pid uin
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "AddHostToPack",
 			"err", err,
 			"took", time.Since(begin),
@@ -192,7 +192,7 @@ func (mw loggingMiddleware) RemoveHostFromPack(ctx context.Context, hid uint, pi
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "RemoveHostFromPack",
 			"err", err,
 			"took", time.Since(begin),
@@ -210,7 +210,7 @@ func (mw loggingMiddleware) ListPacksForHost(ctx context.Context, hid uint) ([]*
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "ListPacksForHost",
 			"err", err,
 			"took", time.Since(begin),
@@ -228,7 +228,7 @@ func (mw loggingMiddleware) ListHostsInPack(ctx context.Context, pid uint, opt k
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "ListHostsInPack",
 			"err", err,
 			"took", time.Since(begin),
@@ -239,9 +239,28 @@ func (mw loggingMiddleware) ListHostsInPack(ctx context.Context, pid uint, opt k
 	return hosts, err
 }
 
+func (mw loggingMiddleware) HostsMissingPack(ctx context.Context, packID uint, opt kolide.HostListOptions) ([]kolide.Host, error) {
+	var (
+		hosts []kolide.Host
+		err   error
+	)
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "HostsMissingPack",
+			"pack", packID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	hosts, err = mw.Service.HostsMissingPack(ctx, packID, opt)
+	return hosts, err
+}
+
 func (mw loggingMiddleware) GetPackSpec(ctx context.Context, name string) (spec *kolide.PackSpec, err error) {
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "GetPackSpec",
 			"err", err,
 			"took", time.Since(begin),
@@ -253,7 +272,7 @@ func (mw loggingMiddleware) GetPackSpec(ctx context.Context, name string) (spec
 
 func (mw loggingMiddleware) GetPackSpecs(ctx context.Context) (specs []*kolide.PackSpec, err error) {
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "GetPackSpecs",
 			"err", err,
 			"took", time.Since(begin),
@@ -263,6 +282,85 @@ func (mw loggingMiddleware) GetPackSpecs(ctx context.Context) (specs []*kolide.P
 	return specs, err
 }
 
+func (mw loggingMiddleware) ExportPack(ctx context.Context, id uint) (spec *kolide.PackSpec, err error) {
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "ExportPack",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	spec, err = mw.Service.ExportPack(ctx, id)
+	return spec, err
+}
+
+func (mw loggingMiddleware) ImportPack(ctx context.Context, spec *kolide.PackSpec) (pack *kolide.Pack, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "ImportPack",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	pack, err = mw.Service.ImportPack(ctx, spec)
+	return pack, err
+}
+
+func (mw loggingMiddleware) ClonePack(ctx context.Context, sourcePackID uint, newName string) (pack *kolide.Pack, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "ClonePack",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	pack, err = mw.Service.ClonePack(ctx, sourcePackID, newName)
+	return pack, err
+}
+
+func (mw loggingMiddleware) ImportPackFromURL(ctx context.Context, url string) (packs []*kolide.Pack, err error) {
+	var (
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "ImportPackFromURL",
+			"url", url,
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	packs, err = mw.Service.ImportPackFromURL(ctx, url)
+	return packs, err
+}
+
 func (mw loggingMiddleware) ApplyPackSpecs(ctx context.Context, specs []*kolide.PackSpec) (err error) {
 	var (
 		loggedInUser = "unauthenticated"
@@ -274,7 +372,7 @@ func (mw loggingMiddleware) ApplyPackSpecs(ctx context.Context, specs []*kolide.
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "ApplyPackSpecs",
 			"err", err,
 			"user", loggedInUser,
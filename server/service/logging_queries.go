@@ -10,7 +10,7 @@ import (
 
 func (mw loggingMiddleware) GetQuerySpec(ctx context.Context, name string) (spec *kolide.QuerySpec, err error) {
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "GetQuerySpec",
 			"err", err,
 			"took", time.Since(begin),
@@ -22,7 +22,7 @@ func (mw loggingMiddleware) GetQuerySpec(ctx context.Context, name string) (spec
 
 func (mw loggingMiddleware) GetQuerySpecs(ctx context.Context) (specs []*kolide.QuerySpec, err error) {
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "GetQuerySpecs",
 			"err", err,
 			"took", time.Since(begin),
@@ -34,7 +34,7 @@ func (mw loggingMiddleware) GetQuerySpecs(ctx context.Context) (specs []*kolide.
 
 func (mw loggingMiddleware) ApplyQuerySpecs(ctx context.Context, specs []*kolide.QuerySpec) (err error) {
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "ApplyQuerySpecs",
 			"err", err,
 			"took", time.Since(begin),
@@ -44,7 +44,7 @@ func (mw loggingMiddleware) ApplyQuerySpecs(ctx context.Context, specs []*kolide
 	return err
 }
 
-func (mw loggingMiddleware) ListQueries(ctx context.Context, opt kolide.ListOptions) ([]*kolide.Query, error) {
+func (mw loggingMiddleware) ListQueries(ctx context.Context, opt kolide.ListQueryOptions) ([]*kolide.Query, error) {
 	var (
 		loggedInUser = "unauthenticated"
 		err          error
@@ -54,7 +54,7 @@ func (mw loggingMiddleware) ListQueries(ctx context.Context, opt kolide.ListOpti
 		loggedInUser = vc.Username()
 	}
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "ListQueries",
 			"err", err,
 			"user", loggedInUser,
@@ -74,7 +74,7 @@ func (mw loggingMiddleware) GetQuery(ctx context.Context, id uint) (*kolide.Quer
 		loggedInUser = vc.Username()
 	}
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "GetQuery",
 			"err", err,
 			"user", loggedInUser,
@@ -95,7 +95,7 @@ func (mw loggingMiddleware) NewQuery(ctx context.Context, p kolide.QueryPayload)
 		loggedInUser = vc.Username()
 	}
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "NewQuery",
 			"err", err,
 			"user", loggedInUser,
@@ -118,7 +118,7 @@ func (mw loggingMiddleware) ModifyQuery(ctx context.Context, id uint, p kolide.Q
 		loggedInUser = vc.Username()
 	}
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "ModifyQuery",
 			"err", err,
 			"user", loggedInUser,
@@ -140,7 +140,7 @@ func (mw loggingMiddleware) DeleteQuery(ctx context.Context, name string) error
 		loggedInUser = vc.Username()
 	}
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "DeleteQuery",
 			"err", err,
 			"name", name,
@@ -151,3 +151,48 @@ func (mw loggingMiddleware) DeleteQuery(ctx context.Context, name string) error
 	err = mw.Service.DeleteQuery(ctx, name)
 	return err
 }
+
+func (mw loggingMiddleware) RestoreQuery(ctx context.Context, id uint) (*kolide.Query, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		query        *kolide.Query
+		err          error
+	)
+	if vc, ok := viewer.FromContext(ctx); ok {
+
+		loggedInUser = vc.Username()
+	}
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "RestoreQuery",
+			"err", err,
+			"id", id,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	query, err = mw.Service.RestoreQuery(ctx, id)
+	return query, err
+}
+
+func (mw loggingMiddleware) ListQueryTags(ctx context.Context) ([]kolide.QueryTag, error) {
+	var (
+		loggedInUser = "unauthenticated"
+		tags         []kolide.QueryTag
+		err          error
+	)
+	if vc, ok := viewer.FromContext(ctx); ok {
+
+		loggedInUser = vc.Username()
+	}
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "ListQueryTags",
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	tags, err = mw.Service.ListQueryTags(ctx)
+	return tags, err
+}
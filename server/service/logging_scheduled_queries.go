@@ -15,7 +15,7 @@ func (mw loggingMiddleware) GetScheduledQueriesInPack(ctx context.Context, id ui
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "GetScheduledQueriesInPack",
 			"err", err,
 			"took", time.Since(begin),
@@ -33,7 +33,7 @@ func (mw loggingMiddleware) GetScheduledQuery(ctx context.Context, id uint) (*ko
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerDebug(err).Log(
+		_ = mw.loggerDebug(ctx, err).Log(
 			"method", "GetScheduledQuery",
 			"err", err,
 			"took", time.Since(begin),
@@ -58,7 +58,7 @@ func (mw loggingMiddleware) ScheduleQuery(ctx context.Context, sq *kolide.Schedu
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "ScheduleQuery",
 			"err", err,
 			"user", loggedInUser,
@@ -82,7 +82,7 @@ func (mw loggingMiddleware) DeleteScheduledQuery(ctx context.Context, id uint) e
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "DeleteScheduledQuery",
 			"err", err,
 			"user", loggedInUser,
@@ -107,7 +107,7 @@ func (mw loggingMiddleware) ModifyScheduledQuery(ctx context.Context, id uint, p
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "ModifyScheduledQuery",
 			"err", err,
 			"user", loggedInUser,
@@ -118,3 +118,48 @@ func (mw loggingMiddleware) ModifyScheduledQuery(ctx context.Context, id uint, p
 	query, err = mw.Service.ModifyScheduledQuery(ctx, id, p)
 	return query, err
 }
+
+func (mw loggingMiddleware) ScheduledQueryStats(ctx context.Context, packID uint) ([]*kolide.AggregatedScheduledQueryStats, error) {
+	var (
+		stats []*kolide.AggregatedScheduledQueryStats
+		err   error
+	)
+
+	defer func(begin time.Time) {
+		_ = mw.loggerDebug(ctx, err).Log(
+			"method", "ScheduledQueryStats",
+			"pack", packID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	stats, err = mw.Service.ScheduledQueryStats(ctx, packID)
+	return stats, err
+}
+
+func (mw loggingMiddleware) ModifyScheduledQueriesInPack(ctx context.Context, packID uint, patch kolide.ScheduledQueryPatch) (int, error) {
+	var (
+		modified     int
+		err          error
+		loggedInUser = "unauthenticated"
+	)
+
+	if vc, ok := viewer.FromContext(ctx); ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "ModifyScheduledQueriesInPack",
+			"pack", packID,
+			"modified", modified,
+			"err", err,
+			"user", loggedInUser,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	modified, err = mw.Service.ModifyScheduledQueriesInPack(ctx, packID, patch)
+	return modified, err
+}
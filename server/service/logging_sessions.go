@@ -10,7 +10,7 @@ import (
 func (mw loggingMiddleware) Login(ctx context.Context, username, password string) (user *kolide.User, token string, err error) {
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "Login",
 			"user", username,
 			"err", err,
@@ -24,7 +24,7 @@ func (mw loggingMiddleware) Login(ctx context.Context, username, password string
 
 func (mw loggingMiddleware) Logout(ctx context.Context) (err error) {
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "Logout",
 			"err", err,
 			"took", time.Since(begin),
@@ -37,7 +37,7 @@ func (mw loggingMiddleware) Logout(ctx context.Context) (err error) {
 
 func (mw loggingMiddleware) InitiateSSO(ctx context.Context, relayURL string) (idpURL string, err error) {
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "InitiateSSO",
 			"err", err,
 			"took", time.Since(begin),
@@ -50,7 +50,7 @@ func (mw loggingMiddleware) InitiateSSO(ctx context.Context, relayURL string) (i
 
 func (mw loggingMiddleware) CallbackSSO(ctx context.Context, auth kolide.Auth) (sess *kolide.SSOSession, err error) {
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "CallbackSSO",
 			"err", err,
 			"took", time.Since(begin),
@@ -62,7 +62,7 @@ func (mw loggingMiddleware) CallbackSSO(ctx context.Context, auth kolide.Auth) (
 
 func (mw loggingMiddleware) SSOSettings(ctx context.Context) (settings *kolide.SSOSettings, err error) {
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "SSOSettings",
 			"err", err,
 			"took", time.Since(begin),
@@ -71,3 +71,15 @@ func (mw loggingMiddleware) SSOSettings(ctx context.Context) (settings *kolide.S
 	settings, err = mw.Service.SSOSettings(ctx)
 	return
 }
+
+func (mw loggingMiddleware) GenerateSAMLMetadata(ctx context.Context) (metadata []byte, err error) {
+	defer func(begin time.Time) {
+		_ = mw.loggerInfo(ctx, err).Log(
+			"method", "GenerateSAMLMetadata",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	metadata, err = mw.Service.GenerateSAMLMetadata(ctx)
+	return
+}
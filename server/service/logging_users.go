@@ -22,7 +22,7 @@ func (mw loggingMiddleware) ChangeUserAdmin(ctx context.Context, id uint, isAdmi
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "ChangeUserAdmin",
 			"user", userName,
 			"changed_by", loggedInUser,
@@ -53,7 +53,7 @@ func (mw loggingMiddleware) ChangeUserEnabled(ctx context.Context, id uint, isEn
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "ChangeUserEnabled",
 			"user", userName,
 			"changed_by", loggedInUser,
@@ -84,7 +84,7 @@ func (mw loggingMiddleware) NewAdminCreatedUser(ctx context.Context, p kolide.Us
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "NewAdminCreatedUser",
 			"user", username,
 			"created_by", loggedInUser,
@@ -100,6 +100,42 @@ func (mw loggingMiddleware) NewAdminCreatedUser(ctx context.Context, p kolide.Us
 	return user, err
 }
 
+func (mw loggingMiddleware) CreateUsers(ctx context.Context, payloads []kolide.UserPayload) ([]*kolide.User, []error) {
+	var (
+		users        []*kolide.User
+		errs         []error
+		loggedInUser = "unauthenticated"
+	)
+
+	vc, ok := viewer.FromContext(ctx)
+	if ok {
+		loggedInUser = vc.Username()
+	}
+
+	defer func(begin time.Time) {
+		var created, failed int
+		for i := range errs {
+			if users[i] != nil {
+				created++
+			}
+			if errs[i] != nil {
+				failed++
+			}
+		}
+		_ = mw.loggerInfo(ctx, nil).Log(
+			"method", "CreateUsers",
+			"created_by", loggedInUser,
+			"requested", len(payloads),
+			"created", created,
+			"failed", failed,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	users, errs = mw.Service.CreateUsers(ctx, payloads)
+	return users, errs
+}
+
 func (mw loggingMiddleware) ListUsers(ctx context.Context, opt kolide.ListOptions) ([]*kolide.User, error) {
 	var (
 		users    []*kolide.User
@@ -113,7 +149,7 @@ func (mw loggingMiddleware) ListUsers(ctx context.Context, opt kolide.ListOption
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "ListUsers",
 			"user", username,
 			"err", err,
@@ -138,7 +174,7 @@ func (mw loggingMiddleware) RequirePasswordReset(ctx context.Context, uid uint,
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "RequirePasswordReset",
 			"user", username,
 			"err", err,
@@ -165,7 +201,7 @@ func (mw loggingMiddleware) NewUser(ctx context.Context, p kolide.UserPayload) (
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "NewUser",
 			"user", username,
 			"created_by", loggedInUser,
@@ -195,7 +231,7 @@ func (mw loggingMiddleware) ModifyUser(ctx context.Context, userID uint, p kolid
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "ModifyUser",
 			"user", username,
 			"modified_by", vc.Username(),
@@ -221,7 +257,7 @@ func (mw loggingMiddleware) User(ctx context.Context, id uint) (*kolide.User, er
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "User",
 			"user", username,
 			"err", err,
@@ -245,7 +281,7 @@ func (mw loggingMiddleware) AuthenticatedUser(ctx context.Context) (*kolide.User
 	)
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "User",
 			"user", username,
 			"err", err,
@@ -272,7 +308,7 @@ func (mw loggingMiddleware) ChangePassword(ctx context.Context, oldPass, newPass
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "ChangePassword",
 			"err", err,
 			"requested_by", requestedBy,
@@ -288,7 +324,7 @@ func (mw loggingMiddleware) ResetPassword(ctx context.Context, token, password s
 	var err error
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "ResetPassword",
 			"err", err,
 			"took", time.Since(begin),
@@ -310,7 +346,7 @@ func (mw loggingMiddleware) RequestPasswordReset(ctx context.Context, email stri
 	}
 
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "RequestPasswordReset",
 			"email", email,
 			"err", err,
@@ -333,7 +369,7 @@ func (mw loggingMiddleware) PerformRequiredPasswordReset(ctx context.Context, pa
 		resetBy = vc.Username()
 	}
 	defer func(begin time.Time) {
-		_ = mw.loggerInfo(err).Log(
+		_ = mw.loggerInfo(ctx, err).Log(
 			"method", "PerformRequiredPasswordReset",
 			"err", err,
 			"reset_by", resetBy,
@@ -0,0 +1,232 @@
+package service
+
+// osqueryTables are the names of the tables known to osquery, as listed in
+// frontend/osquery_tables.json. Queries that use other tables may still be
+// valid on hosts that load extensions, so they are only flagged as warnings.
+var osqueryTables = map[string]bool{
+	"account_policy_data":            true,
+	"acpi_tables":                    true,
+	"ad_config":                      true,
+	"alf":                            true,
+	"alf_exceptions":                 true,
+	"alf_explicit_auths":             true,
+	"alf_services":                   true,
+	"app_schemes":                    true,
+	"appcompat_shims":                true,
+	"apps":                           true,
+	"apt_sources":                    true,
+	"arp_cache":                      true,
+	"asl":                            true,
+	"augeas":                         true,
+	"authenticode":                   true,
+	"authorization_mechanisms":       true,
+	"authorizations":                 true,
+	"authorized_keys":                true,
+	"autoexec":                       true,
+	"battery":                        true,
+	"bitlocker_info":                 true,
+	"block_devices":                  true,
+	"browser_plugins":                true,
+	"carbon_black_info":              true,
+	"carves":                         true,
+	"certificates":                   true,
+	"chocolatey_packages":            true,
+	"chrome_extensions":              true,
+	"cpu_info":                       true,
+	"cpu_time":                       true,
+	"cpuid":                          true,
+	"crashes":                        true,
+	"crontab":                        true,
+	"cups_destinations":              true,
+	"cups_jobs":                      true,
+	"curl":                           true,
+	"curl_certificate":               true,
+	"deb_packages":                   true,
+	"device_file":                    true,
+	"device_firmware":                true,
+	"device_hash":                    true,
+	"device_partitions":              true,
+	"disk_encryption":                true,
+	"disk_events":                    true,
+	"disk_info":                      true,
+	"dns_resolvers":                  true,
+	"docker_container_labels":        true,
+	"docker_container_mounts":        true,
+	"docker_container_networks":      true,
+	"docker_container_ports":         true,
+	"docker_container_processes":     true,
+	"docker_container_stats":         true,
+	"docker_containers":              true,
+	"docker_image_labels":            true,
+	"docker_images":                  true,
+	"docker_info":                    true,
+	"docker_network_labels":          true,
+	"docker_networks":                true,
+	"docker_version":                 true,
+	"docker_volume_labels":           true,
+	"docker_volumes":                 true,
+	"drivers":                        true,
+	"ec2_instance_metadata":          true,
+	"ec2_instance_tags":              true,
+	"elf_dynamic":                    true,
+	"elf_info":                       true,
+	"elf_sections":                   true,
+	"elf_segments":                   true,
+	"elf_symbols":                    true,
+	"etc_hosts":                      true,
+	"etc_protocols":                  true,
+	"etc_services":                   true,
+	"event_taps":                     true,
+	"extended_attributes":            true,
+	"fan_speed_sensors":              true,
+	"fbsd_kmods":                     true,
+	"file":                           true,
+	"file_events":                    true,
+	"firefox_addons":                 true,
+	"gatekeeper":                     true,
+	"gatekeeper_approved_apps":       true,
+	"groups":                         true,
+	"hardware_events":                true,
+	"hash":                           true,
+	"homebrew_packages":              true,
+	"ie_extensions":                  true,
+	"intel_me_info":                  true,
+	"interface_addresses":            true,
+	"interface_details":              true,
+	"iokit_devicetree":               true,
+	"iokit_registry":                 true,
+	"iptables":                       true,
+	"kernel_extensions":              true,
+	"kernel_info":                    true,
+	"kernel_integrity":               true,
+	"kernel_modules":                 true,
+	"kernel_panics":                  true,
+	"keychain_acls":                  true,
+	"keychain_items":                 true,
+	"known_hosts":                    true,
+	"kva_speculative_info":           true,
+	"last":                           true,
+	"launchd":                        true,
+	"launchd_overrides":              true,
+	"listening_ports":                true,
+	"lldp_neighbors":                 true,
+	"load_average":                   true,
+	"logged_in_users":                true,
+	"logical_drives":                 true,
+	"logon_sessions":                 true,
+	"magic":                          true,
+	"managed_policies":               true,
+	"md_devices":                     true,
+	"md_drives":                      true,
+	"md_personalities":               true,
+	"mdfind":                         true,
+	"memory_array_mapped_addresses":  true,
+	"memory_arrays":                  true,
+	"memory_device_mapped_addresses": true,
+	"memory_devices":                 true,
+	"memory_error_info":              true,
+	"memory_info":                    true,
+	"memory_map":                     true,
+	"mounts":                         true,
+	"msr":                            true,
+	"nfs_shares":                     true,
+	"npm_packages":                   true,
+	"ntfs_acl_permissions":           true,
+	"nvram":                          true,
+	"opera_extensions":               true,
+	"os_version":                     true,
+	"osquery_events":                 true,
+	"osquery_extensions":             true,
+	"osquery_flags":                  true,
+	"osquery_info":                   true,
+	"osquery_packs":                  true,
+	"osquery_registry":               true,
+	"osquery_schedule":               true,
+	"package_bom":                    true,
+	"package_install_history":        true,
+	"package_receipts":               true,
+	"patches":                        true,
+	"pci_devices":                    true,
+	"physical_disk_performance":      true,
+	"pipes":                          true,
+	"pkg_packages":                   true,
+	"platform_info":                  true,
+	"plist":                          true,
+	"portage_keywords":               true,
+	"portage_packages":               true,
+	"portage_use":                    true,
+	"power_sensors":                  true,
+	"powershell_events":              true,
+	"preferences":                    true,
+	"process_envs":                   true,
+	"process_events":                 true,
+	"process_file_events":            true,
+	"process_memory_map":             true,
+	"process_namespaces":             true,
+	"process_open_files":             true,
+	"process_open_sockets":           true,
+	"processes":                      true,
+	"programs":                       true,
+	"prometheus_metrics":             true,
+	"python_packages":                true,
+	"quicklook_cache":                true,
+	"registry":                       true,
+	"routes":                         true,
+	"rpm_package_files":              true,
+	"rpm_packages":                   true,
+	"safari_extensions":              true,
+	"sandboxes":                      true,
+	"scheduled_tasks":                true,
+	"selinux_events":                 true,
+	"services":                       true,
+	"shadow":                         true,
+	"shared_folders":                 true,
+	"shared_memory":                  true,
+	"shared_resources":               true,
+	"sharing_preferences":            true,
+	"shell_history":                  true,
+	"signature":                      true,
+	"sip_config":                     true,
+	"smart_drive_info":               true,
+	"smbios_tables":                  true,
+	"smc_keys":                       true,
+	"socket_events":                  true,
+	"ssh_configs":                    true,
+	"startup_items":                  true,
+	"sudoers":                        true,
+	"suid_bin":                       true,
+	"syslog_events":                  true,
+	"system_controls":                true,
+	"system_info":                    true,
+	"temperature_sensors":            true,
+	"time":                           true,
+	"time_machine_backups":           true,
+	"time_machine_destinations":      true,
+	"ulimit_info":                    true,
+	"uptime":                         true,
+	"usb_devices":                    true,
+	"user_events":                    true,
+	"user_groups":                    true,
+	"user_interaction_events":        true,
+	"user_ssh_keys":                  true,
+	"users":                          true,
+	"video_info":                     true,
+	"virtual_memory_info":            true,
+	"wifi_networks":                  true,
+	"wifi_status":                    true,
+	"wifi_survey":                    true,
+	"winbaseobj":                     true,
+	"windows_crashes":                true,
+	"windows_events":                 true,
+	"wmi_bios_info":                  true,
+	"wmi_cli_event_consumers":        true,
+	"wmi_event_filters":              true,
+	"wmi_filter_consumer_binding":    true,
+	"wmi_script_event_consumers":     true,
+	"xprotect_entries":               true,
+	"xprotect_meta":                  true,
+	"xprotect_reports":               true,
+	"yara":                           true,
+	"yara_events":                    true,
+	"yum_sources":                    true,
+}
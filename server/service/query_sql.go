@@ -0,0 +1,355 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sqlTokenKind is the kind of a token in osquery SQL.
+type sqlTokenKind int
+
+const (
+	sqlWord sqlTokenKind = iota
+	sqlQuotedIdentifier
+	sqlString
+	sqlNumber
+	sqlPunctuation
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	// text is the token without quotes, lowercased for words
+	text string
+	pos  int
+}
+
+// sqlStatementKeywords are the keywords that begin the statements osquery
+// can run.
+var sqlStatementKeywords = map[string]bool{
+	"select":  true,
+	"with":    true,
+	"values":  true,
+	"pragma":  true,
+	"explain": true,
+}
+
+// sqlClauseKeywords end the list of tables in a FROM clause. Words that are
+// not keywords after a table name are taken to be an alias.
+var sqlClauseKeywords = map[string]bool{
+	"where": true, "group": true, "order": true, "limit": true, "having": true,
+	"union": true, "intersect": true, "except": true, "join": true, "inner": true,
+	"left": true, "right": true, "full": true, "cross": true, "natural": true,
+	"outer": true, "on": true, "using": true, "window": true, "as": true,
+	"indexed": true, "not": true,
+}
+
+// tokenizeSQL splits sql into tokens, skipping whitespace and comments. It
+// returns an error describing the first unterminated string, identifier or
+// comment.
+func tokenizeSQL(sql string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				return tokens, nil
+			}
+			i += end + 1
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at position %d", i)
+			}
+			i += end + 4
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			kind := sqlQuotedIdentifier
+			if c == '\'' {
+				kind = sqlString
+			} else if c == '[' {
+				closing = ']'
+			}
+			var text strings.Builder
+			j := i + 1
+			for {
+				if j >= len(sql) {
+					if kind == sqlString {
+						return nil, fmt.Errorf("unterminated string at position %d", i)
+					}
+					return nil, fmt.Errorf("unterminated identifier at position %d", i)
+				}
+				if sql[j] == closing {
+					// Quotes are escaped by doubling them
+					if closing != ']' && j+1 < len(sql) && sql[j+1] == closing {
+						text.WriteByte(closing)
+						j += 2
+						continue
+					}
+					break
+				}
+				text.WriteByte(sql[j])
+				j++
+			}
+			tokens = append(tokens, sqlToken{kind: kind, text: text.String(), pos: i})
+			i = j + 1
+		case isSQLWordByte(c):
+			j := i
+			for j < len(sql) && isSQLWordByte(sql[j]) {
+				j++
+			}
+			kind := sqlWord
+			if c >= '0' && c <= '9' {
+				kind = sqlNumber
+			}
+			tokens = append(tokens, sqlToken{kind: kind, text: strings.ToLower(sql[i:j]), pos: i})
+			i = j
+		default:
+			tokens = append(tokens, sqlToken{kind: sqlPunctuation, text: string(c), pos: i})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+func isSQLWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func (t sqlToken) is(text string) bool {
+	return (t.kind == sqlWord || t.kind == sqlPunctuation) && t.text == text
+}
+
+func (t sqlToken) isIdentifier() bool {
+	return t.kind == sqlQuotedIdentifier || (t.kind == sqlWord && !sqlClauseKeywords[t.text])
+}
+
+// checkQuerySQL checks sql for the syntax errors that can be found without
+// a full parser, and for tables that are not known to osquery. Syntax
+// errors mean the query will fail on every host, while unknown tables are
+// returned as warnings.
+func checkQuerySQL(sql string) (syntaxErrors, warnings []string) {
+	tokens, err := tokenizeSQL(sql)
+	if err != nil {
+		return []string{err.Error()}, nil
+	}
+	if len(tokens) == 0 {
+		return []string{"query is empty"}, nil
+	}
+	if first := tokens[0]; first.kind != sqlWord || !sqlStatementKeywords[first.text] {
+		syntaxErrors = append(syntaxErrors, "query must begin with SELECT, WITH, VALUES, PRAGMA or EXPLAIN")
+	}
+
+	depth := 0
+	for i, t := range tokens {
+		switch {
+		case t.is("("):
+			depth++
+		case t.is(")"):
+			depth--
+			if depth < 0 {
+				syntaxErrors = append(syntaxErrors, fmt.Sprintf("unexpected ')' at position %d", t.pos))
+				depth = 0
+			}
+		case t.is(";"):
+			if i != len(tokens)-1 && !tokens[i+1].is(";") {
+				syntaxErrors = append(syntaxErrors, fmt.Sprintf("only a single statement is allowed, found ';' at position %d", t.pos))
+				return syntaxErrors, nil
+			}
+		}
+	}
+	if depth > 0 {
+		syntaxErrors = append(syntaxErrors, "unbalanced parentheses, missing ')'")
+	}
+
+	defined := commonTableNames(tokens)
+	seen := make(map[string]bool)
+	for _, name := range referencedTableNames(tokens) {
+		if name == "" {
+			syntaxErrors = append(syntaxErrors, "missing table name after FROM or JOIN")
+			continue
+		}
+		lower := strings.ToLower(name)
+		if osqueryTables[lower] || defined[lower] || strings.HasPrefix(lower, "sqlite_") || seen[lower] {
+			continue
+		}
+		seen[lower] = true
+		warnings = append(warnings, fmt.Sprintf("unknown table '%s'", name))
+	}
+	return syntaxErrors, warnings
+}
+
+// commonTableNames returns the names defined by WITH clauses, in the form
+// "name AS (" or "name (columns) AS (".
+func commonTableNames(tokens []sqlToken) map[string]bool {
+	names := make(map[string]bool)
+	for i, t := range tokens {
+		if !t.isIdentifier() {
+			continue
+		}
+		j := i + 1
+		if j < len(tokens) && tokens[j].is("(") {
+			j = closingParen(tokens, j) + 1
+		}
+		if j+1 < len(tokens) && tokens[j].is("as") && tokens[j+1].is("(") {
+			names[strings.ToLower(t.text)] = true
+		}
+	}
+	return names
+}
+
+// closingParen returns the index of the token closing the parenthesis at
+// tokens[open], or the last index if it is not closed.
+func closingParen(tokens []sqlToken, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		if tokens[i].is("(") {
+			depth++
+		} else if tokens[i].is(")") {
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens) - 1
+}
+
+// referencedTableNames returns the names of the tables that follow FROM and
+// JOIN, including those in comma separated lists. Subqueries and table
+// valued functions are skipped, and an empty name is returned where a table
+// is missing.
+func referencedTableNames(tokens []sqlToken) []string {
+	var names []string
+	for i := 0; i < len(tokens); i++ {
+		if !tokens[i].is("from") && !tokens[i].is("join") {
+			continue
+		}
+		// IS [NOT] DISTINCT FROM compares values rather than naming a table
+		if tokens[i].is("from") && i > 0 && tokens[i-1].is("distinct") {
+			continue
+		}
+		for {
+			i++
+			if i >= len(tokens) || tokens[i].is(")") || tokens[i].is(";") {
+				names = append(names, "")
+				break
+			}
+			if tokens[i].is("(") {
+				// Subqueries are checked when their own FROM is reached
+				break
+			}
+			if !tokens[i].isIdentifier() {
+				names = append(names, "")
+				break
+			}
+			name := tokens[i].text
+			// Skip the schema of qualified names
+			if i+2 < len(tokens) && tokens[i+1].is(".") && tokens[i+2].isIdentifier() {
+				i += 2
+				name = tokens[i].text
+			}
+			// Table valued functions, such as json_each, are not tables
+			if i+1 < len(tokens) && tokens[i+1].is("(") {
+				i = closingParen(tokens, i+1)
+			} else {
+				names = append(names, name)
+			}
+			// Skip the alias
+			if i+1 < len(tokens) && tokens[i+1].is("as") {
+				i++
+			}
+			if i+1 < len(tokens) && tokens[i+1].isIdentifier() {
+				i++
+			}
+			if i+1 < len(tokens) && tokens[i+1].is(",") {
+				i++
+				continue
+			}
+			break
+		}
+	}
+	return names
+}
+
+// selectListEnd are the keywords that end the select list of a SELECT.
+var selectListEnd = map[string]bool{
+	"from": true, "where": true, "group": true, "having": true, "window": true,
+	"order": true, "limit": true, "union": true, "intersect": true, "except": true,
+}
+
+// selectColumnNames returns the names of the columns returned by sql, taken
+// from the select list of its first SELECT. The names are only known when
+// every column is a column reference or has an alias, so false is returned
+// for select lists with *, with unaliased expressions, or that cannot be
+// parsed.
+func selectColumnNames(sql string) ([]string, bool) {
+	tokens, err := tokenizeSQL(sql)
+	if err != nil || len(tokens) == 0 || !tokens[0].is("select") {
+		return nil, false
+	}
+	start := 1
+	if start < len(tokens) && (tokens[start].is("distinct") || tokens[start].is("all")) {
+		start++
+	}
+
+	var names []string
+	depth := 0
+	for i := start; i <= len(tokens); i++ {
+		if i < len(tokens) {
+			t := tokens[i]
+			if t.is("(") {
+				depth++
+			} else if t.is(")") {
+				depth--
+			}
+			if depth > 0 || !(t.is(",") || t.is(";") || (t.kind == sqlWord && selectListEnd[t.text])) {
+				continue
+			}
+		}
+		name, ok := resultColumnName(sql, tokens[start:i])
+		if !ok {
+			return nil, false
+		}
+		names = append(names, name)
+		if i == len(tokens) || !tokens[i].is(",") {
+			break
+		}
+		start = i + 1
+	}
+	return names, true
+}
+
+// resultColumnName returns the name of the result column defined by the
+// tokens of an item of a select list, as SQLite names it.
+func resultColumnName(sql string, item []sqlToken) (string, bool) {
+	n := len(item)
+	switch {
+	case n >= 3 && item[n-2].is("as") && (item[n-1].isIdentifier() || item[n-1].kind == sqlString):
+		return originalText(sql, item[n-1]), true
+	case n == 1 && item[0].isIdentifier():
+		return originalText(sql, item[0]), true
+	case n == 3 && item[0].isIdentifier() && item[1].is(".") && item[2].isIdentifier():
+		return originalText(sql, item[2]), true
+	case n == 2 && item[0].isIdentifier() && item[1].isIdentifier():
+		return originalText(sql, item[1]), true
+	case n >= 2 && item[n-2].is(")") && item[n-1].isIdentifier():
+		return originalText(sql, item[n-1]), true
+	}
+	return "", false
+}
+
+// originalText returns the text of t as written in sql, as words are
+// lowercased by tokenizeSQL.
+func originalText(sql string, t sqlToken) string {
+	if t.kind == sqlWord && t.pos+len(t.text) <= len(sql) {
+		if text := sql[t.pos : t.pos+len(t.text)]; strings.EqualFold(text, t.text) {
+			return text
+		}
+	}
+	return t.text
+}
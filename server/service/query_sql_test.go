@@ -0,0 +1,98 @@
+package service
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckQuerySQL(t *testing.T) {
+	var testCases = []struct {
+		sql          string
+		syntaxErrors []string
+		warnings     []string
+	}{
+		{sql: "select * from osquery_info;"},
+		{sql: "SELECT p.name FROM processes p JOIN listening_ports AS l USING (pid) WHERE p.name = 'from foo'"},
+		{sql: "select * from users, groups g where users.gid = g.gid"},
+		{sql: "select * from (select * from time) limit 1"},
+		{sql: "with recursive counter(x) as (select 1 union select x + 1 from counter where x < 5) select * from counter"},
+		{sql: "select value from json_each('[1, 2]') -- from comments\n/* join foo */"},
+		{sql: "select * from sqlite_master"},
+		{sql: `select * from "users" where username is not distinct from 'root'`},
+		{
+			sql:      "select * from Custom_Table join custom_table on 1 join other",
+			warnings: []string{"unknown table 'custom_table'", "unknown table 'other'"},
+		},
+		{sql: "", syntaxErrors: []string{"query is empty"}},
+		{sql: " -- nothing", syntaxErrors: []string{"query is empty"}},
+		{sql: "selet * from time", syntaxErrors: []string{"query must begin with SELECT, WITH, VALUES, PRAGMA or EXPLAIN"}},
+		{sql: "delete from time", syntaxErrors: []string{"query must begin with SELECT, WITH, VALUES, PRAGMA or EXPLAIN"}},
+		{sql: "select 'foo from time", syntaxErrors: []string{"unterminated string at position 7"}},
+		{sql: `select "foo from time`, syntaxErrors: []string{"unterminated identifier at position 7"}},
+		{sql: "select * /* from time", syntaxErrors: []string{"unterminated comment at position 9"}},
+		{sql: "select count(* from time", syntaxErrors: []string{"unbalanced parentheses, missing ')'"}},
+		{sql: "select count(*)) from time", syntaxErrors: []string{"unexpected ')' at position 15"}},
+		{sql: "select * from time; select * from users", syntaxErrors: []string{"only a single statement is allowed, found ';' at position 18"}},
+		{sql: "select * from where 1", syntaxErrors: []string{"missing table name after FROM or JOIN"}},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.sql, func(t *testing.T) {
+			syntaxErrors, warnings := checkQuerySQL(tt.sql)
+			assert.Equal(t, tt.syntaxErrors, syntaxErrors)
+			assert.Equal(t, tt.warnings, warnings)
+		})
+	}
+}
+
+func TestSelectColumnNames(t *testing.T) {
+	var testCases = []struct {
+		sql     string
+		columns []string
+	}{
+		{sql: "select hostname, uuid from system_info", columns: []string{"hostname", "uuid"}},
+		{sql: "SELECT DISTINCT p.Name, count(*) AS Total, max(pid) m FROM processes p GROUP BY name", columns: []string{"Name", "Total", "m"}},
+		{sql: `select "user name", path as 'path' from users;`, columns: []string{"user name", "path"}},
+		{sql: "select coalesce(a, (select b from c)) as d, e from f where e = 1", columns: []string{"d", "e"}},
+		{sql: "select 1 as one union select 2", columns: []string{"one"}},
+		{sql: "select * from system_info"},
+		{sql: "select p.* from processes p"},
+		{sql: "select name, pid + 1 from processes"},
+		{sql: "select name is null from processes"},
+		{sql: "with t as (select 1 as a) select a from t"},
+		{sql: "pragma table_info(users)"},
+		{sql: "select 'foo from time"},
+		{sql: "select name, from processes"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.sql, func(t *testing.T) {
+			columns, ok := selectColumnNames(tt.sql)
+			assert.Equal(t, tt.columns != nil, ok)
+			assert.Equal(t, tt.columns, columns)
+		})
+	}
+}
+
+func TestOsqueryTablesMatchFrontend(t *testing.T) {
+	b, err := ioutil.ReadFile("../../frontend/osquery_tables.json")
+	require.Nil(t, err)
+	var schema struct {
+		Tables []struct {
+			Tables []struct {
+				Name string `json:"name"`
+			} `json:"tables"`
+		} `json:"tables"`
+	}
+	require.Nil(t, json.Unmarshal(b, &schema))
+
+	names := make(map[string]bool)
+	for _, group := range schema.Tables {
+		for _, table := range group.Tables {
+			names[table.Name] = true
+		}
+	}
+	assert.Equal(t, names, osqueryTables)
+}
@@ -10,10 +10,14 @@ import (
 
 	"github.com/WatchBeam/clock"
 	kitlog "github.com/go-kit/kit/log"
+	"github.com/kolide/fleet/server/cache"
 	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/contexts/sourceip"
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/kolide/fleet/server/logging"
+	"github.com/kolide/fleet/server/ratelimit"
 	"github.com/kolide/fleet/server/sso"
+	"github.com/kolide/fleet/server/webhook"
 	"github.com/kolide/kit/version"
 	"github.com/pkg/errors"
 )
@@ -29,6 +33,18 @@ func NewService(ds kolide.Datastore, resultStore kolide.QueryResultStore,
 		return nil, errors.Wrap(err, "initializing osquery logging")
 	}
 
+	if _, err := sourceip.ParseTrustedProxies(config.Server.TrustedProxies); err != nil {
+		return nil, errors.Wrap(err, "parsing server.trusted_proxies")
+	}
+	if err := validateCommonNamePatterns(config.Osquery.EnrollClientCertNames); err != nil {
+		return nil, errors.Wrap(err, "parsing osquery.enroll_client_cert_names")
+	}
+
+	var logBudget *ratelimit.Budget
+	if config.Osquery.LogRateLimit > 0 {
+		logBudget = ratelimit.NewBudget(config.Osquery.LogRateLimit, time.Minute, c)
+	}
+
 	svc = service{
 		ds:               ds,
 		resultStore:      resultStore,
@@ -41,8 +57,22 @@ func NewService(ds kolide.Datastore, resultStore kolide.QueryResultStore,
 		metaDataClient: &http.Client{
 			Timeout: 5 * time.Second,
 		},
+		packClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		webhookSender:   webhook.NewSender(),
+		resultsCache:    cache.NewInmemResultsCache(c),
+		campaignStreams: newCampaignStreams(c),
+		logBudget:       logBudget,
+		sessionTimeouts: &sessionLifetimeCache{},
 	}
 	svc = validationMiddleware{svc, ds, sso}
+	svc = idempotencyMiddleware{
+		Service: svc,
+		ds:      ds,
+		cache:   cache.NewInmemIdempotencyCache(c),
+		window:  config.App.IdempotencyWindow,
+	}
 	return svc, nil
 }
 
@@ -58,6 +88,15 @@ type service struct {
 	mailService     kolide.MailService
 	ssoSessionStore sso.SessionStore
 	metaDataClient  *http.Client
+	packClient      *http.Client
+	webhookSender   *webhook.Sender
+	resultsCache    kolide.ResultsCache
+	campaignStreams *campaignStreams
+	// logBudget limits the osquery log rows written per host per minute.
+	// It is nil when logs are not rate limited.
+	logBudget *ratelimit.Budget
+	// sessionTimeouts caches the session lifetime of the app config.
+	sessionTimeouts *sessionLifetimeCache
 }
 
 func (s service) SendEmail(mail kolide.Email) error {
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+
+	"github.com/kolide/fleet/server/contexts/viewer"
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (svc service) ListActivities(ctx context.Context, opt kolide.ListOptions) ([]*kolide.Activity, error) {
+	if opt.OrderKey == "" {
+		opt.OrderKey = "id"
+		opt.OrderDirection = kolide.OrderDescending
+	}
+	return svc.ds.ListActivities(opt)
+}
+
+func (svc service) RecordActivity(ctx context.Context, activity *kolide.Activity) error {
+	if vc, ok := viewer.FromContext(ctx); ok && vc.User != nil {
+		id := vc.UserID()
+		activity.ActorID = &id
+		activity.ActorUsername = vc.Username()
+	}
+	_, err := svc.ds.NewActivity(activity)
+	return err
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/kolide/fleet/server/contexts/viewer"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListActivitiesDefaultOrder(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	var gotOpt kolide.ListOptions
+	ds.ListActivitiesFunc = func(opt kolide.ListOptions) ([]*kolide.Activity, error) {
+		gotOpt = opt
+		return nil, nil
+	}
+
+	_, err = svc.ListActivities(context.Background(), kolide.ListOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, "id", gotOpt.OrderKey)
+	assert.Equal(t, kolide.OrderDescending, gotOpt.OrderDirection)
+
+	_, err = svc.ListActivities(context.Background(), kolide.ListOptions{OrderKey: "action"})
+	require.Nil(t, err)
+	assert.Equal(t, "action", gotOpt.OrderKey)
+	assert.Equal(t, kolide.OrderAscending, gotOpt.OrderDirection)
+}
+
+func TestLogActivity(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	var recorded []*kolide.Activity
+	ds.NewActivityFunc = func(activity *kolide.Activity) (*kolide.Activity, error) {
+		recorded = append(recorded, activity)
+		return activity, nil
+	}
+
+	ctx := viewer.NewContext(context.Background(), viewer.Viewer{
+		User: &kolide.User{ID: 3, Username: "admin", Admin: true},
+	})
+
+	var testCases = []struct {
+		action     string
+		request    interface{}
+		response   interface{}
+		err        error
+		record     bool
+		targetID   *uint
+		targetName string
+	}{
+		{
+			action:   "modify_pack",
+			request:  modifyPackRequest{ID: 7},
+			response: modifyPackResponse{},
+			record:   true,
+			targetID: uintPtr(7),
+		},
+		{
+			action:     "delete_pack",
+			request:    deletePackRequest{Name: "monitoring"},
+			response:   deletePackResponse{},
+			record:     true,
+			targetName: "monitoring",
+		},
+		{
+			action:   "create_user",
+			request:  createUserRequest{},
+			response: createUserResponse{User: &kolide.User{ID: 12}},
+			record:   true,
+			targetID: uintPtr(12),
+		},
+		{
+			action:   "create_invite",
+			request:  createInviteRequest{},
+			response: createInviteResponse{Invite: &kolide.Invite{ID: 4}},
+			record:   true,
+			targetID: uintPtr(4),
+		},
+		{
+			action:   "delete_session",
+			request:  deleteSessionRequest{ID: 9},
+			response: deleteSessionResponse{},
+			record:   true,
+			targetID: uintPtr(9),
+		},
+		{
+			action:   "modify_app_config",
+			request:  appConfigRequest{},
+			response: appConfigResponse{},
+			record:   true,
+		},
+		{
+			action:   "modify_pack",
+			request:  modifyPackRequest{ID: 7},
+			response: modifyPackResponse{Err: errors.New("not found")},
+		},
+		{
+			action:  "modify_pack",
+			request: modifyPackRequest{ID: 7},
+			err:     errors.New("must be an admin"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.action, func(t *testing.T) {
+			recorded = nil
+			next := func(ctx context.Context, request interface{}) (interface{}, error) {
+				return tt.response, tt.err
+			}
+			_, err := logActivity(svc, kitlog.NewNopLogger(), tt.action)(next)(ctx, tt.request)
+			assert.Equal(t, tt.err, err)
+
+			if !tt.record {
+				assert.Len(t, recorded, 0)
+				return
+			}
+			require.Len(t, recorded, 1)
+			activity := recorded[0]
+			assert.Equal(t, tt.action, activity.Action)
+			require.NotNil(t, activity.ActorID)
+			assert.Equal(t, uint(3), *activity.ActorID)
+			assert.Equal(t, "admin", activity.ActorUsername)
+			assert.Equal(t, tt.targetID, activity.TargetID)
+			assert.Equal(t, tt.targetName, activity.TargetName)
+		})
+	}
+}
+
+func TestLogActivityRecordFailure(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ds.NewActivityFunc = func(activity *kolide.Activity) (*kolide.Activity, error) {
+		return nil, errors.New("database unavailable")
+	}
+	ctx := viewer.NewContext(context.Background(), viewer.Viewer{
+		User: &kolide.User{ID: 3, Username: "admin", Admin: true},
+	})
+
+	// The change has been made, so its response is still returned
+	next := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return modifyPackResponse{}, nil
+	}
+	response, err := logActivity(svc, kitlog.NewNopLogger(), "modify_pack")(next)(ctx, modifyPackRequest{ID: 7})
+	require.Nil(t, err)
+	assert.Equal(t, modifyPackResponse{}, response)
+	assert.True(t, ds.NewActivityFuncInvoked)
+}
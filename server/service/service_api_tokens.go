@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/kolide/fleet/server/contexts/viewer"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
+)
+
+func (svc service) CreateAPIToken(ctx context.Context, name string, scopes []string) (*kolide.APIToken, string, error) {
+	vc, ok := viewer.FromContext(ctx)
+	if !ok {
+		return nil, "", errNoContext
+	}
+
+	if len(scopes) == 0 {
+		return nil, "", newInvalidArgumentError("scopes", "at least one scope is required")
+	}
+	var granted kolide.Scopes
+	for _, scope := range scopes {
+		if !kolide.IsValidAPITokenScope(scope) {
+			return nil, "", newInvalidArgumentError("scopes", fmt.Sprintf("unknown scope %q", scope))
+		}
+		// A token can only be minted with permissions the viewer holds
+		if vc.APIToken != nil && !vc.APIToken.Scopes.Contains(scope) {
+			return nil, "", permissionError{message: fmt.Sprintf("cannot grant scope %s", scope)}
+		}
+		if !granted.Contains(scope) {
+			granted = append(granted, scope)
+		}
+	}
+
+	key := make([]byte, svc.config.Session.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, "", errors.Wrap(err, "generating api token key")
+	}
+
+	token, err := svc.ds.NewAPIToken(&kolide.APIToken{
+		UserID: vc.UserID(),
+		Name:   name,
+		Key:    base64.StdEncoding.EncodeToString(key),
+		Scopes: granted,
+	})
+	if err != nil {
+		return nil, "", errors.Wrap(err, "creating api token")
+	}
+
+	bearer, err := generateAPITokenJWT(token.Key, svc.config.Auth.JwtKey)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "generating JWT token")
+	}
+	return token, bearer, nil
+}
+
+func (svc service) ListAPITokens(ctx context.Context) ([]*kolide.APIToken, error) {
+	vc, ok := viewer.FromContext(ctx)
+	if !ok {
+		return nil, errNoContext
+	}
+	return svc.ds.ListAPITokensForUser(vc.UserID())
+}
+
+func (svc service) DeleteAPIToken(ctx context.Context, id uint) error {
+	vc, ok := viewer.FromContext(ctx)
+	if !ok {
+		return errNoContext
+	}
+
+	token, err := svc.ds.APIToken(id)
+	if err != nil {
+		return errors.Wrap(err, "retrieving api token")
+	}
+	if token.UserID != vc.UserID() && !vc.CanPerformAdminActions() {
+		return permissionError{message: "cannot delete api tokens of other users"}
+	}
+	return svc.ds.DeleteAPIToken(id)
+}
+
+func (svc service) GetAPITokenByKey(ctx context.Context, key string) (*kolide.APIToken, error) {
+	return svc.ds.APITokenByKey(key)
+}
+
+// generateAPITokenJWT signs a bearer token for an API token. The claim
+// differs from that of session tokens so that the two cannot be confused.
+func generateAPITokenJWT(apiTokenKey, jwtKey string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"api_token_key": apiTokenKey,
+	})
+
+	return token.SignedString([]byte(jwtKey))
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kolide/fleet/server/contexts/token"
+	"github.com/kolide/fleet/server/contexts/viewer"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAPIToken(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	var created *kolide.APIToken
+	ds.NewAPITokenFunc = func(apiToken *kolide.APIToken) (*kolide.APIToken, error) {
+		apiToken.ID = 1
+		created = apiToken
+		return apiToken, nil
+	}
+	ds.APITokenByKeyFunc = func(key string) (*kolide.APIToken, error) {
+		if created == nil || key != created.Key {
+			return nil, &mock.Error{Message: "not found"}
+		}
+		return created, nil
+	}
+	ds.UserByIDFunc = func(id uint) (*kolide.User, error) {
+		return &kolide.User{ID: id, Username: "admin", Admin: true, Enabled: true}, nil
+	}
+
+	admin := &kolide.User{ID: 3, Username: "admin", Admin: true, Enabled: true}
+	ctx := viewer.NewContext(context.Background(), viewer.Viewer{
+		User:    admin,
+		Session: &kolide.Session{ID: 1, UserID: admin.ID},
+	})
+
+	_, _, err = svc.CreateAPIToken(ctx, "automation", nil)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "scope")
+	_, _, err = svc.CreateAPIToken(ctx, "automation", []string{"hosts:delete"})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "hosts:delete")
+
+	apiToken, bearer, err := svc.CreateAPIToken(ctx, "automation", []string{
+		kolide.ScopeHostsRead, kolide.ScopeQueriesRun, kolide.ScopeHostsRead,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, admin.ID, apiToken.UserID)
+	assert.Equal(t, "automation", apiToken.Name)
+	assert.Equal(t, kolide.Scopes{kolide.ScopeHostsRead, kolide.ScopeQueriesRun}, apiToken.Scopes)
+	assert.NotEmpty(t, apiToken.Key)
+	assert.NotContains(t, bearer, apiToken.Key)
+
+	// The bearer token authenticates as the owner, restricted to the
+	// token's scopes
+	vc, err := authViewer(context.Background(), "CHANGEME", token.Token(bearer), svc)
+	require.Nil(t, err)
+	assert.Equal(t, admin.ID, vc.UserID())
+	assert.Nil(t, vc.Session)
+	require.NotNil(t, vc.APIToken)
+	assert.Equal(t, uint(1), vc.APIToken.ID)
+	assert.True(t, vc.CanPerformAdminActions())
+
+	// A token can only mint tokens with a subset of its own scopes
+	tokenCtx := viewer.NewContext(context.Background(), *vc)
+	_, _, err = svc.CreateAPIToken(tokenCtx, "narrower", []string{kolide.ScopeHostsRead})
+	require.Nil(t, err)
+	_, _, err = svc.CreateAPIToken(tokenCtx, "broader", []string{kolide.ScopeHostsRead, kolide.ScopeHostsWrite})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), kolide.ScopeHostsWrite)
+}
+
+func TestDeleteAPIToken(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ds.APITokenFunc = func(id uint) (*kolide.APIToken, error) {
+		if id != 1 {
+			return nil, &mock.Error{Message: "not found"}
+		}
+		return &kolide.APIToken{ID: 1, UserID: 3}, nil
+	}
+	ds.DeleteAPITokenFunc = func(id uint) error {
+		return nil
+	}
+
+	viewerCtx := func(user *kolide.User) context.Context {
+		return viewer.NewContext(context.Background(), viewer.Viewer{
+			User:    user,
+			Session: &kolide.Session{ID: 1, UserID: user.ID},
+		})
+	}
+	owner := viewerCtx(&kolide.User{ID: 3, Enabled: true})
+	other := viewerCtx(&kolide.User{ID: 4, Enabled: true})
+	admin := viewerCtx(&kolide.User{ID: 5, Enabled: true, Admin: true})
+
+	err = svc.DeleteAPIToken(other, 1)
+	require.NotNil(t, err)
+	assert.IsType(t, permissionError{}, err)
+	assert.False(t, ds.DeleteAPITokenFuncInvoked)
+
+	require.Nil(t, svc.DeleteAPIToken(owner, 1))
+	require.Nil(t, svc.DeleteAPIToken(admin, 1))
+
+	err = svc.DeleteAPIToken(admin, 2)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
+
+func TestScopedUser(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ds.APITokenByKeyFunc = func(key string) (*kolide.APIToken, error) {
+		return &kolide.APIToken{ID: 1, UserID: 3, Key: key, Scopes: kolide.Scopes{kolide.ScopeHostsRead}}, nil
+	}
+	ds.UserByIDFunc = func(id uint) (*kolide.User, error) {
+		return &kolide.User{ID: id, Enabled: true}, nil
+	}
+
+	bearer, err := generateAPITokenJWT("key", "CHANGEME")
+	require.Nil(t, err)
+	ctx := token.NewContext(context.Background(), token.Token(bearer))
+
+	_, err = scopedUser("CHANGEME", svc, kolide.ScopeHostsRead, endpoint.Nop)(ctx, struct{}{})
+	assert.Nil(t, err)
+	_, err = scopedUser("CHANGEME", svc, kolide.ScopeHostsWrite, endpoint.Nop)(ctx, struct{}{})
+	require.NotNil(t, err)
+	assert.IsType(t, permissionError{}, err)
+	_, err = authenticatedUser("CHANGEME", svc, endpoint.Nop)(ctx, struct{}{})
+	require.NotNil(t, err)
+	assert.IsType(t, permissionError{}, err)
+
+	// Scopes do not restrict sessions
+	sessionCtx := viewer.NewContext(context.Background(), viewer.Viewer{
+		User:    &kolide.User{ID: 3, Enabled: true},
+		Session: &kolide.Session{ID: 1, UserID: 3},
+	})
+	_, err = scopedUser("CHANGEME", svc, kolide.ScopeHostsWrite, endpoint.Nop)(sessionCtx, struct{}{})
+	assert.Nil(t, err)
+}
@@ -14,6 +14,7 @@ import (
 
 // mailError is set when an error performing mail operations
 type mailError struct {
+	name    string
 	message string
 }
 
@@ -22,14 +23,28 @@ func (e mailError) Error() string {
 }
 
 func (e mailError) MailError() []map[string]string {
+	name := e.name
+	if name == "" {
+		name = "base"
+	}
 	return []map[string]string{
 		map[string]string{
-			"name":   "base",
+			"name":   name,
 			"reason": e.message,
 		},
 	}
 }
 
+// newMailError creates a mailError, naming the failed SMTP stage when it is
+// known.
+func newMailError(err error) mailError {
+	me := mailError{message: err.Error()}
+	if e, ok := errors.Cause(err).(*mail.Error); ok {
+		me.name = e.Kind
+	}
+	return me
+}
+
 func (svc service) NewAppConfig(ctx context.Context, p kolide.AppConfigPayload) (*kolide.AppConfig, error) {
 	config, err := svc.ds.AppConfig()
 	if err != nil {
@@ -85,12 +100,28 @@ func (svc service) SendTestEmail(ctx context.Context, config *kolide.AppConfig)
 	}
 
 	if err := mail.Test(svc.mailService, testMail); err != nil {
-		return mailError{message: err.Error()}
+		return newMailError(err)
 	}
 	return nil
 
 }
 
+func (svc service) TestSMTPSettings(ctx context.Context, settings kolide.SMTPSettingsPayload, sendTestEmail bool) error {
+	existing, err := svc.ds.AppConfig()
+	if err != nil {
+		return err
+	}
+	config := appConfigFromAppConfigPayload(kolide.AppConfigPayload{SMTPSettings: &settings}, *existing)
+
+	if sendTestEmail {
+		return svc.SendTestEmail(ctx, config)
+	}
+	if err := mail.TestConnection(svc.mailService, config); err != nil {
+		return newMailError(err)
+	}
+	return nil
+}
+
 func (svc service) ModifyAppConfig(ctx context.Context, p kolide.AppConfigPayload) (*kolide.AppConfig, error) {
 	oldAppConfig, err := svc.AppConfig(ctx)
 	if err != nil {
@@ -113,6 +144,9 @@ func (svc service) ModifyAppConfig(ctx context.Context, p kolide.AppConfigPayloa
 	if err := svc.ds.SaveAppConfig(config); err != nil {
 		return nil, err
 	}
+	if svc.sessionTimeouts != nil {
+		svc.sessionTimeouts.invalidate()
+	}
 	return config, nil
 }
 
@@ -171,6 +205,61 @@ func appConfigFromAppConfigPayload(p kolide.AppConfigPayload, config kolide.AppC
 		if settings.AdditionalQueries != nil {
 			config.AdditionalQueries = settings.AdditionalQueries
 		}
+		if settings.RequiredOsqueryVersion != nil {
+			config.RequiredOsqueryVersion = strings.TrimSpace(*settings.RequiredOsqueryVersion)
+		}
+	}
+
+	if settings := p.WebhookSettings; settings != nil {
+		if settings.EnrollmentWebhookURL != nil {
+			config.EnrollmentWebhookURL = strings.TrimSpace(*settings.EnrollmentWebhookURL)
+		}
+		if settings.EnrollmentWebhookSecret != nil && *settings.EnrollmentWebhookSecret != "********" {
+			config.EnrollmentWebhookSecret = *settings.EnrollmentWebhookSecret
+		}
+		if settings.CampaignWebhookURL != nil {
+			config.CampaignWebhookURL = strings.TrimSpace(*settings.CampaignWebhookURL)
+		}
+		if settings.CampaignWebhookSecret != nil && *settings.CampaignWebhookSecret != "********" {
+			config.CampaignWebhookSecret = *settings.CampaignWebhookSecret
+		}
+		if settings.CampaignWebhookRequireResults != nil {
+			config.CampaignWebhookRequireResults = *settings.CampaignWebhookRequireResults
+		}
+	}
+
+	if settings := p.PasswordPolicySettings; settings != nil {
+		if settings.MinLength != nil {
+			config.PasswordMinLength = *settings.MinLength
+		}
+		if settings.RequireNumber != nil {
+			config.PasswordRequireNumber = *settings.RequireNumber
+		}
+		if settings.RequireSymbol != nil {
+			config.PasswordRequireSymbol = *settings.RequireSymbol
+		}
+		if settings.RequireMixedCase != nil {
+			config.PasswordRequireMixedCase = *settings.RequireMixedCase
+		}
+	}
+
+	if settings := p.SessionSettings; settings != nil {
+		if settings.SessionDuration != nil {
+			config.SessionDuration = *settings.SessionDuration
+		}
+		if settings.SessionIdleTimeout != nil {
+			config.SessionIdleTimeout = *settings.SessionIdleTimeout
+		}
+	}
+
+	if settings := p.QuerySettings; settings != nil && settings.DenyList != nil {
+		config.QueryDenyList = kolide.QueryDenyList{}
+		for _, pattern := range *settings.DenyList {
+			if pattern.Pattern == "" {
+				continue
+			}
+			config.QueryDenyList = append(config.QueryDenyList, pattern)
+		}
 	}
 
 	populateSMTP := func(p *kolide.SMTPSettingsPayload) {
@@ -247,3 +336,7 @@ func (svc service) ApplyEnrollSecretSpec(ctx context.Context, spec *kolide.Enrol
 func (svc service) GetEnrollSecretSpec(ctx context.Context) (*kolide.EnrollSecretSpec, error) {
 	return svc.ds.GetEnrollSecretSpec()
 }
+
+func (svc service) EnrollSecretUsage(ctx context.Context) ([]kolide.EnrollSecretUsage, error) {
+	return svc.ds.EnrollSecretUsage()
+}
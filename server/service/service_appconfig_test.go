@@ -5,7 +5,9 @@ import (
 	"testing"
 
 	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/mail"
 	"github.com/kolide/fleet/server/mock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -84,3 +86,29 @@ func TestCreateAppConfig(t *testing.T) {
 		assert.Len(t, gotSecretSpec.Secrets[0].Secret, 32)
 	}
 }
+
+func TestTestSMTPSettingsDoesNotSave(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{SMTPServer: "mail.example.com"}, nil
+	}
+
+	port := uint(2525)
+	err = svc.TestSMTPSettings(context.Background(), kolide.SMTPSettingsPayload{SMTPPort: &port}, false)
+	require.Nil(t, err)
+	assert.True(t, ds.AppConfigFuncInvoked)
+	assert.False(t, ds.SaveAppConfigFuncInvoked)
+}
+
+func TestNewMailError(t *testing.T) {
+	err := newMailError(errors.Wrap(&mail.Error{Kind: mail.ErrorKindTLS, Err: errors.New("bad certificate")}, "sending mail"))
+	assert.Equal(t, []map[string]string{
+		{"name": "tls", "reason": "sending mail: smtp tls error: bad certificate"},
+	}, err.MailError())
+
+	err = newMailError(errors.New("failed to get message body"))
+	assert.Equal(t, "base", err.MailError()[0]["name"])
+}
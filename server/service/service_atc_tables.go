@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
+)
+
+func (svc service) ListATCTables(ctx context.Context) ([]*kolide.ATCTable, error) {
+	return svc.ds.ListATCTables()
+}
+
+func (svc service) GetATCTable(ctx context.Context, id uint) (*kolide.ATCTable, error) {
+	return svc.ds.ATCTable(id)
+}
+
+func (svc service) NewATCTable(ctx context.Context, payload kolide.ATCTablePayload) (*kolide.ATCTable, error) {
+	var table kolide.ATCTable
+	applyATCTablePayload(&table, payload)
+	if err := validateATCTable(&table); err != nil {
+		return nil, err
+	}
+	return svc.ds.NewATCTable(&table)
+}
+
+func (svc service) ModifyATCTable(ctx context.Context, id uint, payload kolide.ATCTablePayload) (*kolide.ATCTable, error) {
+	table, err := svc.ds.ATCTable(id)
+	if err != nil {
+		return nil, err
+	}
+	applyATCTablePayload(table, payload)
+	if err := validateATCTable(table); err != nil {
+		return nil, err
+	}
+	if err := svc.ds.SaveATCTable(table); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+func (svc service) DeleteATCTable(ctx context.Context, id uint) error {
+	return svc.ds.DeleteATCTable(id)
+}
+
+func applyATCTablePayload(table *kolide.ATCTable, payload kolide.ATCTablePayload) {
+	if payload.Name != nil {
+		table.Name = *payload.Name
+	}
+	if payload.Path != nil {
+		table.Path = *payload.Path
+	}
+	if payload.Query != nil {
+		table.Query = *payload.Query
+	}
+	if payload.Columns != nil {
+		table.Columns = *payload.Columns
+	}
+	if payload.Platform != nil {
+		table.Platform = *payload.Platform
+	}
+}
+
+var (
+	atcIdentifierRegexp  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	atcWindowsPathRegexp = regexp.MustCompile(`^[A-Za-z]:\\`)
+)
+
+// atcPlatforms are the osquery platforms that an ATC table can be limited
+// to.
+var atcPlatforms = map[string]bool{
+	"":        true,
+	"darwin":  true,
+	"linux":   true,
+	"windows": true,
+	"freebsd": true,
+	"posix":   true,
+}
+
+// validateATCTable returns an invalidArgumentError describing every problem
+// with table, so that osquery is never sent a table it cannot construct.
+func validateATCTable(table *kolide.ATCTable) error {
+	invalid := &invalidArgumentError{}
+	if !atcIdentifierRegexp.MatchString(table.Name) {
+		invalid.Append("name", "must start with a letter or underscore, and contain only letters, digits and underscores")
+	}
+	if err := validateATCPath(table.Path); err != nil {
+		invalid.Append("path", err.Error())
+	}
+	if strings.TrimSpace(table.Query) == "" {
+		invalid.Append("query", "required")
+	}
+	if len(table.Columns) == 0 {
+		invalid.Append("columns", "at least one column is required")
+	}
+	seen := map[string]bool{}
+	for _, column := range table.Columns {
+		if !atcIdentifierRegexp.MatchString(column) {
+			invalid.Append("columns", fmt.Sprintf("'%s' is not a valid column name", column))
+			continue
+		}
+		if seen[strings.ToLower(column)] {
+			invalid.Append("columns", fmt.Sprintf("'%s' is defined more than once", column))
+		}
+		seen[strings.ToLower(column)] = true
+	}
+	if !atcPlatforms[table.Platform] {
+		invalid.Append("platform", "must be one of darwin, linux, windows, freebsd or posix")
+	}
+	if invalid.HasErrors() {
+		return invalid
+	}
+	return nil
+}
+
+// validateATCPath checks that path is an absolute path to a SQLite database
+// on hosts, in either POSIX or Windows form.
+func validateATCPath(path string) error {
+	if path == "" {
+		return errors.New("required")
+	}
+	if strings.ContainsAny(path, "\x00\n\r") {
+		return errors.New("must not contain null or newline characters")
+	}
+	windows := atcWindowsPathRegexp.MatchString(path)
+	if !strings.HasPrefix(path, "/") && !windows {
+		return errors.New("must be an absolute path")
+	}
+	separator := "/"
+	if windows {
+		separator = `\`
+	}
+	for _, element := range strings.Split(path, separator) {
+		if element == ".." {
+			return errors.New("must not contain '..' elements")
+		}
+	}
+	if strings.HasSuffix(path, separator) {
+		return errors.New("must be the path of a database file")
+	}
+	return nil
+}
+
+// mergeATCTables adds the ATC tables to the auto_table_construction section
+// of config. Tables defined in the osquery options are kept, unless a table of
+// the same name is defined by an admin.
+func mergeATCTables(config map[string]interface{}, tables []*kolide.ATCTable) error {
+	atc := map[string]interface{}{}
+	if section, ok := config["auto_table_construction"]; ok && section != nil {
+		existing, ok := section.(map[string]interface{})
+		if !ok {
+			return errors.New("auto_table_construction must be an object")
+		}
+		for name, table := range existing {
+			atc[name] = table
+		}
+	}
+	for _, table := range tables {
+		atc[table.Name] = kolide.ATCTableConfig{
+			Query:    table.Query,
+			Path:     table.Path,
+			Columns:  table.Columns,
+			Platform: table.Platform,
+		}
+	}
+	config["auto_table_construction"] = atc
+	return nil
+}
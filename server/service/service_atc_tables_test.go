@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/datastore/inmem"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestATCTables(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+	ctx := context.Background()
+
+	columns := kolide.ATCColumns{"title", "url"}
+	table, err := svc.NewATCTable(ctx, kolide.ATCTablePayload{
+		Name:     stringPtr("chrome_bookmarks"),
+		Path:     stringPtr("/Users/%/Library/Application Support/Google/Chrome/Default/Favicons"),
+		Query:    stringPtr("SELECT title, url FROM bookmarks"),
+		Columns:  &columns,
+		Platform: stringPtr("darwin"),
+	})
+	require.Nil(t, err)
+	assert.NotZero(t, table.ID)
+
+	columns = kolide.ATCColumns{"title", "url", "visits"}
+	table, err = svc.ModifyATCTable(ctx, table.ID, kolide.ATCTablePayload{
+		Query:   stringPtr("SELECT title, url, visits FROM bookmarks"),
+		Columns: &columns,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, "chrome_bookmarks", table.Name)
+	assert.Equal(t, kolide.ATCColumns{"title", "url", "visits"}, table.Columns)
+
+	// Modifications are validated against the whole table
+	_, err = svc.ModifyATCTable(ctx, table.ID, kolide.ATCTablePayload{Path: stringPtr("relative.db")})
+	require.NotNil(t, err)
+	assert.IsType(t, &invalidArgumentError{}, err)
+
+	got, err := svc.GetATCTable(ctx, table.ID)
+	require.Nil(t, err)
+	assert.Equal(t, table.Path, got.Path)
+
+	tables, err := svc.ListATCTables(ctx)
+	require.Nil(t, err)
+	require.Len(t, tables, 1)
+
+	require.Nil(t, svc.DeleteATCTable(ctx, table.ID))
+	tables, err = svc.ListATCTables(ctx)
+	require.Nil(t, err)
+	assert.Empty(t, tables)
+}
+
+func TestValidateATCTable(t *testing.T) {
+	valid := func() *kolide.ATCTable {
+		return &kolide.ATCTable{
+			Name:    "app_events",
+			Path:    "/var/lib/app/events.db",
+			Query:   "SELECT id, name FROM events",
+			Columns: kolide.ATCColumns{"id", "name"},
+		}
+	}
+	assert.Nil(t, validateATCTable(valid()))
+
+	windows := valid()
+	windows.Path = `C:\ProgramData\App\events.db`
+	windows.Platform = "windows"
+	assert.Nil(t, validateATCTable(windows))
+
+	var invalidTests = []struct {
+		modify func(table *kolide.ATCTable)
+		field  string
+	}{
+		{func(table *kolide.ATCTable) { table.Name = "app-events" }, "name"},
+		{func(table *kolide.ATCTable) { table.Name = "" }, "name"},
+		{func(table *kolide.ATCTable) { table.Path = "" }, "path"},
+		{func(table *kolide.ATCTable) { table.Path = "events.db" }, "path"},
+		{func(table *kolide.ATCTable) { table.Path = "/var/lib/../../etc/shadow" }, "path"},
+		{func(table *kolide.ATCTable) { table.Path = "/var/lib/app/" }, "path"},
+		{func(table *kolide.ATCTable) { table.Path = "/var/lib/app\nevents.db" }, "path"},
+		{func(table *kolide.ATCTable) { table.Query = " " }, "query"},
+		{func(table *kolide.ATCTable) { table.Columns = nil }, "columns"},
+		{func(table *kolide.ATCTable) { table.Columns = kolide.ATCColumns{"id", "ID"} }, "columns"},
+		{func(table *kolide.ATCTable) { table.Columns = kolide.ATCColumns{"id", "first name"} }, "columns"},
+		{func(table *kolide.ATCTable) { table.Platform = "ubuntu" }, "platform"},
+	}
+	for _, tt := range invalidTests {
+		table := valid()
+		tt.modify(table)
+		err := validateATCTable(table)
+		require.NotNil(t, err, "%+v", table)
+		invalid, ok := err.(*invalidArgumentError)
+		require.True(t, ok)
+		require.Len(t, *invalid, 1)
+		assert.Equal(t, tt.field, (*invalid)[0].name)
+	}
+}
+
+func TestMergeATCTables(t *testing.T) {
+	config := map[string]interface{}{
+		"auto_table_construction": map[string]interface{}{
+			"from_options": map[string]interface{}{"query": "SELECT 1", "path": "/a.db", "columns": []interface{}{"a"}},
+			"replaced":     map[string]interface{}{"query": "SELECT 2", "path": "/b.db", "columns": []interface{}{"b"}},
+		},
+	}
+	err := mergeATCTables(config, []*kolide.ATCTable{
+		{Name: "replaced", Query: "SELECT c FROM t", Path: "/c.db", Columns: kolide.ATCColumns{"c"}},
+	})
+	require.Nil(t, err)
+	atc := config["auto_table_construction"].(map[string]interface{})
+	assert.Len(t, atc, 2)
+	assert.Contains(t, atc, "from_options")
+	assert.Equal(t, kolide.ATCTableConfig{Query: "SELECT c FROM t", Path: "/c.db", Columns: []string{"c"}}, atc["replaced"])
+
+	config = map[string]interface{}{"auto_table_construction": "invalid"}
+	assert.NotNil(t, mergeATCTables(config, []*kolide.ATCTable{{Name: "a"}}))
+}
@@ -2,16 +2,19 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/kolide/fleet/server/contexts/viewer"
 	"github.com/kolide/fleet/server/kolide"
-	"github.com/kolide/fleet/server/websocket"
 	"github.com/pkg/errors"
 )
 
-func (svc service) NewDistributedQueryCampaignByNames(ctx context.Context, queryString string, hosts []string, labels []string) (*kolide.DistributedQueryCampaign, error) {
+func (svc service) NewDistributedQueryCampaignByNames(ctx context.Context, queryString string, hosts []string, labels []string, executionTimeout uint, allowResubmission bool) (*kolide.DistributedQueryCampaign, error) {
 	hostIDs, err := svc.ds.HostIDsByName(hosts)
 	if err != nil {
 		return nil, errors.Wrap(err, "finding host IDs")
@@ -22,14 +25,14 @@ func (svc service) NewDistributedQueryCampaignByNames(ctx context.Context, query
 		return nil, errors.Wrap(err, "finding label IDs")
 	}
 
-	return svc.NewDistributedQueryCampaign(ctx, queryString, hostIDs, labelIDs)
+	return svc.NewDistributedQueryCampaign(ctx, queryString, hostIDs, labelIDs, executionTimeout, allowResubmission)
 }
 
 func uintPtr(n uint) *uint {
 	return &n
 }
 
-func (svc service) NewDistributedQueryCampaign(ctx context.Context, queryString string, hosts []uint, labels []uint) (*kolide.DistributedQueryCampaign, error) {
+func (svc service) NewDistributedQueryCampaign(ctx context.Context, queryString string, hosts []uint, labels []uint, executionTimeout uint, allowResubmission bool) (*kolide.DistributedQueryCampaign, error) {
 	if err := svc.StatusLiveQuery(ctx); err != nil {
 		return nil, err
 	}
@@ -39,6 +42,10 @@ func (svc service) NewDistributedQueryCampaign(ctx context.Context, queryString
 		return nil, errNoContext
 	}
 
+	if err := svc.checkQueryDenyList(queryString); err != nil {
+		return nil, err
+	}
+
 	query, err := svc.ds.NewQuery(&kolide.Query{
 		Name:     fmt.Sprintf("distributed_%s_%d", vc.Username(), time.Now().Unix()),
 		Query:    queryString,
@@ -49,10 +56,291 @@ func (svc service) NewDistributedQueryCampaign(ctx context.Context, queryString
 		return nil, errors.Wrap(err, "new query")
 	}
 
+	return svc.newCampaign(vc.UserID(), query.ID, kolide.QueryWaiting, hosts, labels, executionTimeout, allowResubmission)
+}
+
+func (svc service) NewSavedQueryCampaign(ctx context.Context, queryID uint, hosts []uint, labels []uint, executionTimeout uint, allowResubmission bool) (*kolide.DistributedQueryCampaign, error) {
+	if err := svc.StatusLiveQuery(ctx); err != nil {
+		return nil, err
+	}
+
+	vc, ok := viewer.FromContext(ctx)
+	if !ok {
+		return nil, errNoContext
+	}
+
+	query, err := svc.ds.Query(queryID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get query")
+	}
+	if !query.Saved {
+		return nil, newInvalidArgumentError("query_id", "must be the ID of a saved query")
+	}
+	// Patterns may have been added since the query was saved
+	if err := svc.checkQueryDenyList(query.Query); err != nil {
+		return nil, err
+	}
+
+	if query.CacheTTL == 0 {
+		return svc.newCampaign(vc.UserID(), query.ID, kolide.QueryWaiting, hosts, labels, executionTimeout, allowResubmission)
+	}
+
+	// The targets are deduplicated as they are when the campaign is created,
+	// so that the key matches the one its results are cached under
+	hosts, labels = uniqueIDs(hosts), uniqueIDs(labels)
+	results, ok, err := svc.resultsCache.Get(resultsCacheKey(query, hosts, labels))
+	if err != nil {
+		return nil, errors.Wrap(err, "get cached results")
+	}
+	if !ok {
+		return svc.newCampaign(vc.UserID(), query.ID, kolide.QueryWaiting, hosts, labels, executionTimeout, allowResubmission)
+	}
+
+	// The campaign is recorded as complete so that the query is not sent to
+	// any hosts.
+	campaign, err := svc.newCampaign(vc.UserID(), query.ID, kolide.QueryComplete, hosts, labels, executionTimeout, allowResubmission)
+	if err != nil {
+		return nil, err
+	}
+	campaign.CachedResults = results
+	return campaign, nil
+}
+
+func (svc service) NewSavedQueryCampaignByNames(ctx context.Context, queryName string, hosts []string, labels []string, executionTimeout uint, allowResubmission bool) (*kolide.DistributedQueryCampaign, error) {
+	invalid := &invalidArgumentError{}
+
+	var queryID uint
+	query, err := svc.ds.QueryByName(queryName)
+	switch {
+	case kolide.IsNotFound(err):
+		invalid.Appendf("query_name", "no saved query named '%s'", queryName)
+	case err != nil:
+		return nil, errors.Wrapf(err, "looking up query '%s'", queryName)
+	case !query.Saved:
+		invalid.Appendf("query_name", "'%s' is not a saved query", queryName)
+	default:
+		queryID = query.ID
+	}
+
+	// Names are resolved one at a time so that the names that do not
+	// match are known
+	var hostIDs, labelIDs []uint
+	for _, name := range hosts {
+		ids, err := svc.ds.HostIDsByName([]string{name})
+		if err != nil {
+			return nil, errors.Wrapf(err, "looking up host '%s'", name)
+		}
+		if len(ids) == 0 {
+			invalid.Appendf("hosts", "no host named '%s'", name)
+		}
+		hostIDs = append(hostIDs, ids...)
+	}
+	for _, name := range labels {
+		ids, err := svc.ds.LabelIDsByName([]string{name})
+		if err != nil {
+			return nil, errors.Wrapf(err, "looking up label '%s'", name)
+		}
+		if len(ids) == 0 {
+			invalid.Appendf("labels", "no label named '%s'", name)
+		}
+		labelIDs = append(labelIDs, ids...)
+	}
+	if invalid.HasErrors() {
+		return nil, invalid
+	}
+
+	return svc.NewSavedQueryCampaign(ctx, queryID, hostIDs, labelIDs, executionTimeout, allowResubmission)
+}
+
+func (svc service) DistributedQueryCampaignTargetsCount(ctx context.Context, hosts []uint, labels []uint) (int, []uint, error) {
+	hostIDs, err := svc.ds.HostIDsInTargets(uniqueIDs(hosts), uniqueIDs(labels))
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "resolving targets")
+	}
+	return len(hostIDs), hostIDs, nil
+}
+
+func (svc service) ListRunningCampaigns(ctx context.Context) ([]kolide.DistributedQueryCampaign, error) {
+	campaigns, err := svc.ds.ListActiveDistributedQueryCampaigns()
+	if err != nil {
+		return nil, errors.Wrap(err, "listing active campaigns")
+	}
+	running := make([]kolide.DistributedQueryCampaign, 0, len(campaigns))
+	for _, campaign := range campaigns {
+		running = append(running, *campaign)
+	}
+	return running, nil
+}
+
+func (svc service) StopCampaign(ctx context.Context, id uint) error {
+	vc, ok := viewer.FromContext(ctx)
+	if !ok {
+		return errNoContext
+	}
+
+	campaign, err := svc.ds.DistributedQueryCampaign(id)
+	if err != nil {
+		return errors.Wrap(err, "get campaign")
+	}
+	if campaign.UserID != vc.UserID() && !vc.CanPerformAdminActions() {
+		return permissionError{message: "only the creator of the campaign or an admin may stop it"}
+	}
+
+	// Completing the campaign stops the query from being sent to hosts.
+	// Streams in other processes notice on their next status update.
+	if err := svc.completeCampaign(id); err != nil {
+		return errors.Wrap(err, "completing campaign")
+	}
+	svc.campaignStreams.stop(id)
+	return nil
+}
+
+const (
+	// maxCostEstimateSampleHosts bounds the number of hosts a cost estimate
+	// runs the query against.
+	maxCostEstimateSampleHosts = 10
+	// costEstimateTimeout is how long a cost estimate waits for the sample
+	// hosts to respond. It is kept below the HTTP server's write timeout.
+	costEstimateTimeout = 30 * time.Second
+)
+
+func (svc service) EstimateQueryCost(ctx context.Context, sql string, sampleHostIDs []uint) (kolide.CostEstimate, error) {
+	sampleHostIDs = uniqueIDs(sampleHostIDs)
+	if len(sampleHostIDs) == 0 {
+		return kolide.CostEstimate{}, newInvalidArgumentError("sample_host_ids", "must include at least one host")
+	}
+	if len(sampleHostIDs) > maxCostEstimateSampleHosts {
+		return kolide.CostEstimate{}, newInvalidArgumentError("sample_host_ids",
+			fmt.Sprintf("must include at most %d hosts", maxCostEstimateSampleHosts))
+	}
+
+	totalHosts, err := svc.ds.CountHosts(kolide.HostListOptions{})
+	if err != nil {
+		return kolide.CostEstimate{}, errors.Wrap(err, "counting hosts")
+	}
+
+	campaign, err := svc.NewDistributedQueryCampaign(ctx, sql, sampleHostIDs, nil, uint(costEstimateTimeout/time.Second), false)
+	if err != nil {
+		return kolide.CostEstimate{}, err
+	}
+	// Completing the campaign stops the query from being sent to any
+	// sampled hosts that have not yet responded.
+	defer svc.completeCampaign(campaign.ID)
+
+	readCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	readChan, err := svc.resultStore.ReadChannel(readCtx, *campaign)
+	if err != nil {
+		return kolide.CostEstimate{}, errors.Wrap(err, "open read channel")
+	}
+
+	// Setting status to running causes the query to be sent to the sample
+	timeout := svc.clock.After(costEstimateTimeout)
+	campaign.Status = kolide.QueryRunning
+	if err := svc.ds.SaveDistributedQueryCampaign(campaign); err != nil {
+		return kolide.CostEstimate{}, errors.Wrap(err, "saving campaign state")
+	}
+
+	estimate := kolide.CostEstimate{
+		SampledHosts: uint(len(sampleHostIDs)),
+		TotalHosts:   uint(totalHosts),
+	}
+	var (
+		wallTime uint64
+		rows     uint64
+		size     uint64
+	)
+	// Only the online sample hosts are expected to respond in time
+wait:
+	for estimate.RespondedHosts < campaign.Metrics.OnlineHosts {
+		select {
+		case msg, ok := <-readChan:
+			if !ok {
+				// The read channel was closed; wait for ctx or the
+				// timeout instead
+				readChan = nil
+				continue
+			}
+			res, ok := msg.(kolide.DistributedQueryResult)
+			if !ok {
+				continue
+			}
+			estimate.RespondedHosts++
+			if res.Error != nil {
+				estimate.FailedHosts++
+				continue
+			}
+			if res.Stats != nil {
+				estimate.TimedHosts++
+				wallTime += res.Stats.WallTimeMs
+			}
+			rows += uint64(len(res.Rows))
+			if b, err := json.Marshal(res.Rows); err == nil {
+				size += uint64(len(b))
+			}
+		case <-timeout:
+			break wait
+		case <-ctx.Done():
+			return kolide.CostEstimate{}, ctx.Err()
+		}
+	}
+
+	succeeded := estimate.RespondedHosts - estimate.FailedHosts
+	if succeeded == 0 {
+		return estimate, nil
+	}
+	if estimate.TimedHosts > 0 {
+		estimate.AverageWallTime = float64(wallTime) / float64(estimate.TimedHosts)
+	}
+	estimate.AverageRows = float64(rows) / float64(succeeded)
+	estimate.AverageResultSize = float64(size) / float64(succeeded)
+	estimate.EstimatedWallTime = estimate.AverageWallTime * float64(totalHosts)
+	estimate.EstimatedRows = uint64(estimate.AverageRows * float64(totalHosts))
+	estimate.EstimatedResultSize = uint64(estimate.AverageResultSize * float64(totalHosts))
+	return estimate, nil
+}
+
+// resultsCacheKey returns the key under which the results of running the
+// saved query against the given targets are cached. The key includes the
+// query text and the sorted targets, so changing either of them invalidates
+// previously cached results.
+func resultsCacheKey(query *kolide.Query, hosts []uint, labels []uint) string {
+	sortedIDs := func(ids []uint) []uint {
+		sorted := append([]uint{}, ids...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		return sorted
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d\n%s\n%v\n%v",
+		query.ID, query.Query, sortedIDs(hosts), sortedIDs(labels))))
+	return hex.EncodeToString(sum[:])
+}
+
+// uniqueIDs returns ids with duplicates removed, preserving the order in
+// which the IDs first appear.
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := []uint{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// newCampaign creates a campaign for the query with the given host and label
+// targets. Hosts may be targeted both explicitly by ID and through their
+// labels; such hosts are counted and sent the query only once.
+func (svc service) newCampaign(userID, queryID uint, status kolide.DistributedQueryStatus, hosts []uint, labels []uint, executionTimeout uint, allowResubmission bool) (*kolide.DistributedQueryCampaign, error) {
+	hosts, labels = uniqueIDs(hosts), uniqueIDs(labels)
+
 	campaign, err := svc.ds.NewDistributedQueryCampaign(&kolide.DistributedQueryCampaign{
-		QueryID: query.ID,
-		Status:  kolide.QueryWaiting,
-		UserID:  vc.UserID(),
+		QueryID:           queryID,
+		Status:            status,
+		UserID:            userID,
+		ExecutionTimeout:  executionTimeout,
+		AllowResubmission: allowResubmission,
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "new campaign")
@@ -106,7 +394,110 @@ type campaignStatus struct {
 	Status          string `json:"status"`
 }
 
-func (svc service) StreamCampaignResults(ctx context.Context, conn *websocket.Conn, campaignID uint) {
+// campaignComplete is sent to a resuming client once all results of a
+// completed campaign have been replayed. No further messages will follow.
+type campaignComplete struct {
+	LastSequence uint64 `json:"last_sequence"`
+}
+
+// mapHostnameRows adds the "host_hostname" field to every row. This improves
+// performance of the frontend rendering the results table.
+func mapHostnameRows(hostname string, rows []map[string]string) {
+	for _, row := range rows {
+		row["host_hostname"] = hostname
+	}
+}
+
+// replayCompletedCampaign sends a resuming client the retained results it
+// missed from a campaign that has already completed, followed by a complete
+// message. If aggregate is true, the aggregate of every retained result is
+// sent before the complete message.
+func (svc service) replayCompletedCampaign(conn kolide.CampaignResultsWriter, campaignID uint, lastSequence uint64, aggregate bool) {
+	after := lastSequence
+	if aggregate {
+		after = 0
+	}
+	replayed, err := svc.resultStore.ReplayResults(campaignID, after)
+	if err != nil {
+		conn.WriteJSONError(fmt.Sprintf("cannot replay results for campaign %d", campaignID))
+		return
+	}
+
+	agg := newCampaignAggregate()
+	for _, res := range replayed {
+		agg.add(res)
+		if res.Sequence <= lastSequence {
+			continue
+		}
+		mapHostnameRows(res.Host.HostName, res.Rows)
+		if err := conn.WriteJSONMessage("result", res); err != nil {
+			svc.logger.Log("msg", "error writing to channel", "err", err)
+			return
+		}
+		lastSequence = res.Sequence
+	}
+
+	if aggregate {
+		if err := conn.WriteJSONMessage("aggregate", aggregateUpdate{Rows: agg.all()}); err != nil {
+			svc.logger.Log("msg", "error writing to channel", "err", err)
+			return
+		}
+	}
+	if err := conn.WriteJSONMessage("complete", campaignComplete{LastSequence: lastSequence}); err != nil {
+		svc.logger.Log("msg", "error writing to channel", "err", err)
+	}
+}
+
+// campaignResultsCacheKey returns the results cache key and TTL for the
+// campaign, or an empty key if the campaign's results should not be cached.
+func (svc service) campaignResultsCacheKey(campaign *kolide.DistributedQueryCampaign) (string, time.Duration) {
+	query, err := svc.ds.Query(campaign.QueryID)
+	if err != nil || !query.Saved || query.CacheTTL == 0 {
+		return "", 0
+	}
+	hostIDs, labelIDs, err := svc.ds.DistributedQueryCampaignTargetIDs(campaign.ID)
+	if err != nil {
+		return "", 0
+	}
+	return resultsCacheKey(query, hostIDs, labelIDs), time.Duration(query.CacheTTL) * time.Second
+}
+
+// sessionRevoked returns true if the session of the viewer in ctx no longer
+// exists. Long lived connections use this to end streams for sessions that
+// were deleted after the connection was authenticated.
+func (svc service) sessionRevoked(ctx context.Context) bool {
+	vc, ok := viewer.FromContext(ctx)
+	if !ok || vc.Session == nil {
+		return false
+	}
+	_, err := svc.ds.SessionByID(vc.SessionID())
+	return kolide.IsNotFound(err)
+}
+
+// serverShuttingDown is sent to subscribers of campaigns that are stopped
+// because the server is shutting down.
+const serverShuttingDown = "server shutting down"
+
+// campaignResumeWindow is how long a campaign keeps running after every
+// client streaming it disconnected, so that a client can resume the stream
+// from the last sequence it received.
+const campaignResumeWindow = 5 * time.Minute
+
+func (svc service) DrainCampaigns(ctx context.Context) error {
+	return svc.campaignStreams.drain(ctx)
+}
+
+func (svc service) StreamCampaignResults(ctx context.Context, conn kolide.CampaignResultsWriter, campaignID uint, lastSequence *uint64, aggregate bool) {
+	// Register the stream so that it can be drained on shutdown. Once
+	// draining has begun no new streams are started.
+	if !svc.campaignStreams.add() {
+		conn.WriteJSONError(serverShuttingDown)
+		return
+	}
+	defer svc.campaignStreams.done()
+	streamCtx, cancel := context.WithCancel(svc.campaignStreams.ctx)
+	defer cancel()
+
 	// Find the campaign and ensure it is active
 	campaign, err := svc.ds.DistributedQueryCampaign(campaignID)
 	if err != nil {
@@ -114,46 +505,152 @@ func (svc service) StreamCampaignResults(ctx context.Context, conn *websocket.Co
 		return
 	}
 
-	if campaign.Status != kolide.QueryWaiting {
+	resuming := lastSequence != nil
+	switch {
+	case campaign.Status == kolide.QueryWaiting:
+	case resuming && campaign.Status == kolide.QueryRunning:
+	case resuming && campaign.Status == kolide.QueryComplete:
+		// The campaign finished while the client was disconnected, so
+		// send whatever it missed and let it know no more results are
+		// coming.
+		svc.replayCompletedCampaign(conn, campaignID, *lastSequence, aggregate)
+		return
+	default:
 		conn.WriteJSONError(fmt.Sprintf("campaign %d not running", campaignID))
 		return
 	}
 
 	// Setting status to running will cause the query to be returned to the
 	// targets when they check in for their queries
-	campaign.Status = kolide.QueryRunning
-	if err := svc.ds.SaveDistributedQueryCampaign(campaign); err != nil {
-		conn.WriteJSONError("error saving campaign state")
-		return
+	if campaign.Status == kolide.QueryWaiting {
+		campaign.Status = kolide.QueryRunning
+		if err := svc.ds.SaveDistributedQueryCampaign(campaign); err != nil {
+			conn.WriteJSONError("error saving campaign state")
+			return
+		}
+	}
+
+	// A resumed campaign is streaming again, so results submitted while
+	// nobody is subscribed no longer fall within the resume window.
+	if campaign.DisconnectedAt != nil {
+		if err := svc.ds.MarkDistributedQueryCampaignDisconnected(campaign.ID, nil); err != nil {
+			conn.WriteJSONError("error saving campaign state")
+			return
+		}
 	}
 
 	// Setting the status to completed stops the query from being sent to
 	// targets. If this fails, there is a background job that will clean up
-	// this campaign.
+	// this campaign. When the client disconnects, the campaign is only
+	// completed if no client resumes it within the resume window.
+	complete := false
 	defer func() {
-		campaign.Status = kolide.QueryComplete
-		svc.ds.SaveDistributedQueryCampaign(campaign)
+		if complete {
+			svc.completeCampaign(campaign.ID)
+			return
+		}
+		// The disconnect time lets results submitted while nobody is
+		// subscribed, possibly to another server, leave the campaign
+		// running until the resume window ends.
+		disconnectedAt := svc.clock.Now()
+		svc.ds.MarkDistributedQueryCampaignDisconnected(campaign.ID, &disconnectedAt)
+		svc.campaignStreams.abandon(campaign.ID, campaignResumeWindow, func() {
+			svc.completeCampaign(campaign.ID)
+		})
 	}()
 
+	// Campaigns stopped through StopCampaign end the stream
+	stopped, unwatch := svc.campaignStreams.watch(campaign.ID)
+	defer unwatch()
+
 	// Open the channel from which we will receive incoming query results
 	// (probably from the redis pubsub implementation)
-	readChan, err := svc.resultStore.ReadChannel(context.Background(), *campaign)
+	readChan, err := svc.resultStore.ReadChannel(streamCtx, *campaign)
 	if err != nil {
 		conn.WriteJSONError(fmt.Sprintf("cannot open read channel for campaign %d ", campaignID))
 		return
 	}
 
+	// Results of saved queries with a cache TTL are collected so that they
+	// can be cached once every expected host has responded. A resuming
+	// client has not seen every result, so its results are not cached.
+	var (
+		cacheKey  string
+		cacheTTL  time.Duration
+		collected []kolide.DistributedQueryResult
+	)
+	if !resuming {
+		cacheKey, cacheTTL = svc.campaignResultsCacheKey(campaign)
+	}
+
 	status := campaignStatus{
 		Status: campaignStatusPending,
 	}
 	lastStatus := status
 	lastTotals := targetTotals{}
 
-	// to improve performance of the frontend rendering the results table, we
-	// add the "host_hostname" field to every row.
-	mapHostnameRows := func(hostname string, rows []map[string]string) {
-		for _, row := range rows {
-			row["host_hostname"] = hostname
+	// cursor is the sequence number of the last result sent to the client.
+	// Sequence numbers count every result written for the campaign, so a
+	// resuming client has already received that many results.
+	var cursor uint64
+	if resuming {
+		cursor = *lastSequence
+		status.ActualResults = uint(cursor)
+	}
+
+	// Rows are aggregated before the hostname is added to them, as that
+	// would make the rows of every host distinct
+	var agg *campaignAggregate
+	if aggregate {
+		agg = newCampaignAggregate()
+	}
+
+	writeResult := func(res kolide.DistributedQueryResult) {
+		if agg != nil {
+			if changed := agg.add(res); len(changed) > 0 {
+				if err := conn.WriteJSONMessage("aggregate", aggregateUpdate{Rows: changed}); err != nil {
+					svc.logger.Log("msg", "error writing to channel", "err", err)
+				}
+			}
+		}
+		mapHostnameRows(res.Host.HostName, res.Rows)
+		err = conn.WriteJSONMessage("result", res)
+		if err != nil {
+			svc.logger.Log("msg", "error writing to channel", "err", err)
+		}
+		status.ActualResults++
+		cursor = res.Sequence
+		if cacheKey != "" {
+			collected = append(collected, res)
+		}
+	}
+
+	// Replay results that arrived while the client was disconnected. The
+	// read channel is opened first so that no results are lost between
+	// the replay and the live stream.
+	if resuming {
+		after := cursor
+		if agg != nil {
+			// The aggregate also counts the results the client
+			// received before disconnecting
+			after = 0
+		}
+		replayed, err := svc.resultStore.ReplayResults(campaign.ID, after)
+		if err != nil {
+			conn.WriteJSONError(fmt.Sprintf("cannot replay results for campaign %d", campaignID))
+			return
+		}
+		for _, res := range replayed {
+			if agg != nil && res.Sequence <= cursor {
+				agg.add(res)
+				continue
+			}
+			writeResult(res)
+		}
+		if agg != nil {
+			if err := conn.WriteJSONMessage("aggregate", aggregateUpdate{Rows: agg.all()}); err != nil {
+				svc.logger.Log("msg", "error writing to channel", "err", err)
+			}
 		}
 	}
 
@@ -187,6 +684,11 @@ func (svc service) StreamCampaignResults(ctx context.Context, conn *websocket.Co
 
 		status.ExpectedResults = totals.Online
 		if status.ActualResults >= status.ExpectedResults {
+			if status.Status != campaignStatusFinished && cacheKey != "" && status.ExpectedResults > 0 {
+				if err := svc.resultsCache.Set(cacheKey, collected, cacheTTL); err != nil {
+					svc.logger.Log("msg", "error caching campaign results", "err", err)
+				}
+			}
 			status.Status = campaignStatusFinished
 		}
 		// only write status message if status has changed
@@ -200,6 +702,20 @@ func (svc service) StreamCampaignResults(ctx context.Context, conn *websocket.Co
 		return nil
 	}
 
+	// campaignStopped returns true if the campaign was completed by
+	// another stream or server, such as when it was stopped.
+	campaignStopped := func() bool {
+		current, err := svc.ds.DistributedQueryCampaign(campaign.ID)
+		return err == nil && current.Status == kolide.QueryComplete
+	}
+
+	// writeStopped lets the client know that no more results are coming
+	writeStopped := func() {
+		if err := conn.WriteJSONMessage("complete", campaignComplete{LastSequence: cursor}); err != nil {
+			svc.logger.Log("msg", "error writing to channel", "err", err)
+		}
+	}
+
 	if err := updateStatus(); err != nil {
 		svc.logger.Log("msg", "error updating status", "err", err)
 		return
@@ -218,15 +734,46 @@ func (svc service) StreamCampaignResults(ctx context.Context, conn *websocket.Co
 			// Receive a result and push it over the websocket
 			switch res := res.(type) {
 			case kolide.DistributedQueryResult:
-				mapHostnameRows(res.Host.HostName, res.Rows)
-				err = conn.WriteJSONMessage("result", res)
-				if err != nil {
-					svc.logger.Log("msg", "error writing to channel", "err", err)
+				// Skip results already sent during replay
+				if resuming && res.Sequence <= cursor {
+					continue
 				}
-				status.ActualResults++
+				writeResult(res)
 			}
 
+		case <-stopped:
+			complete = true
+			writeStopped()
+			return
+
+		case <-ctx.Done():
+			// The client disconnected. Returning closes the read
+			// channel, unsubscribing from the result store, and
+			// leaves the campaign running so that it can be resumed.
+			return
+
+		case <-streamCtx.Done():
+			// The server is shutting down. The campaign is marked
+			// completed, so that it is not left running.
+			complete = true
+			conn.WriteJSONError(serverShuttingDown)
+			return
+
 		case <-ticker.C:
+			// Stop streaming once the viewer's session has been
+			// revoked, such as by deleting all sessions for the user
+			if svc.sessionRevoked(ctx) {
+				complete = true
+				conn.WriteJSONError("session revoked")
+				return
+			}
+
+			if campaignStopped() {
+				complete = true
+				writeStopped()
+				return
+			}
+
 			// Update status
 			if err := updateStatus(); err != nil {
 				svc.logger.Log("msg", "error updating status", "err", err)
@@ -236,3 +783,130 @@ func (svc service) StreamCampaignResults(ctx context.Context, conn *websocket.Co
 	}
 
 }
+
+// campaignReapInterval is how often completed campaigns are checked against
+// the campaign retention.
+const campaignReapInterval = time.Hour
+
+func (svc service) ReapCampaigns(ctx context.Context) {
+	ticker := svc.clock.NewTicker(campaignReapInterval)
+	defer ticker.Stop()
+
+	for {
+		svc.reapCampaigns()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+		}
+	}
+}
+
+// reapCampaigns purges the completed campaigns created before the campaign
+// retention. Their results are not stored by the datastore, and expire from
+// the result store on their own.
+func (svc service) reapCampaigns() {
+	before := svc.clock.Now().Add(-svc.config.App.CampaignRetention)
+	purged, err := svc.ds.PurgeCompletedCampaigns(before)
+	if err != nil {
+		svc.logger.Log("msg", "error purging completed campaigns", "err", err)
+		return
+	}
+	svc.logger.Log("msg", "purged completed campaigns", "count", purged)
+}
+
+// completeCampaign marks the campaign completed and, if this call completed
+// it, notifies the campaign webhook.
+func (svc service) completeCampaign(id uint) error {
+	completed, err := svc.ds.CompleteDistributedQueryCampaign(id)
+	if err != nil {
+		return err
+	}
+	if completed {
+		svc.sendCampaignWebhook(id, svc.clock.Now())
+	}
+	return nil
+}
+
+type campaignWebhookPayload struct {
+	CampaignID  uint                                   `json:"campaign_id"`
+	Query       campaignWebhookQuery                   `json:"query"`
+	Targets     campaignWebhookTargets                 `json:"targets"`
+	Results     kolide.DistributedQueryCampaignSummary `json:"results"`
+	CompletedAt time.Time                              `json:"completed_at"`
+}
+
+type campaignWebhookQuery struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+type campaignWebhookTargets struct {
+	HostIDs    []uint `json:"host_ids"`
+	LabelIDs   []uint `json:"label_ids"`
+	TotalHosts uint   `json:"total_hosts"`
+}
+
+// sendCampaignWebhook notifies the campaign webhook configured in the app
+// config, if any, that the campaign completed. Like the enrollment webhook,
+// delivery happens in the background and failures are only logged.
+func (svc service) sendCampaignWebhook(id uint, completedAt time.Time) {
+	go func() {
+		if err := svc.sendCampaignWebhookPayload(id, completedAt); err != nil {
+			svc.logger.Log("msg", "error sending campaign webhook", "campaign_id", id, "err", err)
+		}
+	}()
+}
+
+func (svc service) sendCampaignWebhookPayload(id uint, completedAt time.Time) error {
+	config, err := svc.ds.AppConfig()
+	if err != nil {
+		return errors.Wrap(err, "retrieving app config")
+	}
+	if config.CampaignWebhookURL == "" {
+		return nil
+	}
+
+	summary, err := svc.ds.DistributedQueryCampaignSummary(id)
+	if err != nil {
+		return errors.Wrap(err, "summarizing campaign")
+	}
+	if config.CampaignWebhookRequireResults && summary.Rows == 0 {
+		return nil
+	}
+
+	campaign, err := svc.ds.DistributedQueryCampaign(id)
+	if err != nil {
+		return errors.Wrap(err, "loading campaign")
+	}
+	query, err := svc.ds.Query(campaign.QueryID)
+	if err != nil {
+		return errors.Wrap(err, "loading query")
+	}
+	hostIDs, labelIDs, err := svc.ds.DistributedQueryCampaignTargetIDs(id)
+	if err != nil {
+		return errors.Wrap(err, "loading targets")
+	}
+	targetHosts, err := svc.ds.HostIDsInTargets(hostIDs, labelIDs)
+	if err != nil {
+		return errors.Wrap(err, "resolving targets")
+	}
+
+	payload := campaignWebhookPayload{
+		CampaignID: id,
+		Query: campaignWebhookQuery{
+			ID:    query.ID,
+			Name:  query.Name,
+			Query: query.Query,
+		},
+		Targets: campaignWebhookTargets{
+			HostIDs:    hostIDs,
+			LabelIDs:   labelIDs,
+			TotalHosts: uint(len(targetHosts)),
+		},
+		Results:     *summary,
+		CompletedAt: completedAt,
+	}
+	return svc.webhookSender.Send(context.Background(), config.CampaignWebhookURL, config.CampaignWebhookSecret, payload)
+}
@@ -0,0 +1,446 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/WatchBeam/clock"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/contexts/viewer"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/mock"
+	"github.com/kolide/fleet/server/pubsub"
+	"github.com/kolide/fleet/server/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReapCampaigns(t *testing.T) {
+	ds := new(mock.Store)
+	mockClock := clock.NewMockClock()
+	svc, err := newTestServiceWithClock(ds, nil, mockClock)
+	require.Nil(t, err)
+
+	purges := make(chan time.Time)
+	ds.PurgeCompletedCampaignsFunc = func(before time.Time) (uint, error) {
+		purges <- before
+		return 2, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		svc.ReapCampaigns(ctx)
+		close(done)
+	}()
+
+	// Campaigns are purged right away, and then on every tick
+	retention := 7 * 24 * time.Hour
+	assert.Equal(t, mockClock.Now().Add(-retention), <-purges)
+	mockClock.AddTime(campaignReapInterval)
+	assert.Equal(t, mockClock.Now().Add(-retention), <-purges)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reaper did not stop after the context was cancelled")
+	}
+}
+
+func TestCampaignWebhook(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		received <- r
+	}))
+	defer server.Close()
+
+	ds := new(mock.Store)
+	appConfig := &kolide.AppConfig{
+		CampaignWebhookURL:            server.URL,
+		CampaignWebhookSecret:         "shhh",
+		CampaignWebhookRequireResults: true,
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return appConfig, nil
+	}
+	completed := true
+	ds.CompleteDistributedQueryCampaignFunc = func(id uint) (bool, error) {
+		return completed, nil
+	}
+	summary := &kolide.DistributedQueryCampaignSummary{HostsResponded: 2, HostsFailed: 1}
+	ds.DistributedQueryCampaignSummaryFunc = func(id uint) (*kolide.DistributedQueryCampaignSummary, error) {
+		return summary, nil
+	}
+	ds.DistributedQueryCampaignFunc = func(id uint) (*kolide.DistributedQueryCampaign, error) {
+		return &kolide.DistributedQueryCampaign{ID: id, QueryID: 3}, nil
+	}
+	ds.QueryFunc = func(id uint) (*kolide.Query, error) {
+		return &kolide.Query{ID: id, Name: "time", Query: "select * from time"}, nil
+	}
+	ds.DistributedQueryCampaignTargetIDsFunc = func(id uint) ([]uint, []uint, error) {
+		return []uint{1}, []uint{2}, nil
+	}
+	ds.HostIDsInTargetsFunc = func(hostIDs []uint, labelIDs []uint) ([]uint, error) {
+		return []uint{1, 4, 5}, nil
+	}
+
+	mockClock := clock.NewMockClock()
+	svc := service{
+		ds:            ds,
+		clock:         mockClock,
+		config:        config.TestConfig(),
+		logger:        kitlog.NewNopLogger(),
+		webhookSender: &webhook.Sender{Client: server.Client(), MaxAttempts: 1},
+	}
+
+	// Campaigns that returned no rows are not sent
+	require.Nil(t, svc.sendCampaignWebhookPayload(7, mockClock.Now()))
+	assert.False(t, ds.DistributedQueryCampaignFuncInvoked)
+
+	// Campaigns completed by another call are not sent
+	summary.Rows = 3
+	completed = false
+	require.Nil(t, svc.completeCampaign(7))
+	assert.True(t, ds.CompleteDistributedQueryCampaignFuncInvoked)
+
+	completed = true
+	require.Nil(t, svc.completeCampaign(7))
+
+	var req *http.Request
+	select {
+	case req = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for webhook")
+	}
+	assert.Equal(t, webhook.Sign("shhh", body), req.Header.Get(webhook.SignatureHeader))
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"campaign_id": 7,
+		"query": {"id": 3, "name": "time", "query": "select * from time"},
+		"targets": {"host_ids": [1], "label_ids": [2], "total_hosts": 3},
+		"results": {"hosts_responded": 2, "hosts_failed": 1, "rows": 3},
+		"completed_at": %q
+	}`, mockClock.Now().Format(time.RFC3339Nano)), string(body))
+
+	select {
+	case <-received:
+		t.Fatal("webhook sent more than once")
+	default:
+	}
+}
+
+func TestListRunningCampaigns(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ds.ListActiveDistributedQueryCampaignsFunc = func() ([]*kolide.DistributedQueryCampaign, error) {
+		return []*kolide.DistributedQueryCampaign{
+			{ID: 1, Status: kolide.QueryWaiting},
+			{ID: 2, Status: kolide.QueryRunning},
+		}, nil
+	}
+
+	campaigns, err := svc.ListRunningCampaigns(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, []kolide.DistributedQueryCampaign{
+		{ID: 1, Status: kolide.QueryWaiting},
+		{ID: 2, Status: kolide.QueryRunning},
+	}, campaigns)
+}
+
+func TestStopCampaign(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+	streams := svc.(idempotencyMiddleware).Service.(validationMiddleware).Service.(service).campaignStreams
+
+	ds.DistributedQueryCampaignFunc = func(id uint) (*kolide.DistributedQueryCampaign, error) {
+		return &kolide.DistributedQueryCampaign{ID: id, UserID: 1, Status: kolide.QueryRunning}, nil
+	}
+	var completedIDs []uint
+	ds.CompleteDistributedQueryCampaignFunc = func(id uint) (bool, error) {
+		completedIDs = append(completedIDs, id)
+		return true, nil
+	}
+	// The campaign webhook is not configured
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+
+	viewerCtx := func(user kolide.User) context.Context {
+		user.Enabled = true
+		return viewer.NewContext(context.Background(), viewer.Viewer{
+			User:    &user,
+			Session: &kolide.Session{ID: 1},
+		})
+	}
+
+	// Other users that are not admins may not stop the campaign
+	err = svc.StopCampaign(viewerCtx(kolide.User{ID: 2}), 3)
+	require.NotNil(t, err)
+	assert.IsType(t, permissionError{}, err)
+	assert.Empty(t, completedIDs)
+
+	// The creator stops the campaign, ending its streams
+	stopped, unwatch := streams.watch(3)
+	defer unwatch()
+	require.Nil(t, svc.StopCampaign(viewerCtx(kolide.User{ID: 1}), 3))
+	assert.Equal(t, []uint{3}, completedIDs)
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("stream of the stopped campaign was not notified")
+	}
+
+	// Admins may stop the campaigns of other users
+	require.Nil(t, svc.StopCampaign(viewerCtx(kolide.User{ID: 2, Admin: true}), 4))
+	assert.Equal(t, []uint{3, 4}, completedIDs)
+}
+
+func TestEstimateQueryCost(t *testing.T) {
+	ds := new(mock.Store)
+	rs := pubsub.NewInmemQueryResults()
+	mockClock := clock.NewMockClock()
+	svc, err := newTestServiceWithClock(ds, rs, mockClock)
+	require.Nil(t, err)
+
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	ds.CountHostsFunc = func(opt kolide.HostListOptions) (int, error) {
+		return 100, nil
+	}
+	ds.NewQueryFunc = func(query *kolide.Query, opts ...kolide.OptionalArg) (*kolide.Query, error) {
+		query.ID = 42
+		return query, nil
+	}
+	ds.NewDistributedQueryCampaignFunc = func(camp *kolide.DistributedQueryCampaign) (*kolide.DistributedQueryCampaign, error) {
+		camp.ID = 21
+		return camp, nil
+	}
+	var gotTargets []uint
+	ds.NewDistributedQueryCampaignTargetFunc = func(target *kolide.DistributedQueryCampaignTarget) (*kolide.DistributedQueryCampaignTarget, error) {
+		gotTargets = append(gotTargets, target.TargetID)
+		return target, nil
+	}
+	ds.CountHostsInTargetsFunc = func(hostIDs, labelIDs []uint, now time.Time) (kolide.TargetMetrics, error) {
+		return kolide.TargetMetrics{TotalHosts: 3, OnlineHosts: 3}, nil
+	}
+	running := make(chan struct{})
+	ds.SaveDistributedQueryCampaignFunc = func(camp *kolide.DistributedQueryCampaign) error {
+		assert.Equal(t, kolide.QueryRunning, camp.Status)
+		close(running)
+		return nil
+	}
+	ds.CompleteDistributedQueryCampaignFunc = func(id uint) (bool, error) {
+		return true, nil
+	}
+
+	viewerCtx := viewer.NewContext(context.Background(), viewer.Viewer{
+		User: &kolide.User{ID: 1, Enabled: true},
+	})
+
+	_, err = svc.EstimateQueryCost(viewerCtx, "select 1", nil)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "at least one host")
+	_, err = svc.EstimateQueryCost(viewerCtx, "select 1", []uint{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "at most 10 hosts")
+
+	type result struct {
+		estimate kolide.CostEstimate
+		err      error
+	}
+	done := make(chan result)
+	go func() {
+		estimate, err := svc.EstimateQueryCost(viewerCtx, "select 1", []uint{1, 2, 3, 1})
+		done <- result{estimate, err}
+	}()
+	<-running
+
+	// Results are only delivered once the estimate is reading them
+	writeResult := func(res kolide.DistributedQueryResult) {
+		res.DistributedQueryCampaignID = 21
+		for rs.WriteResult(res) != nil {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	// The wall time is the one reported by hosts, not the time they took
+	// to respond
+	mockClock.AddTime(2 * time.Second)
+	writeResult(kolide.DistributedQueryResult{
+		Host:  kolide.Host{ID: 1},
+		Rows:  []map[string]string{{"1": "1"}, {"1": "1"}},
+		Stats: &kolide.OsqueryDistributedQueryStats{WallTimeMs: 40},
+	})
+	errMsg := "failed"
+	writeResult(kolide.DistributedQueryResult{Host: kolide.Host{ID: 2}, Error: &errMsg})
+
+	// The third host does not respond before the estimate times out
+	mockClock.AddTime(costEstimateTimeout)
+	res := <-done
+	require.Nil(t, res.err)
+	assert.Equal(t, []uint{1, 2, 3}, gotTargets)
+	assert.True(t, ds.CompleteDistributedQueryCampaignFuncInvoked)
+	assert.Equal(t, kolide.CostEstimate{
+		SampledHosts:        3,
+		RespondedHosts:      2,
+		FailedHosts:         1,
+		TotalHosts:          100,
+		TimedHosts:          1,
+		AverageWallTime:     40,
+		AverageRows:         2,
+		AverageResultSize:   21,
+		EstimatedWallTime:   4000,
+		EstimatedRows:       200,
+		EstimatedResultSize: 2100,
+	}, res.estimate)
+}
+
+type recordingResultsWriter struct {
+	messages chan string
+	results  chan kolide.DistributedQueryResult
+}
+
+func newRecordingResultsWriter() *recordingResultsWriter {
+	return &recordingResultsWriter{
+		messages: make(chan string, 100),
+		results:  make(chan kolide.DistributedQueryResult, 100),
+	}
+}
+
+func (w *recordingResultsWriter) WriteJSONMessage(typ string, data interface{}) error {
+	if res, ok := data.(kolide.DistributedQueryResult); ok {
+		w.results <- res
+	}
+	w.messages <- typ
+	return nil
+}
+
+func (w *recordingResultsWriter) WriteJSONError(data interface{}) error {
+	w.messages <- "error"
+	return nil
+}
+
+func TestStreamCampaignResultsResume(t *testing.T) {
+	ds := new(mock.Store)
+	rs := pubsub.NewInmemQueryResults()
+	mockClock := clock.NewMockClock()
+	svc, err := newTestServiceWithClock(ds, rs, mockClock)
+	require.Nil(t, err)
+
+	campaign := &kolide.DistributedQueryCampaign{ID: 1, QueryID: 2, Status: kolide.QueryWaiting}
+	ds.DistributedQueryCampaignFunc = func(id uint) (*kolide.DistributedQueryCampaign, error) {
+		c := *campaign
+		return &c, nil
+	}
+	ds.SaveDistributedQueryCampaignFunc = func(c *kolide.DistributedQueryCampaign) error {
+		campaign.Status = c.Status
+		return nil
+	}
+	ds.MarkDistributedQueryCampaignDisconnectedFunc = func(id uint, disconnectedAt *time.Time) error {
+		campaign.DisconnectedAt = disconnectedAt
+		return nil
+	}
+	completed := make(chan uint, 1)
+	ds.CompleteDistributedQueryCampaignFunc = func(id uint) (bool, error) {
+		completed <- id
+		return true, nil
+	}
+	ds.QueryFunc = func(id uint) (*kolide.Query, error) {
+		return &kolide.Query{ID: id}, nil
+	}
+	ds.DistributedQueryCampaignTargetIDsFunc = func(id uint) ([]uint, []uint, error) {
+		return []uint{1}, nil, nil
+	}
+	ds.CountHostsInTargetsFunc = func(hostIDs, labelIDs []uint, now time.Time) (kolide.TargetMetrics, error) {
+		return kolide.TargetMetrics{TotalHosts: 1, OnlineHosts: 1}, nil
+	}
+	// The campaign webhook is not configured
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+
+	// stream streams the campaign until the returned function disconnects
+	// the client
+	stream := func(conn *recordingResultsWriter, lastSequence *uint64) func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			svc.StreamCampaignResults(ctx, conn, campaign.ID, lastSequence, false)
+			close(done)
+		}()
+		// The totals are written once the stream has replayed any
+		// missed results
+		for typ := range conn.messages {
+			if typ == "totals" {
+				break
+			}
+		}
+		return func() {
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("stream did not end after the client disconnected")
+			}
+		}
+	}
+	assertNotCompleted := func() {
+		select {
+		case <-completed:
+			t.Fatal("campaign completed while it could be resumed")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	// Disconnecting leaves the campaign running
+	disconnect := stream(newRecordingResultsWriter(), nil)
+	disconnect()
+	assert.Equal(t, kolide.QueryRunning, campaign.Status)
+	require.NotNil(t, campaign.DisconnectedAt)
+	assertNotCompleted()
+
+	// A result arrives while the client is disconnected
+	rs.WriteResult(kolide.DistributedQueryResult{
+		DistributedQueryCampaignID: campaign.ID,
+		Host:                       kolide.Host{ID: 1, HostName: "foo"},
+		Rows:                       []map[string]string{{"bar": "baz"}},
+	})
+
+	// Resuming from the last sequence replays the missed result, and
+	// keeps the campaign running past the resume window
+	conn := newRecordingResultsWriter()
+	var lastSequence uint64
+	disconnect = stream(conn, &lastSequence)
+	select {
+	case res := <-conn.results:
+		assert.Equal(t, uint64(1), res.Sequence)
+		assert.Equal(t, "foo", res.Host.HostName)
+	case <-time.After(5 * time.Second):
+		t.Fatal("missed result was not replayed")
+	}
+	assert.Nil(t, campaign.DisconnectedAt)
+	mockClock.AddTime(campaignResumeWindow)
+	assertNotCompleted()
+
+	// The campaign completes once no client resumes it within the window
+	disconnect()
+	assertNotCompleted()
+	mockClock.AddTime(campaignResumeWindow)
+	select {
+	case id := <-completed:
+		assert.Equal(t, campaign.ID, id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("abandoned campaign was not completed")
+	}
+}
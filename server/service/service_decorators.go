@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (svc service) ListDecorators(ctx context.Context) ([]*kolide.Decorator, error) {
+	return svc.ds.ListDecorators()
+}
+
+func (svc service) NewDecorator(ctx context.Context, payload kolide.DecoratorPayload) (*kolide.Decorator, error) {
+	var dec kolide.Decorator
+	if payload.Name != nil {
+		dec.Name = *payload.Name
+	}
+	if payload.Query != nil {
+		dec.Query = *payload.Query
+	}
+	if payload.DecoratorType != nil {
+		dec.Type = *payload.DecoratorType
+	}
+	if payload.Interval != nil && dec.Type == kolide.DecoratorInterval {
+		dec.Interval = *payload.Interval
+	}
+	return svc.ds.NewDecorator(&dec)
+}
+
+func (svc service) ModifyDecorator(ctx context.Context, payload kolide.DecoratorPayload) (*kolide.Decorator, error) {
+	dec, err := svc.ds.Decorator(payload.ID)
+	if err != nil {
+		return nil, err
+	}
+	if dec.BuiltIn {
+		return nil, newPermissionError("decorator", "built in decorators cannot be modified")
+	}
+	if payload.Name != nil {
+		dec.Name = *payload.Name
+	}
+	if payload.Query != nil {
+		dec.Query = *payload.Query
+	}
+	if payload.DecoratorType != nil {
+		dec.Type = *payload.DecoratorType
+	}
+	if payload.Interval != nil {
+		dec.Interval = *payload.Interval
+	}
+	if dec.Type != kolide.DecoratorInterval {
+		dec.Interval = 0
+	} else if dec.Interval == 0 {
+		return nil, newInvalidArgumentError("interval", "must be a positive number of seconds for interval decorators")
+	}
+	if err := svc.ds.SaveDecorator(dec); err != nil {
+		return nil, err
+	}
+	return dec, nil
+}
+
+func (svc service) DeleteDecorator(ctx context.Context, id uint) error {
+	dec, err := svc.ds.Decorator(id)
+	if err != nil {
+		return err
+	}
+	if dec.BuiltIn {
+		return newPermissionError("decorator", "built in decorators cannot be deleted")
+	}
+	return svc.ds.DeleteDecorator(id)
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kolide/fleet/server/config"
+	hostctx "github.com/kolide/fleet/server/contexts/host"
+	"github.com/kolide/fleet/server/datastore/inmem"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDecoratorValidation(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	decType := func(t kolide.DecoratorType) *kolide.DecoratorType { return &t }
+	uintPtr := func(u uint) *uint { return &u }
+
+	var testCases = []struct {
+		payload kolide.DecoratorPayload
+		wantErr string
+	}{
+		{
+			payload: kolide.DecoratorPayload{DecoratorType: decType(kolide.DecoratorLoad)},
+			wantErr: "query",
+		},
+		{
+			payload: kolide.DecoratorPayload{Query: stringPtr("SELECT 1;")},
+			wantErr: "type",
+		},
+		{
+			payload: kolide.DecoratorPayload{
+				Query:         stringPtr("SELECT 1;"),
+				DecoratorType: decType(kolide.DecoratorUndefined),
+			},
+			wantErr: "type",
+		},
+		{
+			payload: kolide.DecoratorPayload{
+				Query:         stringPtr("SELECT 1;"),
+				DecoratorType: decType(kolide.DecoratorInterval),
+			},
+			wantErr: "interval",
+		},
+		{
+			payload: kolide.DecoratorPayload{
+				Query:         stringPtr("SELECT 1;"),
+				DecoratorType: decType(kolide.DecoratorInterval),
+				Interval:      uintPtr(0),
+			},
+			wantErr: "interval",
+		},
+		{
+			payload: kolide.DecoratorPayload{
+				Query:         stringPtr("SELECT 1;"),
+				DecoratorType: decType(kolide.DecoratorInterval),
+				Interval:      uintPtr(60),
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run("", func(t *testing.T) {
+			_, err := svc.NewDecorator(context.Background(), tt.payload)
+			if tt.wantErr == "" {
+				assert.Nil(t, err)
+				return
+			}
+			require.NotNil(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestModifyDecorator(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	dec, err := ds.NewDecorator(&kolide.Decorator{
+		Type:  kolide.DecoratorLoad,
+		Query: "SELECT 1;",
+	})
+	require.Nil(t, err)
+
+	// Changing to an interval decorator requires an interval
+	interval := kolide.DecoratorInterval
+	_, err = svc.ModifyDecorator(context.Background(), kolide.DecoratorPayload{
+		ID:            dec.ID,
+		DecoratorType: &interval,
+	})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "interval")
+
+	seconds := uint(600)
+	dec, err = svc.ModifyDecorator(context.Background(), kolide.DecoratorPayload{
+		ID:            dec.ID,
+		DecoratorType: &interval,
+		Interval:      &seconds,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, kolide.DecoratorInterval, dec.Type)
+	assert.Equal(t, uint(600), dec.Interval)
+
+	builtIn, err := ds.NewDecorator(&kolide.Decorator{
+		Type:    kolide.DecoratorLoad,
+		Query:   "SELECT uuid AS host_uuid FROM system_info;",
+		BuiltIn: true,
+	})
+	require.Nil(t, err)
+	_, err = svc.ModifyDecorator(context.Background(), kolide.DecoratorPayload{
+		ID:    builtIn.ID,
+		Query: stringPtr("SELECT 2;"),
+	})
+	assert.NotNil(t, err)
+	assert.NotNil(t, svc.DeleteDecorator(context.Background(), builtIn.ID))
+
+	require.Nil(t, svc.DeleteDecorator(context.Background(), dec.ID))
+	decs, err := svc.ListDecorators(context.Background())
+	require.Nil(t, err)
+	assert.Len(t, decs, 1)
+}
+
+func TestGetClientConfigDecorators(t *testing.T) {
+	ds := new(mock.Store)
+	ds.LabelOptionsForHostFunc = func(hid uint) (map[string]json.RawMessage, error) {
+		return nil, nil
+	}
+	ds.OptionsForPlatformFunc = func(platform string) (json.RawMessage, error) {
+		return json.RawMessage(`{"options":{},"decorators":{"load":["SELECT version FROM osquery_info;"],"interval":{"60":"SELECT 1;"}}}`), nil
+	}
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return []*kolide.Pack{}, nil
+	}
+	ds.ListDecoratorsFunc = func(opts ...kolide.OptionalArg) ([]*kolide.Decorator, error) {
+		return []*kolide.Decorator{
+			{Type: kolide.DecoratorLoad, Query: "SELECT tag FROM asset_tag;"},
+			{Type: kolide.DecoratorInterval, Interval: 3600, Query: "SELECT total_seconds AS uptime FROM uptime;"},
+		}, nil
+	}
+	ds.ListATCTablesFunc = func() ([]*kolide.ATCTable, error) {
+		return nil, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 1})
+	conf, err := svc.GetClientConfig(ctx)
+	require.Nil(t, err)
+
+	confJSON, err := json.Marshal(conf["decorators"])
+	require.Nil(t, err)
+	assert.JSONEq(t, `{
+		"load": ["SELECT version FROM osquery_info;", "SELECT tag FROM asset_tag;"],
+		"interval": {
+			"60": ["SELECT 1;"],
+			"3600": ["SELECT total_seconds AS uptime FROM uptime;"]
+		}
+	}`, string(confJSON))
+}
@@ -57,6 +57,21 @@ func (e invalidArgumentError) Invalid() []map[string]string {
 	return invalid
 }
 
+// alreadyExistsError is returned when a resource cannot be created because
+// one with the same name already exists.
+type alreadyExistsError struct {
+	resourceType string
+	name         string
+}
+
+func (e alreadyExistsError) Error() string {
+	return fmt.Sprintf("%s '%s' already exists", e.resourceType, e.name)
+}
+
+func (e alreadyExistsError) IsExists() bool {
+	return true
+}
+
 // authentication error
 type authError struct {
 	reason string
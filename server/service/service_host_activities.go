@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+var hostActivityTypes = []string{
+	kolide.HostActivityEnrolled,
+	kolide.HostActivityLabelAdded,
+	kolide.HostActivityLabelRemoved,
+	kolide.HostActivityMaintenanceStarted,
+	kolide.HostActivityMaintenanceEnded,
+	kolide.HostActivityRebooted,
+	kolide.HostActivityMerged,
+}
+
+func (svc service) HostActivities(ctx context.Context, hostID uint, opt kolide.HostActivityListOptions) ([]*kolide.HostActivity, error) {
+	for _, t := range opt.Types {
+		if !isHostActivityType(t) {
+			return nil, newInvalidArgumentError("type", "must be one of "+strings.Join(hostActivityTypes, ", "))
+		}
+	}
+	if _, err := svc.ds.Host(hostID); err != nil {
+		return nil, err
+	}
+	if opt.OrderKey == "" {
+		opt.OrderKey = "id"
+		opt.OrderDirection = kolide.OrderDescending
+	}
+	return svc.ds.ListHostActivities(hostID, opt)
+}
+
+func isHostActivityType(t string) bool {
+	for _, known := range hostActivityTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// recordHostActivity adds an event to the activity feed of the host. The feed
+// is informational, so a failure to record the event is logged rather than
+// failing the operation that caused it.
+func (svc service) recordHostActivity(ctx context.Context, hostID uint, activityType string, details interface{}) {
+	activity := &kolide.HostActivity{
+		HostID:    hostID,
+		CreatedAt: svc.clock.Now(),
+		Type:      activityType,
+	}
+	if details != nil {
+		b, err := json.Marshal(details)
+		if err != nil {
+			contextLogger(ctx, svc.logger).Log("msg", "marshal host activity details", "type", activityType, "err", err)
+			return
+		}
+		raw := json.RawMessage(b)
+		activity.Details = &raw
+	}
+	if _, err := svc.ds.NewHostActivity(activity); err != nil {
+		contextLogger(ctx, svc.logger).Log("msg", "record host activity", "type", activityType, "err", err)
+	}
+}
+
+// recordLabelActivities records the labels added to and removed from the
+// host by the label query results, compared to the labels it was previously
+// a member of.
+func (svc service) recordLabelActivities(ctx context.Context, host *kolide.Host, previous []kolide.Label, results map[uint]bool) {
+	wasMember := make(map[uint]kolide.Label, len(previous))
+	for _, l := range previous {
+		wasMember[l.ID] = l
+	}
+	ids := make([]uint, 0, len(results))
+	for id := range results {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		matches := results[id]
+		label, member := wasMember[id]
+		switch {
+		case matches && !member:
+			details := map[string]interface{}{"label_id": id}
+			if l, err := svc.ds.Label(id); err == nil {
+				details["label_name"] = l.Name
+			}
+			svc.recordHostActivity(ctx, host.ID, kolide.HostActivityLabelAdded, details)
+		case !matches && member:
+			svc.recordHostActivity(ctx, host.ID, kolide.HostActivityLabelRemoved,
+				map[string]interface{}{"label_id": id, "label_name": label.Name})
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/WatchBeam/clock"
+	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/datastore/inmem"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostActivities(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+
+	mockClock := clock.NewMockClock()
+	svc, err := newTestServiceWithClock(ds, nil, mockClock)
+	require.Nil(t, err)
+
+	ctx := context.Background()
+
+	host, err := ds.NewHost(&kolide.Host{HostName: "foo", NodeKey: "foo", UUID: "foo"})
+	require.Nil(t, err)
+	other, err := ds.NewHost(&kolide.Host{HostName: "bar", NodeKey: "bar", UUID: "bar"})
+	require.Nil(t, err)
+
+	until := mockClock.Now().Add(time.Hour)
+	require.Nil(t, svc.SetHostMaintenance(ctx, host.ID, until))
+	mockClock.AddTime(time.Minute)
+	require.Nil(t, svc.SetHostMaintenance(ctx, host.ID, time.Time{}))
+	require.Nil(t, svc.SetHostMaintenance(ctx, other.ID, until))
+
+	// The most recent events are listed first
+	activities, err := svc.HostActivities(ctx, host.ID, kolide.HostActivityListOptions{})
+	require.Nil(t, err)
+	require.Len(t, activities, 2)
+	assert.Equal(t, kolide.HostActivityMaintenanceEnded, activities[0].Type)
+	assert.Equal(t, mockClock.Now(), activities[0].CreatedAt)
+	assert.Nil(t, activities[0].Details)
+	assert.Equal(t, kolide.HostActivityMaintenanceStarted, activities[1].Type)
+	require.NotNil(t, activities[1].Details)
+	assert.Contains(t, string(*activities[1].Details), `"until"`)
+
+	activities, err = svc.HostActivities(ctx, host.ID, kolide.HostActivityListOptions{
+		ListOptions: kolide.ListOptions{PerPage: 1, Page: 1},
+	})
+	require.Nil(t, err)
+	require.Len(t, activities, 1)
+	assert.Equal(t, kolide.HostActivityMaintenanceStarted, activities[0].Type)
+
+	activities, err = svc.HostActivities(ctx, host.ID, kolide.HostActivityListOptions{
+		Types: []string{kolide.HostActivityMaintenanceEnded, kolide.HostActivityRebooted},
+	})
+	require.Nil(t, err)
+	require.Len(t, activities, 1)
+	assert.Equal(t, kolide.HostActivityMaintenanceEnded, activities[0].Type)
+
+	_, err = svc.HostActivities(ctx, host.ID, kolide.HostActivityListOptions{Types: []string{"deleted"}})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "must be one of")
+
+	_, err = svc.HostActivities(ctx, other.ID+1, kolide.HostActivityListOptions{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
+)
+
+func (svc service) ListHostDetailQueries(ctx context.Context) ([]*kolide.HostDetailQuery, error) {
+	return svc.ds.ListHostDetailQueries()
+}
+
+func (svc service) NewHostDetailQuery(ctx context.Context, payload kolide.HostDetailQueryPayload) (*kolide.HostDetailQuery, error) {
+	var query kolide.HostDetailQuery
+	if payload.Name != nil {
+		query.Name = *payload.Name
+	}
+	if payload.Query != nil {
+		query.Query = *payload.Query
+	}
+	return svc.ds.NewHostDetailQuery(&query)
+}
+
+func (svc service) DeleteHostDetailQuery(ctx context.Context, id uint) error {
+	return svc.ds.DeleteHostDetailQuery(id)
+}
+
+func (svc service) HostExtraDetails(ctx context.Context, hostID uint) (kolide.HostExtraDetails, error) {
+	host, err := svc.ds.Host(hostID)
+	if err != nil {
+		return nil, err
+	}
+	return hostExtraDetails(host)
+}
+
+// hostExtraDetails decodes the extra details stored for host.
+func hostExtraDetails(host *kolide.Host) (kolide.HostExtraDetails, error) {
+	details := kolide.HostExtraDetails{}
+	if host.ExtraDetails == nil {
+		return details, nil
+	}
+	if err := json.Unmarshal(*host.ExtraDetails, &details); err != nil {
+		return nil, errors.Wrap(err, "unmarshal host extra details")
+	}
+	return details, nil
+}
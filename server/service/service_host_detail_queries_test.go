@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/datastore/inmem"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHostDetailQuery(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+	ctx := context.Background()
+
+	_, err = svc.NewHostDetailQuery(ctx, kolide.HostDetailQueryPayload{Query: stringPtr("select 1")})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "name")
+	_, err = svc.NewHostDetailQuery(ctx, kolide.HostDetailQueryPayload{Name: stringPtr("chassis")})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "query")
+
+	query, err := svc.NewHostDetailQuery(ctx, kolide.HostDetailQueryPayload{
+		Name:  stringPtr("chassis"),
+		Query: stringPtr("select chassis_type from system_info"),
+	})
+	require.Nil(t, err)
+	assert.NotZero(t, query.ID)
+
+	queries, err := svc.ListHostDetailQueries(ctx)
+	require.Nil(t, err)
+	require.Len(t, queries, 1)
+	assert.Equal(t, "chassis", queries[0].Name)
+
+	require.Nil(t, svc.DeleteHostDetailQuery(ctx, query.ID))
+	queries, err = svc.ListHostDetailQueries(ctx)
+	require.Nil(t, err)
+	assert.Empty(t, queries)
+}
+
+func TestHostExtraDetails(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	host, err := ds.NewHost(&kolide.Host{HostName: "foo", OsqueryHostID: "1", NodeKey: "1", UUID: "1"})
+	require.Nil(t, err)
+
+	// Hosts that have not reported extra details have none
+	details, err := svc.HostExtraDetails(context.Background(), host.ID)
+	require.Nil(t, err)
+	assert.Empty(t, details)
+
+	_, err = svc.HostExtraDetails(context.Background(), 9999)
+	assert.NotNil(t, err)
+}
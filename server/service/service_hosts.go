@@ -2,12 +2,78 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
 )
 
-func (svc service) ListHosts(ctx context.Context, opt kolide.ListOptions) ([]*kolide.Host, error) {
-	return svc.ds.ListHosts(opt)
+// validateHostListFilters checks the filters shared by ListHosts, CountHosts
+// and StreamHosts.
+func validateHostListFilters(opt kolide.HostListOptions) error {
+	switch opt.SeenStatus {
+	case "", kolide.StatusOnline, kolide.StatusOffline:
+	default:
+		return newInvalidArgumentError("seen_status", "must be one of online or offline")
+	}
+	if opt.SeenStatus != "" && opt.SeenWithin <= 0 {
+		return newInvalidArgumentError("seen_minutes", "must be a positive number of minutes")
+	}
+	return nil
+}
+
+func (svc service) ListHosts(ctx context.Context, opt kolide.HostListOptions) ([]*kolide.Host, string, error) {
+	if err := validateHostListFilters(opt); err != nil {
+		return nil, "", err
+	}
+	if opt.After != nil && *opt.After != "" {
+		cursor, err := kolide.ParseHostCursor(*opt.After)
+		if err != nil || cursor.OrderKey != opt.OrderKey {
+			return nil, "", newInvalidArgumentError("after", "invalid cursor for the requested order")
+		}
+	}
+
+	hosts, err := svc.ds.ListHosts(opt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// A full page in cursor mode indicates that more hosts may follow
+	var nextCursor string
+	if opt.After != nil && opt.PerPage > 0 && uint(len(hosts)) == opt.PerPage {
+		nextCursor, err = kolide.NewHostCursor(hosts[len(hosts)-1], opt.OrderKey)
+		if err != nil {
+			return nil, "", newInvalidArgumentError("order_key", err.Error())
+		}
+	}
+	return hosts, nextCursor, nil
+}
+
+func (svc service) CountHosts(ctx context.Context, opt kolide.HostListOptions) (int, error) {
+	if err := validateHostListFilters(opt); err != nil {
+		return 0, err
+	}
+	return svc.ds.CountHosts(opt)
+}
+
+func (svc service) StreamHosts(ctx context.Context, opt kolide.HostListOptions, w io.Writer) error {
+	if err := validateHostListFilters(opt); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	return svc.ds.StreamHosts(opt, func(host *kolide.Host) error {
+		resp, err := hostResponseForHost(ctx, svc, host)
+		if err != nil {
+			return err
+		}
+		return errors.Wrap(enc.Encode(resp), "encode host")
+	})
 }
 
 func (svc service) GetHost(ctx context.Context, id uint) (*kolide.Host, error) {
@@ -15,18 +81,545 @@ func (svc service) GetHost(ctx context.Context, id uint) (*kolide.Host, error) {
 }
 
 func (svc service) GetHostSummary(ctx context.Context) (*kolide.HostSummary, error) {
-	online, offline, mia, new, err := svc.ds.GenerateHostStatusStatistics(svc.clock.Now())
+	online, offline, mia, maintenance, new, err := svc.ds.GenerateHostStatusStatistics(svc.clock.Now())
 	if err != nil {
 		return nil, err
 	}
 	return &kolide.HostSummary{
-		OnlineCount:  online,
-		OfflineCount: offline,
-		MIACount:     mia,
-		NewCount:     new,
+		OnlineCount:      online,
+		OfflineCount:     offline,
+		MIACount:         mia,
+		MaintenanceCount: maintenance,
+		NewCount:         new,
 	}, nil
 }
 
 func (svc service) DeleteHost(ctx context.Context, id uint) error {
 	return svc.ds.DeleteHost(id)
 }
+
+func (svc service) DeleteHostsByLabel(ctx context.Context, labelID uint) (int, error) {
+	// Verify the label exists so that a bad ID is reported as not found
+	// rather than silently deleting nothing.
+	if _, err := svc.ds.Label(labelID); err != nil {
+		return 0, err
+	}
+	return svc.ds.DeleteHostsByLabel(labelID)
+}
+
+func (svc service) RefreshHostConfig(ctx context.Context, hostIDs []uint) error {
+	return svc.ds.SetHostsConfigRefresh(hostIDs, true)
+}
+
+func (svc service) TransferHosts(ctx context.Context, hostIDs []uint, targetLabelID uint) error {
+	if err := svc.checkManualLabel(targetLabelID); err != nil {
+		return err
+	}
+	if len(hostIDs) == 0 {
+		return newInvalidArgumentError("host_ids", "must include at least one host")
+	}
+	missing, err := svc.ds.TransferHostsToLabel(targetLabelID, hostIDs)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return newInvalidArgumentError("host_ids", fmt.Sprintf("hosts do not exist: %v", missing))
+	}
+	// The packs targeting the label now apply to the hosts, so have them
+	// fetch their config without waiting for the refresh interval.
+	return svc.ds.SetHostsConfigRefresh(hostIDs, true)
+}
+
+func (svc service) RefreshHostDetails(ctx context.Context, hostID uint) error {
+	if _, err := svc.ds.Host(hostID); err != nil {
+		return err
+	}
+	return svc.ds.ExpireHostDetails(hostID)
+}
+
+func (svc service) SetHostMaintenance(ctx context.Context, hostID uint, until time.Time) error {
+	if _, err := svc.ds.Host(hostID); err != nil {
+		return err
+	}
+	if until.IsZero() {
+		if err := svc.ds.SetHostMaintenance(hostID, nil); err != nil {
+			return err
+		}
+		svc.recordHostActivity(ctx, hostID, kolide.HostActivityMaintenanceEnded, nil)
+		return nil
+	}
+	if !until.After(svc.clock.Now()) {
+		return newInvalidArgumentError("until", "must be in the future")
+	}
+	if err := svc.ds.SetHostMaintenance(hostID, &until); err != nil {
+		return err
+	}
+	svc.recordHostActivity(ctx, hostID, kolide.HostActivityMaintenanceStarted, map[string]interface{}{"until": until})
+	return nil
+}
+
+func (svc service) ApproveHost(ctx context.Context, hostID uint) error {
+	host, err := svc.ds.Host(hostID)
+	if err != nil {
+		return err
+	}
+	if !host.Pending {
+		return nil
+	}
+	if err := svc.ds.ApproveHost(hostID); err != nil {
+		return err
+	}
+	svc.recordHostActivity(ctx, hostID, kolide.HostActivityApproved, nil)
+	return nil
+}
+
+func (svc service) RotateHostNodeKey(ctx context.Context, hostID uint) error {
+	if _, err := svc.ds.Host(hostID); err != nil {
+		return err
+	}
+	// The new node key is not given to the host, which must enroll again
+	// to get one
+	nodeKey, err := kolide.RandomText(svc.config.Osquery.NodeKeySize)
+	if err != nil {
+		return errors.Wrap(err, "generate node key")
+	}
+	if err := svc.ds.SetHostNodeKey(hostID, nodeKey); err != nil {
+		return err
+	}
+	svc.recordHostActivity(ctx, hostID, kolide.HostActivityNodeKeyRotated, nil)
+	return nil
+}
+
+func (svc service) MergeHosts(ctx context.Context, keepID uint, mergeIDs []uint) (kolide.HostMerge, error) {
+	if len(mergeIDs) == 0 {
+		return kolide.HostMerge{}, newInvalidArgumentError("host_ids", "must include at least one host")
+	}
+	keep, err := svc.ds.Host(keepID)
+	if err != nil {
+		return kolide.HostMerge{}, err
+	}
+
+	var ids []uint
+	seen := make(map[uint]bool, len(mergeIDs))
+	invalid := &invalidArgumentError{}
+	for _, id := range mergeIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if id == keepID {
+			invalid.Append("host_ids", "cannot include the host that is kept")
+			continue
+		}
+		host, err := svc.ds.Host(id)
+		if err != nil {
+			return kolide.HostMerge{}, err
+		}
+		// Hosts that have not reported a platform yet cannot conflict
+		if host.Platform != "" && keep.Platform != "" && host.Platform != keep.Platform {
+			invalid.Append("host_ids", fmt.Sprintf(
+				"host %d has platform %s, but the kept host has platform %s",
+				id, host.Platform, keep.Platform,
+			))
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if invalid.HasErrors() {
+		return kolide.HostMerge{}, invalid
+	}
+
+	merge, err := svc.ds.MergeHosts(keepID, ids)
+	if err != nil {
+		return kolide.HostMerge{}, err
+	}
+	// The kept host may now be a member of more manual labels, and so be
+	// targeted by more packs
+	if err := svc.ds.SetHostsConfigRefresh([]uint{keepID}, true); err != nil {
+		return kolide.HostMerge{}, err
+	}
+	svc.recordHostActivity(ctx, keepID, kolide.HostActivityMerged, merge)
+	return merge, nil
+}
+
+func (svc service) AggregateHosts(ctx context.Context, groupBy string) ([]kolide.HostAggregate, error) {
+	if groupBy != kolide.HostAggregateLabel && !kolide.IsHostAggregateColumn(groupBy) {
+		return nil, newInvalidArgumentError("group_by", fmt.Sprintf(
+			"must be %s or one of %s",
+			kolide.HostAggregateLabel, strings.Join(kolide.HostAggregateColumns, ", "),
+		))
+	}
+	return svc.ds.AggregateHosts(groupBy)
+}
+
+func (svc service) NoisyHosts(ctx context.Context) ([]*kolide.Host, error) {
+	return svc.ds.ListNoisyHosts()
+}
+
+func (svc service) HostRebootHistory(ctx context.Context, hostID uint) ([]*kolide.HostUptimeEvent, error) {
+	if _, err := svc.ds.Host(hostID); err != nil {
+		return nil, err
+	}
+	return svc.ds.ListHostUptimeEvents(hostID)
+}
+
+func (svc service) HostClientConfig(ctx context.Context, hostID uint) (json.RawMessage, error) {
+	host, err := svc.ds.Host(hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := svc.clientConfig(*host)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating client config")
+	}
+	b, err := json.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal client config")
+	}
+	return json.RawMessage(b), nil
+}
+
+func (svc service) HostScheduledQueries(ctx context.Context, hostID uint) ([]kolide.ScheduledQuery, error) {
+	if _, err := svc.ds.Host(hostID); err != nil {
+		return nil, err
+	}
+
+	hostPacks, err := svc.hostPacks(hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	queries := []kolide.ScheduledQuery{}
+	for _, hp := range hostPacks {
+		for _, query := range hp.queries {
+			queries = append(queries, *query)
+		}
+	}
+	return queries, nil
+}
+
+func (svc service) HostPacks(ctx context.Context, hostID uint) ([]kolide.HostPackInfo, error) {
+	if _, err := svc.ds.Host(hostID); err != nil {
+		return nil, err
+	}
+
+	hostLabels, err := svc.ds.ListLabelsForHost(hostID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list labels for host")
+	}
+	member := make(map[uint]bool, len(hostLabels))
+	for _, label := range hostLabels {
+		member[label.ID] = true
+	}
+
+	// The packs served to the host apply to it, while disabled packs are
+	// only included if they target the host
+	applied, err := svc.ds.ListPacksForHost(hostID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list packs for host")
+	}
+	packs, err := svc.ds.ListPacks(kolide.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list packs")
+	}
+
+	infos := []kolide.HostPackInfo{}
+	seen := make(map[uint]bool)
+	for _, pack := range applied {
+		if seen[pack.ID] {
+			continue
+		}
+		seen[pack.ID] = true
+		info, err := svc.hostPackInfo(pack, hostID, member)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	for _, pack := range packs {
+		if seen[pack.ID] || !pack.Disabled {
+			continue
+		}
+		info, err := svc.hostPackInfo(pack, hostID, member)
+		if err != nil {
+			return nil, err
+		}
+		if len(info.Labels) > 0 || info.HostTargeted {
+			infos = append(infos, info)
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Pack.Name < infos[j].Pack.Name
+	})
+	return infos, nil
+}
+
+// hostPackInfo returns the targets of the pack that apply to the host, given
+// the IDs of the labels the host is a member of.
+func (svc service) hostPackInfo(pack *kolide.Pack, hostID uint, member map[uint]bool) (kolide.HostPackInfo, error) {
+	info := kolide.HostPackInfo{Pack: pack, Labels: []*kolide.Label{}, Disabled: pack.Disabled}
+
+	labels, err := svc.ds.ListLabelsForPack(pack.ID)
+	if err != nil {
+		return info, errors.Wrap(err, "list labels for pack")
+	}
+	for _, label := range labels {
+		if member[label.ID] {
+			info.Labels = append(info.Labels, label)
+		}
+	}
+
+	hostIDs, err := svc.ds.ListExplicitHostsInPack(pack.ID, kolide.ListOptions{})
+	if err != nil {
+		return info, errors.Wrap(err, "list hosts in pack")
+	}
+	for _, id := range hostIDs {
+		if id == hostID {
+			info.HostTargeted = true
+			break
+		}
+	}
+	return info, nil
+}
+
+// hostDiffFields are the details compared by DiffHosts, keyed by their name
+// in the JSON representation of hosts. Details that change continuously, such
+// as the uptime and seen time, are not compared.
+var hostDiffFields = map[string]func(h *kolide.Host) string{
+	"hostname":             func(h *kolide.Host) string { return h.HostName },
+	"uuid":                 func(h *kolide.Host) string { return h.UUID },
+	"platform":             func(h *kolide.Host) string { return h.Platform },
+	"osquery_version":      func(h *kolide.Host) string { return h.OsqueryVersion },
+	"disk_encryption":      func(h *kolide.Host) string { return h.DiskEncryption },
+	"os_version":           func(h *kolide.Host) string { return h.OSVersion },
+	"build":                func(h *kolide.Host) string { return h.Build },
+	"platform_like":        func(h *kolide.Host) string { return h.PlatformLike },
+	"code_name":            func(h *kolide.Host) string { return h.CodeName },
+	"memory":               func(h *kolide.Host) string { return strconv.Itoa(h.PhysicalMemory) },
+	"cpu_type":             func(h *kolide.Host) string { return h.CPUType },
+	"cpu_subtype":          func(h *kolide.Host) string { return h.CPUSubtype },
+	"cpu_brand":            func(h *kolide.Host) string { return h.CPUBrand },
+	"cpu_physical_cores":   func(h *kolide.Host) string { return strconv.Itoa(h.CPUPhysicalCores) },
+	"cpu_logical_cores":    func(h *kolide.Host) string { return strconv.Itoa(h.CPULogicalCores) },
+	"hardware_vendor":      func(h *kolide.Host) string { return h.HardwareVendor },
+	"hardware_model":       func(h *kolide.Host) string { return h.HardwareModel },
+	"hardware_version":     func(h *kolide.Host) string { return h.HardwareVersion },
+	"hardware_serial":      func(h *kolide.Host) string { return h.HardwareSerial },
+	"computer_name":        func(h *kolide.Host) string { return h.ComputerName },
+	"enroll_secret_name":   func(h *kolide.Host) string { return h.EnrollSecretName },
+	"distributed_interval": func(h *kolide.Host) string { return strconv.Itoa(int(h.DistributedInterval)) },
+	"config_tls_refresh":   func(h *kolide.Host) string { return strconv.Itoa(int(h.ConfigTLSRefresh)) },
+	"logger_tls_period":    func(h *kolide.Host) string { return strconv.Itoa(int(h.LoggerTLSPeriod)) },
+}
+
+func (svc service) DiffHosts(ctx context.Context, hostAID, hostBID uint) (kolide.HostDiff, error) {
+	diff := kolide.HostDiff{
+		HostAID:               hostAID,
+		HostBID:               hostBID,
+		ChangedDetails:        []kolide.HostDetailChange{},
+		AddedExtraDetails:     []kolide.HostDetailChange{},
+		RemovedExtraDetails:   []kolide.HostDetailChange{},
+		NoExtraDetailsHostIDs: []uint{},
+	}
+	hostA, err := svc.ds.Host(hostAID)
+	if err != nil {
+		return diff, err
+	}
+	hostB, err := svc.ds.Host(hostBID)
+	if err != nil {
+		return diff, err
+	}
+
+	for field, value := range hostDiffFields {
+		a, b := value(hostA), value(hostB)
+		if a != b {
+			diff.ChangedDetails = append(diff.ChangedDetails, kolide.HostDetailChange{Field: field, A: a, B: b})
+		}
+	}
+
+	extraA, err := flattenExtraDetails(hostA)
+	if err != nil {
+		return diff, err
+	}
+	extraB, err := flattenExtraDetails(hostB)
+	if err != nil {
+		return diff, err
+	}
+	if extraA == nil {
+		diff.NoExtraDetailsHostIDs = append(diff.NoExtraDetailsHostIDs, hostAID)
+	}
+	if extraB == nil {
+		diff.NoExtraDetailsHostIDs = append(diff.NoExtraDetailsHostIDs, hostBID)
+	}
+	if extraA != nil && extraB != nil {
+		for field, a := range extraA {
+			b, ok := extraB[field]
+			switch {
+			case !ok:
+				diff.RemovedExtraDetails = append(diff.RemovedExtraDetails, kolide.HostDetailChange{Field: field, A: a})
+			case a != b:
+				diff.ChangedDetails = append(diff.ChangedDetails, kolide.HostDetailChange{Field: field, A: a, B: b})
+			}
+		}
+		for field, b := range extraB {
+			if _, ok := extraA[field]; !ok {
+				diff.AddedExtraDetails = append(diff.AddedExtraDetails, kolide.HostDetailChange{Field: field, B: b})
+			}
+		}
+	}
+
+	for _, changes := range [][]kolide.HostDetailChange{diff.ChangedDetails, diff.AddedExtraDetails, diff.RemovedExtraDetails} {
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	}
+	return diff, nil
+}
+
+// flattenExtraDetails returns the extra details of host keyed by the name of
+// the detail query and the column, or nil if the host has not reported extra
+// details yet.
+func flattenExtraDetails(host *kolide.Host) (map[string]string, error) {
+	if host.ExtraDetails == nil {
+		return nil, nil
+	}
+	details, err := hostExtraDetails(host)
+	if err != nil {
+		return nil, err
+	}
+	flat := make(map[string]string)
+	for name, columns := range details {
+		for column, value := range columns {
+			flat[name+"."+column] = value
+		}
+	}
+	return flat, nil
+}
+
+func (svc service) HostExport(ctx context.Context, hostID uint) (kolide.HostExport, error) {
+	export := kolide.HostExport{ExportedAt: svc.clock.Now()}
+	host, err := svc.ds.Host(hostID)
+	if err != nil {
+		return export, err
+	}
+	export.Host = host
+	export.Status = host.Status(export.ExportedAt)
+
+	if export.ExtraDetails, err = hostExtraDetails(host); err != nil {
+		return export, err
+	}
+	if export.Labels, err = svc.ds.ListLabelsForHost(hostID); err != nil {
+		return export, errors.Wrap(err, "listing labels for host")
+	}
+
+	hostPacks, err := svc.hostPacks(hostID)
+	if err != nil {
+		return export, err
+	}
+	export.Packs = []*kolide.Pack{}
+	export.ScheduledQueries = []kolide.ScheduledQuery{}
+	for _, hp := range hostPacks {
+		export.Packs = append(export.Packs, hp.pack)
+		for _, query := range hp.queries {
+			export.ScheduledQueries = append(export.ScheduledQueries, *query)
+		}
+	}
+
+	if export.ScheduledQueryStats, err = svc.ds.ListScheduledQueryStatsForHost(hostID); err != nil {
+		return export, errors.Wrap(err, "listing scheduled query stats for host")
+	}
+	if export.Reboots, err = svc.ds.ListHostUptimeEvents(hostID); err != nil {
+		return export, errors.Wrap(err, "listing reboots for host")
+	}
+	export.Activities, err = svc.ds.ListHostActivities(hostID, kolide.HostActivityListOptions{
+		ListOptions: kolide.ListOptions{OrderKey: "id", OrderDirection: kolide.OrderDescending},
+	})
+	if err != nil {
+		return export, errors.Wrap(err, "listing activities for host")
+	}
+	return export, nil
+}
+
+func (svc service) HostsByOsqueryVersion(ctx context.Context) ([]kolide.OsqueryVersionCount, error) {
+	config, err := svc.ds.AppConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "retrieving app config")
+	}
+	aggregates, err := svc.ds.AggregateHosts("osquery_version")
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]kolide.OsqueryVersionCount, 0, len(aggregates))
+	for _, agg := range aggregates {
+		versions = append(versions, kolide.OsqueryVersionCount{
+			Version:  agg.Value,
+			Hosts:    agg.Count,
+			Outdated: osqueryVersionOutdated(agg.Value, config.RequiredOsqueryVersion),
+		})
+	}
+	return versions, nil
+}
+
+func (svc service) HostsByEncryptionStatus(ctx context.Context) ([]kolide.DiskEncryptionCount, error) {
+	aggregates, err := svc.ds.AggregateHosts("disk_encryption")
+	if err != nil {
+		return nil, err
+	}
+	hosts := make(map[string]uint, len(aggregates))
+	for _, agg := range aggregates {
+		status := agg.Value
+		if !kolide.IsDiskEncryptionStatus(status) {
+			status = kolide.DiskEncryptionUnknown
+		}
+		hosts[status] += agg.Count
+	}
+	counts := make([]kolide.DiskEncryptionCount, 0, len(kolide.DiskEncryptionStatuses))
+	for _, status := range kolide.DiskEncryptionStatuses {
+		counts = append(counts, kolide.DiskEncryptionCount{Status: status, Hosts: hosts[status]})
+	}
+	return counts, nil
+}
+
+// osqueryVersionOutdated returns whether version is below the required
+// version. Nothing is outdated when no version is required, and versions that
+// cannot be parsed, such as those of hosts that have not reported details
+// yet, are not flagged.
+func osqueryVersionOutdated(version, required string) bool {
+	req, ok := parseOsqueryVersion(required)
+	if !ok {
+		return false
+	}
+	v, ok := parseOsqueryVersion(version)
+	if !ok {
+		return false
+	}
+	for i := range req {
+		if v[i] != req[i] {
+			return v[i] < req[i]
+		}
+	}
+	return false
+}
+
+// parseOsqueryVersion parses the major, minor and patch numbers of an osquery
+// version. Missing minor and patch numbers are zero, and suffixes such as the
+// commit of development builds (4.4.0-12-gd883d7f) are ignored.
+func parseOsqueryVersion(version string) ([3]int, bool) {
+	var parsed [3]int
+	version = strings.TrimSpace(version)
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) > len(parsed) {
+		return parsed, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return parsed, false
+		}
+		parsed[i] = n
+	}
+	return parsed, true
+}
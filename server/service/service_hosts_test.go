@@ -1,13 +1,23 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/WatchBeam/clock"
 	"github.com/kolide/fleet/server/config"
+	hostctx "github.com/kolide/fleet/server/contexts/host"
 	"github.com/kolide/fleet/server/datastore/inmem"
 	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/mock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestListHosts(t *testing.T) {
@@ -19,7 +29,7 @@ func TestListHosts(t *testing.T) {
 
 	ctx := context.Background()
 
-	hosts, err := svc.ListHosts(ctx, kolide.ListOptions{})
+	hosts, _, err := svc.ListHosts(ctx, kolide.HostListOptions{})
 	assert.Nil(t, err)
 	assert.Len(t, hosts, 0)
 
@@ -28,11 +38,114 @@ func TestListHosts(t *testing.T) {
 	})
 	assert.Nil(t, err)
 
-	hosts, err = svc.ListHosts(ctx, kolide.ListOptions{})
+	hosts, _, err = svc.ListHosts(ctx, kolide.HostListOptions{})
 	assert.Nil(t, err)
 	assert.Len(t, hosts, 1)
 }
 
+func TestListHostsSeenStatus(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ctx := context.Background()
+
+	_, err = ds.NewHost(&kolide.Host{HostName: "online", NodeKey: "1", UUID: "1", SeenTime: time.Now()})
+	require.Nil(t, err)
+	_, err = ds.NewHost(&kolide.Host{HostName: "offline", NodeKey: "2", UUID: "2", SeenTime: time.Now().Add(-time.Hour)})
+	require.Nil(t, err)
+	_, err = ds.NewHost(&kolide.Host{HostName: "never", NodeKey: "3", UUID: "3"})
+	require.Nil(t, err)
+
+	hosts, _, err := svc.ListHosts(ctx, kolide.HostListOptions{
+		SeenStatus: kolide.StatusOnline,
+		SeenWithin: 10 * time.Minute,
+	})
+	require.Nil(t, err)
+	require.Len(t, hosts, 1)
+	assert.Equal(t, "online", hosts[0].HostName)
+
+	hosts, _, err = svc.ListHosts(ctx, kolide.HostListOptions{
+		SeenStatus: kolide.StatusOffline,
+		SeenWithin: 10 * time.Minute,
+	})
+	require.Nil(t, err)
+	assert.Len(t, hosts, 2)
+
+	_, _, err = svc.ListHosts(ctx, kolide.HostListOptions{SeenStatus: "mia", SeenWithin: time.Minute})
+	assert.IsType(t, &invalidArgumentError{}, err)
+
+	_, _, err = svc.ListHosts(ctx, kolide.HostListOptions{SeenStatus: kolide.StatusOnline})
+	assert.IsType(t, &invalidArgumentError{}, err)
+}
+
+func TestCountHosts(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ctx := context.Background()
+
+	_, err = ds.NewHost(&kolide.Host{HostName: "online", NodeKey: "1", UUID: "1", SeenTime: time.Now()})
+	require.Nil(t, err)
+	_, err = ds.NewHost(&kolide.Host{HostName: "offline", NodeKey: "2", UUID: "2", SeenTime: time.Now().Add(-time.Hour)})
+	require.Nil(t, err)
+	_, err = ds.NewHost(&kolide.Host{HostName: "never", NodeKey: "3", UUID: "3"})
+	require.Nil(t, err)
+
+	for _, opt := range []kolide.HostListOptions{
+		{},
+		{SeenStatus: kolide.StatusOnline, SeenWithin: 10 * time.Minute},
+		{SeenStatus: kolide.StatusOffline, SeenWithin: 10 * time.Minute},
+	} {
+		hosts, _, err := svc.ListHosts(ctx, opt)
+		require.Nil(t, err)
+		count, err := svc.CountHosts(ctx, opt)
+		require.Nil(t, err)
+		assert.Equal(t, len(hosts), count)
+	}
+
+	_, err = svc.CountHosts(ctx, kolide.HostListOptions{SeenStatus: "mia", SeenWithin: time.Minute})
+	assert.IsType(t, &invalidArgumentError{}, err)
+}
+
+func TestStreamHosts(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ds.StreamHostsFunc = func(opt kolide.HostListOptions, fn func(*kolide.Host) error) error {
+		assert.Equal(t, []uint{3}, opt.LabelIDs)
+		for _, host := range []*kolide.Host{{ID: 1, HostName: "foo"}, {ID: 2, HostName: "bar"}} {
+			if err := fn(host); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	err = svc.StreamHosts(context.Background(), kolide.HostListOptions{LabelIDs: []uint{3}}, &buf)
+	require.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var host HostResponse
+	require.Nil(t, json.Unmarshal([]byte(lines[1]), &host))
+	assert.Equal(t, uint(2), host.ID)
+	assert.Equal(t, "bar", host.DisplayText)
+	assert.Equal(t, kolide.StatusMIA, host.Status)
+
+	ds.StreamHostsFuncInvoked = false
+	err = svc.StreamHosts(context.Background(), kolide.HostListOptions{SeenStatus: "mia", SeenWithin: time.Minute}, &buf)
+	assert.IsType(t, &invalidArgumentError{}, err)
+	assert.False(t, ds.StreamHostsFuncInvoked)
+}
+
 func TestGetHost(t *testing.T) {
 	ds, err := inmem.New(config.TestConfig())
 	assert.Nil(t, err)
@@ -72,8 +185,709 @@ func TestDeleteHost(t *testing.T) {
 	err = svc.DeleteHost(ctx, host.ID)
 	assert.Nil(t, err)
 
-	hosts, err := ds.ListHosts(kolide.ListOptions{})
+	hosts, err := ds.ListHosts(kolide.HostListOptions{})
 	assert.Nil(t, err)
 	assert.Len(t, hosts, 0)
 
 }
+
+func TestSetHostMaintenance(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+
+	mockClock := clock.NewMockClock()
+	svc, err := newTestServiceWithClock(ds, nil, mockClock)
+	require.Nil(t, err)
+
+	ctx := context.Background()
+
+	host, err := ds.NewHost(&kolide.Host{HostName: "foo"})
+	require.Nil(t, err)
+
+	err = svc.SetHostMaintenance(ctx, host.ID, mockClock.Now())
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "must be in the future")
+
+	until := mockClock.Now().Add(time.Hour)
+	require.Nil(t, svc.SetHostMaintenance(ctx, host.ID, until))
+	host, err = ds.Host(host.ID)
+	require.Nil(t, err)
+	require.NotNil(t, host.MaintenanceUntil)
+	assert.Equal(t, until, *host.MaintenanceUntil)
+
+	// A zero time ends the maintenance window
+	require.Nil(t, svc.SetHostMaintenance(ctx, host.ID, time.Time{}))
+	host, err = ds.Host(host.ID)
+	require.Nil(t, err)
+	assert.Nil(t, host.MaintenanceUntil)
+
+	assert.NotNil(t, svc.SetHostMaintenance(ctx, host.ID+1, until))
+}
+
+func TestApproveHost(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ctx := context.Background()
+
+	host, err := ds.EnrollHost("host123", "key1", "default", 0, true)
+	require.Nil(t, err)
+	require.True(t, host.Pending)
+
+	require.Nil(t, svc.ApproveHost(ctx, host.ID))
+	host, err = ds.Host(host.ID)
+	require.Nil(t, err)
+	assert.False(t, host.Pending)
+	activities, err := ds.ListHostActivities(host.ID, kolide.HostActivityListOptions{})
+	require.Nil(t, err)
+	require.Len(t, activities, 1)
+	assert.Equal(t, kolide.HostActivityApproved, activities[0].Type)
+
+	// Approving a host that is not pending has no effect
+	require.Nil(t, svc.ApproveHost(ctx, host.ID))
+	activities, err = ds.ListHostActivities(host.ID, kolide.HostActivityListOptions{})
+	require.Nil(t, err)
+	assert.Len(t, activities, 1)
+
+	assert.NotNil(t, svc.ApproveHost(ctx, host.ID+1))
+}
+
+func TestRotateHostNodeKey(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ctx := context.Background()
+
+	host, err := ds.EnrollHost("host123", "key1", "default", 0, false)
+	require.Nil(t, err)
+	_, err = svc.AuthenticateHost(ctx, "key1")
+	require.Nil(t, err)
+
+	require.Nil(t, svc.RotateHostNodeKey(ctx, host.ID))
+
+	// The old node key is invalid, so the host must enroll again
+	_, err = svc.AuthenticateHost(ctx, "key1")
+	require.NotNil(t, err)
+	nodeInvalid, ok := err.(interface{ NodeInvalid() bool })
+	require.True(t, ok)
+	assert.True(t, nodeInvalid.NodeInvalid())
+
+	rotated, err := ds.Host(host.ID)
+	require.Nil(t, err)
+	assert.NotEmpty(t, rotated.NodeKey)
+	assert.NotEqual(t, "key1", rotated.NodeKey)
+	assert.Equal(t, "host123", rotated.OsqueryHostID)
+	activities, err := ds.ListHostActivities(host.ID, kolide.HostActivityListOptions{})
+	require.Nil(t, err)
+	require.Len(t, activities, 1)
+	assert.Equal(t, kolide.HostActivityNodeKeyRotated, activities[0].Type)
+
+	assert.NotNil(t, svc.RotateHostNodeKey(ctx, host.ID+1))
+}
+
+func TestMergeHosts(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	hosts := map[uint]*kolide.Host{
+		1: {ID: 1, Platform: "darwin"},
+		2: {ID: 2, Platform: "darwin"},
+		3: {ID: 3},
+		4: {ID: 4, Platform: "windows"},
+	}
+	ds.HostFunc = func(id uint) (*kolide.Host, error) {
+		if h, ok := hosts[id]; ok {
+			return h, nil
+		}
+		return nil, notFoundError{}
+	}
+	var merged []uint
+	ds.MergeHostsFunc = func(keepID uint, mergeIDs []uint) (kolide.HostMerge, error) {
+		merged = mergeIDs
+		return kolide.HostMerge{HostID: keepID, MergedHostIDs: mergeIDs, LabelMemberships: 2}, nil
+	}
+	var refreshed []uint
+	ds.SetHostsConfigRefreshFunc = func(hostIDs []uint, requested bool) error {
+		refreshed = hostIDs
+		return nil
+	}
+	var activity *kolide.HostActivity
+	ds.NewHostActivityFunc = func(a *kolide.HostActivity) (*kolide.HostActivity, error) {
+		activity = a
+		return a, nil
+	}
+
+	ctx := context.Background()
+	_, err = svc.MergeHosts(ctx, 1, []uint{})
+	assert.NotNil(t, err)
+	_, err = svc.MergeHosts(ctx, 1, []uint{1})
+	assert.NotNil(t, err)
+	_, err = svc.MergeHosts(ctx, 1, []uint{5})
+	assert.NotNil(t, err)
+	// Hosts with conflicting platforms are not merged
+	_, err = svc.MergeHosts(ctx, 1, []uint{2, 4})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "platform windows")
+	assert.False(t, ds.MergeHostsFuncInvoked)
+
+	// Duplicate IDs are merged once, and hosts without a platform can be
+	// merged into any host
+	merge, err := svc.MergeHosts(ctx, 1, []uint{2, 3, 2})
+	require.Nil(t, err)
+	assert.Equal(t, []uint{2, 3}, merged)
+	assert.Equal(t, 2, merge.LabelMemberships)
+	assert.Equal(t, []uint{1}, refreshed)
+	require.NotNil(t, activity)
+	assert.Equal(t, uint(1), activity.HostID)
+	assert.Equal(t, kolide.HostActivityMerged, activity.Type)
+}
+
+func TestDeleteHostsByLabel(t *testing.T) {
+	ms := new(mock.Store)
+	svc := service{ds: ms}
+
+	ms.LabelFunc = func(lid uint) (*kolide.Label, error) {
+		return &kolide.Label{ID: lid}, nil
+	}
+	ms.DeleteHostsByLabelFunc = func(lid uint) (int, error) {
+		assert.Equal(t, uint(3), lid)
+		return 42, nil
+	}
+
+	deleted, err := svc.DeleteHostsByLabel(context.Background(), 3)
+	require.Nil(t, err)
+	assert.Equal(t, 42, deleted)
+	assert.True(t, ms.DeleteHostsByLabelFuncInvoked)
+}
+
+func TestDeleteHostsByLabelNotFound(t *testing.T) {
+	ms := new(mock.Store)
+	svc := service{ds: ms}
+
+	ms.LabelFunc = func(lid uint) (*kolide.Label, error) {
+		return nil, errors.New("not found")
+	}
+
+	_, err := svc.DeleteHostsByLabel(context.Background(), 3)
+	require.NotNil(t, err)
+	assert.False(t, ms.DeleteHostsByLabelFuncInvoked)
+}
+
+func TestListHostsCursor(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		_, err := ds.NewHost(&kolide.Host{
+			HostName:      fmt.Sprintf("host%d", i),
+			OsqueryHostID: fmt.Sprint(i),
+			NodeKey:       fmt.Sprint(i),
+			UUID:          fmt.Sprint(i),
+		})
+		require.Nil(t, err)
+	}
+
+	after := ""
+	var names []string
+	for {
+		hosts, next, err := svc.ListHosts(ctx, kolide.HostListOptions{
+			ListOptions: kolide.ListOptions{PerPage: 2},
+			After:       &after,
+		})
+		require.Nil(t, err)
+		for _, h := range hosts {
+			names = append(names, h.HostName)
+		}
+		if next == "" {
+			break
+		}
+		after = next
+	}
+	assert.Equal(t, []string{"host0", "host1", "host2", "host3", "host4"}, names)
+
+	// Offset pagination does not return a cursor
+	_, next, err := svc.ListHosts(ctx, kolide.HostListOptions{ListOptions: kolide.ListOptions{PerPage: 2}})
+	require.Nil(t, err)
+	assert.Empty(t, next)
+
+	// Cursors are tied to the order they were created for
+	cursor, err := kolide.NewHostCursor(&kolide.Host{ID: 1}, "")
+	require.Nil(t, err)
+	_, _, err = svc.ListHosts(ctx, kolide.HostListOptions{
+		ListOptions: kolide.ListOptions{PerPage: 2, OrderKey: "hostname"},
+		After:       &cursor,
+	})
+	assert.NotNil(t, err)
+}
+
+func TestRefreshHostConfig(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	host, err := ds.NewHost(&kolide.Host{HostName: "foo", OsqueryHostID: "1", NodeKey: "1", UUID: "1"})
+	require.Nil(t, err)
+
+	// Unknown host IDs are ignored
+	require.Nil(t, svc.RefreshHostConfig(context.Background(), []uint{host.ID, 9999}))
+
+	host, err = ds.Host(host.ID)
+	require.Nil(t, err)
+	assert.True(t, host.ConfigRefreshRequested)
+}
+
+func TestTransferHosts(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ds.LabelFunc = func(lid uint) (*kolide.Label, error) {
+		switch lid {
+		case 1:
+			return &kolide.Label{ID: 1, LabelType: kolide.LabelTypeManual}, nil
+		case 2:
+			return &kolide.Label{ID: 2, LabelType: kolide.LabelTypeRegular}, nil
+		}
+		return nil, &mock.Error{Message: "not found"}
+	}
+	ds.TransferHostsToLabelFunc = func(lid uint, hostIDs []uint) ([]uint, error) {
+		var missing []uint
+		for _, hid := range hostIDs {
+			if hid > 3 {
+				missing = append(missing, hid)
+			}
+		}
+		return missing, nil
+	}
+	var refreshed []uint
+	ds.SetHostsConfigRefreshFunc = func(hostIDs []uint, requested bool) error {
+		assert.True(t, requested)
+		refreshed = hostIDs
+		return nil
+	}
+
+	ctx := context.Background()
+	require.Nil(t, svc.TransferHosts(ctx, []uint{1, 2}, 1))
+	assert.True(t, ds.TransferHostsToLabelFuncInvoked)
+	assert.Equal(t, []uint{1, 2}, refreshed)
+
+	refreshed = nil
+	err = svc.TransferHosts(ctx, []uint{1, 7, 8}, 1)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "[7 8]")
+	assert.Nil(t, refreshed)
+
+	ds.TransferHostsToLabelFuncInvoked = false
+	err = svc.TransferHosts(ctx, []uint{1}, 2)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "manual")
+	err = svc.TransferHosts(ctx, []uint{1}, 3)
+	require.NotNil(t, err)
+	err = svc.TransferHosts(ctx, nil, 1)
+	require.NotNil(t, err)
+	assert.False(t, ds.TransferHostsToLabelFuncInvoked)
+}
+
+func TestRefreshHostDetails(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	host, err := ds.NewHost(&kolide.Host{
+		HostName:         "foo",
+		OsqueryHostID:    "1",
+		NodeKey:          "1",
+		UUID:             "1",
+		DetailUpdateTime: time.Now(),
+	})
+	require.Nil(t, err)
+
+	require.Nil(t, svc.RefreshHostDetails(context.Background(), host.ID))
+
+	host, err = ds.Host(host.ID)
+	require.Nil(t, err)
+	assert.True(t, host.DetailUpdateTime.Before(time.Now().Add(-config.TestConfig().Osquery.DetailUpdateInterval)))
+
+	err = svc.RefreshHostDetails(context.Background(), 9999)
+	require.NotNil(t, err)
+	assert.True(t, kolide.IsNotFound(err))
+}
+
+func TestHostScheduledQueries(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ds.HostFunc = func(id uint) (*kolide.Host, error) {
+		if id != 1 {
+			return nil, &mock.Error{Message: "not found"}
+		}
+		return &kolide.Host{ID: 1}, nil
+	}
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return []*kolide.Pack{{ID: 1, Name: "monitoring"}, {ID: 2, Name: "empty"}}, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	ds.ListScheduledQueriesInPackFunc = func(pid uint, opt kolide.ListOptions) ([]*kolide.ScheduledQuery, error) {
+		if pid != 1 {
+			return []*kolide.ScheduledQuery{}, nil
+		}
+		return []*kolide.ScheduledQuery{
+			{ID: 1, PackID: 1, Name: "time", Query: "select * from time", Interval: 30},
+			{ID: 2, PackID: 1, Name: "disabled", Query: "select 1", Interval: 10, Disabled: true},
+			{ID: 3, PackID: 1, Name: "users", Query: "select * from users", Interval: 60},
+		}, nil
+	}
+
+	queries, err := svc.HostScheduledQueries(context.Background(), 1)
+	require.Nil(t, err)
+	require.Len(t, queries, 2)
+	assert.Equal(t, "time", queries[0].Name)
+	assert.Equal(t, "select * from time", queries[0].Query)
+	assert.Equal(t, uint(30), queries[0].Interval)
+	assert.Equal(t, "users", queries[1].Name)
+
+	_, err = svc.HostScheduledQueries(context.Background(), 2)
+	require.NotNil(t, err)
+	assert.True(t, kolide.IsNotFound(err))
+}
+
+func TestHostPacks(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ds.HostFunc = func(id uint) (*kolide.Host, error) {
+		if id != 1 {
+			return nil, &mock.Error{Message: "not found"}
+		}
+		return &kolide.Host{ID: 1}, nil
+	}
+	ds.ListLabelsForHostFunc = func(hid uint) ([]kolide.Label, error) {
+		return []kolide.Label{{ID: 10, Name: "macOS"}, {ID: 11, Name: "payments"}}, nil
+	}
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return []*kolide.Pack{{ID: 2, Name: "security"}, {ID: 1, Name: "monitoring"}}, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	ds.ListPacksFunc = func(opt kolide.ListOptions) ([]*kolide.Pack, error) {
+		return []*kolide.Pack{
+			{ID: 1, Name: "monitoring"},
+			{ID: 2, Name: "security"},
+			{ID: 3, Name: "audit", Disabled: true},
+			{ID: 4, Name: "linux", Disabled: true},
+			{ID: 5, Name: "unrelated"},
+		}, nil
+	}
+	ds.ListLabelsForPackFunc = func(pid uint) ([]*kolide.Label, error) {
+		switch pid {
+		case 1:
+			return []*kolide.Label{{ID: 10, Name: "macOS"}, {ID: 11, Name: "payments"}, {ID: 12, Name: "Ubuntu"}}, nil
+		case 3:
+			return []*kolide.Label{{ID: 11, Name: "payments"}}, nil
+		case 4:
+			return []*kolide.Label{{ID: 12, Name: "Ubuntu"}}, nil
+		}
+		return []*kolide.Label{}, nil
+	}
+	ds.ListExplicitHostsInPackFunc = func(pid uint, opt kolide.ListOptions) ([]uint, error) {
+		if pid == 2 {
+			return []uint{5, 1}, nil
+		}
+		return []uint{}, nil
+	}
+
+	packs, err := svc.HostPacks(context.Background(), 1)
+	require.Nil(t, err)
+	require.Len(t, packs, 3)
+
+	// Disabled packs are only included when they target the host
+	assert.Equal(t, "audit", packs[0].Pack.Name)
+	assert.True(t, packs[0].Disabled)
+	require.Len(t, packs[0].Labels, 1)
+	assert.Equal(t, "payments", packs[0].Labels[0].Name)
+
+	assert.Equal(t, "monitoring", packs[1].Pack.Name)
+	assert.False(t, packs[1].Disabled)
+	assert.False(t, packs[1].HostTargeted)
+	require.Len(t, packs[1].Labels, 2)
+	assert.Equal(t, "macOS", packs[1].Labels[0].Name)
+	assert.Equal(t, "payments", packs[1].Labels[1].Name)
+
+	assert.Equal(t, "security", packs[2].Pack.Name)
+	assert.True(t, packs[2].HostTargeted)
+	assert.Empty(t, packs[2].Labels)
+
+	_, err = svc.HostPacks(context.Background(), 2)
+	require.NotNil(t, err)
+	assert.True(t, kolide.IsNotFound(err))
+}
+
+func TestHostClientConfig(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	host := kolide.Host{ID: 1, Platform: "darwin"}
+	ds.HostFunc = func(id uint) (*kolide.Host, error) {
+		if id != 1 {
+			return nil, &mock.Error{Message: "not found"}
+		}
+		return &host, nil
+	}
+	ds.LabelOptionsForHostFunc = func(hid uint) (map[string]json.RawMessage, error) {
+		return nil, nil
+	}
+	ds.OptionsForPlatformFunc = func(platform string) (json.RawMessage, error) {
+		assert.Equal(t, "darwin", platform)
+		return json.RawMessage(`{"options": {"distributed_interval": 10}}`), nil
+	}
+	ds.IntervalsForPlatformFunc = func(platform string) (*kolide.OsqueryIntervals, error) {
+		interval := 60
+		return &kolide.OsqueryIntervals{DistributedInterval: &interval}, nil
+	}
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return []*kolide.Pack{{ID: 1, Name: "monitoring"}}, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	ds.ListScheduledQueriesInPackFunc = func(pid uint, opt kolide.ListOptions) ([]*kolide.ScheduledQuery, error) {
+		return []*kolide.ScheduledQuery{
+			{ID: 1, PackID: 1, Name: "time", Query: "select * from time", Interval: 30},
+		}, nil
+	}
+	ds.ListDecoratorsFunc = func(opts ...kolide.OptionalArg) ([]*kolide.Decorator, error) {
+		return []*kolide.Decorator{{Type: kolide.DecoratorLoad, Query: "select uuid from system_info"}}, nil
+	}
+	ds.ListATCTablesFunc = func() ([]*kolide.ATCTable, error) {
+		return nil, nil
+	}
+	ds.SaveHostFunc = func(host *kolide.Host) error {
+		return nil
+	}
+
+	config, err := svc.HostClientConfig(context.Background(), 1)
+	require.Nil(t, err)
+
+	// The preview matches the config served to the host
+	served, err := svc.GetClientConfig(hostctx.NewContext(context.Background(), host))
+	require.Nil(t, err)
+	servedJSON, err := json.Marshal(served)
+	require.Nil(t, err)
+	assert.JSONEq(t, string(servedJSON), string(config))
+	assert.Contains(t, string(config), `"select * from time"`)
+	assert.Contains(t, string(config), `"select uuid from system_info"`)
+	assert.Contains(t, string(config), `"distributed_interval":60`)
+
+	_, err = svc.HostClientConfig(context.Background(), 2)
+	require.NotNil(t, err)
+	assert.True(t, kolide.IsNotFound(err))
+}
+
+func TestAggregateHosts(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ds.AggregateHostsFunc = func(groupBy string) ([]kolide.HostAggregate, error) {
+		return []kolide.HostAggregate{{Value: "darwin", Count: 2}}, nil
+	}
+
+	aggregates, err := svc.AggregateHosts(context.Background(), "platform")
+	require.Nil(t, err)
+	assert.Equal(t, []kolide.HostAggregate{{Value: "darwin", Count: 2}}, aggregates)
+
+	_, err = svc.AggregateHosts(context.Background(), kolide.HostAggregateLabel)
+	require.Nil(t, err)
+
+	ds.AggregateHostsFuncInvoked = false
+	for _, groupBy := range []string{"", "node_key", "platform; DROP TABLE hosts"} {
+		_, err = svc.AggregateHosts(context.Background(), groupBy)
+		require.NotNil(t, err, groupBy)
+		assert.Contains(t, err.Error(), "group_by")
+	}
+	assert.False(t, ds.AggregateHostsFuncInvoked)
+}
+
+func TestDiffHosts(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	extraA := json.RawMessage(`{"chrome":{"version":"84.0"},"firewall":{"enabled":"1"}}`)
+	extraB := json.RawMessage(`{"chrome":{"version":"85.0"},"sshd":{"port":"22"}}`)
+	hosts := map[uint]*kolide.Host{
+		1: {ID: 1, HostName: "baseline", OSVersion: "Ubuntu 18.04", CPULogicalCores: 4, ExtraDetails: &extraA},
+		2: {ID: 2, HostName: "suspect", OSVersion: "Ubuntu 18.04", CPULogicalCores: 8, ExtraDetails: &extraB},
+		3: {ID: 3, HostName: "new", OSVersion: "Ubuntu 18.04", CPULogicalCores: 4},
+	}
+	ds.HostFunc = func(id uint) (*kolide.Host, error) {
+		host, ok := hosts[id]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return host, nil
+	}
+
+	diff, err := svc.DiffHosts(context.Background(), 1, 2)
+	require.Nil(t, err)
+	assert.Equal(t, []kolide.HostDetailChange{
+		{Field: "chrome.version", A: "84.0", B: "85.0"},
+		{Field: "cpu_logical_cores", A: "4", B: "8"},
+		{Field: "hostname", A: "baseline", B: "suspect"},
+	}, diff.ChangedDetails)
+	assert.Equal(t, []kolide.HostDetailChange{{Field: "sshd.port", B: "22"}}, diff.AddedExtraDetails)
+	assert.Equal(t, []kolide.HostDetailChange{{Field: "firewall.enabled", A: "1"}}, diff.RemovedExtraDetails)
+	assert.Empty(t, diff.NoExtraDetailsHostIDs)
+
+	// Extra details are not compared with a host that has not reported them
+	diff, err = svc.DiffHosts(context.Background(), 1, 3)
+	require.Nil(t, err)
+	assert.Equal(t, []kolide.HostDetailChange{{Field: "hostname", A: "baseline", B: "new"}}, diff.ChangedDetails)
+	assert.Empty(t, diff.AddedExtraDetails)
+	assert.Empty(t, diff.RemovedExtraDetails)
+	assert.Equal(t, []uint{3}, diff.NoExtraDetailsHostIDs)
+
+	_, err = svc.DiffHosts(context.Background(), 1, 4)
+	assert.NotNil(t, err)
+}
+
+func TestHostExport(t *testing.T) {
+	ds := new(mock.Store)
+	mockClock := clock.NewMockClock()
+	svc, err := newTestServiceWithClock(ds, nil, mockClock)
+	require.Nil(t, err)
+
+	extra := json.RawMessage(`{"chrome":{"version":"84.0"}}`)
+	host := &kolide.Host{ID: 1, HostName: "foo", ExtraDetails: &extra, SeenTime: mockClock.Now()}
+	ds.HostFunc = func(id uint) (*kolide.Host, error) {
+		if id != host.ID {
+			return nil, errors.New("not found")
+		}
+		return host, nil
+	}
+	ds.ListLabelsForHostFunc = func(hid uint) ([]kolide.Label, error) {
+		return []kolide.Label{{ID: 2, Name: "ubuntu"}}, nil
+	}
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return []*kolide.Pack{{ID: 3, Name: "monitoring"}}, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	ds.ListScheduledQueriesInPackFunc = func(id uint, opts kolide.ListOptions) ([]*kolide.ScheduledQuery, error) {
+		return []*kolide.ScheduledQuery{
+			{ID: 4, PackID: 3, Name: "processes"},
+			{ID: 5, PackID: 3, Name: "disabled", Disabled: true},
+		}, nil
+	}
+	ds.ListScheduledQueryStatsForHostFunc = func(hostID uint) ([]kolide.ScheduledQueryStats, error) {
+		return []kolide.ScheduledQueryStats{{HostID: hostID, ScheduledQueryID: 4, Executions: 10}}, nil
+	}
+	ds.ListHostUptimeEventsFunc = func(hostID uint) ([]*kolide.HostUptimeEvent, error) {
+		return []*kolide.HostUptimeEvent{{ID: 6, HostID: hostID}}, nil
+	}
+	ds.ListHostActivitiesFunc = func(hostID uint, opt kolide.HostActivityListOptions) ([]*kolide.HostActivity, error) {
+		assert.Equal(t, kolide.OrderDescending, opt.OrderDirection)
+		return []*kolide.HostActivity{{ID: 7, HostID: hostID, Type: kolide.HostActivityEnrolled}}, nil
+	}
+
+	export, err := svc.HostExport(context.Background(), 1)
+	require.Nil(t, err)
+	assert.Equal(t, mockClock.Now(), export.ExportedAt)
+	assert.Equal(t, host, export.Host)
+	assert.Equal(t, kolide.StatusOnline, export.Status)
+	assert.Equal(t, kolide.HostExtraDetails{"chrome": {"version": "84.0"}}, export.ExtraDetails)
+	assert.Equal(t, []kolide.Label{{ID: 2, Name: "ubuntu"}}, export.Labels)
+	require.Len(t, export.Packs, 1)
+	assert.Equal(t, uint(3), export.Packs[0].ID)
+	require.Len(t, export.ScheduledQueries, 1)
+	assert.Equal(t, "processes", export.ScheduledQueries[0].Name)
+	assert.Len(t, export.ScheduledQueryStats, 1)
+	assert.Len(t, export.Reboots, 1)
+	assert.Len(t, export.Activities, 1)
+
+	_, err = svc.HostExport(context.Background(), 2)
+	assert.NotNil(t, err)
+}
+
+func TestHostsByOsqueryVersion(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	required := ""
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{RequiredOsqueryVersion: required}, nil
+	}
+	ds.AggregateHostsFunc = func(groupBy string) ([]kolide.HostAggregate, error) {
+		assert.Equal(t, "osquery_version", groupBy)
+		return []kolide.HostAggregate{
+			{Value: "4.4.0", Count: 10},
+			{Value: "3.3.2-12-gd883d7f", Count: 3},
+			{Value: "4.10.1", Count: 2},
+			{Value: "", Count: 1},
+		}, nil
+	}
+
+	// Nothing is outdated when no version is required
+	versions, err := svc.HostsByOsqueryVersion(context.Background())
+	require.Nil(t, err)
+	require.Len(t, versions, 4)
+	for _, v := range versions {
+		assert.False(t, v.Outdated, v.Version)
+	}
+
+	required = "4.4"
+	versions, err = svc.HostsByOsqueryVersion(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, []kolide.OsqueryVersionCount{
+		{Version: "4.4.0", Hosts: 10},
+		{Version: "3.3.2-12-gd883d7f", Hosts: 3, Outdated: true},
+		{Version: "4.10.1", Hosts: 2},
+		{Version: "", Hosts: 1},
+	}, versions)
+}
+
+func TestHostsByEncryptionStatus(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ds.AggregateHostsFunc = func(groupBy string) ([]kolide.HostAggregate, error) {
+		assert.Equal(t, "disk_encryption", groupBy)
+		return []kolide.HostAggregate{
+			{Value: kolide.DiskEncryptionUnknown, Count: 7},
+			{Value: kolide.DiskEncryptionEncrypted, Count: 5},
+		}, nil
+	}
+
+	// Every status is returned, in the same order
+	counts, err := svc.HostsByEncryptionStatus(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, []kolide.DiskEncryptionCount{
+		{Status: kolide.DiskEncryptionEncrypted, Hosts: 5},
+		{Status: kolide.DiskEncryptionUnencrypted, Hosts: 0},
+		{Status: kolide.DiskEncryptionUnknown, Hosts: 7},
+	}, counts)
+}
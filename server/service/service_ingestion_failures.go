@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (svc service) ListIngestionFailures(ctx context.Context, opt kolide.ListOptions) ([]*kolide.IngestionFailure, error) {
+	if opt.OrderKey == "" {
+		opt.OrderKey = "id"
+		opt.OrderDirection = kolide.OrderDescending
+	}
+	return svc.ds.ListIngestionFailures(opt)
+}
+
+// recordIngestionFailure stores the error of a detail query result that
+// could not be ingested. The result is discarded either way, so a failure to
+// store the error is only logged.
+func (svc service) recordIngestionFailure(ctx context.Context, hostID uint, queryName string, ingestErr error) {
+	failure := &kolide.IngestionFailure{
+		HostID:    hostID,
+		QueryName: queryName,
+		Error:     ingestErr.Error(),
+		CreatedAt: svc.clock.Now(),
+	}
+	if _, err := svc.ds.NewIngestionFailure(failure); err != nil {
+		contextLogger(ctx, svc.logger).Log("msg", "record ingestion failure", "query", queryName, "err", err)
+	}
+}
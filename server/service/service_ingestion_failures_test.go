@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/WatchBeam/clock"
+	"github.com/kolide/fleet/server/config"
+	hostctx "github.com/kolide/fleet/server/contexts/host"
+	"github.com/kolide/fleet/server/datastore/inmem"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListIngestionFailures(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+
+	mockClock := clock.NewMockClock()
+	svc, err := newTestServiceWithClock(ds, nil, mockClock)
+	require.Nil(t, err)
+
+	host, err := ds.NewHost(&kolide.Host{HostName: "foo", NodeKey: "foo", UUID: "foo"})
+	require.Nil(t, err)
+	ctx := hostctx.NewContext(context.Background(), *host)
+
+	// Results that cannot be ingested are recorded and discarded, while the
+	// remaining results are stored
+	err = svc.SubmitDistributedQueryResults(ctx, kolide.OsqueryDistributedQueryResults{
+		hostDetailQueryPrefix + "uptime":        {{"total_seconds": "forever"}},
+		hostDetailQueryPrefix + "osquery_info":  {{"version": "4.4.0"}},
+		hostDetailQueryPrefix + "system_info":   {{"physical_memory": "a lot"}},
+		hostDetailQueryPrefix + "not_a_query":   {{"foo": "bar"}},
+		hostExtraDetailQueryPrefix + "firewall": {{"enabled": "1"}},
+	}, map[string]kolide.OsqueryStatus{}, nil)
+	require.NotNil(t, err)
+	assert.True(t, err.(osqueryError).Discard())
+
+	saved, err := ds.Host(host.ID)
+	require.Nil(t, err)
+	assert.Equal(t, "4.4.0", saved.OsqueryVersion)
+
+	// The most recent failures are listed first
+	failures, err := svc.ListIngestionFailures(context.Background(), kolide.ListOptions{})
+	require.Nil(t, err)
+	require.Len(t, failures, 3)
+	names := []string{}
+	for _, f := range failures {
+		assert.Equal(t, host.ID, f.HostID)
+		assert.Equal(t, mockClock.Now(), f.CreatedAt)
+		names = append(names, f.QueryName)
+	}
+	assert.ElementsMatch(t, []string{"uptime", "system_info", "not_a_query"}, names)
+	assert.True(t, failures[0].ID > failures[1].ID)
+
+	for _, f := range failures {
+		switch f.QueryName {
+		case "uptime":
+			assert.Contains(t, f.Error, `parsing "forever"`)
+		case "system_info":
+			assert.Contains(t, f.Error, `parsing "a lot"`)
+		case "not_a_query":
+			assert.Contains(t, f.Error, "unknown detail query not_a_query")
+		}
+	}
+
+	failures, err = svc.ListIngestionFailures(context.Background(), kolide.ListOptions{PerPage: 1, Page: 1})
+	require.Nil(t, err)
+	assert.Len(t, failures, 1)
+}
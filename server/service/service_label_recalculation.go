@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+)
+
+// labelRecalculationBatchSize is the most labels that are sent back to hosts
+// in each run, so that modifying many labels at once does not send hosts a
+// burst of label queries. The remaining labels are sent in later runs.
+const labelRecalculationBatchSize = 20
+
+func (svc service) RecalculateLabels(ctx context.Context) {
+	if svc.config.Osquery.LabelRecalculationInterval <= 0 {
+		return
+	}
+
+	ticker := svc.clock.NewTicker(svc.config.Osquery.LabelRecalculationInterval)
+	defer ticker.Stop()
+
+	for {
+		svc.recalculateLabels()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+		}
+	}
+}
+
+// recalculateLabels marks the executions of the most recently modified labels
+// so that their queries are sent at the next check in of the hosts that last
+// evaluated an earlier version of the label.
+func (svc service) recalculateLabels() {
+	ids, err := svc.ds.RefreshModifiedLabels(labelRecalculationBatchSize)
+	if err != nil {
+		svc.logger.Log("msg", "error recalculating labels", "err", err)
+		return
+	}
+	if len(ids) > 0 {
+		svc.logger.Log("msg", "recalculating modified labels", "labels", len(ids))
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/WatchBeam/clock"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecalculateLabels(t *testing.T) {
+	ds := new(mock.Store)
+	runs := make(chan int, 10)
+	ds.RefreshModifiedLabelsFunc = func(limit int) ([]uint, error) {
+		runs <- limit
+		return []uint{1, 2}, nil
+	}
+
+	mockClock := clock.NewMockClock()
+	conf := config.TestConfig()
+	svc := service{
+		ds:     ds,
+		config: conf,
+		clock:  mockClock,
+		logger: kitlog.NewNopLogger(),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		svc.RecalculateLabels(ctx)
+		close(done)
+	}()
+
+	// Labels are recalculated on start and then every interval
+	assert.Equal(t, labelRecalculationBatchSize, <-runs)
+	mockClock.AddTime(conf.Osquery.LabelRecalculationInterval)
+	assert.Equal(t, labelRecalculationBatchSize, <-runs)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RecalculateLabels did not return after the context was canceled")
+	}
+
+	// Errors are logged and do not stop the job
+	ds.RefreshModifiedLabelsFunc = func(limit int) ([]uint, error) {
+		return nil, errors.New("connection refused")
+	}
+	svc.recalculateLabels()
+	assert.True(t, ds.RefreshModifiedLabelsFuncInvoked)
+
+	// The job is disabled without a positive interval
+	ds.RefreshModifiedLabelsFuncInvoked = false
+	svc.config.Osquery.LabelRecalculationInterval = 0
+	svc.RecalculateLabels(context.Background())
+	require.False(t, ds.RefreshModifiedLabelsFuncInvoked)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// labelSyncTimeout bounds each request to the label sync URL.
+const labelSyncTimeout = 30 * time.Second
+
+// labelSyncResponse is returned by the label sync URL, mapping the names of
+// manual labels to the host identifiers of their members.
+type labelSyncResponse struct {
+	Labels map[string][]string `json:"labels"`
+}
+
+func (svc service) SyncExternalLabels(ctx context.Context) {
+	if svc.config.App.LabelSyncURL == "" {
+		return
+	}
+	if svc.config.App.LabelSyncInterval <= 0 {
+		svc.logger.Log("msg", "label sync disabled, interval must be positive", "interval", svc.config.App.LabelSyncInterval)
+		return
+	}
+
+	ticker := svc.clock.NewTicker(svc.config.App.LabelSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		svc.syncExternalLabels(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+		}
+	}
+}
+
+// syncExternalLabels fetches the label memberships from the label sync URL
+// and reconciles each listed label. Nothing is changed when the memberships
+// cannot be fetched, and a label that fails to reconcile does not prevent
+// the others from being synced.
+func (svc service) syncExternalLabels(ctx context.Context) {
+	labels, err := svc.fetchExternalLabels(ctx)
+	if err != nil {
+		svc.logger.Log("msg", "error fetching external labels", "err", err)
+		return
+	}
+	for name, identifiers := range labels {
+		if err := svc.syncExternalLabel(name, identifiers); err != nil {
+			svc.logger.Log("msg", "error syncing external label", "label", name, "err", err)
+		}
+	}
+}
+
+func (svc service) fetchExternalLabels(ctx context.Context) (map[string][]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, labelSyncTimeout)
+	defer cancel()
+
+	req, err := http.NewRequest("GET", svc.config.App.LabelSyncURL, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create label sync request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if svc.config.App.LabelSyncAuthHeader != "" {
+		req.Header.Set("Authorization", svc.config.App.LabelSyncAuthHeader)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "label sync request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil, errors.Errorf("label sync returned status %d", resp.StatusCode)
+	}
+
+	var body labelSyncResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, errors.Wrap(err, "decode label sync response")
+	}
+	if body.Labels == nil {
+		return nil, errors.New("label sync response is missing labels")
+	}
+	return body.Labels, nil
+}
+
+// syncExternalLabel makes the hosts with the given identifiers the only
+// members of the named manual label. Hosts are matched on the identifier they
+// enrolled with, as chosen by osquery_host_identifier, since hostnames are not
+// unique. Identifiers that do not match a host are ignored.
+func (svc service) syncExternalLabel(name string, identifiers []string) error {
+	ids, err := svc.ds.LabelIDsByName([]string{name})
+	if err != nil {
+		return errors.Wrap(err, "look up label")
+	}
+	if len(ids) == 0 {
+		return errors.New("no label with this name")
+	}
+	lid := ids[0]
+	if err := svc.checkManualLabel(lid); err != nil {
+		return err
+	}
+
+	want, err := svc.ds.HostIDsByIdentifier(identifiers)
+	if err != nil {
+		return errors.Wrap(err, "look up hosts")
+	}
+	current, err := svc.ds.ListHostsInLabel(lid)
+	if err != nil {
+		return errors.Wrap(err, "list label members")
+	}
+
+	wanted := make(map[uint]bool, len(want))
+	for _, id := range want {
+		wanted[id] = true
+	}
+	var remove []uint
+	for _, h := range current {
+		if wanted[h.ID] {
+			delete(wanted, h.ID)
+		} else {
+			remove = append(remove, h.ID)
+		}
+	}
+	var add []uint
+	for _, id := range want {
+		if wanted[id] {
+			add = append(add, id)
+			delete(wanted, id)
+		}
+	}
+
+	if len(add) > 0 {
+		if err := svc.ds.AddHostsToLabel(lid, add); err != nil {
+			return errors.Wrap(err, "add hosts to label")
+		}
+	}
+	if len(remove) > 0 {
+		if err := svc.ds.RemoveHostsFromLabel(lid, remove); err != nil {
+			return errors.Wrap(err, "remove hosts from label")
+		}
+	}
+	return nil
+}
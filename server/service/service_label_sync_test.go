@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncExternalLabels(t *testing.T) {
+	status := http.StatusOK
+	response := `{"labels": {"payments": ["web01", "web02"], "computed": ["web01"], "missing": []}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer abc123", r.Header.Get("Authorization"))
+		w.WriteHeader(status)
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	ds := new(mock.Store)
+	ds.LabelIDsByNameFunc = func(names []string) ([]uint, error) {
+		switch names[0] {
+		case "payments":
+			return []uint{1}, nil
+		case "computed":
+			return []uint{2}, nil
+		}
+		return []uint{}, nil
+	}
+	ds.LabelFunc = func(lid uint) (*kolide.Label, error) {
+		if lid == 1 {
+			return &kolide.Label{ID: 1, LabelType: kolide.LabelTypeManual}, nil
+		}
+		return &kolide.Label{ID: lid, LabelType: kolide.LabelTypeComputed}, nil
+	}
+	ds.HostIDsByIdentifierFunc = func(identifiers []string) ([]uint, error) {
+		assert.Equal(t, []string{"web01", "web02"}, identifiers)
+		return []uint{10, 11}, nil
+	}
+	ds.ListHostsInLabelFunc = func(lid uint) ([]kolide.Host, error) {
+		require.Equal(t, uint(1), lid)
+		return []kolide.Host{{ID: 11}, {ID: 12}}, nil
+	}
+	var added, removed []uint
+	ds.AddHostsToLabelFunc = func(lid uint, hostIDs []uint) error {
+		added = hostIDs
+		return nil
+	}
+	ds.RemoveHostsFromLabelFunc = func(lid uint, hostIDs []uint) error {
+		removed = hostIDs
+		return nil
+	}
+
+	conf := config.TestConfig()
+	conf.App.LabelSyncURL = server.URL
+	conf.App.LabelSyncAuthHeader = "Bearer abc123"
+	svc := service{
+		ds:     ds,
+		config: conf,
+		logger: kitlog.NewNopLogger(),
+	}
+
+	// Only the manual label is reconciled
+	svc.syncExternalLabels(context.Background())
+	assert.Equal(t, []uint{10}, added)
+	assert.Equal(t, []uint{12}, removed)
+
+	// Memberships are left unchanged when the endpoint fails
+	ds.AddHostsToLabelFuncInvoked = false
+	ds.RemoveHostsFromLabelFuncInvoked = false
+	ds.LabelIDsByNameFuncInvoked = false
+	status = http.StatusInternalServerError
+	svc.syncExternalLabels(context.Background())
+	assert.False(t, ds.LabelIDsByNameFuncInvoked)
+
+	status = http.StatusOK
+	response = `{"labels": {"payments": [`
+	svc.syncExternalLabels(context.Background())
+	assert.False(t, ds.LabelIDsByNameFuncInvoked)
+
+	response = `{}`
+	svc.syncExternalLabels(context.Background())
+	assert.False(t, ds.LabelIDsByNameFuncInvoked)
+	assert.False(t, ds.AddHostsToLabelFuncInvoked)
+	assert.False(t, ds.RemoveHostsFromLabelFuncInvoked)
+}
@@ -2,12 +2,110 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"unicode"
 
 	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
 )
 
-func (svc service) ApplyLabelSpecs(ctx context.Context, specs []*kolide.LabelSpec) error {
-	return svc.ds.ApplyLabelSpecs(specs)
+func (svc service) ApplyLabelSpecs(ctx context.Context, specs []*kolide.LabelSpec, prune bool) (*kolide.ApplyLabelSpecsResult, error) {
+	names := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		if names[spec.Name] {
+			return nil, newInvalidArgumentError("name", fmt.Sprintf("label %s is specified more than once", spec.Name))
+		}
+		names[spec.Name] = true
+
+		if spec.Criteria != nil {
+			if spec.Query != "" {
+				return nil, newInvalidArgumentError("criteria", "computed labels must not specify a query")
+			}
+			spec.LabelType = kolide.LabelTypeComputed
+		} else if spec.LabelType == kolide.LabelTypeComputed {
+			return nil, newInvalidArgumentError("criteria", "computed labels must specify criteria")
+		} else if spec.LabelType == kolide.LabelTypeManual {
+			if spec.Query != "" {
+				return nil, newInvalidArgumentError("query", "manual labels must not specify a query")
+			}
+		} else if err := checkLabelQuery(spec.Query); err != nil {
+			return nil, newInvalidArgumentError("query", fmt.Sprintf("label %s: %s", spec.Name, err))
+		}
+	}
+	return svc.ds.SyncLabelSpecs(specs, prune)
+}
+
+// checkLabelQuery performs a lexical check of the SQL of a label query, so
+// that queries osquery would fail to parse are rejected when they are applied
+// rather than silently never matching. The query must be a single SELECT
+// statement, with terminated quotes and comments and balanced parentheses.
+func checkLabelQuery(query string) error {
+	if strings.TrimSpace(query) == "" {
+		return errors.New("query must not be empty")
+	}
+
+	var keyword string
+	depth := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			// Doubled quotes, which escape a quote, are scanned as two
+			// adjacent quoted strings
+			end := c
+			if c == '[' {
+				end = ']'
+			}
+			j := strings.IndexByte(query[i+1:], end)
+			if j < 0 {
+				return errors.Errorf("unterminated %c", c)
+			}
+			i += j + 1
+		case strings.HasPrefix(query[i:], "--"):
+			j := strings.IndexByte(query[i:], '\n')
+			if j < 0 {
+				j = len(query) - i
+			}
+			i += j
+		case strings.HasPrefix(query[i:], "/*"):
+			j := strings.Index(query[i+2:], "*/")
+			if j < 0 {
+				return errors.New("unterminated comment")
+			}
+			i += j + 3
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth < 0 {
+				return errors.New("unbalanced parentheses")
+			}
+		case c == ';':
+			if strings.TrimSpace(query[i+1:]) != "" {
+				return errors.New("query must be a single statement")
+			}
+			i = len(query)
+		case keyword == "" && !unicode.IsSpace(rune(c)):
+			j := strings.IndexFunc(query[i:], func(r rune) bool {
+				return !unicode.IsLetter(r)
+			})
+			if j < 0 {
+				j = len(query) - i
+			}
+			keyword = strings.ToLower(query[i : i+j])
+			if keyword != "select" && keyword != "with" {
+				return errors.New("query must be a SELECT statement")
+			}
+			i += j - 1
+		}
+	}
+	if keyword == "" {
+		return errors.New("query must be a SELECT statement")
+	}
+	if depth != 0 {
+		return errors.New("unbalanced parentheses")
+	}
+	return nil
 }
 
 func (svc service) GetLabelSpecs(ctx context.Context) ([]*kolide.LabelSpec, error) {
@@ -26,10 +124,25 @@ func (svc service) NewLabel(ctx context.Context, p kolide.LabelPayload) (*kolide
 	}
 	label.Name = *p.Name
 
-	if p.Query == nil {
+	switch {
+	case p.Manual && (p.Query != nil || p.Criteria != nil):
+		return nil, newInvalidArgumentError("manual", "manual labels must not specify a query or criteria")
+	case p.Manual:
+		// Members of manual labels are added explicitly with
+		// AddHostsToLabel.
+		label.LabelType = kolide.LabelTypeManual
+	case p.Criteria != nil && p.Query != nil:
+		return nil, newInvalidArgumentError("criteria", "computed labels must not specify a query")
+	case p.Criteria != nil:
+		// Membership of computed labels is evaluated by the server, so
+		// there is no query to distribute to hosts.
+		label.LabelType = kolide.LabelTypeComputed
+		label.Criteria = p.Criteria
+	case p.Query == nil:
 		return nil, newInvalidArgumentError("query", "missing required argument")
+	default:
+		label.Query = *p.Query
 	}
-	label.Query = *p.Query
 
 	if p.Platform != nil {
 		label.Platform = *p.Platform
@@ -39,6 +152,10 @@ func (svc service) NewLabel(ctx context.Context, p kolide.LabelPayload) (*kolide
 		label.Description = *p.Description
 	}
 
+	if p.DetailUpdateInterval != nil {
+		label.DetailUpdateInterval = *p.DetailUpdateInterval
+	}
+
 	label, err := svc.ds.NewLabel(label)
 	if err != nil {
 		return nil, err
@@ -57,6 +174,9 @@ func (svc service) ModifyLabel(ctx context.Context, id uint, payload kolide.Modi
 	if payload.Description != nil {
 		label.Description = *payload.Description
 	}
+	if payload.DetailUpdateInterval != nil {
+		label.DetailUpdateInterval = *payload.DetailUpdateInterval
+	}
 	return svc.ds.SaveLabel(label)
 }
 
@@ -91,3 +211,36 @@ func (svc service) HostIDsForLabel(lid uint) ([]uint, error) {
 	}
 	return ids, nil
 }
+
+func (svc service) AddHostsToLabel(ctx context.Context, lid uint, hostIDs []uint) error {
+	if err := svc.checkManualLabel(lid); err != nil {
+		return err
+	}
+	if len(hostIDs) == 0 {
+		return newInvalidArgumentError("host_ids", "must include at least one host")
+	}
+	return svc.ds.AddHostsToLabel(lid, hostIDs)
+}
+
+func (svc service) RemoveHostsFromLabel(ctx context.Context, lid uint, hostIDs []uint) error {
+	if err := svc.checkManualLabel(lid); err != nil {
+		return err
+	}
+	if len(hostIDs) == 0 {
+		return newInvalidArgumentError("host_ids", "must include at least one host")
+	}
+	return svc.ds.RemoveHostsFromLabel(lid, hostIDs)
+}
+
+// checkManualLabel returns an error if the label does not exist or its
+// membership is not maintained explicitly.
+func (svc service) checkManualLabel(lid uint) error {
+	label, err := svc.ds.Label(lid)
+	if err != nil {
+		return err
+	}
+	if label.LabelType != kolide.LabelTypeManual {
+		return newInvalidArgumentError("label_type", "hosts can only be added to or removed from manual labels")
+	}
+	return nil
+}
@@ -2,12 +2,16 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/kolide/fleet/server/config"
 	"github.com/kolide/fleet/server/datastore/inmem"
 	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/mock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetLabel(t *testing.T) {
@@ -31,3 +35,179 @@ func TestGetLabel(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, label.ID, labelVerify.ID)
 }
+
+func TestNewComputedLabel(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	assert.Nil(t, err)
+
+	svc, err := newTestService(ds, nil)
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+
+	_, err = svc.NewLabel(ctx, kolide.LabelPayload{
+		Name:     stringPtr("both"),
+		Query:    stringPtr("select 1"),
+		Criteria: &kolide.LabelCriteria{Platform: "darwin"},
+	})
+	assert.NotNil(t, err)
+
+	label, err := svc.NewLabel(ctx, kolide.LabelPayload{
+		Name:     stringPtr("offline macs"),
+		Criteria: &kolide.LabelCriteria{Platform: "darwin", OfflineFor: 24 * 60 * 60},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, kolide.LabelTypeComputed, label.LabelType)
+
+	now := time.Now()
+	stale, err := ds.NewHost(&kolide.Host{
+		OsqueryHostID: "1", NodeKey: "1", UUID: "1", HostName: "host1", Platform: "darwin", SeenTime: now.Add(-48 * time.Hour),
+	})
+	assert.Nil(t, err)
+	_, err = ds.NewHost(&kolide.Host{
+		OsqueryHostID: "2", NodeKey: "2", UUID: "2", HostName: "host2", Platform: "darwin", SeenTime: now,
+	})
+	assert.Nil(t, err)
+	_, err = ds.NewHost(&kolide.Host{
+		OsqueryHostID: "3", NodeKey: "3", UUID: "3", HostName: "host3", Platform: "ubuntu", SeenTime: now.Add(-48 * time.Hour),
+	})
+	assert.Nil(t, err)
+
+	ids, err := svc.HostIDsForLabel(label.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint{stale.ID}, ids)
+
+	// Computed labels are never distributed to hosts
+	queries, err := ds.LabelQueriesForHost(stale, now)
+	assert.Nil(t, err)
+	assert.NotContains(t, queries, fmt.Sprint(label.ID))
+}
+
+func TestManualLabelMembership(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	assert.Nil(t, err)
+
+	svc, err := newTestService(ds, nil)
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+
+	_, err = svc.NewLabel(ctx, kolide.LabelPayload{
+		Name:   stringPtr("manual with query"),
+		Query:  stringPtr("select 1"),
+		Manual: true,
+	})
+	assert.NotNil(t, err)
+
+	label, err := svc.NewLabel(ctx, kolide.LabelPayload{
+		Name:   stringPtr("golden image"),
+		Manual: true,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, kolide.LabelTypeManual, label.LabelType)
+
+	regular, err := svc.NewLabel(ctx, kolide.LabelPayload{
+		Name:  stringPtr("regular"),
+		Query: stringPtr("select 1"),
+	})
+	require.Nil(t, err)
+
+	h1, err := ds.NewHost(&kolide.Host{OsqueryHostID: "1", NodeKey: "1", UUID: "1", HostName: "host1"})
+	require.Nil(t, err)
+	h2, err := ds.NewHost(&kolide.Host{OsqueryHostID: "2", NodeKey: "2", UUID: "2", HostName: "host2"})
+	require.Nil(t, err)
+
+	err = svc.AddHostsToLabel(ctx, regular.ID, []uint{h1.ID})
+	assert.NotNil(t, err)
+	err = svc.AddHostsToLabel(ctx, label.ID, nil)
+	assert.NotNil(t, err)
+
+	require.Nil(t, svc.AddHostsToLabel(ctx, label.ID, []uint{h1.ID, h2.ID}))
+	ids, err := svc.HostIDsForLabel(label.ID)
+	require.Nil(t, err)
+	assert.Equal(t, []uint{h1.ID, h2.ID}, ids)
+
+	require.Nil(t, svc.RemoveHostsFromLabel(ctx, label.ID, []uint{h1.ID}))
+	ids, err = svc.HostIDsForLabel(label.ID)
+	require.Nil(t, err)
+	assert.Equal(t, []uint{h2.ID}, ids)
+
+	// Deleting a host removes it from all manual labels
+	require.Nil(t, ds.DeleteHost(h2.ID))
+	ids, err = svc.HostIDsForLabel(label.ID)
+	require.Nil(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestApplyLabelSpecs(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ctx := context.Background()
+
+	var gotPrune bool
+	ds.SyncLabelSpecsFunc = func(specs []*kolide.LabelSpec, prune bool) (*kolide.ApplyLabelSpecsResult, error) {
+		gotPrune = prune
+		return &kolide.ApplyLabelSpecsResult{Created: []string{specs[0].Name}}, nil
+	}
+
+	result, err := svc.ApplyLabelSpecs(ctx, []*kolide.LabelSpec{
+		{Name: "macs", Query: "select 1 from os_version where platform = 'darwin'"},
+		{Name: "offline", Criteria: &kolide.LabelCriteria{Status: "offline"}},
+		{Name: "pilot", LabelType: kolide.LabelTypeManual},
+	}, true)
+	require.Nil(t, err)
+	assert.True(t, gotPrune)
+	assert.Equal(t, []string{"macs"}, result.Created)
+
+	ds.SyncLabelSpecsFuncInvoked = false
+	_, err = svc.ApplyLabelSpecs(ctx, []*kolide.LabelSpec{
+		{Name: "macs", Query: "select 1"},
+		{Name: "macs", Query: "select 2"},
+	}, false)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "more than once")
+
+	_, err = svc.ApplyLabelSpecs(ctx, []*kolide.LabelSpec{
+		{Name: "macs", Query: "select 1 from os_version where (platform = 'darwin'"},
+	}, false)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "label macs: unbalanced parentheses")
+	assert.False(t, ds.SyncLabelSpecsFuncInvoked)
+}
+
+func TestCheckLabelQuery(t *testing.T) {
+	var testCases = []struct {
+		query string
+		err   string
+	}{
+		{query: "select 1"},
+		{query: "SELECT * FROM os_version WHERE platform = 'darwin';"},
+		{query: "-- macOS hosts\nselect 1 from os_version /* (unbalanced */ where name = 'it''s'"},
+		{query: "with t as (select 1) select * from t"},
+		{query: "(select 1)"},
+		{query: `select "a;b", [c)] from t`},
+		{query: "", err: "must not be empty"},
+		{query: "  \n", err: "must not be empty"},
+		{query: "delete from users", err: "SELECT statement"},
+		{query: "1", err: "SELECT statement"},
+		{query: "-- only a comment", err: "SELECT statement"},
+		{query: "select 1; select 2", err: "single statement"},
+		{query: "select count(*", err: "unbalanced parentheses"},
+		{query: "select 1)", err: "unbalanced parentheses"},
+		{query: "select 'foo", err: "unterminated '"},
+		{query: "select 1 /* comment", err: "unterminated comment"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			err := checkLabelQuery(tt.query)
+			if tt.err == "" {
+				assert.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+			}
+		})
+	}
+}
@@ -1,24 +1,67 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-kit/kit/log"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/contexts/clientcert"
 	hostctx "github.com/kolide/fleet/server/contexts/host"
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/kolide/fleet/server/pubsub"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cast"
 )
 
+// rateLimitedLogRows counts the osquery log rows that exceeded the per host
+// log rate limit, by log type and the action taken.
+var rateLimitedLogRows = kitprometheus.NewCounterFrom(prometheus.CounterOpts{
+	Namespace: "osquery",
+	Subsystem: "logs",
+	Name:      "rate_limited_rows",
+	Help:      "Number of osquery log rows that exceeded the log rate limit.",
+}, []string{"log_type", "action"})
+
+// truncatedResultRows counts the osquery result rows that were removed for
+// exceeding the max result rows of their query.
+var truncatedResultRows = kitprometheus.NewCounterFrom(prometheus.CounterOpts{
+	Namespace: "osquery",
+	Subsystem: "logs",
+	Name:      "truncated_result_rows",
+	Help:      "Number of osquery result rows that exceeded the max result rows of their query.",
+}, []string{})
+
+// sampledResultRows counts the osquery result rows that were dropped by the
+// sample rate of their query.
+var sampledResultRows = kitprometheus.NewCounterFrom(prometheus.CounterOpts{
+	Namespace: "osquery",
+	Subsystem: "logs",
+	Name:      "sampled_result_rows",
+	Help:      "Number of osquery result rows dropped by the sample rate of their query.",
+}, []string{})
+
 type osqueryError struct {
 	message     string
 	nodeInvalid bool
+	retryAfter  time.Duration
+	// unavailable indicates a transient failure on the server side, such
+	// as the datastore being unreachable, so osquery should retry the
+	// request.
+	unavailable bool
+	// discard indicates that the request can never succeed, so osquery
+	// should drop it rather than retry.
+	discard bool
 }
 
 func (e osqueryError) Error() string {
@@ -29,6 +72,35 @@ func (e osqueryError) NodeInvalid() bool {
 	return e.nodeInvalid
 }
 
+// RetryAfter returns the duration osquery should wait before retrying the
+// request. A zero value indicates that the request should not be retried.
+func (e osqueryError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+func (e osqueryError) Unavailable() bool {
+	return e.unavailable
+}
+
+func (e osqueryError) Discard() bool {
+	return e.discard
+}
+
+// distributedWriteRetryAfter is how long osquery is asked to wait before
+// resending distributed query results that could not be stored because of a
+// transient failure.
+const distributedWriteRetryAfter = 10 * time.Second
+
+// unavailableError returns an osqueryError asking osquery to retry a request
+// that failed because of a transient error.
+func unavailableError(message string, err error) osqueryError {
+	return osqueryError{
+		message:     message + ": " + err.Error(),
+		unavailable: true,
+		retryAfter:  distributedWriteRetryAfter,
+	}
+}
+
 // Sometimes osquery gives us empty string where we expect an integer.
 // We change the to "0" so it can be handled by the appropriate string to
 // integer conversion function, as these will err on ""
@@ -71,14 +143,96 @@ func (svc service) AuthenticateHost(ctx context.Context, nodeKey string) (*kolid
 	return host, nil
 }
 
-func (svc service) EnrollAgent(ctx context.Context, enrollSecret, hostIdentifier string, hostDetails map[string](map[string]string)) (string, error) {
-	secretName, err := svc.ds.VerifyEnrollSecret(enrollSecret)
+// enrollSecretExpiryGrace is how long after its expiry an enroll secret is
+// still accepted, to allow for clock skew between Fleet and the host
+// deployment tooling.
+const enrollSecretExpiryGrace = 5 * time.Minute
+
+// verifyEnrollSecret returns the active enroll secret matching secret,
+// returning an error if there is none or it has expired.
+func (svc service) verifyEnrollSecret(secret string) (*kolide.EnrollSecret, error) {
+	s, err := svc.ds.VerifyEnrollSecret(secret)
 	if err != nil {
+		return nil, err
+	}
+	if s.ExpiresAt != nil && svc.clock.Now().After(s.ExpiresAt.Add(enrollSecretExpiryGrace)) {
+		return nil, errors.New("secret has expired")
+	}
+	return s, nil
+}
+
+// clientCertIdentity returns the host identity from the common name of the
+// verified TLS client certificate of the request, if client certificate
+// enrollment is enabled. Any certificate issued by the client CA is verified,
+// so an error is returned if its common name is not one that may enroll.
+func (svc service) clientCertIdentity(ctx context.Context) (string, bool, error) {
+	if !svc.config.Osquery.EnrollClientCert {
+		return "", false, nil
+	}
+	cert, ok := clientcert.FromContext(ctx)
+	if !ok || cert.Subject.CommonName == "" {
+		return "", false, nil
+	}
+	cn := cert.Subject.CommonName
+	if !matchCommonName(svc.config.Osquery.EnrollClientCertNames, cn) {
+		return "", false, errors.Errorf("client certificate common name '%s' is not allowed to enroll", cn)
+	}
+	return cn, true, nil
+}
+
+// matchCommonName returns whether cn matches one of the comma-separated
+// patterns, using the syntax of path.Match.
+func matchCommonName(patterns, cn string) bool {
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if ok, err := path.Match(pattern, cn); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCommonNamePatterns returns an error if one of the comma-separated
+// patterns is malformed.
+func validateCommonNamePatterns(patterns string) error {
+	for _, pattern := range strings.Split(patterns, ",") {
+		if _, err := path.Match(strings.TrimSpace(pattern), ""); err != nil {
+			return errors.Errorf("invalid common name pattern '%s'", strings.TrimSpace(pattern))
+		}
+	}
+	return nil
+}
+
+func (svc service) EnrollAgent(ctx context.Context, enrollSecret, hostIdentifier string, hostDetails map[string](map[string]string)) (string, error) {
+	// Hosts that do not provide an enroll secret may enroll with a client
+	// certificate instead. The certificate identifies the host, so the host
+	// identifier it reports is replaced by the certificate's common name.
+	var secretName string
+	var secretLabelID *uint
+	identity, certOK, certErr := svc.clientCertIdentity(ctx)
+	if certErr != nil && enrollSecret == "" {
 		return "", osqueryError{
-			message:     "enroll failed: " + err.Error(),
+			message:     "enroll failed: " + certErr.Error(),
 			nodeInvalid: true,
 		}
 	}
+	if certOK && enrollSecret == "" {
+		hostIdentifier = identity
+	} else {
+		secret, err := svc.verifyEnrollSecret(enrollSecret)
+		if err != nil {
+			return "", osqueryError{
+				message:     "enroll failed: " + err.Error(),
+				nodeInvalid: true,
+			}
+		}
+		secretName = secret.Name
+		secretLabelID = secret.LabelID
+		hostIdentifier = svc.hostIdentifier(hostIdentifier, hostDetails)
+	}
 
 	nodeKey, err := kolide.RandomText(svc.config.Osquery.NodeKeySize)
 	if err != nil {
@@ -88,23 +242,29 @@ func (svc service) EnrollAgent(ctx context.Context, enrollSecret, hostIdentifier
 		}
 	}
 
-	host, err := svc.ds.EnrollHost(hostIdentifier, nodeKey, secretName)
+	host, err := svc.ds.EnrollHost(hostIdentifier, nodeKey, secretName, svc.config.Osquery.EnrollCooldown, svc.config.Osquery.EnrollmentApproval)
 	if err != nil {
 		return "", osqueryError{message: "save enroll failed: " + err.Error(), nodeInvalid: true}
 	}
+	// A host that re-enrolled within the cooldown window keeps its existing
+	// node key, and is not recorded as enrolling again.
+	if host.NodeKey == nodeKey {
+		svc.recordHostActivity(ctx, host.ID, kolide.HostActivityEnrolled, nil)
+	}
 
 	// Save enrollment details if provided
+	logger := contextLogger(ctx, svc.logger)
 	save := false
 	if r, ok := hostDetails["os_version"]; ok {
-		detailQueries["os_version"].IngestFunc(svc.logger, host, []map[string]string{r})
+		detailQueries["os_version"].IngestFunc(logger, host, []map[string]string{r})
 		save = true
 	}
 	if r, ok := hostDetails["osquery_info"]; ok {
-		detailQueries["osquery_info"].IngestFunc(svc.logger, host, []map[string]string{r})
+		detailQueries["osquery_info"].IngestFunc(logger, host, []map[string]string{r})
 		save = true
 	}
 	if r, ok := hostDetails["system_info"]; ok {
-		detailQueries["system_info"].IngestFunc(svc.logger, host, []map[string]string{r})
+		detailQueries["system_info"].IngestFunc(logger, host, []map[string]string{r})
 		save = true
 	}
 	if save {
@@ -113,77 +273,238 @@ func (svc service) EnrollAgent(ctx context.Context, enrollSecret, hostIdentifier
 		}
 	}
 
+	// The label of the secret is a manual label, so the host is added to it
+	// as an explicit member.
+	if secretLabelID != nil {
+		err := svc.ds.AddHostsToLabel(*secretLabelID, []uint{host.ID})
+		if err != nil {
+			return "", osqueryError{message: "adding host to enroll secret label: " + err.Error(), nodeInvalid: true}
+		}
+	}
+
+	// Hosts that re-enrolled within the cooldown keep their existing node
+	// key, and are not reported as new enrollments.
+	if host.NodeKey == nodeKey {
+		svc.sendEnrollmentWebhook(*host, svc.clock.Now())
+	}
+
 	return host.NodeKey, nil
 }
 
-func (svc service) GetClientConfig(ctx context.Context) (map[string]interface{}, error) {
-	host, ok := hostctx.FromContext(ctx)
+// hostIdentifier returns the identifier used to match the enrolling host to
+// an existing host, as chosen by the osquery.host_identifier config. The
+// identifier provided by osquery is used if the chosen identifier is missing
+// from the host details.
+func (svc service) hostIdentifier(provided string, hostDetails map[string](map[string]string)) string {
+	var identifier string
+	switch svc.config.Osquery.HostIdentifier {
+	case config.HostIdentifierUUID:
+		identifier = hostDetails["osquery_info"]["uuid"]
+	case config.HostIdentifierHostname:
+		identifier = hostDetails["system_info"]["hostname"]
+	case config.HostIdentifierInstance:
+		identifier = hostDetails["osquery_info"]["instance_id"]
+	default:
+		return provided
+	}
+	if identifier == "" {
+		svc.logger.Log(
+			"msg", "host details missing host identifier, using provided identifier",
+			"host_identifier", svc.config.Osquery.HostIdentifier,
+			"provided", provided,
+		)
+		return provided
+	}
+	return identifier
+}
+
+// enrollmentWebhookPayload is the JSON body sent to the enrollment webhook.
+type enrollmentWebhookPayload struct {
+	HostID           uint      `json:"host_id"`
+	Hostname         string    `json:"hostname"`
+	EnrolledAt       time.Time `json:"enrolled_at"`
+	EnrollSecretName string    `json:"enroll_secret_name"`
+	OsqueryVersion   string    `json:"osquery_version"`
+	// OsqueryVersionOutdated is true when the host runs an osquery version
+	// below the required osquery version of the app config.
+	OsqueryVersionOutdated bool `json:"osquery_version_outdated"`
+}
+
+// sendEnrollmentWebhook notifies the enrollment webhook configured in the app
+// config, if any, that the host enrolled. Delivery happens in the background
+// so that it never delays enrollment, and failures are only logged.
+func (svc service) sendEnrollmentWebhook(host kolide.Host, enrolledAt time.Time) {
+	go func() {
+		config, err := svc.ds.AppConfig()
+		if err != nil {
+			svc.logger.Log("msg", "error retrieving app config for enrollment webhook", "err", err)
+			return
+		}
+		if config.EnrollmentWebhookURL == "" {
+			return
+		}
+
+		payload := enrollmentWebhookPayload{
+			HostID:           host.ID,
+			Hostname:         host.HostName,
+			EnrolledAt:       enrolledAt,
+			EnrollSecretName: host.EnrollSecretName,
+			OsqueryVersion:   host.OsqueryVersion,
+
+			OsqueryVersionOutdated: osqueryVersionOutdated(host.OsqueryVersion, config.RequiredOsqueryVersion),
+		}
+		err = svc.webhookSender.Send(context.Background(), config.EnrollmentWebhookURL, config.EnrollmentWebhookSecret, payload)
+		if err != nil {
+			svc.logger.Log("msg", "error sending enrollment webhook", "host_id", host.ID, "err", err)
+		}
+	}()
+}
+
+// scheduledQueryContent converts a scheduled query into the query stanza
+// expected by osqueryd. Snapshot queries log the full result set on each
+// run, so the removed setting only applies to differential queries and is
+// omitted for snapshot queries. Unset values are left out so that osqueryd
+// applies its own defaults.
+func scheduledQueryContent(query *kolide.ScheduledQuery) kolide.QueryContent {
+	queryContent := kolide.QueryContent{
+		Query:    query.Query,
+		Interval: query.Interval,
+		Platform: query.Platform,
+		Version:  query.Version,
+		Shard:    query.Shard,
+	}
+
+	if query.Snapshot != nil && *query.Snapshot {
+		snapshot := true
+		queryContent.Snapshot = &snapshot
+	} else if query.Removed != nil {
+		removed := *query.Removed
+		queryContent.Removed = &removed
+	}
+
+	return queryContent
+}
+
+// decoratorsFromConfig converts the decorators section of the osquery options
+// to kolide.Decorators. osquery accepts either a single query or a list of
+// queries for each interval, so both are handled here.
+func decoratorsFromConfig(section interface{}) (kolide.Decorators, error) {
+	var decs kolide.Decorators
+	if section == nil {
+		return decs, nil
+	}
+	m, ok := section.(map[string]interface{})
 	if !ok {
-		return nil, osqueryError{message: "internal error: missing host from request context"}
+		return decs, errors.New("decorators must be an object")
 	}
 
-	baseConfig, err := svc.ds.OptionsForPlatform(host.Platform)
-	if err != nil {
-		return nil, osqueryError{message: "internal error: fetching base config: " + err.Error()}
+	queries := func(v interface{}) ([]string, error) {
+		switch v := v.(type) {
+		case string:
+			return []string{v}, nil
+		case []interface{}:
+			var result []string
+			for _, q := range v {
+				s, ok := q.(string)
+				if !ok {
+					return nil, errors.New("decorator query must be a string")
+				}
+				result = append(result, s)
+			}
+			return result, nil
+		default:
+			return nil, errors.New("decorator queries must be a string or list of strings")
+		}
 	}
 
-	var config map[string]interface{}
-	err = json.Unmarshal(baseConfig, &config)
-	if err != nil {
-		return nil, osqueryError{message: "internal error: parsing base configuration: " + err.Error()}
+	var err error
+	if v, ok := m["load"]; ok {
+		if decs.Load, err = queries(v); err != nil {
+			return decs, err
+		}
+	}
+	if v, ok := m["always"]; ok {
+		if decs.Always, err = queries(v); err != nil {
+			return decs, err
+		}
+	}
+	if v, ok := m["interval"]; ok {
+		intervals, ok := v.(map[string]interface{})
+		if !ok {
+			return decs, errors.New("interval decorators must be an object")
+		}
+		decs.Interval = make(map[string][]string)
+		for interval, v := range intervals {
+			if decs.Interval[interval], err = queries(v); err != nil {
+				return decs, err
+			}
+		}
 	}
+	return decs, nil
+}
+
+// hostPack is a pack that applies to a host, with the scheduled queries of the
+// pack that are sent to the host.
+type hostPack struct {
+	pack    *kolide.Pack
+	queries []*kolide.ScheduledQuery
+}
 
-	packs, err := svc.ds.ListPacksForHost(host.ID)
+// hostPacks returns the packs that apply to the host. Both GetClientConfig and
+// HostScheduledQueries use it, so that the queries disclosed to a host are
+// exactly those in its configuration.
+func (svc service) hostPacks(hostID uint) ([]hostPack, error) {
+	packs, err := svc.ds.ListPacksForHost(hostID)
 	if err != nil {
-		return nil, osqueryError{message: "database error: " + err.Error()}
+		return nil, errors.Wrap(err, "listing packs for host")
+	}
+	denyList, err := svc.queryDenyList()
+	if err != nil {
+		return nil, err
 	}
 
-	packConfig := kolide.Packs{}
+	var hostPacks []hostPack
 	for _, pack := range packs {
-		// first, we must figure out what queries are in this pack
 		queries, err := svc.ds.ListScheduledQueriesInPack(pack.ID, kolide.ListOptions{})
 		if err != nil {
-			return nil, osqueryError{message: "database error: " + err.Error()}
+			return nil, errors.Wrap(err, "listing scheduled queries in pack")
 		}
 
-		// the serializable osquery config struct expects content in a
-		// particular format, so we do the conversion here
-		configQueries := kolide.Queries{}
+		hp := hostPack{pack: pack, queries: []*kolide.ScheduledQuery{}}
 		for _, query := range queries {
-			queryContent := kolide.QueryContent{
-				Query:    query.Query,
-				Interval: query.Interval,
-				Platform: query.Platform,
-				Version:  query.Version,
-				Removed:  query.Removed,
-				Shard:    query.Shard,
-			}
-
-			if query.Removed != nil {
-				queryContent.Removed = query.Removed
+			// disabled queries stay in the pack but are not scheduled
+			// on hosts. A pack with only disabled queries is still sent
+			// with an empty set of queries.
+			if query.Disabled {
+				continue
 			}
-
-			if query.Snapshot != nil && *query.Snapshot {
-				queryContent.Snapshot = query.Snapshot
+			// Queries saved before a matching pattern was added to
+			// the deny list are not sent to hosts either.
+			if _, denied := matchQueryDenyList(denyList, query.Query); denied {
+				continue
 			}
-
-			configQueries[query.Name] = queryContent
+			hp.queries = append(hp.queries, query)
 		}
+		hostPacks = append(hostPacks, hp)
+	}
+	return hostPacks, nil
+}
 
-		// finally, we add the pack to the client config struct with all of
-		// the pack's queries
-		packConfig[pack.Name] = kolide.PackContent{
-			Platform: pack.Platform,
-			Queries:  configQueries,
-		}
+func (svc service) GetClientConfig(ctx context.Context) (map[string]interface{}, error) {
+	host, ok := hostctx.FromContext(ctx)
+	if !ok {
+		return nil, osqueryError{message: "internal error: missing host from request context"}
 	}
 
-	if len(packConfig) > 0 {
-		packJSON, err := json.Marshal(packConfig)
-		if err != nil {
-			return nil, osqueryError{message: "internal error: marshal pack JSON: " + err.Error()}
-		}
-		config["packs"] = json.RawMessage(packJSON)
+	// Hosts pending enrollment approval receive no configuration until an
+	// admin approves them
+	if host.Pending {
+		return map[string]interface{}{}, nil
+	}
+
+	config, err := svc.clientConfig(host)
+	if err != nil {
+		return nil, osqueryError{message: "internal error: " + err.Error()}
 	}
 
 	// Save interval values if they have been updated. Note
@@ -214,10 +535,197 @@ func (svc service) GetClientConfig(ctx context.Context) (map[string]interface{},
 		}
 	}
 
+	// The requested refresh is satisfied by serving the current config
+	if host.ConfigRefreshRequested {
+		if err := svc.ds.SetHostsConfigRefresh([]uint{host.ID}, false); err != nil {
+			return nil, osqueryError{message: "internal error: clear config refresh: " + err.Error()}
+		}
+	}
+
+	return config, nil
+}
+
+// clientConfig generates the osquery config for the host from the options for
+// its platform, the packs that apply to it and the decorators. It is shared by
+// GetClientConfig and HostClientConfig so that the config previewed by admins
+// is the one served to the host.
+func (svc service) clientConfig(host kolide.Host) (map[string]interface{}, error) {
+	baseConfig, err := svc.ds.OptionsForPlatform(host.Platform)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching base config")
+	}
+
+	var config map[string]interface{}
+	err = json.Unmarshal(baseConfig, &config)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing base configuration")
+	}
+
+	if platform := intervalPlatform(host.Platform); platform != "" {
+		intervals, err := svc.ds.IntervalsForPlatform(platform)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetching platform intervals")
+		}
+		mergeOsqueryIntervals(config, intervals)
+	}
+
+	labelOptions, err := svc.ds.LabelOptionsForHost(host.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching label options")
+	}
+	if err := mergeLabelOptions(config, labelOptions); err != nil {
+		return nil, errors.Wrap(err, "merging label options")
+	}
+
+	hostPacks, err := svc.hostPacks(host.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading host packs")
+	}
+
+	now := svc.clock.Now()
+	packConfig := kolide.Packs{}
+	for _, hp := range hostPacks {
+		// the serializable osquery config struct expects content in a
+		// particular format, so we do the conversion here
+		configQueries := kolide.Queries{}
+		// Outside of its schedule window the pack is sent without queries,
+		// so that hosts stop running them at their next config refresh
+		if hp.pack.InScheduleWindow(now) {
+			for _, query := range hp.queries {
+				configQueries[query.Name] = scheduledQueryContent(query)
+			}
+		}
+
+		// finally, we add the pack to the client config struct with all of
+		// the pack's queries
+		packConfig[hp.pack.Name] = kolide.PackContent{
+			Platform: hp.pack.Platform,
+			Queries:  configQueries,
+		}
+	}
+
+	if len(packConfig) > 0 {
+		packJSON, err := json.Marshal(packConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal pack JSON")
+		}
+		config["packs"] = json.RawMessage(packJSON)
+	}
+
+	decorators, err := svc.ds.ListDecorators()
+	if err != nil {
+		return nil, errors.Wrap(err, "listing decorators")
+	}
+
+	if len(decorators) > 0 {
+		// decorators from the datastore are added to any set in the
+		// osquery options
+		decConfig, err := decoratorsFromConfig(config["decorators"])
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing decorators")
+		}
+		decJSON, err := json.Marshal(decConfig.Append(decorators))
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal decorators JSON")
+		}
+		config["decorators"] = json.RawMessage(decJSON)
+	}
+
+	atcTables, err := svc.ds.ListATCTables()
+	if err != nil {
+		return nil, errors.Wrap(err, "listing atc tables")
+	}
+	if len(atcTables) > 0 {
+		if err := mergeATCTables(config, atcTables); err != nil {
+			return nil, errors.Wrap(err, "merging atc tables")
+		}
+	}
+
 	return config, nil
 }
 
+// mergeLabelOptions merges the options overrides of the host's labels into
+// the options of config. Labels are applied in order of name, so when labels
+// set the same option the label whose name sorts last takes precedence.
+func mergeLabelOptions(config map[string]interface{}, labelOptions map[string]json.RawMessage) error {
+	if len(labelOptions) == 0 {
+		return nil
+	}
+
+	options, ok := config["options"].(map[string]interface{})
+	if !ok {
+		options = make(map[string]interface{})
+	}
+
+	labels := make([]string, 0, len(labelOptions))
+	for label := range labelOptions {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	for _, label := range labels {
+		var override map[string]interface{}
+		if err := json.Unmarshal(labelOptions[label], &override); err != nil {
+			return errors.Wrapf(err, "parsing options of label %s", label)
+		}
+		for k, v := range override {
+			options[k] = v
+		}
+	}
+	config["options"] = options
+
+	return nil
+}
+
+// intervalPlatform returns the platform of the interval overrides that apply
+// to hosts reporting the given platform, or an empty string if none apply.
+// Linux hosts report their distribution, such as ubuntu or centos, as their
+// platform.
+func intervalPlatform(platform string) string {
+	switch platform {
+	case "darwin", "windows":
+		return platform
+	case "", "freebsd":
+		return ""
+	}
+	return "linux"
+}
+
+// mergeOsqueryIntervals sets the intervals that are set in overrides in the
+// options of config.
+func mergeOsqueryIntervals(config map[string]interface{}, overrides *kolide.OsqueryIntervals) {
+	if overrides == nil {
+		return
+	}
+	options, ok := config["options"].(map[string]interface{})
+	if !ok {
+		options = make(map[string]interface{})
+	}
+	if overrides.ConfigRefresh != nil {
+		options["config_refresh"] = *overrides.ConfigRefresh
+	}
+	if overrides.DistributedInterval != nil {
+		options["distributed_interval"] = *overrides.DistributedInterval
+	}
+	if overrides.LoggerTLSPeriod != nil {
+		options["logger_tls_period"] = *overrides.LoggerTLSPeriod
+	}
+	config["options"] = options
+}
+
 func (svc service) SubmitStatusLogs(ctx context.Context, logs []json.RawMessage) error {
+	// The logs of hosts pending enrollment approval are dropped
+	if host, ok := hostctx.FromContext(ctx); ok && host.Pending {
+		return nil
+	}
+
+	logs, err := svc.limitLogs(ctx, "status", logs)
+	if err != nil {
+		return err
+	}
+	if len(logs) == 0 {
+		return nil
+	}
 	if err := svc.osqueryLogWriter.Status.Write(ctx, logs); err != nil {
 		return osqueryError{message: "error writing status logs: " + err.Error()}
 	}
@@ -225,12 +733,472 @@ func (svc service) SubmitStatusLogs(ctx context.Context, logs []json.RawMessage)
 }
 
 func (svc service) SubmitResultLogs(ctx context.Context, logs []json.RawMessage) error {
+	host, ok := hostctx.FromContext(ctx)
+	if !ok {
+		return osqueryError{message: "internal error: missing host from request context"}
+	}
+	// The logs of hosts pending enrollment approval are dropped
+	if host.Pending {
+		return nil
+	}
+
+	logs, err := svc.limitLogs(ctx, "result", logs)
+	if err != nil {
+		return err
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+
+	queries, err := svc.hostResultQueries(host)
+	if err != nil {
+		return err
+	}
+	logs = sampleResultLogs(queries, logs)
+	logs = svc.truncateResultLogs(host, queries, logs)
+	logs = mapResultLogs(queries, logs)
+
+	if len(svc.osqueryLogWriter.PackResults) > 0 {
+		var packLogs map[string][]json.RawMessage
+		logs, packLogs = svc.routePackResultLogs(queries, logs)
+		for plugin, pluginLogs := range packLogs {
+			if err := svc.osqueryLogWriter.PackResults[plugin].Write(ctx, pluginLogs); err != nil {
+				return osqueryError{message: "error writing result logs to " + plugin + ": " + err.Error()}
+			}
+		}
+	}
+
+	if len(logs) == 0 {
+		return nil
+	}
 	if err := svc.osqueryLogWriter.Result.Write(ctx, logs); err != nil {
 		return osqueryError{message: "error writing result logs: " + err.Error()}
 	}
 	return nil
 }
 
+// limitLogs applies the per host log rate limit to logs, returning the logs
+// that should be written. With the drop action, the rows beyond the budget of
+// the host are discarded. With the reject action, the request is rejected with
+// a retryable error once the budget is exhausted, so that osquery buffers the
+// logs and sends them again later. A batch that is only partly within the
+// budget is rejected in full without counting against it. Batches larger than
+// the limit could never be accepted, so they are handled as with the drop
+// action. Either way, the host is flagged as noisy.
+func (svc service) limitLogs(ctx context.Context, logType string, logs []json.RawMessage) ([]json.RawMessage, error) {
+	if svc.logBudget == nil || len(logs) == 0 {
+		return logs, nil
+	}
+	host, ok := hostctx.FromContext(ctx)
+	if !ok {
+		return nil, osqueryError{message: "internal error: missing host from request context"}
+	}
+
+	key := strconv.FormatUint(uint64(host.ID), 10)
+	action := svc.config.Osquery.LogRateLimitAction
+	if action == config.LogRateLimitActionReject && len(logs) <= svc.logBudget.Limit() {
+		// A rejected batch is sent again in full, so it is only accepted
+		// when it fits within the budget, and otherwise does not count
+		// against it.
+		ok, retryAfter := svc.logBudget.TakeAll(key, len(logs))
+		if ok {
+			return logs, nil
+		}
+		rateLimitedLogRows.With("log_type", logType, "action", action).Add(float64(len(logs)))
+		svc.recordNoisyHost(host, 0)
+		return nil, osqueryError{
+			message:    fmt.Sprintf("log rate limit exceeded, retry after %s", retryAfter),
+			retryAfter: retryAfter,
+		}
+	}
+
+	taken, _ := svc.logBudget.Take(key, len(logs))
+	if taken == len(logs) {
+		return logs, nil
+	}
+
+	dropped := len(logs) - taken
+	rateLimitedLogRows.With("log_type", logType, "action", config.LogRateLimitActionDrop).Add(float64(dropped))
+	svc.recordNoisyHost(host, uint(dropped))
+	return logs[:taken], nil
+}
+
+// recordNoisyHost flags the host as noisy. Failing to do so does not fail the
+// log submission, as the logs within the budget should still be written.
+func (svc service) recordNoisyHost(host kolide.Host, dropped uint) {
+	if err := svc.ds.RecordNoisyHost(host.ID, dropped, svc.clock.Now()); err != nil {
+		svc.logger.Log(
+			"msg", "error recording noisy host",
+			"host_id", host.ID,
+			"err", err,
+		)
+	}
+}
+
+// hostResultQuery is a scheduled query in one of the packs of a host.
+type hostResultQuery struct {
+	pack  *kolide.Pack
+	query *kolide.ScheduledQuery
+}
+
+// hostResultQueries returns the scheduled queries in the packs of the host,
+// keyed by the name osquery gives their result logs. As in the
+// osquery_schedule table, result logs name scheduled pack queries "pack",
+// followed by the pack name and query name, each preceded by the delimiter.
+func (svc service) hostResultQueries(host kolide.Host) (map[string]hostResultQuery, error) {
+	hostPacks, err := svc.hostPacks(host.ID)
+	if err != nil {
+		return nil, unavailableError("loading packs for host", err)
+	}
+	queries := map[string]hostResultQuery{}
+	if len(hostPacks) == 0 {
+		return queries, nil
+	}
+	delimiter, err := svc.packDelimiter(host)
+	if err != nil {
+		return nil, unavailableError("loading pack delimiter", err)
+	}
+	for _, hp := range hostPacks {
+		for _, query := range hp.queries {
+			name := "pack" + delimiter + hp.pack.Name + delimiter + query.Name
+			queries[name] = hostResultQuery{pack: hp.pack, query: query}
+		}
+	}
+	return queries, nil
+}
+
+// routePackResultLogs separates the result logs of queries in packs with a
+// logger plugin from the result logs for the default plugin. The pack results
+// are returned keyed by plugin. Packs with a plugin that is not configured
+// fall back to the default plugin.
+func (svc service) routePackResultLogs(queries map[string]hostResultQuery, logs []json.RawMessage) ([]json.RawMessage, map[string][]json.RawMessage) {
+	plugins := map[string]string{}
+	for name, q := range queries {
+		if _, ok := svc.osqueryLogWriter.PackResults[q.pack.LoggerPlugin]; ok {
+			plugins[name] = q.pack.LoggerPlugin
+		}
+	}
+	if len(plugins) == 0 {
+		return logs, nil
+	}
+
+	var defaultLogs []json.RawMessage
+	packLogs := map[string][]json.RawMessage{}
+	for _, raw := range logs {
+		var result struct {
+			Name string `json:"name"`
+		}
+		// Logs that cannot be parsed are left for the default plugin,
+		// which accepts anything
+		if err := json.Unmarshal(raw, &result); err == nil {
+			if plugin, ok := plugins[result.Name]; ok {
+				packLogs[plugin] = append(packLogs[plugin], raw)
+				continue
+			}
+		}
+		defaultLogs = append(defaultLogs, raw)
+	}
+	return defaultLogs, packLogs
+}
+
+// sampleResultLogs keeps a fraction of the result rows of the queries with a
+// sample rate below 1. Whether a row is kept depends only on the query name
+// and the contents of the row, so the same row is kept or dropped on every
+// host and in both the added and removed rows of differential logs. Logs of
+// sampled queries are marked with the "sample_rate" they were sampled at,
+// and event formatted logs for dropped rows are removed.
+func sampleResultLogs(queries map[string]hostResultQuery, logs []json.RawMessage) []json.RawMessage {
+	rates := map[string]float64{}
+	for name, q := range queries {
+		if q.query.SampleRate != nil && *q.query.SampleRate < 1 {
+			rates[name] = *q.query.SampleRate
+		}
+	}
+	if len(rates) == 0 {
+		return logs
+	}
+
+	var dropped int
+	kept := make([]json.RawMessage, 0, len(logs))
+	for _, raw := range logs {
+		// Logs that cannot be parsed are passed through unchanged
+		var result map[string]json.RawMessage
+		var name string
+		if err := json.Unmarshal(raw, &result); err != nil || json.Unmarshal(result["name"], &name) != nil {
+			kept = append(kept, raw)
+			continue
+		}
+		rate, ok := rates[name]
+		if !ok {
+			kept = append(kept, raw)
+			continue
+		}
+
+		switch {
+		case result["snapshot"] != nil:
+			dropped += sampleResultRows(result, "snapshot", name, rate)
+		case result["diffResults"] != nil:
+			var diff map[string]json.RawMessage
+			if err := json.Unmarshal(result["diffResults"], &diff); err != nil {
+				kept = append(kept, raw)
+				continue
+			}
+			dropped += sampleResultRows(diff, "added", name, rate)
+			dropped += sampleResultRows(diff, "removed", name, rate)
+			result["diffResults"], _ = json.Marshal(diff)
+		case result["columns"] != nil:
+			if !sampleResultRow(name, result["columns"], rate) {
+				dropped++
+				continue
+			}
+		}
+		result["sample_rate"], _ = json.Marshal(rate)
+		b, err := json.Marshal(result)
+		if err != nil {
+			kept = append(kept, raw)
+			continue
+		}
+		kept = append(kept, b)
+	}
+
+	sampledResultRows.Add(float64(dropped))
+	return kept
+}
+
+// sampleResultRows keeps the sampled rows in the field of result, returning
+// the number of rows dropped. As for truncateResultRows, fields that are not
+// arrays hold no rows.
+func sampleResultRows(result map[string]json.RawMessage, field, name string, rate float64) int {
+	var rows []json.RawMessage
+	if err := json.Unmarshal(result[field], &rows); err != nil {
+		return 0
+	}
+	sampled := make([]json.RawMessage, 0, len(rows))
+	for _, row := range rows {
+		if sampleResultRow(name, row, rate) {
+			sampled = append(sampled, row)
+		}
+	}
+	result[field], _ = json.Marshal(sampled)
+	return len(rows) - len(sampled)
+}
+
+// mapResultLogs applies the column mapping of each query to the rows of its
+// result logs, keeping only the mapped columns, renamed and in the order of
+// the mapping. Mapped columns that are missing from a row are left out of it,
+// and logs that cannot be parsed are passed through unchanged.
+func mapResultLogs(queries map[string]hostResultQuery, logs []json.RawMessage) []json.RawMessage {
+	mappings := map[string]kolide.ColumnMapping{}
+	for name, q := range queries {
+		if q.query.ColumnMapping != nil && len(*q.query.ColumnMapping) > 0 {
+			mappings[name] = *q.query.ColumnMapping
+		}
+	}
+	if len(mappings) == 0 {
+		return logs
+	}
+
+	for i, raw := range logs {
+		var result map[string]json.RawMessage
+		var name string
+		if err := json.Unmarshal(raw, &result); err != nil || json.Unmarshal(result["name"], &name) != nil {
+			continue
+		}
+		mapping, ok := mappings[name]
+		if !ok {
+			continue
+		}
+
+		switch {
+		case result["snapshot"] != nil:
+			mapResultRows(result, "snapshot", mapping)
+		case result["diffResults"] != nil:
+			var diff map[string]json.RawMessage
+			if err := json.Unmarshal(result["diffResults"], &diff); err != nil {
+				continue
+			}
+			mapResultRows(diff, "added", mapping)
+			mapResultRows(diff, "removed", mapping)
+			result["diffResults"], _ = json.Marshal(diff)
+		case result["columns"] != nil:
+			result["columns"] = mapResultRow(result["columns"], mapping)
+		}
+		if b, err := json.Marshal(result); err == nil {
+			logs[i] = b
+		}
+	}
+	return logs
+}
+
+// mapResultRows applies the mapping to the rows in the field of result. As
+// for sampleResultRows, fields that are not arrays hold no rows.
+func mapResultRows(result map[string]json.RawMessage, field string, mapping kolide.ColumnMapping) {
+	var rows []json.RawMessage
+	if err := json.Unmarshal(result[field], &rows); err != nil {
+		return
+	}
+	for i, row := range rows {
+		rows[i] = mapResultRow(row, mapping)
+	}
+	result[field], _ = json.Marshal(rows)
+}
+
+// mapResultRow returns the mapped columns of the row. The row is written
+// directly rather than marshaled from a map, which would sort the columns.
+func mapResultRow(row json.RawMessage, mapping kolide.ColumnMapping) json.RawMessage {
+	var columns map[string]json.RawMessage
+	if err := json.Unmarshal(row, &columns); err != nil {
+		return row
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for _, m := range mapping {
+		value, ok := columns[m.Column]
+		if !ok {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(m.Name())
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+// sampleResultRow returns whether the row of the named query is kept at the
+// sample rate. The row is hashed with its columns in sorted order, so that
+// the order osquery sends them in does not matter.
+func sampleResultRow(name string, row json.RawMessage, rate float64) bool {
+	var columns map[string]json.RawMessage
+	if err := json.Unmarshal(row, &columns); err == nil {
+		if b, err := json.Marshal(columns); err == nil {
+			row = b
+		}
+	}
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write(row)
+	// The top 53 bits of the hash give a uniform float in [0, 1)
+	return float64(h.Sum64()>>11)/(1<<53) < rate
+}
+
+// truncateResultLogs limits the rows in the result logs of each query to the
+// max result rows of the scheduled query, or to the osquery.max_result_rows
+// default when the query does not set it. Zero allows any number of rows.
+//
+// Snapshot and batched differential logs over the limit keep their first
+// rows, and are flagged with "truncated": true. Event formatted logs hold a
+// single row each, so the logs of a query beyond the limit within the
+// submitted batch are dropped. Either way, the removed rows are recorded on
+// the host.
+func (svc service) truncateResultLogs(host kolide.Host, queries map[string]hostResultQuery, logs []json.RawMessage) []json.RawMessage {
+	limited := svc.config.Osquery.MaxResultRows > 0
+	for _, q := range queries {
+		if q.query.MaxResultRows != nil {
+			limited = true
+			break
+		}
+	}
+	if !limited {
+		return logs
+	}
+	maxRows := func(name string) int {
+		if q, ok := queries[name]; ok && q.query.MaxResultRows != nil {
+			return int(*q.query.MaxResultRows)
+		}
+		return svc.config.Osquery.MaxResultRows
+	}
+
+	var truncated int
+	eventRows := map[string]int{}
+	kept := make([]json.RawMessage, 0, len(logs))
+	for _, raw := range logs {
+		// Logs that cannot be parsed are passed through unchanged
+		var result map[string]json.RawMessage
+		var name string
+		if err := json.Unmarshal(raw, &result); err != nil || json.Unmarshal(result["name"], &name) != nil {
+			kept = append(kept, raw)
+			continue
+		}
+		max := maxRows(name)
+		if max <= 0 {
+			kept = append(kept, raw)
+			continue
+		}
+
+		var removed int
+		switch {
+		case result["snapshot"] != nil:
+			_, removed = truncateResultRows(result, "snapshot", max)
+		case result["diffResults"] != nil:
+			var diff map[string]json.RawMessage
+			if err := json.Unmarshal(result["diffResults"], &diff); err != nil {
+				break
+			}
+			// Added rows are kept before removed rows
+			added, removedAdded := truncateResultRows(diff, "added", max)
+			_, removedRemoved := truncateResultRows(diff, "removed", max-added)
+			if removed = removedAdded + removedRemoved; removed > 0 {
+				result["diffResults"], _ = json.Marshal(diff)
+			}
+		case result["columns"] != nil:
+			eventRows[name]++
+			if eventRows[name] > max {
+				truncated++
+				continue
+			}
+		}
+		if removed == 0 {
+			kept = append(kept, raw)
+			continue
+		}
+		truncated += removed
+		result["truncated"] = json.RawMessage("true")
+		b, err := json.Marshal(result)
+		if err != nil {
+			kept = append(kept, raw)
+			continue
+		}
+		kept = append(kept, b)
+	}
+
+	if truncated > 0 {
+		truncatedResultRows.Add(float64(truncated))
+		if err := svc.ds.RecordTruncatedResults(host.ID, uint(truncated), svc.clock.Now()); err != nil {
+			svc.logger.Log(
+				"msg", "error recording truncated results",
+				"host_id", host.ID,
+				"err", err,
+			)
+		}
+	}
+	return kept
+}
+
+// truncateResultRows keeps at most max of the rows in the field of result,
+// returning the number of rows kept and removed. Fields that are not arrays,
+// such as the empty string osquery sends when no rows were added, hold no
+// rows.
+func truncateResultRows(result map[string]json.RawMessage, field string, max int) (kept, removed int) {
+	var rows []json.RawMessage
+	if err := json.Unmarshal(result[field], &rows); err != nil {
+		return 0, 0
+	}
+	if max < 0 {
+		max = 0
+	}
+	if len(rows) <= max {
+		return len(rows), 0
+	}
+	result[field], _ = json.Marshal(rows[:max])
+	return max, len(rows) - max
+}
+
 // hostLabelQueryPrefix is appended before the query name when a query is
 // provided as a label query. This allows the results to be retrieved when
 // osqueryd writes the distributed query results.
@@ -244,15 +1212,33 @@ const hostDetailQueryPrefix = "kolide_detail_query_"
 // provided as an additional query (additional info for hosts to retrieve).
 const hostAdditionalQueryPrefix = "kolide_additional_query_"
 
+// hostExtraDetailQueryPrefix is appended before the query name when a query is
+// provided as a host detail query defined by an admin.
+const hostExtraDetailQueryPrefix = "kolide_extra_detail_query_"
+
 // hostDistributedQueryPrefix is appended before the query name when a query is
 // run from a distributed query campaign
 const hostDistributedQueryPrefix = "kolide_distributed_query_"
 
+// scheduledQueryStatsQueryName is the detail query that reports the
+// performance of the queries scheduled on the host.
+const scheduledQueryStatsQueryName = "scheduled_query_stats"
+
+// uptimeQueryName is the detail query that reports the uptime of the host,
+// from which reboots are detected.
+const uptimeQueryName = "uptime"
+
+// defaultPackDelimiter is the osquery default for the pack_delimiter option,
+// which separates the pack and query names in the osquery_schedule table.
+const defaultPackDelimiter = "_"
+
 // detailQueries defines the detail queries that should be run on the host, as
 // well as how the results of those queries should be ingested into the
-// kolide.Host data model. This map should not be modified at runtime.
+// kolide.Host data model. Queries with Platforms are only run on hosts with
+// one of these platforms. This map should not be modified at runtime.
 var detailQueries = map[string]struct {
 	Query      string
+	Platforms  []string
 	IngestFunc func(logger log.Logger, host *kolide.Host, rows []map[string]string) error
 }{
 	"network_interface": {
@@ -463,7 +1449,7 @@ var detailQueries = map[string]struct {
 			return nil
 		},
 	},
-	"uptime": {
+	uptimeQueryName: {
 		Query: "select * from uptime limit 1",
 		IngestFunc: func(logger log.Logger, host *kolide.Host, rows []map[string]string) error {
 			if len(rows) != 1 {
@@ -481,19 +1467,116 @@ var detailQueries = map[string]struct {
 			return nil
 		},
 	},
+	"disk_encryption_darwin": {
+		// FileVault encrypts the volume mounted at the root
+		Query: `select de.encrypted from disk_encryption de
+                        join mounts m on m.device_alias = de.name
+                        where m.path = '/'`,
+		Platforms: []string{"darwin"},
+		IngestFunc: func(logger log.Logger, host *kolide.Host, rows []map[string]string) error {
+			host.DiskEncryption = diskEncryptionStatus(rows, "encrypted")
+			return nil
+		},
+	},
+	"disk_encryption_windows": {
+		// BitLocker protection is on when protection_status is 1
+		Query:     `select protection_status from bitlocker_info where drive_letter = 'C:'`,
+		Platforms: []string{"windows"},
+		IngestFunc: func(logger log.Logger, host *kolide.Host, rows []map[string]string) error {
+			host.DiskEncryption = diskEncryptionStatus(rows, "protection_status")
+			return nil
+		},
+	},
+	scheduledQueryStatsQueryName: {
+		Query: "select * from osquery_schedule",
+		// The stats are not stored on the host. They are ingested by
+		// ingestScheduledQueryStats, which resolves the scheduled
+		// queries they belong to.
+		IngestFunc: func(logger log.Logger, host *kolide.Host, rows []map[string]string) error {
+			return nil
+		},
+	},
+}
+
+// diskEncryptionStatus returns the disk encryption status reported in column
+// of the single row of a disk encryption detail query, where 1 means the disk
+// is encrypted. The status is unknown when the query failed or the disk was
+// not found.
+func diskEncryptionStatus(rows []map[string]string, column string) string {
+	if len(rows) != 1 {
+		return kolide.DiskEncryptionUnknown
+	}
+	switch rows[0][column] {
+	case "1":
+		return kolide.DiskEncryptionEncrypted
+	case "0":
+		return kolide.DiskEncryptionUnencrypted
+	}
+	return kolide.DiskEncryptionUnknown
+}
+
+// platformDetailQueries returns the detail queries, keyed by name, that are
+// run on hosts with the given platform.
+func platformDetailQueries(platform string) map[string]string {
+	queries := make(map[string]string, len(detailQueries))
+	for name, query := range detailQueries {
+		if len(query.Platforms) > 0 {
+			supported := false
+			for _, p := range query.Platforms {
+				supported = supported || p == platform
+			}
+			if !supported {
+				continue
+			}
+		}
+		queries[name] = query.Query
+	}
+	return queries
+}
+
+// detailUpdateInterval returns the interval at which the host should refresh
+// its details. This is the shortest override among the host's labels, or the
+// configured osquery.detail_update_interval if none of them set one.
+func (svc service) detailUpdateInterval(host kolide.Host) (time.Duration, error) {
+	labels, err := svc.ds.ListLabelsForHost(host.ID)
+	if err != nil {
+		return 0, err
+	}
+	var override uint
+	for _, label := range labels {
+		if label.DetailUpdateInterval > 0 && (override == 0 || label.DetailUpdateInterval < override) {
+			override = label.DetailUpdateInterval
+		}
+	}
+	if override == 0 {
+		return svc.config.Osquery.DetailUpdateInterval, nil
+	}
+	return time.Duration(override) * time.Second, nil
 }
 
 // hostDetailQueries returns the map of queries that should be executed by
 // osqueryd to fill in the host details
 func (svc service) hostDetailQueries(host kolide.Host) (map[string]string, error) {
 	queries := make(map[string]string)
-	if host.DetailUpdateTime.After(svc.clock.Now().Add(-svc.config.Osquery.DetailUpdateInterval)) {
+	interval, err := svc.detailUpdateInterval(host)
+	if err != nil {
+		return nil, osqueryError{message: "get detail update interval: " + err.Error()}
+	}
+	if host.DetailUpdateTime.After(svc.clock.Now().Add(-interval)) {
 		// No need to update already fresh details
 		return queries, nil
 	}
 
-	for name, query := range detailQueries {
-		queries[hostDetailQueryPrefix+name] = query.Query
+	for name, query := range platformDetailQueries(host.Platform) {
+		queries[hostDetailQueryPrefix+name] = query
+	}
+
+	extraQueries, err := svc.ds.ListHostDetailQueries()
+	if err != nil {
+		return nil, osqueryError{message: "get host detail queries: " + err.Error()}
+	}
+	for _, query := range extraQueries {
+		queries[hostExtraDetailQueryPrefix+query.Name] = query.Query
 	}
 
 	// Get additional queries
@@ -524,6 +1607,12 @@ func (svc service) GetDistributedQueries(ctx context.Context) (map[string]string
 		return nil, 0, osqueryError{message: "internal error: missing host from request context"}
 	}
 
+	// Hosts pending enrollment approval run no queries until an admin
+	// approves them
+	if host.Pending {
+		return map[string]string{}, 0, nil
+	}
+
 	queries, err := svc.hostDetailQueries(host)
 	if err != nil {
 		return nil, 0, err
@@ -546,6 +1635,13 @@ func (svc service) GetDistributedQueries(ctx context.Context) (map[string]string
 	}
 
 	for id, query := range distributedQueries {
+		timedOut, err := svc.completeTimedOutCampaign(id)
+		if err != nil {
+			return nil, 0, osqueryError{message: "checking campaign timeout: " + err.Error()}
+		}
+		if timedOut {
+			continue
+		}
 		queries[hostDistributedQueryPrefix+strconv.Itoa(int(id))] = query
 	}
 
@@ -560,31 +1656,207 @@ func (svc service) GetDistributedQueries(ctx context.Context) (map[string]string
 	return queries, accelerate, nil
 }
 
+// completeTimedOutCampaign marks the campaign completed if its execution
+// timeout has elapsed, returning true if so. Completed campaigns are no longer
+// sent to hosts.
+func (svc service) completeTimedOutCampaign(id uint) (bool, error) {
+	campaign, err := svc.ds.DistributedQueryCampaign(id)
+	if err != nil {
+		return false, errors.Wrap(err, "loading campaign")
+	}
+	if !campaign.TimedOut(svc.clock.Now()) {
+		return false, nil
+	}
+
+	if err := svc.completeCampaign(id); err != nil {
+		return false, errors.Wrap(err, "completing timed out campaign")
+	}
+	return true, nil
+}
+
+// rebootTolerance is how far the boot time estimated from the uptime of a
+// host may move between reports without being considered a reboot. It absorbs
+// the delay between osquery reading the uptime and Fleet receiving it, and
+// skew between the clocks of Fleet servers.
+const rebootTolerance = 5 * time.Minute
+
+// detectReboot updates the boot time of the host from the uptime it reported
+// at reportedAt, and returns an event if the host rebooted since its boot
+// time was last estimated. The boot time is derived from the uptime and the
+// server's clock, so skew of the host's clock does not matter, and hosts
+// that stop reporting for a while keep the same boot time until they report
+// again.
+func detectReboot(host *kolide.Host, reportedAt time.Time) *kolide.HostUptimeEvent {
+	if host.Uptime <= 0 {
+		return nil
+	}
+	bootTime := reportedAt.Add(-host.Uptime).Truncate(time.Second).UTC()
+	previous := host.BootTime
+	if previous != nil {
+		moved := bootTime.Sub(*previous)
+		if moved <= rebootTolerance && moved >= -rebootTolerance {
+			return nil
+		}
+	}
+	host.BootTime = &bootTime
+	// An earlier boot time corrects a previous estimate, and is not a
+	// reboot
+	if previous == nil || bootTime.Before(*previous) {
+		return nil
+	}
+	return &kolide.HostUptimeEvent{
+		HostID:           host.ID,
+		BootTime:         bootTime,
+		PreviousBootTime: *previous,
+		DetectedAt:       reportedAt,
+	}
+}
+
 // ingestDetailQuery takes the results of a detail query and modifies the
 // provided kolide.Host appropriately.
 func (svc service) ingestDetailQuery(host *kolide.Host, name string, rows []map[string]string) error {
 	trimmedQuery := strings.TrimPrefix(name, hostDetailQueryPrefix)
 	query, ok := detailQueries[trimmedQuery]
 	if !ok {
-		return osqueryError{message: "unknown detail query " + trimmedQuery}
+		return osqueryError{message: "unknown detail query " + trimmedQuery, discard: true}
 	}
 
 	err := query.IngestFunc(svc.logger, host, rows)
 	if err != nil {
 		return osqueryError{
 			message: fmt.Sprintf("ingesting query %s: %s", name, err.Error()),
+			discard: true,
 		}
 	}
 
 	return nil
 }
 
+// ingestExtraDetailQuery records the result of a host detail query defined by
+// an admin in details. Results with more than one row are discarded.
+func (svc service) ingestExtraDetailQuery(details kolide.HostExtraDetails, name string, rows []map[string]string) error {
+	trimmedQuery := strings.TrimPrefix(name, hostExtraDetailQueryPrefix)
+	switch len(rows) {
+	case 0:
+		details[trimmedQuery] = map[string]string{}
+	case 1:
+		details[trimmedQuery] = rows[0]
+	default:
+		return osqueryError{
+			message: fmt.Sprintf("host detail query %s returned %d rows, expected at most 1", trimmedQuery, len(rows)),
+			discard: true,
+		}
+	}
+	return nil
+}
+
+// ingestScheduledQueryStats stores the performance stats that the host
+// reports in the osquery_schedule table for the scheduled queries in its
+// packs. Queries that are not scheduled by Fleet are ignored.
+func (svc service) ingestScheduledQueryStats(host kolide.Host, rows []map[string]string) error {
+	delimiter, err := svc.packDelimiter(host)
+	if err != nil {
+		return unavailableError("loading pack delimiter", err)
+	}
+	hostPacks, err := svc.hostPacks(host.ID)
+	if err != nil {
+		return unavailableError("loading packs for host", err)
+	}
+
+	// osquery names scheduled pack queries "pack", followed by the pack
+	// name and query name, each preceded by the delimiter.
+	scheduledQueryIDs := map[string]uint{}
+	for _, hp := range hostPacks {
+		for _, query := range hp.queries {
+			name := "pack" + delimiter + hp.pack.Name + delimiter + query.Name
+			scheduledQueryIDs[name] = query.ID
+		}
+	}
+
+	stats := []kolide.ScheduledQueryStats{}
+	for _, row := range rows {
+		id, ok := scheduledQueryIDs[row["name"]]
+		if !ok {
+			continue
+		}
+		s, err := scheduledQueryStatsFromRow(row)
+		if err != nil {
+			return osqueryError{
+				message: fmt.Sprintf("parsing stats for %s: %s", row["name"], err.Error()),
+				discard: true,
+			}
+		}
+		s.HostID = host.ID
+		s.ScheduledQueryID = id
+		stats = append(stats, s)
+	}
+
+	if err := svc.ds.SaveScheduledQueryStats(host.ID, stats); err != nil {
+		return unavailableError("saving scheduled query stats", err)
+	}
+	return nil
+}
+
+// packDelimiter returns the pack_delimiter option in the host's osquery
+// configuration, or the osquery default if it is not set.
+func (svc service) packDelimiter(host kolide.Host) (string, error) {
+	baseConfig, err := svc.ds.OptionsForPlatform(host.Platform)
+	if err != nil {
+		return "", errors.Wrap(err, "fetching base config")
+	}
+	var config struct {
+		Options map[string]interface{} `json:"options"`
+	}
+	if err := json.Unmarshal(baseConfig, &config); err != nil {
+		return "", errors.Wrap(err, "parsing base config")
+	}
+	if delimiter, ok := config.Options["pack_delimiter"].(string); ok && delimiter != "" {
+		return delimiter, nil
+	}
+	return defaultPackDelimiter, nil
+}
+
+// scheduledQueryStatsFromRow parses a row of the osquery_schedule table.
+func scheduledQueryStatsFromRow(row map[string]string) (kolide.ScheduledQueryStats, error) {
+	var stats kolide.ScheduledQueryStats
+
+	lastExecuted, err := strconv.ParseInt(emptyToZero(row["last_executed"]), 10, 64)
+	if err != nil {
+		return stats, errors.Wrap(err, "parsing last_executed")
+	}
+	stats.LastExecuted = time.Unix(lastExecuted, 0).UTC()
+
+	executions, err := strconv.ParseUint(emptyToZero(row["executions"]), 10, 32)
+	if err != nil {
+		return stats, errors.Wrap(err, "parsing executions")
+	}
+	stats.Executions = uint(executions)
+
+	// osquery 5 reports wall_time in seconds and adds wall_time_ms.
+	// Earlier versions report wall_time in milliseconds.
+	wallTime := row["wall_time_ms"]
+	if wallTime == "" {
+		wallTime = row["wall_time"]
+	}
+	stats.WallTime, err = strconv.ParseUint(emptyToZero(wallTime), 10, 64)
+	if err != nil {
+		return stats, errors.Wrap(err, "parsing wall_time")
+	}
+
+	stats.OutputSize, err = strconv.ParseUint(emptyToZero(row["output_size"]), 10, 64)
+	if err != nil {
+		return stats, errors.Wrap(err, "parsing output_size")
+	}
+
+	return stats, nil
+}
+
 // ingestLabelQuery records the results of label queries run by a host
 func (svc service) ingestLabelQuery(host kolide.Host, query string, rows []map[string]string, results map[uint]bool) error {
 	trimmedQuery := strings.TrimPrefix(query, hostLabelQueryPrefix)
 	trimmedQueryNum, err := strconv.Atoi(emptyToZero(trimmedQuery))
 	if err != nil {
-		return errors.Wrap(err, "converting query from string to int")
+		return osqueryError{message: "unable to parse label ID: " + trimmedQuery, discard: true}
 	}
 	// A label query matches if there is at least one result for that
 	// query. We must also store negative results.
@@ -594,12 +1866,39 @@ func (svc service) ingestLabelQuery(host kolide.Host, query string, rows []map[s
 
 // ingestDistributedQuery takes the results of a distributed query and modifies the
 // provided kolide.Host appropriately.
-func (svc service) ingestDistributedQuery(host kolide.Host, name string, rows []map[string]string, failed bool) error {
+func (svc service) ingestDistributedQuery(host kolide.Host, name string, rows []map[string]string, failed bool, stats *kolide.OsqueryDistributedQueryStats) error {
 	trimmedQuery := strings.TrimPrefix(name, hostDistributedQueryPrefix)
 
 	campaignID, err := strconv.Atoi(emptyToZero(trimmedQuery))
 	if err != nil {
-		return osqueryError{message: "unable to parse campaign ID: " + trimmedQuery}
+		return osqueryError{message: "unable to parse campaign ID: " + trimmedQuery, discard: true}
+	}
+
+	// osquery may resend results after reconnecting, so results from a host
+	// that already reported for the campaign are discarded unless the
+	// campaign allows resubmission. Hosts keep their ID when they re-enroll,
+	// so a host that re-enrolls during the campaign is still counted once.
+	executed, err := svc.ds.DistributedQueryExecutionExists(uint(campaignID), host.ID)
+	if err != nil {
+		return unavailableError("checking execution", err)
+	}
+	if executed {
+		campaign, err := svc.ds.DistributedQueryCampaign(uint(campaignID))
+		if kolide.IsNotFound(errors.Cause(err)) {
+			return osqueryError{
+				message: fmt.Sprintf("unknown campaign %d", campaignID),
+				discard: true,
+			}
+		}
+		if err != nil {
+			return unavailableError("loading campaign", err)
+		}
+		if !campaign.AllowResubmission {
+			return osqueryError{
+				message: fmt.Sprintf("duplicate results for campaign %d", campaignID),
+				discard: true,
+			}
+		}
 	}
 
 	// Write the results to the pubsub store
@@ -607,6 +1906,7 @@ func (svc service) ingestDistributedQuery(host kolide.Host, name string, rows []
 		DistributedQueryCampaignID: uint(campaignID),
 		Host:                       host,
 		Rows:                       rows,
+		Stats:                      stats,
 	}
 	if failed {
 		// osquery errors are not currently helpful, but we should fix
@@ -615,47 +1915,81 @@ func (svc service) ingestDistributedQuery(host kolide.Host, name string, rows []
 		res.Error = &errString
 	}
 
+	orphaned := false
 	err = svc.resultStore.WriteResult(res)
 	if err != nil {
 		nErr, ok := err.(pubsub.Error)
 		if !ok || !nErr.NoSubscriber() {
-			return osqueryError{message: "writing results: " + err.Error()}
+			return unavailableError("writing results", err)
 		}
 
 		// If there are no subscribers, the campaign is "orphaned"
 		// and should be closed so that we don't continue trying to
-		// execute that query when we can't write to any subscriber
+		// execute that query when we can't write to any subscriber.
+		// A campaign whose client disconnected within the resume
+		// window is left running, as the result is buffered for the
+		// client to replay when it resumes.
 		campaign, err := svc.ds.DistributedQueryCampaign(uint(campaignID))
+		if kolide.IsNotFound(errors.Cause(err)) {
+			return osqueryError{
+				message: fmt.Sprintf("unknown campaign %d", campaignID),
+				discard: true,
+			}
+		}
 		if err != nil {
-			return osqueryError{message: "loading orphaned campaign: " + err.Error()}
+			return unavailableError("loading orphaned campaign", err)
 		}
+		orphaned = campaign.DisconnectedAt == nil ||
+			!svc.clock.Now().Before(campaign.DisconnectedAt.Add(campaignResumeWindow))
+	}
 
-		campaign.Status = kolide.QueryComplete
-		if err := svc.ds.SaveDistributedQueryCampaign(campaign); err != nil {
-			return osqueryError{message: "closing orphaned campaign: " + err.Error()}
+	// Record execution of the query. Resubmitted results are sent to the
+	// subscribers, but the host's first execution remains the one recorded.
+	if !executed {
+		status := kolide.ExecutionSucceeded
+		if failed {
+			status = kolide.ExecutionFailed
+		}
+		exec := &kolide.DistributedQueryExecution{
+			HostID:                     host.ID,
+			DistributedQueryCampaignID: uint(campaignID),
+			Status:                     status,
+			RowCount:                   uint(len(rows)),
+		}
+		if stats != nil {
+			exec.ExecutionDuration = time.Duration(stats.WallTimeMs) * time.Millisecond
 		}
-	}
 
-	// Record execution of the query
-	status := kolide.ExecutionSucceeded
-	if failed {
-		status = kolide.ExecutionFailed
-	}
-	exec := &kolide.DistributedQueryExecution{
-		HostID:                     host.ID,
-		DistributedQueryCampaignID: uint(campaignID),
-		Status:                     status,
+		_, err = svc.ds.NewDistributedQueryExecution(exec)
+		if err != nil {
+			return unavailableError("recording execution", err)
+		}
 	}
 
-	_, err = svc.ds.NewDistributedQueryExecution(exec)
-	if err != nil {
-		return osqueryError{message: "recording execution: " + err.Error()}
+	// The orphaned campaign is closed once the execution is recorded, so
+	// that the campaign webhook summary includes this host
+	if orphaned {
+		if err := svc.completeCampaign(uint(campaignID)); err != nil {
+			return unavailableError("closing orphaned campaign", err)
+		}
 	}
 
 	return nil
 }
 
-func (svc service) SubmitDistributedQueryResults(ctx context.Context, results kolide.OsqueryDistributedQueryResults, statuses map[string]kolide.OsqueryStatus) error {
+// SubmitDistributedQueryResults stores the results of the queries sent to a
+// host. Errors are returned so that osquery handles them appropriately:
+//
+//   - Results that can never be stored, such as results for an unknown campaign
+//     or detail query, are skipped while the remaining results are stored. A
+//     discard error is then returned, which is sent with a 200 status so that
+//     osquery drops the results instead of resending them.
+//   - Transient failures of the datastore or result store return an
+//     unavailable error, sent with a 503 status and Retry-After header so that
+//     osquery resends the results.
+//   - If the host no longer exists, a node_invalid error is returned so that
+//     osquery re-enrolls.
+func (svc service) SubmitDistributedQueryResults(ctx context.Context, results kolide.OsqueryDistributedQueryResults, statuses map[string]kolide.OsqueryStatus, stats map[string]*kolide.OsqueryDistributedQueryStats) error {
 	host, ok := hostctx.FromContext(ctx)
 
 	if !ok {
@@ -665,9 +1999,19 @@ func (svc service) SubmitDistributedQueryResults(ctx context.Context, results ko
 	var err error
 	detailUpdated := false // Whether detail or additional was updated
 	additionalResults := make(kolide.OsqueryDistributedQueryResults)
+	extraDetails := make(kolide.HostExtraDetails)
 	labelResults := map[uint]bool{}
+	uptimeReported := false
+	var discarded []string
 	for query, rows := range results {
 		switch {
+		case query == hostDetailQueryPrefix+scheduledQueryStatsQueryName:
+			err = svc.ingestScheduledQueryStats(host, rows)
+			detailUpdated = true
+		case query == hostDetailQueryPrefix+uptimeQueryName:
+			err = svc.ingestDetailQuery(&host, query, rows)
+			uptimeReported = err == nil && len(rows) == 1
+			detailUpdated = true
 		case strings.HasPrefix(query, hostDetailQueryPrefix):
 			err = svc.ingestDetailQuery(&host, query, rows)
 			detailUpdated = true
@@ -675,6 +2019,9 @@ func (svc service) SubmitDistributedQueryResults(ctx context.Context, results ko
 			name := strings.TrimPrefix(query, hostAdditionalQueryPrefix)
 			additionalResults[name] = rows
 			detailUpdated = true
+		case strings.HasPrefix(query, hostExtraDetailQueryPrefix):
+			err = svc.ingestExtraDetailQuery(extraDetails, query, rows)
+			detailUpdated = true
 		case strings.HasPrefix(query, hostLabelQueryPrefix):
 			err = svc.ingestLabelQuery(host, query, rows, labelResults)
 		case strings.HasPrefix(query, hostDistributedQueryPrefix):
@@ -682,22 +2029,39 @@ func (svc service) SubmitDistributedQueryResults(ctx context.Context, results ko
 			// status indicates a query error
 			status, ok := statuses[query]
 			failed := (ok && status != kolide.StatusOK)
-			err = svc.ingestDistributedQuery(host, query, rows, failed)
+			err = svc.ingestDistributedQuery(host, query, rows, failed, stats[query])
 		default:
-			err = osqueryError{message: "unknown query prefix: " + query}
+			err = osqueryError{message: "unknown query prefix: " + query, discard: true}
 		}
 
 		if err != nil {
-			return osqueryError{message: "failed to ingest result: " + err.Error()}
+			e, ok := err.(osqueryError)
+			if !ok {
+				e = osqueryError{message: err.Error()}
+			}
+			if e.Discard() {
+				if strings.HasPrefix(query, hostDetailQueryPrefix) {
+					svc.recordIngestionFailure(ctx, host.ID, strings.TrimPrefix(query, hostDetailQueryPrefix), err)
+				}
+				discarded = append(discarded, e.Error())
+				continue
+			}
+			e.message = "failed to ingest result: " + e.message
+			return e
 		}
 
 	}
 
 	if len(labelResults) > 0 {
+		previous, err := svc.ds.ListLabelsForHost(host.ID)
+		if err != nil {
+			return unavailableError("failed to load labels", err)
+		}
 		err = svc.ds.RecordLabelQueryExecutions(&host, labelResults, svc.clock.Now())
 		if err != nil {
-			return osqueryError{message: "failed to save labels: " + err.Error()}
+			return unavailableError("failed to save labels", err)
 		}
+		svc.recordLabelActivities(ctx, &host, previous, labelResults)
 	}
 
 	if detailUpdated {
@@ -708,12 +2072,46 @@ func (svc service) SubmitDistributedQueryResults(ctx context.Context, results ko
 		}
 		additional := json.RawMessage(additionalJSON)
 		host.Additional = &additional
+		extraDetailsJSON, err := json.Marshal(extraDetails)
+		if err != nil {
+			return osqueryError{message: "failed to marshal extra details: " + err.Error()}
+		}
+		extra := json.RawMessage(extraDetailsJSON)
+		host.ExtraDetails = &extra
+	}
+
+	if uptimeReported {
+		if event := detectReboot(&host, svc.clock.Now()); event != nil {
+			// The event is recorded before the host is saved, so that a
+			// reboot is never lost if the results have to be resent.
+			if _, err := svc.ds.NewHostUptimeEvent(event); err != nil {
+				return unavailableError("failed to record reboot", err)
+			}
+			svc.recordHostActivity(ctx, host.ID, kolide.HostActivityRebooted, map[string]interface{}{
+				"boot_time":          event.BootTime,
+				"previous_boot_time": event.PreviousBootTime,
+			})
+		}
 	}
 
 	if len(labelResults) > 0 || detailUpdated {
 		err = svc.ds.SaveHost(&host)
+		if kolide.IsNotFound(errors.Cause(err)) {
+			return osqueryError{
+				message:     "failed to update host details: " + err.Error(),
+				nodeInvalid: true,
+			}
+		}
 		if err != nil {
-			return osqueryError{message: "failed to update host details: " + err.Error()}
+			return unavailableError("failed to update host details", err)
+		}
+	}
+
+	if len(discarded) > 0 {
+		sort.Strings(discarded)
+		return osqueryError{
+			message: "discarded results: " + strings.Join(discarded, "; "),
+			discard: true,
 		}
 	}
 
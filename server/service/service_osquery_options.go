@@ -1,12 +1,39 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
 
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/pkg/errors"
+	"github.com/spf13/cast"
 )
 
+const (
+	// flagfileEnrollSecretPath is the location osqueryd reads the enroll
+	// secret from when started with a generated flagfile.
+	flagfileEnrollSecretPath = "/etc/osquery/enroll_secret"
+	// flagfileServerCertsPath is the location of the Fleet server
+	// certificate when started with a generated flagfile.
+	flagfileServerCertsPath = "/etc/osquery/kolide.crt"
+)
+
+// flagfileIntervals are the interval flags written to a generated flagfile,
+// with the default used when the osquery options do not set a value.
+var flagfileIntervals = []struct {
+	name         string
+	defaultValue uint
+}{
+	{"config_refresh", 10},
+	{"distributed_interval", 10},
+	{"distributed_tls_max_attempts", 3},
+	{"logger_tls_period", 10},
+}
+
 func (svc service) ApplyOptionsSpec(ctx context.Context, spec *kolide.OptionsSpec) error {
 	err := svc.ds.ApplyOptions(spec)
 	if err != nil {
@@ -23,3 +50,67 @@ func (svc service) GetOptionsSpec(ctx context.Context) (*kolide.OptionsSpec, err
 
 	return spec, nil
 }
+
+func (svc service) GenerateOsqueryFlagfile(ctx context.Context, enrollSecret string) ([]byte, error) {
+	if enrollSecret != "" {
+		if _, err := svc.verifyEnrollSecret(enrollSecret); err != nil {
+			return nil, newInvalidArgumentError("enroll_secret", "must be an active enroll secret")
+		}
+	}
+
+	config, err := svc.ds.AppConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "get app config")
+	}
+	if config.KolideServerURL == "" {
+		return nil, newInvalidArgumentError("kolide_server_url", "must be set in the app config to generate a flagfile")
+	}
+	serverURL, err := url.Parse(config.KolideServerURL)
+	if err != nil || serverURL.Host == "" {
+		return nil, newInvalidArgumentError("kolide_server_url", "must be a valid URL to generate a flagfile")
+	}
+	prefix := strings.TrimRight(serverURL.Path, "/") + svc.config.Server.URLPrefix
+
+	spec, err := svc.ds.GetOptions()
+	if err != nil {
+		return nil, errors.Wrap(err, "get options from datastore")
+	}
+	var options struct {
+		Options map[string]interface{} `json:"options"`
+	}
+	if len(spec.Config) > 0 {
+		if err := json.Unmarshal(spec.Config, &options); err != nil {
+			return nil, errors.Wrap(err, "parse osquery options")
+		}
+	}
+
+	var buf bytes.Buffer
+	flag := func(name string, value interface{}) {
+		fmt.Fprintf(&buf, "--%s=%v\n", name, value)
+	}
+
+	flag("tls_hostname", serverURL.Host)
+	flag("tls_server_certs", flagfileServerCertsPath)
+	flag("enroll_secret_path", flagfileEnrollSecretPath)
+	flag("enroll_tls_endpoint", prefix+"/api/v1/osquery/enroll")
+	flag("host_identifier", "uuid")
+	flag("config_plugin", "tls")
+	flag("config_tls_endpoint", prefix+"/api/v1/osquery/config")
+	flag("disable_distributed", false)
+	flag("distributed_plugin", "tls")
+	flag("distributed_tls_read_endpoint", prefix+"/api/v1/osquery/distributed/read")
+	flag("distributed_tls_write_endpoint", prefix+"/api/v1/osquery/distributed/write")
+	flag("logger_plugin", "tls")
+	flag("logger_tls_endpoint", prefix+"/api/v1/osquery/log")
+	for _, interval := range flagfileIntervals {
+		value := interval.defaultValue
+		if v, ok := options.Options[interval.name]; ok {
+			if parsed, err := cast.ToUintE(v); err == nil {
+				value = parsed
+			}
+		}
+		flag(interval.name, value)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateOsqueryFlagfile(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{KolideServerURL: "https://fleet.example.com:8080/"}, nil
+	}
+	ds.GetOptionsFunc = func() (*kolide.OptionsSpec, error) {
+		return &kolide.OptionsSpec{
+			Config: json.RawMessage(`{"options":{"distributed_interval":3,"logger_tls_period":"20"}}`),
+		}, nil
+	}
+	ds.VerifyEnrollSecretFunc = func(secret string) (*kolide.EnrollSecret, error) {
+		if secret != "foobar" {
+			return nil, errors.New("not found")
+		}
+		return &kolide.EnrollSecret{Name: "default"}, nil
+	}
+
+	flagfile, err := svc.GenerateOsqueryFlagfile(context.Background(), "foobar")
+	require.Nil(t, err)
+	expected := `--tls_hostname=fleet.example.com:8080
+--tls_server_certs=/etc/osquery/kolide.crt
+--enroll_secret_path=/etc/osquery/enroll_secret
+--enroll_tls_endpoint=/api/v1/osquery/enroll
+--host_identifier=uuid
+--config_plugin=tls
+--config_tls_endpoint=/api/v1/osquery/config
+--disable_distributed=false
+--distributed_plugin=tls
+--distributed_tls_read_endpoint=/api/v1/osquery/distributed/read
+--distributed_tls_write_endpoint=/api/v1/osquery/distributed/write
+--logger_plugin=tls
+--logger_tls_endpoint=/api/v1/osquery/log
+--config_refresh=10
+--distributed_interval=3
+--distributed_tls_max_attempts=3
+--logger_tls_period=20
+`
+	assert.Equal(t, expected, string(flagfile))
+
+	_, err = svc.GenerateOsqueryFlagfile(context.Background(), "bad")
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "enroll_secret")
+
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{KolideServerURL: "https://fleet.example.com/fleet"}, nil
+	}
+	flagfile, err = svc.GenerateOsqueryFlagfile(context.Background(), "")
+	require.Nil(t, err)
+	assert.Contains(t, string(flagfile), "--tls_hostname=fleet.example.com\n")
+	assert.Contains(t, string(flagfile), "--config_tls_endpoint=/fleet/api/v1/osquery/config\n")
+
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	_, err = svc.GenerateOsqueryFlagfile(context.Background(), "")
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "kolide_server_url")
+}
@@ -2,16 +2,25 @@ package service
 
 import (
 	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/WatchBeam/clock"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/kolide/fleet/server/cache"
 	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/contexts/clientcert"
 	hostctx "github.com/kolide/fleet/server/contexts/host"
 	"github.com/kolide/fleet/server/contexts/viewer"
 	"github.com/kolide/fleet/server/datastore/inmem"
@@ -19,25 +28,35 @@ import (
 	"github.com/kolide/fleet/server/logging"
 	"github.com/kolide/fleet/server/mock"
 	"github.com/kolide/fleet/server/pubsub"
+	"github.com/kolide/fleet/server/ratelimit"
+	"github.com/kolide/fleet/server/webhook"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestEnrollAgent(t *testing.T) {
 	ds := new(mock.Store)
-	ds.VerifyEnrollSecretFunc = func(secret string) (string, error) {
+	ds.VerifyEnrollSecretFunc = func(secret string) (*kolide.EnrollSecret, error) {
 		switch secret {
 		case "valid_secret":
-			return "valid", nil
+			return &kolide.EnrollSecret{Name: "valid"}, nil
 		default:
-			return "", errors.New("not found")
+			return nil, errors.New("not found")
 		}
 	}
-	ds.EnrollHostFunc = func(osqueryHostId, nodeKey, secretName string) (*kolide.Host, error) {
+	var activities []*kolide.HostActivity
+	ds.NewHostActivityFunc = func(activity *kolide.HostActivity) (*kolide.HostActivity, error) {
+		activities = append(activities, activity)
+		return activity, nil
+	}
+	ds.EnrollHostFunc = func(osqueryHostId, nodeKey, secretName string, cooldown time.Duration, pending bool) (*kolide.Host, error) {
 		return &kolide.Host{
-			OsqueryHostID: osqueryHostId, NodeKey: nodeKey, EnrollSecretName: secretName,
+			ID: 1, OsqueryHostID: osqueryHostId, NodeKey: nodeKey, EnrollSecretName: secretName,
 		}, nil
 	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
 
 	svc, err := newTestService(ds, nil)
 	require.Nil(t, err)
@@ -45,16 +64,307 @@ func TestEnrollAgent(t *testing.T) {
 	nodeKey, err := svc.EnrollAgent(context.Background(), "valid_secret", "host123", nil)
 	require.Nil(t, err)
 	assert.NotEmpty(t, nodeKey)
+	if assert.Len(t, activities, 1) {
+		assert.Equal(t, uint(1), activities[0].HostID)
+		assert.Equal(t, kolide.HostActivityEnrolled, activities[0].Type)
+	}
+}
+
+func TestEnrollAgentClientCert(t *testing.T) {
+	ds := new(mock.Store)
+	ds.VerifyEnrollSecretFunc = func(secret string) (*kolide.EnrollSecret, error) {
+		if secret == "valid_secret" {
+			return &kolide.EnrollSecret{Name: "valid"}, nil
+		}
+		return nil, errors.New("not found")
+	}
+	var gotIdentifier, gotSecretName string
+	ds.NewHostActivityFunc = func(activity *kolide.HostActivity) (*kolide.HostActivity, error) {
+		return activity, nil
+	}
+	ds.EnrollHostFunc = func(osqueryHostId, nodeKey, secretName string, cooldown time.Duration, pending bool) (*kolide.Host, error) {
+		gotIdentifier, gotSecretName = osqueryHostId, secretName
+		return &kolide.Host{OsqueryHostID: osqueryHostId, NodeKey: nodeKey}, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+
+	conf := config.TestConfig()
+	conf.Osquery.EnrollClientCert = true
+	conf.Osquery.EnrollClientCertNames = "gateway.example.com, host-*.example.com"
+	svc := service{config: conf, ds: ds, clock: clock.NewMockClock(), logger: kitlog.NewNopLogger()}
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "host-1.example.com"}}
+	ctx := clientcert.NewContext(context.Background(), cert)
+
+	// The certificate identifies the host without an enroll secret
+	nodeKey, err := svc.EnrollAgent(ctx, "", "host123", nil)
+	require.Nil(t, err)
+	assert.NotEmpty(t, nodeKey)
+	assert.Equal(t, "host-1.example.com", gotIdentifier)
+	assert.Equal(t, "", gotSecretName)
+	assert.False(t, ds.VerifyEnrollSecretFuncInvoked)
+
+	// Enroll secrets are still accepted alongside certificates
+	_, err = svc.EnrollAgent(ctx, "valid_secret", "host123", nil)
+	require.Nil(t, err)
+	assert.Equal(t, "host123", gotIdentifier)
+	assert.Equal(t, "valid", gotSecretName)
+
+	// Without a certificate an enroll secret is required
+	_, err = svc.EnrollAgent(context.Background(), "", "host123", nil)
+	require.NotNil(t, err)
+	assert.True(t, err.(osqueryError).NodeInvalid())
+
+	// Certificates issued by the CA to other names may not enroll
+	gotIdentifier = ""
+	other := &x509.Certificate{Subject: pkix.Name{CommonName: "laptop.example.org"}}
+	_, err = svc.EnrollAgent(clientcert.NewContext(context.Background(), other), "", "host123", nil)
+	require.NotNil(t, err)
+	assert.True(t, err.(osqueryError).NodeInvalid())
+	assert.Contains(t, err.Error(), "laptop.example.org")
+	assert.Equal(t, "", gotIdentifier)
+
+	// Certificates are ignored unless client certificate enrollment is
+	// enabled
+	svc.config.Osquery.EnrollClientCert = false
+	_, err = svc.EnrollAgent(ctx, "", "host123", nil)
+	require.NotNil(t, err)
+	assert.True(t, err.(osqueryError).NodeInvalid())
+}
+
+func TestEnrollAgentApproval(t *testing.T) {
+	ds := new(mock.Store)
+	ds.VerifyEnrollSecretFunc = func(secret string) (*kolide.EnrollSecret, error) {
+		return &kolide.EnrollSecret{Name: "valid"}, nil
+	}
+	ds.NewHostActivityFunc = func(activity *kolide.HostActivity) (*kolide.HostActivity, error) {
+		return activity, nil
+	}
+	var gotPending bool
+	ds.EnrollHostFunc = func(osqueryHostId, nodeKey, secretName string, cooldown time.Duration, pending bool) (*kolide.Host, error) {
+		gotPending = pending
+		return &kolide.Host{ID: 1, OsqueryHostID: osqueryHostId, NodeKey: nodeKey, Pending: pending}, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+
+	conf := config.TestConfig()
+	svc := service{config: conf, ds: ds, clock: clock.NewMockClock(), logger: kitlog.NewNopLogger()}
+
+	// Hosts are approved on enrollment by default
+	_, err := svc.EnrollAgent(context.Background(), "valid_secret", "host123", nil)
+	require.Nil(t, err)
+	assert.False(t, gotPending)
+
+	svc.config.Osquery.EnrollmentApproval = true
+	_, err = svc.EnrollAgent(context.Background(), "valid_secret", "host123", nil)
+	require.Nil(t, err)
+	assert.True(t, gotPending)
+
+	// Pending hosts are served an empty config
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 1, Pending: true})
+	config, err := svc.GetClientConfig(ctx)
+	require.Nil(t, err)
+	assert.Empty(t, config)
+	assert.False(t, ds.OptionsForPlatformFuncInvoked)
+
+	// They run no queries, and their logs are dropped
+	queries, accelerate, err := svc.GetDistributedQueries(ctx)
+	require.Nil(t, err)
+	assert.Empty(t, queries)
+	assert.Zero(t, accelerate)
+	assert.False(t, ds.LabelQueriesForHostFuncInvoked)
+	assert.False(t, ds.DistributedQueriesForHostFuncInvoked)
+
+	testLogger := &testJSONLogger{}
+	svc.osqueryLogWriter = &logging.OsqueryLogger{Status: testLogger, Result: testLogger}
+	logs := []json.RawMessage{json.RawMessage(`{"name":"time","hostIdentifier":"host123"}`)}
+	require.Nil(t, svc.SubmitStatusLogs(ctx, logs))
+	require.Nil(t, svc.SubmitResultLogs(ctx, logs))
+	assert.Empty(t, testLogger.logs)
+}
+
+func TestEnrollAgentCooldown(t *testing.T) {
+	ds := new(mock.Store)
+	ds.VerifyEnrollSecretFunc = func(secret string) (*kolide.EnrollSecret, error) {
+		return &kolide.EnrollSecret{Name: "valid"}, nil
+	}
+	existing := &kolide.Host{ID: 1, OsqueryHostID: "host123", NodeKey: "existing_key"}
+	var gotCooldown time.Duration
+	ds.NewHostActivityFunc = func(activity *kolide.HostActivity) (*kolide.HostActivity, error) {
+		return activity, nil
+	}
+	ds.EnrollHostFunc = func(osqueryHostId, nodeKey, secretName string, cooldown time.Duration, pending bool) (*kolide.Host, error) {
+		gotCooldown = cooldown
+		return existing, nil
+	}
+	ds.SaveHostFunc = func(host *kolide.Host) error {
+		return nil
+	}
+
+	conf := config.TestConfig()
+	conf.Osquery.EnrollCooldown = 10 * time.Minute
+	svc := service{config: conf, ds: ds}
+
+	details := map[string](map[string]string){
+		"system_info": {"hostname": "zwass.local", "uuid": "froobling_uuid"},
+	}
+	nodeKey, err := svc.EnrollAgent(context.Background(), "", "host123", details)
+	require.Nil(t, err)
+	assert.Equal(t, "existing_key", nodeKey)
+	assert.Equal(t, 10*time.Minute, gotCooldown)
+}
+
+func TestEnrollHostCooldownClock(t *testing.T) {
+	ds, _, mockClock := setupOsqueryTests(t)
+
+	h1, err := ds.EnrollHost("cooldown_host", "key1", "default", time.Minute, false)
+	require.Nil(t, err)
+	assert.Equal(t, mockClock.Now().UTC(), h1.LastEnrollTime)
+
+	// The cooldown is measured with the clock of the datastore
+	mockClock.AddTime(30 * time.Second)
+	h2, err := ds.EnrollHost("cooldown_host", "key2", "default", time.Minute, false)
+	require.Nil(t, err)
+	assert.Equal(t, "key1", h2.NodeKey)
+
+	mockClock.AddTime(time.Minute)
+	h3, err := ds.EnrollHost("cooldown_host", "key3", "default", time.Minute, false)
+	require.Nil(t, err)
+	assert.Equal(t, h1.ID, h3.ID)
+	assert.Equal(t, "key3", h3.NodeKey)
+	assert.Equal(t, mockClock.Now().UTC(), h3.LastEnrollTime)
+}
+
+func TestEnrollAgentWebhook(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		received <- r
+	}))
+	defer server.Close()
+
+	ds := new(mock.Store)
+	ds.VerifyEnrollSecretFunc = func(secret string) (*kolide.EnrollSecret, error) {
+		return &kolide.EnrollSecret{Name: "valid"}, nil
+	}
+	ds.NewHostActivityFunc = func(activity *kolide.HostActivity) (*kolide.HostActivity, error) {
+		return activity, nil
+	}
+	ds.EnrollHostFunc = func(osqueryHostId, nodeKey, secretName string, cooldown time.Duration, pending bool) (*kolide.Host, error) {
+		return &kolide.Host{
+			ID: 42, OsqueryHostID: osqueryHostId, NodeKey: nodeKey, EnrollSecretName: secretName,
+		}, nil
+	}
+	ds.SaveHostFunc = func(host *kolide.Host) error {
+		return nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{
+			EnrollmentWebhookURL:    server.URL,
+			EnrollmentWebhookSecret: "shhh",
+			RequiredOsqueryVersion:  "4.4.0",
+		}, nil
+	}
+
+	mockClock := clock.NewMockClock()
+	svc := service{
+		ds:            ds,
+		clock:         mockClock,
+		config:        config.TestConfig(),
+		logger:        kitlog.NewNopLogger(),
+		webhookSender: &webhook.Sender{Client: server.Client(), MaxAttempts: 1},
+	}
+
+	details := map[string](map[string]string){
+		"system_info":  {"hostname": "zwass.local"},
+		"osquery_info": {"version": "4.3.0"},
+	}
+	_, err := svc.EnrollAgent(context.Background(), "", "host123", details)
+	require.Nil(t, err)
+
+	var req *http.Request
+	select {
+	case req = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for webhook")
+	}
+	assert.Equal(t, webhook.Sign("shhh", body), req.Header.Get(webhook.SignatureHeader))
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"host_id": 42,
+		"hostname": "zwass.local",
+		"enrolled_at": %q,
+		"enroll_secret_name": "valid",
+		"osquery_version": "4.3.0",
+		"osquery_version_outdated": true
+	}`, mockClock.Now().Format(time.RFC3339Nano)), string(body))
+}
+
+func TestEnrollAgentHostIdentifier(t *testing.T) {
+	ds := new(mock.Store)
+	ds.VerifyEnrollSecretFunc = func(secret string) (*kolide.EnrollSecret, error) {
+		return &kolide.EnrollSecret{Name: "valid"}, nil
+	}
+	var gotIdentifier string
+	ds.NewHostActivityFunc = func(activity *kolide.HostActivity) (*kolide.HostActivity, error) {
+		return activity, nil
+	}
+	ds.EnrollHostFunc = func(osqueryHostId, nodeKey, secretName string, cooldown time.Duration, pending bool) (*kolide.Host, error) {
+		gotIdentifier = osqueryHostId
+		return &kolide.Host{ID: 1, OsqueryHostID: osqueryHostId, NodeKey: nodeKey}, nil
+	}
+	ds.SaveHostFunc = func(host *kolide.Host) error {
+		return nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+
+	details := map[string](map[string]string){
+		"osquery_info": {"uuid": "osquery-uuid", "instance_id": "osquery-instance"},
+		"system_info":  {"hostname": "zwass.local", "uuid": "hardware-uuid"},
+	}
+	var testCases = []struct {
+		hostIdentifier string
+		details        map[string](map[string]string)
+		want           string
+	}{
+		{config.HostIdentifierProvided, details, "provided-id"},
+		{config.HostIdentifierUUID, details, "osquery-uuid"},
+		{config.HostIdentifierHostname, details, "zwass.local"},
+		{config.HostIdentifierInstance, details, "osquery-instance"},
+		// The provided identifier is used when the details are missing
+		{config.HostIdentifierHostname, nil, "provided-id"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.hostIdentifier, func(t *testing.T) {
+			conf := config.TestConfig()
+			conf.Osquery.HostIdentifier = tt.hostIdentifier
+			svc := service{
+				ds:     ds,
+				clock:  clock.NewMockClock(),
+				config: conf,
+				logger: kitlog.NewNopLogger(),
+			}
+			_, err := svc.EnrollAgent(context.Background(), "secret", "provided-id", tt.details)
+			require.Nil(t, err)
+			assert.Equal(t, tt.want, gotIdentifier)
+		})
+	}
 }
 
 func TestEnrollAgentIncorrectEnrollSecret(t *testing.T) {
 	ds := new(mock.Store)
-	ds.VerifyEnrollSecretFunc = func(secret string) (string, error) {
+	ds.VerifyEnrollSecretFunc = func(secret string) (*kolide.EnrollSecret, error) {
 		switch secret {
 		case "valid_secret":
-			return "valid", nil
+			return &kolide.EnrollSecret{Name: "valid"}, nil
 		default:
-			return "", errors.New("not found")
+			return nil, errors.New("not found")
 		}
 	}
 
@@ -66,16 +376,69 @@ func TestEnrollAgentIncorrectEnrollSecret(t *testing.T) {
 	assert.Empty(t, nodeKey)
 }
 
+func TestEnrollAgentScopedSecret(t *testing.T) {
+	c := clock.NewMockClock()
+	labelID := uint(7)
+	expiresAt := c.Now()
+	ds := new(mock.Store)
+	ds.VerifyEnrollSecretFunc = func(secret string) (*kolide.EnrollSecret, error) {
+		return &kolide.EnrollSecret{Name: "contractors", ExpiresAt: &expiresAt, LabelID: &labelID}, nil
+	}
+	ds.NewHostActivityFunc = func(activity *kolide.HostActivity) (*kolide.HostActivity, error) {
+		return activity, nil
+	}
+	ds.EnrollHostFunc = func(osqueryHostId, nodeKey, secretName string, cooldown time.Duration, pending bool) (*kolide.Host, error) {
+		return &kolide.Host{ID: 3, OsqueryHostID: osqueryHostId, NodeKey: nodeKey, EnrollSecretName: secretName}, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	var gotLabelID uint
+	var gotHostIDs []uint
+	ds.AddHostsToLabelFunc = func(lid uint, hostIDs []uint) error {
+		gotLabelID = lid
+		gotHostIDs = hostIDs
+		return nil
+	}
+
+	svc, err := newTestServiceWithClock(ds, nil, c)
+	require.Nil(t, err)
+
+	// Secrets are accepted within the grace window after expiry
+	c.AddTime(enrollSecretExpiryGrace - time.Second)
+	nodeKey, err := svc.EnrollAgent(context.Background(), "secret", "host123", nil)
+	require.Nil(t, err)
+	assert.NotEmpty(t, nodeKey)
+	assert.Equal(t, labelID, gotLabelID)
+	assert.Equal(t, []uint{3}, gotHostIDs)
+	assert.False(t, ds.RecordLabelQueryExecutionsFuncInvoked)
+
+	c.AddTime(2 * time.Second)
+	ds.AddHostsToLabelFuncInvoked = false
+	nodeKey, err = svc.EnrollAgent(context.Background(), "secret", "host123", nil)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "expired")
+	assert.IsType(t, osqueryError{}, err)
+	assert.Empty(t, nodeKey)
+	assert.False(t, ds.AddHostsToLabelFuncInvoked)
+}
+
 func TestEnrollAgentDetails(t *testing.T) {
 	ds := new(mock.Store)
-	ds.VerifyEnrollSecretFunc = func(secret string) (string, error) {
-		return "valid", nil
+	ds.VerifyEnrollSecretFunc = func(secret string) (*kolide.EnrollSecret, error) {
+		return &kolide.EnrollSecret{Name: "valid"}, nil
 	}
-	ds.EnrollHostFunc = func(osqueryHostId, nodeKey, secretName string) (*kolide.Host, error) {
+	ds.NewHostActivityFunc = func(activity *kolide.HostActivity) (*kolide.HostActivity, error) {
+		return activity, nil
+	}
+	ds.EnrollHostFunc = func(osqueryHostId, nodeKey, secretName string, cooldown time.Duration, pending bool) (*kolide.Host, error) {
 		return &kolide.Host{
 			OsqueryHostID: osqueryHostId, NodeKey: nodeKey, EnrollSecretName: secretName,
 		}, nil
 	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
 	var gotHost *kolide.Host
 	ds.SaveHostFunc = func(host *kolide.Host) error {
 		gotHost = host
@@ -159,7 +522,7 @@ func TestSubmitStatusLogs(t *testing.T) {
 	require.Nil(t, err)
 
 	// Hack to get at the service internals and modify the writer
-	serv := ((svc.(validationMiddleware)).Service).(service)
+	serv := svc.(idempotencyMiddleware).Service.(validationMiddleware).Service.(service)
 
 	testLogger := &testJSONLogger{}
 	serv.osqueryLogWriter = &logging.OsqueryLogger{Status: testLogger}
@@ -188,10 +551,16 @@ func TestSubmitResultLogs(t *testing.T) {
 	require.Nil(t, err)
 
 	// Hack to get at the service internals and modify the writer
-	serv := ((svc.(validationMiddleware)).Service).(service)
+	serv := svc.(idempotencyMiddleware).Service.(validationMiddleware).Service.(service)
 
 	testLogger := &testJSONLogger{}
 	serv.osqueryLogWriter = &logging.OsqueryLogger{Result: testLogger}
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return nil, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
 
 	logs := []string{
 		`{"name":"system_info","hostIdentifier":"some_uuid","calendarTime":"Fri Sep 30 17:55:15 2016 UTC","unixTime":"1475258115","decorations":{"host_uuid":"some_uuid","username":"zwass"},"columns":{"cpu_brand":"Intel(R) Core(TM) i7-4770HQ CPU @ 2.20GHz","hostname":"hostimus","physical_memory":"17179869184"},"action":"added"}`,
@@ -215,12 +584,343 @@ func TestSubmitResultLogs(t *testing.T) {
 	assert.Equal(t, results, testLogger.logs)
 }
 
+func TestSubmitLogsRateLimitDrop(t *testing.T) {
+	ds := new(mock.Store)
+	mockClock := clock.NewMockClock()
+	svc, err := newTestServiceWithClock(ds, nil, mockClock)
+	require.Nil(t, err)
+
+	serv := svc.(idempotencyMiddleware).Service.(validationMiddleware).Service.(service)
+	serv.config.Osquery.LogRateLimitAction = config.LogRateLimitActionDrop
+	serv.logBudget = ratelimit.NewBudget(3, time.Minute, mockClock)
+
+	testLogger := &testJSONLogger{}
+	serv.osqueryLogWriter = &logging.OsqueryLogger{Status: testLogger, Result: testLogger}
+
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return nil, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	var noisyID, dropped uint
+	ds.RecordNoisyHostFunc = func(hostID uint, d uint, at time.Time) error {
+		noisyID = hostID
+		dropped += d
+		assert.Equal(t, mockClock.Now(), at)
+		return nil
+	}
+
+	logs := []json.RawMessage{
+		json.RawMessage(`{"a":"1"}`),
+		json.RawMessage(`{"b":"2"}`),
+	}
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 7})
+
+	// Within the budget
+	require.Nil(t, serv.SubmitResultLogs(ctx, logs))
+	assert.Equal(t, logs, testLogger.logs)
+	assert.False(t, ds.RecordNoisyHostFuncInvoked)
+
+	// Status and result logs share the budget, so one row is dropped
+	require.Nil(t, serv.SubmitStatusLogs(ctx, logs))
+	assert.Equal(t, logs[:1], testLogger.logs)
+	assert.True(t, ds.RecordNoisyHostFuncInvoked)
+	assert.Equal(t, uint(7), noisyID)
+	assert.Equal(t, uint(1), dropped)
+
+	// Other hosts have their own budget
+	testLogger.logs = nil
+	require.Nil(t, serv.SubmitResultLogs(hostctx.NewContext(context.Background(), kolide.Host{ID: 8}), logs))
+	assert.Equal(t, logs, testLogger.logs)
+
+	// The budget is renewed after a minute
+	mockClock.AddTime(time.Minute)
+	require.Nil(t, serv.SubmitResultLogs(ctx, logs))
+	assert.Equal(t, logs, testLogger.logs)
+	assert.Equal(t, uint(1), dropped)
+}
+
+func TestSubmitLogsRateLimitReject(t *testing.T) {
+	ds := new(mock.Store)
+	mockClock := clock.NewMockClock()
+	svc, err := newTestServiceWithClock(ds, nil, mockClock)
+	require.Nil(t, err)
+
+	serv := svc.(idempotencyMiddleware).Service.(validationMiddleware).Service.(service)
+	serv.config.Osquery.LogRateLimitAction = config.LogRateLimitActionReject
+	serv.logBudget = ratelimit.NewBudget(3, time.Minute, mockClock)
+
+	testLogger := &testJSONLogger{}
+	serv.osqueryLogWriter = &logging.OsqueryLogger{Result: testLogger}
+
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return nil, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	var recordedDropped uint
+	ds.RecordNoisyHostFunc = func(hostID uint, dropped uint, at time.Time) error {
+		assert.Equal(t, uint(7), hostID)
+		recordedDropped = dropped
+		return nil
+	}
+
+	logs := []json.RawMessage{
+		json.RawMessage(`{"a":"1"}`),
+		json.RawMessage(`{"b":"2"}`),
+	}
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 7})
+
+	require.Nil(t, serv.SubmitResultLogs(ctx, logs))
+	assert.Equal(t, logs, testLogger.logs)
+	assert.False(t, ds.RecordNoisyHostFuncInvoked)
+
+	// A batch partly within the budget is rejected for a retry
+	testLogger.logs = nil
+	mockClock.AddTime(30 * time.Second)
+	err = serv.SubmitResultLogs(ctx, logs)
+	require.NotNil(t, err)
+	osqErr, ok := err.(osqueryError)
+	require.True(t, ok)
+	assert.Equal(t, 30*time.Second, osqErr.retryAfter)
+	assert.Nil(t, testLogger.logs)
+	assert.True(t, ds.RecordNoisyHostFuncInvoked)
+	assert.Equal(t, uint(0), recordedDropped)
+
+	// The rejected batch does not count against the budget
+	require.Nil(t, serv.SubmitResultLogs(ctx, logs[:1]))
+	assert.Equal(t, logs[:1], testLogger.logs)
+
+	// The batch is accepted in full once the budget is renewed
+	testLogger.logs = nil
+	mockClock.AddTime(30 * time.Second)
+	require.Nil(t, serv.SubmitResultLogs(ctx, logs))
+	assert.Equal(t, logs, testLogger.logs)
+
+	// A batch larger than the limit could never be accepted, so the rows
+	// over the limit are dropped
+	testLogger.logs = nil
+	mockClock.AddTime(time.Minute)
+	large := append(logs, logs...)
+	require.Nil(t, serv.SubmitResultLogs(ctx, large))
+	assert.Equal(t, large[:3], testLogger.logs)
+	assert.Equal(t, uint(1), recordedDropped)
+}
+
+func TestSubmitResultLogsPackLoggerPlugin(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	serv := svc.(idempotencyMiddleware).Service.(validationMiddleware).Service.(service)
+	defaultLogger := &testJSONLogger{}
+	firehoseLogger := &testJSONLogger{}
+	serv.osqueryLogWriter = &logging.OsqueryLogger{
+		Result:      defaultLogger,
+		PackResults: map[string]kolide.JSONLogger{"firehose": firehoseLogger},
+	}
+
+	ds.LabelOptionsForHostFunc = func(hid uint) (map[string]json.RawMessage, error) {
+		return nil, nil
+	}
+	ds.OptionsForPlatformFunc = func(platform string) (json.RawMessage, error) {
+		return json.RawMessage(`{"options":{"pack_delimiter":"/"}}`), nil
+	}
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return []*kolide.Pack{
+			{ID: 1, Name: "compliance", LoggerPlugin: "firehose"},
+			{ID: 2, Name: "monitoring"},
+			{ID: 3, Name: "removed_plugin", LoggerPlugin: "pubsub"},
+		}, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	ds.ListScheduledQueriesInPackFunc = func(id uint, opts kolide.ListOptions) ([]*kolide.ScheduledQuery, error) {
+		return []*kolide.ScheduledQuery{{PackID: id, Name: "processes"}}, nil
+	}
+
+	results := []json.RawMessage{
+		json.RawMessage(`{"name":"pack/compliance/processes","action":"added"}`),
+		json.RawMessage(`{"name":"pack/monitoring/processes","action":"added"}`),
+		json.RawMessage(`{"name":"pack/removed_plugin/processes","action":"added"}`),
+		json.RawMessage(`{"name":"local_query","action":"added"}`),
+		json.RawMessage(`["not an object"]`),
+	}
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 1})
+	err = serv.SubmitResultLogs(ctx, results)
+	require.Nil(t, err)
+
+	assert.Equal(t, results[:1], firehoseLogger.logs)
+	assert.Equal(t, results[1:], defaultLogger.logs)
+}
+
+func TestSubmitResultLogsMaxResultRows(t *testing.T) {
+	ds := new(mock.Store)
+	mockClock := clock.NewMockClock()
+	svc, err := newTestServiceWithClock(ds, nil, mockClock)
+	require.Nil(t, err)
+
+	serv := svc.(idempotencyMiddleware).Service.(validationMiddleware).Service.(service)
+	serv.config.Osquery.MaxResultRows = 2
+	testLogger := &testJSONLogger{}
+	serv.osqueryLogWriter = &logging.OsqueryLogger{Result: testLogger}
+
+	ds.OptionsForPlatformFunc = func(platform string) (json.RawMessage, error) {
+		return json.RawMessage(`{"options":{"pack_delimiter":"/"}}`), nil
+	}
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return []*kolide.Pack{{ID: 1, Name: "monitoring"}}, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	ds.ListScheduledQueriesInPackFunc = func(id uint, opts kolide.ListOptions) ([]*kolide.ScheduledQuery, error) {
+		return []*kolide.ScheduledQuery{
+			{PackID: id, Name: "unlimited", MaxResultRows: uintPtr(0)},
+			{PackID: id, Name: "one", MaxResultRows: uintPtr(1)},
+		}, nil
+	}
+	var truncated uint
+	ds.RecordTruncatedResultsFunc = func(hostID uint, n uint, at time.Time) error {
+		assert.Equal(t, uint(7), hostID)
+		assert.Equal(t, mockClock.Now(), at)
+		truncated += n
+		return nil
+	}
+
+	results := []json.RawMessage{
+		// The default limit applies to queries without an override
+		json.RawMessage(`{"name":"local","snapshot":[{"a":"1"},{"a":"2"},{"a":"3"}]}`),
+		json.RawMessage(`{"name":"pack/monitoring/unlimited","snapshot":[{"a":"1"},{"a":"2"},{"a":"3"}]}`),
+		json.RawMessage(`{"name":"pack/monitoring/one","diffResults":{"added":[{"a":"1"}],"removed":[{"a":"2"}]}}`),
+		json.RawMessage(`{"name":"pack/monitoring/one","diffResults":{"added":"","removed":[{"a":"2"}]}}`),
+		json.RawMessage(`{"name":"event","columns":{"a":"1"},"action":"added"}`),
+		json.RawMessage(`{"name":"event","columns":{"a":"2"},"action":"added"}`),
+		json.RawMessage(`{"name":"event","columns":{"a":"3"},"action":"added"}`),
+		json.RawMessage(`["not an object"]`),
+	}
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 7})
+	require.Nil(t, serv.SubmitResultLogs(ctx, results))
+
+	require.Len(t, testLogger.logs, 7)
+	assert.JSONEq(t, `{"name":"local","snapshot":[{"a":"1"},{"a":"2"}],"truncated":true}`, string(testLogger.logs[0]))
+	assert.Equal(t, results[1], testLogger.logs[1])
+	assert.JSONEq(t, `{"name":"pack/monitoring/one","diffResults":{"added":[{"a":"1"}],"removed":[]},"truncated":true}`, string(testLogger.logs[2]))
+	assert.Equal(t, results[3], testLogger.logs[3])
+	assert.Equal(t, results[4:6], testLogger.logs[4:6])
+	assert.Equal(t, results[7], testLogger.logs[6])
+	assert.Equal(t, uint(3), truncated)
+
+	// Nothing is truncated without limits
+	serv.config.Osquery.MaxResultRows = 0
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return nil, nil
+	}
+	ds.RecordTruncatedResultsFuncInvoked = false
+	require.Nil(t, serv.SubmitResultLogs(ctx, results))
+	assert.Equal(t, results, testLogger.logs)
+	assert.False(t, ds.RecordTruncatedResultsFuncInvoked)
+}
+
+func TestSampleResultLogs(t *testing.T) {
+	half, none := 0.5, 0.0
+	queries := map[string]hostResultQuery{
+		"pack/monitoring/half":  {query: &kolide.ScheduledQuery{SampleRate: &half}},
+		"pack/monitoring/none":  {query: &kolide.ScheduledQuery{SampleRate: &none}},
+		"pack/monitoring/every": {query: &kolide.ScheduledQuery{}},
+	}
+
+	var rows []string
+	for i := 0; i < 1000; i++ {
+		rows = append(rows, fmt.Sprintf(`{"a":"%d","b":"x"}`, i))
+	}
+	snapshot := json.RawMessage(`{"name":"pack/monitoring/half","snapshot":[` + strings.Join(rows, ",") + `]}`)
+	logs := []json.RawMessage{
+		snapshot,
+		json.RawMessage(`{"name":"pack/monitoring/every","snapshot":[{"a":"1"}]}`),
+		json.RawMessage(`{"name":"pack/monitoring/none","diffResults":{"added":[{"a":"1"}],"removed":""}}`),
+		json.RawMessage(`["not an object"]`),
+	}
+	sampled := sampleResultLogs(queries, logs)
+	require.Len(t, sampled, 4)
+
+	var result struct {
+		Snapshot   []map[string]string `json:"snapshot"`
+		SampleRate float64             `json:"sample_rate"`
+	}
+	require.Nil(t, json.Unmarshal(sampled[0], &result))
+	assert.Equal(t, 0.5, result.SampleRate)
+	assert.InDelta(t, 500, len(result.Snapshot), 75)
+	assert.Equal(t, logs[1], sampled[1])
+	assert.JSONEq(t, `{"name":"pack/monitoring/none","diffResults":{"added":[],"removed":""},"sample_rate":0}`, string(sampled[2]))
+	assert.Equal(t, logs[3], sampled[3])
+
+	// Sampling is deterministic, and does not depend on the column order
+	// or on the log format
+	assert.Equal(t, sampled[0], sampleResultLogs(queries, logs[:1])[0])
+	var events []json.RawMessage
+	for i := 0; i < 20; i++ {
+		events = append(events, json.RawMessage(fmt.Sprintf(`{"name":"pack/monitoring/half","columns":{"b":"x","a":"%d"},"action":"added"}`, i)))
+	}
+	var kept []string
+	for _, row := range result.Snapshot {
+		if i, _ := strconv.Atoi(row["a"]); i < len(events) {
+			kept = append(kept, row["a"])
+		}
+	}
+	var keptEvents []string
+	for _, raw := range sampleResultLogs(queries, events) {
+		var event struct {
+			Columns    map[string]string `json:"columns"`
+			SampleRate float64           `json:"sample_rate"`
+		}
+		require.Nil(t, json.Unmarshal(raw, &event))
+		assert.Equal(t, 0.5, event.SampleRate)
+		keptEvents = append(keptEvents, event.Columns["a"])
+	}
+	assert.Equal(t, kept, keptEvents)
+}
+
+func TestMapResultLogs(t *testing.T) {
+	mapping := kolide.ColumnMapping{{Column: "uuid", As: "host_id"}, {Column: "hostname"}}
+	queries := map[string]hostResultQuery{
+		"pack/monitoring/mapped": {query: &kolide.ScheduledQuery{ColumnMapping: &mapping}},
+		"pack/monitoring/plain":  {query: &kolide.ScheduledQuery{}},
+	}
+	logs := []json.RawMessage{
+		json.RawMessage(`{"name":"pack/monitoring/mapped","snapshot":[{"hostname":"foo","cpu":"x86","uuid":"1"},{"cpu":"arm"}]}`),
+		json.RawMessage(`{"name":"pack/monitoring/mapped","diffResults":{"added":[{"hostname":"foo","uuid":"1"}],"removed":""}}`),
+		json.RawMessage(`{"name":"pack/monitoring/mapped","columns":{"uuid":"1","cpu":"x86","hostname":"foo"},"action":"added"}`),
+		json.RawMessage(`{"name":"pack/monitoring/plain","columns":{"uuid":"1"},"action":"added"}`),
+		json.RawMessage(`["not an object"]`),
+	}
+	mapped := mapResultLogs(queries, append([]json.RawMessage{}, logs...))
+	require.Len(t, mapped, 5)
+
+	// Columns are logged in the order of the mapping
+	assert.Contains(t, string(mapped[0]), `"snapshot":[{"host_id":"1","hostname":"foo"},{}]`)
+	assert.Contains(t, string(mapped[1]), `"added":[{"host_id":"1","hostname":"foo"}]`)
+	assert.JSONEq(t, `{"name":"pack/monitoring/mapped","diffResults":{"added":[{"host_id":"1","hostname":"foo"}],"removed":""}}`, string(mapped[1]))
+	assert.Contains(t, string(mapped[2]), `"columns":{"host_id":"1","hostname":"foo"}`)
+	assert.Equal(t, logs[3], mapped[3])
+	assert.Equal(t, logs[4], mapped[4])
+}
+
 func TestHostDetailQueries(t *testing.T) {
 	ds := new(mock.Store)
 	additional := json.RawMessage(`{"foobar": "select foo", "bim": "bam"}`)
 	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
 		return &kolide.AppConfig{AdditionalQueries: &additional}, nil
 	}
+	ds.ListHostDetailQueriesFunc = func() ([]*kolide.HostDetailQuery, error) {
+		return []*kolide.HostDetailQuery{{Name: "chassis", Query: "select chassis_type from system_info"}}, nil
+	}
+	ds.ListLabelsForHostFunc = func(hid uint) ([]kolide.Label, error) {
+		return []kolide.Label{}, nil
+	}
 
 	mockClock := clock.NewMockClock()
 	host := kolide.Host{
@@ -251,14 +951,92 @@ func TestHostDetailQueries(t *testing.T) {
 
 	queries, err = svc.hostDetailQueries(host)
 	assert.Nil(t, err)
-	assert.Len(t, queries, len(detailQueries)+2)
+	assert.Len(t, queries, len(platformDetailQueries(""))+3)
 	for name, _ := range queries {
 		assert.True(t,
-			strings.HasPrefix(name, hostDetailQueryPrefix) || strings.HasPrefix(name, hostAdditionalQueryPrefix),
+			strings.HasPrefix(name, hostDetailQueryPrefix) ||
+				strings.HasPrefix(name, hostAdditionalQueryPrefix) ||
+				strings.HasPrefix(name, hostExtraDetailQueryPrefix),
 		)
 	}
 	assert.Equal(t, "bam", queries[hostAdditionalQueryPrefix+"bim"])
 	assert.Equal(t, "select foo", queries[hostAdditionalQueryPrefix+"foobar"])
+	assert.Equal(t, "select chassis_type from system_info", queries[hostExtraDetailQueryPrefix+"chassis"])
+}
+
+func TestDiskEncryptionDetailQueries(t *testing.T) {
+	darwin := platformDetailQueries("darwin")
+	assert.Contains(t, darwin, "disk_encryption_darwin")
+	assert.NotContains(t, darwin, "disk_encryption_windows")
+	windows := platformDetailQueries("windows")
+	assert.Contains(t, windows, "disk_encryption_windows")
+	assert.NotContains(t, windows, "disk_encryption_darwin")
+	ubuntu := platformDetailQueries("ubuntu")
+	assert.NotContains(t, ubuntu, "disk_encryption_darwin")
+	assert.NotContains(t, ubuntu, "disk_encryption_windows")
+	assert.Len(t, darwin, len(ubuntu)+1)
+
+	var testCases = []struct {
+		query  string
+		rows   []map[string]string
+		status string
+	}{
+		{"disk_encryption_darwin", []map[string]string{{"encrypted": "1"}}, kolide.DiskEncryptionEncrypted},
+		{"disk_encryption_darwin", []map[string]string{{"encrypted": "0"}}, kolide.DiskEncryptionUnencrypted},
+		{"disk_encryption_darwin", []map[string]string{}, kolide.DiskEncryptionUnknown},
+		{"disk_encryption_windows", []map[string]string{{"protection_status": "1"}}, kolide.DiskEncryptionEncrypted},
+		{"disk_encryption_windows", []map[string]string{{"protection_status": "0"}}, kolide.DiskEncryptionUnencrypted},
+		{"disk_encryption_windows", []map[string]string{{"protection_status": "2"}}, kolide.DiskEncryptionUnknown},
+		{"disk_encryption_windows", []map[string]string{{"protection_status": "1"}, {"protection_status": "1"}}, kolide.DiskEncryptionUnknown},
+	}
+	for _, tt := range testCases {
+		host := kolide.Host{DiskEncryption: kolide.DiskEncryptionEncrypted}
+		require.Nil(t, detailQueries[tt.query].IngestFunc(kitlog.NewNopLogger(), &host, tt.rows))
+		assert.Equal(t, tt.status, host.DiskEncryption, "%s %v", tt.query, tt.rows)
+	}
+}
+
+func TestHostDetailQueriesLabelInterval(t *testing.T) {
+	ds := new(mock.Store)
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	ds.ListHostDetailQueriesFunc = func() ([]*kolide.HostDetailQuery, error) {
+		return nil, nil
+	}
+	var labels []kolide.Label
+	ds.ListLabelsForHostFunc = func(hid uint) ([]kolide.Label, error) {
+		return labels, nil
+	}
+
+	mockClock := clock.NewMockClock()
+	host := kolide.Host{ID: 1, DetailUpdateTime: mockClock.Now()}
+	svc := service{clock: mockClock, config: config.TestConfig(), ds: ds}
+
+	mockClock.AddTime(10*time.Minute + time.Second)
+
+	// Without overrides the global one hour interval applies
+	labels = []kolide.Label{{ID: 1, Name: "servers"}}
+	queries, err := svc.hostDetailQueries(host)
+	require.Nil(t, err)
+	assert.Empty(t, queries)
+
+	// The shortest override among the host's labels applies
+	labels = []kolide.Label{
+		{ID: 1, Name: "servers", DetailUpdateInterval: 1800},
+		{ID: 2, Name: "databases", DetailUpdateInterval: 600},
+		{ID: 3, Name: "all"},
+	}
+	queries, err = svc.hostDetailQueries(host)
+	require.Nil(t, err)
+	assert.Len(t, queries, len(platformDetailQueries("")))
+
+	// Overrides may also lengthen the interval
+	labels = []kolide.Label{{ID: 4, Name: "laptops", DetailUpdateInterval: 86400}}
+	mockClock.AddTime(2 * time.Hour)
+	queries, err = svc.hostDetailQueries(host)
+	require.Nil(t, err)
+	assert.Empty(t, queries)
 }
 
 func TestGetDistributedQueriesMissingHost(t *testing.T) {
@@ -279,6 +1057,9 @@ func TestLabelQueries(t *testing.T) {
 	ds.LabelQueriesForHostFunc = func(host *kolide.Host, cutoff time.Time) (map[string]string, error) {
 		return map[string]string{}, nil
 	}
+	ds.ListLabelsForHostFunc = func(hid uint) ([]kolide.Label, error) {
+		return []kolide.Label{}, nil
+	}
 	ds.DistributedQueriesForHostFunc = func(host *kolide.Host) (map[uint]string, error) {
 		return map[uint]string{}, nil
 	}
@@ -288,6 +1069,9 @@ func TestLabelQueries(t *testing.T) {
 	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
 		return &kolide.AppConfig{}, nil
 	}
+	ds.ListHostDetailQueriesFunc = func() ([]*kolide.HostDetailQuery, error) {
+		return nil, nil
+	}
 
 	host := &kolide.Host{}
 	ctx := hostctx.NewContext(context.Background(), *host)
@@ -296,7 +1080,7 @@ func TestLabelQueries(t *testing.T) {
 	// should be turned on so that we can quickly fill labels)
 	queries, acc, err := svc.GetDistributedQueries(ctx)
 	assert.Nil(t, err)
-	assert.Len(t, queries, len(detailQueries))
+	assert.Len(t, queries, len(platformDetailQueries("")))
 	assert.NotZero(t, acc)
 
 	// Simulate the detail queries being added
@@ -327,6 +1111,17 @@ func TestLabelQueries(t *testing.T) {
 	var gotHost *kolide.Host
 	var gotResults map[uint]bool
 	var gotTime time.Time
+	var gotActivities []*kolide.HostActivity
+	ds.ListLabelsForHostFunc = func(hid uint) ([]kolide.Label, error) {
+		return []kolide.Label{{ID: 3, Name: "label3"}}, nil
+	}
+	ds.LabelFunc = func(lid uint) (*kolide.Label, error) {
+		return &kolide.Label{ID: lid, Name: fmt.Sprintf("label%d", lid)}, nil
+	}
+	ds.NewHostActivityFunc = func(activity *kolide.HostActivity) (*kolide.HostActivity, error) {
+		gotActivities = append(gotActivities, activity)
+		return activity, nil
+	}
 	ds.RecordLabelQueryExecutionsFunc = func(host *kolide.Host, results map[uint]bool, t time.Time) error {
 		gotHost = host
 		gotResults = results
@@ -341,6 +1136,7 @@ func TestLabelQueries(t *testing.T) {
 			hostLabelQueryPrefix + "1": {{"col1": "val1"}},
 		},
 		map[string]kolide.OsqueryStatus{},
+		nil,
 	)
 	assert.Nil(t, err)
 	assert.Equal(t, host, gotHost)
@@ -359,6 +1155,7 @@ func TestLabelQueries(t *testing.T) {
 			hostLabelQueryPrefix + "3": {},
 		},
 		map[string]kolide.OsqueryStatus{},
+		nil,
 	)
 	assert.Nil(t, err)
 	assert.Equal(t, host, gotHost)
@@ -367,13 +1164,31 @@ func TestLabelQueries(t *testing.T) {
 		assert.Equal(t, true, gotResults[2])
 		assert.Equal(t, false, gotResults[3])
 	}
+
+	// Label changes are recorded in the activity feed of the host
+	if assert.Len(t, gotActivities, 3) {
+		assert.Equal(t, kolide.HostActivityLabelAdded, gotActivities[0].Type)
+		assert.JSONEq(t, `{"label_id":1,"label_name":"label1"}`, string(*gotActivities[0].Details))
+		assert.Equal(t, kolide.HostActivityLabelAdded, gotActivities[1].Type)
+		assert.Equal(t, kolide.HostActivityLabelRemoved, gotActivities[2].Type)
+		assert.JSONEq(t, `{"label_id":3,"label_name":"label3"}`, string(*gotActivities[2].Details))
+	}
 }
 
 func TestGetClientConfig(t *testing.T) {
 	ds := new(mock.Store)
+	ds.ListDecoratorsFunc = func(opts ...kolide.OptionalArg) ([]*kolide.Decorator, error) {
+		return nil, nil
+	}
+	ds.ListATCTablesFunc = func() ([]*kolide.ATCTable, error) {
+		return nil, nil
+	}
 	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
 		return []*kolide.Pack{}, nil
 	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
 	ds.ListScheduledQueriesInPackFunc = func(pid uint, opt kolide.ListOptions) ([]*kolide.ScheduledQuery, error) {
 		tru := true
 		fals := false
@@ -387,11 +1202,16 @@ func TestGetClientConfig(t *testing.T) {
 			return []*kolide.ScheduledQuery{
 				{Name: "foobar", Query: "select 3", Interval: 20, Shard: &fortytwo},
 				{Name: "froobing", Query: "select 'guacamole'", Interval: 60, Snapshot: &tru},
+				{Name: "snapshot_removed", Query: "select 4", Interval: 10, Snapshot: &tru, Removed: &fals},
+				{Name: "differential", Query: "select 5", Interval: 10, Snapshot: &fals, Removed: &tru},
 			}, nil
 		default:
 			return []*kolide.ScheduledQuery{}, nil
 		}
 	}
+	ds.LabelOptionsForHostFunc = func(hid uint) (map[string]json.RawMessage, error) {
+		return nil, nil
+	}
 	ds.OptionsForPlatformFunc = func(platform string) (json.RawMessage, error) {
 		return json.RawMessage(`
 {
@@ -444,65 +1264,241 @@ func TestGetClientConfig(t *testing.T) {
 			"3600": []interface{}{"SELECT total_seconds AS uptime FROM uptime;"},
 		},
 	}
-
-	expectedConfig := map[string]interface{}{
-		"options":    expectedOptions,
-		"decorators": expectedDecorators,
-		"foo":        "bar",
+
+	expectedConfig := map[string]interface{}{
+		"options":    expectedOptions,
+		"decorators": expectedDecorators,
+		"foo":        "bar",
+	}
+
+	// No packs loaded yet
+	conf, err := svc.GetClientConfig(ctx1)
+	require.Nil(t, err)
+	assert.Equal(t, expectedConfig, conf)
+
+	conf, err = svc.GetClientConfig(ctx2)
+	require.Nil(t, err)
+	assert.Equal(t, expectedConfig, conf)
+
+	// Now add packs
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		switch hid {
+		case 1:
+			return []*kolide.Pack{
+				{ID: 1, Name: "pack_by_label"},
+				{ID: 4, Name: "pack_by_other_label"},
+			}, nil
+
+		case 2:
+			return []*kolide.Pack{
+				{ID: 1, Name: "pack_by_label"},
+			}, nil
+		}
+		return []*kolide.Pack{}, nil
+	}
+
+	conf, err = svc.GetClientConfig(ctx1)
+	require.Nil(t, err)
+	assert.Equal(t, expectedOptions, conf["options"])
+	assert.JSONEq(t, `{
+		"pack_by_other_label": {
+			"queries": {
+				"foobar":{"query":"select 3","interval":20,"shard":42},
+				"froobing":{"query":"select 'guacamole'","interval":60,"snapshot":true},
+				"snapshot_removed":{"query":"select 4","interval":10,"snapshot":true},
+				"differential":{"query":"select 5","interval":10,"removed":true}
+			}
+		},
+		"pack_by_label": {
+			"queries":{
+				"time":{"query":"select * from time","interval":30,"removed":false}
+			}
+		}
+	}`,
+		string(conf["packs"].(json.RawMessage)),
+	)
+
+	conf, err = svc.GetClientConfig(ctx2)
+	require.Nil(t, err)
+	assert.Equal(t, expectedOptions, conf["options"])
+	assert.JSONEq(t, `{
+		"pack_by_label": {
+			"queries":{
+				"time":{"query":"select * from time","interval":30,"removed":false}
+			}
+		}
+	}`,
+		string(conf["packs"].(json.RawMessage)),
+	)
+}
+
+func TestGetClientConfigDisabledQueries(t *testing.T) {
+	ds := new(mock.Store)
+	ds.LabelOptionsForHostFunc = func(hid uint) (map[string]json.RawMessage, error) {
+		return nil, nil
+	}
+	ds.OptionsForPlatformFunc = func(platform string) (json.RawMessage, error) {
+		return json.RawMessage(`{"options":{}}`), nil
+	}
+	ds.ListDecoratorsFunc = func(opts ...kolide.OptionalArg) ([]*kolide.Decorator, error) {
+		return nil, nil
+	}
+	ds.ListATCTablesFunc = func() ([]*kolide.ATCTable, error) {
+		return nil, nil
+	}
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return []*kolide.Pack{
+			{ID: 1, Name: "partly_disabled"},
+			{ID: 2, Name: "all_disabled"},
+		}, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	ds.ListScheduledQueriesInPackFunc = func(pid uint, opt kolide.ListOptions) ([]*kolide.ScheduledQuery, error) {
+		switch pid {
+		case 1:
+			return []*kolide.ScheduledQuery{
+				{Name: "enabled", Query: "select 1", Interval: 10},
+				{Name: "disabled", Query: "select 2", Interval: 10, Disabled: true},
+			}, nil
+		case 2:
+			return []*kolide.ScheduledQuery{
+				{Name: "disabled", Query: "select 3", Interval: 10, Disabled: true},
+			}, nil
+		}
+		return nil, nil
+	}
+
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 1})
+	conf, err := svc.GetClientConfig(ctx)
+	require.Nil(t, err)
+	assert.JSONEq(t, `{
+		"partly_disabled": {
+			"queries": {
+				"enabled": {"query":"select 1","interval":10}
+			}
+		},
+		"all_disabled": {
+			"queries": {}
+		}
+	}`,
+		string(conf["packs"].(json.RawMessage)),
+	)
+}
+
+func TestGetClientConfigDeniedQueries(t *testing.T) {
+	ds := new(mock.Store)
+	ds.LabelOptionsForHostFunc = func(hid uint) (map[string]json.RawMessage, error) {
+		return nil, nil
+	}
+	ds.OptionsForPlatformFunc = func(platform string) (json.RawMessage, error) {
+		return json.RawMessage(`{"options":{}}`), nil
+	}
+	ds.ListDecoratorsFunc = func(opts ...kolide.OptionalArg) ([]*kolide.Decorator, error) {
+		return nil, nil
+	}
+	ds.ListATCTablesFunc = func() ([]*kolide.ATCTable, error) {
+		return nil, nil
+	}
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return []*kolide.Pack{{ID: 1, Name: "pack"}}, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{
+			QueryDenyList: kolide.QueryDenyList{{Pattern: "from curl"}},
+		}, nil
+	}
+	ds.ListScheduledQueriesInPackFunc = func(pid uint, opt kolide.ListOptions) ([]*kolide.ScheduledQuery, error) {
+		return []*kolide.ScheduledQuery{
+			{Name: "time", Query: "select * from time", Interval: 10},
+			{Name: "curl", Query: "select * from curl where url = 'http://example.com'", Interval: 10},
+		}, nil
+	}
+
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	// Queries scheduled before they were denied are not sent to hosts
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 1})
+	conf, err := svc.GetClientConfig(ctx)
+	require.Nil(t, err)
+	assert.JSONEq(t, `{
+		"pack": {
+			"queries": {
+				"time": {"query":"select * from time","interval":10}
+			}
+		}
+	}`,
+		string(conf["packs"].(json.RawMessage)),
+	)
+}
+
+func TestGetClientConfigScheduleWindow(t *testing.T) {
+	ds := new(mock.Store)
+	ds.LabelOptionsForHostFunc = func(hid uint) (map[string]json.RawMessage, error) {
+		return nil, nil
+	}
+	ds.OptionsForPlatformFunc = func(platform string) (json.RawMessage, error) {
+		return json.RawMessage(`{"options":{}}`), nil
+	}
+	ds.ListDecoratorsFunc = func(opts ...kolide.OptionalArg) ([]*kolide.Decorator, error) {
+		return nil, nil
+	}
+	ds.ListATCTablesFunc = func() ([]*kolide.ATCTable, error) {
+		return nil, nil
+	}
+	start, end := uint(22), uint(6)
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return []*kolide.Pack{
+			{ID: 1, Name: "always"},
+			{ID: 2, Name: "nightly", WindowStartHour: &start, WindowEndHour: &end},
+		}, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	ds.ListScheduledQueriesInPackFunc = func(pid uint, opt kolide.ListOptions) ([]*kolide.ScheduledQuery, error) {
+		return []*kolide.ScheduledQuery{
+			{Name: fmt.Sprintf("query%d", pid), Query: "select 1", Interval: 10},
+		}, nil
 	}
 
-	// No packs loaded yet
-	conf, err := svc.GetClientConfig(ctx1)
-	require.Nil(t, err)
-	assert.Equal(t, expectedConfig, conf)
-
-	conf, err = svc.GetClientConfig(ctx2)
+	mockClock := clock.NewMockClock(time.Date(2020, 7, 29, 12, 0, 0, 0, time.UTC))
+	svc, err := newTestServiceWithClock(ds, nil, mockClock)
 	require.Nil(t, err)
-	assert.Equal(t, expectedConfig, conf)
-
-	// Now add packs
-	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
-		switch hid {
-		case 1:
-			return []*kolide.Pack{
-				{ID: 1, Name: "pack_by_label"},
-				{ID: 4, Name: "pack_by_other_label"},
-			}, nil
-
-		case 2:
-			return []*kolide.Pack{
-				{ID: 1, Name: "pack_by_label"},
-			}, nil
-		}
-		return []*kolide.Pack{}, nil
-	}
 
-	conf, err = svc.GetClientConfig(ctx1)
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 1})
+	conf, err := svc.GetClientConfig(ctx)
 	require.Nil(t, err)
-	assert.Equal(t, expectedOptions, conf["options"])
 	assert.JSONEq(t, `{
-		"pack_by_other_label": {
+		"always": {
 			"queries": {
-				"foobar":{"query":"select 3","interval":20,"shard":42},
-				"froobing":{"query":"select 'guacamole'","interval":60,"snapshot":true}
+				"query1": {"query":"select 1","interval":10}
 			}
 		},
-		"pack_by_label": {
-			"queries":{
-				"time":{"query":"select * from time","interval":30,"removed":false}
-			}
+		"nightly": {
+			"queries": {}
 		}
 	}`,
 		string(conf["packs"].(json.RawMessage)),
 	)
 
-	conf, err = svc.GetClientConfig(ctx2)
+	mockClock.AddTime(11 * time.Hour)
+	conf, err = svc.GetClientConfig(ctx)
 	require.Nil(t, err)
-	assert.Equal(t, expectedOptions, conf["options"])
 	assert.JSONEq(t, `{
-		"pack_by_label": {
-			"queries":{
-				"time":{"query":"select * from time","interval":30,"removed":false}
+		"always": {
+			"queries": {
+				"query1": {"query":"select 1","interval":10}
+			}
+		},
+		"nightly": {
+			"queries": {
+				"query2": {"query":"select 1","interval":10}
 			}
 		}
 	}`,
@@ -510,6 +1506,180 @@ func TestGetClientConfig(t *testing.T) {
 	)
 }
 
+func TestGetClientConfigATCTables(t *testing.T) {
+	ds := new(mock.Store)
+	ds.LabelOptionsForHostFunc = func(hid uint) (map[string]json.RawMessage, error) {
+		return nil, nil
+	}
+	ds.OptionsForPlatformFunc = func(platform string) (json.RawMessage, error) {
+		return json.RawMessage(`{
+			"options":{},
+			"auto_table_construction":{
+				"legacy":{"query":"SELECT 1","path":"/legacy.db","columns":["a"]}
+			}
+		}`), nil
+	}
+	ds.ListDecoratorsFunc = func(opts ...kolide.OptionalArg) ([]*kolide.Decorator, error) {
+		return nil, nil
+	}
+	ds.ListATCTablesFunc = func() ([]*kolide.ATCTable, error) {
+		return []*kolide.ATCTable{
+			{
+				Name:     "app_events",
+				Path:     "/var/lib/app/events.db",
+				Query:    "SELECT id, name FROM events",
+				Columns:  kolide.ATCColumns{"id", "name"},
+				Platform: "linux",
+			},
+		}, nil
+	}
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return nil, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 1})
+	conf, err := svc.GetClientConfig(ctx)
+	require.Nil(t, err)
+	atcJSON, err := json.Marshal(conf["auto_table_construction"])
+	require.Nil(t, err)
+	assert.JSONEq(t, `{
+		"legacy": {"query":"SELECT 1","path":"/legacy.db","columns":["a"]},
+		"app_events": {
+			"query":"SELECT id, name FROM events",
+			"path":"/var/lib/app/events.db",
+			"columns":["id","name"],
+			"platform":"linux"
+		}
+	}`, string(atcJSON))
+}
+
+func TestGetClientConfigLabelOptions(t *testing.T) {
+	ds := new(mock.Store)
+	ds.OptionsForPlatformFunc = func(platform string) (json.RawMessage, error) {
+		return json.RawMessage(`{"options":{"disable_events":true,"distributed_interval":10,"logger_tls_period":10}}`), nil
+	}
+	ds.LabelOptionsForHostFunc = func(hid uint) (map[string]json.RawMessage, error) {
+		assert.Equal(t, uint(1), hid)
+		return map[string]json.RawMessage{
+			"servers": json.RawMessage(`{"distributed_interval":60,"logger_tls_period":30}`),
+			"macs":    json.RawMessage(`{"disable_events":false,"distributed_interval":30}`),
+		}, nil
+	}
+	ds.ListDecoratorsFunc = func(opts ...kolide.OptionalArg) ([]*kolide.Decorator, error) {
+		return nil, nil
+	}
+	ds.ListATCTablesFunc = func() ([]*kolide.ATCTable, error) {
+		return nil, nil
+	}
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return nil, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	ds.SaveHostFunc = func(host *kolide.Host) error {
+		return nil
+	}
+
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 1, DistributedInterval: 10, LoggerTLSPeriod: 10})
+	conf, err := svc.GetClientConfig(ctx)
+	require.Nil(t, err)
+	// Label options are merged over the base options, with the label that
+	// sorts last taking precedence
+	assert.Equal(t, map[string]interface{}{
+		"disable_events":       false,
+		"distributed_interval": float64(60),
+		"logger_tls_period":    float64(30),
+	}, conf["options"])
+	assert.True(t, ds.SaveHostFuncInvoked)
+}
+
+func TestGetClientConfigPlatformIntervals(t *testing.T) {
+	ds := new(mock.Store)
+	ds.OptionsForPlatformFunc = func(platform string) (json.RawMessage, error) {
+		return json.RawMessage(`{"options":{"config_refresh":10,"distributed_interval":10,"logger_tls_period":10}}`), nil
+	}
+	serverInterval := 300
+	ds.IntervalsForPlatformFunc = func(platform string) (*kolide.OsqueryIntervals, error) {
+		if platform != "linux" {
+			return nil, nil
+		}
+		return &kolide.OsqueryIntervals{ConfigRefresh: &serverInterval, DistributedInterval: &serverInterval}, nil
+	}
+	ds.LabelOptionsForHostFunc = func(hid uint) (map[string]json.RawMessage, error) {
+		if hid != 2 {
+			return nil, nil
+		}
+		return map[string]json.RawMessage{
+			"canary": json.RawMessage(`{"distributed_interval":30}`),
+		}, nil
+	}
+	ds.ListDecoratorsFunc = func(opts ...kolide.OptionalArg) ([]*kolide.Decorator, error) {
+		return nil, nil
+	}
+	ds.ListATCTablesFunc = func() ([]*kolide.ATCTable, error) {
+		return nil, nil
+	}
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return nil, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	ds.SaveHostFunc = func(host *kolide.Host) error {
+		return nil
+	}
+
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	var testCases = []struct {
+		host    kolide.Host
+		options map[string]interface{}
+	}{
+		// Linux distributions use the linux intervals, falling back to
+		// the base options for the intervals that are not set
+		{
+			kolide.Host{ID: 1, Platform: "ubuntu"},
+			map[string]interface{}{"config_refresh": float64(300), "distributed_interval": float64(300), "logger_tls_period": float64(10)},
+		},
+		// Label options take precedence over the platform intervals
+		{
+			kolide.Host{ID: 2, Platform: "centos"},
+			map[string]interface{}{"config_refresh": float64(300), "distributed_interval": float64(30), "logger_tls_period": float64(10)},
+		},
+		{
+			kolide.Host{ID: 3, Platform: "darwin"},
+			map[string]interface{}{"config_refresh": float64(10), "distributed_interval": float64(10), "logger_tls_period": float64(10)},
+		},
+	}
+	for _, tt := range testCases {
+		conf, err := svc.GetClientConfig(hostctx.NewContext(context.Background(), tt.host))
+		require.Nil(t, err)
+		// Round trip the config as it is served to hosts
+		b, err := json.Marshal(conf)
+		require.Nil(t, err)
+		var served map[string]interface{}
+		require.Nil(t, json.Unmarshal(b, &served))
+		assert.Equal(t, tt.options, served["options"], tt.host.Platform)
+	}
+
+	// Hosts that have not reported a platform get the base options
+	ds.IntervalsForPlatformFuncInvoked = false
+	_, err = svc.GetClientConfig(hostctx.NewContext(context.Background(), kolide.Host{ID: 4}))
+	require.Nil(t, err)
+	assert.False(t, ds.IntervalsForPlatformFuncInvoked)
+}
+
 func TestDetailQueriesWithEmptyStrings(t *testing.T) {
 	ds := new(mock.Store)
 	mockClock := clock.NewMockClock()
@@ -522,9 +1692,15 @@ func TestDetailQueriesWithEmptyStrings(t *testing.T) {
 	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
 		return &kolide.AppConfig{}, nil
 	}
+	ds.ListHostDetailQueriesFunc = func() ([]*kolide.HostDetailQuery, error) {
+		return nil, nil
+	}
 	ds.LabelQueriesForHostFunc = func(*kolide.Host, time.Time) (map[string]string, error) {
 		return map[string]string{}, nil
 	}
+	ds.ListLabelsForHostFunc = func(hid uint) ([]kolide.Label, error) {
+		return []kolide.Label{}, nil
+	}
 	ds.DistributedQueriesForHostFunc = func(*kolide.Host) (map[uint]string, error) {
 		return map[uint]string{}, nil
 	}
@@ -533,7 +1709,7 @@ func TestDetailQueriesWithEmptyStrings(t *testing.T) {
 	// queries)
 	queries, acc, err := svc.GetDistributedQueries(ctx)
 	assert.Nil(t, err)
-	assert.Len(t, queries, len(detailQueries))
+	assert.Len(t, queries, len(platformDetailQueries("")))
 	assert.NotZero(t, acc)
 
 	resultJSON := `
@@ -635,7 +1811,7 @@ func TestDetailQueriesWithEmptyStrings(t *testing.T) {
 	}
 
 	// Verify that results are ingested properly
-	svc.SubmitDistributedQueryResults(ctx, results, map[string]kolide.OsqueryStatus{})
+	svc.SubmitDistributedQueryResults(ctx, results, map[string]kolide.OsqueryStatus{}, nil)
 
 	// osquery_info
 	assert.Equal(t, "darwin", gotHost.Platform)
@@ -673,7 +1849,7 @@ func TestDetailQueriesWithEmptyStrings(t *testing.T) {
 
 	queries, acc, err = svc.GetDistributedQueries(ctx)
 	assert.Nil(t, err)
-	assert.Len(t, queries, len(detailQueries))
+	assert.Len(t, queries, len(platformDetailQueries("")))
 	assert.Zero(t, acc)
 }
 
@@ -689,9 +1865,15 @@ func TestDetailQueries(t *testing.T) {
 	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
 		return &kolide.AppConfig{}, nil
 	}
+	ds.ListHostDetailQueriesFunc = func() ([]*kolide.HostDetailQuery, error) {
+		return nil, nil
+	}
 	ds.LabelQueriesForHostFunc = func(*kolide.Host, time.Time) (map[string]string, error) {
 		return map[string]string{}, nil
 	}
+	ds.ListLabelsForHostFunc = func(hid uint) ([]kolide.Label, error) {
+		return []kolide.Label{}, nil
+	}
 	ds.DistributedQueriesForHostFunc = func(*kolide.Host) (map[uint]string, error) {
 		return map[uint]string{}, nil
 	}
@@ -700,7 +1882,7 @@ func TestDetailQueries(t *testing.T) {
 	// queries)
 	queries, acc, err := svc.GetDistributedQueries(ctx)
 	assert.Nil(t, err)
-	assert.Len(t, queries, len(detailQueries))
+	assert.Len(t, queries, len(platformDetailQueries("")))
 	assert.NotZero(t, acc)
 
 	resultJSON := `
@@ -805,7 +1987,7 @@ func TestDetailQueries(t *testing.T) {
 		return nil
 	}
 	// Verify that results are ingested properly
-	svc.SubmitDistributedQueryResults(ctx, results, map[string]kolide.OsqueryStatus{})
+	svc.SubmitDistributedQueryResults(ctx, results, map[string]kolide.OsqueryStatus{}, nil)
 
 	// osquery_info
 	assert.Equal(t, "darwin", gotHost.Platform)
@@ -843,7 +2025,7 @@ func TestDetailQueries(t *testing.T) {
 
 	queries, acc, err = svc.GetDistributedQueries(ctx)
 	assert.Nil(t, err)
-	assert.Len(t, queries, len(detailQueries))
+	assert.Len(t, queries, len(platformDetailQueries("")))
 	assert.Zero(t, acc)
 }
 
@@ -868,6 +2050,9 @@ func TestNewDistributedQueryCampaign(t *testing.T) {
 	ds.LabelQueriesForHostFunc = func(host *kolide.Host, cutoff time.Time) (map[string]string, error) {
 		return map[string]string{}, nil
 	}
+	ds.ListLabelsForHostFunc = func(hid uint) ([]kolide.Label, error) {
+		return []kolide.Label{}, nil
+	}
 	ds.DistributedQueriesForHostFunc = func(host *kolide.Host) (map[uint]string, error) {
 		return map[uint]string{}, nil
 	}
@@ -900,23 +2085,217 @@ func TestNewDistributedQueryCampaign(t *testing.T) {
 			ID: 0,
 		},
 	})
-	q := "select year, month, day, hour, minutes, seconds from time"
-	campaign, err := svc.NewDistributedQueryCampaign(viewerCtx, q, []uint{2}, []uint{1})
+	q := "select year, month, day, hour, minutes, seconds from time"
+	campaign, err := svc.NewDistributedQueryCampaign(viewerCtx, q, []uint{2, 2}, []uint{1, 1}, 30, false)
+	require.Nil(t, err)
+	assert.Equal(t, gotQuery.ID, gotCampaign.QueryID)
+	assert.Equal(t, uint(30), gotCampaign.ExecutionTimeout)
+	assert.Equal(t, []*kolide.DistributedQueryCampaignTarget{
+		&kolide.DistributedQueryCampaignTarget{
+			Type:                       kolide.TargetHost,
+			DistributedQueryCampaignID: campaign.ID,
+			TargetID:                   2,
+		},
+		&kolide.DistributedQueryCampaignTarget{
+			Type:                       kolide.TargetLabel,
+			DistributedQueryCampaignID: campaign.ID,
+			TargetID:                   1,
+		},
+	}, gotTargets,
+	)
+}
+
+func TestDistributedQueryCampaignTargetsCount(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	var gotHosts, gotLabels []uint
+	ds.HostIDsInTargetsFunc = func(hostIDs, labelIDs []uint) ([]uint, error) {
+		gotHosts, gotLabels = hostIDs, labelIDs
+		return []uint{2, 5, 7}, nil
+	}
+
+	count, hostIDs, err := svc.DistributedQueryCampaignTargetsCount(context.Background(), []uint{2, 2, 7}, []uint{1})
+	require.Nil(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, []uint{2, 5, 7}, hostIDs)
+	assert.Equal(t, []uint{2, 7}, gotHosts)
+	assert.Equal(t, []uint{1}, gotLabels)
+	assert.False(t, ds.NewDistributedQueryCampaignFuncInvoked)
+}
+
+func TestNewSavedQueryCampaignCache(t *testing.T) {
+	ds := new(mock.Store)
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	rs := &mock.QueryResultStore{
+		HealthCheckFunc: func() error {
+			return nil
+		},
+	}
+	saved := &kolide.Query{ID: 7, Name: "os_counts", Query: "select * from os_version", Saved: true, CacheTTL: 60}
+	ds.QueryFunc = func(id uint) (*kolide.Query, error) {
+		if id == saved.ID {
+			return saved, nil
+		}
+		return &kolide.Query{ID: id, Saved: false}, nil
+	}
+	var gotCampaign *kolide.DistributedQueryCampaign
+	ds.NewDistributedQueryCampaignFunc = func(camp *kolide.DistributedQueryCampaign) (*kolide.DistributedQueryCampaign, error) {
+		gotCampaign = camp
+		camp.ID = 21
+		return camp, nil
+	}
+	ds.NewDistributedQueryCampaignTargetFunc = func(target *kolide.DistributedQueryCampaignTarget) (*kolide.DistributedQueryCampaignTarget, error) {
+		return target, nil
+	}
+	ds.CountHostsInTargetsFunc = func(hostIDs, labelIDs []uint, now time.Time) (kolide.TargetMetrics, error) {
+		return kolide.TargetMetrics{}, nil
+	}
+
+	mockClock := clock.NewMockClock()
+	svc := service{
+		ds:           ds,
+		resultStore:  rs,
+		clock:        mockClock,
+		resultsCache: cache.NewInmemResultsCache(mockClock),
+	}
+	viewerCtx := viewer.NewContext(context.Background(), viewer.Viewer{
+		User: &kolide.User{ID: 3},
+	})
+
+	// No cached results, so the saved query is run
+	campaign, err := svc.NewSavedQueryCampaign(viewerCtx, 7, []uint{2}, []uint{1, 5}, 0, false)
+	require.Nil(t, err)
+	assert.Equal(t, uint(7), gotCampaign.QueryID)
+	assert.Equal(t, kolide.QueryWaiting, campaign.Status)
+	assert.Nil(t, campaign.CachedResults)
+
+	results := []kolide.DistributedQueryResult{
+		{DistributedQueryCampaignID: 21, Rows: []map[string]string{{"name": "Ubuntu"}}},
+	}
+	require.Nil(t, svc.resultsCache.Set(resultsCacheKey(saved, []uint{2}, []uint{5, 1}), results, time.Minute))
+
+	// Cached results are returned for the same targets in any order
+	campaign, err = svc.NewSavedQueryCampaign(viewerCtx, 7, []uint{2}, []uint{5, 1}, 0, false)
+	require.Nil(t, err)
+	assert.Equal(t, kolide.QueryComplete, campaign.Status)
+	assert.Equal(t, results, campaign.CachedResults)
+
+	// Duplicated targets are served from the cache of the unique targets
+	campaign, err = svc.NewSavedQueryCampaign(viewerCtx, 7, []uint{2, 2}, []uint{5, 1, 5}, 0, false)
+	require.Nil(t, err)
+	assert.Equal(t, kolide.QueryComplete, campaign.Status)
+	assert.Equal(t, results, campaign.CachedResults)
+
+	// Different targets are not served from the cache
+	campaign, err = svc.NewSavedQueryCampaign(viewerCtx, 7, []uint{2}, []uint{1}, 0, false)
+	require.Nil(t, err)
+	assert.Equal(t, kolide.QueryWaiting, campaign.Status)
+	assert.Nil(t, campaign.CachedResults)
+
+	// Changing the query text invalidates the cached results
+	saved.Query = "select name from os_version"
+	campaign, err = svc.NewSavedQueryCampaign(viewerCtx, 7, []uint{2}, []uint{1, 5}, 0, false)
+	require.Nil(t, err)
+	assert.Equal(t, kolide.QueryWaiting, campaign.Status)
+
+	// The results expire after the TTL
+	saved.Query = "select * from os_version"
+	mockClock.AddTime(time.Minute)
+	campaign, err = svc.NewSavedQueryCampaign(viewerCtx, 7, []uint{2}, []uint{1, 5}, 0, false)
+	require.Nil(t, err)
+	assert.Equal(t, kolide.QueryWaiting, campaign.Status)
+
+	// Only saved queries can be run
+	_, err = svc.NewSavedQueryCampaign(viewerCtx, 8, nil, []uint{1}, 0, false)
+	require.NotNil(t, err)
+	assert.IsType(t, &invalidArgumentError{}, err)
+}
+
+func TestNewSavedQueryCampaignByNames(t *testing.T) {
+	ds := new(mock.Store)
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	rs := &mock.QueryResultStore{
+		HealthCheckFunc: func() error {
+			return nil
+		},
+	}
+	ds.QueryByNameFunc = func(name string, opts ...kolide.OptionalArg) (*kolide.Query, error) {
+		switch name {
+		case "os_counts":
+			return &kolide.Query{ID: 7, Name: name, Saved: true}, nil
+		case "distributed_admin_1":
+			return &kolide.Query{ID: 8, Name: name, Saved: false}, nil
+		}
+		return nil, notFoundError{}
+	}
+	ds.QueryFunc = func(id uint) (*kolide.Query, error) {
+		return &kolide.Query{ID: id, Saved: true}, nil
+	}
+	hostIDs := map[string][]uint{"foo.local": {2}, "bar.local": {3, 4}}
+	ds.HostIDsByNameFunc = func(hostnames []string) ([]uint, error) {
+		return hostIDs[hostnames[0]], nil
+	}
+	labelIDs := map[string][]uint{"All Hosts": {1}}
+	ds.LabelIDsByNameFunc = func(labels []string) ([]uint, error) {
+		return labelIDs[labels[0]], nil
+	}
+	var gotCampaign *kolide.DistributedQueryCampaign
+	ds.NewDistributedQueryCampaignFunc = func(camp *kolide.DistributedQueryCampaign) (*kolide.DistributedQueryCampaign, error) {
+		gotCampaign = camp
+		camp.ID = 21
+		return camp, nil
+	}
+	var gotTargets []kolide.DistributedQueryCampaignTarget
+	ds.NewDistributedQueryCampaignTargetFunc = func(target *kolide.DistributedQueryCampaignTarget) (*kolide.DistributedQueryCampaignTarget, error) {
+		gotTargets = append(gotTargets, *target)
+		return target, nil
+	}
+	ds.CountHostsInTargetsFunc = func(hostIDs, labelIDs []uint, now time.Time) (kolide.TargetMetrics, error) {
+		return kolide.TargetMetrics{}, nil
+	}
+
+	svc := service{ds: ds, resultStore: rs, clock: clock.NewMockClock()}
+	viewerCtx := viewer.NewContext(context.Background(), viewer.Viewer{
+		User: &kolide.User{ID: 3},
+	})
+
+	// Every name that does not resolve is reported
+	_, err := svc.NewSavedQueryCampaignByNames(viewerCtx, "missing", []string{"foo.local", "baz.local"}, []string{"All Hosts", "nope"}, 0, false)
+	require.NotNil(t, err)
+	require.IsType(t, &invalidArgumentError{}, err)
+	assert.Equal(t, []map[string]string{
+		{"name": "query_name", "reason": "no saved query named 'missing'"},
+		{"name": "hosts", "reason": "no host named 'baz.local'"},
+		{"name": "labels", "reason": "no label named 'nope'"},
+	}, err.(*invalidArgumentError).Invalid())
+	assert.False(t, ds.NewDistributedQueryCampaignFuncInvoked)
+
+	_, err = svc.NewSavedQueryCampaignByNames(viewerCtx, "distributed_admin_1", nil, []string{"All Hosts"}, 0, false)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "'distributed_admin_1' is not a saved query")
+	assert.False(t, ds.NewDistributedQueryCampaignFuncInvoked)
+
+	campaign, err := svc.NewSavedQueryCampaignByNames(viewerCtx, "os_counts", []string{"foo.local", "bar.local"}, []string{"All Hosts"}, 30, false)
 	require.Nil(t, err)
-	assert.Equal(t, gotQuery.ID, gotCampaign.QueryID)
-	assert.Equal(t, []*kolide.DistributedQueryCampaignTarget{
-		&kolide.DistributedQueryCampaignTarget{
-			Type:                       kolide.TargetHost,
-			DistributedQueryCampaignID: campaign.ID,
-			TargetID:                   2,
-		},
-		&kolide.DistributedQueryCampaignTarget{
-			Type:                       kolide.TargetLabel,
-			DistributedQueryCampaignID: campaign.ID,
-			TargetID:                   1,
-		},
-	}, gotTargets,
-	)
+	assert.Equal(t, uint(21), campaign.ID)
+	assert.Equal(t, uint(7), gotCampaign.QueryID)
+	assert.Equal(t, uint(30), gotCampaign.ExecutionTimeout)
+	var gotHosts, gotLabels []uint
+	for _, target := range gotTargets {
+		if target.Type == kolide.TargetHost {
+			gotHosts = append(gotHosts, target.TargetID)
+		} else {
+			gotLabels = append(gotLabels, target.TargetID)
+		}
+	}
+	assert.ElementsMatch(t, []uint{2, 3, 4}, gotHosts)
+	assert.Equal(t, []uint{1}, gotLabels)
 }
 
 func TestDistributedQueryResults(t *testing.T) {
@@ -934,17 +2313,29 @@ func TestDistributedQueryResults(t *testing.T) {
 	ds.SaveHostFunc = func(host *kolide.Host) error {
 		return nil
 	}
+	ds.ListLabelsForHostFunc = func(hid uint) ([]kolide.Label, error) {
+		return []kolide.Label{}, nil
+	}
 	ds.DistributedQueriesForHostFunc = func(host *kolide.Host) (map[uint]string, error) {
 		return map[uint]string{campaign.ID: "select * from time"}, nil
 	}
+	ds.DistributedQueryCampaignFunc = func(id uint) (*kolide.DistributedQueryCampaign, error) {
+		return campaign, nil
+	}
 	var gotExecution *kolide.DistributedQueryExecution
 	ds.NewDistributedQueryExecutionFunc = func(exec *kolide.DistributedQueryExecution) (*kolide.DistributedQueryExecution, error) {
 		gotExecution = exec
 		return exec, nil
 	}
+	ds.DistributedQueryExecutionExistsFunc = func(campaignID, hostID uint) (bool, error) {
+		return false, nil
+	}
 	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
 		return &kolide.AppConfig{}, nil
 	}
+	ds.ListHostDetailQueriesFunc = func() ([]*kolide.HostDetailQuery, error) {
+		return nil, nil
+	}
 
 	host := &kolide.Host{ID: 1}
 	hostCtx := hostctx.NewContext(context.Background(), *host)
@@ -952,7 +2343,7 @@ func TestDistributedQueryResults(t *testing.T) {
 	// Now we should get the active distributed query
 	queries, acc, err := svc.GetDistributedQueries(hostCtx)
 	require.Nil(t, err)
-	assert.Len(t, queries, len(detailQueries)+1)
+	assert.Len(t, queries, len(platformDetailQueries(""))+1)
 	queryKey := fmt.Sprintf("%s%d", hostDistributedQueryPrefix, campaign.ID)
 	assert.Equal(t, "select * from time", queries[queryKey])
 	assert.NotZero(t, acc)
@@ -970,6 +2361,9 @@ func TestDistributedQueryResults(t *testing.T) {
 	results := map[string][]map[string]string{
 		queryKey: expectedRows,
 	}
+	stats := map[string]*kolide.OsqueryDistributedQueryStats{
+		queryKey: {WallTimeMs: 25, Memory: 1024},
+	}
 
 	// TODO use service method
 	readChan, err := rs.ReadChannel(context.Background(), *campaign)
@@ -988,6 +2382,7 @@ func TestDistributedQueryResults(t *testing.T) {
 				assert.Equal(t, campaign.ID, res.DistributedQueryCampaignID)
 				assert.Equal(t, expectedRows, res.Rows)
 				assert.Equal(t, *host, res.Host)
+				assert.Equal(t, stats[queryKey], res.Stats)
 			} else {
 				t.Error("Wrong result type")
 			}
@@ -1007,11 +2402,12 @@ func TestDistributedQueryResults(t *testing.T) {
 	// this test.
 	time.Sleep(10 * time.Millisecond)
 
-	err = svc.SubmitDistributedQueryResults(hostCtx, results, map[string]kolide.OsqueryStatus{})
+	err = svc.SubmitDistributedQueryResults(hostCtx, results, map[string]kolide.OsqueryStatus{}, stats)
 	require.Nil(t, err)
 	assert.Equal(t, campaign.ID, gotExecution.DistributedQueryCampaignID)
 	assert.Equal(t, host.ID, gotExecution.HostID)
 	assert.Equal(t, kolide.ExecutionSucceeded, gotExecution.Status)
+	assert.Equal(t, 25*time.Millisecond, gotExecution.ExecutionDuration)
 }
 
 func TestOrphanedQueryCampaign(t *testing.T) {
@@ -1027,11 +2423,17 @@ func TestOrphanedQueryCampaign(t *testing.T) {
 	ds.NewDistributedQueryExecutionFunc = func(*kolide.DistributedQueryExecution) (*kolide.DistributedQueryExecution, error) {
 		return nil, nil
 	}
+	ds.DistributedQueryExecutionExistsFunc = func(campaignID, hostID uint) (bool, error) {
+		return false, nil
+	}
 
-	savedCampaign := &kolide.DistributedQueryCampaign{}
-	ds.SaveDistributedQueryCampaignFunc = func(campaign *kolide.DistributedQueryCampaign) error {
-		savedCampaign = campaign
-		return nil
+	var completedID uint
+	ds.CompleteDistributedQueryCampaignFunc = func(id uint) (bool, error) {
+		completedID = id
+		return true, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
 	}
 
 	// Submit results
@@ -1051,13 +2453,114 @@ func TestOrphanedQueryCampaign(t *testing.T) {
 
 	ctx := context.Background()
 	ctx = hostctx.NewContext(context.Background(), host)
-	err = svc.SubmitDistributedQueryResults(ctx, results, map[string]kolide.OsqueryStatus{})
+	err = svc.SubmitDistributedQueryResults(ctx, results, map[string]kolide.OsqueryStatus{}, nil)
 	require.Nil(t, err)
 
-	// Ensure that status is changed to completed when there is no listener for
+	// Ensure that the campaign is completed when there is no listener for
 	// results.
-	require.NotNil(t, savedCampaign)
-	assert.Equal(t, kolide.QueryComplete, savedCampaign.Status)
+	assert.Equal(t, uint(1), completedID)
+}
+
+func TestOrphanedQueryCampaignResumeWindow(t *testing.T) {
+	ds := new(mock.Store)
+	rs := pubsub.NewInmemQueryResults()
+	mockClock := clock.NewMockClock()
+
+	svc, err := newTestServiceWithClock(ds, rs, mockClock)
+	require.Nil(t, err)
+
+	// The client streaming the campaign has just disconnected
+	disconnectedAt := mockClock.Now()
+	ds.DistributedQueryCampaignFunc = func(id uint) (*kolide.DistributedQueryCampaign, error) {
+		return &kolide.DistributedQueryCampaign{
+			ID:             1,
+			Status:         kolide.QueryRunning,
+			DisconnectedAt: &disconnectedAt,
+		}, nil
+	}
+	ds.NewDistributedQueryExecutionFunc = func(*kolide.DistributedQueryExecution) (*kolide.DistributedQueryExecution, error) {
+		return nil, nil
+	}
+	ds.DistributedQueryExecutionExistsFunc = func(campaignID, hostID uint) (bool, error) {
+		return false, nil
+	}
+	ds.CompleteDistributedQueryCampaignFunc = func(id uint) (bool, error) {
+		return true, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 1, HostName: "the fooer"})
+	results := map[string][]map[string]string{
+		hostDistributedQueryPrefix + "1": {{"foo": "bar"}},
+	}
+
+	// Within the resume window the campaign is left running so that the
+	// client can resume it and replay the result
+	mockClock.AddTime(campaignResumeWindow - time.Second)
+	err = svc.SubmitDistributedQueryResults(ctx, results, map[string]kolide.OsqueryStatus{}, nil)
+	require.Nil(t, err)
+	assert.True(t, ds.NewDistributedQueryExecutionFuncInvoked)
+	assert.False(t, ds.CompleteDistributedQueryCampaignFuncInvoked)
+
+	// Once the window has passed the orphaned campaign is completed
+	mockClock.AddTime(time.Second)
+	err = svc.SubmitDistributedQueryResults(ctx, results, map[string]kolide.OsqueryStatus{}, nil)
+	require.Nil(t, err)
+	assert.True(t, ds.CompleteDistributedQueryCampaignFuncInvoked)
+}
+
+func TestDistributedQueryCampaignTimeout(t *testing.T) {
+	mockClock := clock.NewMockClock()
+	ds := new(mock.Store)
+	svc, err := newTestServiceWithClock(ds, nil, mockClock)
+	require.Nil(t, err)
+
+	campaign := &kolide.DistributedQueryCampaign{
+		ID:               42,
+		Status:           kolide.QueryRunning,
+		ExecutionTimeout: 60,
+	}
+	campaign.CreatedAt = mockClock.Now()
+
+	ds.LabelQueriesForHostFunc = func(host *kolide.Host, cutoff time.Time) (map[string]string, error) {
+		return map[string]string{}, nil
+	}
+	ds.ListLabelsForHostFunc = func(hid uint) ([]kolide.Label, error) {
+		return []kolide.Label{}, nil
+	}
+	ds.DistributedQueriesForHostFunc = func(host *kolide.Host) (map[uint]string, error) {
+		return map[uint]string{campaign.ID: "select * from time"}, nil
+	}
+	ds.DistributedQueryCampaignFunc = func(id uint) (*kolide.DistributedQueryCampaign, error) {
+		return campaign, nil
+	}
+	ds.CompleteDistributedQueryCampaignFunc = func(id uint) (bool, error) {
+		return true, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+
+	host := kolide.Host{ID: 1, DetailUpdateTime: mockClock.Now()}
+	hostCtx := hostctx.NewContext(context.Background(), host)
+	queryKey := fmt.Sprintf("%s%d", hostDistributedQueryPrefix, campaign.ID)
+
+	// The query is sent while the timeout has not elapsed
+	mockClock.AddTime(59 * time.Second)
+	queries, _, err := svc.GetDistributedQueries(hostCtx)
+	require.Nil(t, err)
+	assert.Contains(t, queries, queryKey)
+	assert.False(t, ds.CompleteDistributedQueryCampaignFuncInvoked)
+
+	// Once the timeout elapses the query is withheld and the campaign is
+	// completed
+	mockClock.AddTime(1 * time.Second)
+	queries, _, err = svc.GetDistributedQueries(hostCtx)
+	require.Nil(t, err)
+	assert.NotContains(t, queries, queryKey)
+	assert.True(t, ds.CompleteDistributedQueryCampaignFuncInvoked)
 }
 
 func TestUpdateHostIntervals(t *testing.T) {
@@ -1066,9 +2569,18 @@ func TestUpdateHostIntervals(t *testing.T) {
 	svc, err := newTestService(ds, nil)
 	require.Nil(t, err)
 
+	ds.ListDecoratorsFunc = func(opts ...kolide.OptionalArg) ([]*kolide.Decorator, error) {
+		return nil, nil
+	}
+	ds.ListATCTablesFunc = func() ([]*kolide.ATCTable, error) {
+		return nil, nil
+	}
 	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
 		return []*kolide.Pack{}, nil
 	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
 
 	var testCases = []struct {
 		initHost       kolide.Host
@@ -1167,6 +2679,9 @@ func TestUpdateHostIntervals(t *testing.T) {
 		t.Run("", func(t *testing.T) {
 			ctx := hostctx.NewContext(context.Background(), tt.initHost)
 
+			ds.LabelOptionsForHostFunc = func(hid uint) (map[string]json.RawMessage, error) {
+				return nil, nil
+			}
 			ds.OptionsForPlatformFunc = func(platform string) (json.RawMessage, error) {
 				return tt.configOptions, nil
 			}
@@ -1187,10 +2702,10 @@ func TestUpdateHostIntervals(t *testing.T) {
 }
 
 func setupOsqueryTests(t *testing.T) (kolide.Datastore, kolide.Service, *clock.MockClock) {
-	ds, err := inmem.New(config.TestConfig())
+	mockClock := clock.NewMockClock()
+	ds, err := inmem.New(config.TestConfig(), inmem.Clock(mockClock))
 	require.Nil(t, err)
 
-	mockClock := clock.NewMockClock()
 	svc, err := newTestServiceWithClock(ds, nil, mockClock)
 	require.Nil(t, err)
 
@@ -1245,3 +2760,369 @@ func TestAuthenticationErrors(t *testing.T) {
 	require.NotNil(t, err)
 	require.False(t, err.(osqueryError).NodeInvalid())
 }
+
+func TestGetClientConfigClearsRefresh(t *testing.T) {
+	ds := new(mock.Store)
+	ds.ListDecoratorsFunc = func(opts ...kolide.OptionalArg) ([]*kolide.Decorator, error) {
+		return nil, nil
+	}
+	ds.ListATCTablesFunc = func() ([]*kolide.ATCTable, error) {
+		return nil, nil
+	}
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return []*kolide.Pack{}, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	ds.LabelOptionsForHostFunc = func(hid uint) (map[string]json.RawMessage, error) {
+		return nil, nil
+	}
+	ds.OptionsForPlatformFunc = func(platform string) (json.RawMessage, error) {
+		return json.RawMessage(`{"options":{}}`), nil
+	}
+	var cleared []uint
+	ds.SetHostsConfigRefreshFunc = func(hostIDs []uint, requested bool) error {
+		assert.False(t, requested)
+		cleared = append(cleared, hostIDs...)
+		return nil
+	}
+
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 1})
+	_, err = svc.GetClientConfig(ctx)
+	require.Nil(t, err)
+	assert.False(t, ds.SetHostsConfigRefreshFuncInvoked)
+
+	ctx = hostctx.NewContext(context.Background(), kolide.Host{ID: 2, ConfigRefreshRequested: true})
+	_, err = svc.GetClientConfig(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, []uint{2}, cleared)
+}
+
+func TestSubmitDistributedQueryResultsErrors(t *testing.T) {
+	ds := new(mock.Store)
+	rs := pubsub.NewInmemQueryResults()
+	svc, err := newTestService(ds, rs)
+	require.Nil(t, err)
+
+	ds.DistributedQueryCampaignFunc = func(id uint) (*kolide.DistributedQueryCampaign, error) {
+		return nil, &mock.Error{Message: "not found"}
+	}
+	ds.DistributedQueryExecutionExistsFunc = func(campaignID, hostID uint) (bool, error) {
+		return false, nil
+	}
+	var gotResults map[uint]bool
+	ds.ListLabelsForHostFunc = func(hid uint) ([]kolide.Label, error) {
+		return []kolide.Label{{ID: 1}}, nil
+	}
+	ds.NewHostActivityFunc = func(activity *kolide.HostActivity) (*kolide.HostActivity, error) {
+		return activity, nil
+	}
+	ds.RecordLabelQueryExecutionsFunc = func(host *kolide.Host, results map[uint]bool, t time.Time) error {
+		gotResults = results
+		return nil
+	}
+	ds.SaveHostFunc = func(host *kolide.Host) error {
+		return nil
+	}
+
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 1})
+
+	// Results for an unknown campaign are discarded, while the remaining
+	// results are stored
+	err = svc.SubmitDistributedQueryResults(ctx, kolide.OsqueryDistributedQueryResults{
+		hostDistributedQueryPrefix + "42": {{"foo": "bar"}},
+		hostLabelQueryPrefix + "1":        {{"foo": "bar"}},
+	}, map[string]kolide.OsqueryStatus{}, nil)
+	require.NotNil(t, err)
+	oe := err.(osqueryError)
+	assert.True(t, oe.Discard())
+	assert.False(t, oe.Unavailable())
+	assert.Contains(t, oe.Error(), "unknown campaign 42")
+	assert.Equal(t, map[uint]bool{1: true}, gotResults)
+	assert.True(t, ds.SaveHostFuncInvoked)
+
+	// Datastore failures are retried
+	ds.RecordLabelQueryExecutionsFunc = func(host *kolide.Host, results map[uint]bool, t time.Time) error {
+		return errors.New("connection refused")
+	}
+	err = svc.SubmitDistributedQueryResults(ctx, kolide.OsqueryDistributedQueryResults{
+		hostLabelQueryPrefix + "1": {{"foo": "bar"}},
+	}, map[string]kolide.OsqueryStatus{}, nil)
+	require.NotNil(t, err)
+	oe = err.(osqueryError)
+	assert.True(t, oe.Unavailable())
+	assert.False(t, oe.Discard())
+	assert.Equal(t, distributedWriteRetryAfter, oe.RetryAfter())
+
+	// Hosts that no longer exist must re-enroll
+	ds.RecordLabelQueryExecutionsFunc = func(host *kolide.Host, results map[uint]bool, t time.Time) error {
+		return nil
+	}
+	ds.SaveHostFunc = func(host *kolide.Host) error {
+		return &mock.Error{Message: "not found"}
+	}
+	err = svc.SubmitDistributedQueryResults(ctx, kolide.OsqueryDistributedQueryResults{
+		hostLabelQueryPrefix + "1": {{"foo": "bar"}},
+	}, map[string]kolide.OsqueryStatus{}, nil)
+	require.NotNil(t, err)
+	assert.True(t, err.(osqueryError).NodeInvalid())
+}
+
+func TestSubmitDistributedQueryResultsDuplicate(t *testing.T) {
+	ds := new(mock.Store)
+	var written []kolide.DistributedQueryResult
+	rs := &mock.QueryResultStore{
+		WriteResultFunc: func(result kolide.DistributedQueryResult) error {
+			written = append(written, result)
+			return nil
+		},
+	}
+	svc, err := newTestService(ds, rs)
+	require.Nil(t, err)
+
+	campaign := &kolide.DistributedQueryCampaign{ID: 42}
+	ds.DistributedQueryCampaignFunc = func(id uint) (*kolide.DistributedQueryCampaign, error) {
+		return campaign, nil
+	}
+	executed := map[uint]bool{}
+	ds.DistributedQueryExecutionExistsFunc = func(campaignID, hostID uint) (bool, error) {
+		assert.Equal(t, campaign.ID, campaignID)
+		return executed[hostID], nil
+	}
+	var executions []*kolide.DistributedQueryExecution
+	ds.NewDistributedQueryExecutionFunc = func(exec *kolide.DistributedQueryExecution) (*kolide.DistributedQueryExecution, error) {
+		executed[exec.HostID] = true
+		executions = append(executions, exec)
+		return exec, nil
+	}
+	ds.SaveHostFunc = func(host *kolide.Host) error {
+		return nil
+	}
+
+	results := kolide.OsqueryDistributedQueryResults{
+		hostDistributedQueryPrefix + "42": {{"foo": "bar"}},
+	}
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 1})
+	err = svc.SubmitDistributedQueryResults(ctx, results, map[string]kolide.OsqueryStatus{}, nil)
+	require.Nil(t, err)
+	assert.Len(t, written, 1)
+	assert.Len(t, executions, 1)
+
+	// A second submission from the same host is discarded
+	err = svc.SubmitDistributedQueryResults(ctx, results, map[string]kolide.OsqueryStatus{}, nil)
+	require.NotNil(t, err)
+	oe := err.(osqueryError)
+	assert.True(t, oe.Discard())
+	assert.Contains(t, oe.Error(), "duplicate results for campaign 42")
+	assert.Len(t, written, 1)
+	assert.Len(t, executions, 1)
+
+	// Results from other hosts are still stored
+	otherCtx := hostctx.NewContext(context.Background(), kolide.Host{ID: 2})
+	err = svc.SubmitDistributedQueryResults(otherCtx, results, map[string]kolide.OsqueryStatus{}, nil)
+	require.Nil(t, err)
+	assert.Len(t, written, 2)
+	assert.Len(t, executions, 2)
+
+	// Resubmitted results are sent to subscribers when the campaign allows
+	// it, without recording another execution
+	campaign.AllowResubmission = true
+	err = svc.SubmitDistributedQueryResults(ctx, results, map[string]kolide.OsqueryStatus{}, nil)
+	require.Nil(t, err)
+	assert.Len(t, written, 3)
+	assert.Len(t, executions, 2)
+}
+
+func TestIngestScheduledQueryStats(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ds.LabelOptionsForHostFunc = func(hid uint) (map[string]json.RawMessage, error) {
+		return nil, nil
+	}
+	ds.OptionsForPlatformFunc = func(platform string) (json.RawMessage, error) {
+		return json.RawMessage(`{"options":{"pack_delimiter":"/"}}`), nil
+	}
+	ds.ListPacksForHostFunc = func(hid uint) ([]*kolide.Pack, error) {
+		return []*kolide.Pack{{ID: 1, Name: "monitoring"}}, nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	ds.ListScheduledQueriesInPackFunc = func(id uint, opts kolide.ListOptions) ([]*kolide.ScheduledQuery, error) {
+		return []*kolide.ScheduledQuery{
+			{ID: 3, PackID: 1, Name: "processes"},
+			{ID: 4, PackID: 1, Name: "disabled", Disabled: true},
+		}, nil
+	}
+	var saved []kolide.ScheduledQueryStats
+	ds.SaveScheduledQueryStatsFunc = func(hostID uint, stats []kolide.ScheduledQueryStats) error {
+		assert.Equal(t, uint(1), hostID)
+		saved = stats
+		return nil
+	}
+	ds.SaveHostFunc = func(host *kolide.Host) error {
+		return nil
+	}
+
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 1})
+	err = svc.SubmitDistributedQueryResults(ctx, kolide.OsqueryDistributedQueryResults{
+		hostDetailQueryPrefix + scheduledQueryStatsQueryName: {
+			{"name": "pack/monitoring/processes", "executions": "4", "last_executed": "1594857600", "wall_time": "200", "output_size": "1024"},
+			{"name": "pack/monitoring/disabled", "executions": "1", "last_executed": "1594857600", "wall_time": "5", "output_size": "10"},
+			{"name": "local_query", "executions": "1", "last_executed": "1594857600", "wall_time": "5", "output_size": "10"},
+		},
+	}, map[string]kolide.OsqueryStatus{}, nil)
+	require.Nil(t, err)
+	assert.Equal(t, []kolide.ScheduledQueryStats{
+		{
+			HostID:           1,
+			ScheduledQueryID: 3,
+			LastExecuted:     time.Unix(1594857600, 0).UTC(),
+			Executions:       4,
+			WallTime:         200,
+			OutputSize:       1024,
+		},
+	}, saved)
+
+	// osquery 5 reports wall time in milliseconds in wall_time_ms
+	err = svc.SubmitDistributedQueryResults(ctx, kolide.OsqueryDistributedQueryResults{
+		hostDetailQueryPrefix + scheduledQueryStatsQueryName: {
+			{"name": "pack/monitoring/processes", "executions": "2", "wall_time": "1", "wall_time_ms": "1500"},
+		},
+	}, map[string]kolide.OsqueryStatus{}, nil)
+	require.Nil(t, err)
+	require.Len(t, saved, 1)
+	assert.Equal(t, uint64(1500), saved[0].WallTime)
+
+	// Without a configured delimiter, the osquery default is used
+	ds.LabelOptionsForHostFunc = func(hid uint) (map[string]json.RawMessage, error) {
+		return nil, nil
+	}
+	ds.OptionsForPlatformFunc = func(platform string) (json.RawMessage, error) {
+		return json.RawMessage(`{"options":{}}`), nil
+	}
+	err = svc.SubmitDistributedQueryResults(ctx, kolide.OsqueryDistributedQueryResults{
+		hostDetailQueryPrefix + scheduledQueryStatsQueryName: {
+			{"name": "pack_monitoring_processes", "executions": "1"},
+		},
+	}, map[string]kolide.OsqueryStatus{}, nil)
+	require.Nil(t, err)
+	require.Len(t, saved, 1)
+	assert.Equal(t, uint(3), saved[0].ScheduledQueryID)
+}
+
+func TestIngestExtraDetailQueries(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	var saved *kolide.Host
+	ds.SaveHostFunc = func(host *kolide.Host) error {
+		saved = host
+		return nil
+	}
+
+	ctx := hostctx.NewContext(context.Background(), kolide.Host{ID: 1})
+	err = svc.SubmitDistributedQueryResults(ctx, kolide.OsqueryDistributedQueryResults{
+		hostExtraDetailQueryPrefix + "chassis": {{"chassis_type": "Laptop"}},
+		hostExtraDetailQueryPrefix + "none":    {},
+		hostExtraDetailQueryPrefix + "many":    {{"a": "1"}, {"a": "2"}},
+	}, map[string]kolide.OsqueryStatus{}, nil)
+	// Results with more than one row are discarded
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "many returned 2 rows")
+
+	require.NotNil(t, saved)
+	require.NotNil(t, saved.ExtraDetails)
+	var details kolide.HostExtraDetails
+	require.Nil(t, json.Unmarshal(*saved.ExtraDetails, &details))
+	assert.Equal(t, kolide.HostExtraDetails{
+		"chassis": {"chassis_type": "Laptop"},
+		"none":    {},
+	}, details)
+
+	ds.HostFunc = func(id uint) (*kolide.Host, error) {
+		return saved, nil
+	}
+	details, err = svc.HostExtraDetails(context.Background(), 1)
+	require.Nil(t, err)
+	assert.Equal(t, "Laptop", details["chassis"]["chassis_type"])
+}
+
+func TestDetectReboot(t *testing.T) {
+	mockClock := clock.NewMockClock()
+	ds := new(mock.Store)
+	svc, err := newTestServiceWithClock(ds, nil, mockClock)
+	require.Nil(t, err)
+
+	host := kolide.Host{ID: 1}
+	ds.SaveHostFunc = func(h *kolide.Host) error {
+		host = *h
+		return nil
+	}
+	var events []*kolide.HostUptimeEvent
+	ds.NewHostActivityFunc = func(activity *kolide.HostActivity) (*kolide.HostActivity, error) {
+		return activity, nil
+	}
+	ds.NewHostUptimeEventFunc = func(event *kolide.HostUptimeEvent) (*kolide.HostUptimeEvent, error) {
+		events = append(events, event)
+		return event, nil
+	}
+
+	report := func(uptime string) {
+		ctx := hostctx.NewContext(context.Background(), host)
+		err := svc.SubmitDistributedQueryResults(ctx, kolide.OsqueryDistributedQueryResults{
+			hostDetailQueryPrefix + uptimeQueryName: {{"total_seconds": uptime}},
+		}, map[string]kolide.OsqueryStatus{}, nil)
+		require.Nil(t, err)
+	}
+
+	// The first report only estimates the boot time
+	boot := mockClock.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+	report("3600")
+	require.NotNil(t, host.BootTime)
+	assert.Equal(t, boot, *host.BootTime)
+	assert.Empty(t, events)
+
+	// Reporting delays and gaps in reporting are not reboots
+	mockClock.AddTime(time.Minute)
+	report("3540")
+	mockClock.AddTime(72 * time.Hour)
+	report(fmt.Sprint(3600 + 72*3600 + 60))
+	assert.Equal(t, boot, *host.BootTime)
+	assert.Empty(t, events)
+
+	// Results without the uptime do not change the boot time
+	ctx := hostctx.NewContext(context.Background(), host)
+	err = svc.SubmitDistributedQueryResults(ctx, kolide.OsqueryDistributedQueryResults{
+		hostDetailQueryPrefix + uptimeQueryName: {},
+	}, map[string]kolide.OsqueryStatus{}, nil)
+	require.Nil(t, err)
+	assert.Empty(t, events)
+
+	// A later boot time is a reboot, detected even if the host did not
+	// report while it was down
+	mockClock.AddTime(6 * time.Hour)
+	report("600")
+	rebooted := mockClock.Now().Add(-10 * time.Minute).Truncate(time.Second).UTC()
+	require.Len(t, events, 1)
+	assert.Equal(t, &kolide.HostUptimeEvent{
+		HostID:           1,
+		BootTime:         rebooted,
+		PreviousBootTime: boot,
+		DetectedAt:       mockClock.Now(),
+	}, events[0])
+	assert.Equal(t, rebooted, *host.BootTime)
+
+	// An earlier boot time corrects the estimate without recording a reboot
+	mockClock.AddTime(time.Hour)
+	report("7200")
+	assert.Equal(t, mockClock.Now().Add(-2*time.Hour).Truncate(time.Second).UTC(), *host.BootTime)
+	assert.Len(t, events, 1)
+}
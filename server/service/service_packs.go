@@ -2,14 +2,144 @@ package service
 
 import (
 	"context"
-
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kolide/fleet/server/contexts/viewer"
 	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/logging"
+	"github.com/pkg/errors"
+	"gopkg.in/guregu/null.v3"
 )
 
 func (svc service) ApplyPackSpecs(ctx context.Context, specs []*kolide.PackSpec) error {
+	if err := svc.validatePackSpecQueries(specs); err != nil {
+		return err
+	}
+	for _, spec := range specs {
+		if err := svc.validatePackLoggerPlugin(spec.LoggerPlugin); err != nil {
+			return err
+		}
+		if err := validatePackScheduleWindow(spec.WindowStartHour, spec.WindowEndHour, spec.WindowDays); err != nil {
+			return err
+		}
+	}
 	return svc.ds.ApplyPackSpecs(specs)
 }
 
+// validatePackScheduleWindow returns an error if the schedule window hours are
+// not both set or not valid hours, or the days are not valid day names.
+func validatePackScheduleWindow(start, end *uint, days string) error {
+	if (start == nil) != (end == nil) {
+		return newInvalidArgumentError("window_start_hour", "window_start_hour and window_end_hour must be set together")
+	}
+	if start != nil && (*start > 23 || *end > 23) {
+		return newInvalidArgumentError("window_start_hour", "window hours must be between 0 and 23")
+	}
+	if days != "" {
+		if start == nil {
+			return newInvalidArgumentError("window_days", "window_days requires window_start_hour and window_end_hour")
+		}
+		if _, err := kolide.ParseWeekdays(days); err != nil {
+			return newInvalidArgumentError("window_days", err.Error())
+		}
+	}
+	return nil
+}
+
+// applyPackScheduleWindow updates the schedule window of pack from the
+// payload, and validates the resulting window.
+func applyPackScheduleWindow(pack *kolide.Pack, p kolide.PackPayload) error {
+	for _, hour := range []struct {
+		payload *null.Int
+		field   **uint
+	}{
+		{p.WindowStartHour, &pack.WindowStartHour},
+		{p.WindowEndHour, &pack.WindowEndHour},
+	} {
+		switch {
+		case hour.payload == nil:
+		case !hour.payload.Valid:
+			*hour.field = nil
+		case hour.payload.Int64 < 0:
+			return newInvalidArgumentError("window_start_hour", "window hours must be between 0 and 23")
+		default:
+			val := uint(hour.payload.Int64)
+			*hour.field = &val
+		}
+	}
+	if p.WindowDays != nil {
+		pack.WindowDays = *p.WindowDays
+	}
+	return validatePackScheduleWindow(pack.WindowStartHour, pack.WindowEndHour, pack.WindowDays)
+}
+
+// validatePackLoggerPlugin returns an error if plugin is neither empty nor one
+// of the result log plugins configured for Fleet.
+func (svc service) validatePackLoggerPlugin(plugin string) error {
+	if plugin == "" || plugin == svc.config.Osquery.ResultLogPlugin {
+		return nil
+	}
+	for _, p := range logging.PackResultLogPlugins(svc.config) {
+		if p == plugin {
+			return nil
+		}
+	}
+	return newInvalidArgumentError("logger_plugin",
+		fmt.Sprintf("'%s' is not a configured result log plugin, add it to osquery_pack_result_log_plugins", plugin))
+}
+
+// validatePackSpecQueries returns an error if any of the pack specs schedule a
+// query that has been soft deleted. Without this check the scheduled query
+// would be created but never sent to hosts. The sample rates and column
+// mappings of the scheduled queries are validated as well.
+func (svc service) validatePackSpecQueries(specs []*kolide.PackSpec) error {
+	queries, err := svc.ds.ListQueries(kolide.ListQueryOptions{IncludeDeleted: true})
+	if err != nil {
+		return errors.Wrap(err, "listing queries")
+	}
+	deleted := map[string]bool{}
+	sql := map[string]string{}
+	for _, q := range queries {
+		if q.Deleted {
+			deleted[q.Name] = true
+		}
+		sql[q.Name] = q.Query
+	}
+	denyList, err := svc.queryDenyList()
+	if err != nil {
+		return err
+	}
+
+	for _, spec := range specs {
+		for _, q := range spec.Queries {
+			if deleted[q.QueryName] {
+				return newInvalidArgumentError("queries",
+					fmt.Sprintf("pack '%s' references deleted query '%s', restore the query or remove it from the pack", spec.Name, q.QueryName))
+			}
+			if pattern, ok := matchQueryDenyList(denyList, sql[q.QueryName]); ok {
+				return newInvalidArgumentError("queries",
+					fmt.Sprintf("pack '%s' schedules query '%s', which %s", spec.Name, q.QueryName, denyReason(pattern)))
+			}
+			if err := validateSampleRate(q.SampleRate); err != nil {
+				return err
+			}
+			if err := validateColumnMapping(q.ColumnMapping, sql[q.QueryName]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (svc service) GetPackSpecs(ctx context.Context) ([]*kolide.PackSpec, error) {
 	return svc.ds.GetPackSpecs()
 }
@@ -18,6 +148,247 @@ func (svc service) GetPackSpec(ctx context.Context, name string) (*kolide.PackSp
 	return svc.ds.GetPackSpec(name)
 }
 
+func (svc service) ExportPack(ctx context.Context, id uint) (*kolide.PackSpec, error) {
+	pack, err := svc.ds.Pack(id)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := svc.ds.GetPackSpec(pack.Name)
+	if err != nil {
+		return nil, err
+	}
+	// IDs are specific to this Fleet instance, so they are left out of the
+	// exported spec.
+	spec.ID = 0
+	return spec, nil
+}
+
+func (svc service) ImportPack(ctx context.Context, spec *kolide.PackSpec) (*kolide.Pack, error) {
+	if spec == nil || spec.Name == "" {
+		return nil, newInvalidArgumentError("name", "pack name must not be empty")
+	}
+
+	for _, q := range spec.Queries {
+		_, err := svc.ds.QueryByName(q.QueryName)
+		if kolide.IsNotFound(err) {
+			return nil, newInvalidArgumentError("queries",
+				fmt.Sprintf("pack '%s' references unknown query '%s'", spec.Name, q.QueryName))
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "looking up query '%s'", q.QueryName)
+		}
+	}
+
+	for _, l := range spec.Targets.Labels {
+		ids, err := svc.ds.LabelIDsByName([]string{l})
+		if err != nil {
+			return nil, errors.Wrapf(err, "looking up label '%s'", l)
+		}
+		if len(ids) == 0 {
+			return nil, newInvalidArgumentError("targets",
+				fmt.Sprintf("pack '%s' targets unknown label '%s'", spec.Name, l))
+		}
+	}
+
+	// Applying the spec replaces the scheduled queries and targets of an
+	// existing pack, so importing the same spec again is a no-op.
+	spec.ID = 0
+	if err := svc.ApplyPackSpecs(ctx, []*kolide.PackSpec{spec}); err != nil {
+		return nil, err
+	}
+
+	pack, ok, err := svc.ds.PackByName(spec.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "retrieving imported pack")
+	}
+	if !ok {
+		return nil, errors.Errorf("imported pack '%s' not found", spec.Name)
+	}
+	return pack, nil
+}
+
+// maxImportPackSize is the largest pack file, in bytes, that
+// ImportPackFromURL will fetch.
+const maxImportPackSize = 2 << 20
+
+// packLineContinuation matches the backslash escaped newlines that osquery
+// accepts in pack files, but which are not valid JSON.
+var packLineContinuation = regexp.MustCompile(`\s*\\\n`)
+
+func (svc service) ImportPackFromURL(ctx context.Context, packURL string) ([]*kolide.Pack, error) {
+	u, err := url.Parse(packURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, newInvalidArgumentError("url", "must be an http or https URL")
+	}
+
+	body, err := svc.fetchPack(ctx, githubRawURL(u))
+	if err != nil {
+		return nil, err
+	}
+	body = packLineContinuation.ReplaceAll(body, []byte(`\n`))
+
+	// The file is either a single pack, named after the file, or an
+	// osquery config with inline packs.
+	var file struct {
+		kolide.PermissivePackContent
+		Packs map[string]kolide.PermissivePackContent `json:"packs"`
+	}
+	if err := json.Unmarshal(body, &file); err != nil {
+		return nil, newInvalidArgumentError("url", "parsing pack: "+err.Error())
+	}
+	packs := file.Packs
+	if len(packs) == 0 {
+		name := strings.TrimSuffix(path.Base(u.Path), path.Ext(u.Path))
+		if name == "" || name == "." || name == "/" {
+			return nil, newInvalidArgumentError("url", "cannot determine pack name from URL")
+		}
+		packs = map[string]kolide.PermissivePackContent{name: file.PermissivePackContent}
+	}
+
+	names := []string{}
+	for name := range packs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	imported := []*kolide.Pack{}
+	for _, name := range names {
+		spec, err := svc.packSpecFromOsqueryPack(ctx, name, packs[name])
+		if err != nil {
+			return nil, err
+		}
+		pack, err := svc.ImportPack(ctx, spec)
+		if err != nil {
+			return nil, err
+		}
+		imported = append(imported, pack)
+	}
+	return imported, nil
+}
+
+// fetchPack retrieves the pack file at packURL, rejecting files larger than
+// maxImportPackSize.
+func (svc service) fetchPack(ctx context.Context, packURL string) ([]byte, error) {
+	req, err := http.NewRequest("GET", packURL, nil)
+	if err != nil {
+		return nil, newInvalidArgumentError("url", err.Error())
+	}
+	resp, err := svc.packClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, newInvalidArgumentError("url", "fetching pack: "+err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newInvalidArgumentError("url",
+			fmt.Sprintf("fetching pack: unexpected status %d", resp.StatusCode))
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxImportPackSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading pack")
+	}
+	if len(body) > maxImportPackSize {
+		return nil, newInvalidArgumentError("url",
+			fmt.Sprintf("pack must not be larger than %d bytes", maxImportPackSize))
+	}
+	return body, nil
+}
+
+// githubRawURL returns the URL of the raw file for links to files in GitHub
+// repositories (https://github.com/<owner>/<repo>/blob/<ref>/<path>). Other
+// URLs are returned unchanged.
+func githubRawURL(u *url.URL) string {
+	parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 5)
+	if u.Host != "github.com" || len(parts) != 5 || parts[2] != "blob" {
+		return u.String()
+	}
+	raw := url.URL{
+		Scheme: "https",
+		Host:   "raw.githubusercontent.com",
+		Path:   "/" + strings.Join([]string{parts[0], parts[1], parts[3], parts[4]}, "/"),
+	}
+	return raw.String()
+}
+
+// packSpecFromOsqueryPack converts an osquery pack into a pack spec that
+// schedules each of its queries. Queries that do not exist are created;
+// existing queries with the same name are left unchanged.
+func (svc service) packSpecFromOsqueryPack(ctx context.Context, name string, content kolide.PermissivePackContent) (*kolide.PackSpec, error) {
+	if len(content.Queries) == 0 {
+		return nil, newInvalidArgumentError("queries", fmt.Sprintf("pack '%s' has no queries", name))
+	}
+
+	vc, ok := viewer.FromContext(ctx)
+	if !ok {
+		return nil, errNoContext
+	}
+
+	queryNames := []string{}
+	for queryName := range content.Queries {
+		queryNames = append(queryNames, queryName)
+	}
+	sort.Strings(queryNames)
+
+	spec := &kolide.PackSpec{
+		Name:     name,
+		Platform: content.Platform,
+	}
+	for _, queryName := range queryNames {
+		query := content.Queries[queryName]
+		interval, err := query.IntervalSeconds()
+		if err != nil {
+			return nil, newInvalidArgumentError("interval",
+				fmt.Sprintf("query '%s': %s", queryName, err.Error()))
+		}
+
+		_, err = svc.ds.QueryByName(queryName)
+		if kolide.IsNotFound(err) {
+			// Queries created from the pack are validated as if they
+			// were created through the API
+			if err := svc.checkQuerySQL(query.Query); err != nil {
+				return nil, err
+			}
+			if err := svc.checkQueryDenyList(query.Query); err != nil {
+				return nil, err
+			}
+			_, err = svc.ds.NewQuery(&kolide.Query{
+				Name:        queryName,
+				Description: query.Description,
+				Query:       query.Query,
+				Saved:       true,
+				AuthorID:    uintPtr(vc.UserID()),
+			})
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating query '%s'", queryName)
+		}
+
+		// The pack wide version and shard apply to queries that do not
+		// set their own.
+		version, shard := query.Version, query.Shard
+		if version == nil && content.Version != "" {
+			version = &content.Version
+		}
+		if shard == nil && content.Shard != 0 {
+			shard = &content.Shard
+		}
+
+		spec.Queries = append(spec.Queries, kolide.PackSpecQuery{
+			Name:        queryName,
+			QueryName:   queryName,
+			Description: query.Description,
+			Interval:    interval,
+			Snapshot:    query.Snapshot,
+			Removed:     query.Removed,
+			Shard:       shard,
+			Platform:    query.Platform,
+			Version:     version,
+		})
+	}
+	return spec, nil
+}
+
 func (svc service) ListPacks(ctx context.Context, opt kolide.ListOptions) ([]*kolide.Pack, error) {
 	return svc.ds.ListPacks(opt)
 }
@@ -45,6 +416,17 @@ func (svc service) NewPack(ctx context.Context, p kolide.PackPayload) (*kolide.P
 		pack.Disabled = *p.Disabled
 	}
 
+	if p.LoggerPlugin != nil {
+		if err := svc.validatePackLoggerPlugin(*p.LoggerPlugin); err != nil {
+			return nil, err
+		}
+		pack.LoggerPlugin = *p.LoggerPlugin
+	}
+
+	if err := applyPackScheduleWindow(&pack, p); err != nil {
+		return nil, err
+	}
+
 	_, err := svc.ds.NewPack(&pack)
 	if err != nil {
 		return nil, err
@@ -71,6 +453,75 @@ func (svc service) NewPack(ctx context.Context, p kolide.PackPayload) (*kolide.P
 	return &pack, nil
 }
 
+func (svc service) ClonePack(ctx context.Context, sourcePackID uint, newName string) (*kolide.Pack, error) {
+	if newName == "" {
+		return nil, newInvalidArgumentError("name", "pack name must not be empty")
+	}
+
+	source, err := svc.ds.Pack(sourcePackID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, exists, err := svc.ds.PackByName(newName)
+	if err != nil {
+		return nil, errors.Wrap(err, "checking for existing pack")
+	}
+	if exists {
+		return nil, alreadyExistsError{resourceType: "pack", name: newName}
+	}
+
+	labels, err := svc.ds.ListLabelsForPack(source.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing pack labels")
+	}
+	hostIDs, err := svc.ds.ListExplicitHostsInPack(source.ID, kolide.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing pack hosts")
+	}
+	scheduledQueries, err := svc.ds.ListScheduledQueriesInPack(source.ID, kolide.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing scheduled queries")
+	}
+
+	// The clone is disabled so that it does not run until it is enabled.
+	pack := &kolide.Pack{
+		Name:        newName,
+		Description: source.Description,
+		Platform:    source.Platform,
+		Disabled:    true,
+		// The clone keeps the schedule window of the source pack
+		WindowStartHour: source.WindowStartHour,
+		WindowEndHour:   source.WindowEndHour,
+		WindowDays:      source.WindowDays,
+	}
+	if _, err := svc.ds.NewPack(pack); err != nil {
+		return nil, err
+	}
+
+	for _, label := range labels {
+		if err := svc.ds.AddLabelToPack(label.ID, pack.ID); err != nil {
+			return nil, errors.Wrap(err, "adding label to pack")
+		}
+	}
+	for _, hostID := range hostIDs {
+		if err := svc.ds.AddHostToPack(hostID, pack.ID); err != nil {
+			return nil, errors.Wrap(err, "adding host to pack")
+		}
+	}
+	for _, sq := range scheduledQueries {
+		clone := *sq
+		clone.ID = 0
+		clone.PackID = pack.ID
+		clone.UpdateCreateTimestamps = kolide.UpdateCreateTimestamps{}
+		if _, err := svc.ds.NewScheduledQuery(&clone); err != nil {
+			return nil, errors.Wrapf(err, "scheduling query '%s'", sq.Name)
+		}
+	}
+
+	return pack, nil
+}
+
 func (svc service) ModifyPack(ctx context.Context, id uint, p kolide.PackPayload) (*kolide.Pack, error) {
 	pack, err := svc.ds.Pack(id)
 	if err != nil {
@@ -93,6 +544,17 @@ func (svc service) ModifyPack(ctx context.Context, id uint, p kolide.PackPayload
 		pack.Disabled = *p.Disabled
 	}
 
+	if p.LoggerPlugin != nil {
+		if err := svc.validatePackLoggerPlugin(*p.LoggerPlugin); err != nil {
+			return nil, err
+		}
+		pack.LoggerPlugin = *p.LoggerPlugin
+	}
+
+	if err := applyPackScheduleWindow(pack, p); err != nil {
+		return nil, err
+	}
+
 	err = svc.ds.SavePack(pack)
 	if err != nil {
 		return nil, err
@@ -237,6 +699,39 @@ func (svc service) ListExplicitHostsInPack(ctx context.Context, pid uint, opt ko
 	return svc.ds.ListExplicitHostsInPack(pid, opt)
 }
 
+func (svc service) HostsMissingPack(ctx context.Context, packID uint, opt kolide.HostListOptions) ([]kolide.Host, error) {
+	if err := validateHostListFilters(opt); err != nil {
+		return nil, err
+	}
+	if opt.After != nil {
+		return nil, newInvalidArgumentError("after", "cursor pagination is not supported for hosts missing a pack")
+	}
+
+	pack, err := svc.ds.Pack(packID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A pack without enabled queries is absent from every host's osquery
+	// schedule, so no host can be expected to report it
+	queries, err := svc.ds.ListScheduledQueriesInPack(pack.ID, kolide.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing scheduled queries in pack")
+	}
+	enabled := false
+	for _, query := range queries {
+		if !query.Disabled {
+			enabled = true
+			break
+		}
+	}
+	if !enabled {
+		return []kolide.Host{}, nil
+	}
+
+	return svc.ds.ListHostsMissingPack(pack.ID, opt)
+}
+
 func (svc service) ListPacksForHost(ctx context.Context, hid uint) ([]*kolide.Pack, error) {
 	return svc.ds.ListPacksForHost(hid)
 }
@@ -2,12 +2,21 @@ package service
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/contexts/viewer"
 	"github.com/kolide/fleet/server/datastore/inmem"
 	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/mock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
 )
 
 func TestListPacks(t *testing.T) {
@@ -54,3 +63,530 @@ func TestGetPack(t *testing.T) {
 
 	assert.Equal(t, pack.ID, packVerify.ID)
 }
+
+func TestApplyPackSpecsDeletedQuery(t *testing.T) {
+	ds := new(mock.Store)
+	svc := service{ds: ds}
+
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	ds.ListQueriesFunc = func(opt kolide.ListQueryOptions) ([]*kolide.Query, error) {
+		assert.True(t, opt.IncludeDeleted)
+		return []*kolide.Query{
+			{Name: "active"},
+			{Name: "removed", DeleteFields: kolide.DeleteFields{Deleted: true}},
+		}, nil
+	}
+	ds.ApplyPackSpecsFunc = func(specs []*kolide.PackSpec) error {
+		return nil
+	}
+
+	err := svc.ApplyPackSpecs(context.Background(), []*kolide.PackSpec{
+		{Name: "pack", Queries: []kolide.PackSpecQuery{{QueryName: "active"}}},
+	})
+	require.Nil(t, err)
+	assert.True(t, ds.ApplyPackSpecsFuncInvoked)
+
+	ds.ApplyPackSpecsFuncInvoked = false
+	err = svc.ApplyPackSpecs(context.Background(), []*kolide.PackSpec{
+		{Name: "pack", Queries: []kolide.PackSpecQuery{{QueryName: "active"}, {QueryName: "removed"}}},
+	})
+	require.NotNil(t, err)
+	assert.IsType(t, &invalidArgumentError{}, err)
+	assert.Contains(t, err.Error(), "removed")
+	assert.False(t, ds.ApplyPackSpecsFuncInvoked)
+}
+
+func TestApplyPackSpecsDeniedQuery(t *testing.T) {
+	ds := new(mock.Store)
+	svc := service{ds: ds}
+
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{
+			QueryDenyList: kolide.QueryDenyList{{Pattern: "from curl"}},
+		}, nil
+	}
+	ds.ListQueriesFunc = func(opt kolide.ListQueryOptions) ([]*kolide.Query, error) {
+		return []*kolide.Query{
+			{Name: "time", Query: "select * from time"},
+			{Name: "curl", Query: "select * from curl where url = 'http://example.com'"},
+		}, nil
+	}
+	ds.ApplyPackSpecsFunc = func(specs []*kolide.PackSpec) error {
+		return nil
+	}
+
+	// Queries saved before the pattern was denied cannot be scheduled
+	err := svc.ApplyPackSpecs(context.Background(), []*kolide.PackSpec{
+		{Name: "pack", Queries: []kolide.PackSpecQuery{{QueryName: "time"}, {QueryName: "curl"}}},
+	})
+	require.NotNil(t, err)
+	assert.IsType(t, &invalidArgumentError{}, err)
+	assert.Contains(t, err.Error(), "from curl")
+	assert.False(t, ds.ApplyPackSpecsFuncInvoked)
+
+	err = svc.ApplyPackSpecs(context.Background(), []*kolide.PackSpec{
+		{Name: "pack", Queries: []kolide.PackSpecQuery{{QueryName: "time"}}},
+	})
+	require.Nil(t, err)
+	assert.True(t, ds.ApplyPackSpecsFuncInvoked)
+}
+
+func TestPackLoggerPlugin(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+
+	conf := config.TestConfig()
+	conf.Osquery.PackResultLogPlugins = "firehose, pubsub"
+	svc := service{ds: ds, config: conf}
+	ctx := context.Background()
+
+	name := "compliance"
+	plugin := "firehose"
+	pack, err := svc.NewPack(ctx, kolide.PackPayload{Name: &name, LoggerPlugin: &plugin})
+	require.Nil(t, err)
+	assert.Equal(t, "firehose", pack.LoggerPlugin)
+
+	plugin = "filesystem"
+	pack, err = svc.ModifyPack(ctx, pack.ID, kolide.PackPayload{LoggerPlugin: &plugin})
+	require.Nil(t, err)
+	assert.Equal(t, "filesystem", pack.LoggerPlugin)
+
+	plugin = "kafka"
+	_, err = svc.ModifyPack(ctx, pack.ID, kolide.PackPayload{LoggerPlugin: &plugin})
+	require.NotNil(t, err)
+	assert.IsType(t, &invalidArgumentError{}, err)
+
+	err = svc.ApplyPackSpecs(ctx, []*kolide.PackSpec{{Name: "compliance", LoggerPlugin: "kafka"}})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "kafka")
+}
+
+func TestPackScheduleWindow(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+	svc := service{ds: ds, config: config.TestConfig()}
+	ctx := context.Background()
+
+	intPtr := func(n null.Int) *null.Int { return &n }
+	name := "heavy"
+	days := "sat,sun"
+	pack, err := svc.NewPack(ctx, kolide.PackPayload{
+		Name:            &name,
+		WindowStartHour: intPtr(null.IntFrom(22)),
+		WindowEndHour:   intPtr(null.IntFrom(6)),
+		WindowDays:      &days,
+	})
+	require.Nil(t, err)
+	require.NotNil(t, pack.WindowStartHour)
+	require.NotNil(t, pack.WindowEndHour)
+	assert.Equal(t, uint(22), *pack.WindowStartHour)
+	assert.Equal(t, uint(6), *pack.WindowEndHour)
+	assert.Equal(t, "sat,sun", pack.WindowDays)
+
+	// Hours must be set together
+	_, err = svc.ModifyPack(ctx, pack.ID, kolide.PackPayload{WindowEndHour: &null.Int{}})
+	require.NotNil(t, err)
+	assert.IsType(t, &invalidArgumentError{}, err)
+
+	_, err = svc.ModifyPack(ctx, pack.ID, kolide.PackPayload{
+		WindowStartHour: intPtr(null.IntFrom(24)),
+	})
+	require.NotNil(t, err)
+	assert.IsType(t, &invalidArgumentError{}, err)
+
+	days = "weekend"
+	_, err = svc.ModifyPack(ctx, pack.ID, kolide.PackPayload{WindowDays: &days})
+	require.NotNil(t, err)
+	assert.IsType(t, &invalidArgumentError{}, err)
+
+	// Clearing the window schedules the pack at all times
+	days = ""
+	pack, err = svc.ModifyPack(ctx, pack.ID, kolide.PackPayload{
+		WindowStartHour: &null.Int{},
+		WindowEndHour:   &null.Int{},
+		WindowDays:      &days,
+	})
+	require.Nil(t, err)
+	assert.Nil(t, pack.WindowStartHour)
+	assert.Nil(t, pack.WindowEndHour)
+
+	hour := uint(3)
+	err = svc.ApplyPackSpecs(ctx, []*kolide.PackSpec{{Name: "heavy", WindowStartHour: &hour}})
+	require.NotNil(t, err)
+	assert.IsType(t, &invalidArgumentError{}, err)
+}
+
+func TestHostsMissingPack(t *testing.T) {
+	ds := new(mock.Store)
+	svc := service{ds: ds}
+	ctx := context.Background()
+
+	ds.PackFunc = func(id uint) (*kolide.Pack, error) {
+		return &kolide.Pack{ID: id, Name: "baseline"}, nil
+	}
+	queries := []*kolide.ScheduledQuery{{ID: 1, PackID: 7, Name: "time", Disabled: true}}
+	ds.ListScheduledQueriesInPackFunc = func(id uint, opts kolide.ListOptions) ([]*kolide.ScheduledQuery, error) {
+		return queries, nil
+	}
+	ds.ListHostsMissingPackFunc = func(pid uint, opt kolide.HostListOptions) ([]kolide.Host, error) {
+		assert.Equal(t, uint(7), pid)
+		return []kolide.Host{{ID: 3, HostName: "stale"}}, nil
+	}
+
+	// Packs without enabled queries are not expected on any host
+	hosts, err := svc.HostsMissingPack(ctx, 7, kolide.HostListOptions{})
+	require.Nil(t, err)
+	assert.Empty(t, hosts)
+	assert.False(t, ds.ListHostsMissingPackFuncInvoked)
+
+	queries = append(queries, &kolide.ScheduledQuery{ID: 2, PackID: 7, Name: "processes"})
+	hosts, err = svc.HostsMissingPack(ctx, 7, kolide.HostListOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, []kolide.Host{{ID: 3, HostName: "stale"}}, hosts)
+
+	after := ""
+	_, err = svc.HostsMissingPack(ctx, 7, kolide.HostListOptions{After: &after})
+	assert.IsType(t, &invalidArgumentError{}, err)
+	_, err = svc.HostsMissingPack(ctx, 7, kolide.HostListOptions{SeenStatus: "mia", SeenWithin: time.Minute})
+	assert.IsType(t, &invalidArgumentError{}, err)
+}
+
+func TestExportPack(t *testing.T) {
+	ds := new(mock.Store)
+	svc := service{ds: ds}
+
+	ds.PackFunc = func(id uint) (*kolide.Pack, error) {
+		assert.Equal(t, uint(7), id)
+		return &kolide.Pack{ID: 7, Name: "pack"}, nil
+	}
+	ds.GetPackSpecFunc = func(name string) (*kolide.PackSpec, error) {
+		assert.Equal(t, "pack", name)
+		return &kolide.PackSpec{
+			ID:      7,
+			Name:    "pack",
+			Targets: kolide.PackSpecTargets{Labels: []string{"All Hosts"}},
+			Queries: []kolide.PackSpecQuery{{QueryName: "time", Interval: 60}},
+		}, nil
+	}
+
+	spec, err := svc.ExportPack(context.Background(), 7)
+	require.Nil(t, err)
+	assert.Equal(t, &kolide.PackSpec{
+		Name:    "pack",
+		Targets: kolide.PackSpecTargets{Labels: []string{"All Hosts"}},
+		Queries: []kolide.PackSpecQuery{{QueryName: "time", Interval: 60}},
+	}, spec)
+}
+
+func TestImportPack(t *testing.T) {
+	ds := new(mock.Store)
+	svc := service{ds: ds}
+
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	ds.QueryByNameFunc = func(name string, opts ...kolide.OptionalArg) (*kolide.Query, error) {
+		if name == "time" {
+			return &kolide.Query{Name: name}, nil
+		}
+		return nil, &mock.Error{Message: "not found"}
+	}
+	ds.LabelIDsByNameFunc = func(labels []string) ([]uint, error) {
+		if labels[0] == "All Hosts" {
+			return []uint{1}, nil
+		}
+		return []uint{}, nil
+	}
+	ds.ListQueriesFunc = func(opt kolide.ListQueryOptions) ([]*kolide.Query, error) {
+		return []*kolide.Query{{Name: "time"}}, nil
+	}
+	var applied []*kolide.PackSpec
+	ds.ApplyPackSpecsFunc = func(specs []*kolide.PackSpec) error {
+		applied = append(applied, specs...)
+		return nil
+	}
+	ds.PackByNameFunc = func(name string, opts ...kolide.OptionalArg) (*kolide.Pack, bool, error) {
+		return &kolide.Pack{ID: 3, Name: name}, true, nil
+	}
+
+	spec := &kolide.PackSpec{
+		ID:      12,
+		Name:    "pack",
+		Targets: kolide.PackSpecTargets{Labels: []string{"All Hosts"}},
+		Queries: []kolide.PackSpecQuery{{QueryName: "time", Interval: 60}},
+	}
+	pack, err := svc.ImportPack(context.Background(), spec)
+	require.Nil(t, err)
+	assert.Equal(t, uint(3), pack.ID)
+	require.Len(t, applied, 1)
+	assert.Equal(t, uint(0), applied[0].ID)
+
+	_, err = svc.ImportPack(context.Background(), &kolide.PackSpec{
+		Name:    "pack",
+		Queries: []kolide.PackSpecQuery{{QueryName: "missing"}},
+	})
+	require.NotNil(t, err)
+	assert.IsType(t, &invalidArgumentError{}, err)
+	assert.Contains(t, err.Error(), "missing")
+
+	_, err = svc.ImportPack(context.Background(), &kolide.PackSpec{
+		Name:    "pack",
+		Targets: kolide.PackSpecTargets{Labels: []string{"missing"}},
+	})
+	require.NotNil(t, err)
+	assert.IsType(t, &invalidArgumentError{}, err)
+
+	_, err = svc.ImportPack(context.Background(), &kolide.PackSpec{})
+	require.NotNil(t, err)
+	assert.Len(t, applied, 1)
+}
+
+func TestImportPackFromURL(t *testing.T) {
+	ds := new(mock.Store)
+	svc := service{ds: ds, packClient: http.DefaultClient}
+
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/packs/incident-response.conf":
+			w.Write([]byte(`{
+  "platform": "darwin",
+  "version": "2.9.0",
+  "queries": {
+    "time": {"query": "select * from time;", "interval": "60"},
+    "launchd": {
+      "query": "select * \
+        from launchd;",
+      "interval": 3600,
+      "description": "Launch daemons",
+      "snapshot": true,
+      "platform": "linux",
+      "version": "3.0.0"
+    }
+  }
+}`))
+		case "/osquery.conf":
+			w.Write([]byte(`{"packs": {"a": {"queries": {"time": {"query": "select 1;", "interval": 10}}}, "b": {"queries": {"time": {"query": "select 1;", "interval": 20}}}}}`))
+		case "/large.conf":
+			w.Write([]byte(strings.Repeat(" ", maxImportPackSize+1)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var created []*kolide.Query
+	ds.QueryByNameFunc = func(name string, opts ...kolide.OptionalArg) (*kolide.Query, error) {
+		if name == "time" {
+			return &kolide.Query{Name: name}, nil
+		}
+		for _, q := range created {
+			if q.Name == name {
+				return q, nil
+			}
+		}
+		return nil, &mock.Error{Message: "not found"}
+	}
+	ds.NewQueryFunc = func(query *kolide.Query, opts ...kolide.OptionalArg) (*kolide.Query, error) {
+		created = append(created, query)
+		return query, nil
+	}
+	ds.ListQueriesFunc = func(opt kolide.ListQueryOptions) ([]*kolide.Query, error) {
+		return nil, nil
+	}
+	var applied []*kolide.PackSpec
+	ds.ApplyPackSpecsFunc = func(specs []*kolide.PackSpec) error {
+		applied = append(applied, specs...)
+		return nil
+	}
+	ds.PackByNameFunc = func(name string, opts ...kolide.OptionalArg) (*kolide.Pack, bool, error) {
+		return &kolide.Pack{Name: name}, true, nil
+	}
+
+	ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: &kolide.User{ID: 7}})
+
+	packs, err := svc.ImportPackFromURL(ctx, server.URL+"/packs/incident-response.conf")
+	require.Nil(t, err)
+	require.Len(t, packs, 1)
+	assert.Equal(t, "incident-response", packs[0].Name)
+
+	// Only the query that did not already exist is created
+	require.Len(t, created, 1)
+	assert.Equal(t, "launchd", created[0].Name)
+	assert.Equal(t, "select *\n        from launchd;", created[0].Query)
+	assert.Equal(t, "Launch daemons", created[0].Description)
+	assert.True(t, created[0].Saved)
+	require.NotNil(t, created[0].AuthorID)
+	assert.Equal(t, uint(7), *created[0].AuthorID)
+
+	require.Len(t, applied, 1)
+	spec := applied[0]
+	assert.Equal(t, "darwin", spec.Platform)
+	require.Len(t, spec.Queries, 2)
+	launchd, timeQuery := spec.Queries[0], spec.Queries[1]
+	assert.Equal(t, "launchd", launchd.QueryName)
+	assert.Equal(t, uint(3600), launchd.Interval)
+	assert.Equal(t, boolPtr(true), launchd.Snapshot)
+	assert.Equal(t, stringPtr("linux"), launchd.Platform)
+	assert.Equal(t, stringPtr("3.0.0"), launchd.Version)
+	assert.Equal(t, "time", timeQuery.QueryName)
+	assert.Equal(t, uint(60), timeQuery.Interval)
+	assert.Equal(t, stringPtr("2.9.0"), timeQuery.Version)
+
+	applied = nil
+	packs, err = svc.ImportPackFromURL(ctx, server.URL+"/osquery.conf")
+	require.Nil(t, err)
+	require.Len(t, packs, 2)
+	assert.Equal(t, "a", packs[0].Name)
+	assert.Equal(t, "b", packs[1].Name)
+	require.Len(t, applied, 2)
+	assert.Equal(t, uint(20), applied[1].Queries[0].Interval)
+
+	for _, u := range []string{
+		"ftp://example.com/pack.conf",
+		"not a url",
+		server.URL + "/missing.conf",
+		server.URL + "/large.conf",
+	} {
+		_, err = svc.ImportPackFromURL(ctx, u)
+		require.NotNil(t, err, u)
+		assert.IsType(t, &invalidArgumentError{}, err, u)
+	}
+}
+
+func TestImportPackFromURLDeniedQuery(t *testing.T) {
+	ds := new(mock.Store)
+	svc := service{ds: ds, packClient: http.DefaultClient}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"queries": {"curl": {"query": "select * from curl;", "interval": 60}}}`))
+	}))
+	defer server.Close()
+
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{
+			QueryDenyList: kolide.QueryDenyList{{Pattern: "from curl"}},
+		}, nil
+	}
+	ds.QueryByNameFunc = func(name string, opts ...kolide.OptionalArg) (*kolide.Query, error) {
+		return nil, &mock.Error{Message: "not found"}
+	}
+	ds.NewQueryFunc = func(query *kolide.Query, opts ...kolide.OptionalArg) (*kolide.Query, error) {
+		return query, nil
+	}
+
+	ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: &kolide.User{ID: 7}})
+	_, err := svc.ImportPackFromURL(ctx, server.URL+"/pack.conf")
+	require.NotNil(t, err)
+	assert.IsType(t, &invalidArgumentError{}, err)
+	assert.False(t, ds.NewQueryFuncInvoked)
+}
+
+func TestGithubRawURL(t *testing.T) {
+	var testCases = []struct {
+		in, out string
+	}{
+		{
+			"https://github.com/osquery/osquery/blob/master/packs/it-compliance.conf",
+			"https://raw.githubusercontent.com/osquery/osquery/master/packs/it-compliance.conf",
+		},
+		{
+			"https://github.com/osquery/osquery/tree/master/packs",
+			"https://github.com/osquery/osquery/tree/master/packs",
+		},
+		{
+			"https://example.com/a/b/blob/c/d.conf",
+			"https://example.com/a/b/blob/c/d.conf",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.in, func(t *testing.T) {
+			u, err := url.Parse(tt.in)
+			require.Nil(t, err)
+			assert.Equal(t, tt.out, githubRawURL(u))
+		})
+	}
+}
+
+func TestClonePack(t *testing.T) {
+	ds := new(mock.Store)
+	svc := service{ds: ds}
+
+	ds.PackFunc = func(id uint) (*kolide.Pack, error) {
+		if id != 1 {
+			return nil, &mock.Error{Message: "not found"}
+		}
+		return &kolide.Pack{ID: 1, Name: "source", Description: "desc", Platform: "darwin"}, nil
+	}
+	ds.PackByNameFunc = func(name string, opts ...kolide.OptionalArg) (*kolide.Pack, bool, error) {
+		if name == "source" {
+			return &kolide.Pack{ID: 1, Name: name}, true, nil
+		}
+		return nil, false, nil
+	}
+	ds.ListLabelsForPackFunc = func(pid uint) ([]*kolide.Label, error) {
+		return []*kolide.Label{{ID: 3}}, nil
+	}
+	ds.ListExplicitHostsInPackFunc = func(pid uint, opt kolide.ListOptions) ([]uint, error) {
+		return []uint{4}, nil
+	}
+	ds.ListScheduledQueriesInPackFunc = func(id uint, opts kolide.ListOptions) ([]*kolide.ScheduledQuery, error) {
+		return []*kolide.ScheduledQuery{
+			{ID: 5, PackID: 1, Name: "time", QueryID: 6, QueryName: "time", Interval: 60, Snapshot: boolPtr(true)},
+		}, nil
+	}
+	var created *kolide.Pack
+	ds.NewPackFunc = func(pack *kolide.Pack, opts ...kolide.OptionalArg) (*kolide.Pack, error) {
+		pack.ID = 2
+		created = pack
+		return pack, nil
+	}
+	type target struct{ id, packID uint }
+	var labels, hosts []target
+	ds.AddLabelToPackFunc = func(lid, pid uint, opts ...kolide.OptionalArg) error {
+		labels = append(labels, target{lid, pid})
+		return nil
+	}
+	ds.AddHostToPackFunc = func(hid, pid uint) error {
+		hosts = append(hosts, target{hid, pid})
+		return nil
+	}
+	var scheduled []*kolide.ScheduledQuery
+	ds.NewScheduledQueryFunc = func(sq *kolide.ScheduledQuery, opts ...kolide.OptionalArg) (*kolide.ScheduledQuery, error) {
+		scheduled = append(scheduled, sq)
+		return sq, nil
+	}
+
+	pack, err := svc.ClonePack(context.Background(), 1, "clone")
+	require.Nil(t, err)
+	assert.Equal(t, uint(2), pack.ID)
+	require.NotNil(t, created)
+	assert.Equal(t, "clone", created.Name)
+	assert.Equal(t, "desc", created.Description)
+	assert.Equal(t, "darwin", created.Platform)
+	assert.True(t, created.Disabled)
+	assert.Equal(t, []target{{3, 2}}, labels)
+	assert.Equal(t, []target{{4, 2}}, hosts)
+	require.Len(t, scheduled, 1)
+	assert.Equal(t, uint(0), scheduled[0].ID)
+	assert.Equal(t, uint(2), scheduled[0].PackID)
+	assert.Equal(t, uint(6), scheduled[0].QueryID)
+	assert.Equal(t, uint(60), scheduled[0].Interval)
+	assert.Equal(t, boolPtr(true), scheduled[0].Snapshot)
+
+	_, err = svc.ClonePack(context.Background(), 1, "source")
+	require.NotNil(t, err)
+	assert.IsType(t, alreadyExistsError{}, err)
+
+	_, err = svc.ClonePack(context.Background(), 1, "")
+	require.NotNil(t, err)
+	assert.IsType(t, &invalidArgumentError{}, err)
+
+	_, err = svc.ClonePack(context.Background(), 9, "other")
+	require.NotNil(t, err)
+	assert.True(t, kolide.IsNotFound(err))
+}
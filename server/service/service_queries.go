@@ -2,6 +2,11 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/kolide/fleet/server/contexts/viewer"
 	"github.com/kolide/fleet/server/kolide"
@@ -32,6 +37,12 @@ func (svc service) ApplyQuerySpecs(ctx context.Context, specs []*kolide.QuerySpe
 
 	queries := []*kolide.Query{}
 	for _, spec := range specs {
+		if err := svc.checkQuerySQL(spec.Query); err != nil {
+			return err
+		}
+		if err := svc.checkQueryDenyList(spec.Query); err != nil {
+			return err
+		}
 		queries = append(queries, queryFromSpec(spec))
 	}
 
@@ -40,7 +51,7 @@ func (svc service) ApplyQuerySpecs(ctx context.Context, specs []*kolide.QuerySpe
 }
 
 func (svc service) GetQuerySpecs(ctx context.Context) ([]*kolide.QuerySpec, error) {
-	queries, err := svc.ds.ListQueries(kolide.ListOptions{})
+	queries, err := svc.ds.ListQueries(kolide.ListQueryOptions{})
 	if err != nil {
 		return nil, errors.Wrap(err, "getting queries")
 	}
@@ -60,7 +71,7 @@ func (svc service) GetQuerySpec(ctx context.Context, name string) (*kolide.Query
 	return specFromQuery(query), nil
 }
 
-func (svc service) ListQueries(ctx context.Context, opt kolide.ListOptions) ([]*kolide.Query, error) {
+func (svc service) ListQueries(ctx context.Context, opt kolide.ListQueryOptions) ([]*kolide.Query, error) {
 	return svc.ds.ListQueries(opt)
 }
 
@@ -80,9 +91,27 @@ func (svc service) NewQuery(ctx context.Context, p kolide.QueryPayload) (*kolide
 	}
 
 	if p.Query != nil {
+		if err := svc.checkQuerySQL(*p.Query); err != nil {
+			return nil, err
+		}
+		if err := svc.checkQueryDenyList(*p.Query); err != nil {
+			return nil, err
+		}
 		query.Query = *p.Query
 	}
 
+	if p.CacheTTL != nil {
+		query.CacheTTL = *p.CacheTTL
+	}
+
+	if p.Tags != nil {
+		tags, err := normalizeQueryTags(*p.Tags)
+		if err != nil {
+			return nil, err
+		}
+		query.Tags = tags
+	}
+
 	vc, ok := viewer.FromContext(ctx)
 	if ok {
 		query.AuthorID = uintPtr(vc.UserID())
@@ -112,9 +141,26 @@ func (svc service) ModifyQuery(ctx context.Context, id uint, p kolide.QueryPaylo
 	}
 
 	if p.Query != nil {
+		if err := svc.checkQuerySQL(*p.Query); err != nil {
+			return nil, err
+		}
+		if err := svc.checkQueryDenyList(*p.Query); err != nil {
+			return nil, err
+		}
 		query.Query = *p.Query
 	}
 
+	if p.CacheTTL != nil {
+		query.CacheTTL = *p.CacheTTL
+	}
+
+	if p.Tags != nil {
+		query.Tags, err = normalizeQueryTags(*p.Tags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	err = svc.ds.SaveQuery(query)
 	if err != nil {
 		return nil, err
@@ -123,6 +169,84 @@ func (svc service) ModifyQuery(ctx context.Context, id uint, p kolide.QueryPaylo
 	return query, nil
 }
 
+func (svc service) ValidateQuerySQL(ctx context.Context, sql string) (bool, []string, error) {
+	syntaxErrors, warnings := checkQuerySQL(sql)
+	problems := append(syntaxErrors, warnings...)
+	return len(syntaxErrors) == 0, problems, nil
+}
+
+// checkQuerySQL returns an invalid argument error listing the syntax errors
+// in sql when queries are validated before they are saved.
+func (svc service) checkQuerySQL(sql string) error {
+	if !svc.config.App.ValidateQuerySQL {
+		return nil
+	}
+	syntaxErrors, _ := checkQuerySQL(sql)
+	if len(syntaxErrors) == 0 {
+		return nil
+	}
+	invalid := &invalidArgumentError{}
+	for _, problem := range syntaxErrors {
+		invalid.Append("query", problem)
+	}
+	return invalid
+}
+
+// checkQueryDenyList returns an invalid argument error if sql matches a
+// pattern of the query deny list.
+func (svc service) checkQueryDenyList(sql string) error {
+	list, err := svc.queryDenyList()
+	if err != nil {
+		return err
+	}
+	pattern, ok := matchQueryDenyList(list, sql)
+	if !ok {
+		return nil
+	}
+	return newInvalidArgumentError("query", denyReason(pattern))
+}
+
+// queryDenyList returns the query deny list of the app config.
+func (svc service) queryDenyList() (kolide.QueryDenyList, error) {
+	config, err := svc.ds.AppConfig()
+	if kolide.IsNotFound(err) {
+		// There is no app config before setup, so nothing is denied.
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "fetching app config for query deny list")
+	}
+	return config.QueryDenyList, nil
+}
+
+// denyReason describes why a query matching pattern is denied.
+func denyReason(pattern kolide.QueryDenyPattern) string {
+	reason := fmt.Sprintf("matches the denied pattern '%s'", pattern.Pattern)
+	if pattern.Reason != "" {
+		reason += ": " + pattern.Reason
+	}
+	return reason
+}
+
+// matchQueryDenyList returns the first pattern of list matching sql.
+// Regular expressions that do not compile are skipped, as they are rejected
+// when the app config is modified.
+func matchQueryDenyList(list kolide.QueryDenyList, sql string) (kolide.QueryDenyPattern, bool) {
+	lower := strings.ToLower(sql)
+	for _, pattern := range list {
+		if !pattern.Regex {
+			if pattern.Pattern != "" && strings.Contains(lower, strings.ToLower(pattern.Pattern)) {
+				return pattern, true
+			}
+			continue
+		}
+		re, err := regexp.Compile(pattern.Pattern)
+		if err == nil && re.MatchString(sql) {
+			return pattern, true
+		}
+	}
+	return kolide.QueryDenyPattern{}, false
+}
+
 func (svc service) DeleteQuery(ctx context.Context, name string) error {
 	return svc.ds.DeleteQuery(name)
 }
@@ -139,3 +263,40 @@ func (svc service) DeleteQueryByID(ctx context.Context, id uint) error {
 func (svc service) DeleteQueries(ctx context.Context, ids []uint) (uint, error) {
 	return svc.ds.DeleteQueries(ids)
 }
+
+func (svc service) RestoreQuery(ctx context.Context, id uint) (*kolide.Query, error) {
+	if err := svc.ds.RestoreQuery(id); err != nil {
+		return nil, errors.Wrap(err, "restore query")
+	}
+
+	return svc.ds.Query(id)
+}
+
+func (svc service) ListQueryTags(ctx context.Context) ([]kolide.QueryTag, error) {
+	return svc.ds.ListQueryTags()
+}
+
+// maxQueryTagLength is the length of the tag column of the query_tags table.
+const maxQueryTagLength = 255
+
+// normalizeQueryTags trims the whitespace around each tag and removes empty
+// and duplicate tags. Tags are compared ignoring case, matching the collation
+// of the MySQL backend. The tags are returned sorted.
+func normalizeQueryTags(tags []string) ([]string, error) {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxQueryTagLength {
+			return nil, newInvalidArgumentError("tags",
+				fmt.Sprintf("tags must be at most %d characters", maxQueryTagLength))
+		}
+		seen[strings.ToLower(tag)] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
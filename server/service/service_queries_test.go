@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/contexts/viewer"
+	"github.com/kolide/fleet/server/datastore/inmem"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/pubsub"
+	"github.com/kolide/fleet/server/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryTags(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+	user := test.NewUser(t, ds, "Zach", "zwass", "zwass@kolide.co", true)
+	ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: user})
+
+	// Tags are trimmed, deduplicated ignoring case and sorted
+	tags := []string{" compliance", "accounts", "Compliance", ""}
+	query, err := svc.NewQuery(ctx, kolide.QueryPayload{
+		Name:  stringPtr("users"),
+		Query: stringPtr("select * from users"),
+		Tags:  &tags,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"accounts", "compliance"}, query.Tags)
+
+	_, err = svc.NewQuery(ctx, kolide.QueryPayload{
+		Name:  stringPtr("time"),
+		Query: stringPtr("select * from time"),
+	})
+	require.Nil(t, err)
+
+	results, err := svc.ListQueries(ctx, kolide.ListQueryOptions{Tags: []string{"compliance"}})
+	require.Nil(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "users", results[0].Name)
+
+	// Modifying other fields keeps the tags
+	query, err = svc.ModifyQuery(ctx, query.ID, kolide.QueryPayload{Description: stringPtr("all users")})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"accounts", "compliance"}, query.Tags)
+
+	tags = []string{"accounts"}
+	query, err = svc.ModifyQuery(ctx, query.ID, kolide.QueryPayload{Tags: &tags})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"accounts"}, query.Tags)
+
+	queryTags, err := svc.ListQueryTags(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, []kolide.QueryTag{{Tag: "accounts", Count: 1}}, queryTags)
+
+	tags = []string{strings.Repeat("a", maxQueryTagLength+1)}
+	_, err = svc.ModifyQuery(ctx, query.ID, kolide.QueryPayload{Tags: &tags})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "tags")
+}
+
+func TestValidateQuerySQL(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+	svc := service{ds: ds, config: config.TestConfig()}
+	user := test.NewUser(t, ds, "Zach", "zwass", "zwass@kolide.co", true)
+	ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: user})
+
+	valid, problems, err := svc.ValidateQuerySQL(ctx, "select * from osquery_info, custom_table")
+	require.Nil(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, []string{"unknown table 'custom_table'"}, problems)
+
+	// Invalid SQL is saved unless validation is enabled
+	query, err := svc.NewQuery(ctx, kolide.QueryPayload{
+		Name:  stringPtr("typo"),
+		Query: stringPtr("select * from (osquery_info"),
+	})
+	require.Nil(t, err)
+
+	svc.config.App.ValidateQuerySQL = true
+	_, err = svc.ModifyQuery(ctx, query.ID, kolide.QueryPayload{Query: stringPtr("select 'foo from time")})
+	require.NotNil(t, err)
+	invalid, ok := err.(*invalidArgumentError)
+	require.True(t, ok)
+	assert.Equal(t, []map[string]string{{"name": "query", "reason": "unterminated string at position 7"}}, invalid.Invalid())
+
+	// Unknown tables are not rejected
+	_, err = svc.ModifyQuery(ctx, query.ID, kolide.QueryPayload{Query: stringPtr("select * from custom_table")})
+	require.Nil(t, err)
+
+	_, err = svc.NewQuery(ctx, kolide.QueryPayload{
+		Name:  stringPtr("typo2"),
+		Query: stringPtr("selct * from time"),
+	})
+	require.NotNil(t, err)
+}
+
+func TestQueryDenyList(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+	svc, err := newTestService(ds, pubsub.NewInmemQueryResults())
+	require.Nil(t, err)
+	user := test.NewUser(t, ds, "Zach", "zwass", "zwass@kolide.co", true)
+	ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: user})
+
+	// Queries are saved before the deny list is set
+	query, err := svc.NewQuery(ctx, kolide.QueryPayload{
+		Name:  stringPtr("files"),
+		Query: stringPtr("select * from process_open_files"),
+	})
+	require.Nil(t, err)
+
+	_, err = ds.NewAppConfig(&kolide.AppConfig{
+		QueryDenyList: kolide.QueryDenyList{
+			{Pattern: "Process_Open_Files", Reason: "too expensive"},
+			{Pattern: `(?i)from\s+hash\b`, Regex: true},
+		},
+	})
+	require.Nil(t, err)
+
+	denied := func(err error, reason string) {
+		require.NotNil(t, err)
+		invalid, ok := err.(*invalidArgumentError)
+		require.True(t, ok)
+		assert.Equal(t, []map[string]string{{"name": "query", "reason": reason}}, invalid.Invalid())
+	}
+
+	_, err = svc.NewQuery(ctx, kolide.QueryPayload{
+		Name:  stringPtr("hashes"),
+		Query: stringPtr("SELECT * FROM  hash WHERE path = '/etc/passwd'"),
+	})
+	denied(err, `matches the denied pattern '(?i)from\s+hash\b'`)
+
+	_, err = svc.ModifyQuery(ctx, query.ID, kolide.QueryPayload{Query: stringPtr("select pid from process_open_files")})
+	denied(err, "matches the denied pattern 'Process_Open_Files': too expensive")
+
+	_, err = svc.NewDistributedQueryCampaign(ctx, "select * from process_open_files", []uint{1}, nil, 0, false)
+	denied(err, "matches the denied pattern 'Process_Open_Files': too expensive")
+
+	// Saved queries matching patterns added later cannot be run
+	_, err = svc.NewSavedQueryCampaign(ctx, query.ID, []uint{1}, nil, 0, false)
+	denied(err, "matches the denied pattern 'Process_Open_Files': too expensive")
+
+	_, err = svc.NewQuery(ctx, kolide.QueryPayload{
+		Name:  stringPtr("hashes"),
+		Query: stringPtr("select * from hashes"),
+	})
+	assert.Nil(t, err)
+
+	_, err = svc.ModifyQuery(ctx, query.ID, kolide.QueryPayload{Query: stringPtr("select * from processes")})
+	assert.Nil(t, err)
+}
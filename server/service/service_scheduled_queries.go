@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/pkg/errors"
@@ -16,16 +18,29 @@ func (svc service) GetScheduledQuery(ctx context.Context, id uint) (*kolide.Sche
 }
 
 func (svc service) ScheduleQuery(ctx context.Context, sq *kolide.ScheduledQuery) (*kolide.ScheduledQuery, error) {
+	if err := validateSampleRate(sq.SampleRate); err != nil {
+		return nil, err
+	}
+	if sq.ColumnMapping != nil && len(*sq.ColumnMapping) == 0 {
+		sq.ColumnMapping = nil
+	}
+	query, err := svc.ds.Query(sq.QueryID)
+	if err != nil {
+		return nil, errors.Wrap(err, "lookup name for query")
+	}
+	// Queries saved before they were denied must not be scheduled
+	if err := svc.checkQueryDenyList(query.Query); err != nil {
+		return nil, err
+	}
 	// Fill in the name with query name if it is unset (because the UI
 	// doesn't provide a way to set it)
 	if sq.Name == "" {
-		query, err := svc.ds.Query(sq.QueryID)
-		if err != nil {
-			return nil, errors.Wrap(err, "lookup name for query")
-		}
 		sq.Name = query.Name
 		sq.QueryName = query.Name
 	}
+	if err := validateColumnMapping(sq.ColumnMapping, query.Query); err != nil {
+		return nil, err
+	}
 	return svc.ds.NewScheduledQuery(sq)
 }
 
@@ -72,9 +87,130 @@ func (svc service) ModifyScheduledQuery(ctx context.Context, id uint, p kolide.S
 		}
 	}
 
+	if p.Disabled != nil {
+		sq.Disabled = *p.Disabled
+	}
+
+	if p.MaxResultRows != nil {
+		if p.MaxResultRows.Valid {
+			val := uint(p.MaxResultRows.Int64)
+			sq.MaxResultRows = &val
+		} else {
+			sq.MaxResultRows = nil
+		}
+	}
+
+	if p.SampleRate != nil {
+		if p.SampleRate.Valid {
+			val := p.SampleRate.Float64
+			sq.SampleRate = &val
+		} else {
+			sq.SampleRate = nil
+		}
+		if err := validateSampleRate(sq.SampleRate); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.ColumnMapping != nil {
+		sq.ColumnMapping = p.ColumnMapping
+		if len(*sq.ColumnMapping) == 0 {
+			sq.ColumnMapping = nil
+		}
+	}
+
+	if p.QueryID != nil || (sq.ColumnMapping != nil && p.ColumnMapping != nil) {
+		query, err := svc.ds.Query(sq.QueryID)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting query to validate column mapping")
+		}
+		if p.QueryID != nil {
+			if err := svc.checkQueryDenyList(query.Query); err != nil {
+				return nil, err
+			}
+		}
+		if err := validateColumnMapping(sq.ColumnMapping, query.Query); err != nil {
+			return nil, err
+		}
+	}
+
 	return svc.ds.SaveScheduledQuery(sq)
 }
 
+func (svc service) ModifyScheduledQueriesInPack(ctx context.Context, packID uint, patch kolide.ScheduledQueryPatch) (int, error) {
+	invalid := &invalidArgumentError{}
+	if patch.Interval != nil && *patch.Interval == 0 {
+		invalid.Append("interval", "must be positive")
+	}
+	if patch.IntervalMultiplier != nil && *patch.IntervalMultiplier == 0 {
+		invalid.Append("interval_multiplier", "must be positive")
+	}
+	if patch.Interval != nil && patch.IntervalMultiplier != nil {
+		invalid.Append("interval_multiplier", "cannot be combined with interval")
+	}
+	if patch.Interval == nil && patch.IntervalMultiplier == nil && patch.Snapshot == nil && patch.Removed == nil {
+		invalid.Append("patch", "must change at least one field")
+	}
+	if invalid.HasErrors() {
+		return 0, invalid
+	}
+
+	if _, err := svc.ds.Pack(packID); err != nil {
+		return 0, err
+	}
+	return svc.ds.ModifyScheduledQueriesInPack(packID, patch)
+}
+
+// validateSampleRate returns an error if the sample rate of a scheduled query
+// is set and not between 0 and 1.
+func validateSampleRate(rate *float64) error {
+	if rate != nil && !(*rate >= 0 && *rate <= 1) {
+		return newInvalidArgumentError("sample_rate", "must be between 0 and 1")
+	}
+	return nil
+}
+
+// validateColumnMapping returns an error if the column mapping of a scheduled
+// query keeps a column twice, logs two columns with the same name, or keeps a
+// column that the query does not return. The columns returned by the query
+// are only checked when its select list names every column.
+func validateColumnMapping(mapping *kolide.ColumnMapping, sql string) error {
+	if mapping == nil {
+		return nil
+	}
+	columns, known := selectColumnNames(sql)
+	returned := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		returned[c] = true
+	}
+
+	kept := map[string]bool{}
+	logged := map[string]bool{}
+	for i, m := range *mapping {
+		switch {
+		case m.Column == "":
+			return newInvalidArgumentError("column_mapping", fmt.Sprintf("entry %d is missing the column", i+1))
+		case kept[m.Column]:
+			return newInvalidArgumentError("column_mapping", fmt.Sprintf("column '%s' is mapped more than once", m.Column))
+		case logged[m.Name()]:
+			return newInvalidArgumentError("column_mapping", fmt.Sprintf("more than one column is logged as '%s'", m.Name()))
+		case known && !returned[m.Column]:
+			return newInvalidArgumentError("column_mapping",
+				fmt.Sprintf("query does not return column '%s', it returns %s", m.Column, strings.Join(columns, ", ")))
+		}
+		kept[m.Column] = true
+		logged[m.Name()] = true
+	}
+	return nil
+}
+
 func (svc service) DeleteScheduledQuery(ctx context.Context, id uint) error {
 	return svc.ds.DeleteScheduledQuery(id)
 }
+
+func (svc service) ScheduledQueryStats(ctx context.Context, packID uint) ([]*kolide.AggregatedScheduledQueryStats, error) {
+	if _, err := svc.ds.Pack(packID); err != nil {
+		return nil, err
+	}
+	return svc.ds.ScheduledQueryStats(packID)
+}
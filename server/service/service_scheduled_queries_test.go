@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/mock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestModifyScheduledQueryDisabled(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	sq := &kolide.ScheduledQuery{ID: 1, Interval: 60}
+	ds.ScheduledQueryFunc = func(id uint) (*kolide.ScheduledQuery, error) {
+		return sq, nil
+	}
+	ds.SaveScheduledQueryFunc = func(sq *kolide.ScheduledQuery) (*kolide.ScheduledQuery, error) {
+		return sq, nil
+	}
+
+	got, err := svc.ModifyScheduledQuery(context.Background(), 1, kolide.ScheduledQueryPayload{Disabled: boolPtr(true)})
+	require.Nil(t, err)
+	assert.True(t, got.Disabled)
+	assert.Equal(t, uint(60), got.Interval)
+
+	// Omitting disabled leaves it unchanged
+	interval := uint(30)
+	got, err = svc.ModifyScheduledQuery(context.Background(), 1, kolide.ScheduledQueryPayload{Interval: &interval})
+	require.Nil(t, err)
+	assert.True(t, got.Disabled)
+
+	got, err = svc.ModifyScheduledQuery(context.Background(), 1, kolide.ScheduledQueryPayload{Disabled: boolPtr(false)})
+	require.Nil(t, err)
+	assert.False(t, got.Disabled)
+}
+
+func TestModifyScheduledQueryMaxResultRows(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	sq := &kolide.ScheduledQuery{ID: 1, Interval: 60}
+	ds.ScheduledQueryFunc = func(id uint) (*kolide.ScheduledQuery, error) {
+		return sq, nil
+	}
+	ds.SaveScheduledQueryFunc = func(sq *kolide.ScheduledQuery) (*kolide.ScheduledQuery, error) {
+		return sq, nil
+	}
+
+	maxRows := null.IntFrom(100)
+	got, err := svc.ModifyScheduledQuery(context.Background(), 1, kolide.ScheduledQueryPayload{MaxResultRows: &maxRows})
+	require.Nil(t, err)
+	require.NotNil(t, got.MaxResultRows)
+	assert.Equal(t, uint(100), *got.MaxResultRows)
+
+	// Null clears the override, so that the default applies
+	got, err = svc.ModifyScheduledQuery(context.Background(), 1, kolide.ScheduledQueryPayload{MaxResultRows: &null.Int{}})
+	require.Nil(t, err)
+	assert.Nil(t, got.MaxResultRows)
+}
+
+func TestScheduledQueryStats(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ds.PackFunc = func(id uint) (*kolide.Pack, error) {
+		if id != 1 {
+			return nil, &mock.Error{Message: "not found"}
+		}
+		return &kolide.Pack{ID: 1}, nil
+	}
+	ds.ScheduledQueryStatsFunc = func(packID uint) ([]*kolide.AggregatedScheduledQueryStats, error) {
+		return []*kolide.AggregatedScheduledQueryStats{{ScheduledQueryID: 2, Hosts: 3, AverageWallTime: 12.5}}, nil
+	}
+
+	stats, err := svc.ScheduledQueryStats(context.Background(), 1)
+	require.Nil(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, 12.5, stats[0].AverageWallTime)
+
+	// Unknown packs are not found
+	ds.ScheduledQueryStatsFuncInvoked = false
+	_, err = svc.ScheduledQueryStats(context.Background(), 2)
+	require.NotNil(t, err)
+	assert.False(t, ds.ScheduledQueryStatsFuncInvoked)
+}
+
+func TestModifyScheduledQueriesInPack(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	ds.PackFunc = func(id uint) (*kolide.Pack, error) {
+		if id != 1 {
+			return nil, errors.New("not found")
+		}
+		return &kolide.Pack{ID: 1}, nil
+	}
+	var gotPatch kolide.ScheduledQueryPatch
+	ds.ModifyScheduledQueriesInPackFunc = func(packID uint, patch kolide.ScheduledQueryPatch) (int, error) {
+		gotPatch = patch
+		return 3, nil
+	}
+
+	multiplier := uint(2)
+	modified, err := svc.ModifyScheduledQueriesInPack(context.Background(), 1, kolide.ScheduledQueryPatch{IntervalMultiplier: &multiplier})
+	require.Nil(t, err)
+	assert.Equal(t, 3, modified)
+	require.NotNil(t, gotPatch.IntervalMultiplier)
+	assert.Equal(t, uint(2), *gotPatch.IntervalMultiplier)
+
+	_, err = svc.ModifyScheduledQueriesInPack(context.Background(), 2, kolide.ScheduledQueryPatch{Removed: boolPtr(true)})
+	require.NotNil(t, err)
+
+	ds.ModifyScheduledQueriesInPackFuncInvoked = false
+	zero := uint(0)
+	var testCases = []struct {
+		patch   kolide.ScheduledQueryPatch
+		invalid string
+	}{
+		{kolide.ScheduledQueryPatch{Interval: &zero}, "interval"},
+		{kolide.ScheduledQueryPatch{IntervalMultiplier: &zero}, "interval_multiplier"},
+		{kolide.ScheduledQueryPatch{Interval: &multiplier, IntervalMultiplier: &multiplier}, "interval_multiplier"},
+		{kolide.ScheduledQueryPatch{}, "patch"},
+	}
+	for _, tt := range testCases {
+		_, err := svc.ModifyScheduledQueriesInPack(context.Background(), 1, tt.patch)
+		require.NotNil(t, err)
+		invalid, ok := err.(*invalidArgumentError)
+		require.True(t, ok)
+		assert.Equal(t, tt.invalid, invalid.Invalid()[0]["name"])
+	}
+	assert.False(t, ds.ModifyScheduledQueriesInPackFuncInvoked)
+}
+
+func TestScheduledQuerySampleRate(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	sq := &kolide.ScheduledQuery{ID: 1, Name: "foo", Interval: 60}
+	ds.ScheduledQueryFunc = func(id uint) (*kolide.ScheduledQuery, error) {
+		return sq, nil
+	}
+	ds.SaveScheduledQueryFunc = func(sq *kolide.ScheduledQuery) (*kolide.ScheduledQuery, error) {
+		return sq, nil
+	}
+	ds.NewScheduledQueryFunc = func(sq *kolide.ScheduledQuery, opts ...kolide.OptionalArg) (*kolide.ScheduledQuery, error) {
+		return sq, nil
+	}
+
+	rate := null.FloatFrom(0.25)
+	got, err := svc.ModifyScheduledQuery(context.Background(), 1, kolide.ScheduledQueryPayload{SampleRate: &rate})
+	require.Nil(t, err)
+	require.NotNil(t, got.SampleRate)
+	assert.Equal(t, 0.25, *got.SampleRate)
+
+	// Null clears the sample rate, so that all rows are kept
+	clear := null.NewFloat(0, false)
+	got, err = svc.ModifyScheduledQuery(context.Background(), 1, kolide.ScheduledQueryPayload{SampleRate: &clear})
+	require.Nil(t, err)
+	assert.Nil(t, got.SampleRate)
+
+	rate = null.FloatFrom(1.5)
+	_, err = svc.ModifyScheduledQuery(context.Background(), 1, kolide.ScheduledQueryPayload{SampleRate: &rate})
+	require.NotNil(t, err)
+
+	negative := -0.1
+	_, err = svc.ScheduleQuery(context.Background(), &kolide.ScheduledQuery{Name: "bar", SampleRate: &negative})
+	require.NotNil(t, err)
+	assert.False(t, ds.NewScheduledQueryFuncInvoked)
+}
+
+func TestScheduledQueryColumnMapping(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	queries := map[uint]*kolide.Query{
+		1: {ID: 1, Name: "info", Query: "select hostname, uuid as host_uuid from system_info"},
+		2: {ID: 2, Name: "all", Query: "select * from system_info"},
+	}
+	ds.QueryFunc = func(id uint) (*kolide.Query, error) {
+		return queries[id], nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{}, nil
+	}
+	sq := &kolide.ScheduledQuery{ID: 1, Name: "foo", QueryID: 1, Interval: 60}
+	ds.ScheduledQueryFunc = func(id uint) (*kolide.ScheduledQuery, error) {
+		return sq, nil
+	}
+	ds.SaveScheduledQueryFunc = func(sq *kolide.ScheduledQuery) (*kolide.ScheduledQuery, error) {
+		return sq, nil
+	}
+	ds.NewScheduledQueryFunc = func(sq *kolide.ScheduledQuery, opts ...kolide.OptionalArg) (*kolide.ScheduledQuery, error) {
+		return sq, nil
+	}
+
+	mapping := kolide.ColumnMapping{{Column: "host_uuid", As: "id"}, {Column: "hostname"}}
+	got, err := svc.ModifyScheduledQuery(context.Background(), 1, kolide.ScheduledQueryPayload{ColumnMapping: &mapping})
+	require.Nil(t, err)
+	require.NotNil(t, got.ColumnMapping)
+	assert.Equal(t, mapping, *got.ColumnMapping)
+
+	// Changing the query validates the mapping against the new query,
+	// whose columns are not known
+	queryID := uint(2)
+	_, err = svc.ModifyScheduledQuery(context.Background(), 1, kolide.ScheduledQueryPayload{QueryID: &queryID})
+	require.Nil(t, err)
+	queryID = 1
+	_, err = svc.ModifyScheduledQuery(context.Background(), 1, kolide.ScheduledQueryPayload{QueryID: &queryID})
+	require.Nil(t, err)
+
+	// An empty list clears the mapping
+	got, err = svc.ModifyScheduledQuery(context.Background(), 1, kolide.ScheduledQueryPayload{ColumnMapping: &kolide.ColumnMapping{}})
+	require.Nil(t, err)
+	assert.Nil(t, got.ColumnMapping)
+
+	for _, invalid := range []kolide.ColumnMapping{
+		{{Column: "uuid"}},
+		{{Column: ""}},
+		{{Column: "hostname"}, {Column: "hostname", As: "name"}},
+		{{Column: "hostname", As: "id"}, {Column: "host_uuid", As: "id"}},
+	} {
+		invalid := invalid
+		_, err = svc.ModifyScheduledQuery(context.Background(), 1, kolide.ScheduledQueryPayload{ColumnMapping: &invalid})
+		assert.NotNil(t, err, "%v", invalid)
+	}
+
+	unknown := kolide.ColumnMapping{{Column: "uuid"}}
+	_, err = svc.ScheduleQuery(context.Background(), &kolide.ScheduledQuery{Name: "bar", QueryID: 1, ColumnMapping: &unknown})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "query does not return column 'uuid', it returns hostname, host_uuid")
+	assert.False(t, ds.NewScheduledQueryFuncInvoked)
+
+	got, err = svc.ScheduleQuery(context.Background(), &kolide.ScheduledQuery{Name: "bar", QueryID: 2, ColumnMapping: &unknown})
+	require.Nil(t, err)
+	assert.Equal(t, &unknown, got.ColumnMapping)
+}
+
+func TestScheduleQueryDenyList(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	queries := map[uint]*kolide.Query{
+		1: {ID: 1, Name: "time", Query: "select * from time"},
+		2: {ID: 2, Name: "curl", Query: "select * from curl where url = 'http://example.com'"},
+	}
+	ds.QueryFunc = func(id uint) (*kolide.Query, error) {
+		return queries[id], nil
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return &kolide.AppConfig{
+			QueryDenyList: kolide.QueryDenyList{{Pattern: "from curl", Reason: "no network requests"}},
+		}, nil
+	}
+	ds.ScheduledQueryFunc = func(id uint) (*kolide.ScheduledQuery, error) {
+		return &kolide.ScheduledQuery{ID: id, Name: "time", QueryID: 1, Interval: 60}, nil
+	}
+	ds.SaveScheduledQueryFunc = func(sq *kolide.ScheduledQuery) (*kolide.ScheduledQuery, error) {
+		return sq, nil
+	}
+	ds.NewScheduledQueryFunc = func(sq *kolide.ScheduledQuery, opts ...kolide.OptionalArg) (*kolide.ScheduledQuery, error) {
+		return sq, nil
+	}
+
+	// Queries saved before the pattern was denied cannot be scheduled
+	_, err = svc.ScheduleQuery(context.Background(), &kolide.ScheduledQuery{QueryID: 2, Interval: 60})
+	require.NotNil(t, err)
+	assert.IsType(t, &invalidArgumentError{}, err)
+	assert.Contains(t, err.Error(), "no network requests")
+	assert.False(t, ds.NewScheduledQueryFuncInvoked)
+
+	queryID := uint(2)
+	_, err = svc.ModifyScheduledQuery(context.Background(), 1, kolide.ScheduledQueryPayload{QueryID: &queryID})
+	require.NotNil(t, err)
+	assert.IsType(t, &invalidArgumentError{}, err)
+	assert.False(t, ds.SaveScheduledQueryFuncInvoked)
+
+	got, err := svc.ScheduleQuery(context.Background(), &kolide.ScheduledQuery{QueryID: 1, Interval: 60})
+	require.Nil(t, err)
+	assert.Equal(t, "time", got.Name)
+}
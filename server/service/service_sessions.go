@@ -4,13 +4,19 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"html/template"
+	"net"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/kolide/fleet/server/contexts/sourceip"
 	"github.com/kolide/fleet/server/contexts/viewer"
 	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/mail"
 	"github.com/kolide/fleet/server/sso"
 	"github.com/pkg/errors"
 )
@@ -42,21 +48,14 @@ func (svc service) InitiateSSO(ctx context.Context, redirectURL string) (string,
 	settings := sso.Settings{
 		Metadata: metadata,
 		// Construct call back url to send to idp
-		AssertionConsumerServiceURL: appConfig.KolideServerURL + svc.config.Server.URLPrefix + "/api/v1/kolide/sso/callback",
+		AssertionConsumerServiceURL: svc.ssoCallbackURL(appConfig),
 		SessionStore:                svc.ssoSessionStore,
 		OriginalURL:                 redirectURL,
 	}
 
-	// If issuer is not explicitly set, default to host name.
-	var issuer string
-	if appConfig.EntityID == "" {
-		u, err := url.Parse(appConfig.KolideServerURL)
-		if err != nil {
-			return "", errors.Wrap(err, "parsing kolide server url")
-		}
-		issuer = u.Hostname()
-	} else {
-		issuer = appConfig.EntityID
+	issuer, err := ssoIssuer(appConfig)
+	if err != nil {
+		return "", err
 	}
 	idpURL, err := sso.CreateAuthorizationRequest(&settings, issuer)
 	if err != nil {
@@ -66,6 +65,49 @@ func (svc service) InitiateSSO(ctx context.Context, redirectURL string) (string,
 	return idpURL, nil
 }
 
+// ssoCallbackURL returns the assertion consumer service URL that the IDP
+// posts responses to.
+func (svc service) ssoCallbackURL(config *kolide.AppConfig) string {
+	return config.KolideServerURL + svc.config.Server.URLPrefix + "/api/v1/kolide/sso/callback"
+}
+
+// ssoIssuer returns the entity ID that identifies Fleet to the IDP. If it is
+// not explicitly set, it defaults to the host name of the server URL.
+func ssoIssuer(config *kolide.AppConfig) (string, error) {
+	if config.EntityID != "" {
+		return config.EntityID, nil
+	}
+	u, err := url.Parse(config.KolideServerURL)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing kolide server url")
+	}
+	return u.Hostname(), nil
+}
+
+func (svc service) GenerateSAMLMetadata(ctx context.Context) ([]byte, error) {
+	appConfig, err := svc.ds.AppConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "GenerateSAMLMetadata getting app config")
+	}
+
+	if !appConfig.EnableSSO {
+		return nil, errors.New("SSO is not configured, enable SSO in the app config to generate metadata")
+	}
+	if appConfig.KolideServerURL == "" {
+		return nil, errors.New("the Kolide server URL must be set in the app config to generate SSO metadata")
+	}
+
+	issuer, err := ssoIssuer(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := sso.GenerateSPMetadata(issuer, svc.ssoCallbackURL(appConfig))
+	if err != nil {
+		return nil, errors.Wrap(err, "GenerateSAMLMetadata generating metadata")
+	}
+	return metadata, nil
+}
+
 func (svc service) getMetadata(config *kolide.AppConfig) (*sso.Metadata, error) {
 	if config.MetadataURL != "" {
 		metadata, err := sso.GetMetadata(config.MetadataURL, svc.metaDataClient)
@@ -137,9 +179,21 @@ func (svc service) Login(ctx context.Context, username, password string) (*kolid
 		const errMessage = "password login not allowed for single sign on users"
 		return nil, "", authError{reason: errMessage, clientReason: errMessage}
 	}
+	if user.Locked(svc.clock.Now()) {
+		return nil, "", authError{
+			reason:       "account locked",
+			clientReason: "account locked after repeated failed logins, try again later",
+		}
+	}
 	if err = user.ValidatePassword(password); err != nil {
+		svc.recordFailedLogin(ctx, user)
 		return nil, "", authError{reason: "bad password"}
 	}
+	if user.FailedLoginCount > 0 {
+		if err := svc.ds.ResetFailedLogins(user.ID); err != nil {
+			return nil, "", errors.Wrap(err, "resetting failed logins")
+		}
+	}
 	token, err := svc.makeSession(user.ID)
 	if err != nil {
 		return nil, "", err
@@ -148,6 +202,79 @@ func (svc service) Login(ctx context.Context, username, password string) (*kolid
 	return user, token, nil
 }
 
+// recordFailedLogin counts a failed password login of user, and locks the
+// account once the count reaches the configured threshold. Errors are logged
+// rather than returned, so that the login still fails as a bad password.
+func (svc service) recordFailedLogin(ctx context.Context, user *kolide.User) {
+	threshold := svc.config.Auth.LockoutThreshold
+	if threshold <= 0 {
+		return
+	}
+	logger := contextLogger(ctx, svc.logger)
+	count, err := svc.ds.IncrementFailedLogins(user.ID)
+	if err != nil {
+		logger.Log("err", err, "msg", "failed to record failed login", "user", user.Username)
+		return
+	}
+	if count < uint(threshold) {
+		return
+	}
+
+	lockedUntil := svc.clock.Now().Add(svc.config.Auth.LockoutDuration).UTC()
+	if err := svc.ds.LockUser(user.ID, lockedUntil); err != nil {
+		logger.Log("err", err, "msg", "failed to lock user", "user", user.Username)
+		return
+	}
+	sourceIP := requestSourceIP(ctx)
+	logger.Log("msg", "locked user after failed logins", "user", user.Username,
+		"source_ip", sourceIP, "locked_until", lockedUntil)
+
+	if err := svc.sendLockoutEmail(user, sourceIP, lockedUntil); err != nil {
+		logger.Log("err", err, "msg", "failed to send lockout email", "user", user.Username)
+	}
+}
+
+// sendLockoutEmail notifies user, and the configured security address if any,
+// that the account of user is locked.
+func (svc service) sendLockoutEmail(user *kolide.User, sourceIP string, lockedUntil time.Time) error {
+	config, err := svc.ds.AppConfig()
+	if err != nil {
+		return errors.Wrap(err, "getting app config")
+	}
+	to := []string{user.Email}
+	if addr := svc.config.Auth.LockoutNotifyAddress; addr != "" {
+		to = append(to, addr)
+	}
+	lockedEmail := kolide.Email{
+		Subject: "Fleet Account Locked",
+		To:      to,
+		Config:  config,
+		Mailer: &mail.AccountLockedMailer{
+			BaseURL:     template.URL(config.KolideServerURL + svc.config.Server.URLPrefix),
+			AssetURL:    getAssetURL(),
+			Username:    user.Username,
+			SourceIP:    sourceIP,
+			LockedUntil: lockedUntil.Format(time.RFC1123),
+			OrgName:     config.OrgName,
+		},
+	}
+	return svc.mailService.SendEmail(lockedEmail)
+}
+
+// requestSourceIP returns the address of the client that made the request,
+// as set in the context by setSourceIP, or the remote address of the
+// connection otherwise.
+func requestSourceIP(ctx context.Context) string {
+	if addr, ok := sourceip.FromContext(ctx); ok {
+		return addr
+	}
+	remoteAddr, _ := ctx.Value(kithttp.ContextKeyRequestRemoteAddr).(string)
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
 func (svc service) userByEmailOrUsername(username string) (*kolide.User, error) {
 	if strings.Contains(username, "@") {
 		return svc.ds.UserByEmail(username)
@@ -164,10 +291,12 @@ func (svc service) makeSession(id uint) (string, error) {
 		return "", err
 	}
 
+	now := svc.clock.Now().UTC()
 	session := &kolide.Session{
-		UserID:     id,
-		Key:        base64.StdEncoding.EncodeToString(key),
-		AccessedAt: time.Now().UTC(),
+		CreateTimestamp: kolide.CreateTimestamp{CreatedAt: now},
+		UserID:          id,
+		Key:             base64.StdEncoding.EncodeToString(key),
+		AccessedAt:      now,
 	}
 
 	session, err = svc.ds.NewSession(session)
@@ -263,26 +392,144 @@ func (svc service) validateSession(session *kolide.Session) error {
 	if session == nil {
 		return authError{
 			reason:       "active session not present",
-			clientReason: "session error",
+			clientReason: "invalid session",
 		}
 	}
 
-	sessionDuration := svc.config.Session.Duration
-	// duration 0 = unlimited
-	if sessionDuration != 0 && time.Since(session.AccessedAt) >= sessionDuration {
+	duration, idleTimeout, err := svc.cachedSessionLifetime()
+	if err != nil {
+		return err
+	}
+	now := svc.clock.Now()
+	// a zero duration or timeout applies no bound
+	if (duration != 0 && now.Sub(session.CreatedAt) >= duration) ||
+		(idleTimeout != 0 && now.Sub(session.AccessedAt) >= idleTimeout) {
 		err := svc.ds.DestroySession(session)
 		if err != nil {
 			return errors.Wrap(err, "destroying session")
 		}
 		return authError{
 			reason:       "expired session",
-			clientReason: "session error",
+			clientReason: "session expired",
 		}
 	}
 
 	return svc.ds.MarkSessionAccessed(session)
 }
 
+// sessionLifetime returns the absolute duration and the idle timeout of
+// sessions. The idle timeout falls back to the session.duration server
+// option when the app config does not set one.
+func (svc service) sessionLifetime(config *kolide.AppConfig) (duration, idleTimeout time.Duration) {
+	duration = time.Duration(config.SessionDuration) * time.Minute
+	idleTimeout = time.Duration(config.SessionIdleTimeout) * time.Minute
+	if idleTimeout == 0 {
+		idleTimeout = svc.config.Session.Duration
+	}
+	return duration, idleTimeout
+}
+
+// sessionLifetimeTTL is how long the session lifetime read from the app
+// config is cached before validateSession reads it again.
+const sessionLifetimeTTL = time.Minute
+
+// sessionLifetimeCache caches the session lifetime so that validating a
+// session does not read the app config on every request.
+type sessionLifetimeCache struct {
+	mtx         sync.Mutex
+	duration    time.Duration
+	idleTimeout time.Duration
+	expires     time.Time
+}
+
+func (c *sessionLifetimeCache) set(duration, idleTimeout time.Duration, expires time.Time) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.duration, c.idleTimeout, c.expires = duration, idleTimeout, expires
+}
+
+func (c *sessionLifetimeCache) get(now time.Time) (duration, idleTimeout time.Duration, ok bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.duration, c.idleTimeout, now.Before(c.expires)
+}
+
+// invalidate makes the next session validation read the app config.
+func (c *sessionLifetimeCache) invalidate() {
+	c.set(0, 0, time.Time{})
+}
+
+// cachedSessionLifetime returns the session lifetime, reading the app config
+// only when the cached lifetime has expired.
+func (svc service) cachedSessionLifetime() (duration, idleTimeout time.Duration, err error) {
+	now := svc.clock.Now()
+	if svc.sessionTimeouts != nil {
+		if duration, idleTimeout, ok := svc.sessionTimeouts.get(now); ok {
+			return duration, idleTimeout, nil
+		}
+	}
+	config, err := svc.ds.AppConfig()
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "getting app config")
+	}
+	duration, idleTimeout = svc.sessionLifetime(config)
+	if svc.sessionTimeouts != nil {
+		svc.sessionTimeouts.set(duration, idleTimeout, now.Add(sessionLifetimeTTL))
+	}
+	return duration, idleTimeout, nil
+}
+
+// sessionReapInterval is how often expired sessions are destroyed.
+const sessionReapInterval = 15 * time.Minute
+
+func (svc service) ReapSessions(ctx context.Context) {
+	ticker := svc.clock.NewTicker(sessionReapInterval)
+	defer ticker.Stop()
+
+	for {
+		svc.reapSessions()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+		}
+	}
+}
+
+// reapSessions destroys the sessions that would be rejected as expired by
+// validateSession, so that abandoned sessions do not accumulate.
+func (svc service) reapSessions() {
+	config, err := svc.ds.AppConfig()
+	if err != nil {
+		svc.logger.Log("msg", "error getting app config to reap sessions", "err", err)
+		return
+	}
+	duration, idleTimeout := svc.sessionLifetime(config)
+	now := svc.clock.Now()
+	if svc.sessionTimeouts != nil {
+		svc.sessionTimeouts.set(duration, idleTimeout, now.Add(sessionLifetimeTTL))
+	}
+	if duration == 0 && idleTimeout == 0 {
+		return
+	}
+
+	var createdBefore, accessedBefore time.Time
+	if duration != 0 {
+		createdBefore = now.Add(-duration)
+	}
+	if idleTimeout != 0 {
+		accessedBefore = now.Add(-idleTimeout)
+	}
+	destroyed, err := svc.ds.DestroyExpiredSessions(createdBefore, accessedBefore)
+	if err != nil {
+		svc.logger.Log("msg", "error destroying expired sessions", "err", err)
+		return
+	}
+	if destroyed > 0 {
+		svc.logger.Log("msg", "destroyed expired sessions", "count", destroyed)
+	}
+}
+
 // Given a session key create a JWT to be delivered to the client
 func generateJWT(sessionKey, jwtKey string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
@@ -5,10 +5,18 @@ import (
 	"testing"
 	"time"
 
+	"github.com/WatchBeam/clock"
+	kitlog "github.com/go-kit/kit/log"
+	kithttp "github.com/go-kit/kit/transport/http"
+
 	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/contexts/sourceip"
 	"github.com/kolide/fleet/server/contexts/token"
+	"github.com/kolide/fleet/server/contexts/viewer"
 	"github.com/kolide/fleet/server/datastore/inmem"
 	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/mail"
+	"github.com/kolide/fleet/server/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -20,6 +28,7 @@ func TestAuthenticate(t *testing.T) {
 	require.Nil(t, err)
 	svc, err := newTestService(ds, nil)
 	require.Nil(t, err)
+	createTestAppConfig(t, ds)
 	users := createTestUsers(t, ds)
 
 	var loginTests = []struct {
@@ -60,6 +69,82 @@ func TestAuthenticate(t *testing.T) {
 	}
 }
 
+func TestLoginLockout(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+	createTestAppConfig(t, ds)
+	users := createTestUsers(t, ds)
+
+	var sent []kolide.Email
+	mailer := &mockMailService{SendEmailFn: func(e kolide.Email) error {
+		sent = append(sent, e)
+		return nil
+	}}
+	mockClock := clock.NewMockClock()
+	conf := config.TestConfig()
+	conf.Auth.LockoutThreshold = 3
+	conf.Auth.LockoutNotifyAddress = "security@example.com"
+	svc, err := NewService(ds, nil, kitlog.NewNopLogger(), conf, mailer, mockClock, nil)
+	require.Nil(t, err)
+
+	ctx := context.WithValue(context.Background(), kithttp.ContextKeyRequestRemoteAddr, "10.0.0.1:52000")
+	// The notification does not include the address claimed by the client
+	ctx = context.WithValue(ctx, kithttp.ContextKeyRequestXForwardedFor, "203.0.113.7")
+	const password = "foobarbaz1234!"
+
+	for i := 0; i < 2; i++ {
+		_, _, err = svc.Login(ctx, "user1", "wrong")
+		require.NotNil(t, err)
+		assert.Equal(t, "bad password", err.Error())
+	}
+	assert.False(t, mailer.Invoked)
+
+	// A successful login resets the count
+	_, _, err = svc.Login(ctx, "user1", password)
+	require.Nil(t, err)
+	user, err := ds.UserByID(users["user1"].ID)
+	require.Nil(t, err)
+	assert.Zero(t, user.FailedLoginCount)
+
+	for i := 0; i < 3; i++ {
+		_, _, err = svc.Login(ctx, "user1", "wrong")
+		require.NotNil(t, err)
+	}
+	require.Len(t, sent, 1)
+	assert.Equal(t, []string{"user1@example.com", "security@example.com"}, sent[0].To)
+	lockedMailer, ok := sent[0].Mailer.(*mail.AccountLockedMailer)
+	require.True(t, ok)
+	assert.Equal(t, "10.0.0.1", lockedMailer.SourceIP)
+	assert.Equal(t, "user1", lockedMailer.Username)
+
+	// The correct password is rejected during the lockout
+	_, _, err = svc.Login(ctx, "user1", password)
+	require.NotNil(t, err)
+	assert.Equal(t, "account locked", err.Error())
+
+	// Other users are unaffected
+	_, _, err = svc.Login(ctx, "user2", "bazfoo1234!")
+	require.Nil(t, err)
+
+	mockClock.AddTime(conf.Auth.LockoutDuration + time.Second)
+	_, _, err = svc.Login(ctx, "user1", password)
+	require.Nil(t, err)
+}
+
+func TestRequestSourceIP(t *testing.T) {
+	ctx := context.WithValue(context.Background(), kithttp.ContextKeyRequestRemoteAddr, "10.0.0.1:52000")
+	assert.Equal(t, "10.0.0.1", requestSourceIP(ctx))
+
+	// The header set by clients is not used
+	ctx = context.WithValue(ctx, kithttp.ContextKeyRequestXForwardedFor, "192.168.1.5, 10.0.0.2")
+	assert.Equal(t, "10.0.0.1", requestSourceIP(ctx))
+
+	ctx = sourceip.NewContext(ctx, "10.0.0.2")
+	assert.Equal(t, "10.0.0.2", requestSourceIP(ctx))
+
+	assert.Equal(t, "", requestSourceIP(context.Background()))
+}
+
 func TestGenerateJWT(t *testing.T) {
 	jwtKey := ""
 	tokenString, err := generateJWT("4", jwtKey)
@@ -87,3 +172,213 @@ func (authViewerService) GetSessionByKey(ctx context.Context, key string) (*koli
 func (authViewerService) User(ctx context.Context, uid uint) (*kolide.User, error) {
 	return &kolide.User{}, nil
 }
+
+func TestDeleteSessionsForUserRevokesTokens(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+	createTestAppConfig(t, ds)
+
+	users := createTestUsers(t, ds)
+	user, admin := users["user1"], users["admin1"]
+
+	jwtKey := "secret"
+	newToken := func(userID uint, key string) token.Token {
+		_, err := ds.NewSession(&kolide.Session{UserID: userID, Key: key, AccessedAt: time.Now()})
+		require.Nil(t, err)
+		tokenString, err := generateJWT(key, jwtKey)
+		require.Nil(t, err)
+		return token.Token(tokenString)
+	}
+	laptop := newToken(user.ID, "laptop")
+	phone := newToken(user.ID, "phone")
+	other := newToken(admin.ID, "admin")
+
+	for _, tok := range []token.Token{laptop, phone, other} {
+		_, err := authViewer(context.Background(), jwtKey, tok, svc)
+		require.Nil(t, err)
+	}
+
+	vc, err := authViewer(context.Background(), jwtKey, laptop, svc)
+	require.Nil(t, err)
+	ctx := viewer.NewContext(context.Background(), *vc)
+	assert.False(t, service{ds: ds}.sessionRevoked(ctx))
+
+	require.Nil(t, svc.DeleteSessionsForUser(ctx, user.ID))
+
+	// Every token for the user is rejected, including the one used to
+	// authenticate a request that is still in flight.
+	for _, tok := range []token.Token{laptop, phone} {
+		_, err := authViewer(context.Background(), jwtKey, tok, svc)
+		require.NotNil(t, err)
+		assert.IsType(t, authError{}, err)
+	}
+	assert.True(t, service{ds: ds}.sessionRevoked(ctx))
+
+	// Sessions for other users are unaffected
+	_, err = authViewer(context.Background(), jwtKey, other, svc)
+	assert.Nil(t, err)
+}
+
+func TestGenerateSAMLMetadata(t *testing.T) {
+	ds := new(mock.Store)
+	svc, err := newTestService(ds, nil)
+	require.Nil(t, err)
+
+	appConfig := &kolide.AppConfig{
+		KolideServerURL: "https://fleet.example.com",
+		EnableSSO:       true,
+		EntityID:        "kolide",
+	}
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		return appConfig, nil
+	}
+
+	metadata, err := svc.GenerateSAMLMetadata(context.Background())
+	require.Nil(t, err)
+	assert.Contains(t, string(metadata), `entityID="kolide"`)
+	assert.Contains(t, string(metadata), `Location="https://fleet.example.com/api/v1/kolide/sso/callback"`)
+
+	// Entity ID defaults to the server host name
+	appConfig.EntityID = ""
+	metadata, err = svc.GenerateSAMLMetadata(context.Background())
+	require.Nil(t, err)
+	assert.Contains(t, string(metadata), `entityID="fleet.example.com"`)
+
+	// SSO must be configured
+	appConfig.EnableSSO = false
+	_, err = svc.GenerateSAMLMetadata(context.Background())
+	assert.NotNil(t, err)
+}
+
+func TestSessionExpiry(t *testing.T) {
+	ds := new(mock.Store)
+	mockClock := clock.NewMockClock()
+	svc, err := newTestServiceWithClock(ds, nil, mockClock)
+	require.Nil(t, err)
+
+	ds.AppConfigFunc = mock.ReturnFakeAppConfig(&kolide.AppConfig{
+		SessionDuration:    8 * 60,
+		SessionIdleTimeout: 30,
+	})
+	var session *kolide.Session
+	ds.SessionByKeyFunc = func(key string) (*kolide.Session, error) {
+		return session, nil
+	}
+	ds.MarkSessionAccessedFunc = func(s *kolide.Session) error {
+		s.AccessedAt = mockClock.Now()
+		return nil
+	}
+	var destroyed bool
+	ds.DestroySessionFunc = func(s *kolide.Session) error {
+		destroyed = true
+		return nil
+	}
+
+	start := mockClock.Now()
+	session = &kolide.Session{
+		CreateTimestamp: kolide.CreateTimestamp{CreatedAt: start},
+		AccessedAt:      start,
+	}
+
+	// Use within the idle timeout slides the expiry
+	for i := 0; i < 16; i++ {
+		mockClock.AddTime(29 * time.Minute)
+		_, err = svc.GetSessionByKey(context.Background(), "key")
+		require.Nil(t, err)
+	}
+	assert.False(t, destroyed)
+
+	// The absolute duration is enforced however recently the session was used
+	mockClock.AddTime(29 * time.Minute)
+	_, err = svc.GetSessionByKey(context.Background(), "key")
+	require.NotNil(t, err)
+	assert.Equal(t, "session expired", err.(authError).AuthError())
+	assert.True(t, destroyed)
+
+	destroyed = false
+	session = &kolide.Session{
+		CreateTimestamp: kolide.CreateTimestamp{CreatedAt: mockClock.Now()},
+		AccessedAt:      mockClock.Now(),
+	}
+	mockClock.AddTime(30 * time.Minute)
+	_, err = svc.GetSessionByKey(context.Background(), "key")
+	require.NotNil(t, err)
+	assert.Equal(t, "session expired", err.(authError).AuthError())
+	assert.True(t, destroyed)
+}
+
+func TestSessionLifetimeCached(t *testing.T) {
+	ds := new(mock.Store)
+	mockClock := clock.NewMockClock()
+	svc, err := newTestServiceWithClock(ds, nil, mockClock)
+	require.Nil(t, err)
+
+	reads := 0
+	ds.AppConfigFunc = func() (*kolide.AppConfig, error) {
+		reads++
+		return &kolide.AppConfig{SessionIdleTimeout: 30}, nil
+	}
+	ds.SessionByKeyFunc = func(key string) (*kolide.Session, error) {
+		return &kolide.Session{
+			CreateTimestamp: kolide.CreateTimestamp{CreatedAt: mockClock.Now()},
+			AccessedAt:      mockClock.Now(),
+		}, nil
+	}
+	ds.MarkSessionAccessedFunc = func(s *kolide.Session) error {
+		return nil
+	}
+
+	// The app config is read once per sessionLifetimeTTL, not per request
+	for i := 0; i < 3; i++ {
+		_, err = svc.GetSessionByKey(context.Background(), "key")
+		require.Nil(t, err)
+	}
+	assert.Equal(t, 1, reads)
+
+	mockClock.AddTime(sessionLifetimeTTL)
+	_, err = svc.GetSessionByKey(context.Background(), "key")
+	require.Nil(t, err)
+	assert.Equal(t, 2, reads)
+}
+
+func TestReapSessions(t *testing.T) {
+	ds := new(mock.Store)
+	mockClock := clock.NewMockClock()
+	svc, err := newTestServiceWithClock(ds, nil, mockClock)
+	require.Nil(t, err)
+
+	ds.AppConfigFunc = mock.ReturnFakeAppConfig(&kolide.AppConfig{
+		SessionDuration:    8 * 60,
+		SessionIdleTimeout: 30,
+	})
+	type bounds struct{ createdBefore, accessedBefore time.Time }
+	reaps := make(chan bounds)
+	ds.DestroyExpiredSessionsFunc = func(createdBefore, accessedBefore time.Time) (uint, error) {
+		reaps <- bounds{createdBefore, accessedBefore}
+		return 1, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		svc.ReapSessions(ctx)
+		close(done)
+	}()
+
+	// Sessions are reaped right away, and then on every tick
+	expected := func() bounds {
+		return bounds{mockClock.Now().Add(-8 * time.Hour), mockClock.Now().Add(-30 * time.Minute)}
+	}
+	assert.Equal(t, expected(), <-reaps)
+	mockClock.AddTime(sessionReapInterval)
+	assert.Equal(t, expected(), <-reaps)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reaper did not stop after the context was cancelled")
+	}
+}
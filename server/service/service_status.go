@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"sort"
 
+	"github.com/kolide/fleet/server/health"
+	"github.com/kolide/fleet/server/kolide"
 	"github.com/pkg/errors"
 )
 
@@ -22,3 +25,68 @@ func (svc service) StatusLiveQuery(ctx context.Context) error {
 
 	return svc.StatusResultStore(ctx)
 }
+
+func (svc service) Healthz(ctx context.Context) (kolide.HealthStatus, error) {
+	status := kolide.HealthStatus{Healthy: true, Dependencies: []kolide.DependencyHealth{}}
+	check := func(name string, critical bool, hc health.Checker) {
+		dep := kolide.DependencyHealth{Name: name, Critical: critical, Healthy: true}
+		if err := hc.HealthCheck(); err != nil {
+			dep.Healthy = false
+			dep.Error = err.Error()
+			if critical {
+				status.Healthy = false
+			}
+		}
+		status.Dependencies = append(status.Dependencies, dep)
+	}
+
+	// Datastores that cannot fail, such as the inmem datastore, do not
+	// implement health.Checker
+	if hc, ok := svc.ds.(health.Checker); ok {
+		check("datastore", true, hc)
+	}
+	check("query_result_store", true, svc.resultStore)
+
+	if svc.config.Osquery.HealthCheckLogPlugins && svc.osqueryLogWriter != nil {
+		for _, dep := range svc.logPluginHealthCheckers() {
+			check(dep.name, false, dep.checker)
+		}
+	}
+
+	return status, nil
+}
+
+type namedHealthChecker struct {
+	name    string
+	checker health.Checker
+}
+
+// logPluginHealthCheckers returns the osquery log writers that can be health
+// checked. Writers shared by several log types are checked once.
+func (svc service) logPluginHealthCheckers() []namedHealthChecker {
+	names := []string{"status_log_plugin", "result_log_plugin"}
+	writers := map[string]kolide.JSONLogger{
+		"status_log_plugin": svc.osqueryLogWriter.Status,
+		"result_log_plugin": svc.osqueryLogWriter.Result,
+	}
+	plugins := make([]string, 0, len(svc.osqueryLogWriter.PackResults))
+	for plugin, writer := range svc.osqueryLogWriter.PackResults {
+		name := "pack_result_log_plugin:" + plugin
+		plugins = append(plugins, name)
+		writers[name] = writer
+	}
+	sort.Strings(plugins)
+	names = append(names, plugins...)
+
+	var checkers []namedHealthChecker
+	seen := map[health.Checker]bool{}
+	for _, name := range names {
+		hc, ok := writers[name].(health.Checker)
+		if !ok || seen[hc] {
+			continue
+		}
+		seen[hc] = true
+		checkers = append(checkers, namedHealthChecker{name: name, checker: hc})
+	}
+	return checkers
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/kolide/fleet/server/config"
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/kolide/fleet/server/logging"
+	"github.com/kolide/fleet/server/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// healthCheckedStore is a mock datastore that can be health checked.
+type healthCheckedStore struct {
+	mock.Store
+	err error
+}
+
+func (s *healthCheckedStore) HealthCheck() error { return s.err }
+
+// healthCheckedLogger is a log writer that can be health checked.
+type healthCheckedLogger struct {
+	testJSONLogger
+	err error
+}
+
+func (l *healthCheckedLogger) HealthCheck() error { return l.err }
+
+func TestHealthz(t *testing.T) {
+	ds := &healthCheckedStore{}
+	rs := &mock.QueryResultStore{HealthCheckFunc: func() error { return nil }}
+	logWriter := &healthCheckedLogger{err: errors.New("stream not active")}
+	svc := service{
+		ds:          ds,
+		resultStore: rs,
+		config:      config.TestConfig(),
+		logger:      kitlog.NewNopLogger(),
+		osqueryLogWriter: &logging.OsqueryLogger{
+			Status:      &testJSONLogger{},
+			Result:      logWriter,
+			PackResults: map[string]kolide.JSONLogger{"firehose": logWriter},
+		},
+	}
+
+	status, err := svc.Healthz(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, kolide.HealthStatus{
+		Healthy: true,
+		Dependencies: []kolide.DependencyHealth{
+			{Name: "datastore", Critical: true, Healthy: true},
+			{Name: "query_result_store", Critical: true, Healthy: true},
+		},
+	}, status)
+
+	// Unhealthy log destinations do not affect the overall health, and
+	// shared writers are checked once
+	svc.config.Osquery.HealthCheckLogPlugins = true
+	status, err = svc.Healthz(context.Background())
+	require.Nil(t, err)
+	assert.True(t, status.Healthy)
+	require.Len(t, status.Dependencies, 3)
+	assert.Equal(t, kolide.DependencyHealth{
+		Name:  "result_log_plugin",
+		Error: "stream not active",
+	}, status.Dependencies[2])
+
+	rs.HealthCheckFunc = func() error { return errors.New("redis unavailable") }
+	status, err = svc.Healthz(context.Background())
+	require.Nil(t, err)
+	assert.False(t, status.Healthy)
+	assert.Equal(t, kolide.DependencyHealth{
+		Name:     "query_result_store",
+		Critical: true,
+		Error:    "redis unavailable",
+	}, status.Dependencies[1])
+}
+
+func TestHealthzHandler(t *testing.T) {
+	ds := &healthCheckedStore{}
+	rs := &mock.QueryResultStore{HealthCheckFunc: func() error { return nil }}
+	svc := service{
+		ds:          ds,
+		resultStore: rs,
+		config:      config.TestConfig(),
+		logger:      kitlog.NewNopLogger(),
+	}
+	handler := MakeHealthzHandler(svc, kitlog.NewNopLogger())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	ds.err = errors.New("connection refused")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var status kolide.HealthStatus
+	require.Nil(t, json.NewDecoder(rec.Body).Decode(&status))
+	assert.False(t, status.Healthy)
+	assert.Equal(t, kolide.DependencyHealth{
+		Name:     "datastore",
+		Critical: true,
+		Error:    "connection refused",
+	}, status.Dependencies[0])
+}
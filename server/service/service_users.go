@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"html/template"
+	"strings"
 	"time"
 
 	"github.com/kolide/fleet/server/contexts/viewer"
@@ -38,6 +39,76 @@ func (svc service) NewAdminCreatedUser(ctx context.Context, p kolide.UserPayload
 	return svc.newUser(p)
 }
 
+func (svc service) CreateUsers(ctx context.Context, payloads []kolide.UserPayload) ([]*kolide.User, []error) {
+	users := make([]*kolide.User, len(payloads))
+	errs := make([]error, len(payloads))
+
+	// Check that the emails are unique before creating any users, so that
+	// each collision is reported against its entry
+	seen := make(map[string]bool)
+	for i, p := range payloads {
+		email := strings.ToLower(*p.Email)
+		if seen[email] {
+			errs[i] = newInvalidArgumentError("email", "duplicated in the batch")
+			continue
+		}
+		seen[email] = true
+
+		_, err := svc.ds.UserByEmail(*p.Email)
+		if err == nil {
+			errs[i] = newInvalidArgumentError("email", "a user with this account already exists")
+		} else if !kolide.IsNotFound(err) {
+			errs[i] = err
+		}
+	}
+
+	config, err := svc.AppConfig(ctx)
+	if err != nil {
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = err
+			}
+		}
+		return users, errs
+	}
+	createdBy := "An administrator"
+	if vc, ok := viewer.FromContext(ctx); ok {
+		createdBy = vc.FullName()
+		if createdBy == "" {
+			createdBy = vc.Username()
+		}
+	}
+
+	for i, p := range payloads {
+		if errs[i] != nil {
+			continue
+		}
+		user, err := svc.newUser(p)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		users[i] = user
+
+		createdEmail := kolide.Email{
+			Subject: "Your Fleet Account",
+			To:      []string{user.Email},
+			Config:  config,
+			Mailer: &mail.UserCreatedMailer{
+				User:              user,
+				BaseURL:           template.URL(config.KolideServerURL + svc.config.Server.URLPrefix),
+				AssetURL:          getAssetURL(),
+				OrgName:           config.OrgName,
+				CreatedByUsername: createdBy,
+			},
+		}
+		if err := svc.mailService.SendEmail(createdEmail); err != nil {
+			errs[i] = errors.Wrap(err, "sending account email")
+		}
+	}
+	return users, errs
+}
+
 func (svc service) newUser(p kolide.UserPayload) (*kolide.User, error) {
 	var ssoEnabled bool
 	// if user is SSO generate a fake password
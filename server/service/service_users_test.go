@@ -12,6 +12,7 @@ import (
 	"github.com/kolide/fleet/server/kolide"
 
 	"github.com/WatchBeam/clock"
+	kitlog "github.com/go-kit/kit/log"
 	"github.com/kolide/fleet/server/mock"
 	pkg_errors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -432,6 +433,71 @@ func TestCreateUser(t *testing.T) {
 	}
 }
 
+func TestCreateUsers(t *testing.T) {
+	ds, err := inmem.New(config.TestConfig())
+	require.Nil(t, err)
+	createTestAppConfig(t, ds)
+	users := createTestUsers(t, ds)
+	admin1 := users["admin1"]
+
+	var sentTo []string
+	mailer := &mockMailService{SendEmailFn: func(e kolide.Email) error {
+		sentTo = append(sentTo, e.To...)
+		if e.To[0] == "unreachable@example.com" {
+			return errors.New("mail failed")
+		}
+		return nil
+	}}
+	svc, err := NewService(ds, nil, kitlog.NewNopLogger(), config.TestConfig(), mailer, clock.C, nil)
+	require.Nil(t, err)
+	ctx := viewer.NewContext(context.Background(), viewer.Viewer{User: &admin1})
+
+	payload := func(username, email string) kolide.UserPayload {
+		return kolide.UserPayload{
+			Username: stringPtr(username),
+			Email:    stringPtr(email),
+			Password: stringPtr("foobarbaz1234!"),
+		}
+	}
+	created, errs := svc.CreateUsers(ctx, []kolide.UserPayload{
+		payload("alice", "alice@example.com"),
+		payload("admin9", "admin1@example.com"),
+		payload("alice2", "Alice@example.com"),
+		payload("@bob", "bob@example.com"),
+		payload("carol", "unreachable@example.com"),
+		payload("dave", "dave@example.com"),
+	})
+	require.Len(t, created, 6)
+	require.Len(t, errs, 6)
+
+	require.Nil(t, errs[0])
+	require.NotNil(t, created[0])
+	assert.Equal(t, "alice", created[0].Username)
+
+	// Email collisions are reported per entry
+	assert.EqualError(t, errs[1], "validation failed: email a user with this account already exists")
+	assert.Nil(t, created[1])
+	assert.EqualError(t, errs[2], "validation failed: email duplicated in the batch")
+	assert.Nil(t, created[2])
+
+	assert.EqualError(t, errs[3], "validation failed: username '@' character not allowed in usernames")
+	assert.Nil(t, created[3])
+
+	// The user is created even if the email could not be sent
+	assert.NotNil(t, errs[4])
+	require.NotNil(t, created[4])
+	assert.Equal(t, "carol", created[4].Username)
+
+	require.Nil(t, errs[5])
+	require.NotNil(t, created[5])
+
+	assert.Equal(t, []string{"alice@example.com", "unreachable@example.com", "dave@example.com"}, sentTo)
+	for _, username := range []string{"alice", "carol", "dave"} {
+		_, err := ds.User(username)
+		assert.Nil(t, err)
+	}
+}
+
 func setupInvites(t *testing.T, ds kolide.Datastore, emails []string) map[string]*kolide.Invite {
 	invites := make(map[string]*kolide.Invite)
 	users := createTestUsers(t, ds)
@@ -507,7 +573,9 @@ func TestChangePassword(t *testing.T) {
 			oldPassword: "abcd",
 			wantErr: &invalidArgumentError{
 				{name: "new_password", reason: "cannot be empty"},
-				{name: "new_password", reason: "password does not meet validation requirements"},
+				{name: "new_password", reason: "must be at least 7 characters"},
+				{name: "new_password", reason: "must contain a number"},
+				{name: "new_password", reason: "must contain a symbol"},
 			},
 		},
 	}
@@ -568,7 +636,9 @@ func TestResetPassword(t *testing.T) {
 			token: "abcd",
 			wantErr: &invalidArgumentError{
 				{name: "new_password", reason: "cannot be empty field"},
-				{name: "new_password", reason: "password does not meet validation requirements"},
+				{name: "new_password", reason: "must be at least 7 characters"},
+				{name: "new_password", reason: "must contain a number"},
+				{name: "new_password", reason: "must contain a symbol"},
 			},
 		},
 	}
@@ -608,6 +678,7 @@ func TestRequirePasswordReset(t *testing.T) {
 	svc, err := newTestService(ds, nil)
 	require.Nil(t, err)
 
+	createTestAppConfig(t, ds)
 	createTestUsers(t, ds)
 
 	for _, tt := range testUsers {
@@ -673,7 +744,7 @@ func TestPerformRequiredPasswordReset(t *testing.T) {
 			require.Nil(t, err)
 
 			// should error when not logged in
-			_, err = svc.PerformRequiredPasswordReset(ctx, "new_pass")
+			_, err = svc.PerformRequiredPasswordReset(ctx, "new_pass1!")
 			require.NotNil(t, err)
 
 			session, err := ds.NewSession(&kolide.Session{
@@ -685,7 +756,7 @@ func TestPerformRequiredPasswordReset(t *testing.T) {
 			// should error when reset not required
 			_, err = svc.RequirePasswordReset(ctx, user.ID, false)
 			require.Nil(t, err)
-			_, err = svc.PerformRequiredPasswordReset(ctx, "new_pass")
+			_, err = svc.PerformRequiredPasswordReset(ctx, "new_pass1!")
 			require.NotNil(t, err)
 
 			_, err = svc.RequirePasswordReset(ctx, user.ID, true)
@@ -696,14 +767,14 @@ func TestPerformRequiredPasswordReset(t *testing.T) {
 			require.NotNil(t, err)
 
 			// should succeed with good new password
-			u, err := svc.PerformRequiredPasswordReset(ctx, "new_pass")
+			u, err := svc.PerformRequiredPasswordReset(ctx, "new_pass1!")
 			require.Nil(t, err)
 			assert.False(t, u.AdminForcedPasswordReset)
 
 			ctx = context.Background()
 
 			// Now user should be able to login with new password
-			u, _, err = svc.Login(ctx, tt.Username, "new_pass")
+			u, _, err = svc.Login(ctx, tt.Username, "new_pass1!")
 			require.Nil(t, err)
 			assert.False(t, u.AdminForcedPasswordReset)
 		})
@@ -711,35 +782,61 @@ func TestPerformRequiredPasswordReset(t *testing.T) {
 }
 
 func TestUserPasswordRequirements(t *testing.T) {
+	defaultPolicy := &kolide.AppConfig{
+		PasswordMinLength:     7,
+		PasswordRequireNumber: true,
+		PasswordRequireSymbol: true,
+	}
 	var passwordTests = []struct {
+		config   *kolide.AppConfig
 		password string
-		wantErr  bool
+		failed   []string
 	}{
 		{
+			config:   defaultPolicy,
 			password: "foobar",
-			wantErr:  true,
+			failed: []string{
+				"must be at least 7 characters",
+				"must contain a number",
+				"must contain a symbol",
+			},
 		},
 		{
+			config:   defaultPolicy,
 			password: "foobarbaz",
-			wantErr:  true,
+			failed:   []string{"must contain a number", "must contain a symbol"},
 		},
 		{
+			config:   defaultPolicy,
 			password: "foobarbaz!",
-			wantErr:  true,
+			failed:   []string{"must contain a number"},
+		},
+		{
+			config:   defaultPolicy,
+			password: "foobarbaz!3",
 		},
 		{
+			config:   &kolide.AppConfig{},
+			password: "foobar",
+			failed:   []string{"must be at least 7 characters"},
+		},
+		{
+			config:   &kolide.AppConfig{PasswordMinLength: 12, PasswordRequireMixedCase: true},
 			password: "foobarbaz!3",
+			failed: []string{
+				"must be at least 12 characters",
+				"must contain upper and lower case letters",
+			},
+		},
+		{
+			config:   &kolide.AppConfig{PasswordMinLength: 12, PasswordRequireMixedCase: true},
+			password: "FooBarBazQux",
 		},
 	}
 
 	for _, tt := range passwordTests {
 		t.Run(tt.password, func(t *testing.T) {
-			err := validatePasswordRequirements(tt.password)
-			if tt.wantErr {
-				assert.NotNil(t, err)
-			} else {
-				assert.Nil(t, err)
-			}
+			assert.Equal(t, tt.failed, validatePasswordRequirements(tt.config, tt.password))
 		})
 	}
 }
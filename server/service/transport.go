@@ -1,12 +1,16 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/kolide/fleet/server/kolide"
@@ -32,6 +36,37 @@ func encodeResponse(ctx context.Context, w http.ResponseWriter, response interfa
 		return nil
 	}
 
+	if s, ok := response.(streamer); ok {
+		sw := &streamWriter{w: w, contentType: s.contentType()}
+		if err := s.stream(sw); err != nil {
+			if !sw.started {
+				encodeError(ctx, err, w)
+				return nil
+			}
+			// The status has already been sent, so the error can only
+			// be logged
+			return err
+		}
+		sw.start()
+		return nil
+	}
+
+	if doc, ok := response.(xmlDocument); ok {
+		w.Header().Set("Content-Type", "application/xml; charset=UTF-8")
+		_, err := w.Write(doc.xml())
+		return err
+	}
+
+	if doc, ok := response.(textDocument); ok {
+		w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+		_, err := w.Write(doc.text())
+		return err
+	}
+
+	if c, ok := response.(conditional); ok {
+		return encodeConditionalResponse(w, response, c.ifNoneMatch())
+	}
+
 	if e, ok := response.(statuser); ok {
 		w.WriteHeader(e.status())
 		if e.status() == http.StatusNoContent {
@@ -44,18 +79,96 @@ func encodeResponse(ctx context.Context, w http.ResponseWriter, response interfa
 	return enc.Encode(response)
 }
 
+// encodeConditionalResponse writes the response with an ETag computed from
+// its JSON encoding, or only a 304 Not Modified status if the ETag matches
+// ifNoneMatch.
+func encodeConditionalResponse(w http.ResponseWriter, response interface{}, ifNoneMatch string) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(response); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(body.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	if etagMatches(ifNoneMatch, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	_, err := w.Write(body.Bytes())
+	return err
+}
+
+// etagMatches returns true if the If-None-Match header value matches etag.
+// Weak comparison is used, as described in RFC 7232.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // statuser allows response types to implement a custom
 // http success status - default is 200 OK
 type statuser interface {
 	status() int
 }
 
+// conditional is a response that supports conditional requests. The ETag of
+// the response is a hash of its JSON encoding, so that clients polling for an
+// unchanged result do not download it again.
+type conditional interface {
+	ifNoneMatch() string
+}
+
 // loads a html page
 type htmlPage interface {
 	html() string
 	error() error
 }
 
+// xmlDocument is a response that is written as raw XML rather than JSON
+type xmlDocument interface {
+	xml() []byte
+}
+
+// textDocument is a response that is written as plain text rather than JSON
+type textDocument interface {
+	text() []byte
+}
+
+// streamer is a response that writes its body incrementally, such as an
+// export too large to hold in memory
+type streamer interface {
+	contentType() string
+	stream(w io.Writer) error
+}
+
+// streamWriter delays setting the content type of a streamer until the first
+// write, so that errors returned before anything is written can be encoded
+// as JSON.
+type streamWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	started     bool
+}
+
+func (sw *streamWriter) start() {
+	if !sw.started {
+		sw.w.Header().Set("Content-Type", sw.contentType)
+		sw.started = true
+	}
+}
+
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	sw.start()
+	return sw.w.Write(p)
+}
+
 func idFromRequest(r *http.Request, name string) (uint, error) {
 	vars := mux.Vars(r)
 	id, ok := vars[name]
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/kolide/fleet/server/kolide"
@@ -11,11 +12,24 @@ import (
 func decodeModifyAppConfigRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	var payload kolide.AppConfigPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		// Report fields with the wrong type (such as a string SMTP port)
+		// as validation errors so that clients can identify the field.
+		if e, ok := err.(*json.UnmarshalTypeError); ok && e.Field != "" {
+			return nil, newInvalidArgumentError(e.Field, fmt.Sprintf("must be of type %s", e.Type))
+		}
 		return nil, err
 	}
 	return appConfigRequest{Payload: payload}, nil
 }
 
+func decodeTestSMTPSettingsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req testSMTPSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
 func decodeApplyEnrollSecretSpecRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	var req applyEnrollSecretSpecRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeModifyAppConfigTypeError(t *testing.T) {
+	body := strings.NewReader(`{"smtp_settings": {"port": "587"}}`)
+	req := httptest.NewRequest("PATCH", "/api/v1/kolide/config", body)
+	_, err := decodeModifyAppConfigRequest(context.Background(), req)
+	require.NotNil(t, err)
+	invalid, ok := err.(*invalidArgumentError)
+	require.True(t, ok)
+	require.Len(t, *invalid, 1)
+	assert.Equal(t, "smtp_settings.port", (*invalid)[0].name)
+}
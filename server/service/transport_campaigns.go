@@ -21,3 +21,48 @@ func decodeCreateDistributedQueryCampaignByNamesRequest(ctx context.Context, r *
 	}
 	return req, nil
 }
+
+func decodeCreateSavedQueryCampaignByNamesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req createSavedQueryCampaignByNamesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func decodeDistributedQueryCampaignTargetsCountRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req distributedQueryCampaignTargetsCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func decodeEstimateQueryCostRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req estimateQueryCostRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func decodeCreateSavedQueryCampaignRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	var req createSavedQueryCampaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	req.ID = id
+	return req, nil
+}
+
+func decodeStopCampaignRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	return stopCampaignRequest{ID: id}, nil
+}
@@ -3,7 +3,10 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/pkg/errors"
@@ -37,6 +40,19 @@ func namedError(name string, err string) []map[string]string {
 	}
 }
 
+// batchError encodes the error for one entry of a batch request, which
+// cannot be reported with a status code of its own.
+func batchError(err error) *jsonError {
+	type validationError interface {
+		error
+		Invalid() []map[string]string
+	}
+	if e, ok := err.(validationError); ok {
+		return &jsonError{Message: "Validation Failed", Errors: e.Invalid()}
+	}
+	return &jsonError{Message: err.Error(), Errors: baseError(err.Error())}
+}
+
 // encode error and status header to the client
 func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
 	enc := json.NewEncoder(w)
@@ -117,11 +133,50 @@ func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
 		// for debugging purposes (and perhaps osquery will use this
 		// error message in the future).
 
+		type retryableError interface {
+			RetryAfter() time.Duration
+		}
+		type unavailableError interface {
+			Unavailable() bool
+		}
+		type discardError interface {
+			Discard() bool
+		}
+
 		errMap := map[string]interface{}{"error": e.Error()}
-		if e.NodeInvalid() {
+		var retryAfter time.Duration
+		if re, ok := err.(retryableError); ok {
+			retryAfter = re.RetryAfter()
+		}
+		unavailable := false
+		if ue, ok := err.(unavailableError); ok {
+			unavailable = ue.Unavailable()
+		}
+		discard := false
+		if de, ok := err.(discardError); ok {
+			discard = de.Discard()
+		}
+		switch {
+		case e.NodeInvalid():
 			w.WriteHeader(http.StatusUnauthorized)
 			errMap["node_invalid"] = true
-		} else {
+		case discard:
+			// osquery only resends requests that do not succeed, so
+			// requests that can never succeed are acknowledged
+			w.WriteHeader(http.StatusOK)
+		case unavailable:
+			if retryAfter > 0 {
+				seconds := int(math.Ceil(retryAfter.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				errMap["retry_after"] = seconds
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case retryAfter > 0:
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			w.WriteHeader(http.StatusTooManyRequests)
+			errMap["retry_after"] = seconds
+		default:
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 
@@ -129,6 +184,21 @@ func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
 		return
 	}
 
+	type throttledError interface {
+		error
+		RetryAfter() time.Duration
+	}
+	if e, ok := err.(throttledError); ok && e.RetryAfter() > 0 {
+		te := jsonError{
+			Message: "Too Many Requests",
+			Errors:  baseError(e.Error()),
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter().Seconds()))))
+		w.WriteHeader(http.StatusTooManyRequests)
+		enc.Encode(te)
+		return
+	}
+
 	type notFoundError interface {
 		error
 		IsNotFound() bool
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeOsqueryError(t *testing.T) {
+	var testCases = []struct {
+		name       string
+		err        error
+		status     int
+		retryAfter string
+		body       map[string]interface{}
+	}{
+		{
+			name:   "node invalid",
+			err:    osqueryError{message: "invalid node key", nodeInvalid: true},
+			status: http.StatusUnauthorized,
+			body:   map[string]interface{}{"error": "invalid node key", "node_invalid": true},
+		},
+		{
+			name:   "discard",
+			err:    osqueryError{message: "unknown campaign 1", discard: true},
+			status: http.StatusOK,
+			body:   map[string]interface{}{"error": "unknown campaign 1"},
+		},
+		{
+			name:       "unavailable",
+			err:        unavailableError("recording execution", errors.New("connection refused")),
+			status:     http.StatusServiceUnavailable,
+			retryAfter: "10",
+			body:       map[string]interface{}{"error": "recording execution: connection refused", "retry_after": float64(10)},
+		},
+		{
+			name:       "rate limited",
+			err:        osqueryError{message: "rate limit exceeded", retryAfter: 1500 * time.Millisecond},
+			status:     http.StatusTooManyRequests,
+			retryAfter: "2",
+			body:       map[string]interface{}{"error": "rate limit exceeded", "retry_after": float64(2)},
+		},
+		{
+			name:   "other",
+			err:    osqueryError{message: "internal error"},
+			status: http.StatusInternalServerError,
+			body:   map[string]interface{}{"error": "internal error"},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			encodeError(context.Background(), tt.err, w)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.retryAfter, w.Header().Get("Retry-After"))
+			var body map[string]interface{}
+			require.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.body, body)
+		})
+	}
+}
+
+func TestEncodeThrottledError(t *testing.T) {
+	w := httptest.NewRecorder()
+	encodeError(context.Background(), throttledError{retryAfter: 30 * time.Second}, w)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	var body jsonError
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "Too Many Requests", body.Message)
+}
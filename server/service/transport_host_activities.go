@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func decodeHostActivitiesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	opt, err := listOptionsFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	return hostActivitiesRequest{
+		ID: id,
+		ListOptions: kolide.HostActivityListOptions{
+			ListOptions: opt,
+			Types:       r.URL.Query()["type"],
+		},
+	}, nil
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+func decodeNewHostDetailQueryRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req newHostDetailQueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func decodeDeleteHostDetailQueryRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	return deleteHostDetailQueryRequest{ID: id}, nil
+}
+
+func decodeGetHostExtraDetailsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	return getHostExtraDetailsRequest{ID: id}, nil
+}
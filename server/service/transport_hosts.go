@@ -2,7 +2,14 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
 )
 
 func decodeGetHostRequest(ctx context.Context, r *http.Request) (interface{}, error) {
@@ -13,6 +20,62 @@ func decodeGetHostRequest(ctx context.Context, r *http.Request) (interface{}, er
 	return getHostRequest{ID: id}, nil
 }
 
+func decodeAggregateHostsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	return aggregateHostsRequest{GroupBy: r.URL.Query().Get("group_by")}, nil
+}
+
+func decodeHostRebootHistoryRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	return hostRebootHistoryRequest{ID: id}, nil
+}
+
+func decodeHostExportRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	return hostExportRequest{ID: id}, nil
+}
+
+func decodeHostClientConfigRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	return hostClientConfigRequest{ID: id}, nil
+}
+
+func decodeHostScheduledQueriesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	return hostScheduledQueriesRequest{ID: id}, nil
+}
+
+func decodeHostPacksRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	return hostPacksRequest{ID: id}, nil
+}
+
+func decodeDiffHostsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	hostAID, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	hostBID, err := idFromRequest(r, "other_id")
+	if err != nil {
+		return nil, err
+	}
+	return diffHostsRequest{HostAID: hostAID, HostBID: hostBID}, nil
+}
+
 func decodeDeleteHostRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	id, err := idFromRequest(r, "id")
 	if err != nil {
@@ -21,10 +84,156 @@ func decodeDeleteHostRequest(ctx context.Context, r *http.Request) (interface{},
 	return deleteHostRequest{ID: id}, nil
 }
 
+func decodeDeleteHostsByLabelRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	return deleteHostsByLabelRequest{LabelID: id}, nil
+}
+
+func decodeRefreshHostConfigRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req refreshHostConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func decodeTransferHostsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req transferHostsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func decodeMergeHostsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	var req mergeHostsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	req.ID = id
+	return req, nil
+}
+
+func decodeRefreshHostDetailsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	return refreshHostDetailsRequest{ID: id}, nil
+}
+
+func decodeSetHostMaintenanceRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	var req setHostMaintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	req.ID = id
+	return req, nil
+}
+
+func decodeApproveHostRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	return approveHostRequest{ID: id}, nil
+}
+
+func decodeRotateHostNodeKeyRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	return rotateHostNodeKeyRequest{ID: id}, nil
+}
+
 func decodeListHostsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	opt, err := listOptionsFromRequest(r)
 	if err != nil {
 		return nil, err
 	}
-	return listHostsRequest{ListOptions: opt}, nil
+	hopt := kolide.HostListOptions{ListOptions: opt}
+	if err := hostFiltersFromRequest(r, &hopt); err != nil {
+		return nil, err
+	}
+
+	// The presence of the after parameter, even if empty, opts in to
+	// cursor pagination
+	if after, ok := r.URL.Query()["after"]; ok {
+		if r.URL.Query().Get("page") != "" {
+			return nil, errors.New("page must not be specified with after")
+		}
+		hopt.After = &after[0]
+		if hopt.PerPage == 0 {
+			hopt.PerPage = defaultPerPage
+		}
+	}
+
+	return listHostsRequest{ListOptions: hopt}, nil
+}
+
+func decodeCountHostsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var hopt kolide.HostListOptions
+	if err := hostFiltersFromRequest(r, &hopt); err != nil {
+		return nil, err
+	}
+	return countHostsRequest{ListOptions: hopt}, nil
+}
+
+func decodeStreamHostsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var hopt kolide.HostListOptions
+	if err := hostFiltersFromRequest(r, &hopt); err != nil {
+		return nil, err
+	}
+	return streamHostsRequest{ListOptions: hopt}, nil
+}
+
+// hostFiltersFromRequest parses the host filter query parameters shared by
+// the list, count and stream hosts endpoints.
+func hostFiltersFromRequest(r *http.Request, hopt *kolide.HostListOptions) error {
+	hopt.SeenStatus = r.URL.Query().Get("seen_status")
+	hopt.MatchQuery = r.URL.Query().Get("query")
+	seenMinutes := r.URL.Query().Get("seen_minutes")
+	if seenMinutes != "" {
+		minutes, err := strconv.Atoi(seenMinutes)
+		if err != nil {
+			return errors.New("non-int seen_minutes value")
+		}
+		hopt.SeenWithin = time.Duration(minutes) * time.Minute
+	}
+	if hopt.SeenStatus == "" && seenMinutes != "" {
+		return errors.New("seen_status must be specified with seen_minutes")
+	}
+	for _, labelID := range r.URL.Query()["label_id"] {
+		id, err := strconv.ParseUint(labelID, 10, 0)
+		if err != nil {
+			return errors.New("non-int label_id value")
+		}
+		hopt.LabelIDs = append(hopt.LabelIDs, uint(id))
+	}
+	if pending := r.URL.Query().Get("pending"); pending != "" {
+		p, err := strconv.ParseBool(pending)
+		if err != nil {
+			return errors.New("non-bool pending value")
+		}
+		hopt.Pending = &p
+	}
+	if status := r.URL.Query().Get("disk_encryption"); status != "" {
+		if !kolide.IsDiskEncryptionStatus(status) {
+			return errors.Errorf("disk_encryption must be one of %s", strings.Join(kolide.DiskEncryptionStatuses, ", "))
+		}
+		hopt.DiskEncryption = status
+	}
+	return nil
 }
@@ -0,0 +1,14 @@
+package service
+
+import (
+	"context"
+	"net/http"
+)
+
+func decodeListIngestionFailuresRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	opt, err := listOptionsFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	return listIngestionFailuresRequest{ListOptions: opt}, nil
+}
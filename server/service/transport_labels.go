@@ -74,3 +74,16 @@ func decodeModifyLabelRequest(ctx context.Context, r *http.Request) (interface{}
 	resp.ID = id
 	return resp, nil
 }
+
+func decodeLabelMembersRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	var req labelMembersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	req.ID = id
+	return req, nil
+}
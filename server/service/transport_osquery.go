@@ -4,13 +4,65 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
+	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/pkg/errors"
 )
 
+// limitedBody wraps a request body, failing reads once more than maxSize
+// bytes have been read, so that a host sending an oversized body cannot
+// exhaust the memory of the server while it is decoded. A maxSize of zero
+// disables the limit.
+type limitedBody struct {
+	r         io.Reader
+	maxSize   int64
+	remaining int64
+	// exceeded is set once the body is found to be too large. The JSON
+	// decoder does not always return the error of the reader, so decoders
+	// check this instead.
+	exceeded bool
+}
+
+func newLimitedBody(r io.Reader, maxSize int64) *limitedBody {
+	return &limitedBody{r: r, maxSize: maxSize, remaining: maxSize}
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.maxSize <= 0 {
+		return l.r.Read(p)
+	}
+	// Read one byte past the limit to tell a body of exactly maxSize
+	// bytes from one that is too large
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) <= l.remaining {
+		l.remaining -= int64(n)
+		return n, err
+	}
+	n = int(l.remaining)
+	l.remaining = 0
+	l.exceeded = true
+	return n, errors.New("request body too large")
+}
+
+// tooLargeError returns the osqueryError telling the host that the body was
+// too large.
+func (l *limitedBody) tooLargeError() error {
+	return osqueryError{
+		message: fmt.Sprintf(
+			"request body exceeds the maximum of %d bytes, reduce the amount of data the host sends in each request",
+			l.maxSize,
+		),
+	}
+}
+
 func decodeEnrollAgentRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	var req enrollAgentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -31,6 +83,16 @@ func decodeGetClientConfigRequest(ctx context.Context, r *http.Request) (interfa
 	return req, nil
 }
 
+func decodeGetScheduledQueriesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req getScheduledQueriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	return req, nil
+}
+
 func decodeGetDistributedQueriesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	var req getDistributedQueriesRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -41,7 +103,16 @@ func decodeGetDistributedQueriesRequest(ctx context.Context, r *http.Request) (i
 	return req, nil
 }
 
-func decodeSubmitDistributedQueryResultsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+// makeDecodeSubmitDistributedQueryResultsRequest returns the decoder for
+// distributed query results, rejecting bodies larger than maxBodySize bytes.
+// A maxBodySize of zero disables the limit.
+func makeDecodeSubmitDistributedQueryResultsRequest(maxBodySize int64) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		return decodeSubmitDistributedQueryResultsRequest(r, maxBodySize)
+	}
+}
+
+func decodeSubmitDistributedQueryResultsRequest(r *http.Request, maxBodySize int64) (interface{}, error) {
 	// When a distributed query has no results, the JSON schema is
 	// inconsistent, so we use this shim and massage into a consistent
 	// schema. For example (simplified from actual osqueryd 1.8.2 output):
@@ -59,7 +130,11 @@ func decodeSubmitDistributedQueryResultsRequest(ctx context.Context, r *http.Req
 	}
 
 	var shim distributedQueryResultsShim
-	if err := json.NewDecoder(r.Body).Decode(&shim); err != nil {
+	body := newLimitedBody(r.Body, maxBodySize)
+	if err := json.NewDecoder(body).Decode(&shim); err != nil {
+		if body.exceeded {
+			return nil, body.tooLargeError()
+		}
 		return nil, err
 	}
 	defer r.Body.Close()
@@ -102,7 +177,17 @@ func decodeSubmitDistributedQueryResultsRequest(ctx context.Context, r *http.Req
 	return req, nil
 }
 
-func decodeSubmitLogsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+// makeDecodeSubmitLogsRequest returns the decoder for result and status
+// logs, rejecting bodies larger than maxBodySize bytes. The limit applies to
+// the decompressed body of gzipped requests. A maxBodySize of zero disables
+// the limit.
+func makeDecodeSubmitLogsRequest(maxBodySize int64) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		return decodeSubmitLogsRequest(r, maxBodySize)
+	}
+}
+
+func decodeSubmitLogsRequest(r *http.Request, maxBodySize int64) (interface{}, error) {
 	var err error
 	body := r.Body
 	if r.Header.Get("content-encoding") == "gzip" {
@@ -114,7 +199,11 @@ func decodeSubmitLogsRequest(ctx context.Context, r *http.Request) (interface{},
 	}
 
 	var req submitLogsRequest
-	if err = json.NewDecoder(body).Decode(&req); err != nil {
+	limited := newLimitedBody(body, maxBodySize)
+	if err = json.NewDecoder(limited).Decode(&req); err != nil {
+		if limited.exceeded {
+			return nil, limited.tooLargeError()
+		}
 		return nil, errors.Wrap(err, "decoding JSON")
 	}
 	defer r.Body.Close()
@@ -14,3 +14,11 @@ func decodeApplyOsqueryOptionsSpecRequest(ctx context.Context, r *http.Request)
 	return req, nil
 
 }
+
+func decodeGenerateOsqueryFlagfileRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req generateOsqueryFlagfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
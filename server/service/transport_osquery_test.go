@@ -82,7 +82,7 @@ func TestDecodeGetDistributedQueriesRequest(t *testing.T) {
 func TestDecodeSubmitDistributedQueryResultsRequest(t *testing.T) {
 	router := mux.NewRouter()
 	router.HandleFunc("/", func(writer http.ResponseWriter, request *http.Request) {
-		r, err := decodeSubmitDistributedQueryResultsRequest(context.Background(), request)
+		r, err := decodeSubmitDistributedQueryResultsRequest(request, 0)
 		require.Nil(t, err)
 
 		params := r.(submitDistributedQueryResultsRequest)
@@ -127,7 +127,7 @@ func TestDecodeSubmitDistributedQueryResultsRequest(t *testing.T) {
 func TestDecodeSubmitLogsRequest(t *testing.T) {
 	router := mux.NewRouter()
 	router.HandleFunc("/", func(writer http.ResponseWriter, request *http.Request) {
-		r, err := decodeSubmitLogsRequest(context.Background(), request)
+		r, err := decodeSubmitLogsRequest(request, 0)
 		require.Nil(t, err)
 
 		params := r.(submitLogsRequest)
@@ -179,3 +179,40 @@ func TestDecodeSubmitLogsRequest(t *testing.T) {
 		req,
 	)
 }
+
+func TestDecodeRequestBodySizeLimit(t *testing.T) {
+	bodyJSON := []byte(`{"node_key":"key","log_type":"result","data":[{"name":"pack/foo/bar"}]}`)
+	size := int64(len(bodyJSON))
+
+	// A body of exactly the maximum size is accepted
+	_, err := decodeSubmitLogsRequest(httptest.NewRequest("POST", "/", bytes.NewReader(bodyJSON)), size)
+	require.Nil(t, err)
+
+	_, err = decodeSubmitLogsRequest(httptest.NewRequest("POST", "/", bytes.NewReader(bodyJSON)), size-1)
+	require.NotNil(t, err)
+	require.IsType(t, osqueryError{}, err)
+	assert.Contains(t, err.Error(), "exceeds the maximum")
+	assert.False(t, err.(osqueryError).NodeInvalid())
+
+	// The limit applies to the decompressed body
+	body := new(bytes.Buffer)
+	gzWriter := gzip.NewWriter(body)
+	_, err = gzWriter.Write(bytes.Repeat([]byte(" "), 1000))
+	require.Nil(t, err)
+	_, err = gzWriter.Write(bodyJSON)
+	require.Nil(t, err)
+	require.Nil(t, gzWriter.Close())
+	require.True(t, int64(body.Len()) < size+1000)
+	req := httptest.NewRequest("POST", "/", body)
+	req.Header.Add("Content-Encoding", "gzip")
+	_, err = decodeSubmitLogsRequest(req, size+999)
+	require.NotNil(t, err)
+	assert.IsType(t, osqueryError{}, err)
+
+	resultsJSON := []byte(`{"node_key":"key","queries":{"id1":[{"col1":"val1"}]},"statuses":{"id1":0}}`)
+	_, err = decodeSubmitDistributedQueryResultsRequest(httptest.NewRequest("POST", "/", bytes.NewReader(resultsJSON)), 0)
+	require.Nil(t, err)
+	_, err = decodeSubmitDistributedQueryResultsRequest(httptest.NewRequest("POST", "/", bytes.NewReader(resultsJSON)), 10)
+	require.NotNil(t, err)
+	assert.IsType(t, osqueryError{}, err)
+}
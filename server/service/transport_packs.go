@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+
+	"github.com/kolide/fleet/server/kolide"
 )
 
 func decodeCreatePackRequest(ctx context.Context, r *http.Request) (interface{}, error) {
@@ -28,6 +30,19 @@ func decodeModifyPackRequest(ctx context.Context, r *http.Request) (interface{},
 	return req, nil
 }
 
+func decodeClonePackRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	var req clonePackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	req.ID = id
+	return req, nil
+}
+
 func decodeDeletePackRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	name, err := nameFromRequest(r, "name")
 	if err != nil {
@@ -63,7 +78,49 @@ func decodeListPacksRequest(ctx context.Context, r *http.Request) (interface{},
 	if err != nil {
 		return nil, err
 	}
-	return listPacksRequest{ListOptions: opt}, nil
+	return listPacksRequest{ListOptions: opt, IfNoneMatch: r.Header.Get("If-None-Match")}, nil
+}
+
+func decodeListHostsMissingPackRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	opt, err := listOptionsFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	req := listHostsMissingPackRequest{ID: id, ListOptions: kolide.HostListOptions{ListOptions: opt}}
+	if err := hostFiltersFromRequest(r, &req.ListOptions); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func decodeExportPackRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	var req exportPackRequest
+	req.ID = id
+	return req, nil
+}
+
+func decodeImportPackRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req importPackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func decodeImportPackFromURLRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req importPackFromURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	return req, nil
 }
 
 func decodeApplyPackSpecsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
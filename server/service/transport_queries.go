@@ -4,6 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
 )
 
 func decodeCreateQueryRequest(ctx context.Context, r *http.Request) (interface{}, error) {
@@ -70,7 +74,33 @@ func decodeListQueriesRequest(ctx context.Context, r *http.Request) (interface{}
 	if err != nil {
 		return nil, err
 	}
-	return listQueriesRequest{ListOptions: opt}, nil
+	qopt := kolide.ListQueryOptions{ListOptions: opt}
+
+	if includeDeleted := r.URL.Query().Get("include_deleted"); includeDeleted != "" {
+		qopt.IncludeDeleted, err = strconv.ParseBool(includeDeleted)
+		if err != nil {
+			return nil, errors.New("invalid include_deleted value")
+		}
+	}
+	if authorID := r.URL.Query().Get("author_id"); authorID != "" {
+		id, err := strconv.ParseUint(authorID, 10, 64)
+		if err != nil {
+			return nil, errors.New("invalid author_id value")
+		}
+		qopt.AuthorID = uintPtr(uint(id))
+	}
+	qopt.MatchQuery = r.URL.Query().Get("query")
+	qopt.Tags = r.URL.Query()["tag"]
+
+	return listQueriesRequest{ListOptions: qopt, IfNoneMatch: r.Header.Get("If-None-Match")}, nil
+}
+
+func decodeRestoreQueryRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	return restoreQueryRequest{ID: id}, nil
 }
 
 func decodeApplyQuerySpecsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
@@ -81,3 +111,11 @@ func decodeApplyQuerySpecsRequest(ctx context.Context, r *http.Request) (interfa
 	return req, nil
 
 }
+
+func decodeValidateQuerySQLRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req validateQuerySQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDecodeCreateQueryRequest(t *testing.T) {
@@ -87,3 +88,47 @@ func TestDecodeGetQueryRequest(t *testing.T) {
 		httptest.NewRequest("GET", "/api/v1/kolide/queries/1", nil),
 	)
 }
+
+func TestDecodeListQueriesRequest(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/kolide/queries", func(writer http.ResponseWriter, request *http.Request) {
+		r, err := decodeListQueriesRequest(context.Background(), request)
+		assert.Nil(t, err)
+
+		params := r.(listQueriesRequest)
+		assert.True(t, params.ListOptions.IncludeDeleted)
+		assert.Equal(t, uint(2), params.ListOptions.Page)
+		require.NotNil(t, params.ListOptions.AuthorID)
+		assert.Equal(t, uint(3), *params.ListOptions.AuthorID)
+		assert.Equal(t, "from users", params.ListOptions.MatchQuery)
+		assert.Equal(t, []string{"compliance", "accounts"}, params.ListOptions.Tags)
+		assert.Equal(t, `"abc"`, params.IfNoneMatch)
+	}).Methods("GET")
+
+	req := httptest.NewRequest("GET", "/api/v1/kolide/queries?page=2&include_deleted=true&author_id=3&query=from+users&tag=compliance&tag=accounts", nil)
+	req.Header.Set("If-None-Match", `"abc"`)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestDecodeListQueriesRequestInvalidAuthor(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/kolide/queries?author_id=zwass", nil)
+	_, err := decodeListQueriesRequest(context.Background(), req)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "author_id")
+}
+
+func TestDecodeRestoreQueryRequest(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/kolide/queries/{id}/restore", func(writer http.ResponseWriter, request *http.Request) {
+		r, err := decodeRestoreQueryRequest(context.Background(), request)
+		assert.Nil(t, err)
+
+		params := r.(restoreQueryRequest)
+		assert.Equal(t, uint(1), params.ID)
+	}).Methods("POST")
+
+	router.ServeHTTP(
+		httptest.NewRecorder(),
+		httptest.NewRequest("POST", "/api/v1/kolide/queries/1/restore", nil),
+	)
+}
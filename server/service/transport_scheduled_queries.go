@@ -41,6 +41,21 @@ func decodeModifyScheduledQueryRequest(ctx context.Context, r *http.Request) (in
 	return req, nil
 }
 
+func decodeModifyScheduledQueriesInPackRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	var req modifyScheduledQueriesInPackRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req.patch); err != nil {
+		return nil, err
+	}
+
+	req.ID = id
+	return req, nil
+}
+
 func decodeDeleteScheduledQueryRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	id, err := idFromRequest(r, "id")
 	if err != nil {
@@ -60,3 +75,13 @@ func decodeGetScheduledQueryRequest(ctx context.Context, r *http.Request) (inter
 	req.ID = id
 	return req, nil
 }
+
+func decodeGetScheduledQueryStatsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := idFromRequest(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	var req getScheduledQueryStatsRequest
+	req.ID = id
+	return req, nil
+}
@@ -1,12 +1,17 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestListOptionsFromRequest(t *testing.T) {
@@ -111,3 +116,75 @@ func TestListOptionsFromRequest(t *testing.T) {
 		})
 	}
 }
+
+type testStreamer struct {
+	body string
+	err  error
+}
+
+func (s testStreamer) contentType() string { return "application/x-ndjson" }
+
+func (s testStreamer) stream(w io.Writer) error {
+	if s.body != "" {
+		if _, err := io.WriteString(w, s.body); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func TestEncodeStreamerResponse(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := encodeResponse(context.Background(), rec, testStreamer{body: "{}\n{}\n"})
+	assert.Nil(t, err)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "{}\n{}\n", rec.Body.String())
+
+	// Errors before the first write are encoded as usual
+	rec = httptest.NewRecorder()
+	err = encodeResponse(context.Background(), rec, testStreamer{err: newInvalidArgumentError("seen_status", "invalid")})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "seen_status")
+
+	// Errors after the first write can only be returned
+	rec = httptest.NewRecorder()
+	err = encodeResponse(context.Background(), rec, testStreamer{body: "{}\n", err: errors.New("connection lost")})
+	assert.EqualError(t, err, "connection lost")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+type testConditional struct {
+	Value   string `json:"value"`
+	ifMatch string
+}
+
+func (c testConditional) ifNoneMatch() string { return c.ifMatch }
+
+func TestEncodeConditionalResponse(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := encodeResponse(context.Background(), rec, testConditional{Value: "foo"})
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"value":"foo"}`, rec.Body.String())
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// The unchanged response is not sent again
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		rec = httptest.NewRecorder()
+		err = encodeResponse(context.Background(), rec, testConditional{Value: "foo", ifMatch: ifNoneMatch})
+		require.Nil(t, err)
+		assert.Equal(t, http.StatusNotModified, rec.Code, ifNoneMatch)
+		assert.Equal(t, etag, rec.Header().Get("ETag"))
+		assert.Empty(t, rec.Body.String())
+	}
+
+	// A changed response has a new ETag
+	rec = httptest.NewRecorder()
+	err = encodeResponse(context.Background(), rec, testConditional{Value: "bar", ifMatch: etag})
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+	assert.JSONEq(t, `{"value":"bar"}`, rec.Body.String())
+}
@@ -43,6 +43,14 @@ func decodeCreateUserRequest(ctx context.Context, r *http.Request) (interface{},
 	return req, nil
 }
 
+func decodeCreateUsersRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req createUsersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
 func decodeGetUserRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	id, err := idFromRequest(r, "id")
 	if err != nil {
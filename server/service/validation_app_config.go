@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"net/url"
+	"regexp"
 
 	"github.com/kolide/fleet/server/kolide"
 	"github.com/pkg/errors"
@@ -13,13 +15,36 @@ func (mw validationMiddleware) ModifyAppConfig(ctx context.Context, p kolide.App
 		return nil, errors.Wrap(err, "fetching existing app config in validation")
 	}
 	invalid := &invalidArgumentError{}
+	validateServerSettings(p, invalid)
+	validateSMTPSettings(p, invalid)
 	validateSSOSettings(p, existing, invalid)
+	validateHostSettings(p, invalid)
+	validateWebhookSettings(p, invalid)
+	validatePasswordPolicySettings(p, invalid)
+	validateSessionSettings(p, invalid)
+	validateQuerySettings(p, invalid)
 	if invalid.HasErrors() {
 		return nil, invalid
 	}
 	return mw.Service.ModifyAppConfig(ctx, p)
 }
 
+func (mw validationMiddleware) ApplyEnrollSecretSpec(ctx context.Context, spec *kolide.EnrollSecretSpec) error {
+	invalid := &invalidArgumentError{}
+	for _, s := range spec.Secrets {
+		if s.Name == "" {
+			invalid.Append("name", "enroll secret name must not be empty")
+		}
+		if s.Secret == "" {
+			invalid.Appendf("secret", "enroll secret %q must not be empty", s.Name)
+		}
+	}
+	if invalid.HasErrors() {
+		return invalid
+	}
+	return mw.Service.ApplyEnrollSecretSpec(ctx, spec)
+}
+
 func isSet(val *string) bool {
 	if val != nil {
 		return len(*val) > 0
@@ -27,6 +52,26 @@ func isSet(val *string) bool {
 	return false
 }
 
+func validateServerSettings(p kolide.AppConfigPayload, invalid *invalidArgumentError) {
+	if p.ServerSettings == nil || !isSet(p.ServerSettings.KolideServerURL) {
+		return
+	}
+	if err := validateKolideServerURL(cleanupURL(*p.ServerSettings.KolideServerURL)); err != nil {
+		invalid.Append("kolide_server_url", err.Error())
+	}
+}
+
+func validateSMTPSettings(p kolide.AppConfigPayload, invalid *invalidArgumentError) {
+	if p.SMTPSettings == nil || p.SMTPSettings.SMTPPort == nil {
+		return
+	}
+	port := *p.SMTPSettings.SMTPPort
+	enabled := p.SMTPSettings.SMTPEnabled != nil && *p.SMTPSettings.SMTPEnabled
+	if port > 65535 || (port == 0 && enabled) {
+		invalid.Append("port", "must be between 1 and 65535")
+	}
+}
+
 func validateSSOSettings(p kolide.AppConfigPayload, existing *kolide.AppConfig, invalid *invalidArgumentError) {
 	if p.SSOSettings != nil && p.SSOSettings.EnableSSO != nil {
 		if *p.SSOSettings.EnableSSO {
@@ -59,3 +104,64 @@ func validateSSOSettings(p kolide.AppConfigPayload, existing *kolide.AppConfig,
 		}
 	}
 }
+
+func validateHostSettings(p kolide.AppConfigPayload, invalid *invalidArgumentError) {
+	if p.HostSettings == nil || !isSet(p.HostSettings.RequiredOsqueryVersion) {
+		return
+	}
+	if _, ok := parseOsqueryVersion(*p.HostSettings.RequiredOsqueryVersion); !ok {
+		invalid.Append("required_osquery_version", "must be a version such as 4.4.0")
+	}
+}
+
+func validateWebhookSettings(p kolide.AppConfigPayload, invalid *invalidArgumentError) {
+	if p.WebhookSettings == nil {
+		return
+	}
+	if isSet(p.WebhookSettings.EnrollmentWebhookURL) && !isWebhookURL(*p.WebhookSettings.EnrollmentWebhookURL) {
+		invalid.Append("enrollment_webhook_url", "must be an http or https URL")
+	}
+	if isSet(p.WebhookSettings.CampaignWebhookURL) && !isWebhookURL(*p.WebhookSettings.CampaignWebhookURL) {
+		invalid.Append("campaign_webhook_url", "must be an http or https URL")
+	}
+}
+
+func isWebhookURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validatePasswordPolicySettings(p kolide.AppConfigPayload, invalid *invalidArgumentError) {
+	if p.PasswordPolicySettings == nil || p.PasswordPolicySettings.MinLength == nil {
+		return
+	}
+	if *p.PasswordPolicySettings.MinLength < 1 {
+		invalid.Append("min_length", "must be at least 1")
+	}
+}
+
+func validateSessionSettings(p kolide.AppConfigPayload, invalid *invalidArgumentError) {
+	if p.SessionSettings == nil {
+		return
+	}
+	if d := p.SessionSettings.SessionDuration; d != nil && *d < 0 {
+		invalid.Append("session_duration", "must not be negative")
+	}
+	if t := p.SessionSettings.SessionIdleTimeout; t != nil && *t < 0 {
+		invalid.Append("session_idle_timeout", "must not be negative")
+	}
+}
+
+func validateQuerySettings(p kolide.AppConfigPayload, invalid *invalidArgumentError) {
+	if p.QuerySettings == nil || p.QuerySettings.DenyList == nil {
+		return
+	}
+	for _, pattern := range *p.QuerySettings.DenyList {
+		if !pattern.Regex {
+			continue
+		}
+		if _, err := regexp.Compile(pattern.Pattern); err != nil {
+			invalid.Appendf("deny_list", "invalid regular expression '%s': %s", pattern.Pattern, err)
+		}
+	}
+}
@@ -45,3 +45,92 @@ func TestMissingMetadata(t *testing.T) {
 	assert.Equal(t, "metadata", invalid[0].name)
 	assert.Equal(t, "either metadata or metadata_url must be defined", invalid[0].reason)
 }
+
+func TestValidateWebhookSettings(t *testing.T) {
+	for _, tt := range []struct {
+		url   string
+		valid bool
+	}{
+		{"", true},
+		{"https://cmdb.example.com/hooks/fleet", true},
+		{"http://10.0.0.1:8080/enroll", true},
+		{"ftp://cmdb.example.com", false},
+		{"cmdb.example.com/hooks", false},
+		{"https://", false},
+	} {
+		invalid := invalidArgumentError{}
+		url := tt.url
+		p := kolide.AppConfigPayload{
+			WebhookSettings: &kolide.WebhookSettings{EnrollmentWebhookURL: &url},
+		}
+		validateWebhookSettings(p, &invalid)
+		assert.Equal(t, !tt.valid, invalid.HasErrors(), tt.url)
+	}
+}
+
+func TestValidateHostSettings(t *testing.T) {
+	for _, tt := range []struct {
+		version string
+		valid   bool
+	}{
+		{"", true},
+		{"4.4.0", true},
+		{"4", true},
+		{"3.3.2-12-gd883d7f", true},
+		{"4.4.0.1", false},
+		{"latest", false},
+		{"v4.4.0", false},
+	} {
+		invalid := invalidArgumentError{}
+		version := tt.version
+		p := kolide.AppConfigPayload{
+			HostSettings: &kolide.HostSettings{RequiredOsqueryVersion: &version},
+		}
+		validateHostSettings(p, &invalid)
+		assert.Equal(t, !tt.valid, invalid.HasErrors(), tt.version)
+	}
+}
+
+func TestValidateQuerySettings(t *testing.T) {
+	denyList := kolide.QueryDenyList{
+		{Pattern: "process_open_files"},
+		{Pattern: "(", Regex: false},
+		{Pattern: `from\s+hash`, Regex: true},
+		{Pattern: "from (hash", Regex: true},
+	}
+	invalid := invalidArgumentError{}
+	validateQuerySettings(kolide.AppConfigPayload{QuerySettings: &kolide.QuerySettings{DenyList: &denyList}}, &invalid)
+	require.Len(t, invalid, 1)
+	assert.Equal(t, "deny_list", invalid[0].name)
+	assert.Equal(t, "invalid regular expression 'from (hash': error parsing regexp: missing closing ): `from (hash`", invalid[0].reason)
+}
+
+func TestValidateServerAndSMTPSettings(t *testing.T) {
+	enabled, disabled := true, false
+	for _, tt := range []struct {
+		url     string
+		port    uint
+		enabled *bool
+		invalid []string
+	}{
+		{"https://fleet.example.com", 587, &enabled, nil},
+		{"", 0, &disabled, nil},
+		{"http://fleet.example.com", 587, nil, []string{"kolide_server_url"}},
+		{"https://fleet.example.com", 0, &enabled, []string{"port"}},
+		{"://bad", 70000, nil, []string{"kolide_server_url", "port"}},
+	} {
+		invalid := invalidArgumentError{}
+		url, port := tt.url, tt.port
+		p := kolide.AppConfigPayload{
+			ServerSettings: &kolide.ServerSettings{KolideServerURL: &url},
+			SMTPSettings:   &kolide.SMTPSettingsPayload{SMTPPort: &port, SMTPEnabled: tt.enabled},
+		}
+		validateServerSettings(p, &invalid)
+		validateSMTPSettings(p, &invalid)
+		var names []string
+		for _, e := range invalid {
+			names = append(names, e.name)
+		}
+		assert.Equal(t, tt.invalid, names, tt.url)
+	}
+}
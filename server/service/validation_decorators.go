@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw validationMiddleware) NewDecorator(ctx context.Context, payload kolide.DecoratorPayload) (*kolide.Decorator, error) {
+	invalid := &invalidArgumentError{}
+	if payload.Query == nil || *payload.Query == "" {
+		invalid.Append("query", "required")
+	}
+	if payload.DecoratorType == nil {
+		invalid.Append("type", "required")
+	} else {
+		validateDecoratorType(*payload.DecoratorType, payload.Interval, invalid)
+	}
+	if invalid.HasErrors() {
+		return nil, invalid
+	}
+	return mw.Service.NewDecorator(ctx, payload)
+}
+
+func (mw validationMiddleware) ModifyDecorator(ctx context.Context, payload kolide.DecoratorPayload) (*kolide.Decorator, error) {
+	invalid := &invalidArgumentError{}
+	if payload.Query != nil && *payload.Query == "" {
+		invalid.Append("query", "must not be empty")
+	}
+	if payload.DecoratorType != nil {
+		validateDecoratorType(*payload.DecoratorType, payload.Interval, invalid)
+	} else if payload.Interval != nil && *payload.Interval == 0 {
+		invalid.Append("interval", "must be a positive number of seconds")
+	}
+	if invalid.HasErrors() {
+		return nil, invalid
+	}
+	return mw.Service.ModifyDecorator(ctx, payload)
+}
+
+func validateDecoratorType(decType kolide.DecoratorType, interval *uint, invalid *invalidArgumentError) {
+	switch decType {
+	case kolide.DecoratorLoad, kolide.DecoratorAlways:
+	case kolide.DecoratorInterval:
+		if interval == nil || *interval == 0 {
+			invalid.Append("interval", "must be a positive number of seconds for interval decorators")
+		}
+	default:
+		invalid.Append("type", "must be one of load, always or interval")
+	}
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+
+	"github.com/kolide/fleet/server/kolide"
+)
+
+func (mw validationMiddleware) NewHostDetailQuery(ctx context.Context, payload kolide.HostDetailQueryPayload) (*kolide.HostDetailQuery, error) {
+	invalid := &invalidArgumentError{}
+	if payload.Name == nil || *payload.Name == "" {
+		invalid.Append("name", "required")
+	}
+	if payload.Query == nil || *payload.Query == "" {
+		invalid.Append("query", "required")
+	}
+	if invalid.HasErrors() {
+		return nil, invalid
+	}
+	return mw.Service.NewHostDetailQuery(ctx, payload)
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
+)
+
+func (mw validationMiddleware) ApplyOptionsSpec(ctx context.Context, spec *kolide.OptionsSpec) error {
+	invalid := &invalidArgumentError{}
+	if len(spec.Overrides.Labels) > 0 {
+		known, err := mw.ds.ListOptions()
+		if err != nil {
+			return errors.Wrap(err, "listing known osquery options")
+		}
+		validateLabelOptions(spec.Overrides.Labels, known, invalid)
+	}
+	validateOsqueryIntervals(spec.Overrides.Intervals, invalid)
+	if invalid.HasErrors() {
+		return invalid
+	}
+	return mw.Service.ApplyOptionsSpec(ctx, spec)
+}
+
+// validateLabelOptions checks that the label overrides are objects that only
+// set known osquery options to values of the right type.
+func validateLabelOptions(labels map[string]json.RawMessage, known []kolide.Option, invalid *invalidArgumentError) {
+	byName := make(map[string]kolide.Option, len(known))
+	for _, opt := range known {
+		byName[opt.Name] = opt
+	}
+
+	for label, raw := range labels {
+		field := "overrides.labels." + label
+		var options map[string]interface{}
+		if err := json.Unmarshal(raw, &options); err != nil {
+			invalid.Append(field, "must be an object of osquery options")
+			continue
+		}
+
+		// Sort the names so that errors are reported in a stable order
+		names := make([]string, 0, len(options))
+		for name := range options {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			opt, ok := byName[name]
+			switch {
+			case !ok:
+				invalid.Append(field, "unknown osquery option "+name)
+			case opt.ReadOnly:
+				invalid.Append(field, "readonly option "+name)
+			case !opt.SameType(options[name]):
+				invalid.Append(field, "type mismatch for option "+name)
+			}
+		}
+	}
+}
+
+// validateOsqueryIntervals checks that the interval overrides are for one of
+// kolide.IntervalPlatforms, and only set positive numbers of seconds.
+func validateOsqueryIntervals(overrides map[string]kolide.OsqueryIntervals, invalid *invalidArgumentError) {
+	platforms := make([]string, 0, len(overrides))
+	for platform := range overrides {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+
+	for _, platform := range platforms {
+		field := "overrides.intervals." + platform
+		if !isIntervalPlatform(platform) {
+			invalid.Append(field, "platform must be one of "+strings.Join(kolide.IntervalPlatforms, ", "))
+			continue
+		}
+		intervals := overrides[platform]
+		for _, interval := range []struct {
+			name  string
+			value *int
+		}{
+			{"config_refresh", intervals.ConfigRefresh},
+			{"distributed_interval", intervals.DistributedInterval},
+			{"logger_tls_period", intervals.LoggerTLSPeriod},
+		} {
+			if interval.value != nil && *interval.value <= 0 {
+				invalid.Append(field, interval.name+" must be a positive number of seconds")
+			}
+		}
+	}
+}
+
+func isIntervalPlatform(platform string) bool {
+	for _, p := range kolide.IntervalPlatforms {
+		if p == platform {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kolide/fleet/server/kolide"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateLabelOptions(t *testing.T) {
+	known := []kolide.Option{
+		{Name: "disable_events", Type: kolide.OptionTypeBool},
+		{Name: "distributed_interval", Type: kolide.OptionTypeInt},
+		{Name: "pack_delimiter", Type: kolide.OptionTypeString, ReadOnly: true},
+	}
+
+	invalid := &invalidArgumentError{}
+	validateLabelOptions(map[string]json.RawMessage{
+		"macs": json.RawMessage(`{"disable_events": false, "distributed_interval": 30}`),
+	}, known, invalid)
+	assert.False(t, invalid.HasErrors())
+
+	invalid = &invalidArgumentError{}
+	validateLabelOptions(map[string]json.RawMessage{
+		"macs": json.RawMessage(`{"pack_delimiter": "-", "foobar": 1, "disable_events": "no"}`),
+		"bad":  json.RawMessage(`[1, 2]`),
+	}, known, invalid)
+	assert.ElementsMatch(t, []invalidArgument{
+		{name: "overrides.labels.bad", reason: "must be an object of osquery options"},
+		{name: "overrides.labels.macs", reason: "type mismatch for option disable_events"},
+		{name: "overrides.labels.macs", reason: "unknown osquery option foobar"},
+		{name: "overrides.labels.macs", reason: "readonly option pack_delimiter"},
+	}, []invalidArgument(*invalid))
+}
+
+func TestValidateOsqueryIntervals(t *testing.T) {
+	positive, zero, negative := 60, 0, -10
+
+	invalid := &invalidArgumentError{}
+	validateOsqueryIntervals(map[string]kolide.OsqueryIntervals{
+		"darwin": {ConfigRefresh: &positive, DistributedInterval: &positive},
+		"linux":  {LoggerTLSPeriod: &positive},
+	}, invalid)
+	assert.False(t, invalid.HasErrors())
+
+	invalid = &invalidArgumentError{}
+	validateOsqueryIntervals(map[string]kolide.OsqueryIntervals{
+		"windows": {ConfigRefresh: &zero, DistributedInterval: &positive, LoggerTLSPeriod: &negative},
+		"ubuntu":  {ConfigRefresh: &positive},
+	}, invalid)
+	assert.Equal(t, []invalidArgument{
+		{name: "overrides.intervals.ubuntu", reason: "platform must be one of darwin, windows, linux"},
+		{name: "overrides.intervals.windows", reason: "config_refresh must be a positive number of seconds"},
+		{name: "overrides.intervals.windows", reason: "logger_tls_period must be a positive number of seconds"},
+	}, []invalidArgument(*invalid))
+}
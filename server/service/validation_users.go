@@ -2,16 +2,73 @@ package service
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/kolide/fleet/server/contexts/viewer"
 	"github.com/kolide/fleet/server/kolide"
+	"github.com/pkg/errors"
 )
 
 func (mw validationMiddleware) NewUser(ctx context.Context, p kolide.UserPayload) (*kolide.User, error) {
 	invalid := &invalidArgumentError{}
+	if err := mw.validateNewUserPayload(p, invalid); err != nil {
+		return nil, err
+	}
+
+	if p.InviteToken == nil {
+		invalid.Append("invite_token", "missing required argument")
+	} else {
+		if *p.InviteToken == "" {
+			invalid.Append("invite_token", "cannot be empty")
+		}
+	}
+
+	if invalid.HasErrors() {
+		return nil, invalid
+	}
+	return mw.Service.NewUser(ctx, p)
+}
+
+func (mw validationMiddleware) CreateUsers(ctx context.Context, payloads []kolide.UserPayload) ([]*kolide.User, []error) {
+	users := make([]*kolide.User, len(payloads))
+	errs := make([]error, len(payloads))
+
+	// Only the valid payloads are passed on, and the results are mapped
+	// back to the index of their payload
+	var (
+		valid   []kolide.UserPayload
+		indices []int
+	)
+	for i, p := range payloads {
+		invalid := &invalidArgumentError{}
+		if err := mw.validateNewUserPayload(p, invalid); err != nil {
+			errs[i] = err
+			continue
+		}
+		if invalid.HasErrors() {
+			errs[i] = invalid
+			continue
+		}
+		valid = append(valid, p)
+		indices = append(indices, i)
+	}
+	if len(valid) == 0 {
+		return users, errs
+	}
+
+	created, createErrs := mw.Service.CreateUsers(ctx, valid)
+	for j, i := range indices {
+		users[i] = created[j]
+		errs[i] = createErrs[j]
+	}
+	return users, errs
+}
+
+// validateNewUserPayload validates the fields required to create a user.
+func (mw validationMiddleware) validateNewUserPayload(p kolide.UserPayload, invalid *invalidArgumentError) error {
 	if p.Username == nil {
 		invalid.Append("username", "missing required argument")
 	} else {
@@ -32,8 +89,8 @@ func (mw validationMiddleware) NewUser(ctx context.Context, p kolide.UserPayload
 			if *p.Password == "" {
 				invalid.Append("password", "cannot be empty")
 			}
-			if err := validatePasswordRequirements(*p.Password); err != nil {
-				invalid.Append("password", err.Error())
+			if err := mw.validatePassword("password", *p.Password, invalid); err != nil {
+				return err
 			}
 		}
 	}
@@ -45,19 +102,7 @@ func (mw validationMiddleware) NewUser(ctx context.Context, p kolide.UserPayload
 			invalid.Append("email", "cannot be empty")
 		}
 	}
-
-	if p.InviteToken == nil {
-		invalid.Append("invite_token", "missing required argument")
-	} else {
-		if *p.InviteToken == "" {
-			invalid.Append("invite_token", "cannot be empty")
-		}
-	}
-
-	if invalid.HasErrors() {
-		return nil, invalid
-	}
-	return mw.Service.NewUser(ctx, p)
+	return nil
 }
 
 func (mw validationMiddleware) ModifyUser(ctx context.Context, userID uint, p kolide.UserPayload) (*kolide.User, error) {
@@ -126,8 +171,8 @@ func (mw validationMiddleware) ChangePassword(ctx context.Context, oldPass, newP
 		invalid.Append("new_password", "cannot be empty")
 	}
 
-	if err := validatePasswordRequirements(newPass); err != nil {
-		invalid.Append("new_password", err.Error())
+	if err := mw.validatePassword("new_password", newPass, invalid); err != nil {
+		return err
 	}
 
 	if invalid.HasErrors() {
@@ -144,8 +189,8 @@ func (mw validationMiddleware) ResetPassword(ctx context.Context, token, passwor
 	if password == "" {
 		invalid.Append("new_password", "cannot be empty field")
 	}
-	if err := validatePasswordRequirements(password); err != nil {
-		invalid.Append("new_password", err.Error())
+	if err := mw.validatePassword("new_password", password, invalid); err != nil {
+		return err
 	}
 	if invalid.HasErrors() {
 		return invalid
@@ -153,14 +198,49 @@ func (mw validationMiddleware) ResetPassword(ctx context.Context, token, passwor
 	return mw.Service.ResetPassword(ctx, token, password)
 }
 
-// Requirements for user password:
-// at least 7 character length
-// at least 1 symbol
-// at least 1 number
-func validatePasswordRequirements(password string) error {
+func (mw validationMiddleware) PerformRequiredPasswordReset(ctx context.Context, password string) (*kolide.User, error) {
+	invalid := &invalidArgumentError{}
+	if password == "" {
+		invalid.Append("new_password", "cannot be empty field")
+	}
+	if err := mw.validatePassword("new_password", password, invalid); err != nil {
+		return nil, err
+	}
+	if invalid.HasErrors() {
+		return nil, invalid
+	}
+	return mw.Service.PerformRequiredPasswordReset(ctx, password)
+}
+
+// validatePassword checks password against the password policy in the app
+// config, appending a reason to invalid for each requirement it does not
+// meet.
+func (mw validationMiddleware) validatePassword(name, password string, invalid *invalidArgumentError) error {
+	config, err := mw.ds.AppConfig()
+	if kolide.IsNotFound(err) {
+		// There is no app config before setup, so the defaults apply.
+		config = &kolide.AppConfig{
+			PasswordMinLength:     kolide.DefaultPasswordMinLength,
+			PasswordRequireNumber: true,
+			PasswordRequireSymbol: true,
+		}
+	} else if err != nil {
+		return errors.Wrap(err, "fetching app config for password policy")
+	}
+	for _, reason := range validatePasswordRequirements(config, password) {
+		invalid.Append(name, reason)
+	}
+	return nil
+}
+
+// validatePasswordRequirements returns the requirements of the password
+// policy in config that password does not meet.
+func validatePasswordRequirements(config *kolide.AppConfig, password string) []string {
 	var (
 		number bool
 		symbol bool
+		upper  bool
+		lower  bool
 	)
 
 	for _, s := range password {
@@ -169,14 +249,25 @@ func validatePasswordRequirements(password string) error {
 			number = true
 		case unicode.IsPunct(s) || unicode.IsSymbol(s):
 			symbol = true
+		case unicode.IsUpper(s):
+			upper = true
+		case unicode.IsLower(s):
+			lower = true
 		}
 	}
 
-	if len(password) >= 7 &&
-		number &&
-		symbol {
-		return nil
+	var failed []string
+	if minLength := config.MinPasswordLength(); utf8.RuneCountInString(password) < minLength {
+		failed = append(failed, fmt.Sprintf("must be at least %d characters", minLength))
 	}
-
-	return errors.New("password does not meet validation requirements")
+	if config.PasswordRequireNumber && !number {
+		failed = append(failed, "must contain a number")
+	}
+	if config.PasswordRequireSymbol && !symbol {
+		failed = append(failed, "must contain a symbol")
+	}
+	if config.PasswordRequireMixedCase && !(upper && lower) {
+		failed = append(failed, "must contain upper and lower case letters")
+	}
+	return failed
 }
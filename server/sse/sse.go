@@ -0,0 +1,223 @@
+// Package sse contains helpers for streaming messages to clients with
+// server-sent events, for clients that cannot use websockets, such as those
+// behind proxies that do not support them.
+package sse
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kolide/fleet/server/websocket"
+	"github.com/pkg/errors"
+)
+
+const (
+	// errType is the type string used for error messages.
+	errType string = "error"
+
+	// DoneEvent is the name of the event sent when the stream ends. Clients
+	// should not reconnect after receiving it.
+	DoneEvent string = "done"
+)
+
+// Writer writes messages to a server-sent events stream. Each message is
+// sent as the data of an unnamed event, encoded as a websocket.JSONMessage
+// so that clients handle the messages of both transports alike.
+type Writer struct {
+	w     io.Writer
+	flush func() error
+	// conn is the hijacked connection of HTTP/1.x streams
+	conn   net.Conn
+	closed chan struct{}
+}
+
+// NewWriter starts an event stream in response to r and returns a Writer for
+// its events. HTTP/1.x connections are hijacked so that streams outlive the
+// write timeout of the server. Other connections must support flushing, as
+// events would otherwise only reach the client once the response is
+// complete. The stream must be closed with Close.
+func NewWriter(w http.ResponseWriter, r *http.Request) (*Writer, error) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Ask proxies such as nginx not to buffer the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	closed := make(chan struct{})
+	if hj, ok := w.(http.Hijacker); ok && r.ProtoMajor == 1 {
+		conn, rw, err := hj.Hijack()
+		if err != nil {
+			return nil, errors.Wrap(err, "hijacking connection")
+		}
+		conn.SetDeadline(time.Time{})
+		// The body of the response ends when the connection is closed
+		w.Header().Set("Connection", "close")
+		io.WriteString(rw, "HTTP/1.1 200 OK\r\n")
+		w.Header().Write(rw)
+		io.WriteString(rw, "\r\n")
+		if err := rw.Flush(); err != nil {
+			conn.Close()
+			return nil, errors.Wrap(err, "writing headers")
+		}
+		// Clients send nothing more, so a read returns once the
+		// connection is closed
+		go func() {
+			io.Copy(ioutil.Discard, rw)
+			close(closed)
+		}()
+		return &Writer{w: rw, flush: rw.Flush, conn: conn, closed: closed}, nil
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errors.New("response writer does not support flushing")
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	go func() {
+		<-r.Context().Done()
+		close(closed)
+	}()
+	flush := func() error {
+		flusher.Flush()
+		return nil
+	}
+	return &Writer{w: w, flush: flush, closed: closed}, nil
+}
+
+// Closed returns a channel that is closed once the client disconnects.
+func (w *Writer) Closed() <-chan struct{} {
+	return w.closed
+}
+
+// Close ends the stream. The response is complete once the handler
+// returns, so Close only needs to close hijacked connections.
+func (w *Writer) Close() error {
+	if w.conn == nil {
+		return nil
+	}
+	return w.conn.Close()
+}
+
+// WriteEvent writes an event with the given name and data. The event is
+// unnamed if event is empty.
+func (w *Writer) WriteEvent(event, data string) error {
+	var buf strings.Builder
+	if event != "" {
+		buf.WriteString("event: " + event + "\n")
+	}
+	for _, line := range strings.Split(data, "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteString("\n")
+	if _, err := io.WriteString(w.w, buf.String()); err != nil {
+		return errors.Wrap(err, "writing event")
+	}
+	return errors.Wrap(w.flush(), "flushing event")
+}
+
+func (w *Writer) WriteJSON(msg websocket.JSONMessage) error {
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "marshalling JSON")
+	}
+	return w.WriteEvent("", string(buf))
+}
+
+// WriteJSONMessage writes the provided data as JSON (using the
+// websocket.JSONMessage struct), returning any error condition from the
+// connection.
+func (w *Writer) WriteJSONMessage(typ string, data interface{}) error {
+	return w.WriteJSON(websocket.JSONMessage{Type: typ, Data: data})
+}
+
+// WriteJSONError writes an error (JSONMessage struct with Type="error"),
+// returning any error condition from the connection.
+func (w *Writer) WriteJSONError(data interface{}) error {
+	return w.WriteJSONMessage(errType, data)
+}
+
+// WriteDone writes the event ending the stream.
+func (w *Writer) WriteDone() error {
+	return w.WriteEvent(DoneEvent, "{}")
+}
+
+// Reader reads the events of a server-sent events stream.
+type Reader struct {
+	scanner *bufio.Scanner
+}
+
+// NewReader returns a Reader for the events read from r.
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	// Results may include many rows, so allow large events
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &Reader{scanner: scanner}
+}
+
+// ReadEvent reads the next event, returning its name and data. Comments and
+// fields other than event and data are ignored. io.EOF is returned once the
+// stream ends.
+func (r *Reader) ReadEvent() (event, data string, err error) {
+	var lines []string
+	hasData := false
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if line == "" {
+			if hasData {
+				return event, strings.Join(lines, "\n"), nil
+			}
+			event = ""
+			continue
+		}
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			lines = append(lines, value)
+			hasData = true
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return "", "", errors.Wrap(err, "reading event stream")
+	}
+	return "", "", io.EOF
+}
+
+// ReadJSONMessage reads the next unnamed event as a websocket.JSONMessage.
+// Note that the JSONMessage.Data field is guaranteed to be *json.RawMessage.
+// When the stream ends with a done event, or without one, io.EOF is
+// returned.
+func (r *Reader) ReadJSONMessage() (*websocket.JSONMessage, error) {
+	for {
+		event, data, err := r.ReadEvent()
+		if err != nil {
+			return nil, err
+		}
+		switch event {
+		case "":
+		case DoneEvent:
+			return nil, io.EOF
+		default:
+			continue
+		}
+
+		msg := &websocket.JSONMessage{Data: &json.RawMessage{}}
+		if err := json.Unmarshal([]byte(data), msg); err != nil {
+			return nil, errors.Wrap(err, "parsing msg json")
+		}
+		if msg.Type == "" {
+			return nil, errors.New("missing message type")
+		}
+		return msg, nil
+	}
+}
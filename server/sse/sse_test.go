@@ -0,0 +1,116 @@
+package sse
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterRecorder(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w, err := NewWriter(rec, httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+
+	require.NoError(t, w.WriteJSONMessage("result", map[string]int{"rows": 2}))
+	require.NoError(t, w.WriteJSONError("campaign 1 not running"))
+	require.NoError(t, w.WriteDone())
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	assert.True(t, rec.Flushed)
+	assert.Equal(t,
+		`data: {"type":"result","data":{"rows":2}}`+"\n\n"+
+			`data: {"type":"error","data":"campaign 1 not running"}`+"\n\n"+
+			"event: done\ndata: {}\n\n",
+		rec.Body.String(),
+	)
+}
+
+func TestWriterHijacked(t *testing.T) {
+	disconnected := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("X-Request-ID", "abc")
+		w, err := NewWriter(rw, r)
+		require.NoError(t, err)
+		defer w.Close()
+
+		require.NoError(t, w.WriteJSONMessage("status", "pending"))
+		select {
+		case <-w.Closed():
+			close(disconnected)
+		case <-time.After(5 * time.Second):
+			t.Error("client disconnect not detected")
+		}
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	// Headers set before the stream started are kept
+	assert.Equal(t, "abc", resp.Header.Get("X-Request-ID"))
+
+	msg, err := NewReader(resp.Body).ReadJSONMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "status", msg.Type)
+	assert.Equal(t, `"pending"`, string(*(msg.Data.(*json.RawMessage))))
+
+	resp.Body.Close()
+	select {
+	case <-disconnected:
+	case <-time.After(5 * time.Second):
+		t.Error("handler did not return")
+	}
+}
+
+func TestReadEvent(t *testing.T) {
+	stream := ": comment\n\n" +
+		"event: status\nid: 3\ndata: first\ndata:second\n\n" +
+		"data: {}\n\n" +
+		"event: empty\n\n" +
+		"data: last"
+
+	r := NewReader(strings.NewReader(stream))
+	event, data, err := r.ReadEvent()
+	require.NoError(t, err)
+	assert.Equal(t, "status", event)
+	assert.Equal(t, "first\nsecond", data)
+
+	event, data, err = r.ReadEvent()
+	require.NoError(t, err)
+	assert.Equal(t, "", event)
+	assert.Equal(t, "{}", data)
+
+	// Events without data are not dispatched, nor are incomplete events
+	_, _, err = r.ReadEvent()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReadJSONMessage(t *testing.T) {
+	stream := "event: other\ndata: ignored\n\n" +
+		`data: {"type":"result","data":{"host":"web01"}}` + "\n\n" +
+		"data: {}\n\n" +
+		"event: done\ndata: {}\n\n" +
+		`data: {"type":"result","data":{}}` + "\n\n"
+
+	r := NewReader(strings.NewReader(stream))
+	msg, err := r.ReadJSONMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "result", msg.Type)
+	assert.JSONEq(t, `{"host":"web01"}`, string(*(msg.Data.(*json.RawMessage))))
+
+	_, err = r.ReadJSONMessage()
+	assert.EqualError(t, err, "missing message type")
+
+	// The done event ends the stream
+	_, err = r.ReadJSONMessage()
+	assert.Equal(t, io.EOF, err)
+}
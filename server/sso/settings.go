@@ -44,8 +44,65 @@ type SingleSignOnService struct {
 const (
 	PasswordProtectedTransport = "urn:oasis:names:tc:SAML:2.0:ac:classes:PasswordProtectedTransport"
 	RedirectBinding            = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	PostBinding                = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	ProtocolSAML2              = "urn:oasis:names:tc:SAML:2.0:protocol"
+	EmailAddressNameIDFormat   = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
 )
 
+// spMetadata describes Fleet as a SAML service provider. It is handed to the
+// IDP so that it knows where to send assertions.
+type spMetadata struct {
+	XMLName         xml.Name        `xml:"urn:oasis:names:tc:SAML:2.0:metadata EntityDescriptor"`
+	EntityID        string          `xml:"entityID,attr"`
+	SPSSODescriptor spSSODescriptor `xml:"SPSSODescriptor"`
+}
+
+type spSSODescriptor struct {
+	AuthnRequestsSigned        bool                       `xml:"AuthnRequestsSigned,attr"`
+	WantAssertionsSigned       bool                       `xml:"WantAssertionsSigned,attr"`
+	ProtocolSupportEnumeration string                     `xml:"protocolSupportEnumeration,attr"`
+	NameIDFormats              []NameIDFormat             `xml:"NameIDFormat"`
+	AssertionConsumerServices  []AssertionConsumerService `xml:"AssertionConsumerService"`
+}
+
+// GenerateSPMetadata creates service provider metadata XML for the entity ID
+// and assertion consumer service URL.
+func GenerateSPMetadata(entityID, acsURL string) ([]byte, error) {
+	if entityID == "" {
+		return nil, errors.New("entity ID must be set to generate metadata")
+	}
+	if acsURL == "" {
+		return nil, errors.New("assertion consumer service URL must be set to generate metadata")
+	}
+
+	md := spMetadata{
+		EntityID: entityID,
+		SPSSODescriptor: spSSODescriptor{
+			// Fleet does not sign authorization requests, but requires
+			// the IDP to sign its assertions
+			AuthnRequestsSigned:        false,
+			WantAssertionsSigned:       true,
+			ProtocolSupportEnumeration: ProtocolSAML2,
+			NameIDFormats: []NameIDFormat{
+				{Value: EmailAddressNameIDFormat},
+			},
+			AssertionConsumerServices: []AssertionConsumerService{
+				{
+					Binding:  PostBinding,
+					Location: acsURL,
+					Index:    "0",
+				},
+			},
+		},
+	}
+
+	out, err := xml.MarshalIndent(md, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling SP metadata")
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
 type Settings struct {
 	Metadata *Metadata
 	// AssertionConsumerServiceURL is the call back on the service provider which responds
@@ -1,6 +1,7 @@
 package sso
 
 import (
+	"encoding/xml"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -76,3 +77,25 @@ func TestGetMetadata(t *testing.T) {
 		settings.IDPSSODescriptor.SingleSignOnService[0].Location)
 	assert.Equal(t, "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST", settings.IDPSSODescriptor.SingleSignOnService[0].Binding)
 }
+
+func TestGenerateSPMetadata(t *testing.T) {
+	out, err := GenerateSPMetadata("https://fleet.example.com", "https://fleet.example.com/api/v1/kolide/sso/callback")
+	require.Nil(t, err)
+
+	var md spMetadata
+	require.Nil(t, xml.Unmarshal(out, &md))
+	assert.Equal(t, "urn:oasis:names:tc:SAML:2.0:metadata", md.XMLName.Space)
+	assert.Equal(t, "EntityDescriptor", md.XMLName.Local)
+	assert.Equal(t, "https://fleet.example.com", md.EntityID)
+	assert.Equal(t, ProtocolSAML2, md.SPSSODescriptor.ProtocolSupportEnumeration)
+	assert.True(t, md.SPSSODescriptor.WantAssertionsSigned)
+	require.Len(t, md.SPSSODescriptor.AssertionConsumerServices, 1)
+	acs := md.SPSSODescriptor.AssertionConsumerServices[0]
+	assert.Equal(t, PostBinding, acs.Binding)
+	assert.Equal(t, "https://fleet.example.com/api/v1/kolide/sso/callback", acs.Location)
+
+	_, err = GenerateSPMetadata("", "https://fleet.example.com/api/v1/kolide/sso/callback")
+	assert.NotNil(t, err)
+	_, err = GenerateSPMetadata("https://fleet.example.com", "")
+	assert.NotNil(t, err)
+}
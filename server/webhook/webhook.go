@@ -0,0 +1,106 @@
+// Package webhook delivers signed JSON notifications to HTTP endpoints
+// configured by Fleet administrators.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SignatureHeader is the HTTP header containing the HMAC-SHA256 signature of
+// the request body, computed with the webhook secret.
+const SignatureHeader = "X-Fleet-Signature"
+
+// Sender POSTs JSON payloads to webhook URLs, retrying with exponential
+// backoff when delivery fails or the receiver responds with a server error.
+type Sender struct {
+	Client *http.Client
+	// MaxAttempts is the number of delivery attempts made before giving up.
+	MaxAttempts int
+	// Backoff is the delay before the first retry. It doubles after each
+	// subsequent attempt.
+	Backoff time.Duration
+}
+
+// NewSender creates a Sender with default timeout and retry settings.
+func NewSender() *Sender {
+	return &Sender{
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts: 5,
+		Backoff:     time.Second,
+	}
+}
+
+// Sign returns the value of the signature header for body, in the form
+// "sha256=<hex digest>".
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send marshals payload to JSON and POSTs it to url. When secret is not
+// empty, the body is signed and the signature included in SignatureHeader.
+func (s *Sender) Send(ctx context.Context, url, secret string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "marshal webhook payload")
+	}
+
+	backoff := s.Backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := s.post(ctx, url, secret, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= s.MaxAttempts {
+			return errors.Wrapf(err, "webhook failed after %d attempts", attempt)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post makes a single delivery attempt, reporting whether a failed attempt
+// should be retried.
+func (s *Sender) post(ctx context.Context, url, secret string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, errors.Wrap(err, "create webhook request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return true, errors.Wrap(err, "send webhook request")
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return true, errors.Errorf("webhook returned status %d", resp.StatusCode)
+	case resp.StatusCode >= http.StatusMultipleChoices:
+		return false, errors.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return false, nil
+}
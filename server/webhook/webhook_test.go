@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendSigned(t *testing.T) {
+	var gotBody []byte
+	var gotSignature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = ioutil.ReadAll(r.Body)
+		gotSignature = r.Header.Get(SignatureHeader)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	}))
+	defer server.Close()
+
+	s := &Sender{Client: server.Client(), MaxAttempts: 1}
+	err := s.Send(context.Background(), server.URL, "secret", map[string]string{"foo": "bar"})
+	require.Nil(t, err)
+
+	var payload map[string]string
+	require.Nil(t, json.Unmarshal(gotBody, &payload))
+	assert.Equal(t, "bar", payload["foo"])
+	assert.Equal(t, Sign("secret", gotBody), gotSignature)
+	assert.NotEqual(t, Sign("other", gotBody), gotSignature)
+}
+
+func TestSendRetriesServerErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	s := &Sender{Client: server.Client(), MaxAttempts: 5}
+	err := s.Send(context.Background(), server.URL, "", nil)
+	require.Nil(t, err)
+	assert.Equal(t, 3, attempts)
+
+	// Retries stop after MaxAttempts
+	attempts = -10
+	err = s.Send(context.Background(), server.URL, "", nil)
+	require.NotNil(t, err)
+	assert.Equal(t, -5, attempts)
+}
+
+func TestSendDoesNotRetryClientErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	s := &Sender{Client: server.Client(), MaxAttempts: 5}
+	err := s.Send(context.Background(), server.URL, "", nil)
+	require.NotNil(t, err)
+	assert.Equal(t, 1, attempts)
+}